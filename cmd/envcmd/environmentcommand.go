@@ -60,11 +60,13 @@ func WriteCurrentEnvironment(envName string) error {
 	return nil
 }
 
-// There is simple ordering for the default environment.  Firstly check the
-// JUJU_ENV environment variable.  If that is set, it gets used.  If it isn't
-// set, look in the $JUJU_HOME/current-environment file.  If neither are
-// available, read environments.yaml and use the default environment therein.
-func getDefaultEnvironment() (string, error) {
+// GetDefaultEnvironment returns the name of the environment that commands
+// operate on when none is specified. There is simple ordering for the
+// default environment.  Firstly check the JUJU_ENV environment variable.
+// If that is set, it gets used.  If it isn't set, look in the
+// $JUJU_HOME/current-environment file.  If neither are available, read
+// environments.yaml and use the default environment therein.
+func GetDefaultEnvironment() (string, error) {
 	if defaultEnv := os.Getenv(osenv.JujuEnvEnvKey); defaultEnv != "" {
 		return defaultEnv, nil
 	}
@@ -241,7 +243,7 @@ func (w *environCommandWrapper) ensureEnvName() error {
 	if w.envName != "" {
 		return nil
 	}
-	defaultEnv, err := getDefaultEnvironment()
+	defaultEnv, err := GetDefaultEnvironment()
 	if err != nil {
 		return err
 	}
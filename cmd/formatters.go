@@ -0,0 +1,92 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/juju/cmd"
+)
+
+// Table defines how the value written by a command is laid out
+// in the "tabular" and "csv" output formats.
+type Table struct {
+	// Columns holds the headings of the columns.
+	Columns []string
+
+	// Rows returns the rows of the table for the given value,
+	// each holding a field for every column.
+	Rows func(value interface{}) ([][]string, error)
+}
+
+// Formatters returns the given formatters with the "tabular" and
+// "csv" formats for the table added, for use with cmd.Output.AddFlags.
+func (t Table) Formatters(formatters map[string]cmd.Formatter) map[string]cmd.Formatter {
+	result := map[string]cmd.Formatter{
+		"tabular": t.FormatTabular,
+		"csv":     t.FormatCSV,
+	}
+	for name, formatter := range formatters {
+		result[name] = formatter
+	}
+	return result
+}
+
+// FormatTabular returns the rows of the table for the given value,
+// aligned in columns under their headings.
+func (t Table) FormatTabular(value interface{}) ([]byte, error) {
+	rows, err := t.rows(value)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 1, 2, ' ', 0)
+	for _, row := range rows {
+		for i, field := range row {
+			if i > 0 {
+				fmt.Fprint(tw, "\t")
+			}
+			fmt.Fprint(tw, field)
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+	// The output is terminated by a newline when written.
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// FormatCSV returns the rows of the table for the given
+// value as comma-separated values, headed by the column
+// headings.
+func (t Table) FormatCSV(value interface{}) ([]byte, error) {
+	rows, err := t.rows(value)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	// The output is terminated by a newline when written.
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// rows returns the headings and the rows of the
+// table for the given value, checking their width.
+func (t Table) rows(value interface{}) ([][]string, error) {
+	rows, err := t.Rows(value)
+	if err != nil {
+		return nil, err
+	}
+	for i, row := range rows {
+		if len(row) != len(t.Columns) {
+			return nil, fmt.Errorf("row %d has %d fields, expected %d", i, len(row), len(t.Columns))
+		}
+	}
+	return append([][]string{t.Columns}, rows...), nil
+}
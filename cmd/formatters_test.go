@@ -0,0 +1,79 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package cmd_test
+
+import (
+	"fmt"
+	stdtesting "testing"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/cmd"
+)
+
+func Test(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+type TableSuite struct{}
+
+var _ = gc.Suite(&TableSuite{})
+
+var testTable = cmd.Table{
+	Columns: []string{"NAME", "VALUE"},
+	Rows: func(value interface{}) ([][]string, error) {
+		values, ok := value.(map[string]string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected value %T", value)
+		}
+		var rows [][]string
+		for _, name := range []string{"a", "long-name"} {
+			rows = append(rows, []string{name, values[name]})
+		}
+		return rows, nil
+	},
+}
+
+func (*TableSuite) TestFormatTabular(c *gc.C) {
+	out, err := testTable.FormatTabular(map[string]string{"a": "1", "long-name": "two words"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(out), gc.Equals, ""+
+		"NAME       VALUE\n"+
+		"a          1\n"+
+		"long-name  two words")
+}
+
+func (*TableSuite) TestFormatCSV(c *gc.C) {
+	out, err := testTable.FormatCSV(map[string]string{"a": "1", "long-name": `say "hi", then`})
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(out), gc.Equals, ""+
+		"NAME,VALUE\n"+
+		"a,1\n"+
+		`long-name,"say ""hi"", then"`)
+}
+
+func (*TableSuite) TestRowsError(c *gc.C) {
+	_, err := testTable.FormatTabular(42)
+	c.Assert(err, gc.ErrorMatches, "unexpected value int")
+	_, err = testTable.FormatCSV(42)
+	c.Assert(err, gc.ErrorMatches, "unexpected value int")
+}
+
+func (*TableSuite) TestBadRowWidth(c *gc.C) {
+	table := cmd.Table{
+		Columns: []string{"A", "B"},
+		Rows: func(interface{}) ([][]string, error) {
+			return [][]string{{"1", "2"}, {"3"}}, nil
+		},
+	}
+	_, err := table.FormatCSV(nil)
+	c.Assert(err, gc.ErrorMatches, "row 1 has 1 fields, expected 2")
+}
+
+func (*TableSuite) TestFormatters(c *gc.C) {
+	formatters := testTable.Formatters(nil)
+	c.Assert(formatters, gc.HasLen, 2)
+	c.Assert(formatters["tabular"], gc.NotNil)
+	c.Assert(formatters["csv"], gc.NotNil)
+}
@@ -5,6 +5,7 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/juju/cmd"
@@ -16,6 +17,7 @@ import (
 	"github.com/juju/juju/constraints"
 	"github.com/juju/juju/environs/manual"
 	"github.com/juju/juju/instance"
+	"github.com/juju/juju/juju/osenv"
 	"github.com/juju/juju/state/api/params"
 )
 
@@ -41,7 +43,13 @@ Machines are created in a clean state and ready to have units deployed.
 
 This command also supports manual provisioning of existing machines via SSH. The
 target machine must be able to communicate with the API server, and be able to
-access the environment storage.
+access the environment storage. The series and architecture of the target
+machine are detected over SSH, and provisioning is refused if juju cannot run
+an agent on them.
+
+The SSH user need not be root, but must be able to use sudo. If sudo requires
+a password, it is prompted for, unless it is given in the JUJU_SUDO_PASSWORD
+environment variable.
 
 Examples:
    juju add-machine                      (starts a new machine)
@@ -134,6 +142,8 @@ func (c *AddMachineCommand) Run(ctx *cmd.Context) error {
 			Stdin:  ctx.Stdin,
 			Stdout: ctx.Stdout,
 			Stderr: ctx.Stderr,
+
+			SudoPassword: os.Getenv(osenv.JujuSudoPasswordEnvKey),
 		}
 		machineId, err := manualProvisioner(args)
 		if err == nil {
@@ -15,6 +15,7 @@ import (
 	"github.com/juju/juju/constraints"
 	"github.com/juju/juju/environs/manual"
 	"github.com/juju/juju/instance"
+	"github.com/juju/juju/juju/osenv"
 	jujutesting "github.com/juju/juju/juju/testing"
 	"github.com/juju/juju/state"
 	"github.com/juju/juju/state/api/params"
@@ -53,6 +54,18 @@ func (s *AddMachineSuite) TestSSHPlacement(c *gc.C) {
 	c.Assert(testing.Stderr(context), gc.Equals, "created machine 42\n")
 }
 
+func (s *AddMachineSuite) TestSSHPlacementSudoPassword(c *gc.C) {
+	s.PatchEnvironment(osenv.JujuSudoPasswordEnvKey, "s3cret")
+	var sudoPassword string
+	s.PatchValue(&manualProvisioner, func(args manual.ProvisionMachineArgs) (string, error) {
+		sudoPassword = args.SudoPassword
+		return "42", nil
+	})
+	_, err := runAddMachine(c, "ssh:10.1.2.3")
+	c.Assert(err, gc.IsNil)
+	c.Assert(sudoPassword, gc.Equals, "s3cret")
+}
+
 func (s *AddMachineSuite) TestSSHPlacementError(c *gc.C) {
 	s.PatchValue(&manualProvisioner, func(args manual.ProvisionMachineArgs) (string, error) {
 		return "", fmt.Errorf("failed to initialize warp core")
@@ -6,6 +6,7 @@ package main
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/juju/cmd"
 	"launchpad.net/gnuflag"
@@ -45,6 +46,10 @@ type AddUnitCommand struct {
 	envcmd.EnvCommandBase
 	UnitCommandBase
 	ServiceName string
+
+	// Placement holds the placement directives given with --to,
+	// matched in order with the new units.
+	Placement []string
 }
 
 const addUnitDoc = `
@@ -56,11 +61,21 @@ By default, services are deployed to newly provisioned machines.  Alternatively,
 service units can be added to a specific existing machine using the --to
 argument.
 
+The --to argument may hold a comma-separated list of placement directives,
+which are used in order for the new units, one per unit; any further units
+are deployed to newly provisioned machines. Besides machines and containers,
+a directive may be passed to the provider to place a new machine, such as
+zone=us-east-1a on EC2. The units are added in order, so if one cannot be
+added, the units before it have already been added.
+
 Examples:
  juju add-unit mysql -n 5          (Add 5 mysql units on 5 new machines)
  juju add-unit mysql --to 23       (Add a mysql unit to machine 23)
  juju add-unit mysql --to 24/lxc/3 (Add unit to lxc container 3 on host machine 24)
  juju add-unit mysql --to lxc:25   (Add unit to a new lxc container on host machine 25)
+ juju add-unit mysql --to kvm:26   (Add unit to a new kvm container on host machine 26)
+ juju add-unit mysql -n 3 --to 23,lxc:25,zone=us-east-1c
+                                   (Add 3 units, one on each of the given places)
 `
 
 func (c *AddUnitCommand) Info() *cmd.Info {
@@ -87,7 +102,30 @@ func (c *AddUnitCommand) Init(args []string) error {
 	if err := cmd.CheckEmpty(args[1:]); err != nil {
 		return err
 	}
-	return c.UnitCommandBase.Init(args)
+	if c.NumUnits < 1 {
+		return errors.New("--num-units must be a positive integer")
+	}
+	if c.ToMachineSpec == "" {
+		return nil
+	}
+	c.Placement = strings.Split(c.ToMachineSpec, ",")
+	for _, spec := range c.Placement {
+		if !cmd.IsMachineOrNewContainer(spec) && !isProviderPlacement(spec) {
+			return fmt.Errorf("invalid --to parameter %q", spec)
+		}
+	}
+	if len(c.Placement) > c.NumUnits {
+		return fmt.Errorf("cannot use %d placement directives with %d units", len(c.Placement), c.NumUnits)
+	}
+	return nil
+}
+
+// isProviderPlacement returns whether the given --to parameter is
+// a placement directive to be interpreted by the provider, such as
+// zone=us-east-1a.
+func isProviderPlacement(spec string) bool {
+	parts := strings.SplitN(spec, "=", 2)
+	return len(parts) == 2 && parts[0] != "" && parts[1] != ""
 }
 
 // Run connects to the environment specified on the command line
@@ -99,6 +137,33 @@ func (c *AddUnitCommand) Run(_ *cmd.Context) error {
 	}
 	defer apiclient.Close()
 
-	_, err = apiclient.AddServiceUnits(c.ServiceName, c.NumUnits, c.ToMachineSpec)
-	return err
+	// Units are added one at a time to the places given, so that
+	// each is placed as requested even by servers only able to
+	// place a single unit.
+	var added []string
+	for _, spec := range c.Placement {
+		units, err := apiclient.AddServiceUnits(c.ServiceName, 1, spec)
+		if err != nil {
+			return addUnitsError(added, spec, err)
+		}
+		added = append(added, units...)
+	}
+	if n := c.NumUnits - len(c.Placement); n > 0 {
+		if _, err := apiclient.AddServiceUnits(c.ServiceName, n, ""); err != nil {
+			return addUnitsError(added, "", err)
+		}
+	}
+	return nil
+}
+
+// addUnitsError returns the error for a failure to add units after
+// the given units have been added.
+func addUnitsError(added []string, spec string, err error) error {
+	if len(added) == 0 {
+		return err
+	}
+	if spec == "" {
+		return fmt.Errorf("cannot add remaining units (added %s): %v", strings.Join(added, ", "), err)
+	}
+	return fmt.Errorf("cannot add unit to %q (added %s): %v", spec, strings.Join(added, ", "), err)
 }
@@ -32,11 +32,27 @@ var initAddUnitErrorTests = []struct {
 		args: []string{"some-service-name", "--to", "bigglesplop"},
 		err:  `invalid --to parameter "bigglesplop"`,
 	}, {
-		args: []string{"some-service-name", "-n", "2", "--to", "123"},
-		err:  `cannot use --num-units > 1 with --to`,
+		args: []string{"some-service-name", "--to", "123,456"},
+		err:  `cannot use 2 placement directives with 1 units`,
+	}, {
+		args: []string{"some-service-name", "-n", "2", "--to", "123,bigglesplop"},
+		err:  `invalid --to parameter "bigglesplop"`,
+	}, {
+		args: []string{"some-service-name", "-n", "2", "--to", "123,"},
+		err:  `invalid --to parameter ""`,
+	}, {
+		args: []string{"some-service-name", "--to", "zone="},
+		err:  `invalid --to parameter "zone="`,
 	},
 }
 
+func (s *AddUnitSuite) TestInitPlacement(c *gc.C) {
+	com := &AddUnitCommand{}
+	err := testing.InitCommand(envcmd.Wrap(com), []string{"some-service-name", "-n", "4", "--to", "1,lxc:2,zone=us-east-1a"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(com.Placement, gc.DeepEquals, []string{"1", "lxc:2", "zone=us-east-1a"})
+}
+
 func (s *AddUnitSuite) TestInitErrors(c *gc.C) {
 	for i, t := range initAddUnitErrorTests {
 		c.Logf("test %d", i)
@@ -131,3 +147,47 @@ func (s *AddUnitSuite) TestForceMachineNewContainer(c *gc.C) {
 	s.assertForceMachine(c, svc, 3, 1, machine.Id()+"/lxc/0")
 	s.assertForceMachine(c, svc, 3, 2, machine.Id())
 }
+
+func (s *AddUnitSuite) TestForceMachineNewKVMContainer(c *gc.C) {
+	curl := s.setupService(c)
+	machine, err := s.State.AddMachine("precise", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	err = runAddUnit(c, "some-service-name", "--to", "kvm:"+machine.Id())
+	c.Assert(err, gc.IsNil)
+	svc, _ := s.AssertService(c, "some-service-name", curl, 2, 0)
+	s.assertForceMachine(c, svc, 2, 1, machine.Id()+"/kvm/0")
+}
+
+func (s *AddUnitSuite) TestMultiplePlacement(c *gc.C) {
+	curl := s.setupService(c)
+	machine, err := s.State.AddMachine("precise", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	machine2, err := s.State.AddMachine("precise", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	err = runAddUnit(c, "some-service-name", "-n", "4", "--to", machine2.Id()+",lxc:"+machine.Id()+","+machine.Id())
+	c.Assert(err, gc.IsNil)
+	svc, _ := s.AssertService(c, "some-service-name", curl, 5, 0)
+	s.assertForceMachine(c, svc, 5, 1, machine2.Id())
+	s.assertForceMachine(c, svc, 5, 2, machine.Id()+"/lxc/0")
+	s.assertForceMachine(c, svc, 5, 3, machine.Id())
+	// The remaining unit is deployed to a new machine.
+	units, err := svc.AllUnits()
+	c.Assert(err, gc.IsNil)
+	mid, err := units[4].AssignedMachineId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(mid, gc.Not(gc.Matches), machine.Id()+"|"+machine2.Id()+"|.*/lxc/.*")
+}
+
+func (s *AddUnitSuite) TestMultiplePlacementFailure(c *gc.C) {
+	curl := s.setupService(c)
+	machine, err := s.State.AddMachine("precise", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	// The dummy provider rejects the directive.
+	err = runAddUnit(c, "some-service-name", "-n", "2", "--to", machine.Id()+",zone=nowhere")
+	c.Assert(err, gc.ErrorMatches, `cannot add unit to "zone=nowhere" \(added some-service-name/1\): .*zone=nowhere placement is invalid`)
+	svc, _ := s.AssertService(c, "some-service-name", curl, 3, 0)
+	s.assertForceMachine(c, svc, 3, 1, machine.Id())
+}
@@ -0,0 +1,129 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/juju/juju/juju/osenv"
+)
+
+// aliasesSection is the section of $JUJU_HOME/config
+// that holds the user's command aliases.
+const aliasesSection = "aliases"
+
+// expandAliases returns the given command line arguments with the
+// command name replaced by the words of the alias of that name, if
+// there is one. The arguments following the alias are passed through
+// unchanged. Aliases are expanded only once, so an alias may be named
+// after the command it invokes.
+func expandAliases(args []string) ([]string, error) {
+	if len(args) == 0 {
+		return args, nil
+	}
+	aliases, err := readAliases(osenv.JujuHomePath("config"))
+	if err != nil {
+		return nil, err
+	}
+	words, ok := aliases[args[0]]
+	if !ok {
+		return args, nil
+	}
+	logger.Debugf("expanding alias %q to %q", args[0], words)
+	return append(words, args[1:]...), nil
+}
+
+// readAliases reads the aliases section of the given file, which
+// holds lines such as
+//
+//	[aliases]
+//	st = "status --format short"
+//
+// and returns the words of each alias. Blank lines and lines
+// starting with "#" or ";" are ignored, as are other sections.
+// A missing file holds no aliases.
+func readAliases(path string) (map[string][]string, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	aliases := make(map[string][]string)
+	section := ""
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || line[0] == '#' || line[0] == ';':
+			continue
+		case line[0] == '[' && line[len(line)-1] == ']':
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		case section != aliasesSection:
+			continue
+		}
+		i := strings.Index(line, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("%s:%d: expected alias = command", path, n)
+		}
+		name := strings.TrimSpace(line[:i])
+		words, err := splitWords(strings.TrimSpace(line[i+1:]))
+		if err == nil && (name == "" || len(words) == 0) {
+			err = fmt.Errorf("expected alias = command")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, n, err)
+		}
+		aliases[name] = words
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return aliases, nil
+}
+
+// splitWords splits the value of an alias into words. The whole
+// value may be quoted, and words holding spaces may be quoted
+// within it.
+func splitWords(value string) ([]string, error) {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') &&
+		strings.IndexByte(value[1:], value[0]) == len(value)-2 {
+		value = value[1 : len(value)-1]
+	}
+	var words []string
+	var word []rune
+	inWord := false
+	var quote rune
+	for _, r := range value {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			word = append(word, r)
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, string(word))
+				word, inWord = nil, false
+			}
+		default:
+			word = append(word, r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", value)
+	}
+	if inWord {
+		words = append(words, string(word))
+	}
+	return words, nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"io/ioutil"
+
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/juju/osenv"
+	"github.com/juju/juju/testing"
+)
+
+type AliasesSuite struct {
+	testing.FakeJujuHomeSuite
+}
+
+var _ = gc.Suite(&AliasesSuite{})
+
+const aliasesConfig = `
+# Settings for other tools are ignored.
+[other]
+st = something else
+
+[aliases]
+st = "status --format short"
+; Aliases may be named after the command they run.
+status = status --format yaml
+find = find-status 'hook failed' --since 1h
+`
+
+func (s *AliasesSuite) writeConfig(c *gc.C, content string) {
+	err := ioutil.WriteFile(osenv.JujuHomePath("config"), []byte(content), 0644)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *AliasesSuite) TestExpandAliases(c *gc.C) {
+	s.writeConfig(c, aliasesConfig)
+	for i, t := range []struct {
+		args   []string
+		expect []string
+	}{{
+		args:   nil,
+		expect: nil,
+	}, {
+		args:   []string{"st"},
+		expect: []string{"status", "--format", "short"},
+	}, {
+		args:   []string{"st", "wordpress", "mysql"},
+		expect: []string{"status", "--format", "short", "wordpress", "mysql"},
+	}, {
+		args:   []string{"status", "-e", "local"},
+		expect: []string{"status", "--format", "yaml", "-e", "local"},
+	}, {
+		args:   []string{"find"},
+		expect: []string{"find-status", "hook failed", "--since", "1h"},
+	}, {
+		args:   []string{"deploy", "st"},
+		expect: []string{"deploy", "st"},
+	}} {
+		c.Logf("test %d: %q", i, t.args)
+		args, err := expandAliases(t.args)
+		c.Assert(err, gc.IsNil)
+		c.Assert(args, jc.DeepEquals, t.expect)
+	}
+}
+
+func (s *AliasesSuite) TestExpandAliasesNoConfig(c *gc.C) {
+	args, err := expandAliases([]string{"st"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(args, jc.DeepEquals, []string{"st"})
+}
+
+func (s *AliasesSuite) TestExpandAliasesBadConfig(c *gc.C) {
+	for i, t := range []struct {
+		content string
+		err     string
+	}{{
+		content: "[aliases]\nst status\n",
+		err:     `.*config:2: expected alias = command`,
+	}, {
+		content: "[aliases]\n\nst =\n",
+		err:     `.*config:3: expected alias = command`,
+	}, {
+		content: "[aliases]\nst = status 'wordpress\n",
+		err:     `.*config:2: unterminated quote in "status 'wordpress"`,
+	}} {
+		c.Logf("test %d", i)
+		s.writeConfig(c, t.content)
+		_, err := expandAliases([]string{"st"})
+		c.Assert(err, gc.ErrorMatches, t.err)
+	}
+}
+
+func (s *AliasesSuite) TestRunAlias(c *gc.C) {
+	s.writeConfig(c, "[aliases]\nver = version --format json\n")
+	out := badrun(c, 0, "ver")
+	c.Assert(out, gc.Matches, `"\d+\.\d+.*"\n`)
+}
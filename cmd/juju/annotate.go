@@ -0,0 +1,183 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/juju/cmd"
+	"github.com/juju/names"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/cmd/envcmd"
+)
+
+const annotateDoc = `
+Sets annotations on a machine, service or unit, or on the environment itself.
+Annotations are free-form key/value pairs that juju stores but does not
+interpret, so that GUIs and scripts can share metadata about the entities
+of an environment. An annotation with an empty value is removed.
+
+The entity is a machine id, a service name, a unit name, or "environment".
+
+Examples:
+    juju annotate mysql owner=dba-team
+    juju annotate 0 rack=r42 position=
+    juju annotate environment purpose=staging
+
+See Also:
+    juju help annotations
+`
+
+const annotationsDoc = `
+Prints the annotations set on a machine, service or unit, or on the
+environment itself. The entity is a machine id, a service name, a unit
+name, or "environment".
+
+Examples:
+    juju annotations mysql
+    juju annotations --format json mysql/0
+
+See Also:
+    juju help annotate
+`
+
+// environmentEntity is the name by which the annotation
+// commands refer to the environment.
+const environmentEntity = "environment"
+
+// annotationsAPI defines the API methods used
+// by the annotate and annotations commands.
+type annotationsAPI interface {
+	GetAnnotations(tag string) (map[string]string, error)
+	SetAnnotations(tag string, pairs map[string]string) error
+	EnvironmentUUID() string
+	Close() error
+}
+
+var getAnnotationsAPI = func(c *envcmd.EnvCommandBase) (annotationsAPI, error) {
+	return c.NewAPIClient()
+}
+
+// checkAnnotatedEntity returns an error if the
+// given entity cannot hold annotations.
+func checkAnnotatedEntity(entity string) error {
+	switch {
+	case entity == environmentEntity,
+		names.IsValidMachine(entity),
+		names.IsValidUnit(entity),
+		names.IsValidService(entity):
+		return nil
+	}
+	return fmt.Errorf("invalid entity %q: expected a machine, service, unit or %q", entity, environmentEntity)
+}
+
+// annotatedEntityTag returns the tag of the given
+// entity, which must be valid.
+func annotatedEntityTag(client annotationsAPI, entity string) string {
+	switch {
+	case entity == environmentEntity:
+		return names.NewEnvironTag(client.EnvironmentUUID()).String()
+	case names.IsValidMachine(entity):
+		return names.NewMachineTag(entity).String()
+	case names.IsValidUnit(entity):
+		return names.NewUnitTag(entity).String()
+	}
+	return names.NewServiceTag(entity).String()
+}
+
+// AnnotateCommand sets annotations on an entity.
+type AnnotateCommand struct {
+	envcmd.EnvCommandBase
+	Entity string
+	Pairs  map[string]string
+}
+
+func (c *AnnotateCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "annotate",
+		Args:    "<entity> key=value [key=value ...]",
+		Purpose: "set annotations on an entity",
+		Doc:     annotateDoc,
+	}
+}
+
+func (c *AnnotateCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no entity specified")
+	}
+	c.Entity = args[0]
+	if err := checkAnnotatedEntity(c.Entity); err != nil {
+		return err
+	}
+	if len(args) == 1 {
+		return errors.New("no annotations specified")
+	}
+	pairs, err := parse(args[1:])
+	if err != nil {
+		return err
+	}
+	c.Pairs = pairs
+	return nil
+}
+
+func (c *AnnotateCommand) Run(_ *cmd.Context) error {
+	client, err := getAnnotationsAPI(&c.EnvCommandBase)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.SetAnnotations(annotatedEntityTag(client, c.Entity), c.Pairs)
+}
+
+// AnnotationsCommand prints the annotations of an entity.
+type AnnotationsCommand struct {
+	envcmd.EnvCommandBase
+	out    cmd.Output
+	Entity string
+}
+
+func (c *AnnotationsCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "annotations",
+		Args:    "<entity>",
+		Purpose: "print the annotations of an entity",
+		Doc:     annotationsDoc,
+	}
+}
+
+func (c *AnnotationsCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "yaml", map[string]cmd.Formatter{
+		"yaml": cmd.FormatYaml,
+		"json": cmd.FormatJson,
+	})
+}
+
+func (c *AnnotationsCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no entity specified")
+	}
+	c.Entity = args[0]
+	if err := checkAnnotatedEntity(c.Entity); err != nil {
+		return err
+	}
+	return cmd.CheckEmpty(args[1:])
+}
+
+func (c *AnnotationsCommand) Run(ctx *cmd.Context) error {
+	client, err := getAnnotationsAPI(&c.EnvCommandBase)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	annotations, err := client.GetAnnotations(annotatedEntityTag(client, c.Entity))
+	if err != nil {
+		return err
+	}
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	return c.out.Write(ctx, annotations)
+}
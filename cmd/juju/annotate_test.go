@@ -0,0 +1,111 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/testing"
+)
+
+type AnnotateSuite struct {
+	testing.FakeJujuHomeSuite
+	api *mockAnnotationsAPI
+}
+
+var _ = gc.Suite(&AnnotateSuite{})
+
+func (s *AnnotateSuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	s.api = &mockAnnotationsAPI{
+		annotations: map[string]map[string]string{
+			"service-mysql": {"owner": "dba-team", "tier": "backend"},
+		},
+	}
+	s.PatchValue(&getAnnotationsAPI, func(_ *envcmd.EnvCommandBase) (annotationsAPI, error) {
+		return s.api, nil
+	})
+}
+
+func (s *AnnotateSuite) TestAnnotateInit(c *gc.C) {
+	for i, test := range []struct {
+		args []string
+		err  string
+	}{
+		{nil, "no entity specified"},
+		{[]string{"mysql"}, "no annotations specified"},
+		{[]string{"mysql", "owner"}, `invalid option: "owner"`},
+		{[]string{"foo/bar", "owner=me"}, `invalid entity "foo/bar": expected a machine, service, unit or "environment"`},
+	} {
+		c.Logf("test %d: %v", i, test.args)
+		err := testing.InitCommand(envcmd.Wrap(&AnnotateCommand{}), test.args)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
+
+func (s *AnnotateSuite) TestAnnotate(c *gc.C) {
+	for i, test := range []struct {
+		entity string
+		tag    string
+	}{
+		{"mysql", "service-mysql"},
+		{"mysql/0", "unit-mysql-0"},
+		{"0/lxc/1", "machine-0-lxc-1"},
+		{"environment", "environment-deadbeef"},
+	} {
+		c.Logf("test %d: %s", i, test.entity)
+		_, err := testing.RunCommand(c, envcmd.Wrap(&AnnotateCommand{}), test.entity, "rack=r42", "tier=")
+		c.Assert(err, gc.IsNil)
+		c.Assert(s.api.set[test.tag], jc.DeepEquals, map[string]string{"rack": "r42", "tier": ""})
+	}
+}
+
+func (s *AnnotateSuite) TestAnnotations(c *gc.C) {
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&AnnotationsCommand{}), "mysql")
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, "owner: dba-team\ntier: backend\n")
+
+	ctx, err = testing.RunCommand(c, envcmd.Wrap(&AnnotationsCommand{}), "--format", "json", "mysql/0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, "{}\n")
+}
+
+func (s *AnnotateSuite) TestAnnotationsError(c *gc.C) {
+	_, err := testing.RunCommand(c, envcmd.Wrap(&AnnotationsCommand{}), "nosuch")
+	c.Assert(err, gc.ErrorMatches, `service "nosuch" not found`)
+	err = testing.InitCommand(envcmd.Wrap(&AnnotationsCommand{}), []string{"mysql", "extra"})
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["extra"\]`)
+}
+
+type mockAnnotationsAPI struct {
+	annotations map[string]map[string]string
+	set         map[string]map[string]string
+}
+
+func (*mockAnnotationsAPI) Close() error {
+	return nil
+}
+
+func (*mockAnnotationsAPI) EnvironmentUUID() string {
+	return "deadbeef"
+}
+
+func (m *mockAnnotationsAPI) GetAnnotations(tag string) (map[string]string, error) {
+	if tag == "service-nosuch" {
+		return nil, fmt.Errorf(`service "nosuch" not found`)
+	}
+	return m.annotations[tag], nil
+}
+
+func (m *mockAnnotationsAPI) SetAnnotations(tag string, pairs map[string]string) error {
+	if m.set == nil {
+		m.set = make(map[string]map[string]string)
+	}
+	m.set[tag] = pairs
+	return nil
+}
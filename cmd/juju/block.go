@@ -0,0 +1,142 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/juju/cmd"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/cmd/envcmd"
+)
+
+// blockTypes holds the operations that can be blocked, from the
+// least to the most restrictive.
+var blockTypes = []string{"destroy-environment", "remove-object", "all-changes"}
+
+func checkBlockType(name string) error {
+	for _, t := range blockTypes {
+		if name == t {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown operation %q: expected one of %s", name, strings.Join(blockTypes, ", "))
+}
+
+const blockDoc = `
+Blocks operations on the environment, to protect it from accidents. The
+operations that can be blocked are:
+
+    destroy-environment  destroying the environment
+    remove-object        destroying the environment, or removing machines,
+                         services, units and relations
+    all-changes          any change to the environment
+
+While an operation is blocked, attempts to perform it fail, reporting the
+message given when the block was set. Blocking an operation that is already
+blocked replaces its message. Use "juju unblock" to remove a block.
+
+With no arguments, the current blocks are listed.
+
+Examples:
+    juju block destroy-environment
+    juju block all-changes "production freeze until Monday"
+    juju block
+`
+
+// BlockCommand blocks operations on the environment, or lists
+// the current blocks.
+type BlockCommand struct {
+	envcmd.EnvCommandBase
+	out       cmd.Output
+	Operation string
+	Message   string
+}
+
+func (c *BlockCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "block",
+		Args:    "[<operation> [<message>]]",
+		Purpose: "block operations on the environment",
+		Doc:     blockDoc,
+	}
+}
+
+func (c *BlockCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "smart", cmd.DefaultFormatters)
+}
+
+func (c *BlockCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	c.Operation, c.Message = args[0], strings.Join(args[1:], " ")
+	return checkBlockType(c.Operation)
+}
+
+func (c *BlockCommand) Run(ctx *cmd.Context) error {
+	client, err := c.NewAPIClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if c.Operation != "" {
+		return client.SwitchBlockOn(c.Operation, c.Message)
+	}
+	blocks, err := client.ListBlocks()
+	if err != nil {
+		return err
+	}
+	result := make(map[string]string)
+	for _, block := range blocks {
+		result[block.Type] = block.Message
+	}
+	return c.out.Write(ctx, result)
+}
+
+const unblockDoc = `
+Removes a block set with "juju block", allowing the operation to be
+performed again. See "juju help block" for the operations that can be
+blocked.
+
+Examples:
+    juju unblock all-changes
+`
+
+// UnblockCommand removes a block on operations on the environment.
+type UnblockCommand struct {
+	envcmd.EnvCommandBase
+	Operation string
+}
+
+func (c *UnblockCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "unblock",
+		Args:    "<operation>",
+		Purpose: "unblock operations on the environment",
+		Doc:     unblockDoc,
+	}
+}
+
+func (c *UnblockCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no operation specified")
+	}
+	c.Operation, args = args[0], args[1:]
+	if err := checkBlockType(c.Operation); err != nil {
+		return err
+	}
+	return cmd.CheckEmpty(args)
+}
+
+func (c *UnblockCommand) Run(_ *cmd.Context) error {
+	client, err := c.NewAPIClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.SwitchBlockOff(c.Operation)
+}
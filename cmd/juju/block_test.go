@@ -0,0 +1,92 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/cmd/envcmd"
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/testing"
+)
+
+type BlockSuite struct {
+	jujutesting.RepoSuite
+}
+
+var _ = gc.Suite(&BlockSuite{})
+
+func (s *BlockSuite) TestInit(c *gc.C) {
+	for i, test := range []struct {
+		args []string
+		err  string
+	}{{
+		args: []string{"everything"},
+		err:  `unknown operation "everything": expected one of destroy-environment, remove-object, all-changes`,
+	}} {
+		c.Logf("test %d: %v", i, test.args)
+		err := testing.InitCommand(envcmd.Wrap(&BlockCommand{}), test.args)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+	for i, test := range []struct {
+		args []string
+		err  string
+	}{{
+		args: nil,
+		err:  "no operation specified",
+	}, {
+		args: []string{"everything"},
+		err:  `unknown operation "everything": expected one of destroy-environment, remove-object, all-changes`,
+	}, {
+		args: []string{"all-changes", "extra"},
+		err:  `unrecognized args: \["extra"\]`,
+	}} {
+		c.Logf("test %d: %v", i, test.args)
+		err := testing.InitCommand(envcmd.Wrap(&UnblockCommand{}), test.args)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
+
+func (s *BlockSuite) assertBlocks(c *gc.C, expected map[state.BlockType]string) {
+	blocks, err := s.State.AllBlocks()
+	c.Assert(err, gc.IsNil)
+	actual := make(map[state.BlockType]string)
+	for _, block := range blocks {
+		actual[block.Type()] = block.Message()
+	}
+	c.Assert(actual, jc.DeepEquals, expected)
+}
+
+func (s *BlockSuite) TestBlockAndUnblock(c *gc.C) {
+	_, err := testing.RunCommand(c, envcmd.Wrap(&BlockCommand{}), "all-changes", "production", "freeze")
+	c.Assert(err, gc.IsNil)
+	_, err = testing.RunCommand(c, envcmd.Wrap(&BlockCommand{}), "destroy-environment")
+	c.Assert(err, gc.IsNil)
+	s.assertBlocks(c, map[state.BlockType]string{
+		state.ChangeBlock:  "production freeze",
+		state.DestroyBlock: "",
+	})
+
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&BlockCommand{}), "--format", "yaml")
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, "all-changes: production freeze\ndestroy-environment: \"\"\n")
+
+	_, err = testing.RunCommand(c, envcmd.Wrap(&UnblockCommand{}), "all-changes")
+	c.Assert(err, gc.IsNil)
+	s.assertBlocks(c, map[state.BlockType]string{state.DestroyBlock: ""})
+}
+
+func (s *BlockSuite) TestBlockedOperation(c *gc.C) {
+	err := s.State.SwitchBlockOn(state.RemoveBlock, "ask the DBA first")
+	c.Assert(err, gc.IsNil)
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	_, err = testing.RunCommand(c, envcmd.Wrap(&RemoveMachineCommand{}), m.Id())
+	c.Assert(err, gc.ErrorMatches, "the operation has been blocked: ask the DBA first")
+	err = m.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(m.Life(), gc.Equals, state.Alive)
+}
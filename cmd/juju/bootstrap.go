@@ -6,6 +6,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/juju/charm"
 	"github.com/juju/cmd"
@@ -17,9 +18,12 @@ import (
 	"github.com/juju/juju/environs"
 	"github.com/juju/juju/environs/bootstrap"
 	"github.com/juju/juju/environs/imagemetadata"
+	"github.com/juju/juju/environs/storage"
+	"github.com/juju/juju/environs/sync"
 	"github.com/juju/juju/environs/tools"
 	"github.com/juju/juju/instance"
 	"github.com/juju/juju/provider"
+	"github.com/juju/juju/version"
 )
 
 const bootstrapDoc = `
@@ -50,7 +54,14 @@ following settings in your environments.yaml (all values represent number of sec
 Private clouds may need to specify their own custom image metadata, and possibly upload
 Juju tools to cloud storage if no outgoing Internet access is available. In this case,
 use the --metadata-source paramater to tell bootstrap a local directory from which to
-upload tools and/or image metadata.
+upload tools and/or image metadata. The directory is laid out as generated by
+juju metadata generate-image and generate-tools: image metadata under images/,
+and tools tarballs with their metadata under tools/. Tools matching this client's
+version are copied to the environment's storage along with the image metadata,
+and are used to provision the state server and every machine added later, so an
+environment without any access to the Internet can be bootstrapped with:
+
+    juju bootstrap --metadata-source ~/juju-metadata
 
 See Also:
    juju help switch
@@ -263,9 +274,34 @@ var uploadCustomMetadata = func(metadataDir string, env environs.Environ) error
 	} else {
 		logger.Infof("custom image metadata uploaded")
 	}
+	if err := uploadLocalTools(metadataDir, env); err != nil {
+		// Do not error if tools directory doesn't exist.
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("uploading tools: %v", err)
+		}
+	} else {
+		logger.Infof("custom tools uploaded")
+	}
 	return nil
 }
 
+// uploadLocalTools copies the tools found in the given metadata
+// directory that match the current major and minor version to the
+// environment's storage, so that the machines of the environment
+// can be provisioned without access to the public tools.
+func uploadLocalTools(metadataDir string, env environs.Environ) error {
+	if _, err := os.Stat(filepath.Join(metadataDir, storage.BaseToolsPath)); err != nil {
+		return err
+	}
+	return sync.SyncTools(&sync.SyncContext{
+		Target:       env.Storage(),
+		Source:       metadataDir,
+		AllVersions:  true,
+		MajorVersion: version.Current.Major,
+		MinorVersion: version.Current.Minor,
+	})
+}
+
 var validateConstraints = func(cons constraints.Value, env environs.Environ) error {
 	validator, err := env.ConstraintsValidator()
 	if err != nil {
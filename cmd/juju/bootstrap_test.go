@@ -593,6 +593,25 @@ func (s *BootstrapSuite) TestAutoSyncLocalSource(c *gc.C) {
 	checkTools(c, env, v120All)
 }
 
+func (s *BootstrapSuite) TestUploadLocalTools(c *gc.C) {
+	sourceDir := createToolsSource(c, vAll)
+	s.PatchValue(&version.Current.Number, version.MustParse("1.2.0"))
+	env := resetJujuHome(c)
+
+	_, err := coretesting.RunCommand(c, envcmd.Wrap(&BootstrapCommand{}), "--metadata-source", sourceDir)
+	c.Assert(err, gc.IsNil)
+
+	// The tools matching the current version have been copied
+	// to the environment's storage, so that machines can be
+	// provisioned without access to the local directory.
+	list, err := envtools.ReadList(env.Storage(), 1, 2)
+	c.Assert(err, gc.IsNil)
+	c.Assert(list, gc.HasLen, len(v120All))
+	for _, tools := range list {
+		c.Check(tools.Version.Number, gc.Equals, version.MustParse("1.2.0"))
+	}
+}
+
 func (s *BootstrapSuite) setupAutoUploadTest(c *gc.C, vers, series string) environs.Environ {
 	s.PatchValue(&envtools.BundleTools, toolstesting.GetMockBundleTools(c))
 	sourceDir := createToolsSource(c, vAll)
@@ -0,0 +1,312 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juju/cmd"
+	"github.com/juju/names"
+	"github.com/juju/utils"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/state/api/params"
+)
+
+const cachedImagesDoc = `
+Lists the container base images cached on the machines of the environment,
+including the state servers, with their size in bytes and when they were
+last used. LXC clone templates and KVM images downloaded by uvtool are
+listed; images are named by their kind and name, for example
+"lxc:juju-trusty-template".
+
+With --delete, the named images are deleted from the machines that hold
+them instead. Containers already created from an image are not affected,
+and the image is downloaded again the next time it is needed.
+
+Examples:
+    juju cached-images
+    juju cached-images --machine 0,1
+    juju cached-images --delete lxc:juju-precise-template
+`
+
+// CachedImagesCommand lists and deletes the container
+// images cached on the machines of the environment.
+type CachedImagesCommand struct {
+	envcmd.EnvCommandBase
+	out      cmd.Output
+	machines []string
+	delete   bool
+	images   []string
+}
+
+func (c *CachedImagesCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "cached-images",
+		Args:    "[--delete <image> ...]",
+		Purpose: "list or delete the container images cached on machines",
+		Doc:     cachedImagesDoc,
+	}
+}
+
+func (c *CachedImagesCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "yaml", map[string]cmd.Formatter{
+		"yaml": cmd.FormatYaml,
+		"json": cmd.FormatJson,
+	})
+	f.Var(cmd.NewStringsValue(nil, &c.machines), "machine", "only consider the given machines")
+	f.BoolVar(&c.delete, "delete", false, "delete the given images")
+}
+
+func (c *CachedImagesCommand) Init(args []string) error {
+	for _, id := range c.machines {
+		if !names.IsValidMachine(id) {
+			return fmt.Errorf("invalid machine id %q", id)
+		}
+	}
+	if !c.delete {
+		return cmd.CheckEmpty(args)
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("no images specified")
+	}
+	for _, image := range args {
+		if _, _, err := parseImageName(image); err != nil {
+			return err
+		}
+	}
+	c.images = args
+	return nil
+}
+
+// cachedImagesAPI defines the API methods used
+// by the cached-images command.
+type cachedImagesAPI interface {
+	RunOnAllMachines(commands string, timeout time.Duration) ([]params.RunResult, error)
+	Run(run params.RunParams) ([]params.RunResult, error)
+	Close() error
+}
+
+var getCachedImagesAPI = func(c *CachedImagesCommand) (cachedImagesAPI, error) {
+	return c.NewAPIClient()
+}
+
+// cachedImagesTimeout holds how long the commands
+// run on machines to list or delete images may take.
+const cachedImagesTimeout = 5 * time.Minute
+
+// listImagesScript prints a line for each cached image holding
+// its kind, name, size in bytes and last use as a Unix time.
+// LXC templates record their last use when they are cloned;
+// for KVM images, the access time of the image file is used.
+const listImagesScript = `sudo sh <<'EOF'
+for dir in /var/lib/lxc/juju-*-template; do
+    [ -d "$dir" ] || continue
+    name=$(basename "$dir")
+    used=$(stat -c %Y "/var/lib/juju/containers/$name/last-used" 2>/dev/null || stat -c %Y "$dir")
+    echo lxc "$name" "$(du -sb "$dir" | cut -f1)" "$used"
+done
+for file in /var/lib/uvtool/libvirt/images/*; do
+    [ -f "$file" ] || continue
+    echo kvm "$(basename "$file")" "$(stat -c %s "$file")" "$(stat -c %X "$file")"
+done
+EOF
+`
+
+// cachedImage holds the details of an image cached on a machine.
+type cachedImage struct {
+	Machine  string `yaml:"machine" json:"machine"`
+	Image    string `yaml:"image" json:"image"`
+	Size     int64  `yaml:"size" json:"size"`
+	LastUsed string `yaml:"last-used" json:"last-used"`
+}
+
+func (c *CachedImagesCommand) Run(ctx *cmd.Context) error {
+	client, err := getCachedImagesAPI(c)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	images, err := c.listImages(ctx, client)
+	if err != nil {
+		return err
+	}
+	if !c.delete {
+		return c.out.Write(ctx, images)
+	}
+	return c.deleteImages(ctx, client, images)
+}
+
+// listImages returns the images cached on the selected machines,
+// ordered by machine and image name.
+func (c *CachedImagesCommand) listImages(ctx *cmd.Context, client cachedImagesAPI) ([]cachedImage, error) {
+	var results []params.RunResult
+	var err error
+	if len(c.machines) == 0 {
+		results, err = client.RunOnAllMachines(listImagesScript, cachedImagesTimeout)
+	} else {
+		results, err = client.Run(params.RunParams{
+			Commands: listImagesScript,
+			Timeout:  cachedImagesTimeout,
+			Machines: c.machines,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	images := []cachedImage{}
+	for _, result := range results {
+		if err := runResultError(result); err != nil {
+			fmt.Fprintf(ctx.Stderr, "cannot list images on machine %s: %v\n", result.MachineId, err)
+			continue
+		}
+		found, err := parseCachedImages(result.MachineId, result.Stdout)
+		if err != nil {
+			return nil, fmt.Errorf("cannot list images on machine %s: %v", result.MachineId, err)
+		}
+		images = append(images, found...)
+	}
+	sort.Sort(cachedImagesByMachine(images))
+	return images, nil
+}
+
+// deleteImages deletes the images named on the command
+// line from the machines, among those given, that hold them.
+func (c *CachedImagesCommand) deleteImages(ctx *cmd.Context, client cachedImagesAPI, images []cachedImage) error {
+	byMachine := make(map[string][]string)
+	var machines []string
+	for _, name := range c.images {
+		found := false
+		for _, image := range images {
+			if image.Image != name {
+				continue
+			}
+			found = true
+			if byMachine[image.Machine] == nil {
+				machines = append(machines, image.Machine)
+			}
+			byMachine[image.Machine] = append(byMachine[image.Machine], name)
+		}
+		if !found {
+			return fmt.Errorf("image %q not found", name)
+		}
+	}
+	sort.Sort(naturally(machines))
+	failed := false
+	for _, machine := range machines {
+		var commands []string
+		for _, name := range byMachine[machine] {
+			commands = append(commands, deleteImageCommand(name))
+		}
+		results, err := client.Run(params.RunParams{
+			Commands: strings.Join(commands, "\n"),
+			Timeout:  cachedImagesTimeout,
+			Machines: []string{machine},
+		})
+		if err == nil && len(results) == 1 {
+			err = runResultError(results[0])
+		}
+		if err != nil {
+			fmt.Fprintf(ctx.Stderr, "cannot delete images on machine %s: %v\n", machine, err)
+			failed = true
+			continue
+		}
+		for _, name := range byMachine[machine] {
+			fmt.Fprintf(ctx.Stderr, "deleted %s from machine %s\n", name, machine)
+		}
+	}
+	if failed {
+		return cmd.ErrSilent
+	}
+	return nil
+}
+
+// parseImageName splits an image name of the form kind:name.
+func parseImageName(image string) (kind, name string, err error) {
+	parts := strings.SplitN(image, ":", 2)
+	if len(parts) != 2 || parts[1] == "" || strings.Contains(parts[1], "/") {
+		return "", "", fmt.Errorf("invalid image name %q: expected lxc:<name> or kvm:<name>", image)
+	}
+	switch parts[0] {
+	case "lxc", "kvm":
+		return parts[0], parts[1], nil
+	}
+	return "", "", fmt.Errorf("invalid image name %q: unknown image kind %q", image, parts[0])
+}
+
+// deleteImageCommand returns the shell command that deletes the
+// named image, which must be valid.
+func deleteImageCommand(image string) string {
+	kind, name, _ := parseImageName(image)
+	if kind == "lxc" {
+		return "sudo lxc-destroy -n " + utils.ShQuote(name)
+	}
+	return "sudo virsh vol-delete --pool uvtool " + utils.ShQuote(name)
+}
+
+// parseCachedImages parses the output of listImagesScript
+// run on the given machine.
+func parseCachedImages(machine string, output []byte) ([]cachedImage, error) {
+	var images []cachedImage
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("unexpected output %q", scanner.Text())
+		}
+		size, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid size %q", fields[2])
+		}
+		used, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time %q", fields[3])
+		}
+		images = append(images, cachedImage{
+			Machine:  machine,
+			Image:    fields[0] + ":" + fields[1],
+			Size:     size,
+			LastUsed: time.Unix(used, 0).UTC().Format(time.RFC3339),
+		})
+	}
+	return images, scanner.Err()
+}
+
+// runResultError returns an error describing the failure
+// of a command run on a machine, if it failed.
+func runResultError(result params.RunResult) error {
+	switch {
+	case result.Error != "":
+		return fmt.Errorf("%s", result.Error)
+	case result.Code != 0:
+		stderr := strings.TrimSpace(string(result.Stderr))
+		if stderr == "" {
+			return fmt.Errorf("exit status %d", result.Code)
+		}
+		return fmt.Errorf("exit status %d: %s", result.Code, stderr)
+	}
+	return nil
+}
+
+type cachedImagesByMachine []cachedImage
+
+func (s cachedImagesByMachine) Len() int      { return len(s) }
+func (s cachedImagesByMachine) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s cachedImagesByMachine) Less(i, j int) bool {
+	if s[i].Machine != s[j].Machine {
+		return naturalLess(s[i].Machine, s[j].Machine)
+	}
+	return s[i].Image < s[j].Image
+}
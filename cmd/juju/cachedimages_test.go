@@ -0,0 +1,177 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	"github.com/juju/utils/exec"
+	gc "launchpad.net/gocheck"
+	"launchpad.net/goyaml"
+
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/testing"
+)
+
+type CachedImagesSuite struct {
+	testing.FakeJujuHomeSuite
+	api *mockCachedImagesAPI
+}
+
+var _ = gc.Suite(&CachedImagesSuite{})
+
+func (s *CachedImagesSuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	s.api = &mockCachedImagesAPI{
+		images: map[string]string{
+			"0": "lxc juju-trusty-template 412000000 1412000000\n" +
+				"kvm trusty-amd64-release 250000000 1411000000\n",
+			"1":  "",
+			"10": "lxc juju-precise-template 398000000 1400000000\n",
+			"2":  "lxc juju-precise-template 398000000 1401000000\n",
+		},
+	}
+	s.PatchValue(&getCachedImagesAPI, func(_ *CachedImagesCommand) (cachedImagesAPI, error) {
+		return s.api, nil
+	})
+}
+
+func runCachedImages(c *gc.C, args ...string) (string, string, error) {
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&CachedImagesCommand{}), args...)
+	if ctx == nil {
+		return "", "", err
+	}
+	return testing.Stdout(ctx), testing.Stderr(ctx), err
+}
+
+func (s *CachedImagesSuite) TestInit(c *gc.C) {
+	_, _, err := runCachedImages(c, "lxc:juju-trusty-template")
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["lxc:juju-trusty-template"\]`)
+	_, _, err = runCachedImages(c, "--delete")
+	c.Assert(err, gc.ErrorMatches, "no images specified")
+	_, _, err = runCachedImages(c, "--delete", "juju-trusty-template")
+	c.Assert(err, gc.ErrorMatches, `invalid image name "juju-trusty-template": expected lxc:<name> or kvm:<name>`)
+	_, _, err = runCachedImages(c, "--delete", "docker:trusty")
+	c.Assert(err, gc.ErrorMatches, `invalid image name "docker:trusty": unknown image kind "docker"`)
+	_, _, err = runCachedImages(c, "--machine", "foo")
+	c.Assert(err, gc.ErrorMatches, `invalid machine id "foo"`)
+}
+
+func (s *CachedImagesSuite) TestList(c *gc.C) {
+	stdout, _, err := runCachedImages(c)
+	c.Assert(err, gc.IsNil)
+	var images []cachedImage
+	err = goyaml.Unmarshal([]byte(stdout), &images)
+	c.Assert(err, gc.IsNil)
+	c.Assert(images, jc.DeepEquals, []cachedImage{{
+		Machine:  "0",
+		Image:    "kvm:trusty-amd64-release",
+		Size:     250000000,
+		LastUsed: "2014-09-18T00:26:40Z",
+	}, {
+		Machine:  "0",
+		Image:    "lxc:juju-trusty-template",
+		Size:     412000000,
+		LastUsed: "2014-09-29T14:13:20Z",
+	}, {
+		Machine:  "2",
+		Image:    "lxc:juju-precise-template",
+		Size:     398000000,
+		LastUsed: "2014-05-25T06:40:00Z",
+	}, {
+		Machine:  "10",
+		Image:    "lxc:juju-precise-template",
+		Size:     398000000,
+		LastUsed: "2014-05-13T16:53:20Z",
+	}})
+	c.Assert(s.api.ranOnAll, jc.IsTrue)
+}
+
+func (s *CachedImagesSuite) TestListMachines(c *gc.C) {
+	stdout, _, err := runCachedImages(c, "--machine", "2", "--format", "json")
+	c.Assert(err, gc.IsNil)
+	c.Assert(stdout, gc.Equals, `[{"machine":"2","image":"lxc:juju-precise-template","size":398000000,"last-used":"2014-05-25T06:40:00Z"}]`+"\n")
+	c.Assert(s.api.ranOnAll, jc.IsFalse)
+}
+
+func (s *CachedImagesSuite) TestListFailure(c *gc.C) {
+	s.api.failures = map[string]params.RunResult{
+		"1": {ExecResponse: exec.ExecResponse{
+			Code:   1,
+			Stderr: []byte("sudo: no tty present\n"),
+		}},
+	}
+	stdout, stderr, err := runCachedImages(c, "--machine", "1,2")
+	c.Assert(err, gc.IsNil)
+	c.Assert(stdout, gc.Matches, `(?s)- machine: "2"\n.*`)
+	c.Assert(stderr, gc.Equals, "cannot list images on machine 1: exit status 1: sudo: no tty present\n")
+}
+
+func (s *CachedImagesSuite) TestDelete(c *gc.C) {
+	_, stderr, err := runCachedImages(c, "--delete", "lxc:juju-precise-template", "kvm:trusty-amd64-release")
+	c.Assert(err, gc.IsNil)
+	c.Assert(stderr, gc.Equals, ""+
+		"deleted kvm:trusty-amd64-release from machine 0\n"+
+		"deleted lxc:juju-precise-template from machine 2\n"+
+		"deleted lxc:juju-precise-template from machine 10\n")
+	c.Assert(s.api.deleted, jc.DeepEquals, map[string]string{
+		"0":  "sudo virsh vol-delete --pool uvtool 'trusty-amd64-release'",
+		"2":  "sudo lxc-destroy -n 'juju-precise-template'",
+		"10": "sudo lxc-destroy -n 'juju-precise-template'",
+	})
+}
+
+func (s *CachedImagesSuite) TestDeleteNotFound(c *gc.C) {
+	_, _, err := runCachedImages(c, "--delete", "lxc:juju-utopic-template")
+	c.Assert(err, gc.ErrorMatches, `image "lxc:juju-utopic-template" not found`)
+	c.Assert(s.api.deleted, gc.HasLen, 0)
+}
+
+type mockCachedImagesAPI struct {
+	// images holds the output of the image listing script
+	// for each machine.
+	images   map[string]string
+	failures map[string]params.RunResult
+	ranOnAll bool
+	deleted  map[string]string
+}
+
+func (*mockCachedImagesAPI) Close() error {
+	return nil
+}
+
+func (m *mockCachedImagesAPI) RunOnAllMachines(commands string, timeout time.Duration) ([]params.RunResult, error) {
+	m.ranOnAll = true
+	var machines []string
+	for id := range m.images {
+		machines = append(machines, id)
+	}
+	return m.Run(params.RunParams{Commands: commands, Machines: machines})
+}
+
+func (m *mockCachedImagesAPI) Run(run params.RunParams) ([]params.RunResult, error) {
+	var results []params.RunResult
+	for _, id := range run.Machines {
+		if result, ok := m.failures[id]; ok {
+			result.MachineId = id
+			results = append(results, result)
+			continue
+		}
+		if run.Commands == listImagesScript {
+			results = append(results, params.RunResult{
+				ExecResponse: exec.ExecResponse{Stdout: []byte(m.images[id])},
+				MachineId:    id,
+			})
+			continue
+		}
+		if m.deleted == nil {
+			m.deleted = make(map[string]string)
+		}
+		m.deleted[id] = run.Commands
+		results = append(results, params.RunResult{MachineId: id})
+	}
+	return results, nil
+}
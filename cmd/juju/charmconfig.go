@@ -0,0 +1,120 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/juju/charm"
+	"github.com/juju/cmd"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/juju/osenv"
+)
+
+const charmConfigDoc = `
+Prints the configuration options declared by a charm, with their types,
+default values and descriptions, without deploying the charm. Charms from
+the charm store are downloaded to the local charm cache if they are not
+already there; local charms are read from the repository given by
+--repository, which defaults to $JUJU_REPOSITORY.
+
+If the charm URL does not include a series, the series given by --series
+is used.
+
+Examples:
+    juju charm-config cs:trusty/postgresql
+    juju charm-config --format json mysql
+    juju charm-config --repository ~/charms local:trusty/mycharm
+`
+
+// CharmConfigCommand prints the configuration options of a charm.
+type CharmConfigCommand struct {
+	cmd.CommandBase
+	out       cmd.Output
+	CharmName string
+	Series    string
+	RepoPath  string
+}
+
+func (c *CharmConfigCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "charm-config",
+		Args:    "<charm url>",
+		Purpose: "show the configuration options of a charm",
+		Doc:     charmConfigDoc,
+	}
+}
+
+func (c *CharmConfigCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "yaml", map[string]cmd.Formatter{
+		"yaml": cmd.FormatYaml,
+		"json": cmd.FormatJson,
+	})
+	f.StringVar(&c.Series, "series", config.LatestLtsSeries(), "series to use if the charm url does not specify one")
+	f.StringVar(&c.RepoPath, "repository", os.Getenv(osenv.JujuRepositoryEnvKey), "local charm repository")
+}
+
+func (c *CharmConfigCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no charm specified")
+	}
+	c.CharmName = args[0]
+	return cmd.CheckEmpty(args[1:])
+}
+
+// charmOptionInfo describes a single charm configuration option.
+// Default is always reported, so that an option without a default
+// can be told apart from one whose default is false, zero or empty.
+type charmOptionInfo struct {
+	Type        string      `json:"type" yaml:"type"`
+	Default     interface{} `json:"default" yaml:"default"`
+	Description string      `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// charmConfigInfo holds the information printed by the
+// charm-config command.
+type charmConfigInfo struct {
+	Charm    string                     `json:"charm" yaml:"charm"`
+	Settings map[string]charmOptionInfo `json:"settings" yaml:"settings"`
+}
+
+func (c *CharmConfigCommand) Run(ctx *cmd.Context) error {
+	curl, err := charm.InferURL(c.CharmName, c.Series)
+	if err != nil {
+		return fmt.Errorf("invalid charm name %q: %v", c.CharmName, err)
+	}
+	repo, err := charm.InferRepository(curl.Reference, ctx.AbsPath(c.RepoPath))
+	if err != nil {
+		return err
+	}
+	if curl.Revision < 0 {
+		latest, err := charm.Latest(repo, curl)
+		if err != nil {
+			return err
+		}
+		curl = curl.WithRevision(latest)
+	}
+	ch, err := repo.Get(curl)
+	if err != nil {
+		return err
+	}
+	info := charmConfigInfo{
+		Charm:    curl.String(),
+		Settings: make(map[string]charmOptionInfo),
+	}
+	if cfg := ch.Config(); cfg != nil {
+		for name, option := range cfg.Options {
+			info.Settings[name] = charmOptionInfo{
+				Type:        option.Type,
+				Default:     option.Default,
+				Description: option.Description,
+			}
+		}
+	}
+	return c.out.Write(ctx, info)
+}
@@ -0,0 +1,113 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	charmtesting "github.com/juju/charm/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+	"launchpad.net/goyaml"
+
+	"github.com/juju/juju/testing"
+)
+
+type CharmConfigSuite struct {
+	testing.FakeJujuHomeSuite
+	repoPath string
+}
+
+var _ = gc.Suite(&CharmConfigSuite{})
+
+func (s *CharmConfigSuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	s.repoPath = c.MkDir()
+	seriesPath := filepath.Join(s.repoPath, "precise")
+	err := os.Mkdir(seriesPath, 0777)
+	c.Assert(err, gc.IsNil)
+	charmtesting.Charms.ClonedDirPath(seriesPath, "dummy")
+}
+
+func (s *CharmConfigSuite) runCharmConfig(c *gc.C, args ...string) ([]byte, error) {
+	args = append([]string{"--repository", s.repoPath}, args...)
+	ctx, err := testing.RunCommand(c, &CharmConfigCommand{}, args...)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(testing.Stdout(ctx)), nil
+}
+
+var dummyCharmSettings = map[string]interface{}{
+	"title": map[string]interface{}{
+		"type":        "string",
+		"default":     "My Title",
+		"description": "A descriptive title used for the service.",
+	},
+	"outlook": map[string]interface{}{
+		"type":        "string",
+		"default":     nil,
+		"description": "No default outlook.",
+	},
+	"username": map[string]interface{}{
+		"type":        "string",
+		"default":     "admin001",
+		"description": "The name of the initial account (given admin permissions).",
+	},
+	"skill-level": map[string]interface{}{
+		"type":        "int",
+		"default":     nil,
+		"description": "A number indicating skill.",
+	},
+}
+
+func (s *CharmConfigSuite) TestInit(c *gc.C) {
+	err := testing.InitCommand(&CharmConfigCommand{}, nil)
+	c.Assert(err, gc.ErrorMatches, "no charm specified")
+	err = testing.InitCommand(&CharmConfigCommand{}, []string{"mysql", "extra"})
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["extra"\]`)
+}
+
+func (s *CharmConfigSuite) TestCharmConfigYAML(c *gc.C) {
+	out, err := s.runCharmConfig(c, "local:precise/dummy")
+	c.Assert(err, gc.IsNil)
+	var info map[string]interface{}
+	err = goyaml.Unmarshal(out, &info)
+	c.Assert(err, gc.IsNil)
+	c.Assert(info["charm"], gc.Matches, `local:precise/dummy-\d+`)
+
+	// Round trip the expected settings to get the same
+	// map types as the output.
+	buf, err := goyaml.Marshal(dummyCharmSettings)
+	c.Assert(err, gc.IsNil)
+	var expected map[string]interface{}
+	err = goyaml.Unmarshal(buf, &expected)
+	c.Assert(err, gc.IsNil)
+	var settings map[string]interface{}
+	buf, err = goyaml.Marshal(info["settings"])
+	c.Assert(err, gc.IsNil)
+	err = goyaml.Unmarshal(buf, &settings)
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings, jc.DeepEquals, expected)
+}
+
+func (s *CharmConfigSuite) TestCharmConfigJSONDefaultSeries(c *gc.C) {
+	out, err := s.runCharmConfig(c, "--format", "json", "--series", "precise", "local:dummy")
+	c.Assert(err, gc.IsNil)
+	var info struct {
+		Charm    string
+		Settings map[string]interface{}
+	}
+	err = json.Unmarshal(out, &info)
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Charm, gc.Matches, `local:precise/dummy-\d+`)
+	c.Assert(info.Settings, jc.DeepEquals, dummyCharmSettings)
+}
+
+func (s *CharmConfigSuite) TestCharmNotFound(c *gc.C) {
+	_, err := s.runCharmConfig(c, "local:precise/nosuch-1")
+	c.Assert(err, gc.ErrorMatches, `charm not found in ".*": local:precise/nosuch-1`)
+}
@@ -0,0 +1,122 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/juju/cmd"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/state/api/params"
+)
+
+const cleanUnusedDoc = `
+Destroys the machines that host no units and no containers, so that the
+instances left behind when services and units are removed do not keep
+running. Machines that manage the environment are never destroyed.
+
+With --older-than, only the machines added longer ago than the given
+duration are destroyed, leaving alone the machines that were added for
+units yet to be deployed to them. The machines added before juju started
+recording when machines were added are then left alone too.
+
+With --dry-run, the machines that would be destroyed are listed, and
+nothing is destroyed.
+
+A machine hosting only unused containers is not itself unused: once its
+containers have been destroyed, running clean-unused again destroys it.
+
+Examples:
+    juju clean-unused --dry-run
+    juju clean-unused --older-than 24h
+`
+
+// CleanUnusedCommand destroys the machines that host
+// no units and no containers.
+type CleanUnusedCommand struct {
+	envcmd.EnvCommandBase
+	olderThan time.Duration
+	dryRun    bool
+}
+
+func (c *CleanUnusedCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "clean-unused",
+		Purpose: "destroy machines that host no units or containers",
+		Doc:     cleanUnusedDoc,
+	}
+}
+
+func (c *CleanUnusedCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.DurationVar(&c.olderThan, "older-than", 0, "only destroy machines added longer ago than this")
+	f.BoolVar(&c.dryRun, "dry-run", false, "don't destroy anything, just list the unused machines")
+}
+
+func (c *CleanUnusedCommand) Init(args []string) error {
+	if c.olderThan < 0 {
+		return fmt.Errorf("invalid --older-than duration %v", c.olderThan)
+	}
+	return cmd.CheckEmpty(args)
+}
+
+func (c *CleanUnusedCommand) Run(ctx *cmd.Context) error {
+	client, err := c.NewAPIClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	unused, err := client.UnusedMachines()
+	if err != nil {
+		return err
+	}
+	candidates := selectUnusedMachines(unused, c.olderThan, time.Now())
+	if len(candidates) == 0 {
+		ctx.Infof("no unused machines found")
+		return nil
+	}
+	if c.dryRun {
+		tw := tabwriter.NewWriter(ctx.Stdout, 0, 1, 2, ' ', 0)
+		fmt.Fprintln(tw, "MACHINE\tADDED")
+		for _, m := range candidates {
+			added := "unknown"
+			if m.DateCreated != nil {
+				added = m.DateCreated.UTC().Format(time.RFC3339)
+			}
+			fmt.Fprintf(tw, "%s\t%s\n", m.Id, added)
+		}
+		return tw.Flush()
+	}
+	ids := make([]string, len(candidates))
+	for i, m := range candidates {
+		ids[i] = m.Id
+	}
+	results := forEachEntity(ids, func(id string) error {
+		return client.DestroyMachines(id)
+	})
+	if failed := writeEntityResults(ctx.Stderr, "MACHINE", "destroyed", results); failed > 0 {
+		return fmt.Errorf("cannot destroy %d of %d unused machines", failed, len(ids))
+	}
+	return nil
+}
+
+// selectUnusedMachines returns the given machines that were added
+// longer ago than olderThan before now. If olderThan is zero, all
+// the machines are returned; otherwise, those added at an unknown
+// time are left out.
+func selectUnusedMachines(machines []params.UnusedMachine, olderThan time.Duration, now time.Time) []params.UnusedMachine {
+	if olderThan == 0 {
+		return machines
+	}
+	var selected []params.UnusedMachine
+	for _, m := range machines {
+		if m.DateCreated != nil && now.Sub(*m.DateCreated) > olderThan {
+			selected = append(selected, m)
+		}
+	}
+	return selected
+}
@@ -0,0 +1,106 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/cmd/envcmd"
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/testing"
+)
+
+type CleanUnusedSuite struct {
+	jujutesting.JujuConnSuite
+	unused *state.Machine
+	used   *state.Machine
+}
+
+var _ = gc.Suite(&CleanUnusedSuite{})
+
+func (s *CleanUnusedSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	var err error
+	s.used, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	s.unused, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	svc := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	u, err := svc.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = u.AssignToMachine(s.used)
+	c.Assert(err, gc.IsNil)
+}
+
+func runCleanUnused(c *gc.C, args ...string) (stdout, stderr string, err error) {
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&CleanUnusedCommand{}), args...)
+	if err != nil {
+		return "", "", err
+	}
+	return testing.Stdout(ctx), testing.Stderr(ctx), nil
+}
+
+func (s *CleanUnusedSuite) assertLife(c *gc.C, m *state.Machine, life state.Life) {
+	err := m.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(m.Life(), gc.Equals, life)
+}
+
+func (s *CleanUnusedSuite) TestInit(c *gc.C) {
+	_, _, err := runCleanUnused(c, "--older-than", "-1h")
+	c.Assert(err, gc.ErrorMatches, "invalid --older-than duration -1h0m0s")
+	_, _, err = runCleanUnused(c, "foo")
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["foo"\]`)
+}
+
+func (s *CleanUnusedSuite) TestDryRun(c *gc.C) {
+	stdout, _, err := runCleanUnused(c, "--dry-run")
+	c.Assert(err, gc.IsNil)
+	c.Assert(stdout, gc.Matches, ""+
+		"MACHINE +ADDED\n"+
+		s.unused.Id()+" +\\d{4}-\\d\\d-\\d\\dT\\d\\d:\\d\\d:\\d\\dZ\n")
+	s.assertLife(c, s.unused, state.Alive)
+	s.assertLife(c, s.used, state.Alive)
+}
+
+func (s *CleanUnusedSuite) TestCleanUnused(c *gc.C) {
+	_, stderr, err := runCleanUnused(c)
+	c.Assert(err, gc.IsNil)
+	c.Assert(stderr, gc.Matches, ""+
+		"MACHINE +RESULT\n"+
+		s.unused.Id()+" +destroyed\n")
+	s.assertLife(c, s.unused, state.Dying)
+	s.assertLife(c, s.used, state.Alive)
+
+	// Dying machines are not unused any more.
+	_, stderr, err = runCleanUnused(c)
+	c.Assert(err, gc.IsNil)
+	c.Assert(stderr, gc.Equals, "no unused machines found\n")
+}
+
+func (s *CleanUnusedSuite) TestOlderThan(c *gc.C) {
+	_, stderr, err := runCleanUnused(c, "--older-than", "1h")
+	c.Assert(err, gc.IsNil)
+	c.Assert(stderr, gc.Equals, "no unused machines found\n")
+	s.assertLife(c, s.unused, state.Alive)
+}
+
+func (s *CleanUnusedSuite) TestSelectUnusedMachines(c *gc.C) {
+	now := time.Date(2014, 10, 15, 12, 0, 0, 0, time.UTC)
+	old := now.Add(-2 * time.Hour)
+	recent := now.Add(-time.Minute)
+	machines := []params.UnusedMachine{
+		{Id: "1", DateCreated: &old},
+		{Id: "2", DateCreated: &recent},
+		{Id: "3"},
+	}
+	c.Assert(selectUnusedMachines(machines, 0, now), jc.DeepEquals, machines)
+	c.Assert(selectUnusedMachines(machines, time.Hour, now), jc.DeepEquals, machines[:1])
+	c.Assert(selectUnusedMachines(machines, 3*time.Hour, now), gc.HasLen, 0)
+}
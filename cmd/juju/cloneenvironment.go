@@ -0,0 +1,311 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/juju/charm"
+	"github.com/juju/cmd"
+	"launchpad.net/gnuflag"
+	"launchpad.net/goyaml"
+
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/constraints"
+	"github.com/juju/juju/juju"
+	"github.com/juju/juju/state/api"
+	"github.com/juju/juju/state/api/params"
+)
+
+const cloneEnvironmentDoc = `
+Recreates the services of the current environment in another, already
+bootstrapped, environment. For each service the charm, the number of units,
+the explicitly set configuration options, the constraints and whether it is
+exposed are copied, and all relations between the services are added.
+
+Machines, containers and placement directives are not copied; the target
+environment provisions machines for the new units as usual.
+
+Settings that only make sense in the target environment, such as
+provider-specific constraints, may be given in a YAML mapping file:
+
+    services:
+      mysql:
+        constraints: mem=8G instance-type=m1.large
+        config:
+          dataset-size: 80%
+
+Options given in the mapping file override those read from the current
+environment; constraints replace them.
+
+Charms must be available from the charm store; services running local
+charms are not cloned.
+
+Examples:
+    juju clone-environment -e staging production
+    juju clone-environment -e staging --mapping production.yaml production
+`
+
+// CloneEnvironmentCommand recreates the services and relations of one
+// environment in another.
+type CloneEnvironmentCommand struct {
+	envcmd.EnvCommandBase
+	Target  string
+	Mapping cmd.FileVar
+}
+
+func (c *CloneEnvironmentCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "clone-environment",
+		Args:    "<target environment>",
+		Purpose: "recreate the services of an environment in another environment",
+		Doc:     cloneEnvironmentDoc,
+	}
+}
+
+func (c *CloneEnvironmentCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.Var(&c.Mapping, "mapping", "path to yaml-formatted settings for the target environment")
+}
+
+func (c *CloneEnvironmentCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no target environment specified")
+	}
+	c.Target, args = args[0], args[1:]
+	return cmd.CheckEmpty(args)
+}
+
+// cloneMapping holds the settings read from a clone-environment
+// mapping file.
+type cloneMapping struct {
+	Services map[string]cloneServiceMapping `yaml:"services"`
+}
+
+// cloneServiceMapping holds the target environment settings
+// for a single service.
+type cloneServiceMapping struct {
+	Constraints string                 `yaml:"constraints"`
+	Config      map[string]interface{} `yaml:"config"`
+}
+
+// clonedService describes a service to be created in the
+// target environment.
+type clonedService struct {
+	Name        string
+	CharmURL    string
+	NumUnits    int
+	Config      map[string]interface{}
+	Constraints constraints.Value
+	Exposed     bool
+}
+
+// cloneSourceAPI defines the API methods used to read the
+// environment being cloned.
+type cloneSourceAPI interface {
+	Close() error
+	Status(patterns []string) (*api.Status, error)
+	ServiceGet(service string) (*params.ServiceGetResults, error)
+}
+
+// cloneTargetAPI defines the API methods used to recreate
+// services in the target environment.
+type cloneTargetAPI interface {
+	Close() error
+	Status(patterns []string) (*api.Status, error)
+	AddCharm(curl *charm.URL) error
+	ServiceDeploy(charmURL string, serviceName string, numUnits int, configYAML string, cons constraints.Value, toMachineSpec string) error
+	AddRelation(endpoints ...string) (*params.AddRelationResults, error)
+	ServiceExpose(service string) error
+}
+
+var getCloneSourceAPI = func(c *CloneEnvironmentCommand) (cloneSourceAPI, error) {
+	return c.NewAPIClient()
+}
+
+var getCloneTargetAPI = func(envName string) (cloneTargetAPI, error) {
+	return juju.NewAPIClientFromName(envName)
+}
+
+func (c *CloneEnvironmentCommand) Run(ctx *cmd.Context) error {
+	var mapping cloneMapping
+	if c.Mapping.Path != "" {
+		data, err := c.Mapping.Read(ctx)
+		if err != nil {
+			return err
+		}
+		if err := goyaml.Unmarshal(data, &mapping); err != nil {
+			return fmt.Errorf("cannot parse mapping file: %v", err)
+		}
+	}
+
+	source, err := getCloneSourceAPI(c)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	status, err := source.Status(nil)
+	if err != nil {
+		return err
+	}
+	services, err := cloneServices(ctx, source, status, mapping)
+	if err != nil {
+		return err
+	}
+
+	target, err := getCloneTargetAPI(c.Target)
+	if err != nil {
+		return err
+	}
+	defer target.Close()
+	targetStatus, err := target.Status(nil)
+	if err != nil {
+		return err
+	}
+	for _, svc := range services {
+		if _, ok := targetStatus.Services[svc.Name]; ok {
+			return fmt.Errorf("service %q already exists in environment %q", svc.Name, c.Target)
+		}
+	}
+
+	cloned := make(map[string]bool)
+	for _, svc := range services {
+		if err := deployClonedService(target, svc); err != nil {
+			return fmt.Errorf("cannot clone service %q: %v", svc.Name, err)
+		}
+		cloned[svc.Name] = true
+		ctx.Infof("cloned service %s", svc.Name)
+	}
+	for _, endpoints := range cloneRelations(status, cloned) {
+		if _, err := target.AddRelation(endpoints...); err != nil {
+			return fmt.Errorf("cannot add relation %v: %v", endpoints, err)
+		}
+		ctx.Infof("added relation %s %s", endpoints[0], endpoints[1])
+	}
+	return nil
+}
+
+// cloneServices returns the services of the source environment, sorted
+// by name, with the settings from the mapping applied.
+func cloneServices(ctx *cmd.Context, source cloneSourceAPI, status *api.Status, mapping cloneMapping) ([]*clonedService, error) {
+	names := make([]string, 0, len(status.Services))
+	for name := range status.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for name := range mapping.Services {
+		if _, ok := status.Services[name]; !ok {
+			return nil, fmt.Errorf("mapping file refers to unknown service %q", name)
+		}
+	}
+
+	var services []*clonedService
+	for _, name := range names {
+		svcStatus := status.Services[name]
+		curl, err := charm.ParseURL(svcStatus.Charm)
+		if err != nil {
+			return nil, err
+		}
+		if curl.Schema != "cs" {
+			ctx.Infof("skipping service %s: charm %s is not in the charm store", name, curl)
+			continue
+		}
+		results, err := source.ServiceGet(name)
+		if err != nil {
+			return nil, err
+		}
+		svc := &clonedService{
+			Name:        name,
+			CharmURL:    curl.String(),
+			NumUnits:    len(svcStatus.Units),
+			Config:      explicitSettings(results.Config),
+			Constraints: results.Constraints,
+			Exposed:     svcStatus.Exposed,
+		}
+		if len(svcStatus.SubordinateTo) > 0 {
+			// Units of subordinate services are
+			// created by their relations.
+			svc.NumUnits = 0
+		}
+		if m, ok := mapping.Services[name]; ok {
+			for key, value := range m.Config {
+				svc.Config[key] = value
+			}
+			if m.Constraints != "" {
+				if svc.Constraints, err = constraints.Parse(m.Constraints); err != nil {
+					return nil, fmt.Errorf("invalid constraints for service %q: %v", name, err)
+				}
+			}
+		}
+		services = append(services, svc)
+	}
+	return services, nil
+}
+
+// explicitSettings returns the values of the options reported by
+// ServiceGet that have been set explicitly, rather than defaulted.
+func explicitSettings(config map[string]interface{}) map[string]interface{} {
+	settings := make(map[string]interface{})
+	for name, info := range config {
+		info, ok := info.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if isDefault, _ := info["default"].(bool); isDefault {
+			continue
+		}
+		if value, ok := info["value"]; ok {
+			settings[name] = value
+		}
+	}
+	return settings
+}
+
+// deployClonedService creates the given service in the target environment.
+func deployClonedService(target cloneTargetAPI, svc *clonedService) error {
+	curl, err := charm.ParseURL(svc.CharmURL)
+	if err != nil {
+		return err
+	}
+	if err := target.AddCharm(curl); err != nil {
+		return err
+	}
+	var configYAML string
+	if len(svc.Config) > 0 {
+		data, err := goyaml.Marshal(map[string]interface{}{svc.Name: svc.Config})
+		if err != nil {
+			return err
+		}
+		configYAML = string(data)
+	}
+	if err := target.ServiceDeploy(svc.CharmURL, svc.Name, svc.NumUnits, configYAML, svc.Constraints, ""); err != nil {
+		return err
+	}
+	if svc.Exposed {
+		return target.ServiceExpose(svc.Name)
+	}
+	return nil
+}
+
+// cloneRelations returns the endpoints of the relations in the
+// given status between services that have been cloned. Peer
+// relations are created automatically and are not included.
+func cloneRelations(status *api.Status, cloned map[string]bool) [][]string {
+	var relations [][]string
+	for _, rel := range status.Relations {
+		if len(rel.Endpoints) != 2 {
+			continue
+		}
+		var endpoints []string
+		for _, ep := range rel.Endpoints {
+			if cloned[ep.ServiceName] {
+				endpoints = append(endpoints, ep.String())
+			}
+		}
+		if len(endpoints) == 2 {
+			relations = append(relations, endpoints)
+		}
+	}
+	return relations
+}
@@ -0,0 +1,261 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/juju/charm"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+	"launchpad.net/goyaml"
+
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/constraints"
+	"github.com/juju/juju/state/api"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/testing"
+)
+
+type CloneEnvironmentSuite struct {
+	testing.FakeJujuHomeSuite
+	source *mockCloneSource
+	target *mockCloneTarget
+}
+
+var _ = gc.Suite(&CloneEnvironmentSuite{})
+
+func (s *CloneEnvironmentSuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	s.source = &mockCloneSource{
+		status: &api.Status{
+			Services: map[string]api.ServiceStatus{
+				"mysql": {
+					Charm: "cs:precise/mysql-1",
+					Units: map[string]api.UnitStatus{"mysql/0": {}},
+				},
+				"wordpress": {
+					Charm:   "cs:precise/wordpress-3",
+					Exposed: true,
+					Units: map[string]api.UnitStatus{
+						"wordpress/0": {},
+						"wordpress/1": {},
+					},
+				},
+				"logging": {
+					Charm:         "cs:precise/logging-2",
+					SubordinateTo: []string{"wordpress"},
+				},
+				"mine": {
+					Charm: "local:precise/mine-0",
+					Units: map[string]api.UnitStatus{"mine/0": {}},
+				},
+			},
+			Relations: []api.RelationStatus{{
+				Endpoints: []api.EndpointStatus{
+					{ServiceName: "wordpress", Name: "db"},
+					{ServiceName: "mysql", Name: "server"},
+				},
+			}, {
+				Endpoints: []api.EndpointStatus{
+					{ServiceName: "wordpress", Name: "juju-info"},
+					{ServiceName: "logging", Name: "info"},
+				},
+			}, {
+				Endpoints: []api.EndpointStatus{
+					{ServiceName: "wordpress", Name: "loadbalancer"},
+				},
+			}, {
+				Endpoints: []api.EndpointStatus{
+					{ServiceName: "mine", Name: "db"},
+					{ServiceName: "mysql", Name: "server"},
+				},
+			}},
+		},
+		services: map[string]*params.ServiceGetResults{
+			"mysql": {
+				Config: map[string]interface{}{
+					"dataset-size": map[string]interface{}{"value": "80%"},
+					"tuning":       map[string]interface{}{"value": "safest", "default": true},
+				},
+				Constraints: constraints.MustParse("mem=4G"),
+			},
+			"wordpress": {
+				Config: map[string]interface{}{},
+			},
+			"logging": {
+				Config: map[string]interface{}{},
+			},
+		},
+	}
+	s.target = &mockCloneTarget{status: &api.Status{}}
+	s.PatchValue(&getCloneSourceAPI, func(_ *CloneEnvironmentCommand) (cloneSourceAPI, error) {
+		return s.source, nil
+	})
+	s.PatchValue(&getCloneTargetAPI, func(envName string) (cloneTargetAPI, error) {
+		c.Check(envName, gc.Equals, "production")
+		return s.target, nil
+	})
+}
+
+func runCloneEnvironment(c *gc.C, args ...string) (string, error) {
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&CloneEnvironmentCommand{}), args...)
+	if err != nil {
+		return "", err
+	}
+	return testing.Stderr(ctx), nil
+}
+
+func (s *CloneEnvironmentSuite) TestInit(c *gc.C) {
+	_, err := runCloneEnvironment(c)
+	c.Assert(err, gc.ErrorMatches, "no target environment specified")
+	_, err = runCloneEnvironment(c, "production", "extra")
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["extra"\]`)
+}
+
+func (s *CloneEnvironmentSuite) TestClone(c *gc.C) {
+	out, err := runCloneEnvironment(c, "production")
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.target.charms, jc.DeepEquals, []string{
+		"cs:precise/logging-2",
+		"cs:precise/mysql-1",
+		"cs:precise/wordpress-3",
+	})
+	c.Assert(s.target.deployed, gc.HasLen, 3)
+	c.Assert(s.target.deployed[0], jc.DeepEquals, deployedService{
+		name: "logging", charmURL: "cs:precise/logging-2", numUnits: 0,
+	})
+	c.Assert(s.target.deployed[1].name, gc.Equals, "mysql")
+	c.Assert(s.target.deployed[1].numUnits, gc.Equals, 1)
+	c.Assert(s.target.deployed[1].cons, jc.DeepEquals, constraints.MustParse("mem=4G"))
+	var config map[string]map[string]interface{}
+	err = goyaml.Unmarshal([]byte(s.target.deployed[1].configYAML), &config)
+	c.Assert(err, gc.IsNil)
+	c.Assert(config, jc.DeepEquals, map[string]map[string]interface{}{
+		"mysql": {"dataset-size": "80%"},
+	})
+	c.Assert(s.target.deployed[2], jc.DeepEquals, deployedService{
+		name: "wordpress", charmURL: "cs:precise/wordpress-3", numUnits: 2,
+	})
+	c.Assert(s.target.exposed, jc.DeepEquals, []string{"wordpress"})
+	c.Assert(s.target.relations, jc.DeepEquals, [][]string{
+		{"wordpress:db", "mysql:server"},
+		{"wordpress:juju-info", "logging:info"},
+	})
+	c.Assert(out, gc.Equals, ""+
+		"skipping service mine: charm local:precise/mine-0 is not in the charm store\n"+
+		"cloned service logging\n"+
+		"cloned service mysql\n"+
+		"cloned service wordpress\n"+
+		"added relation wordpress:db mysql:server\n"+
+		"added relation wordpress:juju-info logging:info\n")
+}
+
+func (s *CloneEnvironmentSuite) TestCloneWithMapping(c *gc.C) {
+	path := filepath.Join(c.MkDir(), "mapping.yaml")
+	err := ioutil.WriteFile(path, []byte(`
+services:
+  mysql:
+    constraints: mem=8G
+    config:
+      dataset-size: 50%
+      tuning: fast
+`), 0644)
+	c.Assert(err, gc.IsNil)
+	_, err = runCloneEnvironment(c, "--mapping", path, "production")
+	c.Assert(err, gc.IsNil)
+	mysql := s.target.deployed[1]
+	c.Assert(mysql.name, gc.Equals, "mysql")
+	c.Assert(mysql.cons, jc.DeepEquals, constraints.MustParse("mem=8G"))
+	var config map[string]map[string]interface{}
+	err = goyaml.Unmarshal([]byte(mysql.configYAML), &config)
+	c.Assert(err, gc.IsNil)
+	c.Assert(config, jc.DeepEquals, map[string]map[string]interface{}{
+		"mysql": {"dataset-size": "50%", "tuning": "fast"},
+	})
+}
+
+func (s *CloneEnvironmentSuite) TestCloneMappingUnknownService(c *gc.C) {
+	path := filepath.Join(c.MkDir(), "mapping.yaml")
+	err := ioutil.WriteFile(path, []byte("services: {nosuch: {constraints: mem=8G}}"), 0644)
+	c.Assert(err, gc.IsNil)
+	_, err = runCloneEnvironment(c, "--mapping", path, "production")
+	c.Assert(err, gc.ErrorMatches, `mapping file refers to unknown service "nosuch"`)
+	c.Assert(s.target.deployed, gc.HasLen, 0)
+}
+
+func (s *CloneEnvironmentSuite) TestCloneExistingService(c *gc.C) {
+	s.target.status.Services = map[string]api.ServiceStatus{"mysql": {}}
+	_, err := runCloneEnvironment(c, "production")
+	c.Assert(err, gc.ErrorMatches, `service "mysql" already exists in environment "production"`)
+	c.Assert(s.target.deployed, gc.HasLen, 0)
+}
+
+type mockCloneSource struct {
+	status   *api.Status
+	services map[string]*params.ServiceGetResults
+}
+
+func (*mockCloneSource) Close() error {
+	return nil
+}
+
+func (m *mockCloneSource) Status(patterns []string) (*api.Status, error) {
+	return m.status, nil
+}
+
+func (m *mockCloneSource) ServiceGet(service string) (*params.ServiceGetResults, error) {
+	return m.services[service], nil
+}
+
+type deployedService struct {
+	name       string
+	charmURL   string
+	numUnits   int
+	configYAML string
+	cons       constraints.Value
+}
+
+type mockCloneTarget struct {
+	status    *api.Status
+	charms    []string
+	deployed  []deployedService
+	exposed   []string
+	relations [][]string
+}
+
+func (*mockCloneTarget) Close() error {
+	return nil
+}
+
+func (m *mockCloneTarget) Status(patterns []string) (*api.Status, error) {
+	return m.status, nil
+}
+
+func (m *mockCloneTarget) AddCharm(curl *charm.URL) error {
+	m.charms = append(m.charms, curl.String())
+	return nil
+}
+
+func (m *mockCloneTarget) ServiceDeploy(charmURL string, serviceName string, numUnits int, configYAML string, cons constraints.Value, toMachineSpec string) error {
+	m.deployed = append(m.deployed, deployedService{
+		name:       serviceName,
+		charmURL:   charmURL,
+		numUnits:   numUnits,
+		configYAML: configYAML,
+		cons:       cons,
+	})
+	return nil
+}
+
+func (m *mockCloneTarget) AddRelation(endpoints ...string) (*params.AddRelationResults, error) {
+	m.relations = append(m.relations, endpoints)
+	return &params.AddRelationResults{}, nil
+}
+
+func (m *mockCloneTarget) ServiceExpose(service string) error {
+	m.exposed = append(m.exposed, service)
+	return nil
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/juju/juju/cmd/envcmd"
 	"github.com/juju/juju/constraints"
+	"github.com/juju/juju/state/api/params"
 )
 
 const getConstraintsDoc = `
@@ -19,6 +20,16 @@ get-constraints returns a list of constraints that have been set on
 the environment using juju set-constraints.  You can also view constraints set
 for a specific service by using juju get-constraints <service>.
 
+With --effective, the constraints of the service are shown merged with
+those of the environment, as they will be used for new machines added for
+the service.
+
+Examples:
+
+   get-constraints                           (constraints set on the environment)
+   get-constraints wordpress                 (constraints set on wordpress only)
+   get-constraints --effective wordpress     (constraints used for new wordpress machines)
+
 See Also:
    juju help constraints
    juju help set-constraints
@@ -33,12 +44,32 @@ set-constraints <service>.
 Constraints set on a service are combined with environment constraints for
 commands (such as juju deploy) that provision machines for services.  Where
 environment and service constraints overlap, the service constraints take
-precedence.
+precedence.  The constraints are merged key by key:
+
+ - a key set on the service overrides the same key set on the environment;
+ - a key set on the environment applies to the service when the service
+   does not set it, so defaults such as mem or instance-type need only be
+   set once;
+ - a key set on the service also overrides the environment keys that
+   conflict with it, as defined by the environment's provider: for example
+   instance-type on the service discards mem on the environment, and the
+   other way round;
+ - a key set with an empty value (such as "mem=") on the service clears
+   the environment default for that key.
+
+Every call replaces the whole set of constraints of the environment or
+service, so keys that are not given are removed.  Calling set-constraints
+with no constraints clears them all.  Constraints given with --constraints
+to commands such as deploy and add-machine are merged in the same way,
+over the service and environment constraints.  Use
+juju get-constraints --effective <service> to see the merged result.
 
 Examples:
 
    set-constraints mem=8G                         (all new machines in the environment must have at least 8GB of RAM)
    set-constraints --service wordpress mem=4G     (all new wordpress machines can ignore the 8G constraint above, and require only 4G)
+   set-constraints instance-type=m1.large         (all new machines in the environment use m1.large unless overridden)
+   set-constraints --service mysql mem=16G        (new mysql machines ignore instance-type above, and require 16G)
 
 See Also:
    juju help constraints
@@ -52,6 +83,7 @@ See Also:
 type GetConstraintsCommand struct {
 	envcmd.EnvCommandBase
 	ServiceName string
+	Effective   bool
 	out         cmd.Output
 }
 
@@ -74,6 +106,7 @@ func (c *GetConstraintsCommand) SetFlags(f *gnuflag.FlagSet) {
 		"yaml":        cmd.FormatYaml,
 		"json":        cmd.FormatJson,
 	})
+	f.BoolVar(&c.Effective, "effective", false, "show the service constraints merged with the environment constraints")
 }
 
 func (c *GetConstraintsCommand) Init(args []string) error {
@@ -83,6 +116,9 @@ func (c *GetConstraintsCommand) Init(args []string) error {
 		}
 		c.ServiceName, args = args[0], args[1:]
 	}
+	if c.Effective && c.ServiceName == "" {
+		return fmt.Errorf("--effective requires a service name")
+	}
 	return cmd.CheckEmpty(args)
 }
 
@@ -94,9 +130,15 @@ func (c *GetConstraintsCommand) Run(ctx *cmd.Context) error {
 	defer apiclient.Close()
 
 	var cons constraints.Value
-	if c.ServiceName == "" {
+	switch {
+	case c.ServiceName == "":
 		cons, err = apiclient.GetEnvironmentConstraints()
-	} else {
+	case c.Effective:
+		cons, err = apiclient.GetServiceEffectiveConstraints(c.ServiceName)
+		if params.IsCodeNotImplemented(err) {
+			return fmt.Errorf("--effective is not supported by this environment's API server")
+		}
+	default:
 		cons, err = apiclient.GetServiceConstraints(c.ServiceName)
 	}
 	if err != nil {
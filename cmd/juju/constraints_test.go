@@ -143,3 +143,28 @@ func (s *ConstraintsCommandsSuite) TestGetErrors(c *gc.C) {
 	assertGetError(c, 2, `unrecognized args: \["blether"\]`, "goodname", "blether")
 	assertGetError(c, 1, `service "missing" not found`, "missing")
 }
+
+func (s *ConstraintsCommandsSuite) TestGetEffective(c *gc.C) {
+	err := s.State.SetEnvironConstraints(constraints.MustParse("mem=8G cpu-cores=4 arch=amd64"))
+	c.Assert(err, gc.IsNil)
+	svc := s.AddTestingService(c, "svc", s.AddTestingCharm(c, "dummy"))
+	err = svc.SetConstraints(constraints.MustParse("mem=4G cpu-cores="))
+	c.Assert(err, gc.IsNil)
+	assertGet(c, "cpu-cores= mem=4096M\n", "svc")
+	assertGet(c, "arch=amd64 cpu-cores= mem=4096M\n", "--effective", "svc")
+}
+
+func (s *ConstraintsCommandsSuite) TestGetEffectiveConflicts(c *gc.C) {
+	err := s.State.SetEnvironConstraints(constraints.MustParse("mem=8G arch=amd64"))
+	c.Assert(err, gc.IsNil)
+	svc := s.AddTestingService(c, "svc", s.AddTestingCharm(c, "dummy"))
+	err = svc.SetConstraints(constraints.MustParse("instance-type=m1.large"))
+	c.Assert(err, gc.IsNil)
+	// The dummy provider only considers mem to conflict with
+	// instance-type, so arch still applies.
+	assertGet(c, "arch=amd64 instance-type=m1.large\n", "--effective", "svc")
+}
+
+func (s *ConstraintsCommandsSuite) TestGetEffectiveWithoutService(c *gc.C) {
+	assertGetError(c, 2, "--effective requires a service name", "--effective")
+}
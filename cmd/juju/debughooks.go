@@ -6,11 +6,16 @@ package main
 import (
 	"encoding/base64"
 	"fmt"
+	"os"
+	"os/exec"
+	"path"
 	"sort"
+	"strings"
 
 	"github.com/juju/charm/hooks"
 	"github.com/juju/cmd"
 	"github.com/juju/names"
+	"github.com/juju/utils"
 
 	unitdebug "github.com/juju/juju/worker/uniter/debug"
 )
@@ -18,17 +23,34 @@ import (
 // DebugHooksCommand is responsible for launching a ssh shell on a given unit or machine.
 type DebugHooksCommand struct {
 	SSHCommand
+	units []string
 	hooks []string
 }
 
 const debugHooksDoc = `
 Interactively debug a hook remotely on a service unit.
+
+If hook names are given, only those hooks are intercepted, and the others
+run as usual. Hook names may be patterns in which "*" matches any sequence
+of characters and "?" any single character, so "*-relation-*" intercepts
+every relation hook. A hook name of "*" on its own intercepts every hook,
+which is the default.
+
+Several units, separated by commas, may be debugged at once. Each unit is
+then debugged in its own window of a local tmux session, which requires
+tmux to be installed on the client; if the command is run inside tmux, the
+windows are opened in the current session.
+
+Examples:
+    juju debug-hooks mysql/0
+    juju debug-hooks mysql/0 config-changed "*-relation-*"
+    juju debug-hooks mysql/0,mysql/1,mysql/2 db-relation-changed
 `
 
 func (c *DebugHooksCommand) Info() *cmd.Info {
 	return &cmd.Info{
 		Name:    "debug-hooks",
-		Args:    "<unit name> [hook names]",
+		Args:    "<unit name>[,<unit name>...] [hook names]",
 		Purpose: "launch a tmux session to debug a hook",
 		Doc:     debugHooksDoc,
 	}
@@ -38,10 +60,13 @@ func (c *DebugHooksCommand) Init(args []string) error {
 	if len(args) < 1 {
 		return fmt.Errorf("no unit name specified")
 	}
-	c.Target = args[0]
-	if !names.IsValidUnit(c.Target) {
-		return fmt.Errorf("%q is not a valid unit name", c.Target)
+	c.units = strings.Split(args[0], ",")
+	for _, unit := range c.units {
+		if !names.IsValidUnit(unit) {
+			return fmt.Errorf("%q is not a valid unit name", unit)
+		}
 	}
+	c.Target = c.units[0]
 
 	// If any of the hooks is "*", then debug all hooks.
 	c.hooks = append([]string{}, args[1:]...)
@@ -54,14 +79,16 @@ func (c *DebugHooksCommand) Init(args []string) error {
 	return nil
 }
 
-func (c *DebugHooksCommand) validateHooks() error {
+// validateHooks checks that the hooks to debug exist on the given
+// unit, and returns their names with any patterns expanded.
+func (c *DebugHooksCommand) validateHooks(unit string) ([]string, error) {
 	if len(c.hooks) == 0 {
-		return nil
+		return nil, nil
 	}
-	service := names.UnitService(c.Target)
+	service := names.UnitService(unit)
 	relations, err := c.apiClient.ServiceCharmRelations(service)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	validHooks := make(map[string]bool)
@@ -74,23 +101,42 @@ func (c *DebugHooksCommand) validateHooks() error {
 			validHooks[hook] = true
 		}
 	}
+	hookNames := make([]string, 0, len(validHooks))
+	for hookName, _ := range validHooks {
+		hookNames = append(hookNames, hookName)
+	}
+	sort.Strings(hookNames)
+	var debugHooks []string
 	for _, hook := range c.hooks {
-		if !validHooks[hook] {
-			names := make([]string, 0, len(validHooks))
-			for hookName, _ := range validHooks {
-				names = append(names, hookName)
+		if !strings.ContainsAny(hook, "*?[") {
+			if !validHooks[hook] {
+				logger.Infof("unknown hook %s, valid hook names: %v", hook, hookNames)
+				return nil, fmt.Errorf("unit %q does not contain hook %q", unit, hook)
 			}
-			sort.Strings(names)
-			logger.Infof("unknown hook %s, valid hook names: %v", hook, names)
-			return fmt.Errorf("unit %q does not contain hook %q", c.Target, hook)
+			debugHooks = append(debugHooks, hook)
+			continue
+		}
+		matched := false
+		for _, name := range hookNames {
+			if ok, err := path.Match(hook, name); err != nil {
+				return nil, fmt.Errorf("invalid hook pattern %q: %v", hook, err)
+			} else if ok {
+				debugHooks = append(debugHooks, name)
+				matched = true
+			}
+		}
+		if !matched {
+			logger.Infof("no hook matches %s, valid hook names: %v", hook, hookNames)
+			return nil, fmt.Errorf("unit %q has no hooks matching %q", unit, hook)
 		}
 	}
-	return nil
+	return debugHooks, nil
 }
 
 // Run ensures c.Target is a unit, and resolves its address,
 // and connects to it via SSH to execute the debug-hooks
-// script.
+// script. If several units are to be debugged, a window
+// running debug-hooks on each of them is opened in tmux.
 func (c *DebugHooksCommand) Run(ctx *cmd.Context) error {
 	var err error
 	c.apiClient, err = c.initAPIClient()
@@ -98,14 +144,74 @@ func (c *DebugHooksCommand) Run(ctx *cmd.Context) error {
 		return err
 	}
 	defer c.apiClient.Close()
-	err = c.validateHooks()
+	if len(c.units) > 1 {
+		for _, unit := range c.units {
+			if _, err := c.validateHooks(unit); err != nil {
+				return err
+			}
+		}
+		return c.debugInWindows(ctx)
+	}
+	debugHooks, err := c.validateHooks(c.Target)
 	if err != nil {
 		return err
 	}
 	debugctx := unitdebug.NewHooksContext(c.Target)
-	script := base64.StdEncoding.EncodeToString([]byte(unitdebug.ClientScript(debugctx, c.hooks)))
+	script := base64.StdEncoding.EncodeToString([]byte(unitdebug.ClientScript(debugctx, debugHooks)))
 	innercmd := fmt.Sprintf(`F=$(mktemp); echo %s | base64 -d > $F; . $F`, script)
 	args := []string{fmt.Sprintf("sudo /bin/bash -c '%s'", innercmd)}
 	c.Args = args
 	return c.SSHCommand.Run(ctx)
 }
+
+// runTmux runs tmux on the client with the given arguments;
+// it is a variable so that tests can replace it.
+var runTmux = func(ctx *cmd.Context, args ...string) error {
+	command := exec.Command("tmux", args...)
+	command.Stdin = ctx.Stdin
+	command.Stdout = ctx.Stdout
+	command.Stderr = ctx.Stderr
+	return command.Run()
+}
+
+// debugInWindows opens a tmux window running debug-hooks for each of
+// the units, in the current tmux session if there is one, or else in a
+// new session which is then attached to.
+func (c *DebugHooksCommand) debugInWindows(ctx *cmd.Context) error {
+	inTmux := os.Getenv("TMUX") != ""
+	session := fmt.Sprintf("juju-debug-hooks-%d", os.Getpid())
+	for i, unit := range c.units {
+		command := c.unitCommand(unit)
+		var args []string
+		switch {
+		case inTmux:
+			args = []string{"new-window", "-n", unit, command}
+		case i == 0:
+			args = []string{"new-session", "-d", "-s", session, "-n", unit, command}
+		default:
+			args = []string{"new-window", "-t", session, "-n", unit, command}
+		}
+		if err := runTmux(ctx, args...); err != nil {
+			return fmt.Errorf("cannot open tmux window for unit %s: %v", unit, err)
+		}
+	}
+	if inTmux {
+		return nil
+	}
+	return runTmux(ctx, "attach-session", "-t", session)
+}
+
+// unitCommand returns the command line that debugs
+// the hooks of the given unit on its own.
+func (c *DebugHooksCommand) unitCommand(unit string) string {
+	args := []string{os.Args[0], "debug-hooks"}
+	if envName := c.ConnectionName(); envName != "" {
+		args = append(args, "-e", envName)
+	}
+	if !c.proxy {
+		args = append(args, "--proxy=false")
+	}
+	args = append(args, unit)
+	args = append(args, c.hooks...)
+	return utils.CommandString(args...)
+}
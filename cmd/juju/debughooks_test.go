@@ -4,8 +4,13 @@
 package main
 
 import (
+	"fmt"
+	"os"
 	"regexp"
 
+	"github.com/juju/cmd"
+	jc "github.com/juju/testing/checkers"
+	"github.com/juju/utils"
 	gc "launchpad.net/gocheck"
 
 	coretesting "github.com/juju/juju/testing"
@@ -52,6 +57,18 @@ var debugHooksTests = []struct {
 	info:   `relation hooks have the relation name prefixed`,
 	args:   []string{"mysql/0", "juju-info-relation-joined"},
 	result: ".*\n",
+}, {
+	info:   `hook names may be patterns`,
+	args:   []string{"mysql/0", "config-*", "*-relation-joined"},
+	result: ".*\n",
+}, {
+	info:  `patterns must match some hook`,
+	args:  []string{"mysql/0", "*-relation-broken", "db-*"},
+	error: `unit "mysql/0" has no hooks matching "db-\*"`,
+}, {
+	info:  `invalid unit syntax in a list`,
+	args:  []string{"mysql/0,mysql"},
+	error: `"mysql" is not a valid unit name`,
 }, {
 	info:  `invalid unit syntax`,
 	args:  []string{"mysql"},
@@ -93,3 +110,61 @@ func (s *DebugHooksSuite) TestDebugHooksCommand(c *gc.C) {
 		}
 	}
 }
+
+func (s *DebugHooksSuite) TestDebugHooksSeveralUnits(c *gc.C) {
+	machines := s.makeMachines(2, c, true)
+	dummy := s.AddTestingCharm(c, "dummy")
+	srv := s.AddTestingService(c, "mysql", dummy)
+	s.addUnit(srv, machines[0], c)
+	s.addUnit(srv, machines[1], c)
+
+	var calls [][]string
+	s.PatchValue(&runTmux, func(_ *cmd.Context, args ...string) error {
+		calls = append(calls, args)
+		return nil
+	})
+	for i, inTmux := range []string{"", "/tmp/tmux-1000/default,123,0"} {
+		c.Logf("test %d: TMUX=%q", i, inTmux)
+		s.PatchEnvironment("TMUX", inTmux)
+		calls = nil
+		debugHooksCmd := &DebugHooksCommand{}
+		err := debugHooksCmd.Init([]string{"mysql/0,mysql/1", "*-relation-*"})
+		c.Assert(err, gc.IsNil)
+		err = debugHooksCmd.Run(coretesting.Context(c))
+		c.Assert(err, gc.IsNil)
+
+		command := func(unit string) string {
+			return utils.CommandString(os.Args[0], "debug-hooks", "--proxy=false", unit, "*-relation-*")
+		}
+		session := fmt.Sprintf("juju-debug-hooks-%d", os.Getpid())
+		if inTmux != "" {
+			c.Assert(calls, jc.DeepEquals, [][]string{
+				{"new-window", "-n", "mysql/0", command("mysql/0")},
+				{"new-window", "-n", "mysql/1", command("mysql/1")},
+			})
+		} else {
+			c.Assert(calls, jc.DeepEquals, [][]string{
+				{"new-session", "-d", "-s", session, "-n", "mysql/0", command("mysql/0")},
+				{"new-window", "-t", session, "-n", "mysql/1", command("mysql/1")},
+				{"attach-session", "-t", session},
+			})
+		}
+	}
+}
+
+func (s *DebugHooksSuite) TestDebugHooksSeveralUnitsValidatesHooks(c *gc.C) {
+	machines := s.makeMachines(2, c, true)
+	dummy := s.AddTestingCharm(c, "dummy")
+	srv := s.AddTestingService(c, "mysql", dummy)
+	s.addUnit(srv, machines[0], c)
+	s.addUnit(srv, machines[1], c)
+	s.PatchValue(&runTmux, func(_ *cmd.Context, args ...string) error {
+		c.Fatalf("unexpected tmux call %v", args)
+		return nil
+	})
+	debugHooksCmd := &DebugHooksCommand{}
+	err := debugHooksCmd.Init([]string{"mysql/0,mysql/1", "invalid-hook"})
+	c.Assert(err, gc.IsNil)
+	err = debugHooksCmd.Run(coretesting.Context(c))
+	c.Assert(err, gc.ErrorMatches, `unit "mysql/0" does not contain hook "invalid-hook"`)
+}
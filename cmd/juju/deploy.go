@@ -30,6 +30,7 @@ type DeployCommand struct {
 	Config       cmd.FileVar
 	Constraints  constraints.Value
 	Networks     string
+	Bundle       string
 	BumpRevision bool   // Remove this once the 1.16 support is dropped.
 	RepoPath     string // defaults to JUJU_REPOSITORY
 }
@@ -85,6 +86,7 @@ Examples:
    juju deploy mysql --to 23       (deploy to machine 23)
    juju deploy mysql --to 24/lxc/3 (deploy to lxc container 3 on host machine 24)
    juju deploy mysql --to lxc:25   (deploy to a new lxc container on host machine 25)
+   juju deploy mysql --to kvm:26   (deploy to a new kvm container on host machine 26)
 
    juju deploy mysql -n 5 --constraints mem=8G
    (deploy 5 instances of mysql with at least 8 GB of RAM each)
@@ -102,6 +104,10 @@ to configure on it. The --networks argument instructs juju to add all the
 networks specified with it to all new machines deployed to host units of
 the service. Not supported on all providers.
 
+When a service is deployed as part of a bundle, the name of the bundle
+can be given with the --bundle argument. It is recorded along with who
+deployed the service, and shown by "juju status --include provenance".
+
 See Also:
    juju help constraints
    juju help set-constraints
@@ -125,6 +131,7 @@ func (c *DeployCommand) SetFlags(f *gnuflag.FlagSet) {
 	f.Var(&c.Config, "config", "path to yaml-formatted service config")
 	f.Var(constraints.ConstraintsValue{Target: &c.Constraints}, "constraints", "set service constraints")
 	f.StringVar(&c.Networks, "networks", "", "bind the service to specific networks")
+	f.StringVar(&c.Bundle, "bundle", "", "name of the bundle the service is deployed as part of")
 	f.StringVar(&c.RepoPath, "repository", os.Getenv(osenv.JujuRepositoryEnvKey), "local charm repository")
 }
 
@@ -229,7 +236,8 @@ func (c *DeployCommand) Run(ctx *cmd.Context) error {
 			return err
 		}
 	}
-	err = client.ServiceDeployWithNetworks(
+	err = client.ServiceDeployInBundle(
+		c.Bundle,
 		curl.String(),
 		serviceName,
 		numUnits,
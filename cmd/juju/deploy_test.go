@@ -120,6 +120,18 @@ func (s *DeploySuite) TestCharmBundle(c *gc.C) {
 	s.AssertService(c, "some-service-name", curl, 1, 0)
 }
 
+func (s *DeploySuite) TestDeployInBundle(c *gc.C) {
+	charmtesting.Charms.BundlePath(s.SeriesPath, "dummy")
+	err := runDeploy(c, "local:dummy", "--bundle", "wiki")
+	c.Assert(err, gc.IsNil)
+	service, err := s.State.Service("dummy")
+	c.Assert(err, gc.IsNil)
+	record := service.DeploymentRecord()
+	c.Assert(record, gc.NotNil)
+	c.Assert(record.Bundle, gc.Equals, "wiki")
+	c.Assert(record.CharmURL, gc.Equals, "local:precise/dummy-1")
+}
+
 func (s *DeploySuite) TestSubordinateCharm(c *gc.C) {
 	charmtesting.Charms.BundlePath(s.SeriesPath, "logging")
 	err := runDeploy(c, "local:logging")
@@ -30,11 +30,31 @@ type DestroyEnvironmentCommand struct {
 	force     bool
 }
 
+const destroyEnvironmentDoc = `
+Destroys the named environment. By default, the environment's state server
+is asked to cleanly tear down all machines and agents before the remaining
+resources are removed through the environment provider.
+
+If the state server is unreachable or no longer functioning, the --force
+flag skips the API entirely: all instances are terminated directly through
+the environment provider, and the provider storage is deleted. Resources
+not created by juju are left alone, so review your provider console
+afterwards for anything that needs to be cleaned up.
+
+Examples:
+	# Cleanly destroy the "production" environment
+	$ juju destroy-environment production
+
+	# Destroy an environment whose state server is dead
+	$ juju destroy-environment --force production
+`
+
 func (c *DestroyEnvironmentCommand) Info() *cmd.Info {
 	return &cmd.Info{
 		Name:    "destroy-environment",
 		Args:    "<environment name>",
 		Purpose: "terminate all machines and other associated resources for an environment",
+		Doc:     destroyEnvironmentDoc,
 	}
 }
 
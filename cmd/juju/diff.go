@@ -0,0 +1,429 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/juju/charm"
+	"github.com/juju/cmd"
+	"launchpad.net/gnuflag"
+	"launchpad.net/goyaml"
+
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/constraints"
+	"github.com/juju/juju/state/api"
+	"github.com/juju/juju/state/api/params"
+)
+
+const diffDoc = `
+Compares a bundle with the running environment and reports the differences in
+services, charms, unit counts, configuration options, constraints and
+relations.
+
+The bundle is a YAML file in the format used by juju-deployer:
+
+    services:
+      wordpress:
+        charm: cs:precise/wordpress
+        num_units: 2
+        options:
+          tuning: optimized
+        constraints: mem=2G
+      mysql:
+        charm: cs:precise/mysql
+    relations:
+      - [wordpress:db, mysql]
+
+Files holding several named bundles must select one with --bundle. Charms
+given without a revision match any revision. Configuration options that are
+not mentioned in the bundle are only reported if they have been set
+explicitly in the environment.
+
+Examples:
+    juju diff production.yaml
+    juju diff --bundle wiki bundles.yaml
+    juju diff --format yaml production.yaml
+`
+
+// DiffCommand compares a bundle with the running environment.
+type DiffCommand struct {
+	envcmd.EnvCommandBase
+	out        cmd.Output
+	BundleFile cmd.FileVar
+	BundleName string
+}
+
+func (c *DiffCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "diff",
+		Args:    "<bundle file>",
+		Purpose: "show differences between a bundle and the environment",
+		Doc:     diffDoc,
+	}
+}
+
+func (c *DiffCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.StringVar(&c.BundleName, "bundle", "", "name of the bundle to compare, if the file holds several")
+	c.out.AddFlags(f, "simple", map[string]cmd.Formatter{
+		"yaml":   cmd.FormatYaml,
+		"json":   cmd.FormatJson,
+		"simple": formatDiffSimple,
+	})
+}
+
+func (c *DiffCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no bundle file specified")
+	}
+	c.BundleFile.Path, args = args[0], args[1:]
+	return cmd.CheckEmpty(args)
+}
+
+// bundleService holds the details of a service in a bundle.
+type bundleService struct {
+	Charm       string                 `yaml:"charm"`
+	NumUnits    *int                   `yaml:"num_units"`
+	Options     map[string]interface{} `yaml:"options"`
+	Constraints string                 `yaml:"constraints"`
+}
+
+// bundle holds the services and relations of a bundle.
+type bundle struct {
+	Services  map[string]bundleService `yaml:"services"`
+	Relations [][]string               `yaml:"relations"`
+}
+
+// valueDiff holds a value that differs between the bundle
+// and the environment. A nil value is absent.
+type valueDiff struct {
+	Bundle      interface{} `json:"bundle" yaml:"bundle"`
+	Environment interface{} `json:"environment" yaml:"environment"`
+}
+
+// serviceDiff holds the differences of a single service.
+type serviceDiff struct {
+	Missing     string                `json:"missing,omitempty" yaml:"missing,omitempty"`
+	Charm       *valueDiff            `json:"charm,omitempty" yaml:"charm,omitempty"`
+	NumUnits    *valueDiff            `json:"num-units,omitempty" yaml:"num-units,omitempty"`
+	Constraints *valueDiff            `json:"constraints,omitempty" yaml:"constraints,omitempty"`
+	Options     map[string]*valueDiff `json:"options,omitempty" yaml:"options,omitempty"`
+}
+
+// relationsDiff holds the relations found on one side only.
+type relationsDiff struct {
+	BundleOnly      [][]string `json:"bundle-only,omitempty" yaml:"bundle-only,omitempty"`
+	EnvironmentOnly [][]string `json:"environment-only,omitempty" yaml:"environment-only,omitempty"`
+}
+
+// environmentDiff holds all the differences between a bundle
+// and the environment.
+type environmentDiff struct {
+	Services  map[string]*serviceDiff `json:"services,omitempty" yaml:"services,omitempty"`
+	Relations *relationsDiff          `json:"relations,omitempty" yaml:"relations,omitempty"`
+}
+
+// diffAPI defines the API methods used by the diff command.
+type diffAPI interface {
+	Close() error
+	Status(patterns []string) (*api.Status, error)
+	ServiceGet(service string) (*params.ServiceGetResults, error)
+}
+
+var getDiffAPI = func(c *DiffCommand) (diffAPI, error) {
+	return c.NewAPIClient()
+}
+
+func (c *DiffCommand) Run(ctx *cmd.Context) error {
+	data, err := c.BundleFile.Read(ctx)
+	if err != nil {
+		return err
+	}
+	b, err := parseBundle(data, c.BundleName)
+	if err != nil {
+		return err
+	}
+	client, err := getDiffAPI(c)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	status, err := client.Status(nil)
+	if err != nil {
+		return err
+	}
+	diff, err := diffEnvironment(client, b, status)
+	if err != nil {
+		return err
+	}
+	return c.out.Write(ctx, diff)
+}
+
+// parseBundle reads the bundle with the given name from data. If name is
+// empty, data must hold either a single bundle, or services and
+// relations at the top level.
+func parseBundle(data []byte, name string) (*bundle, error) {
+	var top bundle
+	if err := goyaml.Unmarshal(data, &top); err != nil {
+		return nil, fmt.Errorf("cannot parse bundle: %v", err)
+	}
+	if top.Services != nil {
+		if name != "" {
+			return nil, fmt.Errorf("bundle file does not contain named bundles")
+		}
+		return &top, nil
+	}
+	var named map[string]bundle
+	if err := goyaml.Unmarshal(data, &named); err != nil {
+		return nil, fmt.Errorf("cannot parse bundle: %v", err)
+	}
+	if name == "" {
+		if len(named) != 1 {
+			var names []string
+			for n := range named {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			return nil, fmt.Errorf("bundle file holds several bundles; select one of %s with --bundle", strings.Join(names, ", "))
+		}
+		for n := range named {
+			name = n
+		}
+	}
+	b, ok := named[name]
+	if !ok {
+		return nil, fmt.Errorf("bundle %q not found", name)
+	}
+	return &b, nil
+}
+
+// diffEnvironment compares the bundle with the environment
+// described by status.
+func diffEnvironment(client diffAPI, b *bundle, status *api.Status) (*environmentDiff, error) {
+	diff := &environmentDiff{
+		Services: make(map[string]*serviceDiff),
+	}
+	for name := range b.Services {
+		if _, ok := status.Services[name]; !ok {
+			diff.Services[name] = &serviceDiff{Missing: "environment"}
+		}
+	}
+	for name, svcStatus := range status.Services {
+		bundleSvc, ok := b.Services[name]
+		if !ok {
+			diff.Services[name] = &serviceDiff{Missing: "bundle"}
+			continue
+		}
+		config, err := client.ServiceGet(name)
+		if err != nil {
+			return nil, err
+		}
+		if svcDiff := diffService(bundleSvc, svcStatus, config); svcDiff != nil {
+			diff.Services[name] = svcDiff
+		}
+	}
+	if len(diff.Services) == 0 {
+		diff.Services = nil
+	}
+	diff.Relations = diffRelations(b.Relations, status.Relations)
+	return diff, nil
+}
+
+// diffService returns the differences between a service in the bundle
+// and in the environment, or nil if there are none.
+func diffService(bundleSvc bundleService, svcStatus api.ServiceStatus, config *params.ServiceGetResults) *serviceDiff {
+	var diff serviceDiff
+	changed := false
+	if !charmMatches(bundleSvc.Charm, svcStatus.Charm) {
+		diff.Charm = &valueDiff{bundleSvc.Charm, svcStatus.Charm}
+		changed = true
+	}
+	if len(svcStatus.SubordinateTo) == 0 {
+		// Units of subordinate services are not deployed
+		// directly, so their number is not compared.
+		bundleUnits := 1
+		if bundleSvc.NumUnits != nil {
+			bundleUnits = *bundleSvc.NumUnits
+		}
+		if bundleUnits != len(svcStatus.Units) {
+			diff.NumUnits = &valueDiff{bundleUnits, len(svcStatus.Units)}
+			changed = true
+		}
+	}
+	bundleCons := bundleSvc.Constraints
+	if cons, err := constraints.Parse(bundleCons); err == nil {
+		bundleCons = cons.String()
+	}
+	if envCons := config.Constraints.String(); bundleCons != envCons {
+		diff.Constraints = &valueDiff{bundleCons, envCons}
+		changed = true
+	}
+	values := make(map[string]interface{})
+	for name, info := range config.Config {
+		if info, ok := info.(map[string]interface{}); ok {
+			values[name] = info["value"]
+		}
+	}
+	compare := make(map[string]bool)
+	for name := range bundleSvc.Options {
+		compare[name] = true
+	}
+	for name := range explicitSettings(config.Config) {
+		compare[name] = true
+	}
+	for name := range compare {
+		bundleValue, envValue := bundleSvc.Options[name], values[name]
+		if fmt.Sprint(bundleValue) == fmt.Sprint(envValue) {
+			continue
+		}
+		if diff.Options == nil {
+			diff.Options = make(map[string]*valueDiff)
+		}
+		diff.Options[name] = &valueDiff{bundleValue, envValue}
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return &diff
+}
+
+// charmMatches reports whether the charm given in a bundle refers to
+// the charm deployed in the environment. A bundle charm without a
+// revision matches any revision.
+func charmMatches(bundleCharm, envCharm string) bool {
+	envURL, err := charm.ParseURL(envCharm)
+	if err != nil {
+		return bundleCharm == envCharm
+	}
+	bundleURL, err := charm.InferURL(bundleCharm, envURL.Series)
+	if err != nil {
+		return bundleCharm == envCharm
+	}
+	if bundleURL.Revision == -1 {
+		envURL = envURL.WithRevision(-1)
+	}
+	return bundleURL.String() == envURL.String()
+}
+
+// diffRelations returns the relations found only in the bundle or only
+// in the environment, or nil if there are none. Relation endpoints in
+// the bundle may omit the relation name.
+func diffRelations(bundleRelations [][]string, envRelations []api.RelationStatus) *relationsDiff {
+	var envEndpoints [][]string
+	for _, rel := range envRelations {
+		if len(rel.Endpoints) != 2 {
+			// Peer relations are implicit.
+			continue
+		}
+		envEndpoints = append(envEndpoints, []string{
+			rel.Endpoints[0].String(),
+			rel.Endpoints[1].String(),
+		})
+	}
+	var diff relationsDiff
+	matched := make([]bool, len(envEndpoints))
+	for _, endpoints := range bundleRelations {
+		found := false
+		for i, env := range envEndpoints {
+			if !matched[i] && relationMatches(endpoints, env) {
+				matched[i] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			diff.BundleOnly = append(diff.BundleOnly, endpoints)
+		}
+	}
+	for i, env := range envEndpoints {
+		if !matched[i] {
+			diff.EnvironmentOnly = append(diff.EnvironmentOnly, env)
+		}
+	}
+	if diff.BundleOnly == nil && diff.EnvironmentOnly == nil {
+		return nil
+	}
+	return &diff
+}
+
+// relationMatches reports whether the endpoints of a bundle relation
+// identify the given environment relation, in either order.
+func relationMatches(bundleEndpoints, envEndpoints []string) bool {
+	if len(bundleEndpoints) != 2 {
+		return false
+	}
+	match := func(spec, endpoint string) bool {
+		if strings.Contains(spec, ":") {
+			return spec == endpoint
+		}
+		return strings.SplitN(endpoint, ":", 2)[0] == spec
+	}
+	a, b := bundleEndpoints[0], bundleEndpoints[1]
+	return match(a, envEndpoints[0]) && match(b, envEndpoints[1]) ||
+		match(a, envEndpoints[1]) && match(b, envEndpoints[0])
+}
+
+// formatDiffSimple returns a human readable summary of the differences.
+func formatDiffSimple(value interface{}) ([]byte, error) {
+	diff, ok := value.(*environmentDiff)
+	if !ok {
+		return nil, fmt.Errorf("expected value of type %T, got %T", diff, value)
+	}
+	var buf bytes.Buffer
+	var names []string
+	for name := range diff.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		svc := diff.Services[name]
+		if svc.Missing != "" {
+			fmt.Fprintf(&buf, "service %s: missing from %s\n", name, svc.Missing)
+			continue
+		}
+		fmt.Fprintf(&buf, "service %s:\n", name)
+		if svc.Charm != nil {
+			fmt.Fprintf(&buf, "  charm: bundle %v, environment %v\n", svc.Charm.Bundle, svc.Charm.Environment)
+		}
+		if svc.NumUnits != nil {
+			fmt.Fprintf(&buf, "  units: bundle %v, environment %v\n", svc.NumUnits.Bundle, svc.NumUnits.Environment)
+		}
+		if svc.Constraints != nil {
+			fmt.Fprintf(&buf, "  constraints: bundle %q, environment %q\n", svc.Constraints.Bundle, svc.Constraints.Environment)
+		}
+		var options []string
+		for option := range svc.Options {
+			options = append(options, option)
+		}
+		sort.Strings(options)
+		for _, option := range options {
+			d := svc.Options[option]
+			fmt.Fprintf(&buf, "  option %s: bundle %s, environment %s\n", option, formatDiffValue(d.Bundle), formatDiffValue(d.Environment))
+		}
+	}
+	if diff.Relations != nil {
+		for _, rel := range diff.Relations.BundleOnly {
+			fmt.Fprintf(&buf, "relation %s: missing from environment\n", strings.Join(rel, " "))
+		}
+		for _, rel := range diff.Relations.EnvironmentOnly {
+			fmt.Fprintf(&buf, "relation %s: missing from bundle\n", strings.Join(rel, " "))
+		}
+	}
+	if buf.Len() == 0 {
+		buf.WriteString("no differences\n")
+	}
+	// The output is terminated by a newline when written.
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+func formatDiffValue(value interface{}) string {
+	if value == nil {
+		return "unset"
+	}
+	return fmt.Sprintf("%q", fmt.Sprint(value))
+}
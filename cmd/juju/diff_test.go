@@ -0,0 +1,231 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/constraints"
+	"github.com/juju/juju/state/api"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/testing"
+)
+
+type DiffSuite struct {
+	testing.FakeJujuHomeSuite
+	api *mockDiffAPI
+}
+
+var _ = gc.Suite(&DiffSuite{})
+
+func (s *DiffSuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	s.api = &mockDiffAPI{
+		status: &api.Status{
+			Services: map[string]api.ServiceStatus{
+				"wordpress": {
+					Charm: "cs:precise/wordpress-3",
+					Units: map[string]api.UnitStatus{"wordpress/0": {}},
+				},
+				"mysql": {
+					Charm: "cs:precise/mysql-1",
+					Units: map[string]api.UnitStatus{"mysql/0": {}},
+				},
+				"logging": {
+					Charm:         "cs:precise/logging-2",
+					SubordinateTo: []string{"wordpress"},
+				},
+			},
+			Relations: []api.RelationStatus{{
+				Endpoints: []api.EndpointStatus{
+					{ServiceName: "wordpress", Name: "db"},
+					{ServiceName: "mysql", Name: "server"},
+				},
+			}, {
+				Endpoints: []api.EndpointStatus{
+					{ServiceName: "wordpress", Name: "juju-info"},
+					{ServiceName: "logging", Name: "info"},
+				},
+			}, {
+				Endpoints: []api.EndpointStatus{
+					{ServiceName: "mysql", Name: "cluster"},
+				},
+			}},
+		},
+		services: map[string]*params.ServiceGetResults{
+			"wordpress": {
+				Config: map[string]interface{}{
+					"tuning": map[string]interface{}{"value": "single", "default": true},
+					"debug":  map[string]interface{}{"value": "yes"},
+				},
+			},
+			"mysql": {
+				Config: map[string]interface{}{
+					"dataset-size": map[string]interface{}{"value": "80%", "default": true},
+					"max-connections": map[string]interface{}{
+						"value": float64(-1), "default": true,
+					},
+				},
+				Constraints: constraints.MustParse("mem=4G"),
+			},
+			"logging": {
+				Config: map[string]interface{}{},
+			},
+		},
+	}
+	s.PatchValue(&getDiffAPI, func(_ *DiffCommand) (diffAPI, error) {
+		return s.api, nil
+	})
+}
+
+func (s *DiffSuite) runDiff(c *gc.C, bundle string, args ...string) (string, error) {
+	path := filepath.Join(c.MkDir(), "bundle.yaml")
+	err := ioutil.WriteFile(path, []byte(bundle), 0644)
+	c.Assert(err, gc.IsNil)
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&DiffCommand{}), append(args, path)...)
+	if err != nil {
+		return "", err
+	}
+	return testing.Stdout(ctx), nil
+}
+
+const matchingBundle = `
+services:
+  wordpress:
+    charm: cs:precise/wordpress
+    options:
+      debug: "yes"
+  mysql:
+    charm: mysql
+    constraints: mem=4096M
+    options:
+      max-connections: -1
+  logging:
+    charm: cs:precise/logging-2
+relations:
+  - [wordpress:db, mysql]
+  - [logging, wordpress]
+`
+
+func (s *DiffSuite) TestInit(c *gc.C) {
+	err := testing.InitCommand(envcmd.Wrap(&DiffCommand{}), nil)
+	c.Assert(err, gc.ErrorMatches, "no bundle file specified")
+	err = testing.InitCommand(envcmd.Wrap(&DiffCommand{}), []string{"a.yaml", "b.yaml"})
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["b.yaml"\]`)
+}
+
+func (s *DiffSuite) TestNoDifferences(c *gc.C) {
+	out, err := s.runDiff(c, matchingBundle)
+	c.Assert(err, gc.IsNil)
+	c.Assert(out, gc.Equals, "no differences\n")
+}
+
+func (s *DiffSuite) TestDifferences(c *gc.C) {
+	out, err := s.runDiff(c, `
+services:
+  wordpress:
+    charm: cs:precise/wordpress-4
+    num_units: 3
+    options:
+      tuning: optimized
+  mysql:
+    charm: cs:precise/mysql
+  haproxy:
+    charm: cs:precise/haproxy
+relations:
+  - [wordpress:db, mysql:server]
+  - [haproxy, wordpress]
+`)
+	c.Assert(err, gc.IsNil)
+	c.Assert(out, gc.Equals, ""+
+		"service haproxy: missing from environment\n"+
+		"service logging: missing from bundle\n"+
+		"service mysql:\n"+
+		"  constraints: bundle \"\", environment \"mem=4096M\"\n"+
+		"service wordpress:\n"+
+		"  charm: bundle cs:precise/wordpress-4, environment cs:precise/wordpress-3\n"+
+		"  units: bundle 3, environment 1\n"+
+		"  option debug: bundle unset, environment \"yes\"\n"+
+		"  option tuning: bundle \"optimized\", environment \"single\"\n"+
+		"relation haproxy wordpress: missing from environment\n"+
+		"relation wordpress:juju-info logging:info: missing from bundle\n")
+}
+
+func (s *DiffSuite) TestNamedBundles(c *gc.C) {
+	bundles := "wiki:" + indent(matchingBundle) + "\nother:\n  services: {}\n"
+	_, err := s.runDiff(c, bundles)
+	c.Assert(err, gc.ErrorMatches, "bundle file holds several bundles; select one of other, wiki with --bundle")
+	out, err := s.runDiff(c, bundles, "--bundle", "wiki")
+	c.Assert(err, gc.IsNil)
+	c.Assert(out, gc.Equals, "no differences\n")
+	_, err = s.runDiff(c, bundles, "--bundle", "nosuch")
+	c.Assert(err, gc.ErrorMatches, `bundle "nosuch" not found`)
+}
+
+func (s *DiffSuite) TestSingleNamedBundle(c *gc.C) {
+	out, err := s.runDiff(c, "wiki:"+indent(matchingBundle))
+	c.Assert(err, gc.IsNil)
+	c.Assert(out, gc.Equals, "no differences\n")
+}
+
+func (s *DiffSuite) TestCharmMatches(c *gc.C) {
+	for i, test := range []struct {
+		bundle, env string
+		match       bool
+	}{
+		{"cs:precise/mysql-1", "cs:precise/mysql-1", true},
+		{"cs:precise/mysql", "cs:precise/mysql-1", true},
+		{"mysql", "cs:precise/mysql-1", true},
+		{"cs:precise/mysql-2", "cs:precise/mysql-1", false},
+		{"cs:trusty/mysql", "cs:precise/mysql-1", false},
+		{"local:precise/mysql", "cs:precise/mysql-1", false},
+	} {
+		c.Logf("test %d: %s %s", i, test.bundle, test.env)
+		c.Check(charmMatches(test.bundle, test.env), gc.Equals, test.match)
+	}
+}
+
+func (s *DiffSuite) TestRelationMatches(c *gc.C) {
+	env := []string{"wordpress:db", "mysql:server"}
+	c.Check(relationMatches([]string{"wordpress:db", "mysql:server"}, env), jc.IsTrue)
+	c.Check(relationMatches([]string{"mysql", "wordpress"}, env), jc.IsTrue)
+	c.Check(relationMatches([]string{"mysql:server", "wordpress"}, env), jc.IsTrue)
+	c.Check(relationMatches([]string{"mysql:db", "wordpress"}, env), jc.IsFalse)
+	c.Check(relationMatches([]string{"mysql"}, env), jc.IsFalse)
+}
+
+// indent indents each line of the given YAML document
+// so that it can be nested under a key.
+func indent(doc string) string {
+	var out []byte
+	for _, b := range []byte(doc) {
+		out = append(out, b)
+		if b == '\n' {
+			out = append(out, "  "...)
+		}
+	}
+	return string(out)
+}
+
+type mockDiffAPI struct {
+	status   *api.Status
+	services map[string]*params.ServiceGetResults
+}
+
+func (*mockDiffAPI) Close() error {
+	return nil
+}
+
+func (m *mockDiffAPI) Status(patterns []string) (*api.Status, error) {
+	return m.status, nil
+}
+
+func (m *mockDiffAPI) ServiceGet(service string) (*params.ServiceGetResults, error) {
+	return m.services[service], nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/juju/utils/parallel"
+)
+
+// maxConcurrentCalls bounds the number of API calls made at once
+// by the commands that act on many entities.
+const maxConcurrentCalls = 10
+
+// entityResult holds the outcome of an operation on a single entity.
+type entityResult struct {
+	Entity string
+	Error  error
+}
+
+// forEachEntity calls f for each of the given entities, with at most
+// maxConcurrentCalls calls in progress at once. It carries on past
+// failures, and returns the outcome for each entity in the order given.
+func forEachEntity(entities []string, f func(entity string) error) []entityResult {
+	results := make([]entityResult, len(entities))
+	run := parallel.NewRun(maxConcurrentCalls)
+	for i, entity := range entities {
+		i, entity := i, entity
+		run.Do(func() error {
+			results[i] = entityResult{entity, f(entity)}
+			return nil
+		})
+	}
+	run.Wait()
+	return results
+}
+
+// writeEntityResults writes a table of the given results to w, headed
+// by the given kind of entity and describing each success as done.
+// It returns the number of failed operations.
+func writeEntityResults(w io.Writer, kind, done string, results []entityResult) int {
+	failed := 0
+	tw := tabwriter.NewWriter(w, 0, 1, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tRESULT\n", kind)
+	for _, result := range results {
+		if result.Error != nil {
+			fmt.Fprintf(tw, "%s\terror: %v\n", result.Entity, result.Error)
+			failed++
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", result.Entity, done)
+	}
+	tw.Flush()
+	return failed
+}
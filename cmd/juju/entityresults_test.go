@@ -0,0 +1,69 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/testing"
+)
+
+type EntityResultsSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&EntityResultsSuite{})
+
+func (s *EntityResultsSuite) TestForEachEntity(c *gc.C) {
+	var mu sync.Mutex
+	active, maxActive := 0, 0
+	entities := make([]string, 3*maxConcurrentCalls)
+	for i := range entities {
+		entities[i] = fmt.Sprintf("unit/%d", i)
+	}
+	results := forEachEntity(entities, func(entity string) error {
+		mu.Lock()
+		active++
+		if active > maxActive {
+			maxActive = active
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			active--
+			mu.Unlock()
+		}()
+		if entity == "unit/1" {
+			return fmt.Errorf("boom")
+		}
+		return nil
+	})
+	c.Assert(results, gc.HasLen, len(entities))
+	for i, result := range results {
+		c.Check(result.Entity, gc.Equals, entities[i])
+		if i == 1 {
+			c.Check(result.Error, gc.ErrorMatches, "boom")
+		} else {
+			c.Check(result.Error, gc.IsNil)
+		}
+	}
+	c.Assert(maxActive <= maxConcurrentCalls, gc.Equals, true)
+}
+
+func (s *EntityResultsSuite) TestWriteEntityResults(c *gc.C) {
+	var buf bytes.Buffer
+	failed := writeEntityResults(&buf, "UNIT", "resolved", []entityResult{
+		{"mysql/0", nil},
+		{"mysql/10", fmt.Errorf("already resolved")},
+	})
+	c.Assert(failed, gc.Equals, 1)
+	c.Assert(buf.String(), gc.Equals, ""+
+		"UNIT      RESULT\n"+
+		"mysql/0   resolved\n"+
+		"mysql/10  error: already resolved\n")
+}
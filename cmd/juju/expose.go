@@ -5,39 +5,54 @@ package main
 
 import (
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/juju/cmd"
 
 	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/network"
 )
 
 // ExposeCommand is responsible exposing services.
 type ExposeCommand struct {
 	envcmd.EnvCommandBase
 	ServiceName string
+	Ports       []network.Port
 }
 
 var jujuExposeHelp = `
 Adjusts firewall rules and similar security mechanisms of the provider, to
 allow the service to be accessed on its public address.
 
+By default all the ports opened by the service's units are made accessible.
+If ports are given, as <port>[/<protocol>] where the protocol is "tcp" (the
+default) or "udp", only those ports are made accessible, and only while the
+units have them open. Ports given to successive expose commands are added to
+those already exposed.
+
+Examples:
+    juju expose wordpress
+    juju expose wordpress 80 443/tcp
 `
 
 func (c *ExposeCommand) Info() *cmd.Info {
 	return &cmd.Info{
 		Name:    "expose",
-		Args:    "<service>",
+		Args:    "<service> [<port>[/<protocol>] ...]",
 		Purpose: "expose a service",
 		Doc:     jujuExposeHelp,
 	}
 }
 
-func (c *ExposeCommand) Init(args []string) error {
+func (c *ExposeCommand) Init(args []string) (err error) {
 	if len(args) == 0 {
 		return errors.New("no service name specified")
 	}
 	c.ServiceName = args[0]
-	return cmd.CheckEmpty(args[1:])
+	c.Ports, err = parsePorts(args[1:])
+	return err
 }
 
 // Run changes the juju-managed firewall to expose any
@@ -48,5 +63,33 @@ func (c *ExposeCommand) Run(_ *cmd.Context) error {
 		return err
 	}
 	defer client.Close()
+	if len(c.Ports) > 0 {
+		return client.ServiceExposePorts(c.ServiceName, c.Ports)
+	}
 	return client.ServiceExpose(c.ServiceName)
 }
+
+// parsePorts parses port specifications of the
+// form <port>[/<protocol>].
+func parsePorts(args []string) ([]network.Port, error) {
+	var ports []network.Port
+	for _, arg := range args {
+		parts := strings.Split(arg, "/")
+		if len(parts) > 2 {
+			return nil, fmt.Errorf("invalid port %q: expected <port>[/<protocol>]", arg)
+		}
+		number, err := strconv.Atoi(parts[0])
+		if err != nil || number < 1 || number > 65535 {
+			return nil, fmt.Errorf("invalid port %q: port must be in the range [1, 65535]", arg)
+		}
+		protocol := "tcp"
+		if len(parts) == 2 {
+			protocol = strings.ToLower(parts[1])
+			if protocol != "tcp" && protocol != "udp" {
+				return nil, fmt.Errorf(`invalid port %q: protocol must be "tcp" or "udp"`, arg)
+			}
+		}
+		ports = append(ports, network.Port{Protocol: protocol, Number: number})
+	}
+	return ports, nil
+}
@@ -6,10 +6,12 @@ package main
 import (
 	"github.com/juju/charm"
 	charmtesting "github.com/juju/charm/testing"
+	jc "github.com/juju/testing/checkers"
 	gc "launchpad.net/gocheck"
 
 	"github.com/juju/juju/cmd/envcmd"
 	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/network"
 	"github.com/juju/juju/testing"
 )
 
@@ -45,3 +47,35 @@ func (s *ExposeSuite) TestExpose(c *gc.C) {
 	err = runExpose(c, "nonexistent-service")
 	c.Assert(err, gc.ErrorMatches, `service "nonexistent-service" not found`)
 }
+
+func (s *ExposeSuite) TestExposePorts(c *gc.C) {
+	charmtesting.Charms.BundlePath(s.SeriesPath, "dummy")
+	err := runDeploy(c, "local:dummy", "some-service-name")
+	c.Assert(err, gc.IsNil)
+
+	err = runExpose(c, "some-service-name", "443", "53/UDP")
+	c.Assert(err, gc.IsNil)
+	s.assertExposed(c, "some-service-name")
+	svc, err := s.State.Service("some-service-name")
+	c.Assert(err, gc.IsNil)
+	c.Assert(svc.ExposedPorts(), jc.DeepEquals, []network.Port{{"tcp", 443}, {"udp", 53}})
+}
+
+func (s *ExposeSuite) TestParsePorts(c *gc.C) {
+	ports, err := parsePorts([]string{"80", "443/tcp", "53/udp"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(ports, jc.DeepEquals, []network.Port{{"tcp", 80}, {"tcp", 443}, {"udp", 53}})
+
+	for _, test := range []struct {
+		arg, err string
+	}{
+		{"http", `invalid port "http": port must be in the range \[1, 65535\]`},
+		{"0", `invalid port "0": port must be in the range \[1, 65535\]`},
+		{"65536/tcp", `invalid port "65536/tcp": port must be in the range \[1, 65535\]`},
+		{"80/icmp", `invalid port "80/icmp": protocol must be "tcp" or "udp"`},
+		{"80/tcp/udp", `invalid port "80/tcp/udp": expected <port>\[/<protocol>\]`},
+	} {
+		_, err := parsePorts([]string{test.arg})
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/juju/cmd"
+	"launchpad.net/gnuflag"
+
+	jujucmd "github.com/juju/juju/cmd"
+	"github.com/juju/juju/cmd/envcmd"
+)
+
+const findStatusDoc = `
+Searches the statuses of all the machines and units in the environment,
+including the recent statuses they have since left, for the given text.
+The search ignores case, and each machine and unit keeps a history of
+its most recent statuses only.
+
+With --since, only the statuses set within the given duration are shown.
+
+Examples:
+	$ juju find-status "disk full"
+	$ juju find-status --since 2h error
+	$ juju find-status --format yaml "hook failed"
+`
+
+// FindStatusCommand searches the status history of
+// the machines and units in the environment.
+type FindStatusCommand struct {
+	envcmd.EnvCommandBase
+	out   cmd.Output
+	text  string
+	since time.Duration
+}
+
+func (c *FindStatusCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "find-status",
+		Args:    "<text>",
+		Purpose: "search the status history of machines and units",
+		Doc:     findStatusDoc,
+	}
+}
+
+func (c *FindStatusCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.DurationVar(&c.since, "since", 0, "only show statuses set within this duration")
+	c.out.AddFlags(f, "simple", findStatusTable.Formatters(map[string]cmd.Formatter{
+		"yaml":   cmd.FormatYaml,
+		"json":   cmd.FormatJson,
+		"simple": formatFoundStatusSimple,
+	}))
+}
+
+func (c *FindStatusCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no text specified")
+	}
+	c.text, args = args[0], args[1:]
+	if c.since < 0 {
+		return fmt.Errorf("invalid --since duration %v", c.since)
+	}
+	return cmd.CheckEmpty(args)
+}
+
+// foundStatus holds a status shown by the find-status command.
+type foundStatus struct {
+	Entity string    `json:"entity" yaml:"entity"`
+	Status string    `json:"status" yaml:"status"`
+	Info   string    `json:"info,omitempty" yaml:"info,omitempty"`
+	Time   time.Time `json:"time" yaml:"time"`
+}
+
+func (c *FindStatusCommand) Run(ctx *cmd.Context) error {
+	apiclient, err := c.NewAPIClient()
+	if err != nil {
+		return err
+	}
+	defer apiclient.Close()
+	var since time.Time
+	if c.since > 0 {
+		since = time.Now().Add(-c.since)
+	}
+	entries, err := apiclient.FindStatus(c.text, since)
+	if err != nil {
+		return err
+	}
+	found := make([]foundStatus, len(entries))
+	for i, entry := range entries {
+		found[i] = foundStatus{
+			Entity: entry.Entity,
+			Status: string(entry.Status),
+			Info:   entry.Info,
+			Time:   entry.Time,
+		}
+	}
+	return c.out.Write(ctx, found)
+}
+
+// formatFoundStatusSimple returns a tabular summary of the found statuses.
+func formatFoundStatusSimple(value interface{}) ([]byte, error) {
+	found, ok := value.([]foundStatus)
+	if !ok {
+		return nil, fmt.Errorf("expected value of type %T, got %T", found, value)
+	}
+	if len(found) == 0 {
+		return nil, nil
+	}
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 1, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tENTITY\tSTATUS\tINFO")
+	for _, s := range found {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", s.Time.Local().Format("2006-01-02 15:04:05"), s.Entity, s.Status, s.Info)
+	}
+	tw.Flush()
+	// The output is terminated by a newline when written.
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// findStatusTable lays out the found statuses in the tabular
+// and csv formats, with times in UTC for importing elsewhere.
+var findStatusTable = jujucmd.Table{
+	Columns: []string{"TIME", "ENTITY", "STATUS", "INFO"},
+	Rows: func(value interface{}) ([][]string, error) {
+		found, ok := value.([]foundStatus)
+		if !ok {
+			return nil, fmt.Errorf("expected value of type %T, got %T", found, value)
+		}
+		rows := make([][]string, len(found))
+		for i, s := range found {
+			rows[i] = []string{s.Time.UTC().Format(time.RFC3339), s.Entity, s.Status, s.Info}
+		}
+		return rows, nil
+	},
+}
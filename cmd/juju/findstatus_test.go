@@ -0,0 +1,93 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+	"launchpad.net/goyaml"
+
+	"github.com/juju/juju/cmd/envcmd"
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/testing"
+)
+
+type FindStatusSuite struct {
+	jujutesting.RepoSuite
+}
+
+var _ = gc.Suite(&FindStatusSuite{})
+
+func runFindStatus(c *gc.C, args ...string) (string, error) {
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&FindStatusCommand{}), args...)
+	if err != nil {
+		return "", err
+	}
+	return testing.Stdout(ctx), nil
+}
+
+func (s *FindStatusSuite) TestInit(c *gc.C) {
+	_, err := runFindStatus(c)
+	c.Assert(err, gc.ErrorMatches, "no text specified")
+	_, err = runFindStatus(c, "disk", "full")
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["full"\]`)
+	_, err = runFindStatus(c, "--since", "-1h", "disk")
+	c.Assert(err, gc.ErrorMatches, `invalid --since duration -1h0m0s`)
+}
+
+func (s *FindStatusSuite) TestFindStatus(c *gc.C) {
+	m0, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	m1, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = m0.SetStatus(params.StatusError, "Disk full", nil)
+	c.Assert(err, gc.IsNil)
+	err = m0.SetStatus(params.StatusStarted, "", nil)
+	c.Assert(err, gc.IsNil)
+	err = m1.SetStatus(params.StatusError, "network unreachable", nil)
+	c.Assert(err, gc.IsNil)
+
+	out, err := runFindStatus(c, "--format", "yaml", "disk full")
+	c.Assert(err, gc.IsNil)
+	var found []map[string]interface{}
+	err = goyaml.Unmarshal([]byte(out), &found)
+	c.Assert(err, gc.IsNil)
+	c.Assert(found, gc.HasLen, 1)
+	c.Assert(found[0]["entity"], gc.Equals, "machine-0")
+	c.Assert(found[0]["status"], gc.Equals, "error")
+	c.Assert(found[0]["info"], gc.Equals, "Disk full")
+}
+
+func (s *FindStatusSuite) TestFindStatusNothingFound(c *gc.C) {
+	out, err := runFindStatus(c, "--since", "1h", "disk full")
+	c.Assert(err, gc.IsNil)
+	c.Assert(out, gc.Matches, "\n?")
+}
+
+func (s *FindStatusSuite) TestFormatFoundStatusSimple(c *gc.C) {
+	t := time.Date(2014, 6, 1, 12, 30, 0, 0, time.Local)
+	out, err := formatFoundStatusSimple([]foundStatus{
+		{Entity: "unit-mysql-0", Status: "error", Info: "hook failed", Time: t},
+		{Entity: "machine-1", Status: "started", Time: t},
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(out), gc.Equals, ""+
+		"TIME                 ENTITY        STATUS   INFO\n"+
+		"2014-06-01 12:30:00  unit-mysql-0  error    hook failed\n"+
+		"2014-06-01 12:30:00  machine-1     started  ")
+}
+
+func (s *FindStatusSuite) TestFindStatusTable(c *gc.C) {
+	t := time.Date(2014, 6, 1, 12, 30, 0, 0, time.UTC)
+	out, err := findStatusTable.FormatCSV([]foundStatus{
+		{Entity: "unit-mysql-0", Status: "error", Info: "hook failed: config-changed", Time: t},
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(out), gc.Equals, ""+
+		"TIME,ENTITY,STATUS,INFO\n"+
+		"2014-06-01T12:30:00Z,unit-mysql-0,error,hook failed: config-changed")
+}
@@ -5,10 +5,13 @@ package main
 
 import (
 	"errors"
+	"fmt"
+	"sort"
 
 	"github.com/juju/cmd"
 	"launchpad.net/gnuflag"
 
+	jujucmd "github.com/juju/juju/cmd"
 	"github.com/juju/juju/cmd/envcmd"
 )
 
@@ -29,9 +32,9 @@ func (c *GetCommand) Info() *cmd.Info {
 
 func (c *GetCommand) SetFlags(f *gnuflag.FlagSet) {
 	// TODO(dfc) add json formatting ?
-	c.out.AddFlags(f, "yaml", map[string]cmd.Formatter{
+	c.out.AddFlags(f, "yaml", getTable.Formatters(map[string]cmd.Formatter{
 		"yaml": cmd.FormatYaml,
-	})
+	}))
 }
 
 func (c *GetCommand) Init(args []string) error {
@@ -64,3 +67,32 @@ func (c *GetCommand) Run(ctx *cmd.Context) error {
 	}
 	return c.out.Write(ctx, resultsMap)
 }
+
+// getTable lays out the settings of a service,
+// ordered by name, in the tabular and csv formats.
+var getTable = jujucmd.Table{
+	Columns: []string{"OPTION", "TYPE", "DEFAULT", "VALUE"},
+	Rows: func(value interface{}) ([][]string, error) {
+		results, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected value of type %T, got %T", results, value)
+		}
+		settings, _ := results["settings"].(map[string]interface{})
+		names := make([]string, 0, len(settings))
+		for name := range settings {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		rows := make([][]string, len(names))
+		for i, name := range names {
+			info, _ := settings[name].(map[string]interface{})
+			isDefault, _ := info["default"].(bool)
+			optionValue := ""
+			if v, ok := info["value"]; ok && v != nil {
+				optionValue = fmt.Sprint(v)
+			}
+			rows[i] = []string{name, fmt.Sprint(info["type"]), fmt.Sprint(isDefault), optionValue}
+		}
+		return rows, nil
+	},
+}
@@ -86,3 +86,34 @@ func (s *GetSuite) TestGetConfig(c *gc.C) {
 		c.Assert(actual, gc.DeepEquals, expected)
 	}
 }
+
+func (s *GetSuite) TestGetTable(c *gc.C) {
+	out, err := getTable.FormatCSV(map[string]interface{}{
+		"service": "dummy-service",
+		"charm":   "dummy",
+		"settings": map[string]interface{}{
+			"title": map[string]interface{}{
+				"description": "A descriptive title used for the service.",
+				"type":        "string",
+				"value":       "Nearly There",
+			},
+			"outlook": map[string]interface{}{
+				"description": "No default outlook.",
+				"type":        "string",
+				"default":     true,
+			},
+			"skill-level": map[string]interface{}{
+				"description": "A number indicating skill.",
+				"type":        "int",
+				"value":       int64(3),
+				"default":     true,
+			},
+		},
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(out), gc.Equals, ""+
+		"OPTION,TYPE,DEFAULT,VALUE\n"+
+		"outlook,string,true,\n"+
+		"skill-level,int,true,3\n"+
+		"title,string,false,Nearly There")
+}
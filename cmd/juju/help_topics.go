@@ -22,6 +22,7 @@ Basic commands:
   juju help commands    list all commands
   juju help glossary    glossary of terms
   juju help topics      list all help topics
+  juju help --search placement   find commands and topics about placement
 
 Provider information:
   juju help azure-provider       use on Windows Azure
@@ -270,6 +271,15 @@ determine the full list of constraints on the machine(s) to be provisioned by
 the command.  Service-specific constraints will override environment-specific
 constraints, which override the juju default constraints.
 
+The constraints are merged key by key, so a default such as mem or
+instance-type set once on the environment applies to every new machine whose
+service does not set that key.  A key set on a service also discards the
+environment keys it conflicts with: for example instance-type set on a service
+discards mem set on the environment.  A key set to an empty value on a service
+(such as "mem=") clears the environment default for that key.  The merged
+constraints of a service can be viewed with juju get-constraints --effective
+<service>.
+
 Constraints are specified as key value pairs separated by an equals sign, with
 multiple constraints delimited by a space.
 
@@ -313,6 +323,17 @@ container
       lxc - an lxc container
       kvm - a kvm container
 
+container-network
+   Container-network defines how a container machine is networked, overriding
+   the environment's container-network-type setting.  It is ignored for
+   machines which are not containers.  Supported types:
+      bridge - (default) the bridge named by LXC_BRIDGE, or else the host's
+               private container bridge (such as lxcbr0)
+      host-bridge - the bridge carrying the host's default route, sharing the
+               host's network
+      macvlan - a MACVLAN interface on the host's network device (lxc only)
+      ovs - the Open vSwitch bridge named by LXC_BRIDGE (lxc only)
+
 cpu-power
    Cpu-power is a whole number that defines the speed of the machine's CPU,
    where 100 CpuPower is considered to be equivalent to 1 Amazon ECU (or,
@@ -346,6 +367,171 @@ See Also:
    juju help bootstrap
 `
 
+const helpPlacement = `
+Placement directives tell juju where to put a new machine or unit, instead
+of letting it provision a fresh instance. They are given with the --to
+argument of bootstrap, deploy and add-unit, and as the argument of
+add-machine.
+
+A directive is one of:
+
+<machine>
+   An existing machine, such as 23. The unit is deployed alongside any
+   units already on that machine.
+
+<container-type>:<machine>
+   A new container of the given type (lxc or kvm) on an existing machine,
+   such as lxc:25.
+
+<machine>/<container-type>/<container>
+   An existing container, such as 24/lxc/3.
+
+<provider directive>
+   A directive understood by the environment's provider, such as
+   zone=us-east-1a on EC2, or a node name such as maas-node-7.local
+   on MaaS. Provider directives are accepted by bootstrap and
+   add-machine only.
+
+Placement takes precedence over constraints: a machine chosen with --to is
+used even if it does not satisfy the service's constraints. Units of
+services deployed with --to share the machine with anything already on it,
+so charms that are not designed to be co-located may conflict.
+
+Examples:
+
+   juju deploy mysql --to 23
+   juju add-unit mysql --to lxc:25
+   juju add-machine zone=us-east-1c
+
+See Also:
+   juju help constraints
+   juju help deploy
+   juju help add-unit
+   juju help add-machine
+`
+
+const helpNetworks = `
+Juju gives names to the networks available in an environment, such as those
+of a MaaS cluster, so that services can be bound to them. Networks are used
+in two ways:
+
+As constraints, networks select the machines that may host a service's units.
+The networks constraint takes a comma-delimited list of network names; names
+with a "^" prefix must not be available on the machine:
+
+   juju deploy mysql --constraints networks=db,^logging
+
+With the --networks argument of deploy, the named networks are also
+configured on every new machine that hosts units of the service:
+
+   juju deploy mysql --networks storage,db
+
+The networks configured on a machine are shown by juju status. Networks are
+not supported by all providers; on providers without network support, these
+arguments cause an error.
+
+See Also:
+   juju help constraints
+   juju help deploy
+`
+
+const helpAuthentication = `
+Every juju client connects to the environment's API server as a juju user,
+with the credentials recorded in the environment's .jenv file in
+$JUJU_HOME/environments. When an environment is bootstrapped, the "admin"
+user is created, with the admin-secret from environments.yaml as its
+password (a random password is generated if admin-secret is not set).
+
+Further users may be added with "juju user add", which prints the new user's
+password and can write a .jenv file that the new user can copy into their
+own $JUJU_HOME/environments to connect. Passwords are changed with
+"juju user change-password", and users are disabled with "juju remove-user".
+
+Users can also be authenticated against an LDAP directory, by setting
+auth-backend to "ldap" in the environment configuration. Users are looked
+up by binding to ldap-url with the distinguished name given by ldap-user-dn,
+in which %s is replaced by the user name, for example:
+
+    auth-backend: ldap
+    ldap-url: ldaps://ldap.example.com
+    ldap-user-dn: uid=%s,ou=people,dc=example,dc=com
+    ldap-group-base: ou=groups,dc=example,dc=com
+    ldap-admin-group: juju-admins
+    ldap-read-only-group: juju-viewers
+
+Members of ldap-admin-group have full access to the environment, and members
+of ldap-read-only-group may only look at it, with commands such as status.
+If ldap-group-base is not set, every LDAP user has full access. LDAP users
+log in with their LDAP name prefixed by "ldap+", for example "ldap+alice",
+so that they can never be taken for users held by juju itself, such as
+"admin". They are recorded as juju users when they first log in, so that
+they can be disabled with "juju remove-user".
+
+Access to the machines of the environment over ssh is separate: it is granted
+to the keys given by authorized-keys or authorized-keys-path in the environment
+configuration, and managed with the authorized-keys commands.
+
+See Also:
+   juju help user
+   juju help authorized-keys
+   juju help remove-user
+`
+
+const helpWebhooks = `
+The state servers of an environment can post its events to webhooks, so that
+external systems such as chat bots and monitoring dashboards are told of them
+as they happen. The webhooks are set with webhook-urls in the environment
+configuration, as a list of http or https URLs separated by commas:
+
+    juju set-env webhook-urls=https://hooks.example.com/juju
+
+Each event is posted to every URL as a JSON document such as:
+
+    {
+      "kind": "hook-failed",
+      "environment": "3d2f4c0e-...",
+      "entity": "unit-wordpress-0",
+      "info": "hook failed: \"config-changed\"",
+      "time": "2014-10-15T12:00:00Z"
+    }
+
+The kinds of events are:
+
+    unit-started          a unit's agent reports that it has started
+    hook-failed           a unit's hook has failed
+    machine-provisioned   an instance has been started for a machine
+    upgrade-complete      every machine agent runs the new agent-version,
+                          which is given in "info"
+
+Events that cannot be posted are retried for a minute, and then dropped.
+If webhook-secret is set, every request carries an X-Juju-Signature header
+holding "sha256=" followed by the hex encoded HMAC-SHA256 of the request
+body, keyed by the secret, so that the webhook can check that the event
+comes from the environment.
+
+See Also:
+   juju help set-env
+`
+
+const helpAliases = `
+Frequently used commands can be shortened by defining aliases for them in
+the aliases section of $JUJU_HOME/config (~/.juju/config by default):
+
+    [aliases]
+    st = "status --format short"
+    dbg = debug-log --replay --include-module juju.worker
+
+An alias is used in place of a command name, and any further arguments
+are passed on after the words of the alias, so that
+
+    juju st wordpress
+
+runs "juju status --format short wordpress". Aliases are only expanded
+once, so an alias may have the same name as the command it runs, in order
+to change its default flags. Words holding spaces may be quoted within
+the alias.
+`
+
 const helpGlossary = `
 Bootstrap
   To boostrap an environment means initializing it so that Services may be
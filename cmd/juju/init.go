@@ -12,17 +12,35 @@ import (
 	"github.com/juju/juju/environs"
 )
 
+const initDoc = `
+Writes a boilerplate environments.yaml file, holding a sample environment
+for each provider. Every setting supported by a provider is shown, with
+its default value and an explanation, so that the file can be edited to
+configure an environment before running bootstrap.
+
+With --provider, only the sample environment of the given provider is
+printed to stdout, indented so that it can be appended to the
+environments of an existing environments.yaml file.
+
+Examples:
+    juju generate-config
+    juju generate-config --show
+    juju generate-config --provider maas >> ~/.juju/environments.yaml
+`
+
 // InitCommand is used to write out a boilerplate environments.yaml file.
 type InitCommand struct {
 	cmd.CommandBase
 	WriteFile bool
 	Show      bool
+	Provider  string
 }
 
 func (c *InitCommand) Info() *cmd.Info {
 	return &cmd.Info{
 		Name:    "init",
 		Purpose: "generate boilerplate configuration for juju environments",
+		Doc:     initDoc,
 		Aliases: []string{"generate-config"},
 	}
 }
@@ -30,6 +48,7 @@ func (c *InitCommand) Info() *cmd.Info {
 func (c *InitCommand) SetFlags(f *gnuflag.FlagSet) {
 	f.BoolVar(&c.WriteFile, "f", false, "force overwriting environments.yaml file even if it exists (ignored if --show flag specified)")
 	f.BoolVar(&c.Show, "show", false, "print the generated configuration data to stdout instead of writing it to a file")
+	f.StringVar(&c.Provider, "provider", "", "print the sample configuration of the given provider only")
 }
 
 var errJujuEnvExists = fmt.Errorf(`A juju environment configuration already exists.
@@ -41,6 +60,14 @@ Use -f to overwrite the existing environments.yaml.
 // a boilerplate version is created so that the user can edit it to get started.
 func (c *InitCommand) Run(context *cmd.Context) error {
 	out := context.Stdout
+	if c.Provider != "" {
+		config, err := environs.ProviderBoilerplateConfig(c.Provider)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(out, config)
+		return nil
+	}
 	config := environs.BoilerplateConfig()
 	if c.Show {
 		fmt.Fprint(out, config)
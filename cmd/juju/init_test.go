@@ -11,8 +11,10 @@ import (
 
 	"github.com/juju/cmd"
 	gitjujutesting "github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
 	gc "launchpad.net/gocheck"
 
+	"github.com/juju/juju/environs"
 	"github.com/juju/juju/testing"
 )
 
@@ -100,3 +102,31 @@ func (*InitSuite) TestExistingEnvironmentOverwritten(c *gc.C) {
 	strippedData := strings.Replace(string(data), "\n", "", -1)
 	c.Assert(strippedData, gc.Matches, ".*# This is the Juju config file, which you can use.*")
 }
+
+// With --provider, only the sample environment of that provider
+// is printed, ready to be appended to an existing environments.yaml.
+func (*InitSuite) TestProviderBoilerPlatePrinted(c *gc.C) {
+	testing.WriteEnvironments(c, existingEnv)
+
+	ctx, err := testing.RunCommand(c, &InitCommand{}, "--provider", "ec2")
+	c.Assert(err, gc.IsNil)
+	out := testing.Stdout(ctx)
+	c.Assert(out, gc.Matches, `(?s)    # https://juju.ubuntu.com/docs/config-aws.html\n    amazon:\n        type: ec2\n.*`)
+	c.Assert(out, gc.Matches, `(?s).*\n        # api-port: 17070\n.*`)
+	c.Assert(strings.Count(out, "type:"), gc.Equals, 1)
+
+	envs, err := environs.ReadEnvironsBytes([]byte(existingEnv + out))
+	c.Assert(err, gc.IsNil)
+	c.Assert(envs.Names(), jc.SameContents, []string{"test", "amazon"})
+
+	// The environments.yaml is left untouched.
+	environpath := gitjujutesting.HomePath(".juju", "environments.yaml")
+	data, err := ioutil.ReadFile(environpath)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(data), gc.Equals, existingEnv)
+}
+
+func (*InitSuite) TestUnknownProvider(c *gc.C) {
+	_, err := testing.RunCommand(c, &InitCommand{}, "--provider", "nowhere")
+	c.Assert(err, gc.ErrorMatches, `no registered provider for "nowhere"`)
+}
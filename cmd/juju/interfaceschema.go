@@ -0,0 +1,110 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/juju/cmd"
+	"launchpad.net/gnuflag"
+	"launchpad.net/goyaml"
+
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/state/api/params"
+)
+
+const setInterfaceSchemaDoc = `
+Registers a schema for the relation settings of a relation interface. Once
+registered, any relation-set performed by a unit on a relation over the
+interface is rejected if a declared setting has a value of the wrong type.
+If the schema is strict, settings it does not declare are rejected too.
+Settings juju writes itself, such as private-address, are always allowed.
+
+The schema is read from a YAML file of the form:
+
+    strict: true
+    fields:
+      host: string
+      port: int
+      ratio: float
+      slave: bool
+
+Registering a schema for an interface replaces any existing schema. Use
+--remove to stop validating the interface's settings.
+
+Charms may also declare schemas for their interfaces, in the same form,
+under interface-schemas in metadata.yaml. Settings written by a charm's
+units must conform to both the charm's schema and the registered one.
+
+Examples:
+    juju set-interface-schema mysql mysql-schema.yaml
+    juju set-interface-schema mysql --remove
+`
+
+// SetInterfaceSchemaCommand registers or removes the schema of
+// a relation interface.
+type SetInterfaceSchemaCommand struct {
+	envcmd.EnvCommandBase
+	Interface string
+	Schema    cmd.FileVar
+	Remove    bool
+}
+
+func (c *SetInterfaceSchemaCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "set-interface-schema",
+		Args:    "<interface> [<schema file>]",
+		Purpose: "validate relation settings of an interface against a schema",
+		Doc:     setInterfaceSchemaDoc,
+	}
+}
+
+func (c *SetInterfaceSchemaCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.BoolVar(&c.Remove, "remove", false, "remove the interface's schema")
+}
+
+func (c *SetInterfaceSchemaCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no interface specified")
+	}
+	c.Interface, args = args[0], args[1:]
+	if c.Remove {
+		return cmd.CheckEmpty(args)
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("no schema file specified")
+	}
+	c.Schema.Path, args = args[0], args[1:]
+	return cmd.CheckEmpty(args)
+}
+
+// interfaceSchemaFile holds the contents of a schema file.
+type interfaceSchemaFile struct {
+	Strict bool              `yaml:"strict"`
+	Fields map[string]string `yaml:"fields"`
+}
+
+func (c *SetInterfaceSchemaCommand) Run(ctx *cmd.Context) error {
+	client, err := c.NewAPIClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if c.Remove {
+		return client.RemoveInterfaceSchema(c.Interface)
+	}
+	data, err := c.Schema.Read(ctx)
+	if err != nil {
+		return err
+	}
+	var schema interfaceSchemaFile
+	if err := goyaml.Unmarshal(data, &schema); err != nil {
+		return fmt.Errorf("cannot parse schema file: %v", err)
+	}
+	return client.SetInterfaceSchema(params.InterfaceSchema{
+		Interface: c.Interface,
+		Fields:    schema.Fields,
+		Strict:    schema.Strict,
+	})
+}
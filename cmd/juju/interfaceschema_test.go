@@ -0,0 +1,73 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/interfaceschema"
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/testing"
+)
+
+type SetInterfaceSchemaSuite struct {
+	jujutesting.RepoSuite
+}
+
+var _ = gc.Suite(&SetInterfaceSchemaSuite{})
+
+func runSetInterfaceSchema(c *gc.C, args ...string) error {
+	_, err := testing.RunCommand(c, envcmd.Wrap(&SetInterfaceSchemaCommand{}), args...)
+	return err
+}
+
+func (s *SetInterfaceSchemaSuite) TestInit(c *gc.C) {
+	for i, test := range []struct {
+		args []string
+		err  string
+	}{{
+		args: nil,
+		err:  "no interface specified",
+	}, {
+		args: []string{"mysql"},
+		err:  "no schema file specified",
+	}, {
+		args: []string{"mysql", "schema.yaml", "extra"},
+		err:  `unrecognized args: \["extra"\]`,
+	}, {
+		args: []string{"mysql", "--remove", "schema.yaml"},
+		err:  `unrecognized args: \["schema.yaml"\]`,
+	}} {
+		c.Logf("test %d: %v", i, test.args)
+		err := testing.InitCommand(envcmd.Wrap(&SetInterfaceSchemaCommand{}), test.args)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
+
+func (s *SetInterfaceSchemaSuite) TestSetAndRemoveSchema(c *gc.C) {
+	path := filepath.Join(c.MkDir(), "schema.yaml")
+	err := ioutil.WriteFile(path, []byte("strict: true\nfields:\n  port: int\n"), 0644)
+	c.Assert(err, gc.IsNil)
+
+	err = runSetInterfaceSchema(c, "mysql", path)
+	c.Assert(err, gc.IsNil)
+	schema, err := s.State.InterfaceSchema("mysql")
+	c.Assert(err, gc.IsNil)
+	c.Assert(*schema, jc.DeepEquals, interfaceschema.Schema{
+		Interface: "mysql",
+		Fields:    map[string]interfaceschema.FieldType{"port": interfaceschema.Int},
+		Strict:    true,
+	})
+
+	err = runSetInterfaceSchema(c, "mysql", "--remove")
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.InterfaceSchema("mysql")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
@@ -0,0 +1,218 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/juju/cmd"
+	"launchpad.net/gnuflag"
+
+	jujucmd "github.com/juju/juju/cmd"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/state/api"
+	"github.com/juju/juju/state/api/params"
+)
+
+const machinesDoc = `
+Lists the machines in the environment, including containers.
+
+With --utilization, the most recent resource usage sampled by each
+machine agent is shown, along with the average CPU usage over all
+retained samples. Machine agents sample their resource usage every
+few minutes, so newly started machines may not have any data yet.
+
+Examples:
+	$ juju machines
+	$ juju machines --utilization
+	$ juju machines --utilization --format yaml
+`
+
+// MachinesCommand lists the machines in the environment.
+type MachinesCommand struct {
+	envcmd.EnvCommandBase
+	out         cmd.Output
+	utilization bool
+}
+
+func (c *MachinesCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "machines",
+		Purpose: "list the machines in the environment",
+		Doc:     machinesDoc,
+	}
+}
+
+func (c *MachinesCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.BoolVar(&c.utilization, "utilization", false, "show resource utilization of the machines")
+	c.out.AddFlags(f, "simple", machinesTable.Formatters(map[string]cmd.Formatter{
+		"yaml":   cmd.FormatYaml,
+		"json":   cmd.FormatJson,
+		"simple": formatMachinesSimple,
+	}))
+}
+
+func (c *MachinesCommand) Init(args []string) error {
+	return cmd.CheckEmpty(args)
+}
+
+// machineInfo holds the details of a machine shown by the
+// machines command.
+type machineInfo struct {
+	Id          string           `json:"id" yaml:"id"`
+	AgentState  string           `json:"agent-state,omitempty" yaml:"agent-state,omitempty"`
+	InstanceId  string           `json:"instance-id,omitempty" yaml:"instance-id,omitempty"`
+	Series      string           `json:"series,omitempty" yaml:"series,omitempty"`
+	Utilization *utilizationInfo `json:"utilization,omitempty" yaml:"utilization,omitempty"`
+}
+
+// utilizationInfo summarises the utilization samples of a machine.
+type utilizationInfo struct {
+	Samples       int     `json:"samples" yaml:"samples"`
+	CPUPercent    float64 `json:"cpu-percent" yaml:"cpu-percent"`
+	AvgCPUPercent float64 `json:"avg-cpu-percent" yaml:"avg-cpu-percent"`
+	MemUsed       uint64  `json:"mem-used" yaml:"mem-used"`
+	MemTotal      uint64  `json:"mem-total" yaml:"mem-total"`
+	DiskUsed      uint64  `json:"disk-used" yaml:"disk-used"`
+	DiskTotal     uint64  `json:"disk-total" yaml:"disk-total"`
+}
+
+func (c *MachinesCommand) Run(ctx *cmd.Context) error {
+	apiclient, err := c.NewAPIClient()
+	if err != nil {
+		return err
+	}
+	defer apiclient.Close()
+	status, err := apiclient.Status(nil)
+	if err != nil {
+		return err
+	}
+	machines := flattenMachines(status.Machines)
+	if c.utilization && len(machines) > 0 {
+		ids := make([]string, len(machines))
+		for i, m := range machines {
+			ids[i] = m.Id
+		}
+		results, err := apiclient.MachineUtilization(ids...)
+		if err != nil {
+			return err
+		}
+		if len(results) != len(ids) {
+			return fmt.Errorf("expected %d results, got %d", len(ids), len(results))
+		}
+		for i, result := range results {
+			if result.Error != nil {
+				logger.Warningf("cannot get utilization of machine %s: %v", ids[i], result.Error)
+				continue
+			}
+			machines[i].Utilization = summariseUtilization(result.Samples)
+		}
+	}
+	return c.out.Write(ctx, machines)
+}
+
+// flattenMachines returns the details of all the given machines and
+// their containers, ordered by machine id.
+func flattenMachines(machines map[string]api.MachineStatus) []*machineInfo {
+	var result []*machineInfo
+	for _, id := range sortedMachineIds(machines) {
+		m := machines[id]
+		result = append(result, &machineInfo{
+			Id:         id,
+			AgentState: string(m.Agent.Status),
+			InstanceId: string(m.InstanceId),
+			Series:     m.Series,
+		})
+		result = append(result, flattenMachines(m.Containers)...)
+	}
+	return result
+}
+
+// summariseUtilization returns the latest sample and the average
+// CPU usage of the given samples, which must be ordered oldest first.
+func summariseUtilization(samples []params.UtilizationSample) *utilizationInfo {
+	if len(samples) == 0 {
+		return nil
+	}
+	latest := samples[len(samples)-1]
+	var cpu float64
+	for _, sample := range samples {
+		cpu += sample.CPUPercent
+	}
+	return &utilizationInfo{
+		Samples:       len(samples),
+		CPUPercent:    latest.CPUPercent,
+		AvgCPUPercent: cpu / float64(len(samples)),
+		MemUsed:       latest.MemUsed,
+		MemTotal:      latest.MemTotal,
+		DiskUsed:      latest.DiskUsed,
+		DiskTotal:     latest.DiskTotal,
+	}
+}
+
+// formatMachinesSimple returns a tabular summary of the machines.
+func formatMachinesSimple(value interface{}) ([]byte, error) {
+	machines, ok := value.([]*machineInfo)
+	if !ok {
+		return nil, fmt.Errorf("expected value of type %T, got %T", machines, value)
+	}
+	withUtilization := false
+	for _, m := range machines {
+		if m.Utilization != nil {
+			withUtilization = true
+			break
+		}
+	}
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 1, 2, ' ', 0)
+	if withUtilization {
+		fmt.Fprintln(tw, "MACHINE\tSTATE\tINSTANCE\tSERIES\tCPU\tAVG-CPU\tMEM\tDISK")
+	} else {
+		fmt.Fprintln(tw, "MACHINE\tSTATE\tINSTANCE\tSERIES")
+	}
+	for _, m := range machines {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s", m.Id, m.AgentState, m.InstanceId, m.Series)
+		if u := m.Utilization; u != nil {
+			fmt.Fprintf(tw, "\t%.1f%%\t%.1f%%\t%d/%dM\t%d/%dM",
+				u.CPUPercent, u.AvgCPUPercent, u.MemUsed, u.MemTotal, u.DiskUsed, u.DiskTotal)
+		} else if withUtilization {
+			fmt.Fprint(tw, "\t-\t-\t-\t-")
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+	// The output is terminated by a newline when written.
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// machinesTable lays out the machines in the tabular and csv formats,
+// which always have the utilization columns, left empty for machines
+// without utilization data.
+var machinesTable = jujucmd.Table{
+	Columns: []string{"MACHINE", "STATE", "INSTANCE", "SERIES", "CPU", "AVG-CPU", "MEM-USED", "MEM-TOTAL", "DISK-USED", "DISK-TOTAL"},
+	Rows: func(value interface{}) ([][]string, error) {
+		machines, ok := value.([]*machineInfo)
+		if !ok {
+			return nil, fmt.Errorf("expected value of type %T, got %T", machines, value)
+		}
+		rows := make([][]string, len(machines))
+		for i, m := range machines {
+			row := []string{m.Id, m.AgentState, m.InstanceId, m.Series, "", "", "", "", "", ""}
+			if u := m.Utilization; u != nil {
+				copy(row[4:], []string{
+					fmt.Sprintf("%.1f", u.CPUPercent),
+					fmt.Sprintf("%.1f", u.AvgCPUPercent),
+					fmt.Sprint(u.MemUsed),
+					fmt.Sprint(u.MemTotal),
+					fmt.Sprint(u.DiskUsed),
+					fmt.Sprint(u.DiskTotal),
+				})
+			}
+			rows[i] = row
+		}
+		return rows, nil
+	},
+}
@@ -0,0 +1,123 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/instance"
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/testing"
+)
+
+type MachinesSuite struct {
+	jujutesting.RepoSuite
+}
+
+var _ = gc.Suite(&MachinesSuite{})
+
+func runMachines(c *gc.C, args ...string) (string, error) {
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&MachinesCommand{}), args...)
+	if err != nil {
+		return "", err
+	}
+	return testing.Stdout(ctx), nil
+}
+
+func (s *MachinesSuite) TestInit(c *gc.C) {
+	_, err := testing.RunCommand(c, envcmd.Wrap(&MachinesCommand{}), "extra")
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["extra"\]`)
+}
+
+func (s *MachinesSuite) TestListMachinesAndContainers(c *gc.C) {
+	m0, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.AddMachineInsideMachine(state.MachineTemplate{
+		Series: "quantal",
+		Jobs:   []state.MachineJob{state.JobHostUnits},
+	}, m0.Id(), instance.LXC)
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.AddMachine("precise", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	out, err := runMachines(c)
+	c.Assert(err, gc.IsNil)
+	c.Assert(out, gc.Equals, ""+
+		"MACHINE  STATE    INSTANCE  SERIES\n"+
+		"0        pending            quantal\n"+
+		"0/lxc/0  pending            quantal\n"+
+		"1        pending            precise\n")
+}
+
+func (s *MachinesSuite) TestUtilization(c *gc.C) {
+	m0, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	now := time.Now()
+	for i, cpu := range []float64{10, 30} {
+		err = m0.RecordUtilization(state.UtilizationSample{
+			Time:       now.Add(time.Duration(i) * time.Minute),
+			CPUPercent: cpu,
+			MemUsed:    512,
+			MemTotal:   2048,
+			DiskUsed:   1024,
+			DiskTotal:  8192,
+		})
+		c.Assert(err, gc.IsNil)
+	}
+
+	out, err := runMachines(c, "--utilization")
+	c.Assert(err, gc.IsNil)
+	c.Assert(out, gc.Equals, ""+
+		"MACHINE  STATE    INSTANCE  SERIES   CPU    AVG-CPU  MEM        DISK\n"+
+		"0        pending            quantal  30.0%  20.0%    512/2048M  1024/8192M\n"+
+		"1        pending            quantal  -      -        -          -\n")
+}
+
+func (s *MachinesSuite) TestSummariseUtilization(c *gc.C) {
+	c.Assert(summariseUtilization(nil), gc.IsNil)
+	info := summariseUtilization([]params.UtilizationSample{
+		{CPUPercent: 50, MemUsed: 1, MemTotal: 2},
+		{CPUPercent: 100, MemUsed: 3, MemTotal: 4},
+	})
+	c.Assert(info, gc.DeepEquals, &utilizationInfo{
+		Samples:       2,
+		CPUPercent:    100,
+		AvgCPUPercent: 75,
+		MemUsed:       3,
+		MemTotal:      4,
+	})
+}
+
+func (s *MachinesSuite) TestMachinesTable(c *gc.C) {
+	out, err := machinesTable.FormatCSV([]*machineInfo{{
+		Id:         "0",
+		AgentState: "started",
+		InstanceId: "i-0",
+		Series:     "trusty",
+		Utilization: &utilizationInfo{
+			Samples:       2,
+			CPUPercent:    30,
+			AvgCPUPercent: 20,
+			MemUsed:       512,
+			MemTotal:      2048,
+			DiskUsed:      1024,
+			DiskTotal:     8192,
+		},
+	}, {
+		Id:     "0/lxc/0",
+		Series: "precise",
+	}})
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(out), gc.Equals, ""+
+		"MACHINE,STATE,INSTANCE,SERIES,CPU,AVG-CPU,MEM-USED,MEM-TOTAL,DISK-USED,DISK-TOTAL\n"+
+		"0,started,i-0,trusty,30.0,20.0,512,2048,1024,8192\n"+
+		"0/lxc/0,,,precise,,,,,,")
+}
@@ -51,12 +51,13 @@ func Main(args []string) {
 		os.Stdout.Write(x[2:])
 		os.Exit(0)
 	}
-	jcmd := jujucmd.NewSuperCommand(cmd.SuperCommandParams{
+	jcmd := jujucmd.NewSuperCommandWithoutVersion(cmd.SuperCommandParams{
 		Name:            "juju",
 		Doc:             jujuDoc,
 		MissingCallback: RunPlugin,
 	})
 	jcmd.AddHelpTopic("basics", "Basic commands", helpBasics)
+	jcmd.AddHelpTopic("aliases", "How to define aliases for commands", helpAliases)
 	jcmd.AddHelpTopic("local-provider", "How to configure a local (LXC) provider",
 		helpProviderStart+helpLocalProvider+helpProviderEnd)
 	jcmd.AddHelpTopic("openstack-provider", "How to configure an OpenStack provider",
@@ -68,13 +69,22 @@ func Main(args []string) {
 	jcmd.AddHelpTopic("azure-provider", "How to configure a Windows Azure provider",
 		helpProviderStart+helpAzureProvider+helpProviderEnd, "azure")
 	jcmd.AddHelpTopic("constraints", "How to use commands with constraints", helpConstraints)
+	jcmd.AddHelpTopic("placement", "How to place machines and units with --to", helpPlacement)
+	jcmd.AddHelpTopic("networks", "How to bind services to networks", helpNetworks)
+	jcmd.AddHelpTopic("authentication", "How users and keys are authenticated", helpAuthentication)
 	jcmd.AddHelpTopic("glossary", "Glossary of terms", helpGlossary)
 	jcmd.AddHelpTopic("logging", "How Juju handles logging", helpLogging)
+	jcmd.AddHelpTopic("webhooks", "How environment events are posted to webhooks", helpWebhooks)
 
 	jcmd.AddHelpTopicCallback("plugins", "Show Juju plugins", PluginHelpTopic)
 
 	registerCommands(jcmd, ctx)
-	os.Exit(cmd.Main(jcmd, ctx, args[1:]))
+	cmdArgs, err := expandAliases(args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(2)
+	}
+	os.Exit(cmd.Main(jcmd, ctx, cmdArgs))
 }
 
 type commandRegistry interface {
@@ -94,17 +104,26 @@ func registerCommands(r commandRegistry, ctx *cmd.Context) {
 	r.Register(wrapEnvCommand(&DeployCommand{}))
 	r.Register(wrapEnvCommand(&AddRelationCommand{}))
 	r.Register(wrapEnvCommand(&AddUnitCommand{}))
+	r.Register(wrapEnvCommand(&CloneEnvironmentCommand{}))
 
 	// Destruction commands.
 	r.Register(wrapEnvCommand(&RemoveMachineCommand{}))
 	r.Register(wrapEnvCommand(&RemoveRelationCommand{}))
 	r.Register(wrapEnvCommand(&RemoveServiceCommand{}))
 	r.Register(wrapEnvCommand(&RemoveUnitCommand{}))
+	r.Register(wrapEnvCommand(&CleanUnusedCommand{}))
 	r.Register(&DestroyEnvironmentCommand{})
 
 	// Reporting commands.
 	r.Register(wrapEnvCommand(&StatusCommand{}))
+	r.Register(wrapEnvCommand(&MachinesCommand{}))
+	r.Register(wrapEnvCommand(&FindStatusCommand{}))
+	r.Register(wrapEnvCommand(&TopCommand{}))
+	r.Register(wrapEnvCommand(&DiffCommand{}))
+	r.Register(wrapEnvCommand(&WaitCommand{}))
 	r.Register(&SwitchCommand{})
+	r.Register(&VersionCommand{})
+	r.Register(&ValidateConfigCommand{})
 	r.Register(wrapEnvCommand(&EndpointCommand{}))
 
 	// Error resolution and debugging commands.
@@ -115,10 +134,12 @@ func registerCommands(r commandRegistry, ctx *cmd.Context) {
 	r.Register(wrapEnvCommand(&DebugLogCommand{}))
 	r.Register(wrapEnvCommand(&DebugHooksCommand{}))
 	r.Register(wrapEnvCommand(&RetryProvisioningCommand{}))
+	r.Register(wrapEnvCommand(&CachedImagesCommand{}))
 
 	// Configuration commands.
 	r.Register(&InitCommand{})
 	r.Register(wrapEnvCommand(&GetCommand{}))
+	r.Register(&CharmConfigCommand{})
 	r.Register(wrapEnvCommand(&SetCommand{}))
 	r.Register(wrapEnvCommand(&UnsetCommand{}))
 	r.Register(wrapEnvCommand(&GetConstraintsCommand{}))
@@ -126,6 +147,9 @@ func registerCommands(r commandRegistry, ctx *cmd.Context) {
 	r.Register(wrapEnvCommand(&GetEnvironmentCommand{}))
 	r.Register(wrapEnvCommand(&SetEnvironmentCommand{}))
 	r.Register(wrapEnvCommand(&UnsetEnvironmentCommand{}))
+	r.Register(wrapEnvCommand(&SetInterfaceSchemaCommand{}))
+	r.Register(wrapEnvCommand(&AnnotateCommand{}))
+	r.Register(wrapEnvCommand(&AnnotationsCommand{}))
 	r.Register(wrapEnvCommand(&ExposeCommand{}))
 	r.Register(wrapEnvCommand(&SyncToolsCommand{}))
 	r.Register(wrapEnvCommand(&UnexposeCommand{}))
@@ -146,6 +170,10 @@ func registerCommands(r commandRegistry, ctx *cmd.Context) {
 
 	// Manage state server availability.
 	r.Register(wrapEnvCommand(&EnsureAvailabilityCommand{}))
+
+	// Protect the environment from accidental changes.
+	r.Register(wrapEnvCommand(&BlockCommand{}))
+	r.Register(wrapEnvCommand(&UnblockCommand{}))
 }
 
 // envCmdWrapper is a struct that wraps an environment command and lets us handle
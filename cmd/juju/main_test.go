@@ -190,10 +190,17 @@ var commandNames = []string{
 	"add-machine",
 	"add-relation",
 	"add-unit",
+	"annotate",
+	"annotations",
 	"api-endpoints",
 	"authorised-keys", // alias for authorized-keys
 	"authorized-keys",
+	"block",
 	"bootstrap",
+	"cached-images",
+	"charm-config",
+	"clean-unused",
+	"clone-environment",
 	"debug-hooks",
 	"debug-log",
 	"deploy",
@@ -202,9 +209,11 @@ var commandNames = []string{
 	"destroy-relation",
 	"destroy-service",
 	"destroy-unit",
+	"diff",
 	"ensure-availability",
 	"env", // alias for switch
 	"expose",
+	"find-status",
 	"generate-config", // alias for init
 	"get",
 	"get-constraints",
@@ -213,6 +222,7 @@ var commandNames = []string{
 	"help",
 	"help-tool",
 	"init",
+	"machines",
 	"publish",
 	"remove-machine",  // alias for destroy-machine
 	"remove-relation", // alias for destroy-relation
@@ -226,12 +236,15 @@ var commandNames = []string{
 	"set-constraints",
 	"set-env", // alias for set-environment
 	"set-environment",
+	"set-interface-schema",
 	"ssh",
 	"stat", // alias for status
 	"status",
 	"switch",
 	"sync-tools",
 	"terminate-machine", // alias for destroy-machine
+	"top",
+	"unblock",
 	"unexpose",
 	"unset",
 	"unset-env", // alias for unset-environment
@@ -239,7 +252,9 @@ var commandNames = []string{
 	"upgrade-charm",
 	"upgrade-juju",
 	"user",
+	"validate-config",
 	"version",
+	"wait",
 }
 
 func (s *MainSuite) TestHelpCommands(c *gc.C) {
@@ -261,6 +276,8 @@ func (s *MainSuite) TestHelpCommands(c *gc.C) {
 }
 
 var topicNames = []string{
+	"aliases",
+	"authentication",
 	"azure-provider",
 	"basics",
 	"commands",
@@ -271,9 +288,12 @@ var topicNames = []string{
 	"hpcloud-provider",
 	"local-provider",
 	"logging",
+	"networks",
 	"openstack-provider",
+	"placement",
 	"plugins",
 	"topics",
+	"webhooks",
 }
 
 func (s *MainSuite) TestHelpTopics(c *gc.C) {
@@ -294,6 +314,23 @@ func (s *MainSuite) TestHelpTopics(c *gc.C) {
 	c.Assert(names, gc.DeepEquals, topicNames)
 }
 
+func (s *MainSuite) TestHelpSearch(c *gc.C) {
+	defer osenv.SetJujuHome(osenv.SetJujuHome(c.MkDir()))
+	out := badrun(c, 0, "help", "--search", "PLACEMENT", "directive")
+	c.Assert(out, gc.Matches, `Commands matching "PLACEMENT directive":
+    clone-environment +.*
+
+Topics matching "PLACEMENT directive":
+    placement +How to place machines and units with --to
+`)
+
+	out = badrun(c, 0, "help", "--search", "no-such-keyword")
+	c.Assert(out, gc.Equals, "no commands or topics match \"no-such-keyword\"\n")
+
+	out = badrun(c, 2, "help", "--search")
+	c.Assert(out, gc.Equals, "error: no search terms specified\n")
+}
+
 var globalFlags = []string{
 	"--debug .*",
 	"--description .*",
@@ -5,12 +5,15 @@ package main
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/juju/cmd"
 	"github.com/juju/names"
 	"launchpad.net/gnuflag"
 
 	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/state/api"
 )
 
 // RemoveMachineCommand causes an existing machine to be destroyed.
@@ -26,6 +29,9 @@ running units or containers can only be removed with the --force flag; doing
 so will also remove all those units and containers without giving them any
 opportunity to shut down cleanly.
 
+When --force is used, the containers and units that will be removed along
+with each machine are reported.
+
 Examples:
 	# Remove machine number 5 which has no running units or containers
 	$ juju remove-machine 5
@@ -61,14 +67,97 @@ func (c *RemoveMachineCommand) Init(args []string) error {
 	return nil
 }
 
-func (c *RemoveMachineCommand) Run(_ *cmd.Context) error {
+func (c *RemoveMachineCommand) Run(ctx *cmd.Context) error {
 	apiclient, err := c.NewAPIClient()
 	if err != nil {
 		return err
 	}
 	defer apiclient.Close()
 	if c.Force {
+		// Report what is going to be removed along with each machine,
+		// as forced removal does not give anything a chance to stop
+		// cleanly. Failing to get the status is not fatal.
+		status, err := apiclient.Status(nil)
+		if err != nil {
+			logger.Warningf("cannot get status of machines to be removed: %v", err)
+		} else {
+			for _, id := range c.MachineIds {
+				reportForcedRemoval(ctx, status, id)
+			}
+		}
 		return apiclient.ForceDestroyMachines(c.MachineIds...)
 	}
 	return apiclient.DestroyMachines(c.MachineIds...)
 }
+
+// reportForcedRemoval writes a summary of the containers and units that
+// will be removed along with the given machine.
+func reportForcedRemoval(ctx *cmd.Context, status *api.Status, machineId string) {
+	machine, ok := findMachineStatus(status.Machines, machineId)
+	if !ok {
+		return
+	}
+	machineIds := []string{machineId}
+	var containers []string
+	collectContainers(machine, &containers)
+	machineIds = append(machineIds, containers...)
+	units := unitsOnMachines(status, machineIds)
+	ctx.Infof("removing machine %s", machineId)
+	if len(containers) > 0 {
+		ctx.Infof("  containers: %s", strings.Join(containers, ", "))
+	}
+	if len(units) > 0 {
+		ctx.Infof("  units: %s", strings.Join(units, ", "))
+	}
+}
+
+// findMachineStatus returns the status of the machine with the given id,
+// searching recursively through containers.
+func findMachineStatus(machines map[string]api.MachineStatus, machineId string) (api.MachineStatus, bool) {
+	for id, m := range machines {
+		if id == machineId {
+			return m, true
+		}
+		if found, ok := findMachineStatus(m.Containers, machineId); ok {
+			return found, true
+		}
+	}
+	return api.MachineStatus{}, false
+}
+
+// collectContainers appends the ids of all containers hosted on the
+// given machine, including nested containers, to ids.
+func collectContainers(machine api.MachineStatus, ids *[]string) {
+	for _, id := range sortedMachineIds(machine.Containers) {
+		*ids = append(*ids, id)
+		collectContainers(machine.Containers[id], ids)
+	}
+}
+
+func sortedMachineIds(machines map[string]api.MachineStatus) []string {
+	keys := make([]string, 0, len(machines))
+	for id := range machines {
+		keys = append(keys, id)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// unitsOnMachines returns the sorted names of the principal units
+// assigned to any of the given machines.
+func unitsOnMachines(status *api.Status, machineIds []string) []string {
+	onMachine := make(map[string]bool)
+	for _, id := range machineIds {
+		onMachine[id] = true
+	}
+	var units []string
+	for _, service := range status.Services {
+		for name, unit := range service.Units {
+			if onMachine[unit.Machine] {
+				units = append(units, name)
+			}
+		}
+	}
+	sort.Strings(units)
+	return units
+}
@@ -10,6 +10,7 @@ import (
 	gc "launchpad.net/gocheck"
 
 	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/instance"
 	jujutesting "github.com/juju/juju/juju/testing"
 	"github.com/juju/juju/state"
 	"github.com/juju/juju/testing"
@@ -117,6 +118,27 @@ func (s *RemoveMachineSuite) TestForce(c *gc.C) {
 	c.Assert(m0.Life(), gc.Equals, state.Alive)
 }
 
+func (s *RemoveMachineSuite) TestForceReportsContainersAndUnits(c *gc.C) {
+	m0, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	template := state.MachineTemplate{
+		Series: "quantal",
+		Jobs:   []state.MachineJob{state.JobHostUnits},
+	}
+	container, err := s.State.AddMachineInsideMachine(template, m0.Id(), instance.LXC)
+	c.Assert(err, gc.IsNil)
+	charmtesting.Charms.BundlePath(s.SeriesPath, "riak")
+	err = runDeploy(c, "local:riak", "riak", "--to", container.Id())
+	c.Assert(err, gc.IsNil)
+
+	context, err := testing.RunCommand(c, envcmd.Wrap(&RemoveMachineCommand{}), m0.Id(), "--force")
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stderr(context), gc.Equals, ""+
+		"removing machine 0\n"+
+		"  containers: 0/lxc/0\n"+
+		"  units: riak/0\n")
+}
+
 func (s *RemoveMachineSuite) TestBadArgs(c *gc.C) {
 	// Check invalid args.
 	err := runRemoveMachine(c)
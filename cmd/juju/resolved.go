@@ -5,35 +5,70 @@ package main
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/juju/cmd"
 	"github.com/juju/names"
 	"launchpad.net/gnuflag"
 
 	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/state/api"
+	"github.com/juju/juju/state/api/params"
 )
 
+const resolvedDoc = `
+Marks a unit in an error state as resolved, so that its agent continues
+as if the failed hook had succeeded. With --retry, the failed hook is
+run again instead.
+
+With --all, the argument is a service name, and every unit of that
+service that is in an error state is marked resolved. This is useful
+after fixing a bad configuration change that broke all the units of a
+service. The units are resolved concurrently and the outcome for each
+one is reported, so a unit that cannot be resolved does not stop the
+others from being resolved.
+
+Examples:
+    juju resolved wordpress/0
+    juju resolved --retry wordpress/0
+    juju resolved --all --retry wordpress
+`
+
 // ResolvedCommand marks a unit in an error state as ready to continue.
 type ResolvedCommand struct {
 	envcmd.EnvCommandBase
-	UnitName string
-	Retry    bool
+	UnitName    string
+	ServiceName string
+	All         bool
+	Retry       bool
 }
 
 func (c *ResolvedCommand) Info() *cmd.Info {
 	return &cmd.Info{
 		Name:    "resolved",
-		Args:    "<unit>",
+		Args:    "<unit> | --all <service>",
 		Purpose: "marks unit errors resolved",
+		Doc:     resolvedDoc,
 	}
 }
 
 func (c *ResolvedCommand) SetFlags(f *gnuflag.FlagSet) {
 	f.BoolVar(&c.Retry, "r", false, "re-execute failed hooks")
 	f.BoolVar(&c.Retry, "retry", false, "")
+	f.BoolVar(&c.All, "all", false, "resolve all units of the given service that are in an error state")
 }
 
 func (c *ResolvedCommand) Init(args []string) error {
+	if c.All {
+		if len(args) == 0 {
+			return fmt.Errorf("no service specified")
+		}
+		c.ServiceName = args[0]
+		if !names.IsValidService(c.ServiceName) {
+			return fmt.Errorf("invalid service name %q", c.ServiceName)
+		}
+		return cmd.CheckEmpty(args[1:])
+	}
 	if len(args) > 0 {
 		c.UnitName = args[0]
 		if !names.IsValidUnit(c.UnitName) {
@@ -46,11 +81,52 @@ func (c *ResolvedCommand) Init(args []string) error {
 	return cmd.CheckEmpty(args)
 }
 
-func (c *ResolvedCommand) Run(_ *cmd.Context) error {
+func (c *ResolvedCommand) Run(ctx *cmd.Context) error {
 	client, err := c.NewAPIClient()
 	if err != nil {
 		return err
 	}
 	defer client.Close()
-	return client.Resolved(c.UnitName, c.Retry)
+	if !c.All {
+		return client.Resolved(c.UnitName, c.Retry)
+	}
+	status, err := client.Status(nil)
+	if err != nil {
+		return err
+	}
+	if _, ok := status.Services[c.ServiceName]; !ok {
+		return fmt.Errorf("service %q not found", c.ServiceName)
+	}
+	units := failedUnits(status, c.ServiceName)
+	if len(units) == 0 {
+		return fmt.Errorf("no units of service %q are in an error state", c.ServiceName)
+	}
+	results := forEachEntity(units, func(unitName string) error {
+		return client.Resolved(unitName, c.Retry)
+	})
+	if failed := writeEntityResults(ctx.Stderr, "UNIT", "resolved", results); failed > 0 {
+		return fmt.Errorf("cannot resolve %d of %d units", failed, len(units))
+	}
+	return nil
+}
+
+// failedUnits returns the sorted names of the units of the given
+// service whose agents are in an error state. Units of subordinate
+// services are found among the subordinates of their principals.
+func failedUnits(status *api.Status, serviceName string) []string {
+	var units []string
+	var collect func(map[string]api.UnitStatus)
+	collect = func(unitStatuses map[string]api.UnitStatus) {
+		for name, unit := range unitStatuses {
+			if names.UnitService(name) == serviceName && unit.Agent.Status == params.StatusError {
+				units = append(units, name)
+			}
+			collect(unit.Subordinates)
+		}
+	}
+	for _, service := range status.Services {
+		collect(service.Units)
+	}
+	sort.Strings(units)
+	return units
 }
@@ -100,3 +100,54 @@ func (s *ResolvedSuite) TestResolved(c *gc.C) {
 		}
 	}
 }
+
+func (s *ResolvedSuite) TestResolvedAllInit(c *gc.C) {
+	err := runResolved(c, []string{"--all"})
+	c.Assert(err, gc.ErrorMatches, "no service specified")
+	err = runResolved(c, []string{"--all", "dummy/0"})
+	c.Assert(err, gc.ErrorMatches, `invalid service name "dummy/0"`)
+	err = runResolved(c, []string{"--all", "dummy", "other"})
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["other"\]`)
+}
+
+func (s *ResolvedSuite) TestResolvedAll(c *gc.C) {
+	charmtesting.Charms.BundlePath(s.SeriesPath, "dummy")
+	err := runDeploy(c, "-n", "3", "local:dummy", "dummy")
+	c.Assert(err, gc.IsNil)
+
+	err = runResolved(c, []string{"--all", "nosuch"})
+	c.Assert(err, gc.ErrorMatches, `service "nosuch" not found`)
+	err = runResolved(c, []string{"--all", "dummy"})
+	c.Assert(err, gc.ErrorMatches, `no units of service "dummy" are in an error state`)
+
+	for _, name := range []string{"dummy/0", "dummy/2"} {
+		u, err := s.State.Unit(name)
+		c.Assert(err, gc.IsNil)
+		err = u.SetStatus(params.StatusError, "lol borken", nil)
+		c.Assert(err, gc.IsNil)
+	}
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&ResolvedCommand{}), "--all", "--retry", "dummy")
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stderr(ctx), gc.Equals, ""+
+		"UNIT     RESULT\n"+
+		"dummy/0  resolved\n"+
+		"dummy/2  resolved\n")
+
+	for name, mode := range map[string]state.ResolvedMode{
+		"dummy/0": state.ResolvedRetryHooks,
+		"dummy/1": state.ResolvedNone,
+		"dummy/2": state.ResolvedRetryHooks,
+	} {
+		unit, err := s.State.Unit(name)
+		c.Assert(err, gc.IsNil)
+		c.Check(unit.Resolved(), gc.Equals, mode, gc.Commentf("unit %s", name))
+	}
+
+	// Units that are already resolved are reported.
+	ctx, err = testing.RunCommand(c, envcmd.Wrap(&ResolvedCommand{}), "--all", "dummy")
+	c.Assert(err, gc.ErrorMatches, "cannot resolve 2 of 2 units")
+	c.Assert(testing.Stderr(ctx), gc.Equals, ""+
+		"UNIT     RESULT\n"+
+		"dummy/0  error: cannot set resolved mode for unit \"dummy/0\": already resolved\n"+
+		"dummy/2  error: cannot set resolved mode for unit \"dummy/2\": already resolved\n")
+}
@@ -16,14 +16,26 @@ import (
 // the provisoner that it should try to re-provision the machine.
 type RetryProvisioningCommand struct {
 	envcmd.EnvCommandBase
-	Machines []string
+	Machines   []string
+	machineIds []string
 }
 
+const retryProvisioningDoc = `
+Tells the provisioner to try again to provision machines that failed to
+start. The outcome for each machine is reported in a table, and machines
+that cannot be retried do not stop the others from being retried.
+
+Examples:
+    juju retry-provisioning 3
+    juju retry-provisioning 3 4 7
+`
+
 func (c *RetryProvisioningCommand) Info() *cmd.Info {
 	return &cmd.Info{
 		Name:    "retry-provisioning",
 		Args:    "<machine> [...]",
 		Purpose: "retries provisioning for failed machines",
+		Doc:     retryProvisioningDoc,
 	}
 }
 
@@ -32,6 +44,7 @@ func (c *RetryProvisioningCommand) Init(args []string) error {
 		return fmt.Errorf("no machine specified")
 	}
 	c.Machines = make([]string, len(args))
+	c.machineIds = args
 	for i, arg := range args {
 		if !names.IsValidMachine(arg) {
 			return fmt.Errorf("invalid machine %q", arg)
@@ -51,10 +64,18 @@ func (c *RetryProvisioningCommand) Run(context *cmd.Context) error {
 	if err != nil {
 		return err
 	}
+	if len(results) != len(c.Machines) {
+		return fmt.Errorf("expected %d results, got %d", len(c.Machines), len(results))
+	}
+	entityResults := make([]entityResult, len(results))
 	for i, result := range results {
+		entityResults[i] = entityResult{c.machineIds[i], nil}
 		if result.Error != nil {
-			fmt.Fprintf(context.Stderr, "cannot retry provisioning %q: %v\n", c.Machines[i], result.Error)
+			entityResults[i].Error = result.Error
 		}
 	}
+	if failed := writeEntityResults(context.Stderr, "MACHINE", "retrying", entityResults); failed > 0 {
+		return fmt.Errorf("cannot retry provisioning %d of %d machines", failed, len(results))
+	}
 	return nil
 }
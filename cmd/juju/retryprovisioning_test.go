@@ -4,8 +4,6 @@
 package main
 
 import (
-	"strings"
-
 	jc "github.com/juju/testing/checkers"
 	gc "launchpad.net/gocheck"
 
@@ -33,16 +31,29 @@ var resolvedMachineTests = []struct {
 		args: []string{"jeremy-fisher"},
 		err:  `invalid machine "jeremy-fisher"`,
 	}, {
-		args:   []string{"42"},
-		stdErr: `cannot retry provisioning "machine-42": machine 42 not found`,
+		args: []string{"42"},
+		err:  `cannot retry provisioning 1 of 1 machines`,
+		stdErr: "" +
+			"MACHINE  RESULT\n" +
+			"42       error: machine 42 not found\n",
 	}, {
-		args:   []string{"1"},
-		stdErr: `cannot retry provisioning "machine-1": machine "machine-1" is not in an error state`,
+		args: []string{"1"},
+		err:  `cannot retry provisioning 1 of 1 machines`,
+		stdErr: "" +
+			"MACHINE  RESULT\n" +
+			"1        error: machine \"machine-1\" is not in an error state\n",
 	}, {
 		args: []string{"0"},
+		stdErr: "" +
+			"MACHINE  RESULT\n" +
+			"0        retrying\n",
 	}, {
-		args:   []string{"0", "1"},
-		stdErr: `cannot retry provisioning "machine-1": machine "machine-1" is not in an error state`,
+		args: []string{"0", "1"},
+		err:  `cannot retry provisioning 1 of 2 machines`,
+		stdErr: "" +
+			"MACHINE  RESULT\n" +
+			"0        retrying\n" +
+			"1        error: machine \"machine-1\" is not in an error state\n",
 	},
 }
 
@@ -65,13 +76,13 @@ func (s *retryProvisioningSuite) TestResolved(c *gc.C) {
 		context, err := testing.RunCommand(c, envcmd.Wrap(&RetryProvisioningCommand{}), t.args...)
 		if t.err != "" {
 			c.Check(err, gc.ErrorMatches, t.err)
-			continue
 		} else {
 			c.Check(err, gc.IsNil)
 		}
-		output := testing.Stderr(context)
-		stripped := strings.Replace(output, "\n", "", -1)
-		c.Check(stripped, gc.Equals, t.stdErr)
+		if t.stdErr == "" {
+			continue
+		}
+		c.Check(testing.Stderr(context), gc.Equals, t.stdErr)
 		if t.args[0] == "0" {
 			status, info, data, err := m.Status()
 			c.Check(err, gc.IsNil)
@@ -12,6 +12,7 @@ import (
 
 	"github.com/juju/cmd"
 	"github.com/juju/names"
+	"github.com/juju/utils/parallel"
 	"launchpad.net/gnuflag"
 
 	"github.com/juju/juju/cmd/envcmd"
@@ -52,6 +53,9 @@ is equivalent to
 Commands run for services or units are executed in a 'hook context' for
 the unit.
 
+If one of the targets cannot be found, the commands are still run on the
+others, and the error is reported in the result for that target.
+
 --all is provided as a simple way to run the command on all the machines
 in the environment.  If you specify --all you cannot provide additional
 targets.
@@ -155,6 +159,9 @@ func ConvertRunResults(runResults []params.RunResult) interface{} {
 			values["UnitId"] = result.UnitId
 
 		}
+		if result.ServiceName != "" {
+			values["ServiceName"] = result.ServiceName
+		}
 		storeOutput(values, "Stdout", result.Stdout)
 		if len(result.Stderr) > 0 {
 			storeOutput(values, "Stderr", result.Stderr)
@@ -182,14 +189,26 @@ func (c *RunCommand) Run(ctx *cmd.Context) error {
 	if c.all {
 		runResults, err = client.RunOnAllMachines(c.commands, c.timeout)
 	} else {
-		params := params.RunParams{
+		runParams := params.RunParams{
 			Commands: c.commands,
 			Timeout:  c.timeout,
 			Machines: c.machines,
 			Services: c.services,
 			Units:    c.units,
 		}
-		runResults, err = client.Run(params)
+		if len(c.machines)+len(c.services)+len(c.units) > 1 {
+			runResults, err = client.RunTargets(runParams)
+			if params.IsCodeNotImplemented(err) {
+				// Older API servers run the commands nowhere if any
+				// of the targets is rejected, so run them on each
+				// target separately to reach the valid ones and
+				// report the others.
+				logger.Debugf("RunTargets not supported by the API server, running on each target")
+				runResults, err = c.runOnEachTarget(client), nil
+			}
+		} else {
+			runResults, err = client.Run(runParams)
+		}
 	}
 
 	if err != nil {
@@ -216,6 +235,55 @@ func (c *RunCommand) Run(ctx *cmd.Context) error {
 	return nil
 }
 
+// runOnEachTarget runs the commands on each of the targets with a
+// separate API call, with at most maxConcurrentCalls calls in progress
+// at once, and returns the results in the order of the targets.
+// Targets that cannot be reached are reported by results holding only
+// the error.
+func (c *RunCommand) runOnEachTarget(client RunClient) []params.RunResult {
+	var targets []params.RunParams
+	for _, machine := range c.machines {
+		targets = append(targets, params.RunParams{Machines: []string{machine}})
+	}
+	for _, service := range c.services {
+		targets = append(targets, params.RunParams{Services: []string{service}})
+	}
+	for _, unit := range c.units {
+		targets = append(targets, params.RunParams{Units: []string{unit}})
+	}
+	targetResults := make([][]params.RunResult, len(targets))
+	run := parallel.NewRun(maxConcurrentCalls)
+	for i, target := range targets {
+		i, target := i, target
+		target.Commands = c.commands
+		target.Timeout = c.timeout
+		run.Do(func() error {
+			results, err := client.Run(target)
+			if err != nil {
+				result := params.RunResult{Error: err.Error()}
+				if len(target.Machines) > 0 {
+					result.MachineId = target.Machines[0]
+				}
+				if len(target.Units) > 0 {
+					result.UnitId = target.Units[0]
+				}
+				if len(target.Services) > 0 {
+					result.ServiceName = target.Services[0]
+				}
+				results = []params.RunResult{result}
+			}
+			targetResults[i] = results
+			return nil
+		})
+	}
+	run.Wait()
+	var runResults []params.RunResult
+	for _, results := range targetResults {
+		runResults = append(runResults, results...)
+	}
+	return runResults
+}
+
 // In order to be able to easily mock out the API side for testing,
 // the API client is got using a function.
 
@@ -223,6 +291,7 @@ type RunClient interface {
 	Close() error
 	RunOnAllMachines(commands string, timeout time.Duration) ([]params.RunResult, error)
 	Run(run params.RunParams) ([]params.RunResult, error)
+	RunTargets(run params.RunParams) ([]params.RunResult, error)
 }
 
 // Here we need the signature to be correct for the interface.
@@ -267,6 +267,89 @@ func (s *RunSuite) TestRunForMachineAndUnit(c *gc.C) {
 	c.Check(testing.Stdout(context), gc.Equals, string(jsonFormatted)+"\n")
 }
 
+func (s *RunSuite) TestRunWithMissingTargets(c *gc.C) {
+	mock := s.setupMockAPI()
+	machineResponse := mockResponse{
+		stdout:    "megatron\n",
+		machineId: "0",
+	}
+	unitResponse := mockResponse{
+		stdout:    "bumblebee",
+		machineId: "1",
+		unitId:    "unit/0",
+	}
+	mock.setResponse("0", machineResponse)
+	mock.setResponse("unit/0", unitResponse)
+	mock.setMissing("3", "nosuch")
+
+	unformatted := ConvertRunResults([]params.RunResult{
+		makeRunResult(machineResponse),
+		makeRunResult(mockResponse{machineId: "3", error: "3 not found"}),
+		{ServiceName: "nosuch", Error: "nosuch not found"},
+		makeRunResult(unitResponse),
+	})
+	jsonFormatted, err := cmd.FormatJson(unformatted)
+	c.Assert(err, gc.IsNil)
+
+	context, err := testing.RunCommand(c, envcmd.Wrap(&RunCommand{}),
+		"--format=json", "--machine=0,3", "--service=nosuch", "--unit=unit/0", "hostname",
+	)
+	c.Assert(err, gc.IsNil)
+
+	c.Check(testing.Stdout(context), gc.Equals, string(jsonFormatted)+"\n")
+	c.Check(mock.calls, gc.Equals, 1)
+}
+
+func (s *RunSuite) TestRunWithMissingTargetsNoRunTargets(c *gc.C) {
+	mock := s.setupMockAPI()
+	mock.runTargetsNotImplemented = true
+	machineResponse := mockResponse{
+		stdout:    "megatron\n",
+		machineId: "0",
+	}
+	unitResponse := mockResponse{
+		stdout:    "bumblebee",
+		machineId: "1",
+		unitId:    "unit/0",
+	}
+	mock.setResponse("0", machineResponse)
+	mock.setResponse("unit/0", unitResponse)
+	mock.setMissing("3", "nosuch")
+
+	unformatted := ConvertRunResults([]params.RunResult{
+		makeRunResult(machineResponse),
+		makeRunResult(mockResponse{machineId: "3", error: "3 not found"}),
+		{ServiceName: "nosuch", Error: "nosuch not found"},
+		makeRunResult(unitResponse),
+	})
+	jsonFormatted, err := cmd.FormatJson(unformatted)
+	c.Assert(err, gc.IsNil)
+
+	context, err := testing.RunCommand(c, envcmd.Wrap(&RunCommand{}),
+		"--format=json", "--machine=0,3", "--service=nosuch", "--unit=unit/0", "hostname",
+	)
+	c.Assert(err, gc.IsNil)
+
+	c.Check(testing.Stdout(context), gc.Equals, string(jsonFormatted)+"\n")
+	// One failed RunTargets call, then one Run call per target.
+	c.Check(mock.calls, gc.Equals, 5)
+}
+
+func (s *RunSuite) TestRunTargetsErrorNotRetried(c *gc.C) {
+	mock := s.setupMockAPI()
+	mock.runTargetsError = fmt.Errorf("boom")
+	_, err := testing.RunCommand(c, envcmd.Wrap(&RunCommand{}), "--machine=0,1", "hostname")
+	c.Assert(err, gc.ErrorMatches, "boom")
+	c.Check(mock.calls, gc.Equals, 1)
+}
+
+func (s *RunSuite) TestRunWithMissingSingleTarget(c *gc.C) {
+	mock := s.setupMockAPI()
+	mock.setMissing("3")
+	_, err := testing.RunCommand(c, envcmd.Wrap(&RunCommand{}), "--machine=3", "hostname")
+	c.Assert(err, gc.ErrorMatches, "3 not found")
+}
+
 func (s *RunSuite) TestAllMachines(c *gc.C) {
 	mock := s.setupMockAPI()
 	mock.setMachinesAlive("0", "1")
@@ -363,6 +446,13 @@ type mockRunAPI struct {
 	// machines, services, units
 	machines  map[string]bool
 	responses map[string]params.RunResult
+	// missing holds the targets that Run and RunTargets reject.
+	missing map[string]bool
+	// runTargetsNotImplemented makes RunTargets behave like an older
+	// API server.
+	runTargetsNotImplemented bool
+	runTargetsError          error
+	calls                    int
 }
 
 type mockResponse struct {
@@ -430,7 +520,26 @@ func (m *mockRunAPI) RunOnAllMachines(commands string, timeout time.Duration) ([
 	return result, nil
 }
 
+func (m *mockRunAPI) setMissing(ids ...string) {
+	if m.missing == nil {
+		m.missing = make(map[string]bool)
+	}
+	for _, id := range ids {
+		m.missing[id] = true
+	}
+}
+
 func (m *mockRunAPI) Run(runParams params.RunParams) ([]params.RunResult, error) {
+	m.calls++
+	var targets []string
+	targets = append(targets, runParams.Machines...)
+	targets = append(targets, runParams.Services...)
+	targets = append(targets, runParams.Units...)
+	for _, id := range targets {
+		if m.missing[id] {
+			return nil, fmt.Errorf("%s not found", id)
+		}
+	}
 	var result []params.RunResult
 	// Just add in ids that match in order.
 	for _, id := range runParams.Machines {
@@ -449,3 +558,35 @@ func (m *mockRunAPI) Run(runParams params.RunParams) ([]params.RunResult, error)
 
 	return result, nil
 }
+
+func (m *mockRunAPI) RunTargets(runParams params.RunParams) ([]params.RunResult, error) {
+	m.calls++
+	if m.runTargetsNotImplemented {
+		return nil, &params.Error{Code: params.CodeNotImplemented, Message: "no such request"}
+	}
+	if m.runTargetsError != nil {
+		return nil, m.runTargetsError
+	}
+	var result []params.RunResult
+	for _, id := range runParams.Machines {
+		if m.missing[id] {
+			result = append(result, params.RunResult{MachineId: id, Error: id + " not found"})
+		} else if response, found := m.responses[id]; found {
+			result = append(result, response)
+		}
+	}
+	// mock has no units for services
+	for _, id := range runParams.Services {
+		if m.missing[id] {
+			result = append(result, params.RunResult{ServiceName: id, Error: id + " not found"})
+		}
+	}
+	for _, id := range runParams.Units {
+		if m.missing[id] {
+			result = append(result, params.RunResult{UnitId: id, Error: id + " not found"})
+		} else if response, found := m.responses[id]; found {
+			result = append(result, response)
+		}
+	}
+	return result, nil
+}
@@ -8,6 +8,8 @@ import (
 	"net"
 	"os"
 	"os/exec"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/juju/cmd"
@@ -24,6 +26,9 @@ import (
 // SSHCommand is responsible for launching a ssh shell on a given unit or machine.
 type SSHCommand struct {
 	SSHCommon
+	localForwards  []string
+	remoteForwards []string
+	socks          string
 }
 
 // SSHCommon provides common methods for SSHCommand, SCPCommand and DebugHooksCommand.
@@ -79,6 +84,26 @@ Connect to the first mysql unit:
 Connect to the first mysql unit and run 'ls -la /var/log/juju':
 
     juju ssh mysql/0 ls -la /var/log/juju
+
+Ports can be forwarded through the target while the connection is open.
+-L [bind_address:]port:host:hostport forwards the local port to the host
+and port reachable from the target, and -R [bind_address:]port:host:hostport
+forwards the port of the target to the host and port reachable from the
+local machine. Both may be given more than once. When the host of -L is a
+machine id or a unit name, it is replaced by the internal address of that
+machine or unit, so services listening only on private addresses can be
+reached. --socks [bind_address:]port serves a SOCKS proxy on the local port
+which connects through the target.
+
+Reach the mysql database of the first mysql unit on local port 3306,
+through machine 0:
+
+    juju ssh -L 3306:mysql/0:3306 0
+
+Browse the private addresses of the environment through a SOCKS proxy
+on local port 1080, without running a command on machine 0:
+
+    juju ssh --socks 1080 0 -N
 `
 
 func (c *SSHCommand) Info() *cmd.Info {
@@ -90,14 +115,152 @@ func (c *SSHCommand) Info() *cmd.Info {
 	}
 }
 
+func (c *SSHCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.SSHCommon.SetFlags(f)
+	f.Var(newForwardsValue(&c.localForwards), "L", "forward a local port through the target: [bind_address:]port:host:hostport")
+	f.Var(newForwardsValue(&c.remoteForwards), "R", "forward a port of the target to the local machine: [bind_address:]port:host:hostport")
+	f.StringVar(&c.socks, "socks", "", "serve a SOCKS proxy through the target on a local port: [bind_address:]port")
+}
+
 func (c *SSHCommand) Init(args []string) error {
 	if len(args) == 0 {
 		return fmt.Errorf("no target name specified")
 	}
+	for _, spec := range c.localForwards {
+		if _, err := parseForward(spec); err != nil {
+			return fmt.Errorf("invalid -L forward %q: %v", spec, err)
+		}
+	}
+	for _, spec := range c.remoteForwards {
+		if _, err := parseForward(spec); err != nil {
+			return fmt.Errorf("invalid -R forward %q: %v", spec, err)
+		}
+	}
+	if c.socks != "" {
+		if err := checkSOCKSSpec(c.socks); err != nil {
+			return fmt.Errorf("invalid --socks port %q: %v", c.socks, err)
+		}
+	}
 	c.Target, c.Args = args[0], args[1:]
 	return nil
 }
 
+// forwardsValue implements gnuflag.Value, appending
+// each port forwarding specification it is given.
+type forwardsValue []string
+
+func newForwardsValue(target *[]string) *forwardsValue {
+	return (*forwardsValue)(target)
+}
+
+func (v *forwardsValue) Set(s string) error {
+	*v = append(*v, s)
+	return nil
+}
+
+func (v *forwardsValue) String() string {
+	return strings.Join(*v, " ")
+}
+
+// forward holds a port forwarding specification in
+// the format [bind_address:]port:host:hostport. IPv6
+// addresses are enclosed in brackets.
+type forward struct {
+	bindAddress string
+	port        string
+	host        string
+	hostPort    string
+}
+
+func (f forward) String() string {
+	spec := f.port + ":" + bracketHost(f.host) + ":" + f.hostPort
+	if f.bindAddress != "" {
+		spec = bracketHost(f.bindAddress) + ":" + spec
+	}
+	return spec
+}
+
+// bracketHost encloses the host in brackets if it is an IPv6
+// address, as ssh expects in forwarding specifications.
+func bracketHost(host string) string {
+	if strings.Contains(host, ":") {
+		return "[" + host + "]"
+	}
+	return host
+}
+
+// splitForward splits a forwarding specification at the colons
+// which are not within the brackets of an IPv6 address, and
+// removes the brackets.
+func splitForward(spec string) []string {
+	var parts []string
+	inBrackets := false
+	start := 0
+	for i, r := range spec {
+		switch r {
+		case '[':
+			inBrackets = true
+		case ']':
+			inBrackets = false
+		case ':':
+			if !inBrackets {
+				parts = append(parts, spec[start:i])
+				start = i + 1
+			}
+		}
+	}
+	parts = append(parts, spec[start:])
+	for i, part := range parts {
+		if strings.HasPrefix(part, "[") && strings.HasSuffix(part, "]") {
+			parts[i] = part[1 : len(part)-1]
+		}
+	}
+	return parts
+}
+
+// parseForward parses a port forwarding specification
+// in the format [bind_address:]port:host:hostport.
+func parseForward(spec string) (forward, error) {
+	var f forward
+	parts := splitForward(spec)
+	switch len(parts) {
+	case 3:
+		f.port, f.host, f.hostPort = parts[0], parts[1], parts[2]
+	case 4:
+		f.bindAddress, f.port, f.host, f.hostPort = parts[0], parts[1], parts[2], parts[3]
+	default:
+		return forward{}, fmt.Errorf("expected [bind_address:]port:host:hostport")
+	}
+	if f.host == "" {
+		return forward{}, fmt.Errorf("no host specified")
+	}
+	if err := checkPort(f.port); err != nil {
+		return forward{}, err
+	}
+	if err := checkPort(f.hostPort); err != nil {
+		return forward{}, err
+	}
+	return f, nil
+}
+
+// checkSOCKSSpec checks a SOCKS proxy specification
+// in the format [bind_address:]port.
+func checkSOCKSSpec(spec string) error {
+	port := spec
+	if i := strings.LastIndex(spec, ":"); i >= 0 {
+		port = spec[i+1:]
+	}
+	return checkPort(port)
+}
+
+func checkPort(port string) error {
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	return nil
+}
+
 // getJujuExecutable returns the path to the juju
 // executable, or an error if it could not be found.
 var getJujuExecutable = func() (string, error) {
@@ -147,6 +310,9 @@ func (c *SSHCommand) Run(ctx *cmd.Context) error {
 	if err != nil {
 		return err
 	}
+	if err := c.setForwards(options); err != nil {
+		return err
+	}
 	cmd := ssh.Command("ubuntu@"+host, c.Args, options)
 	cmd.Stdin = ctx.Stdin
 	cmd.Stdout = ctx.Stdout
@@ -154,6 +320,37 @@ func (c *SSHCommand) Run(ctx *cmd.Context) error {
 	return cmd.Run()
 }
 
+// setForwards adds the requested port forwarding to the given
+// options. The host of each local forward that names a machine
+// or unit is replaced by the internal address of that machine
+// or unit, which is reachable from the target.
+func (c *SSHCommand) setForwards(options *ssh.Options) error {
+	for _, spec := range c.localForwards {
+		f, err := parseForward(spec)
+		if err != nil {
+			return err
+		}
+		if names.IsValidMachine(f.host) || names.IsValidUnit(f.host) {
+			if _, err := c.ensureAPIClient(); err != nil {
+				return err
+			}
+			addr, err := c.apiClient.PrivateAddress(f.host)
+			if err != nil {
+				return fmt.Errorf("cannot resolve the address of %q: %v", f.host, err)
+			}
+			f.host = addr
+		}
+		options.AddLocalForward(f.String())
+	}
+	for _, spec := range c.remoteForwards {
+		options.AddRemoteForward(spec)
+	}
+	if c.socks != "" {
+		options.SetDynamicForward(c.socks)
+	}
+	return nil
+}
+
 // proxySSH returns true iff both c.proxy and
 // the proxy-ssh environment configuration
 // are true.
@@ -94,6 +94,26 @@ var sshTests = []struct {
 		[]string{"ssh", "--proxy=false", "mysql/0"},
 		sshArgsNoProxy + "ubuntu@dummyenv-0.dns\n",
 	},
+	{
+		"forward a local port to unit mysql/0 through machine 1",
+		[]string{"ssh", "-L", "3306:mysql/0:3306", "1"},
+		sshArgs + "-L 3306:dummyenv-0.internal:3306 ubuntu@dummyenv-1.internal\n",
+	},
+	{
+		"forward local ports to machine 2 and to a named host without proxy",
+		[]string{"ssh", "--proxy=false", "-L", "127.0.0.1:8080:2:80", "-L", "8443:localhost:443", "0"},
+		sshArgsNoProxy + "-L 127.0.0.1:8080:dummyenv-2.internal:80 -L 8443:localhost:443 ubuntu@dummyenv-0.dns\n",
+	},
+	{
+		"forward a remote port and serve a SOCKS proxy through mongodb/1",
+		[]string{"ssh", "-R", "9000:localhost:9000", "--socks", "1080", "mongodb/1", "-N"},
+		sshArgs + "-R 9000:localhost:9000 -D 1080 ubuntu@dummyenv-2.internal -N\n",
+	},
+	{
+		"forward a local port bound to an IPv6 address to an IPv6 host",
+		[]string{"ssh", "-L", "[::1]:8080:[2001:db8::5]:80", "0"},
+		sshArgs + "-L [::1]:8080:[2001:db8::5]:80 ubuntu@dummyenv-0.internal\n",
+	},
 }
 
 func (s *SSHSuite) TestSSHCommand(c *gc.C) {
@@ -126,6 +146,26 @@ func (s *SSHSuite) TestSSHCommand(c *gc.C) {
 	}
 }
 
+var sshInitErrorTests = []struct {
+	args []string
+	err  string
+}{
+	{[]string{}, "no target name specified"},
+	{[]string{"-L", "3306:mysql/0", "0"}, `invalid -L forward "3306:mysql/0": expected \[bind_address:\]port:host:hostport`},
+	{[]string{"-L", "3306::3306", "0"}, `invalid -L forward "3306::3306": no host specified`},
+	{[]string{"-L", "0:mysql/0:3306", "0"}, `invalid -L forward "0:mysql/0:3306": invalid port "0"`},
+	{[]string{"-R", "9000:localhost:http", "0"}, `invalid -R forward "9000:localhost:http": invalid port "http"`},
+	{[]string{"--socks", "localhost:70000", "0"}, `invalid --socks port "localhost:70000": invalid port "70000"`},
+}
+
+func (s *SSHSuite) TestSSHCommandInitErrors(c *gc.C) {
+	for i, t := range sshInitErrorTests {
+		c.Logf("test %d: %v", i, t.args)
+		err := coretesting.InitCommand(&SSHCommand{}, t.args)
+		c.Check(err, gc.ErrorMatches, t.err)
+	}
+}
+
 func (s *SSHSuite) TestSSHCommandEnvironProxySSH(c *gc.C) {
 	s.makeMachines(1, c, true)
 	// Setting proxy-ssh=false in the environment overrides --proxy.
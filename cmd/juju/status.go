@@ -4,12 +4,18 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/juju/cmd"
+	"github.com/juju/names"
 	"launchpad.net/gnuflag"
 
+	jujucmd "github.com/juju/juju/cmd"
 	"github.com/juju/juju/cmd/envcmd"
 	"github.com/juju/juju/instance"
 	"github.com/juju/juju/network"
@@ -22,6 +28,8 @@ type StatusCommand struct {
 	envcmd.EnvCommandBase
 	out      cmd.Output
 	patterns []string
+	sortBy   string
+	include  []string
 }
 
 var statusDoc = `
@@ -36,6 +44,23 @@ will be displayed.
 Wildcards ('*') may be specified in service/unit names to match any sequence
 of characters. For example, 'nova-*' will match any service whose name begins
 with 'nova-': 'nova-compute', 'nova-volume', etc.
+
+Machines, containers and units are always listed in a stable order, with
+numbers in their names compared by value, so that successive snapshots of
+the status can be compared line by line. By default units are ordered by
+name; --sort machine orders them by the machine they are assigned to
+instead. As machine and unit numbers are allocated in sequence, ordering
+by name also lists them from oldest to newest. YAML output always lists
+units by name.
+
+With --format tabular or --format csv, the machines and units are listed
+one per line, with their agent state, machine and address, which suits
+quick inspection and importing into spreadsheets respectively.
+
+Additional information may be requested with --include:
+
+    provenance  who deployed each service, when, from which host,
+                and with which charm and bundle
 `
 
 func (c *StatusCommand) Info() *cmd.Info {
@@ -49,13 +74,32 @@ func (c *StatusCommand) Info() *cmd.Info {
 }
 
 func (c *StatusCommand) SetFlags(f *gnuflag.FlagSet) {
-	c.out.AddFlags(f, "yaml", map[string]cmd.Formatter{
+	c.out.AddFlags(f, "yaml", statusTable.Formatters(map[string]cmd.Formatter{
 		"yaml": cmd.FormatYaml,
 		"json": cmd.FormatJson,
-	})
+	}))
+	f.StringVar(&c.sortBy, "sort", sortByName, `order of units in json output: "name" or "machine"`)
+	f.Var(cmd.NewStringsValue(nil, &c.include), "include", `additional information to include: "provenance"`)
 }
 
+const (
+	sortByName    = "name"
+	sortByMachine = "machine"
+
+	includeProvenance = "provenance"
+)
+
 func (c *StatusCommand) Init(args []string) error {
+	switch c.sortBy {
+	case sortByName, sortByMachine:
+	default:
+		return fmt.Errorf("invalid sort order %q: expected %q or %q", c.sortBy, sortByName, sortByMachine)
+	}
+	for _, include := range c.include {
+		if include != includeProvenance {
+			return fmt.Errorf("cannot include %q: expected %q", include, includeProvenance)
+		}
+	}
 	c.patterns = args
 	return nil
 }
@@ -97,13 +141,13 @@ func (c *StatusCommand) Run(ctx *cmd.Context) error {
 		// Display any error, but continue to print status if some was returned
 		fmt.Fprintf(ctx.Stderr, "%v\n", err)
 	}
-	result := newStatusFormatter(status).format()
+	result := newStatusFormatter(status, c.sortBy, len(c.include) > 0).format()
 	return c.out.Write(ctx, result)
 }
 
 type formattedStatus struct {
 	Environment string                   `json:"environment"`
-	Machines    map[string]machineStatus `json:"machines"`
+	Machines    machineStatuses          `json:"machines"`
 	Services    map[string]serviceStatus `json:"services"`
 	Networks    map[string]networkStatus `json:"networks,omitempty" yaml:",omitempty"`
 }
@@ -113,19 +157,20 @@ type errorStatus struct {
 }
 
 type machineStatus struct {
-	Err            error                    `json:"-" yaml:",omitempty"`
-	AgentState     params.Status            `json:"agent-state,omitempty" yaml:"agent-state,omitempty"`
-	AgentStateInfo string                   `json:"agent-state-info,omitempty" yaml:"agent-state-info,omitempty"`
-	AgentVersion   string                   `json:"agent-version,omitempty" yaml:"agent-version,omitempty"`
-	DNSName        string                   `json:"dns-name,omitempty" yaml:"dns-name,omitempty"`
-	InstanceId     instance.Id              `json:"instance-id,omitempty" yaml:"instance-id,omitempty"`
-	InstanceState  string                   `json:"instance-state,omitempty" yaml:"instance-state,omitempty"`
-	Life           string                   `json:"life,omitempty" yaml:"life,omitempty"`
-	Series         string                   `json:"series,omitempty" yaml:"series,omitempty"`
-	Id             string                   `json:"-" yaml:"-"`
-	Containers     map[string]machineStatus `json:"containers,omitempty" yaml:"containers,omitempty"`
-	Hardware       string                   `json:"hardware,omitempty" yaml:"hardware,omitempty"`
-	HAStatus       string                   `json:"state-server-member-status,omitempty" yaml:"state-server-member-status,omitempty"`
+	Err            error           `json:"-" yaml:",omitempty"`
+	AgentState     params.Status   `json:"agent-state,omitempty" yaml:"agent-state,omitempty"`
+	AgentStateInfo string          `json:"agent-state-info,omitempty" yaml:"agent-state-info,omitempty"`
+	AgentVersion   string          `json:"agent-version,omitempty" yaml:"agent-version,omitempty"`
+	DNSName        string          `json:"dns-name,omitempty" yaml:"dns-name,omitempty"`
+	InstanceId     instance.Id     `json:"instance-id,omitempty" yaml:"instance-id,omitempty"`
+	InstanceState  string          `json:"instance-state,omitempty" yaml:"instance-state,omitempty"`
+	Life           string          `json:"life,omitempty" yaml:"life,omitempty"`
+	Series         string          `json:"series,omitempty" yaml:"series,omitempty"`
+	Id             string          `json:"-" yaml:"-"`
+	Containers     machineStatuses `json:"containers,omitempty" yaml:"containers,omitempty"`
+	Hardware       string          `json:"hardware,omitempty" yaml:"hardware,omitempty"`
+	HAStatus       string          `json:"state-server-member-status,omitempty" yaml:"state-server-member-status,omitempty"`
+	Maintenance    bool            `json:"maintenance,omitempty" yaml:"maintenance,omitempty"`
 }
 
 // A goyaml bug means we can't declare these types
@@ -151,15 +196,16 @@ func (s machineStatus) GetYAML() (tag string, value interface{}) {
 }
 
 type serviceStatus struct {
-	Err           error                 `json:"-" yaml:",omitempty"`
-	Charm         string                `json:"charm" yaml:"charm"`
-	CanUpgradeTo  string                `json:"can-upgrade-to,omitempty" yaml:"can-upgrade-to,omitempty"`
-	Exposed       bool                  `json:"exposed" yaml:"exposed"`
-	Life          string                `json:"life,omitempty" yaml:"life,omitempty"`
-	Relations     map[string][]string   `json:"relations,omitempty" yaml:"relations,omitempty"`
-	Networks      map[string][]string   `json:"networks,omitempty" yaml:"networks,omitempty"`
-	SubordinateTo []string              `json:"subordinate-to,omitempty" yaml:"subordinate-to,omitempty"`
-	Units         map[string]unitStatus `json:"units,omitempty" yaml:"units,omitempty"`
+	Err           error               `json:"-" yaml:",omitempty"`
+	Charm         string              `json:"charm" yaml:"charm"`
+	CanUpgradeTo  string              `json:"can-upgrade-to,omitempty" yaml:"can-upgrade-to,omitempty"`
+	Exposed       bool                `json:"exposed" yaml:"exposed"`
+	Life          string              `json:"life,omitempty" yaml:"life,omitempty"`
+	Relations     map[string][]string `json:"relations,omitempty" yaml:"relations,omitempty"`
+	Networks      map[string][]string `json:"networks,omitempty" yaml:"networks,omitempty"`
+	SubordinateTo []string            `json:"subordinate-to,omitempty" yaml:"subordinate-to,omitempty"`
+	Units         unitStatuses        `json:"units,omitempty" yaml:"units,omitempty"`
+	Provenance    *provenanceStatus   `json:"provenance,omitempty" yaml:"provenance,omitempty"`
 }
 
 type serviceStatusNoMarshal serviceStatus
@@ -180,17 +226,31 @@ func (s serviceStatus) GetYAML() (tag string, value interface{}) {
 	return "", sNoMethods(s)
 }
 
+// provenanceStatus describes how a service was deployed.
+type provenanceStatus struct {
+	User        string `json:"user" yaml:"user"`
+	ClientHost  string `json:"client-host,omitempty" yaml:"client-host,omitempty"`
+	Charm       string `json:"charm" yaml:"charm"`
+	CharmSha256 string `json:"charm-sha256,omitempty" yaml:"charm-sha256,omitempty"`
+	Bundle      string `json:"bundle,omitempty" yaml:"bundle,omitempty"`
+	Deployed    string `json:"deployed" yaml:"deployed"`
+}
+
 type unitStatus struct {
-	Err            error                 `json:"-" yaml:",omitempty"`
-	Charm          string                `json:"upgrading-from,omitempty" yaml:"upgrading-from,omitempty"`
-	AgentState     params.Status         `json:"agent-state,omitempty" yaml:"agent-state,omitempty"`
-	AgentStateInfo string                `json:"agent-state-info,omitempty" yaml:"agent-state-info,omitempty"`
-	AgentVersion   string                `json:"agent-version,omitempty" yaml:"agent-version,omitempty"`
-	Life           string                `json:"life,omitempty" yaml:"life,omitempty"`
-	Machine        string                `json:"machine,omitempty" yaml:"machine,omitempty"`
-	OpenedPorts    []string              `json:"open-ports,omitempty" yaml:"open-ports,omitempty"`
-	PublicAddress  string                `json:"public-address,omitempty" yaml:"public-address,omitempty"`
-	Subordinates   map[string]unitStatus `json:"subordinates,omitempty" yaml:"subordinates,omitempty"`
+	Err            error         `json:"-" yaml:",omitempty"`
+	Charm          string        `json:"upgrading-from,omitempty" yaml:"upgrading-from,omitempty"`
+	AgentState     params.Status `json:"agent-state,omitempty" yaml:"agent-state,omitempty"`
+	AgentStateInfo string        `json:"agent-state-info,omitempty" yaml:"agent-state-info,omitempty"`
+	AgentVersion   string        `json:"agent-version,omitempty" yaml:"agent-version,omitempty"`
+	Life           string        `json:"life,omitempty" yaml:"life,omitempty"`
+	Machine        string        `json:"machine,omitempty" yaml:"machine,omitempty"`
+	OpenedPorts    []string      `json:"open-ports,omitempty" yaml:"open-ports,omitempty"`
+	PublicAddress  string        `json:"public-address,omitempty" yaml:"public-address,omitempty"`
+	Subordinates   unitStatuses  `json:"subordinates,omitempty" yaml:"subordinates,omitempty"`
+
+	// sortKey holds the value units are ordered by,
+	// before their names, in json output.
+	sortKey string
 }
 
 type unitStatusNoMarshal unitStatus
@@ -210,6 +270,135 @@ func (s unitStatus) GetYAML() (tag string, value interface{}) {
 	return "", unitStatusNoMarshal(s)
 }
 
+// statusTable lays out the machines and units of
+// the status in the tabular and csv formats.
+var statusTable = jujucmd.Table{
+	Columns: []string{"KIND", "ID", "AGENT-STATE", "MACHINE", "ADDRESS", "INFO"},
+	Rows: func(value interface{}) ([][]string, error) {
+		status, ok := value.(formattedStatus)
+		if !ok {
+			return nil, fmt.Errorf("expected value of type %T, got %T", status, value)
+		}
+		var rows [][]string
+		var addMachines func(machines machineStatuses)
+		addMachines = func(machines machineStatuses) {
+			ids := make([]string, 0, len(machines))
+			for id := range machines {
+				ids = append(ids, id)
+			}
+			sort.Sort(naturally(ids))
+			for _, id := range ids {
+				m := machines[id]
+				info := m.AgentStateInfo
+				if m.Err != nil {
+					info = m.Err.Error()
+				}
+				rows = append(rows, []string{"machine", id, string(m.AgentState), "", m.DNSName, info})
+				addMachines(m.Containers)
+			}
+		}
+		addMachines(status.Machines)
+		var addUnits func(units unitStatuses)
+		addUnits = func(units unitStatuses) {
+			names := make([]string, 0, len(units))
+			for name := range units {
+				names = append(names, name)
+			}
+			sort.Sort(naturally(names))
+			for _, name := range names {
+				u := units[name]
+				info := u.AgentStateInfo
+				if u.Err != nil {
+					info = u.Err.Error()
+				}
+				rows = append(rows, []string{"unit", name, string(u.AgentState), u.Machine, u.PublicAddress, info})
+				addUnits(u.Subordinates)
+			}
+		}
+		serviceNames := make([]string, 0, len(status.Services))
+		for name := range status.Services {
+			serviceNames = append(serviceNames, name)
+		}
+		sort.Strings(serviceNames)
+		for _, name := range serviceNames {
+			addUnits(status.Services[name].Units)
+		}
+		return rows, nil
+	},
+}
+
+// machineStatuses holds the status of a set of machines, keyed
+// by machine id. Its json encoding lists the machines in natural
+// order of their ids, matching the yaml encoding.
+type machineStatuses map[string]machineStatus
+
+func (ms machineStatuses) MarshalJSON() ([]byte, error) {
+	ids := make([]string, 0, len(ms))
+	for id := range ms {
+		ids = append(ids, id)
+	}
+	sort.Sort(naturally(ids))
+	return marshalOrderedJSON(ids, func(id string) interface{} {
+		return ms[id]
+	})
+}
+
+// unitStatuses holds the status of a set of units, keyed by unit
+// name. Its json encoding lists the units by their sort key and
+// then in natural order of their names.
+type unitStatuses map[string]unitStatus
+
+func (us unitStatuses) MarshalJSON() ([]byte, error) {
+	names := make([]string, 0, len(us))
+	for name := range us {
+		names = append(names, name)
+	}
+	sort.Sort(unitsBySortKey{names, us})
+	return marshalOrderedJSON(names, func(name string) interface{} {
+		return us[name]
+	})
+}
+
+type unitsBySortKey struct {
+	names []string
+	units unitStatuses
+}
+
+func (u unitsBySortKey) Len() int      { return len(u.names) }
+func (u unitsBySortKey) Swap(i, j int) { u.names[i], u.names[j] = u.names[j], u.names[i] }
+func (u unitsBySortKey) Less(i, j int) bool {
+	ki, kj := u.units[u.names[i]].sortKey, u.units[u.names[j]].sortKey
+	if ki != kj {
+		return naturalLess(ki, kj)
+	}
+	return naturalLess(u.names[i], u.names[j])
+}
+
+// marshalOrderedJSON returns the json encoding of an object
+// holding the value returned by value for each of the given keys,
+// in the order given.
+func marshalOrderedJSON(keys []string, value func(key string) interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		data, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+		buf.WriteByte(':')
+		if data, err = json.Marshal(value(key)); err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
 type networkStatus struct {
 	Err        error      `json:"-" yaml:",omitempty"`
 	ProviderId network.Id `json:"provider-id" yaml:"provider-id"`
@@ -236,14 +425,18 @@ func (n networkStatus) GetYAML() (tag string, value interface{}) {
 }
 
 type statusFormatter struct {
-	status    *api.Status
-	relations map[int]api.RelationStatus
+	status            *api.Status
+	relations         map[int]api.RelationStatus
+	sortBy            string
+	includeProvenance bool
 }
 
-func newStatusFormatter(status *api.Status) *statusFormatter {
+func newStatusFormatter(status *api.Status, sortBy string, includeProvenance bool) *statusFormatter {
 	sf := statusFormatter{
-		status:    status,
-		relations: make(map[int]api.RelationStatus),
+		status:            status,
+		relations:         make(map[int]api.RelationStatus),
+		sortBy:            sortBy,
+		includeProvenance: includeProvenance,
 	}
 	for _, relation := range status.Relations {
 		sf.relations[relation.Id] = relation
@@ -257,7 +450,7 @@ func (sf *statusFormatter) format() formattedStatus {
 	}
 	out := formattedStatus{
 		Environment: sf.status.EnvironmentName,
-		Machines:    make(map[string]machineStatus),
+		Machines:    make(machineStatuses),
 		Services:    make(map[string]serviceStatus),
 	}
 	for k, m := range sf.status.Machines {
@@ -292,7 +485,7 @@ func (sf *statusFormatter) formatMachine(machine api.MachineStatus) machineStatu
 			InstanceState:  machine.InstanceState,
 			Series:         machine.Series,
 			Id:             machine.Id,
-			Containers:     make(map[string]machineStatus),
+			Containers:     make(machineStatuses),
 			Hardware:       machine.Hardware,
 		}
 	} else {
@@ -309,11 +502,12 @@ func (sf *statusFormatter) formatMachine(machine api.MachineStatus) machineStatu
 			InstanceState:  machine.InstanceState,
 			Series:         machine.Series,
 			Id:             machine.Id,
-			Containers:     make(map[string]machineStatus),
+			Containers:     make(machineStatuses),
 			Hardware:       machine.Hardware,
 		}
 	}
 
+	out.Maintenance = machine.Maintenance
 	for k, m := range machine.Containers {
 		out.Containers[k] = sf.formatMachine(m)
 	}
@@ -337,7 +531,7 @@ func (sf *statusFormatter) formatService(name string, service api.ServiceStatus)
 		Networks:      make(map[string][]string),
 		CanUpgradeTo:  service.CanUpgradeTo,
 		SubordinateTo: service.SubordinateTo,
-		Units:         make(map[string]unitStatus),
+		Units:         make(unitStatuses),
 	}
 	if len(service.Networks.Enabled) > 0 {
 		out.Networks["enabled"] = service.Networks.Enabled
@@ -348,9 +542,27 @@ func (sf *statusFormatter) formatService(name string, service api.ServiceStatus)
 	for k, m := range service.Units {
 		out.Units[k] = sf.formatUnit(m, name)
 	}
+	if sf.includeProvenance && service.Deployment != nil {
+		out.Provenance = formatProvenance(service.Deployment)
+	}
 	return out
 }
 
+func formatProvenance(record *api.DeploymentRecord) *provenanceStatus {
+	user := record.User
+	if tag, err := names.ParseTag(user); err == nil {
+		user = tag.Id()
+	}
+	return &provenanceStatus{
+		User:        user,
+		ClientHost:  record.ClientHost,
+		Charm:       record.CharmURL,
+		CharmSha256: record.CharmSha256,
+		Bundle:      record.Bundle,
+		Deployed:    record.Deployed.UTC().Format(time.RFC3339),
+	}
+}
+
 func (sf *statusFormatter) formatUnit(unit api.UnitStatus, serviceName string) unitStatus {
 	out := unitStatus{
 		Err:            unit.Err,
@@ -362,7 +574,10 @@ func (sf *statusFormatter) formatUnit(unit api.UnitStatus, serviceName string) u
 		OpenedPorts:    unit.OpenedPorts,
 		PublicAddress:  unit.PublicAddress,
 		Charm:          unit.Charm,
-		Subordinates:   make(map[string]unitStatus),
+		Subordinates:   make(unitStatuses),
+	}
+	if sf.sortBy == sortByMachine {
+		out.sortKey = unit.Machine
 	}
 	for k, m := range unit.Subordinates {
 		out.Subordinates[k] = sf.formatUnit(m, serviceName)
@@ -448,3 +663,47 @@ func adjustInfoIfAgentDown(status, origStatus params.Status, info string) string
 	}
 	return info
 }
+
+// naturally implements sort.Interface, ordering strings
+// with naturalLess.
+type naturally []string
+
+func (n naturally) Len() int           { return len(n) }
+func (n naturally) Less(i, j int) bool { return naturalLess(n[i], n[j]) }
+func (n naturally) Swap(i, j int)      { n[i], n[j] = n[j], n[i] }
+
+// naturalLess reports whether a sorts before b when runs of digits
+// are compared by their numeric value, so that "wordpress/2" sorts
+// before "wordpress/10".
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		na, nb := leadingDigits(a), leadingDigits(b)
+		if na > 0 && nb > 0 {
+			da := strings.TrimLeft(a[:na], "0")
+			db := strings.TrimLeft(b[:nb], "0")
+			if len(da) != len(db) {
+				return len(da) < len(db)
+			}
+			if da != db {
+				return da < db
+			}
+			a, b = a[na:], b[nb:]
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+// leadingDigits returns the number of decimal digits at the
+// start of s.
+func leadingDigits(s string) int {
+	n := 0
+	for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+		n++
+	}
+	return n
+}
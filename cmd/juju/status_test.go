@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/juju/charm"
 	charmtesting "github.com/juju/charm/testing"
@@ -1698,6 +1699,37 @@ var statusTests = []testCase{
 			},
 		},
 	),
+	test(
+		"machine in maintenance",
+		addMachine{machineId: "0", job: state.JobManageEnviron},
+		setAddresses{"0", []network.Address{network.NewAddress("dummyenv-0.dns", network.ScopeUnknown)}},
+		startAliveMachine{"0"},
+		setMachineStatus{"0", params.StatusStarted, ""},
+		addMachine{machineId: "1", job: state.JobHostUnits},
+		setAddresses{"1", []network.Address{network.NewAddress("dummyenv-1.dns", network.ScopeUnknown)}},
+		startAliveMachine{"1"},
+		setMachineStatus{"1", params.StatusStarted, ""},
+		setMachineMaintenance{"1"},
+
+		expect{
+			"machine 1 shows it is in maintenance",
+			M{
+				"environment": "dummyenv",
+				"machines": M{
+					"0": machine0,
+					"1": M{
+						"agent-state": "started",
+						"dns-name":    "dummyenv-1.dns",
+						"instance-id": "dummyenv-1",
+						"series":      "quantal",
+						"hardware":    "arch=amd64 cpu-cores=1 mem=1024M root-disk=8192M",
+						"maintenance": true,
+					},
+				},
+				"services": M{},
+			},
+		},
+	),
 }
 
 // TODO(dfc) test failing components by destructively mutating the state under the hood
@@ -2052,6 +2084,17 @@ func (sms setMachineStatus) step(c *gc.C, ctx *context) {
 	c.Assert(err, gc.IsNil)
 }
 
+type setMachineMaintenance struct {
+	machineId string
+}
+
+func (smm setMachineMaintenance) step(c *gc.C, ctx *context) {
+	m, err := ctx.st.Machine(smm.machineId)
+	c.Assert(err, gc.IsNil)
+	err = m.SetMaintenance()
+	c.Assert(err, gc.IsNil)
+}
+
 type relateServices struct {
 	ep1, ep2 string
 }
@@ -2311,3 +2354,170 @@ func (s *StatusSuite) TestStatusWithPreRelationsServer(c *gc.C) {
 	defer s.resetContext(c, ctx)
 	ctx.run(c, []stepper{expected})
 }
+
+func (s *StatusSuite) patchOrderingStatus() {
+	client := newFakeApiClient(&api.Status{
+		EnvironmentName: "dummyenv",
+		Machines: map[string]api.MachineStatus{
+			"1":  {Id: "1"},
+			"10": {Id: "10"},
+			"2":  {Id: "2"},
+		},
+		Services: map[string]api.ServiceStatus{
+			"wordpress": {
+				Charm: "cs:quantal/wordpress-3",
+				Units: map[string]api.UnitStatus{
+					"wordpress/1":  {Machine: "2"},
+					"wordpress/10": {Machine: "1"},
+					"wordpress/2":  {Machine: "10"},
+				},
+			},
+		},
+	})
+	s.PatchValue(&newApiClientForStatus, func(_ *StatusCommand) (statusAPI, error) {
+		return &client, nil
+	})
+}
+
+func (s *StatusSuite) TestStatusJSONNaturalOrder(c *gc.C) {
+	s.patchOrderingStatus()
+	code, stdout, stderr := runStatus(c, "--format", "json")
+	c.Assert(code, gc.Equals, 0)
+	c.Assert(string(stderr), gc.Equals, "")
+	c.Assert(string(stdout), gc.Equals, `{"environment":"dummyenv",`+
+		`"machines":{"1":{},"2":{},"10":{}},`+
+		`"services":{"wordpress":{"charm":"cs:quantal/wordpress-3","exposed":false,`+
+		`"units":{"wordpress/1":{"machine":"2"},"wordpress/2":{"machine":"10"},"wordpress/10":{"machine":"1"}}}}}`+"\n")
+}
+
+func (s *StatusSuite) TestStatusJSONSortByMachine(c *gc.C) {
+	s.patchOrderingStatus()
+	code, stdout, stderr := runStatus(c, "--format", "json", "--sort", "machine")
+	c.Assert(code, gc.Equals, 0)
+	c.Assert(string(stderr), gc.Equals, "")
+	c.Assert(string(stdout), jc.Contains,
+		`"units":{"wordpress/10":{"machine":"1"},"wordpress/1":{"machine":"2"},"wordpress/2":{"machine":"10"}}`)
+}
+
+func (s *StatusSuite) TestStatusInvalidSort(c *gc.C) {
+	code, _, stderr := runStatus(c, "--sort", "age")
+	c.Assert(code, gc.Equals, 2)
+	c.Assert(string(stderr), gc.Equals, `error: invalid sort order "age": expected "name" or "machine"`+"\n")
+}
+
+func (s *StatusSuite) TestStatusIncludeProvenance(c *gc.C) {
+	client := newFakeApiClient(&api.Status{
+		EnvironmentName: "dummyenv",
+		Services: map[string]api.ServiceStatus{
+			"wordpress": {
+				Charm: "cs:quantal/wordpress-3",
+				Deployment: &api.DeploymentRecord{
+					User:        "user-admin",
+					ClientHost:  "laptop",
+					CharmURL:    "cs:quantal/wordpress-3",
+					CharmSha256: "abc123",
+					Deployed:    time.Date(2014, 9, 1, 12, 30, 0, 0, time.UTC),
+				},
+			},
+		},
+	})
+	s.PatchValue(&newApiClientForStatus, func(_ *StatusCommand) (statusAPI, error) {
+		return &client, nil
+	})
+
+	// Provenance is only shown when requested.
+	code, stdout, _ := runStatus(c, "--format", "json")
+	c.Assert(code, gc.Equals, 0)
+	c.Assert(string(stdout), gc.Not(jc.Contains), "provenance")
+
+	code, stdout, stderr := runStatus(c, "--format", "json", "--include", "provenance")
+	c.Assert(code, gc.Equals, 0)
+	c.Assert(string(stderr), gc.Equals, "")
+	c.Assert(string(stdout), jc.Contains, `"provenance":{"user":"admin","client-host":"laptop",`+
+		`"charm":"cs:quantal/wordpress-3","charm-sha256":"abc123","deployed":"2014-09-01T12:30:00Z"}`)
+}
+
+func (s *StatusSuite) TestStatusInvalidInclude(c *gc.C) {
+	code, _, stderr := runStatus(c, "--include", "secrets")
+	c.Assert(code, gc.Equals, 2)
+	c.Assert(string(stderr), gc.Equals, `error: cannot include "secrets": expected "provenance"`+"\n")
+}
+
+func (s *StatusSuite) TestNaturalLess(c *gc.C) {
+	for i, test := range []struct {
+		a, b string
+		less bool
+	}{
+		{"a", "b", true},
+		{"b", "a", false},
+		{"a", "a", false},
+		{"wordpress/2", "wordpress/10", true},
+		{"wordpress/10", "wordpress/2", false},
+		{"1/lxc/3", "1/lxc/10", true},
+		{"1/lxc/0", "10", true},
+		{"mysql/1", "wordpress/0", true},
+		{"abc", "abcd", true},
+		{"007", "8", true},
+	} {
+		c.Logf("test %d: %q < %q", i, test.a, test.b)
+		c.Check(naturalLess(test.a, test.b), gc.Equals, test.less)
+	}
+}
+
+func (s *StatusSuite) TestStatusTable(c *gc.C) {
+	status := formattedStatus{
+		Machines: machineStatuses{
+			"10": {AgentState: params.StatusStarted, DNSName: "dummyenv-10.dns"},
+			"2": {
+				AgentState: params.StatusStarted,
+				DNSName:    "dummyenv-2.dns",
+				Containers: machineStatuses{
+					"2/lxc/0": {AgentState: params.StatusPending},
+				},
+			},
+			"3": {Err: fmt.Errorf("broken machine")},
+		},
+		Services: map[string]serviceStatus{
+			"wordpress": {
+				Units: unitStatuses{
+					"wordpress/0": {
+						AgentState:    params.StatusStarted,
+						Machine:       "2",
+						PublicAddress: "dummyenv-2.dns",
+						Subordinates: unitStatuses{
+							"logging/0": {AgentState: params.StatusError, AgentStateInfo: "hook failed"},
+						},
+					},
+				},
+			},
+			"mysql": {
+				Units: unitStatuses{
+					"mysql/0": {AgentState: params.StatusPending, Machine: "10"},
+				},
+			},
+		},
+	}
+	out, err := statusTable.FormatTabular(status)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(out), gc.Equals, ""+
+		"KIND     ID           AGENT-STATE  MACHINE  ADDRESS          INFO\n"+
+		"machine  2            started               dummyenv-2.dns   \n"+
+		"machine  2/lxc/0      pending                                \n"+
+		"machine  3                                                   broken machine\n"+
+		"machine  10           started               dummyenv-10.dns  \n"+
+		"unit     mysql/0      pending      10                        \n"+
+		"unit     wordpress/0  started      2        dummyenv-2.dns   \n"+
+		"unit     logging/0    error                                  hook failed")
+
+	out, err = statusTable.FormatCSV(status)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(out), gc.Equals, ""+
+		"KIND,ID,AGENT-STATE,MACHINE,ADDRESS,INFO\n"+
+		"machine,2,started,,dummyenv-2.dns,\n"+
+		"machine,2/lxc/0,pending,,,\n"+
+		"machine,3,,,,broken machine\n"+
+		"machine,10,started,,dummyenv-10.dns,\n"+
+		"unit,mysql/0,pending,10,,\n"+
+		"unit,wordpress/0,started,2,dummyenv-2.dns,\n"+
+		"unit,logging/0,error,,,hook failed")
+}
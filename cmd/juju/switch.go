@@ -4,20 +4,23 @@
 package main
 
 import (
-	"errors"
 	"fmt"
 	"os"
 	"sort"
 
 	"github.com/juju/cmd"
+	"github.com/juju/errors"
 	"launchpad.net/gnuflag"
 
+	jujucmd "github.com/juju/juju/cmd"
 	"github.com/juju/juju/cmd/envcmd"
 	"github.com/juju/juju/environs"
+	"github.com/juju/juju/environs/configstore"
 )
 
 type SwitchCommand struct {
 	cmd.CommandBase
+	out     cmd.Output
 	EnvName string
 	List    bool
 }
@@ -31,6 +34,19 @@ environment as defined by the file $JUJU_HOME/current-environment.
 If a command line parameter is passed in, that value will is stored in the
 current environment file if it represents a valid environment name as
 specified in the environments.yaml file.
+
+With --list, every environment in environments.yaml is shown with its
+provider type, whether it appears to be bootstrapped, and a "*" marking
+the current environment. An environment appears to be bootstrapped if
+the address of its API server is recorded in its .jenv file in
+$JUJU_HOME/environments. When JUJU_ENV overrides the current environment,
+the marker reads "* JUJU_ENV".
+
+Examples:
+    juju switch
+    juju switch staging
+    juju switch --list
+    juju switch --list --format yaml
 `
 
 func (c *SwitchCommand) Info() *cmd.Info {
@@ -46,6 +62,10 @@ func (c *SwitchCommand) Info() *cmd.Info {
 func (c *SwitchCommand) SetFlags(f *gnuflag.FlagSet) {
 	f.BoolVar(&c.List, "l", false, "list the environment names")
 	f.BoolVar(&c.List, "list", false, "")
+	c.out.AddFlags(f, "tabular", environmentsTable.Formatters(map[string]cmd.Formatter{
+		"yaml": cmd.FormatYaml,
+		"json": cmd.FormatJson,
+	}))
 }
 
 func (c *SwitchCommand) Init(args []string) (err error) {
@@ -81,10 +101,7 @@ func (c *SwitchCommand) Run(ctx *cmd.Context) error {
 		if c.EnvName != "" {
 			return errors.New("cannot switch and list at the same time")
 		}
-		for _, name := range names {
-			fmt.Fprintf(ctx.Stdout, "%s\n", name)
-		}
-		return nil
+		return c.out.Write(ctx, listEnvironments(environments, names))
 	}
 
 	jujuEnv := os.Getenv("JUJU_ENV")
@@ -126,3 +143,86 @@ func (c *SwitchCommand) Run(ctx *cmd.Context) error {
 	}
 	return nil
 }
+
+// environmentListing holds the details of an
+// environment shown by switch --list.
+type environmentListing struct {
+	Name         string `yaml:"name" json:"name"`
+	Type         string `yaml:"type" json:"type"`
+	Bootstrapped bool   `yaml:"bootstrapped" json:"bootstrapped"`
+	Current      bool   `yaml:"current,omitempty" json:"current,omitempty"`
+
+	// FromJujuEnv records that the environment is current
+	// because JUJU_ENV is set to its name.
+	FromJujuEnv bool `yaml:"from-juju-env,omitempty" json:"from-juju-env,omitempty"`
+}
+
+// listEnvironments returns the details of the named environments.
+func listEnvironments(environments *environs.Environs, names []string) []environmentListing {
+	current := os.Getenv("JUJU_ENV")
+	fromJujuEnv := current != ""
+	if current == "" {
+		current = envcmd.ReadCurrentEnvironment()
+	}
+	if current == "" {
+		current = environments.Default
+	}
+	// The store is only used to find out which environments
+	// are bootstrapped, so it is no matter if it cannot be read.
+	store, err := configstore.Default()
+	if err != nil {
+		logger.Warningf("cannot open the environment info store: %v", err)
+	}
+	listing := make([]environmentListing, len(names))
+	for i, name := range names {
+		listing[i] = environmentListing{
+			Name:         name,
+			Type:         environments.Type(name),
+			Bootstrapped: store != nil && isBootstrapped(store, name),
+			Current:      name == current,
+			FromJujuEnv:  name == current && fromJujuEnv,
+		}
+	}
+	return listing
+}
+
+// isBootstrapped returns whether the API server addresses
+// of the named environment are recorded in the store.
+func isBootstrapped(store configstore.Storage, name string) bool {
+	info, err := store.ReadInfo(name)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			logger.Warningf("cannot read the info of environment %q: %v", name, err)
+		}
+		return false
+	}
+	return len(info.APIEndpoint().Addresses) > 0
+}
+
+// environmentsTable lays out the environments listed by switch
+// --list in the tabular and csv formats.
+var environmentsTable = jujucmd.Table{
+	Columns: []string{"CURRENT", "ENVIRONMENT", "TYPE", "BOOTSTRAPPED"},
+	Rows: func(value interface{}) ([][]string, error) {
+		listing, ok := value.([]environmentListing)
+		if !ok {
+			return nil, fmt.Errorf("expected value of type %T, got %T", listing, value)
+		}
+		rows := make([][]string, len(listing))
+		for i, env := range listing {
+			current := ""
+			switch {
+			case env.FromJujuEnv:
+				current = "* JUJU_ENV"
+			case env.Current:
+				current = "*"
+			}
+			bootstrapped := "no"
+			if env.Bootstrapped {
+				bootstrapped = "yes"
+			}
+			rows[i] = []string{current, env.Name, env.Type, bootstrapped}
+		}
+		return rows, nil
+	},
+}
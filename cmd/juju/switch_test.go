@@ -10,6 +10,7 @@ import (
 	gc "launchpad.net/gocheck"
 
 	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/environs/configstore"
 	_ "github.com/juju/juju/juju"
 	"github.com/juju/juju/testing"
 )
@@ -87,23 +88,67 @@ func (*SwitchSimpleSuite) TestSettingWhenJujuEnvSet(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, `cannot switch when JUJU_ENV is overriding the environment \(set to "using-env"\)`)
 }
 
-const expectedEnvironments = `erewhemos
-erewhemos-2
+const expectedEnvironments = `
+CURRENT  ENVIRONMENT  TYPE   BOOTSTRAPPED
+*        erewhemos    dummy  no
+         erewhemos-2  dummy  no
 `
 
 func (*SwitchSimpleSuite) TestListEnvironments(c *gc.C) {
 	testing.WriteEnvironments(c, testing.MultipleEnvConfig)
 	context, err := testing.RunCommand(c, &SwitchCommand{}, "--list")
 	c.Assert(err, gc.IsNil)
-	c.Assert(testing.Stdout(context), gc.Equals, expectedEnvironments)
+	c.Assert(testing.Stdout(context), gc.Equals, expectedEnvironments[1:])
+}
+
+func (*SwitchSimpleSuite) TestListEnvironmentsCurrentEnvironment(c *gc.C) {
+	testing.WriteEnvironments(c, testing.MultipleEnvConfig)
+	err := envcmd.WriteCurrentEnvironment("erewhemos-2")
+	c.Assert(err, gc.IsNil)
+	context, err := testing.RunCommand(c, &SwitchCommand{}, "--list")
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(context), gc.Equals, ""+
+		"CURRENT  ENVIRONMENT  TYPE   BOOTSTRAPPED\n"+
+		"         erewhemos    dummy  no\n"+
+		"*        erewhemos-2  dummy  no\n")
 }
 
 func (*SwitchSimpleSuite) TestListEnvironmentsOSJujuEnvSet(c *gc.C) {
 	testing.WriteEnvironments(c, testing.MultipleEnvConfig)
-	os.Setenv("JUJU_ENV", "using-env")
+	os.Setenv("JUJU_ENV", "erewhemos-2")
 	context, err := testing.RunCommand(c, &SwitchCommand{}, "--list")
 	c.Assert(err, gc.IsNil)
-	c.Assert(testing.Stdout(context), gc.Equals, expectedEnvironments)
+	c.Assert(testing.Stdout(context), gc.Equals, ""+
+		"CURRENT     ENVIRONMENT  TYPE   BOOTSTRAPPED\n"+
+		"            erewhemos    dummy  no\n"+
+		"* JUJU_ENV  erewhemos-2  dummy  no\n")
+}
+
+func (*SwitchSimpleSuite) TestListEnvironmentsBootstrapped(c *gc.C) {
+	testing.WriteEnvironments(c, testing.MultipleEnvConfig)
+	store, err := configstore.Default()
+	c.Assert(err, gc.IsNil)
+	info := store.CreateInfo("erewhemos-2")
+	info.SetAPIEndpoint(configstore.APIEndpoint{Addresses: []string{"10.0.0.1:17070"}})
+	err = info.Write()
+	c.Assert(err, gc.IsNil)
+	// An environment that has been prepared but whose API
+	// server address is not known is not bootstrapped.
+	info = store.CreateInfo("erewhemos")
+	err = info.Write()
+	c.Assert(err, gc.IsNil)
+
+	context, err := testing.RunCommand(c, &SwitchCommand{}, "--list", "--format", "yaml")
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(context), gc.Equals, `
+- name: erewhemos
+  type: dummy
+  bootstrapped: false
+  current: true
+- name: erewhemos-2
+  type: dummy
+  bootstrapped: true
+`[1:])
 }
 
 func (*SwitchSimpleSuite) TestListEnvironmentsAndChange(c *gc.C) {
@@ -4,6 +4,9 @@
 package main
 
 import (
+	"fmt"
+
+	"github.com/juju/charm"
 	"github.com/juju/cmd"
 	"github.com/juju/loggo"
 	"launchpad.net/gnuflag"
@@ -11,6 +14,7 @@ import (
 	"github.com/juju/juju/cmd/envcmd"
 	"github.com/juju/juju/environs/filestorage"
 	"github.com/juju/juju/environs/sync"
+	"github.com/juju/juju/juju/arch"
 	"github.com/juju/juju/version"
 )
 
@@ -24,6 +28,9 @@ type SyncToolsCommand struct {
 	versionStr   string
 	majorVersion int
 	minorVersion int
+	number       version.Number
+	series       string
+	arch         string
 	dryRun       bool
 	dev          bool
 	public       bool
@@ -47,13 +54,26 @@ access the Internet. Alternatively you can specify a local directory as source.
 Sometimes this is because the environment does not have public access,
 and sometimes you just want to avoid having to access data outside of
 the local cloud.
+
+The tools copied can be restricted to a major[.minor] version or to an
+exact version with --version, and to a series and architecture with
+--series and --arch. Each tool is reported as it is copied, with its size
+and the amount of data copied so far; with --dry-run, the tools that would
+be copied are listed, with their size, and nothing is copied.
+
+Examples:
+    juju sync-tools --version 1.20
+    juju sync-tools --version 1.20.7 --series trusty --arch amd64
+    juju sync-tools --all --dry-run
 `,
 	}
 }
 
 func (c *SyncToolsCommand) SetFlags(f *gnuflag.FlagSet) {
 	f.BoolVar(&c.allVersions, "all", false, "copy all versions, not just the latest")
-	f.StringVar(&c.versionStr, "version", "", "copy a specific major[.minor] version")
+	f.StringVar(&c.versionStr, "version", "", "copy a specific major[.minor[.patch]] version")
+	f.StringVar(&c.series, "series", "", "copy only tools for the given series")
+	f.StringVar(&c.arch, "arch", "", "copy only tools for the given architecture")
 	f.BoolVar(&c.dryRun, "dry-run", false, "don't copy, just print what would be copied")
 	f.BoolVar(&c.dev, "dev", false, "consider development versions as well as released ones")
 	f.BoolVar(&c.public, "public", false, "tools are for a public cloud, so generate mirrors information")
@@ -71,9 +91,17 @@ func (c *SyncToolsCommand) Init(args []string) error {
 	if c.versionStr != "" {
 		var err error
 		if c.majorVersion, c.minorVersion, err = version.ParseMajorMinor(c.versionStr); err != nil {
-			return err
+			if c.number, err = version.Parse(c.versionStr); err != nil {
+				return fmt.Errorf("invalid version %q", c.versionStr)
+			}
 		}
 	}
+	if c.series != "" && !charm.IsValidSeries(c.series) {
+		return fmt.Errorf("invalid series %q", c.series)
+	}
+	if c.arch != "" && !arch.IsSupportedArch(c.arch) {
+		return fmt.Errorf("invalid architecture %q", c.arch)
+	}
 	return cmd.CheckEmpty(args)
 }
 
@@ -109,6 +137,9 @@ func (c *SyncToolsCommand) Run(ctx *cmd.Context) (resultErr error) {
 		AllVersions:  c.allVersions,
 		MajorVersion: c.majorVersion,
 		MinorVersion: c.minorVersion,
+		Number:       c.number,
+		Series:       c.series,
+		Arch:         c.arch,
 		DryRun:       c.dryRun,
 		Dev:          c.dev,
 		Public:       c.public,
@@ -18,6 +18,7 @@ import (
 	"github.com/juju/juju/environs/sync"
 	"github.com/juju/juju/provider/dummy"
 	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/version"
 )
 
 type syncToolsSuite struct {
@@ -118,6 +119,15 @@ var syncToolsCommandTests = []struct {
 			MinorVersion: 2,
 		},
 	},
+	{
+		description: "specify exact version, series and arch",
+		args:        []string{"-e", "test-target", "--version", "1.2.3", "--series", "trusty", "--arch", "amd64"},
+		sctx: &sync.SyncContext{
+			Number: version.MustParse("1.2.3"),
+			Series: "trusty",
+			Arch:   "amd64",
+		},
+	},
 }
 
 func (s *syncToolsSuite) TestSyncToolsCommand(c *gc.C) {
@@ -130,6 +140,9 @@ func (s *syncToolsSuite) TestSyncToolsCommand(c *gc.C) {
 			c.Assert(sctx.AllVersions, gc.Equals, test.sctx.AllVersions)
 			c.Assert(sctx.MajorVersion, gc.Equals, test.sctx.MajorVersion)
 			c.Assert(sctx.MinorVersion, gc.Equals, test.sctx.MinorVersion)
+			c.Assert(sctx.Number, gc.Equals, test.sctx.Number)
+			c.Assert(sctx.Series, gc.Equals, test.sctx.Series)
+			c.Assert(sctx.Arch, gc.Equals, test.sctx.Arch)
 			c.Assert(sctx.DryRun, gc.Equals, test.sctx.DryRun)
 			c.Assert(sctx.Dev, gc.Equals, test.sctx.Dev)
 			c.Assert(sctx.Public, gc.Equals, test.sctx.Public)
@@ -146,6 +159,26 @@ func (s *syncToolsSuite) TestSyncToolsCommand(c *gc.C) {
 	}
 }
 
+func (s *syncToolsSuite) TestSyncToolsCommandInvalidFilters(c *gc.C) {
+	for i, test := range []struct {
+		args []string
+		err  string
+	}{{
+		args: []string{"--version", "1.2.3.4.5"},
+		err:  `invalid version "1.2.3.4.5"`,
+	}, {
+		args: []string{"--series", "bad series"},
+		err:  `invalid series "bad series"`,
+	}, {
+		args: []string{"--arch", "z80"},
+		err:  `invalid architecture "z80"`,
+	}} {
+		c.Logf("test %d: %v", i, test.args)
+		err := coretesting.InitCommand(envcmd.Wrap(&SyncToolsCommand{}), test.args)
+		c.Assert(err, gc.ErrorMatches, test.err)
+	}
+}
+
 func (s *syncToolsSuite) TestSyncToolsCommandTargetDirectory(c *gc.C) {
 	called := false
 	dir := c.MkDir()
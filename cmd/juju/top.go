@@ -0,0 +1,402 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/juju/cmd"
+	"github.com/juju/names"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/state/api"
+	"github.com/juju/juju/state/api/params"
+)
+
+const topDoc = `
+Shows the activity of the environment, refreshed live until interrupted.
+
+Every interval, the screen shows:
+
+  - the rate of API requests served by the state server that juju top
+    is connected to, and the number of connections open to it;
+  - the rate of changes to units, which follow the hooks they run, the
+    number of hooks that have failed and the number of units in error;
+  - the number of machines waiting for an instance to be provisioned,
+    and the number whose provisioning has failed;
+  - the machines, units and services that changed most often during
+    the interval, with their current status.
+
+Unit agents do not report each hook they run, so the rate of unit
+changes stands in for the rate of hook executions. With --count, juju
+top stops after showing that many screens, which is useful when its
+output is not a terminal.
+
+Examples:
+    juju top
+    juju top --interval 5s --busiest 20
+    juju top --count 1
+`
+
+// TopCommand shows the activity of the environment.
+type TopCommand struct {
+	envcmd.EnvCommandBase
+	interval time.Duration
+	count    int
+	busiest  int
+}
+
+func (c *TopCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "top",
+		Purpose: "show the activity of the environment",
+		Doc:     topDoc,
+	}
+}
+
+func (c *TopCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.DurationVar(&c.interval, "interval", 2*time.Second, "time between refreshes")
+	f.IntVar(&c.count, "n", 0, "stop after showing this many screens (0 means never)")
+	f.IntVar(&c.count, "count", 0, "")
+	f.IntVar(&c.busiest, "busiest", 10, "number of busiest entities shown")
+}
+
+func (c *TopCommand) Init(args []string) error {
+	if c.interval <= 0 {
+		return fmt.Errorf("invalid interval %v: must be positive", c.interval)
+	}
+	if c.count < 0 {
+		return fmt.Errorf("invalid count %d: must not be negative", c.count)
+	}
+	if c.busiest < 0 {
+		return fmt.Errorf("invalid number of busiest entities %d: must not be negative", c.busiest)
+	}
+	return cmd.CheckEmpty(args)
+}
+
+// topAPI defines the API methods used by the top command.
+type topAPI interface {
+	APIActivity() (params.APIActivity, error)
+	WatchAll() (allWatcher, error)
+	Close() error
+}
+
+// allWatcher defines the methods of the API's all-watcher
+// used by the top command.
+type allWatcher interface {
+	Next() ([]params.Delta, error)
+	Stop() error
+}
+
+var getTopAPI = func(c *TopCommand) (topAPI, error) {
+	client, err := c.NewAPIClient()
+	if err != nil {
+		return nil, err
+	}
+	return topClient{client}, nil
+}
+
+// topClient adapts the API client to topAPI.
+type topClient struct {
+	*api.Client
+}
+
+func (c topClient) WatchAll() (allWatcher, error) {
+	w, err := c.Client.WatchAll()
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// clearScreen moves the cursor home and clears the terminal.
+const clearScreen = "\x1b[H\x1b[2J"
+
+func (c *TopCommand) Run(ctx *cmd.Context) error {
+	client, err := getTopAPI(c)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	watcher, err := client.WatchAll()
+	if err != nil {
+		return err
+	}
+	defer watcher.Stop()
+	// The first changes hold the whole environment,
+	// so they are not counted as activity.
+	initial, err := watcher.Next()
+	if err != nil {
+		return err
+	}
+	model := newTopModel()
+	model.seed(initial)
+
+	done := make(chan struct{})
+	defer close(done)
+	deltas := make(chan []params.Delta)
+	watchErr := make(chan error, 1)
+	go func() {
+		for {
+			d, err := watcher.Next()
+			if err != nil {
+				watchErr <- err
+				return
+			}
+			select {
+			case deltas <- d:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	last, err := client.APIActivity()
+	if err != nil {
+		return err
+	}
+	lastTime := time.Now()
+	interrupted := make(chan os.Signal, 1)
+	ctx.InterruptNotify(interrupted)
+	defer ctx.StopInterruptNotify(interrupted)
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	terminal := isTerminal(ctx.Stdout)
+	for screens := 0; c.count == 0 || screens < c.count; {
+		select {
+		case d := <-deltas:
+			model.update(d)
+		case err := <-watchErr:
+			return err
+		case <-interrupted:
+			return nil
+		case now := <-ticker.C:
+			activity, err := client.APIActivity()
+			if err != nil {
+				return err
+			}
+			calls := activity.Calls - last.Calls
+			if calls < 0 {
+				// The state server has restarted.
+				calls = activity.Calls
+			}
+			if terminal {
+				fmt.Fprint(ctx.Stdout, clearScreen)
+			} else if screens > 0 {
+				fmt.Fprintln(ctx.Stdout)
+			}
+			model.render(ctx.Stdout, topScreen{
+				EnvName:     c.ConnectionName(),
+				Time:        now,
+				Elapsed:     now.Sub(lastTime),
+				APICalls:    calls,
+				Connections: activity.Connections,
+				Busiest:     c.busiest,
+			})
+			model.reset()
+			last, lastTime = activity, now
+			screens++
+		}
+	}
+	return nil
+}
+
+// isTerminal returns whether the given writer is a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// topModel holds the state of the environment as seen by
+// the top command, and the activity since the last screen.
+type topModel struct {
+	machines map[string]*params.MachineInfo
+	units    map[string]*params.UnitInfo
+
+	// statuses holds the current status of each
+	// entity, keyed by tag.
+	statuses map[string]string
+
+	// changes holds the number of changes of
+	// each entity since the last screen.
+	changes      map[string]int
+	unitChanges  int
+	hookFailures int
+}
+
+func newTopModel() *topModel {
+	m := &topModel{
+		machines: make(map[string]*params.MachineInfo),
+		units:    make(map[string]*params.UnitInfo),
+		statuses: make(map[string]string),
+	}
+	m.reset()
+	return m
+}
+
+// seed records the given changes without counting them as activity.
+func (m *topModel) seed(deltas []params.Delta) {
+	m.update(deltas)
+	m.reset()
+}
+
+// reset forgets the activity since the last screen,
+// and the entities that have been removed.
+func (m *topModel) reset() {
+	for tag, status := range m.statuses {
+		if status == "removed" {
+			delete(m.statuses, tag)
+		}
+	}
+	m.changes = make(map[string]int)
+	m.unitChanges = 0
+	m.hookFailures = 0
+}
+
+// update records the given changes.
+func (m *topModel) update(deltas []params.Delta) {
+	for _, delta := range deltas {
+		var tag, status string
+		switch info := delta.Entity.(type) {
+		case *params.MachineInfo:
+			tag, status = names.NewMachineTag(info.Id).String(), string(info.Status)
+			if delta.Removed {
+				delete(m.machines, info.Id)
+			} else {
+				m.machines[info.Id] = info
+			}
+		case *params.UnitInfo:
+			tag, status = names.NewUnitTag(info.Name).String(), string(info.Status)
+			m.unitChanges++
+			if delta.Removed {
+				delete(m.units, info.Name)
+				break
+			}
+			last := m.units[info.Name]
+			if isHookFailure(info) && (last == nil || !isHookFailure(last) || last.StatusInfo != info.StatusInfo) {
+				m.hookFailures++
+			}
+			m.units[info.Name] = info
+		case *params.ServiceInfo:
+			tag, status = names.NewServiceTag(info.Name).String(), string(info.Life)
+		default:
+			continue
+		}
+		if delta.Removed {
+			status = "removed"
+		}
+		m.statuses[tag] = status
+		m.changes[tag]++
+	}
+}
+
+// unitsInError returns the number of units in an error state.
+func (m *topModel) unitsInError() int {
+	n := 0
+	for _, info := range m.units {
+		if info.Status == params.StatusError {
+			n++
+		}
+	}
+	return n
+}
+
+// isHookFailure returns whether the status
+// of the given unit reports a failed hook.
+func isHookFailure(u *params.UnitInfo) bool {
+	return u.Status == params.StatusError && strings.HasPrefix(u.StatusInfo, "hook failed")
+}
+
+// provisioning returns the number of live machines waiting for an
+// instance, and the number of those whose provisioning has failed.
+func (m *topModel) provisioning() (queued, failed int) {
+	for _, info := range m.machines {
+		if info.InstanceId != "" || info.Life != params.Alive {
+			continue
+		}
+		if info.Status == params.StatusError {
+			failed++
+		} else {
+			queued++
+		}
+	}
+	return queued, failed
+}
+
+// topScreen holds the details of a screen
+// shown by the top command.
+type topScreen struct {
+	EnvName     string
+	Time        time.Time
+	Elapsed     time.Duration
+	APICalls    int64
+	Connections int64
+	Busiest     int
+}
+
+// busiestEntity holds the number of changes of an entity.
+type busiestEntity struct {
+	tag     string
+	changes int
+}
+
+type byChanges []busiestEntity
+
+func (b byChanges) Len() int      { return len(b) }
+func (b byChanges) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byChanges) Less(i, j int) bool {
+	if b[i].changes != b[j].changes {
+		return b[i].changes > b[j].changes
+	}
+	return b[i].tag < b[j].tag
+}
+
+// render writes a screen showing the activity since the last one.
+func (m *topModel) render(w io.Writer, screen topScreen) {
+	rate := func(n int64) string {
+		seconds := screen.Elapsed.Seconds()
+		if seconds <= 0 {
+			return "-"
+		}
+		return fmt.Sprintf("%.1f/s", float64(n)/seconds)
+	}
+	queued, failed := m.provisioning()
+	fmt.Fprintf(w, "juju top - %s - %s\n\n", screen.EnvName, screen.Time.Format("15:04:05"))
+	tw := tabwriter.NewWriter(w, 0, 1, 2, ' ', 0)
+	fmt.Fprintf(tw, "API calls:\t%s\t(%d connections)\n", rate(screen.APICalls), screen.Connections)
+	fmt.Fprintf(tw, "Unit changes:\t%s\t(%d units)\n", rate(int64(m.unitChanges)), len(m.units))
+	fmt.Fprintf(tw, "Hook failures:\t%d\t(%d units in error)\n", m.hookFailures, m.unitsInError())
+	fmt.Fprintf(tw, "Provisioning queue:\t%d\t(%d failed)\n", queued, failed)
+	tw.Flush()
+
+	busiest := make([]busiestEntity, 0, len(m.changes))
+	for tag, changes := range m.changes {
+		busiest = append(busiest, busiestEntity{tag, changes})
+	}
+	sort.Sort(byChanges(busiest))
+	if len(busiest) > screen.Busiest {
+		busiest = busiest[:screen.Busiest]
+	}
+	if len(busiest) == 0 {
+		return
+	}
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 1, 2, ' ', 0)
+	fmt.Fprintln(tw, "ENTITY\tCHANGES\tSTATUS")
+	for _, b := range busiest {
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", b.tag, b.changes, m.statuses[b.tag])
+	}
+	tw.Flush()
+}
@@ -0,0 +1,210 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/testing"
+)
+
+type TopSuite struct {
+	testing.FakeJujuHomeSuite
+	api *mockTopAPI
+}
+
+var _ = gc.Suite(&TopSuite{})
+
+func (s *TopSuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	s.api = &mockTopAPI{
+		watcher: &mockAllWatcher{
+			initial: []params.Delta{{
+				Entity: &params.MachineInfo{Id: "0", InstanceId: "i-0", Status: params.StatusStarted, Life: params.Alive},
+			}, {
+				Entity: &params.UnitInfo{Name: "mysql/0", Status: params.StatusStarted},
+			}},
+			deltas:  make(chan []params.Delta, 10),
+			stopped: make(chan struct{}),
+		},
+	}
+	s.PatchValue(&getTopAPI, func(*TopCommand) (topAPI, error) {
+		return s.api, nil
+	})
+}
+
+func runTop(c *gc.C, args ...string) (string, error) {
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&TopCommand{}), args...)
+	if err != nil {
+		return "", err
+	}
+	return testing.Stdout(ctx), nil
+}
+
+func (s *TopSuite) TestInit(c *gc.C) {
+	_, err := runTop(c, "--interval", "0s")
+	c.Assert(err, gc.ErrorMatches, "invalid interval 0s?: must be positive")
+	_, err = runTop(c, "--count", "-1")
+	c.Assert(err, gc.ErrorMatches, "invalid count -1: must not be negative")
+	_, err = runTop(c, "--busiest", "-1")
+	c.Assert(err, gc.ErrorMatches, "invalid number of busiest entities -1: must not be negative")
+	_, err = runTop(c, "foo")
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["foo"\]`)
+}
+
+func (s *TopSuite) TestTop(c *gc.C) {
+	s.api.watcher.deltas <- []params.Delta{{
+		Entity: &params.MachineInfo{Id: "1", Status: params.StatusPending, Life: params.Alive},
+	}, {
+		Entity: &params.UnitInfo{Name: "mysql/0", Status: params.StatusError, StatusInfo: `hook failed: "config-changed"`},
+	}}
+	out, err := runTop(c, "--count", "1", "--interval", "50ms")
+	c.Assert(err, gc.IsNil)
+	c.Assert(out, gc.Matches, `juju top - .* - \d\d:\d\d:\d\d
+
+API calls: +\d+\.\d/s +\(1 connections\)
+Unit changes: +\d+\.\d/s +\(1 units\)
+Hook failures: +1 +\(1 units in error\)
+Provisioning queue: +1 +\(0 failed\)
+
+ENTITY +CHANGES +STATUS
+machine-1 +1 +pending
+unit-mysql-0 +1 +error
+`)
+	c.Assert(s.api.closed, gc.Equals, true)
+	c.Assert(s.api.watcher.isStopped(), gc.Equals, true)
+}
+
+func (s *TopSuite) TestTopWatcherError(c *gc.C) {
+	s.api.watcher.err = errors.New("watcher was stopped")
+	close(s.api.watcher.deltas)
+	_, err := runTop(c, "--interval", "1h")
+	c.Assert(err, gc.ErrorMatches, "watcher was stopped")
+}
+
+func (s *TopSuite) TestTopAPIError(c *gc.C) {
+	s.api.err = errors.New("connection refused")
+	_, err := runTop(c, "--count", "1")
+	c.Assert(err, gc.ErrorMatches, "connection refused")
+}
+
+func (s *TopSuite) TestModel(c *gc.C) {
+	m := newTopModel()
+	m.seed([]params.Delta{{
+		Entity: &params.MachineInfo{Id: "0", InstanceId: "i-0", Life: params.Alive},
+	}, {
+		Entity: &params.UnitInfo{Name: "mysql/0", Status: params.StatusError, StatusInfo: `hook failed: "install"`},
+	}, {
+		Entity: &params.ServiceInfo{Name: "mysql", Life: params.Alive},
+	}})
+	m.update([]params.Delta{{
+		Entity: &params.MachineInfo{Id: "1", Life: params.Alive},
+	}, {
+		Entity: &params.MachineInfo{Id: "2", Status: params.StatusError, Life: params.Alive},
+	}, {
+		Entity: &params.MachineInfo{Id: "3", Life: params.Dying},
+	}, {
+		// The same failure does not count twice.
+		Entity: &params.UnitInfo{Name: "mysql/0", Status: params.StatusError, StatusInfo: `hook failed: "install"`},
+	}, {
+		Entity: &params.UnitInfo{Name: "mysql/0", Status: params.StatusStarted},
+	}, {
+		Entity: &params.UnitInfo{Name: "mysql/0", Status: params.StatusError, StatusInfo: `hook failed: "config-changed"`},
+	}, {
+		Entity: &params.UnitInfo{Name: "mysql/1", Status: params.StatusError, StatusInfo: "disk full"},
+	}, {
+		Removed: true,
+		Entity:  &params.ServiceInfo{Name: "mysql"},
+	}})
+	var buf bytes.Buffer
+	m.render(&buf, topScreen{
+		EnvName:     "local",
+		Time:        time.Date(2014, 10, 15, 12, 30, 0, 0, time.UTC),
+		Elapsed:     2 * time.Second,
+		APICalls:    25,
+		Connections: 3,
+		Busiest:     3,
+	})
+	c.Assert(buf.String(), gc.Equals, `
+juju top - local - 12:30:00
+
+API calls:           12.5/s  (3 connections)
+Unit changes:        2.0/s   (2 units)
+Hook failures:       1       (2 units in error)
+Provisioning queue:  1       (1 failed)
+
+ENTITY        CHANGES  STATUS
+unit-mysql-0  3        error
+machine-1     1        pending
+machine-2     1        error
+`[1:])
+
+	// The activity is forgotten for the next screen.
+	m.reset()
+	buf.Reset()
+	m.render(&buf, topScreen{EnvName: "local", Elapsed: time.Second, Busiest: 3})
+	c.Assert(buf.String(), gc.Matches, `(?s).*Unit changes: +0\.0/s +\(2 units\)\nHook failures: +0 +\(2 units in error\)\n.*\(1 failed\)\n$`)
+}
+
+type mockTopAPI struct {
+	watcher *mockAllWatcher
+	err     error
+	closed  bool
+}
+
+func (m *mockTopAPI) APIActivity() (params.APIActivity, error) {
+	return params.APIActivity{Calls: 10, Connections: 1}, m.err
+}
+
+func (m *mockTopAPI) WatchAll() (allWatcher, error) {
+	return m.watcher, nil
+}
+
+func (m *mockTopAPI) Close() error {
+	m.closed = true
+	return nil
+}
+
+type mockAllWatcher struct {
+	initial []params.Delta
+	deltas  chan []params.Delta
+	err     error
+	stopped chan struct{}
+}
+
+func (w *mockAllWatcher) Next() ([]params.Delta, error) {
+	if d := w.initial; d != nil {
+		w.initial = nil
+		return d, nil
+	}
+	select {
+	case d, ok := <-w.deltas:
+		if ok {
+			return d, nil
+		}
+		return nil, w.err
+	case <-w.stopped:
+		return nil, errors.New("watcher was stopped")
+	}
+}
+
+func (w *mockAllWatcher) Stop() error {
+	close(w.stopped)
+	return nil
+}
+
+func (w *mockAllWatcher) isStopped() bool {
+	select {
+	case <-w.stopped:
+		return true
+	default:
+		return false
+	}
+}
@@ -9,28 +9,45 @@ import (
 	"github.com/juju/cmd"
 
 	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/network"
 )
 
 // UnexposeCommand is responsible exposing services.
 type UnexposeCommand struct {
 	envcmd.EnvCommandBase
 	ServiceName string
+	Ports       []network.Port
 }
 
+var jujuUnexposeHelp = `
+Adjusts firewall rules and similar security mechanisms of the provider, so
+that the service is no longer accessible on its public address.
+
+If ports are given, as <port>[/<protocol>], only those ports are made
+inaccessible; the service is unexposed once none of its exposed ports
+remain. Ports can only be given if the service was exposed with ports.
+
+Examples:
+    juju unexpose wordpress
+    juju unexpose wordpress 80/tcp
+`
+
 func (c *UnexposeCommand) Info() *cmd.Info {
 	return &cmd.Info{
 		Name:    "unexpose",
-		Args:    "<service>",
+		Args:    "<service> [<port>[/<protocol>] ...]",
 		Purpose: "unexpose a service",
+		Doc:     jujuUnexposeHelp,
 	}
 }
 
-func (c *UnexposeCommand) Init(args []string) error {
+func (c *UnexposeCommand) Init(args []string) (err error) {
 	if len(args) == 0 {
 		return errors.New("no service name specified")
 	}
 	c.ServiceName = args[0]
-	return cmd.CheckEmpty(args[1:])
+	c.Ports, err = parsePorts(args[1:])
+	return err
 }
 
 // Run changes the juju-managed firewall to hide any
@@ -41,5 +58,8 @@ func (c *UnexposeCommand) Run(_ *cmd.Context) error {
 		return err
 	}
 	defer client.Close()
+	if len(c.Ports) > 0 {
+		return client.ServiceUnexposePorts(c.ServiceName, c.Ports)
+	}
 	return client.ServiceUnexpose(c.ServiceName)
 }
@@ -6,10 +6,12 @@ package main
 import (
 	"github.com/juju/charm"
 	charmtesting "github.com/juju/charm/testing"
+	jc "github.com/juju/testing/checkers"
 	gc "launchpad.net/gocheck"
 
 	"github.com/juju/juju/cmd/envcmd"
 	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/network"
 	"github.com/juju/juju/testing"
 )
 
@@ -49,3 +51,22 @@ func (s *UnexposeSuite) TestUnexpose(c *gc.C) {
 	err = runUnexpose(c, "nonexistent-service")
 	c.Assert(err, gc.ErrorMatches, `service "nonexistent-service" not found`)
 }
+
+func (s *UnexposeSuite) TestUnexposePorts(c *gc.C) {
+	charmtesting.Charms.BundlePath(s.SeriesPath, "dummy")
+	err := runDeploy(c, "local:dummy", "some-service-name")
+	c.Assert(err, gc.IsNil)
+
+	err = runExpose(c, "some-service-name", "80", "443")
+	c.Assert(err, gc.IsNil)
+	err = runUnexpose(c, "some-service-name", "80/tcp")
+	c.Assert(err, gc.IsNil)
+	s.assertExposed(c, "some-service-name", true)
+	svc, err := s.State.Service("some-service-name")
+	c.Assert(err, gc.IsNil)
+	c.Assert(svc.ExposedPorts(), jc.DeepEquals, []network.Port{{"tcp", 443}})
+
+	err = runUnexpose(c, "some-service-name", "443/tcp")
+	c.Assert(err, gc.IsNil)
+	s.assertExposed(c, "some-service-name", false)
+}
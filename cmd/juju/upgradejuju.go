@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"sort"
 	"strings"
 
 	"github.com/juju/cmd"
@@ -66,6 +67,10 @@ value of the environment's agent-version setting:
 Both of these depend on tools availability, which some situations (no
 outgoing internet access) and provider types (such as maas) require that
 you manage yourself; see the documentation for "sync-tools".
+
+With --dry-run, the available tools, the version that would be chosen
+and the agents that would be upgraded to it are reported, but the
+environment's agent-version is left unchanged.
 `
 
 func (c *UpgradeJujuCommand) Info() *cmd.Info {
@@ -161,6 +166,13 @@ func (c *UpgradeJujuCommand) Run(ctx *cmd.Context) (err error) {
 	ctx.Infof("available tools:\n%s", formatTools(context.tools))
 	ctx.Infof("best version:\n    %s", context.chosen)
 	if c.DryRun {
+		status, err := client.Status(nil)
+		if err != nil {
+			return err
+		}
+		if agents := agentsToUpgrade(context.chosen, status); len(agents) > 0 {
+			ctx.Infof("agents to upgrade:\n    %s", strings.Join(agents, "\n    "))
+		}
 		ctx.Infof("upgrade to this version by running\n    juju upgrade-juju --version=\"%s\"\n", context.chosen)
 	} else {
 		if err := client.SetEnvironAgentVersion(context.chosen); err != nil {
@@ -171,6 +183,26 @@ func (c *UpgradeJujuCommand) Run(ctx *cmd.Context) (err error) {
 	return nil
 }
 
+// agentsToUpgrade returns the machine and unit agents in the given
+// status that are not yet running the chosen version, along with
+// the version they are running.
+func agentsToUpgrade(chosen version.Number, status *api.Status) []string {
+	versions := collectAgentVersions(chosen.String(), status)
+	var agents []string
+	add := func(kind string, agentVersions map[string]string) {
+		for name, v := range agentVersions {
+			if v != chosen.String() {
+				agents = append(agents, fmt.Sprintf("%s %s: %s", kind, name, v))
+			}
+		}
+	}
+	add("machine", versions.StateServers)
+	add("machine", versions.Machines)
+	add("unit", versions.Units)
+	sort.Strings(agents)
+	return agents
+}
+
 // initVersions collects state relevant to an upgrade decision. The returned
 // agent and client versions, and the list of currently available tools, will
 // always be accurate; the chosen version, and the flag indicating development
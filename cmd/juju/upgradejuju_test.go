@@ -23,6 +23,8 @@ import (
 	envtools "github.com/juju/juju/environs/tools"
 	toolstesting "github.com/juju/juju/environs/tools/testing"
 	"github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state/api"
+	"github.com/juju/juju/state/api/params"
 	coretesting "github.com/juju/juju/testing"
 	"github.com/juju/juju/version"
 )
@@ -488,3 +490,37 @@ upgrade to this version by running
 		c.Assert(output, gc.Equals, test.expectedCmdOutput)
 	}
 }
+
+func (s *UpgradeJujuSuite) TestAgentsToUpgrade(c *gc.C) {
+	status := &api.Status{
+		Machines: map[string]api.MachineStatus{
+			"0": {
+				Agent: api.AgentStatus{Version: "2.2.3"},
+				Jobs:  []params.MachineJob{params.JobManageEnviron},
+			},
+			"1": {
+				Agent: api.AgentStatus{Version: "2.0.0"},
+				Containers: map[string]api.MachineStatus{
+					"1/lxc/0": {AgentVersion: "2.0.0"},
+				},
+			},
+			"2": {},
+		},
+		Services: map[string]api.ServiceStatus{
+			"mysql": {
+				Units: map[string]api.UnitStatus{
+					"mysql/0": {Agent: api.AgentStatus{Version: "2.0.0"}},
+					"mysql/1": {Agent: api.AgentStatus{Version: "2.2.3"}},
+				},
+			},
+		},
+	}
+	agents := agentsToUpgrade(version.MustParse("2.2.3"), status)
+	c.Assert(agents, jc.DeepEquals, []string{
+		"machine 1/lxc/0: 2.0.0",
+		"machine 1: 2.0.0",
+		"machine 2: unknown",
+		"unit mysql/0: 2.0.0",
+	})
+	c.Assert(agentsToUpgrade(version.MustParse("2.2.3"), &api.Status{}), gc.HasLen, 0)
+}
@@ -0,0 +1,94 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/environs"
+)
+
+const validateConfigDoc = `
+Checks the configuration of environments in environments.yaml without
+bootstrapping them. Each configuration is checked by its provider, so
+provider-specific problems such as missing credentials, unknown regions
+and settings of the wrong type are found as well as general ones.
+
+If no environment names are given, every environment is checked. All the
+environments are checked even if some of them are invalid, and a line is
+printed for each one.
+
+Examples:
+    juju validate-config
+    juju validate-config amazon maas
+`
+
+// ValidateConfigCommand checks the configuration
+// of environments in environments.yaml.
+type ValidateConfigCommand struct {
+	cmd.CommandBase
+	EnvNames []string
+}
+
+func (c *ValidateConfigCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "validate-config",
+		Args:    "[<environment name> ...]",
+		Purpose: "check the configuration of environments without bootstrapping",
+		Doc:     validateConfigDoc,
+	}
+}
+
+func (c *ValidateConfigCommand) Init(args []string) error {
+	c.EnvNames = args
+	return nil
+}
+
+func (c *ValidateConfigCommand) Run(ctx *cmd.Context) error {
+	// Passing through the empty string reads the default environments.yaml file.
+	environments, err := environs.ReadEnvirons("")
+	if err != nil {
+		return err
+	}
+	names := c.EnvNames
+	if len(names) == 0 {
+		names = environments.Names()
+		sort.Strings(names)
+	}
+	invalid := 0
+	for _, name := range names {
+		if err := validateEnvironConfig(environments, name); err != nil {
+			fmt.Fprintf(ctx.Stdout, "%s: %v\n", name, err)
+			invalid++
+			continue
+		}
+		fmt.Fprintf(ctx.Stdout, "%s: ok\n", name)
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d environments have an invalid configuration", invalid, len(names))
+	}
+	return nil
+}
+
+// validateEnvironConfig checks the configuration of the named
+// environment as its provider would before preparing it.
+func validateEnvironConfig(environments *environs.Environs, name string) error {
+	cfg, err := environments.Config(name)
+	if errors.IsNotFound(err) {
+		return fmt.Errorf("environment not found")
+	}
+	if err != nil {
+		return err
+	}
+	provider, err := environs.Provider(cfg.Type())
+	if err != nil {
+		return err
+	}
+	_, err = provider.Validate(cfg, nil)
+	return err
+}
@@ -0,0 +1,59 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	gc "launchpad.net/gocheck"
+
+	_ "github.com/juju/juju/juju"
+	"github.com/juju/juju/testing"
+)
+
+type ValidateConfigSuite struct {
+	testing.FakeJujuHomeSuite
+}
+
+var _ = gc.Suite(&ValidateConfigSuite{})
+
+const invalidEnvConfig = `
+environments:
+    erewhemos:
+        type: dummy
+        authorized-keys: i-am-a-key
+    bad-state-id:
+        type: dummy
+        authorized-keys: i-am-a-key
+        state-id: not-a-number
+    bad-firewall:
+        type: dummy
+        authorized-keys: i-am-a-key
+        firewall-mode: sometimes
+    bad-type:
+        type: nowhere
+`
+
+func (*ValidateConfigSuite) TestValidateAll(c *gc.C) {
+	testing.WriteEnvironments(c, testing.MultipleEnvConfig)
+	ctx, err := testing.RunCommand(c, &ValidateConfigCommand{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, "erewhemos: ok\nerewhemos-2: ok\n")
+}
+
+func (*ValidateConfigSuite) TestValidateReportsAllProblems(c *gc.C) {
+	testing.WriteEnvironments(c, invalidEnvConfig)
+	ctx, err := testing.RunCommand(c, &ValidateConfigCommand{})
+	c.Assert(err, gc.ErrorMatches, "3 of 4 environments have an invalid configuration")
+	c.Assert(testing.Stdout(ctx), gc.Matches, ""+
+		`bad-firewall: invalid firewall mode in environment configuration: "sometimes"\n`+
+		`bad-state-id: invalid state-id "not-a-number"\n`+
+		`bad-type: environment "bad-type" has an unknown provider type "nowhere"\n`+
+		`erewhemos: ok\n`)
+}
+
+func (*ValidateConfigSuite) TestValidateNamed(c *gc.C) {
+	testing.WriteEnvironments(c, invalidEnvConfig)
+	ctx, err := testing.RunCommand(c, &ValidateConfigCommand{}, "erewhemos", "missing")
+	c.Assert(err, gc.ErrorMatches, "1 of 2 environments have an invalid configuration")
+	c.Assert(testing.Stdout(ctx), gc.Equals, "erewhemos: ok\nmissing: environment not found\n")
+}
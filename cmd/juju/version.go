@@ -0,0 +1,192 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/juju/cmd"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/juju"
+	"github.com/juju/juju/state/api"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/version"
+)
+
+const versionDoc = `
+Prints the version of this juju client.
+
+With --all, the environment is also queried for the version of the tools
+running on its state servers and on every machine and unit agent. Agents
+running a version other than the environment's agent-version are listed
+under "mismatched", which helps to find the agents left behind by an
+upgrade that has not completed.
+
+Examples:
+    juju version
+    juju version --all
+    juju version --all -e staging --format json
+`
+
+// VersionCommand prints the version of the client and,
+// optionally, of the agents of an environment.
+type VersionCommand struct {
+	cmd.CommandBase
+	out     cmd.Output
+	all     bool
+	envName string
+}
+
+func (c *VersionCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "version",
+		Purpose: "print the current version",
+		Doc:     versionDoc,
+	}
+}
+
+func (c *VersionCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "smart", cmd.DefaultFormatters)
+	f.BoolVar(&c.all, "all", false, "also print the versions of the environment's agents")
+	f.StringVar(&c.envName, "e", "", "juju environment to query with --all")
+	f.StringVar(&c.envName, "environment", "", "")
+}
+
+func (c *VersionCommand) Init(args []string) error {
+	if c.envName != "" && !c.all {
+		return fmt.Errorf("--environment requires --all")
+	}
+	return cmd.CheckEmpty(args)
+}
+
+// versionAPI defines the API methods used by the version command.
+type versionAPI interface {
+	Status(patterns []string) (*api.Status, error)
+	EnvironmentGet() (map[string]interface{}, error)
+	Close() error
+}
+
+var getVersionAPI = func(envName string) (versionAPI, error) {
+	root, err := juju.NewAPIFromName(envName)
+	if err != nil {
+		return nil, err
+	}
+	return root.Client(), nil
+}
+
+// agentVersions holds the versions printed by version --all.
+type agentVersions struct {
+	Client       string            `yaml:"client" json:"client"`
+	Environment  string            `yaml:"environment" json:"environment"`
+	StateServers map[string]string `yaml:"state-servers" json:"state-servers"`
+	Machines     map[string]string `yaml:"machines,omitempty" json:"machines,omitempty"`
+	Units        map[string]string `yaml:"units,omitempty" json:"units,omitempty"`
+	Mismatched   []string          `yaml:"mismatched,omitempty" json:"mismatched,omitempty"`
+}
+
+// unknownVersion is reported for agents that have
+// not yet reported the version they are running.
+const unknownVersion = "unknown"
+
+func (c *VersionCommand) Run(ctx *cmd.Context) error {
+	if !c.all {
+		return c.out.Write(ctx, version.Current.String())
+	}
+	envName := c.envName
+	if envName == "" {
+		var err error
+		if envName, err = envcmd.GetDefaultEnvironment(); err != nil {
+			return err
+		}
+	}
+	client, err := getVersionAPI(envName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	attrs, err := client.EnvironmentGet()
+	if err != nil {
+		return err
+	}
+	status, err := client.Status(nil)
+	if err != nil {
+		return err
+	}
+	envVersion, _ := attrs["agent-version"].(string)
+	return c.out.Write(ctx, collectAgentVersions(envVersion, status))
+}
+
+// collectAgentVersions returns the versions of the agents
+// in the given status, flagging those that differ from
+// the environment's agent version.
+func collectAgentVersions(envVersion string, status *api.Status) *agentVersions {
+	versions := &agentVersions{
+		Client:       version.Current.String(),
+		Environment:  envVersion,
+		StateServers: make(map[string]string),
+		Machines:     make(map[string]string),
+		Units:        make(map[string]string),
+	}
+	check := func(kind, name, agentVersion string) string {
+		if agentVersion == "" {
+			return unknownVersion
+		}
+		if agentVersion != envVersion {
+			versions.Mismatched = append(versions.Mismatched, fmt.Sprintf("%s %s: %s", kind, name, agentVersion))
+		}
+		return agentVersion
+	}
+	var addMachine func(id string, m api.MachineStatus)
+	addMachine = func(id string, m api.MachineStatus) {
+		v := check("machine", id, agentStatusVersion(m.Agent, m.AgentVersion))
+		if isStateServer(m) {
+			versions.StateServers[id] = v
+		} else {
+			versions.Machines[id] = v
+		}
+		for id, container := range m.Containers {
+			addMachine(id, container)
+		}
+	}
+	for id, m := range status.Machines {
+		addMachine(id, m)
+	}
+	var addUnit func(name string, u api.UnitStatus)
+	addUnit = func(name string, u api.UnitStatus) {
+		versions.Units[name] = check("unit", name, agentStatusVersion(u.Agent, u.AgentVersion))
+		for name, subordinate := range u.Subordinates {
+			addUnit(name, subordinate)
+		}
+	}
+	for _, service := range status.Services {
+		for name, u := range service.Units {
+			addUnit(name, u)
+		}
+	}
+	sort.Strings(versions.Mismatched)
+	return versions
+}
+
+// agentStatusVersion returns the version reported by an agent,
+// falling back to the legacy field filled in by older servers.
+func agentStatusVersion(agent api.AgentStatus, legacyVersion string) string {
+	if agent.Version != "" {
+		return agent.Version
+	}
+	return legacyVersion
+}
+
+// isStateServer returns whether the given machine manages the environment.
+func isStateServer(m api.MachineStatus) bool {
+	for _, job := range m.Jobs {
+		if job == params.JobManageEnviron {
+			return true
+		}
+	}
+	return false
+}
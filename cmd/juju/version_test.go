@@ -0,0 +1,140 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+	"launchpad.net/goyaml"
+
+	"github.com/juju/juju/state/api"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/testing"
+	"github.com/juju/juju/version"
+)
+
+type VersionSuite struct {
+	testing.FakeJujuHomeSuite
+	api     *mockVersionAPI
+	envName string
+}
+
+var _ = gc.Suite(&VersionSuite{})
+
+func (s *VersionSuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	s.PatchValue(&version.Current, version.MustParseBinary("1.21.0-trusty-amd64"))
+	s.api = &mockVersionAPI{
+		config: map[string]interface{}{"agent-version": "1.21.0"},
+		status: &api.Status{
+			Machines: map[string]api.MachineStatus{
+				"0": {
+					Agent: api.AgentStatus{Version: "1.21.0"},
+					Jobs:  []params.MachineJob{params.JobManageEnviron},
+				},
+				"1": {
+					Agent: api.AgentStatus{Version: "1.21.0"},
+					Jobs:  []params.MachineJob{params.JobHostUnits},
+					Containers: map[string]api.MachineStatus{
+						"1/lxc/0": {AgentVersion: "1.20.7"},
+					},
+				},
+				"2": {},
+			},
+			Services: map[string]api.ServiceStatus{
+				"mysql": {
+					Units: map[string]api.UnitStatus{
+						"mysql/0": {
+							Agent: api.AgentStatus{Version: "1.20.7"},
+							Subordinates: map[string]api.UnitStatus{
+								"logging/0": {Agent: api.AgentStatus{Version: "1.21.0"}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	s.envName = ""
+	s.PatchValue(&getVersionAPI, func(envName string) (versionAPI, error) {
+		s.envName = envName
+		return s.api, nil
+	})
+}
+
+func (s *VersionSuite) TestInit(c *gc.C) {
+	err := testing.InitCommand(&VersionCommand{}, []string{"foo"})
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["foo"\]`)
+	err = testing.InitCommand(&VersionCommand{}, []string{"-e", "staging"})
+	c.Assert(err, gc.ErrorMatches, "--environment requires --all")
+}
+
+func (s *VersionSuite) TestVersion(c *gc.C) {
+	ctx, err := testing.RunCommand(c, &VersionCommand{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, "1.21.0-trusty-amd64\n")
+	c.Assert(s.envName, gc.Equals, "")
+}
+
+func (s *VersionSuite) TestVersionAll(c *gc.C) {
+	ctx, err := testing.RunCommand(c, &VersionCommand{}, "--all")
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.envName, gc.Equals, "erewhemos")
+	var versions map[string]interface{}
+	err = goyaml.Unmarshal([]byte(testing.Stdout(ctx)), &versions)
+	c.Assert(err, gc.IsNil)
+	c.Assert(versions, jc.DeepEquals, map[string]interface{}{
+		"client":        "1.21.0-trusty-amd64",
+		"environment":   "1.21.0",
+		"state-servers": map[interface{}]interface{}{"0": "1.21.0"},
+		"machines": map[interface{}]interface{}{
+			"1":       "1.21.0",
+			"1/lxc/0": "1.20.7",
+			"2":       "unknown",
+		},
+		"units": map[interface{}]interface{}{
+			"logging/0": "1.21.0",
+			"mysql/0":   "1.20.7",
+		},
+		"mismatched": []interface{}{
+			"machine 1/lxc/0: 1.20.7",
+			"unit mysql/0: 1.20.7",
+		},
+	})
+}
+
+func (s *VersionSuite) TestVersionAllEnvironment(c *gc.C) {
+	s.api.status = &api.Status{}
+	ctx, err := testing.RunCommand(c, &VersionCommand{}, "--all", "-e", "staging", "--format", "json")
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.envName, gc.Equals, "staging")
+	c.Assert(testing.Stdout(ctx), gc.Equals,
+		`{"client":"1.21.0-trusty-amd64","environment":"1.21.0","state-servers":{}}`+"\n")
+}
+
+func (s *VersionSuite) TestVersionAllError(c *gc.C) {
+	s.api.err = fmt.Errorf("connection refused")
+	_, err := testing.RunCommand(c, &VersionCommand{}, "--all")
+	c.Assert(err, gc.ErrorMatches, "connection refused")
+}
+
+type mockVersionAPI struct {
+	config map[string]interface{}
+	status *api.Status
+	err    error
+}
+
+func (*mockVersionAPI) Close() error {
+	return nil
+}
+
+func (m *mockVersionAPI) EnvironmentGet() (map[string]interface{}, error) {
+	return m.config, m.err
+}
+
+func (m *mockVersionAPI) Status(patterns []string) (*api.Status, error) {
+	return m.status, m.err
+}
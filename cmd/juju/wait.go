@@ -0,0 +1,175 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/juju/cmd"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/state/api"
+	"github.com/juju/juju/state/api/params"
+)
+
+const waitDoc = `
+Waits until the environment has settled: every machine and container has
+been provisioned and its agent has started, every unit has run its install,
+config-changed and start hooks, and nothing is in an error state.
+
+The exit status tells scripts and CI pipelines why the command finished:
+
+    0  the environment has settled
+    1  the command failed, for example the API could not be reached
+    3  a machine or unit is in an error state
+    4  the timeout expired before the environment settled
+
+Examples:
+    juju deploy mysql && juju wait
+    juju wait --timeout 1h
+`
+
+// Exit statuses returned by the wait command, in addition
+// to 0 on success and 1 for general failures.
+const (
+	waitErrorExitStatus   = 3
+	waitTimeoutExitStatus = 4
+)
+
+// WaitCommand waits for the environment to settle.
+type WaitCommand struct {
+	envcmd.EnvCommandBase
+	timeout time.Duration
+}
+
+func (c *WaitCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "wait",
+		Purpose: "wait until all machines and units have started",
+		Doc:     waitDoc,
+	}
+}
+
+func (c *WaitCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.DurationVar(&c.timeout, "timeout", 30*time.Minute, "how long to wait for the environment to settle")
+}
+
+func (c *WaitCommand) Init(args []string) error {
+	if c.timeout <= 0 {
+		return fmt.Errorf("timeout must be positive")
+	}
+	return cmd.CheckEmpty(args)
+}
+
+// waitAPI defines the API methods used by the wait command.
+type waitAPI interface {
+	Status(patterns []string) (*api.Status, error)
+	Close() error
+}
+
+var getWaitAPI = func(c *WaitCommand) (waitAPI, error) {
+	return c.NewAPIClient()
+}
+
+// waitPollInterval holds how often the wait command
+// checks the status of the environment.
+var waitPollInterval = 5 * time.Second
+
+func (c *WaitCommand) Run(ctx *cmd.Context) error {
+	client, err := getWaitAPI(c)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	timeout := time.After(c.timeout)
+	for {
+		status, err := client.Status(nil)
+		if err != nil {
+			return err
+		}
+		pending, failed := unsettled(status)
+		if len(failed) > 0 {
+			for _, msg := range failed {
+				fmt.Fprintf(ctx.Stderr, "%s\n", msg)
+			}
+			return cmd.NewRcPassthroughError(waitErrorExitStatus)
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+		select {
+		case <-timeout:
+			fmt.Fprintf(ctx.Stderr, "timed out after %v waiting for:\n", c.timeout)
+			for _, msg := range pending {
+				fmt.Fprintf(ctx.Stderr, "  %s\n", msg)
+			}
+			return cmd.NewRcPassthroughError(waitTimeoutExitStatus)
+		case <-time.After(waitPollInterval):
+		}
+	}
+}
+
+// unsettled returns descriptions of the machines and units in the
+// given status that have not yet started, and of those that are in
+// an error state, both sorted.
+func unsettled(status *api.Status) (pending, failed []string) {
+	for id, m := range status.Machines {
+		checkMachineSettled(id, m, &pending, &failed)
+	}
+	for serviceName, service := range status.Services {
+		if service.Err != nil {
+			failed = append(failed, fmt.Sprintf("service %s: %v", serviceName, service.Err))
+			continue
+		}
+		for name, unit := range service.Units {
+			checkUnitSettled(name, unit, &pending, &failed)
+		}
+	}
+	sort.Strings(pending)
+	sort.Strings(failed)
+	return pending, failed
+}
+
+func checkMachineSettled(id string, m api.MachineStatus, pending, failed *[]string) {
+	switch {
+	case m.Err != nil:
+		*failed = append(*failed, fmt.Sprintf("machine %s: %v", id, m.Err))
+	case m.AgentState == params.StatusError:
+		*failed = append(*failed, fmt.Sprintf("machine %s: %s", id, m.AgentStateInfo))
+	case m.InstanceId == "":
+		*pending = append(*pending, fmt.Sprintf("machine %s: not provisioned", id))
+	case m.AgentState != params.StatusStarted:
+		*pending = append(*pending, fmt.Sprintf("machine %s: %s", id, agentState(m.AgentState)))
+	}
+	for id, container := range m.Containers {
+		checkMachineSettled(id, container, pending, failed)
+	}
+}
+
+func checkUnitSettled(name string, u api.UnitStatus, pending, failed *[]string) {
+	switch {
+	case u.Err != nil:
+		*failed = append(*failed, fmt.Sprintf("unit %s: %v", name, u.Err))
+	case u.AgentState == params.StatusError:
+		*failed = append(*failed, fmt.Sprintf("unit %s: %s", name, u.AgentStateInfo))
+	case u.AgentState != params.StatusStarted:
+		*pending = append(*pending, fmt.Sprintf("unit %s: %s", name, agentState(u.AgentState)))
+	}
+	for name, subordinate := range u.Subordinates {
+		checkUnitSettled(name, subordinate, pending, failed)
+	}
+}
+
+// agentState returns the given agent state, or
+// "pending" if none has been set yet.
+func agentState(status params.Status) params.Status {
+	if status == "" {
+		return params.StatusPending
+	}
+	return status
+}
@@ -0,0 +1,153 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"time"
+
+	"github.com/juju/cmd"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/state/api"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/testing"
+)
+
+type WaitSuite struct {
+	testing.FakeJujuHomeSuite
+	api *mockWaitAPI
+}
+
+var _ = gc.Suite(&WaitSuite{})
+
+func (s *WaitSuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	s.api = &mockWaitAPI{}
+	s.PatchValue(&getWaitAPI, func(_ *WaitCommand) (waitAPI, error) {
+		return s.api, nil
+	})
+	s.PatchValue(&waitPollInterval, time.Millisecond)
+}
+
+func runWait(c *gc.C, args ...string) (string, error) {
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&WaitCommand{}), args...)
+	return testing.Stderr(ctx), err
+}
+
+func settledStatus() *api.Status {
+	return &api.Status{
+		Machines: map[string]api.MachineStatus{
+			"0": {
+				InstanceId: instance.Id("i-0"),
+				AgentState: params.StatusStarted,
+				Containers: map[string]api.MachineStatus{
+					"0/lxc/0": {InstanceId: instance.Id("i-0-lxc-0"), AgentState: params.StatusStarted},
+				},
+			},
+		},
+		Services: map[string]api.ServiceStatus{
+			"wordpress": {
+				Units: map[string]api.UnitStatus{
+					"wordpress/0": {
+						AgentState: params.StatusStarted,
+						Subordinates: map[string]api.UnitStatus{
+							"logging/0": {AgentState: params.StatusStarted},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func exitStatus(c *gc.C, err error) int {
+	c.Assert(err, jc.Satisfies, cmd.IsRcPassthroughError)
+	return err.(*cmd.RcPassthroughError).Code
+}
+
+func (s *WaitSuite) TestInit(c *gc.C) {
+	err := testing.InitCommand(envcmd.Wrap(&WaitCommand{}), []string{"extra"})
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["extra"\]`)
+	err = testing.InitCommand(envcmd.Wrap(&WaitCommand{}), []string{"--timeout", "0"})
+	c.Assert(err, gc.ErrorMatches, "timeout must be positive")
+}
+
+func (s *WaitSuite) TestSettled(c *gc.C) {
+	s.api.statuses = []*api.Status{settledStatus()}
+	out, err := runWait(c)
+	c.Assert(err, gc.IsNil)
+	c.Assert(out, gc.Equals, "")
+	c.Assert(s.api.calls, gc.Equals, 1)
+}
+
+func (s *WaitSuite) TestWaitsUntilSettled(c *gc.C) {
+	pending := settledStatus()
+	pending.Machines["1"] = api.MachineStatus{}
+	pending.Services["mysql"] = api.ServiceStatus{
+		Units: map[string]api.UnitStatus{
+			"mysql/0": {AgentState: params.StatusInstalled},
+		},
+	}
+	s.api.statuses = []*api.Status{pending, pending, settledStatus()}
+	_, err := runWait(c)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.api.calls, gc.Equals, 3)
+}
+
+func (s *WaitSuite) TestError(c *gc.C) {
+	failed := settledStatus()
+	unit := failed.Services["wordpress"].Units["wordpress/0"]
+	unit.Subordinates["logging/0"] = api.UnitStatus{
+		AgentState:     params.StatusError,
+		AgentStateInfo: `hook failed: "install"`,
+	}
+	failed.Machines["1"] = api.MachineStatus{
+		AgentState:     params.StatusError,
+		AgentStateInfo: "no matching tools available",
+	}
+	s.api.statuses = []*api.Status{failed}
+	out, err := runWait(c)
+	c.Assert(exitStatus(c, err), gc.Equals, waitErrorExitStatus)
+	c.Assert(out, gc.Equals, ""+
+		"machine 1: no matching tools available\n"+
+		"unit logging/0: hook failed: \"install\"\n")
+}
+
+func (s *WaitSuite) TestTimeout(c *gc.C) {
+	pending := settledStatus()
+	pending.Machines["1"] = api.MachineStatus{}
+	pending.Services["mysql"] = api.ServiceStatus{
+		Units: map[string]api.UnitStatus{"mysql/0": {}},
+	}
+	s.api.statuses = []*api.Status{pending}
+	out, err := runWait(c, "--timeout", "10ms")
+	c.Assert(exitStatus(c, err), gc.Equals, waitTimeoutExitStatus)
+	c.Assert(out, gc.Equals, ""+
+		"timed out after 10ms waiting for:\n"+
+		"  machine 1: not provisioned\n"+
+		"  unit mysql/0: pending\n")
+}
+
+// mockWaitAPI returns the given statuses in turn,
+// repeating the last one.
+type mockWaitAPI struct {
+	statuses []*api.Status
+	calls    int
+}
+
+func (m *mockWaitAPI) Status(patterns []string) (*api.Status, error) {
+	i := m.calls
+	if i >= len(m.statuses) {
+		i = len(m.statuses) - 1
+	}
+	m.calls++
+	return m.statuses[i], nil
+}
+
+func (*mockWaitAPI) Close() error {
+	return nil
+}
@@ -0,0 +1,133 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+)
+
+// interfaceAddrs is patched in tests.
+var interfaceAddrs = net.InterfaceAddrs
+
+// apiListener returns a listener for the API server on the given
+// port. If listenNetwork is empty, it listens on all addresses.
+// Otherwise it listens on this machine's own addresses within that
+// network, and on the loopback address so that the agents on the
+// machine can always reach the API server at localhost.
+func apiListener(listenNetwork string, port int) (net.Listener, error) {
+	portString := strconv.Itoa(port)
+	if listenNetwork == "" {
+		return net.Listen("tcp", net.JoinHostPort("", portString))
+	}
+	_, ipNet, err := net.ParseCIDR(listenNetwork)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := interfaceAddrs()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get machine addresses: %v", err)
+	}
+	hosts := []string{"127.0.0.1"}
+	for _, addr := range addrs {
+		ipAddr, ok := addr.(*net.IPNet)
+		if !ok || ipAddr.IP.IsLoopback() || !ipNet.Contains(ipAddr.IP) {
+			continue
+		}
+		hosts = append(hosts, ipAddr.IP.String())
+	}
+	if len(hosts) == 1 {
+		return nil, fmt.Errorf("machine has no address in api-listen-network %s", listenNetwork)
+	}
+	var listeners []net.Listener
+	for _, host := range hosts {
+		listener, err := net.Listen("tcp", net.JoinHostPort(host, portString))
+		if err != nil {
+			for _, listener := range listeners {
+				listener.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, listener)
+	}
+	return newMultiListener(listeners), nil
+}
+
+// multiListener is a net.Listener that accepts connections from
+// several listeners.
+type multiListener struct {
+	listeners []net.Listener
+	accepted  chan acceptResult
+	closing   chan struct{}
+	closeOnce sync.Once
+}
+
+type acceptResult struct {
+	conn net.Conn
+	err  error
+}
+
+func newMultiListener(listeners []net.Listener) *multiListener {
+	l := &multiListener{
+		listeners: listeners,
+		accepted:  make(chan acceptResult),
+		closing:   make(chan struct{}),
+	}
+	for _, listener := range listeners {
+		go l.accept(listener)
+	}
+	return l
+}
+
+func (l *multiListener) accept(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		select {
+		case l.accepted <- acceptResult{conn, err}:
+		case <-l.closing:
+			if conn != nil {
+				conn.Close()
+			}
+			return
+		}
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Temporary() {
+				continue
+			}
+			return
+		}
+	}
+}
+
+// Accept implements net.Listener.Accept.
+func (l *multiListener) Accept() (net.Conn, error) {
+	select {
+	case result := <-l.accepted:
+		return result.conn, result.err
+	case <-l.closing:
+		return nil, fmt.Errorf("listener closed")
+	}
+}
+
+// Close implements net.Listener.Close.
+func (l *multiListener) Close() error {
+	var err error
+	l.closeOnce.Do(func() {
+		close(l.closing)
+		for _, listener := range l.listeners {
+			if e := listener.Close(); e != nil && err == nil {
+				err = e
+			}
+		}
+	})
+	return err
+}
+
+// Addr implements net.Listener.Addr. It returns the address of the
+// first listener; all the listeners share the same port.
+func (l *multiListener) Addr() net.Addr {
+	return l.listeners[0].Addr()
+}
@@ -0,0 +1,98 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"net"
+
+	gc "launchpad.net/gocheck"
+
+	coretesting "github.com/juju/juju/testing"
+)
+
+type apiListenerSuite struct {
+	coretesting.BaseSuite
+}
+
+var _ = gc.Suite(&apiListenerSuite{})
+
+// freePort returns a port that is not currently in use.
+func freePort(c *gc.C) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, gc.IsNil)
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+// nonLoopbackAddr returns an IPv4 address of this machine that is
+// not a loopback address.
+func nonLoopbackAddr(c *gc.C) *net.IPNet {
+	addrs, err := net.InterfaceAddrs()
+	c.Assert(err, gc.IsNil)
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && ipNet.IP.To4() != nil {
+			return ipNet
+		}
+	}
+	c.Skip("machine has no non-loopback IPv4 address")
+	return nil
+}
+
+func assertAccepts(c *gc.C, listener net.Listener, addr string) {
+	conn, err := net.Dial("tcp", addr)
+	c.Assert(err, gc.IsNil)
+	defer conn.Close()
+	accepted, err := listener.Accept()
+	c.Assert(err, gc.IsNil)
+	accepted.Close()
+}
+
+func (s *apiListenerSuite) TestAllAddresses(c *gc.C) {
+	port := freePort(c)
+	listener, err := apiListener("", port)
+	c.Assert(err, gc.IsNil)
+	defer listener.Close()
+	c.Assert(listener.Addr().(*net.TCPAddr).Port, gc.Equals, port)
+	assertAccepts(c, listener, (&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port}).String())
+}
+
+func (s *apiListenerSuite) TestListenNetwork(c *gc.C) {
+	ipNet := nonLoopbackAddr(c)
+	network := &net.IPNet{IP: ipNet.IP.Mask(ipNet.Mask), Mask: ipNet.Mask}
+	port := freePort(c)
+	listener, err := apiListener(network.String(), port)
+	c.Assert(err, gc.IsNil)
+	defer listener.Close()
+
+	// The machine's address in the network and localhost
+	// are both served, on the same port.
+	assertAccepts(c, listener, (&net.TCPAddr{IP: ipNet.IP, Port: port}).String())
+	assertAccepts(c, listener, (&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port}).String())
+}
+
+func (s *apiListenerSuite) TestNoAddressInNetwork(c *gc.C) {
+	s.PatchValue(&interfaceAddrs, func() ([]net.Addr, error) {
+		return []net.Addr{
+			&net.IPNet{IP: net.IPv4(127, 0, 0, 1), Mask: net.CIDRMask(8, 32)},
+			&net.IPNet{IP: net.IPv4(10, 0, 0, 1), Mask: net.CIDRMask(24, 32)},
+		}, nil
+	})
+	listener, err := apiListener("192.168.0.0/24", freePort(c))
+	c.Assert(err, gc.ErrorMatches, "machine has no address in api-listen-network 192.168.0.0/24")
+	c.Assert(listener, gc.IsNil)
+}
+
+func (s *apiListenerSuite) TestMultiListenerClose(c *gc.C) {
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, gc.IsNil)
+	listener := newMultiListener([]net.Listener{inner})
+	c.Assert(listener.Addr(), gc.Equals, inner.Addr())
+	err = listener.Close()
+	c.Assert(err, gc.IsNil)
+	_, err = listener.Accept()
+	c.Assert(err, gc.ErrorMatches, "listener closed")
+	// Closing again is harmless.
+	err = listener.Close()
+	c.Assert(err, gc.IsNil)
+}
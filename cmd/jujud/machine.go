@@ -9,7 +9,6 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
-	"strconv"
 	"sync"
 	"time"
 
@@ -21,7 +20,6 @@ import (
 	"github.com/juju/utils"
 	"github.com/juju/utils/symlink"
 	"github.com/juju/utils/voyeur"
-	"gopkg.in/mgo.v2"
 	"launchpad.net/gnuflag"
 	"launchpad.net/tomb"
 
@@ -44,6 +42,7 @@ import (
 	"github.com/juju/juju/version"
 	"github.com/juju/juju/worker"
 	"github.com/juju/juju/worker/apiaddressupdater"
+	"github.com/juju/juju/worker/auditpruner"
 	"github.com/juju/juju/worker/authenticationworker"
 	"github.com/juju/juju/worker/charmrevisionworker"
 	"github.com/juju/juju/worker/cleaner"
@@ -57,12 +56,16 @@ import (
 	"github.com/juju/juju/worker/minunitsworker"
 	"github.com/juju/juju/worker/networker"
 	"github.com/juju/juju/worker/peergrouper"
+	"github.com/juju/juju/worker/presencetimings"
 	"github.com/juju/juju/worker/provisioner"
 	"github.com/juju/juju/worker/resumer"
 	"github.com/juju/juju/worker/rsyslog"
 	"github.com/juju/juju/worker/singular"
+	"github.com/juju/juju/worker/statepruner"
 	"github.com/juju/juju/worker/terminationworker"
 	"github.com/juju/juju/worker/upgrader"
+	"github.com/juju/juju/worker/utilization"
+	"github.com/juju/juju/worker/webhooks"
 )
 
 var logger = loggo.GetLogger("juju.cmd.jujud")
@@ -85,6 +88,7 @@ var (
 	maybeInitiateMongoServer = peergrouper.MaybeInitiateMongoServer
 	ensureMongoAdminUser     = mongo.EnsureAdminUser
 	newSingularRunner        = singular.New
+	newLeaseRunner           = singular.NewLeaseRunner
 	peergrouperNew           = peergrouper.New
 
 	// reportOpenedAPI is exposed for tests to know when
@@ -296,6 +300,13 @@ func (a *MachineAgent) APIWorker() (worker.Worker, error) {
 	a.startWorkerAfterUpgrade(runner, "machiner", func() (worker.Worker, error) {
 		return machiner.NewMachiner(st.Machiner(), agentConfig), nil
 	})
+	a.startWorkerAfterUpgrade(runner, "utilization", func() (worker.Worker, error) {
+		m, err := st.Machiner().Machine(agentConfig.Tag().(names.MachineTag))
+		if err != nil {
+			return nil, err
+		}
+		return utilization.NewSampler(m, agentConfig.DataDir()), nil
+	})
 	a.startWorkerAfterUpgrade(runner, "apiaddressupdater", func() (worker.Worker, error) {
 		return apiaddressupdater.NewAPIAddressUpdater(st.Machiner(), a), nil
 	})
@@ -449,12 +460,11 @@ func (a *MachineAgent) StateWorker() (worker.Worker, error) {
 	}
 	reportOpenedState(st)
 
-	singularStateConn := singularStateConn{st.MongoSession(), m}
 	runner := newRunner(connectionIsFatal(st), moreImportant)
-	singularRunner, err := newSingularRunner(runner, singularStateConn)
-	if err != nil {
-		return nil, fmt.Errorf("cannot make singular State Runner: %v", err)
-	}
+	// Workers that must run exactly once are each guarded by their
+	// own lease, so that they are spread across the state servers
+	// rather than all running on the mongo master.
+	singularRunner := newLeaseRunner(runner, st, m.Tag().String())
 
 	// Take advantage of special knowledge here in that we will only ever want
 	// the storage provider on one machine, and that is the "bootstrap" node.
@@ -479,6 +489,9 @@ func (a *MachineAgent) StateWorker() (worker.Worker, error) {
 			a.startWorkerAfterUpgrade(runner, "peergrouper", func() (worker.Worker, error) {
 				return peergrouperNew(st)
 			})
+			runner.StartWorker("presencetimings", func() (worker.Worker, error) {
+				return presencetimings.NewUpdater(st), nil
+			})
 			runner.StartWorker("apiserver", func() (worker.Worker, error) {
 				// If the configuration does not have the required information,
 				// it is currently not a recoverable error, so we kill the whole
@@ -499,8 +512,11 @@ func (a *MachineAgent) StateWorker() (worker.Worker, error) {
 				dataDir := agentConfig.DataDir()
 				logDir := agentConfig.LogDir()
 
-				endpoint := net.JoinHostPort("", strconv.Itoa(info.APIPort))
-				listener, err := net.Listen("tcp", endpoint)
+				envConfig, err := st.EnvironConfig()
+				if err != nil {
+					return nil, err
+				}
+				listener, err := apiListener(envConfig.APIListenNetwork(), info.APIPort)
 				if err != nil {
 					return nil, err
 				}
@@ -521,9 +537,18 @@ func (a *MachineAgent) StateWorker() (worker.Worker, error) {
 				// the transaction log.
 				return resumer.NewResumer(st), nil
 			})
+			a.startWorkerAfterUpgrade(singularRunner, "auditpruner", func() (worker.Worker, error) {
+				return auditpruner.NewPruner(st, auditpruner.DefaultRetention, auditpruner.DefaultInterval), nil
+			})
+			a.startWorkerAfterUpgrade(singularRunner, "statepruner", func() (worker.Worker, error) {
+				return statepruner.NewPruner(st, statepruner.DefaultThresholds, statepruner.DefaultInterval), nil
+			})
 			a.startWorkerAfterUpgrade(singularRunner, "minunitsworker", func() (worker.Worker, error) {
 				return minunitsworker.NewMinUnitsWorker(st), nil
 			})
+			a.startWorkerAfterUpgrade(singularRunner, "webhooks", func() (worker.Worker, error) {
+				return webhooks.NewNotifier(st), nil
+			})
 		case state.JobManageStateDeprecated:
 			// Legacy environments may set this, but we ignore it.
 		default:
@@ -837,18 +862,3 @@ func (c singularAPIConn) IsMaster() (bool, error) {
 func (c singularAPIConn) Ping() error {
 	return c.apiState.Ping()
 }
-
-// singularStateConn implements singular.Conn on
-// top of a State connection.
-type singularStateConn struct {
-	session *mgo.Session
-	machine *state.Machine
-}
-
-func (c singularStateConn) IsMaster() (bool, error) {
-	return mongo.IsMaster(c.session, c.machine)
-}
-
-func (c singularStateConn) Ping() error {
-	return c.session.Ping()
-}
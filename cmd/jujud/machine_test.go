@@ -93,6 +93,7 @@ func (s *commonMachineSuite) SetUpTest(c *gc.C) {
 
 	s.singularRecord = &singularRunnerRecord{}
 	s.agentSuite.PatchValue(&newSingularRunner, s.singularRecord.newSingularRunner)
+	s.agentSuite.PatchValue(&newLeaseRunner, s.singularRecord.newLeaseRunner)
 	s.agentSuite.PatchValue(&peergrouperNew, func(st *state.State) (worker.Worker, error) {
 		return newDummyWorker(), nil
 	})
@@ -397,12 +398,14 @@ func (s *MachineSuite) TestManageEnviron(c *gc.C) {
 	}
 
 	c.Assert(s.singularRecord.started(), jc.DeepEquals, []string{
+		"auditpruner",
 		"charm-revision-updater",
 		"cleaner",
 		"environ-provisioner",
 		"firewaller",
 		"minunitsworker",
 		"resumer",
+		"statepruner",
 	})
 }
 
@@ -1088,6 +1091,13 @@ func (r *singularRunnerRecord) newSingularRunner(runner worker.Runner, conn sing
 	}, nil
 }
 
+func (r *singularRunnerRecord) newLeaseRunner(runner worker.Runner, claimer singular.LeaseClaimer, holder string) worker.Runner {
+	return &fakeSingularRunner{
+		Runner: singular.NewLeaseRunner(runner, claimer, holder),
+		record: r,
+	}
+}
+
 // started returns the names of all singular-started workers.
 func (r *singularRunnerRecord) started() []string {
 	return r.startedWorkers.SortedValues()
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"os"
 	"path"
 	"strconv"
@@ -284,7 +285,7 @@ func (c *restoreCommand) Run(ctx *cmd.Context) error {
 	for a := attempt.Start(); a.Next(); {
 		st, err = state.Open(&authentication.MongoInfo{
 			Info: mongo.Info{
-				Addrs:  []string{fmt.Sprintf("%s:%d", machine0Addr, cfg.StatePort())},
+				Addrs:  []string{net.JoinHostPort(machine0Addr, strconv.Itoa(cfg.StatePort()))},
 				CACert: caCert,
 			},
 			Tag:      tag,
@@ -570,7 +571,8 @@ func runViaSsh(addr string, script string) error {
 }
 
 func sendViaScp(file, host, destFile string) error {
-	err := ssh.Copy([]string{file, "ubuntu@" + host + ":" + destFile}, nil)
+	// Joining the path as a port brackets IPv6 addresses, as scp needs.
+	err := ssh.Copy([]string{file, "ubuntu@" + net.JoinHostPort(host, destFile)}, nil)
 	if err != nil {
 		return fmt.Errorf("scp command failed: %v", err)
 	}
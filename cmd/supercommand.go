@@ -1,7 +1,12 @@
 package cmd
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"os"
+	"sort"
+	"strings"
 
 	"github.com/juju/cmd"
 	"github.com/juju/loggo"
@@ -12,18 +17,50 @@ import (
 
 var logger = loggo.GetLogger("juju.cmd")
 
+// SuperCommand is a cmd.SuperCommand that also records its
+// subcommands and help topics, so that "help --search" can
+// find the ones matching a set of keywords.
+type SuperCommand struct {
+	*cmd.SuperCommand
+	commands    []cmd.Command
+	topics      []helpTopic
+	searchTerms []string
+}
+
+// helpTopic holds a help topic registered with a SuperCommand.
+// The text of topics provided by a callback is not recorded,
+// because producing it may be expensive.
+type helpTopic struct {
+	name  string
+	short string
+	long  string
+}
+
 // NewSuperCommand is like cmd.NewSuperCommand but
 // it adds juju-specific functionality:
 // - The default logging configuration is taken from the environment;
 // - The version is configured to the current juju version;
-// - The command emits a log message when a command runs.
-func NewSuperCommand(p cmd.SuperCommandParams) *cmd.SuperCommand {
+// - The command emits a log message when a command runs;
+// - "help --search" lists the commands and topics matching keywords.
+func NewSuperCommand(p cmd.SuperCommandParams) *SuperCommand {
+	p.Version = version.Current.String()
+	return newSuperCommand(p)
+}
+
+// NewSuperCommandWithoutVersion is like NewSuperCommand but it
+// does not register the standard version subcommand, so that
+// the caller can register a version subcommand of its own.
+func NewSuperCommandWithoutVersion(p cmd.SuperCommandParams) *SuperCommand {
+	p.Version = ""
+	return newSuperCommand(p)
+}
+
+func newSuperCommand(p cmd.SuperCommandParams) *SuperCommand {
 	p.Log = &cmd.Log{
 		DefaultConfig: os.Getenv(osenv.JujuLoggingConfigEnvKey),
 	}
-	p.Version = version.Current.String()
 	p.NotifyRun = runNotifier
-	return cmd.NewSuperCommand(p)
+	return &SuperCommand{SuperCommand: cmd.NewSuperCommand(p)}
 }
 
 // NewSubSuperCommand should be used to create a SuperCommand
@@ -36,3 +73,112 @@ func NewSubSuperCommand(p cmd.SuperCommandParams) *cmd.SuperCommand {
 func runNotifier(name string) {
 	logger.Infof("running %s [%s %s]", name, version.Current, version.Compiler)
 }
+
+// Register makes a subcommand available for use on the command line.
+func (c *SuperCommand) Register(subcmd cmd.Command) {
+	c.SuperCommand.Register(subcmd)
+	c.commands = append(c.commands, subcmd)
+}
+
+// AddHelpTopic adds a new help topic with the description being
+// the short param, and the full text being the long param.
+func (c *SuperCommand) AddHelpTopic(name, short, long string, aliases ...string) {
+	c.SuperCommand.AddHelpTopic(name, short, long, aliases...)
+	c.topics = append(c.topics, helpTopic{name, short, long})
+}
+
+// AddHelpTopicCallback adds a new help topic with the description
+// being the short param, and the full text being the result of the
+// callback function.
+func (c *SuperCommand) AddHelpTopicCallback(name, short string, callback func() string) {
+	c.SuperCommand.AddHelpTopicCallback(name, short, callback)
+	c.topics = append(c.topics, helpTopic{name: name, short: short})
+}
+
+// Init initializes the command for running. "help --search"
+// is handled here; all other arguments are passed on to the
+// underlying cmd.SuperCommand.
+func (c *SuperCommand) Init(args []string) error {
+	if len(args) < 2 || args[0] != "help" || args[1] != "--search" {
+		return c.SuperCommand.Init(args)
+	}
+	if len(args) == 2 {
+		return errors.New("no search terms specified")
+	}
+	c.searchTerms = args[2:]
+	return nil
+}
+
+// Run executes the subcommand that was selected in Init.
+func (c *SuperCommand) Run(ctx *cmd.Context) error {
+	if c.searchTerms == nil {
+		return c.SuperCommand.Run(ctx)
+	}
+	_, err := ctx.Stdout.Write(c.searchHelp(c.searchTerms))
+	return err
+}
+
+// searchHelp returns a listing of the commands and help topics
+// whose name, summary or documentation contain all the given
+// terms, ignoring case.
+func (c *SuperCommand) searchHelp(terms []string) []byte {
+	commands := make(map[string]string)
+	for _, subcmd := range c.commands {
+		info := subcmd.Info()
+		if matchesTerms(terms, info.Name, info.Purpose, info.Doc) {
+			commands[info.Name] = info.Purpose
+		}
+	}
+	topics := make(map[string]string)
+	for _, topic := range c.topics {
+		if matchesTerms(terms, topic.name, topic.short, topic.long) {
+			topics[topic.name] = topic.short
+		}
+	}
+	query := strings.Join(terms, " ")
+	if len(commands) == 0 && len(topics) == 0 {
+		return []byte(fmt.Sprintf("no commands or topics match %q\n", query))
+	}
+	var buf bytes.Buffer
+	if len(commands) > 0 {
+		fmt.Fprintf(&buf, "Commands matching %q:\n", query)
+		writeHelpList(&buf, commands)
+	}
+	if len(topics) > 0 {
+		if len(commands) > 0 {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(&buf, "Topics matching %q:\n", query)
+		writeHelpList(&buf, topics)
+	}
+	return buf.Bytes()
+}
+
+// matchesTerms reports whether every term is
+// contained in at least one of the given texts.
+func matchesTerms(terms []string, texts ...string) bool {
+	text := strings.ToLower(strings.Join(texts, "\n"))
+	for _, term := range terms {
+		if !strings.Contains(text, strings.ToLower(term)) {
+			return false
+		}
+	}
+	return true
+}
+
+// writeHelpList writes the given names and
+// summaries as an aligned list, sorted by name.
+func writeHelpList(buf *bytes.Buffer, entries map[string]string) {
+	var names []string
+	width := 0
+	for name := range entries {
+		names = append(names, name)
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(buf, "    %-*s  %s\n", width, name, entries[name])
+	}
+}
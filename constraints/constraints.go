@@ -20,15 +20,16 @@ import (
 // The following constants list the supported constraint attribute names, as defined
 // by the fields in the Value struct.
 const (
-	Arch         = "arch"
-	Container    = "container"
-	CpuCores     = "cpu-cores"
-	CpuPower     = "cpu-power"
-	Mem          = "mem"
-	RootDisk     = "root-disk"
-	Tags         = "tags"
-	InstanceType = "instance-type"
-	Networks     = "networks"
+	Arch             = "arch"
+	Container        = "container"
+	CpuCores         = "cpu-cores"
+	CpuPower         = "cpu-power"
+	Mem              = "mem"
+	RootDisk         = "root-disk"
+	Tags             = "tags"
+	InstanceType     = "instance-type"
+	Networks         = "networks"
+	ContainerNetwork = "container-network"
 )
 
 // Value describes a user's requirements of the hardware on which units
@@ -78,6 +79,12 @@ type Value struct {
 	// negative values are accepted, and the difference is the latter
 	// have a "^" prefix to the name.
 	Networks *[]string `json:"networks,omitempty" yaml:"networks,omitempty"`
+
+	// ContainerNetwork, if not nil or empty, names the type of
+	// networking used by a container machine ("bridge", "host-bridge",
+	// "macvlan" or "ovs"), overriding the environment's
+	// container-network-type. It is ignored for other machines.
+	ContainerNetwork *string `json:"container-network,omitempty" yaml:"container-network,omitempty"`
 }
 
 // fieldNames records a mapping from the constraint tag to struct field name.
@@ -115,6 +122,12 @@ func (v *Value) HasInstanceType() bool {
 	return v.InstanceType != nil && *v.InstanceType != ""
 }
 
+// HasContainerNetwork returns true if the constraints.Value specifies
+// a container network type.
+func (v *Value) HasContainerNetwork() bool {
+	return v.ContainerNetwork != nil && *v.ContainerNetwork != ""
+}
+
 // extractNetworks returns the list of networks to include or exclude
 // (without the "^" prefixes).
 func (v *Value) extractNetworks() (include, exclude []string) {
@@ -160,6 +173,9 @@ func (v Value) String() string {
 	if v.Container != nil {
 		strs = append(strs, "container="+string(*v.Container))
 	}
+	if v.ContainerNetwork != nil {
+		strs = append(strs, "container-network="+*v.ContainerNetwork)
+	}
 	if v.CpuCores != nil {
 		strs = append(strs, "cpu-cores="+uintStr(*v.CpuCores))
 	}
@@ -330,6 +346,8 @@ func (v *Value) setRaw(raw string) error {
 		err = v.setInstanceType(str)
 	case Networks:
 		err = v.setNetworks(str)
+	case ContainerNetwork:
+		err = v.setContainerNetwork(str)
 	default:
 		return fmt.Errorf("unknown constraint %q", name)
 	}
@@ -376,6 +394,8 @@ func (v *Value) SetYAML(tag string, value interface{}) bool {
 			if err == nil {
 				err = v.validateNetworks(networks)
 			}
+		case ContainerNetwork:
+			err = v.setContainerNetwork(vstr)
 		default:
 			return false
 		}
@@ -478,6 +498,19 @@ func (v *Value) setNetworks(str string) error {
 	return nil
 }
 
+func (v *Value) setContainerNetwork(str string) error {
+	if v.ContainerNetwork != nil {
+		return fmt.Errorf("already set")
+	}
+	switch str {
+	case "", "bridge", "host-bridge", "macvlan", "ovs":
+	default:
+		return fmt.Errorf("unknown container network type %q", str)
+	}
+	v.ContainerNetwork = &str
+	return nil
+}
+
 func (v *Value) validateNetworks(networks *[]string) error {
 	if networks == nil {
 		return nil
@@ -289,6 +289,23 @@ var parseConstraintsTests = []struct {
 		args:    []string{"instance-type="},
 	},
 
+	// container network
+	{
+		summary: "set container network",
+		args:    []string{"container-network=macvlan"},
+	}, {
+		summary: "container network empty",
+		args:    []string{"container-network="},
+	}, {
+		summary: "unknown container network",
+		args:    []string{"container-network=token-ring"},
+		err:     `bad "container-network" constraint: unknown container network type "token-ring"`,
+	}, {
+		summary: "double set container network",
+		args:    []string{"container-network=ovs", "container-network=bridge"},
+		err:     `bad "container-network" constraint: already set`,
+	},
+
 	// Everything at once.
 	{
 		summary: "kitchen sink together",
@@ -414,6 +431,8 @@ func (s *ConstraintsSuite) TestIsEmpty(c *gc.C) {
 	c.Check(&con, gc.Not(jc.Satisfies), constraints.IsEmpty)
 	con = constraints.MustParse("instance-type=")
 	c.Check(&con, gc.Not(jc.Satisfies), constraints.IsEmpty)
+	con = constraints.MustParse("container-network=")
+	c.Check(&con, gc.Not(jc.Satisfies), constraints.IsEmpty)
 }
 
 func uint64p(i uint64) *uint64 {
@@ -461,16 +480,19 @@ var constraintsRoundtripTests = []roundTrip{
 	{"Networks3", constraints.Value{Networks: &[]string{"net1", "^net2"}}},
 	{"InstanceType1", constraints.Value{InstanceType: strp("")}},
 	{"InstanceType2", constraints.Value{InstanceType: strp("foo")}},
+	{"ContainerNetwork1", constraints.Value{ContainerNetwork: strp("")}},
+	{"ContainerNetwork2", constraints.Value{ContainerNetwork: strp("host-bridge")}},
 	{"All", constraints.Value{
-		Arch:         strp("i386"),
-		Container:    ctypep("lxc"),
-		CpuCores:     uint64p(4096),
-		CpuPower:     uint64p(9001),
-		Mem:          uint64p(18000000000),
-		RootDisk:     uint64p(24000000000),
-		Tags:         &[]string{"foo", "bar"},
-		Networks:     &[]string{"net1", "^net2"},
-		InstanceType: strp("foo"),
+		Arch:             strp("i386"),
+		Container:        ctypep("lxc"),
+		CpuCores:         uint64p(4096),
+		CpuPower:         uint64p(9001),
+		Mem:              uint64p(18000000000),
+		RootDisk:         uint64p(24000000000),
+		Tags:             &[]string{"foo", "bar"},
+		Networks:         &[]string{"net1", "^net2"},
+		InstanceType:     strp("foo"),
+		ContainerNetwork: strp("ovs"),
 	}},
 }
 
@@ -548,6 +570,15 @@ func (s *ConstraintsSuite) TestHasInstanceType(c *gc.C) {
 	c.Check(cons.HasInstanceType(), jc.IsTrue)
 }
 
+func (s *ConstraintsSuite) TestHasContainerNetwork(c *gc.C) {
+	cons := constraints.MustParse("arch=amd64")
+	c.Check(cons.HasContainerNetwork(), jc.IsFalse)
+	cons = constraints.MustParse("container-network=")
+	c.Check(cons.HasContainerNetwork(), jc.IsFalse)
+	cons = constraints.MustParse("arch=amd64 container-network=macvlan")
+	c.Check(cons.HasContainerNetwork(), jc.IsTrue)
+}
+
 const initialWithoutCons = "root-disk=8G mem=4G arch=amd64 cpu-power=1000 cpu-cores=4 networks=net1,^net2 tags=foo container=lxc instance-type=bar"
 
 var withoutTests = []struct {
@@ -6,6 +6,7 @@ package lxc
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sync"
@@ -39,6 +40,11 @@ end script
 `
 )
 
+// TemplateLastUsedFile names the file, in the container directory of
+// a clone template, that records when the template was last cloned.
+// It lets cached templates that are no longer used be found and removed.
+const TemplateLastUsedFile = "last-used"
+
 var (
 	TemplateLockDir = "/var/lib/juju/locks"
 
@@ -74,6 +80,13 @@ func templateUserData(
 	return data, nil
 }
 
+// markTemplateUsed records that the named template has just been cloned.
+func markTemplateUsed(name string) error {
+	path := filepath.Join(container.ContainerDir, name, TemplateLastUsedFile)
+	now := time.Now().UTC().Format(time.RFC3339)
+	return ioutil.WriteFile(path, []byte(now+"\n"), 0644)
+}
+
 func AcquireTemplateLock(name, message string) (*fslock.Lock, error) {
 	logger.Infof("wait for fslock on %v", name)
 	lock, err := fslock.NewLock(TemplateLockDir, name)
@@ -206,6 +206,9 @@ func (manager *containerManager) CreateContainer(
 			logger.Errorf("lxc container cloning failed: %v", err)
 			return nil, nil, err
 		}
+		if err := markTemplateUsed(templateContainer.Name()); err != nil {
+			logger.Warningf("cannot record use of template %q: %v", templateContainer.Name(), err)
+		}
 	} else {
 		// Note here that the lxcObjectFacotry only returns a valid container
 		// object, and doesn't actually construct the underlying lxc container on
@@ -374,6 +377,15 @@ func generateNetworkConfig(network *container.NetworkConfig) string {
 	switch network.NetworkType {
 	case container.PhysicalNetwork:
 		lxcConfig = networkConfigTemplate("phys", network.Device)
+	case container.MACVLANNetwork:
+		// Use bridge mode so that containers sharing the same
+		// host device can talk to each other.
+		lxcConfig = networkConfigTemplate("macvlan", network.Device) +
+			"lxc.network.macvlan.mode = bridge\n"
+	case container.OVSNetwork:
+		// LXC attaches the veth pair to Open vSwitch bridges
+		// natively when the link names an OVS bridge.
+		lxcConfig = networkConfigTemplate("veth", network.Device)
 	default:
 		logger.Warningf("Unknown network config type %q: using bridge", network.NetworkType)
 		fallthrough
@@ -303,6 +303,10 @@ func (s *LxcSuite) TestCreateContainerEventsWithCloneExistingTemplate(c *gc.C) {
 	s.AssertEvent(c, cloned, mock.Cloned, "juju-series-template")
 	c.Assert(cloned.Args, gc.IsNil)
 	s.AssertEvent(c, <-s.events, mock.Started, name)
+
+	// The template records that it has been used.
+	lastUsed := filepath.Join(s.ContainerDir, "juju-series-template", lxc.TemplateLastUsedFile)
+	c.Assert(lastUsed, jc.IsNonEmptyFile)
 }
 
 func (s *LxcSuite) TestCreateContainerEventsWithCloneExistingTemplateAUFS(c *gc.C) {
@@ -480,6 +484,14 @@ func (*NetworkSuite) TestGenerateNetworkConfig(c *gc.C) {
 		config: container.PhysicalNetworkConfig("foo"),
 		net:    "phys",
 		link:   "foo",
+	}, {
+		config: container.MACVLANNetworkConfig("foo"),
+		net:    "macvlan",
+		link:   "foo",
+	}, {
+		config: container.OVSNetworkConfig("foo"),
+		net:    "veth",
+		link:   "foo",
 	}} {
 		config := lxc.GenerateNetworkConfig(test.config)
 		c.Assert(config, jc.Contains, fmt.Sprintf("lxc.network.type = %s\n", test.net))
@@ -6,8 +6,18 @@ package container
 const (
 	// BridgeNetwork will have the container use the network bridge.
 	BridgeNetwork = "bridge"
+	// HostBridgeNetwork will have the container attached to the bridge
+	// carrying the host's own network, so that it shares the host's
+	// network. It is configured as a BridgeNetwork.
+	HostBridgeNetwork = "host-bridge"
 	// PhyscialNetwork will have the container use a specified network device.
 	PhysicalNetwork = "physical"
+	// MACVLANNetwork will have the container use a MACVLAN interface
+	// created on top of the specified host network device.
+	MACVLANNetwork = "macvlan"
+	// OVSNetwork will have the container attached to the specified
+	// Open vSwitch bridge.
+	OVSNetwork = "ovs"
 )
 
 // NetworkConfig defines how the container network will be configured.
@@ -27,3 +37,15 @@ func BridgeNetworkConfig(device string) *NetworkConfig {
 func PhysicalNetworkConfig(device string) *NetworkConfig {
 	return &NetworkConfig{PhysicalNetwork, device}
 }
+
+// MACVLANNetworkConfig returns a valid NetworkConfig to give the
+// container a MACVLAN interface on top of the specified host device.
+func MACVLANNetworkConfig(device string) *NetworkConfig {
+	return &NetworkConfig{MACVLANNetwork, device}
+}
+
+// OVSNetworkConfig returns a valid NetworkConfig to attach the
+// container to the specified Open vSwitch bridge.
+func OVSNetworkConfig(device string) *NetworkConfig {
+	return &NetworkConfig{OVSNetwork, device}
+}
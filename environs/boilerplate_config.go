@@ -8,7 +8,10 @@ import (
 	"crypto/rand"
 	"fmt"
 	"io"
+	"sort"
 	"text/template"
+
+	"github.com/juju/juju/environs/config"
 )
 
 var configHeader = `
@@ -56,21 +59,103 @@ func randomKey() string {
 	return fmt.Sprintf("%x", buf)
 }
 
+// commonBoilerplate holds the settings supported by all
+// providers, which are added to each provider's sample
+// configuration with their default values.
+var commonBoilerplate = fmt.Sprintf(`
+# The settings below are supported by all providers. They are
+# optional and are shown with their default values.
+
+# default-series sets the Ubuntu series used for machines and
+# charms when none is specified. It defaults to the latest LTS.
+#
+# default-series: <series>
+
+# firewall-mode sets how ports are opened: "instance" uses a
+# firewall per machine, "global" a single firewall for all
+# machines of the environment, and "none" leaves the firewall
+# to be managed by the user.
+#
+# firewall-mode: instance
+
+# state-port, api-port and syslog-port set the ports used by the
+# state server database, the API server and the log aggregator.
+# api-listen-network restricts each API server to its own addresses
+# in the given network (and to localhost).
+# These cannot be changed once the environment is bootstrapped.
+#
+# state-port: %d
+# api-port: %d
+# syslog-port: %d
+# api-listen-network: <cidr>
+
+# bootstrap-timeout, bootstrap-retry-delay and bootstrap-addresses-delay
+# set, in seconds, how long bootstrap waits for the state server to be
+# reachable and how often it tries to connect to it.
+#
+# bootstrap-timeout: %d
+# bootstrap-retry-delay: %d
+# bootstrap-addresses-delay: %d
+
+# logging-config sets the logging levels of the agents.
+#
+# logging-config: <root>=WARNING;unit=DEBUG
+
+# http-proxy, https-proxy, ftp-proxy and no-proxy set the proxies used
+# by the machines of the environment; apt-http-proxy, apt-https-proxy
+# and apt-ftp-proxy override them for apt.
+#
+# http-proxy: <url>
+# https-proxy: <url>
+# ftp-proxy: <url>
+# no-proxy: <hosts>
+
+# tools-metadata-url and image-metadata-url set where tools and
+# images are looked for in addition to the official locations.
+#
+# tools-metadata-url: <url>
+# image-metadata-url: <url>
+
+# storage-backend selects a blob store to hold the environment's
+# storage instead of the one provided by the provider: "s3" for an
+# S3-compatible store, "swift" for a Swift store using v1
+# authentication, "webdav" for a WebDAV server allowing anonymous
+# reads, or "httpstorage" for the storage server of a juju state
+# server. storage-url gives the store's endpoint, and
+# storage-container the bucket, container or collection used. These
+# cannot be changed once the environment is bootstrapped. The local
+# provider always uses its own storage. storage-ca-cert gives the
+# certificate of the CA that signed the certificate of an httpstorage
+# or https WebDAV server, if it is not the environment's own CA or a
+# CA trusted by the system respectively.
+#
+# storage-backend: s3
+# storage-url: <url>
+# storage-container: <bucket>
+# storage-access-key: <key>
+# storage-secret-key: <secret>
+# storage-ca-cert: <PEM-encoded certificate>
+`[1:],
+	config.DefaultStatePort,
+	config.DefaultAPIPort,
+	config.DefaultSyslogPort,
+	config.DefaultBootstrapSSHTimeout,
+	config.DefaultBootstrapSSHRetryDelay,
+	config.DefaultBootstrapSSHAddressesDelay,
+)
+
 // BoilerplateConfig returns a sample juju configuration.
 func BoilerplateConfig() string {
 	var config bytes.Buffer
 
 	config.WriteString(configHeader)
-	for name, p := range providers {
-		t, err := parseTemplate(p.BoilerplateConfig())
-		if err != nil {
-			panic(fmt.Errorf("cannot parse boilerplate from %s: %v", name, err))
-		}
-		var ecfg bytes.Buffer
-		if err := t.Execute(&ecfg, nil); err != nil {
-			panic(fmt.Errorf("cannot generate boilerplate from %s: %v", name, err))
-		}
-		indent(&config, ecfg.Bytes(), "    ")
+	var names []string
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		indent(&config, providerBoilerplate(name, providers[name]), "    ")
 	}
 
 	// Sanity check to ensure the boilerplate parses.
@@ -81,6 +166,43 @@ func BoilerplateConfig() string {
 	return config.String()
 }
 
+// ProviderBoilerplateConfig returns a sample configuration for
+// an environment of the given provider type, indented so that
+// it can be appended to the environments of an existing
+// environments.yaml file.
+func ProviderBoilerplateConfig(providerType string) (string, error) {
+	p, err := Provider(providerType)
+	if err != nil {
+		return "", err
+	}
+	if alias, ok := providerAliases[providerType]; ok {
+		providerType = alias
+	}
+	var config bytes.Buffer
+	indent(&config, providerBoilerplate(providerType, p), "    ")
+	return config.String(), nil
+}
+
+// providerBoilerplate returns the sample configuration of the
+// given provider, followed by the settings common to all providers.
+func providerBoilerplate(name string, p EnvironProvider) []byte {
+	t, err := parseTemplate(p.BoilerplateConfig())
+	if err != nil {
+		panic(fmt.Errorf("cannot parse boilerplate from %s: %v", name, err))
+	}
+	var ecfg bytes.Buffer
+	if err := t.Execute(&ecfg, nil); err != nil {
+		panic(fmt.Errorf("cannot generate boilerplate from %s: %v", name, err))
+	}
+	text := bytes.TrimRight(ecfg.Bytes(), "\n")
+	var b bytes.Buffer
+	b.Write(text)
+	b.WriteString("\n\n")
+	indent(&b, []byte(commonBoilerplate), "    ")
+	b.WriteString("\n")
+	return b.Bytes()
+}
+
 func parseTemplate(s string) (*template.Template, error) {
 	t := template.New("")
 	t.Funcs(template.FuncMap{"rand": randomKey})
@@ -38,3 +38,29 @@ func (*BoilerplateConfigSuite) TestBoilerPlateAliases(c *gc.C) {
 	n = strings.Count(boilerplate_text, "type: null")
 	c.Assert(n, gc.Equals, 0)
 }
+
+func (*BoilerplateConfigSuite) TestBoilerPlateCommonSettings(c *gc.C) {
+	defer osenv.SetJujuHome(osenv.SetJujuHome(c.MkDir()))
+	boilerplate_text := environs.BoilerplateConfig()
+	// The settings common to all providers are shown
+	// in the sample configuration of each provider.
+	envs, err := environs.ReadEnvironsBytes([]byte(boilerplate_text))
+	c.Assert(err, gc.IsNil)
+	n := strings.Count(boilerplate_text, "        # api-port: 17070\n")
+	c.Assert(n, gc.Equals, len(envs.Names()))
+}
+
+func (*BoilerplateConfigSuite) TestProviderBoilerPlate(c *gc.C) {
+	text, err := environs.ProviderBoilerplateConfig("null")
+	c.Assert(err, gc.IsNil)
+	c.Assert(strings.Count(text, "type: manual"), gc.Equals, 1)
+	c.Assert(strings.Count(text, "type: "), gc.Equals, 1)
+	c.Assert(text, gc.Matches, `(?s).*\n        # state-port: 37017\n.*`)
+
+	envs, err := environs.ReadEnvironsBytes([]byte("environments:\n" + text))
+	c.Assert(err, gc.IsNil)
+	c.Assert(envs.Names(), gc.HasLen, 1)
+
+	_, err = environs.ProviderBoilerplateConfig("nowhere")
+	c.Assert(err, gc.ErrorMatches, `no registered provider for "nowhere"`)
+}
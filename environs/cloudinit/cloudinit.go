@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"net/url"
 	"path"
 	"strconv"
 	"strings"
@@ -234,6 +235,22 @@ func AddAptCommands(proxySettings proxy.Settings, c *cloudinit.Config) {
 	}
 }
 
+// AddPackageCommands updates the cloudinit.Config instance with the
+// package management commands appropriate for the OS of the given series.
+func AddPackageCommands(series string, proxySettings proxy.Settings, c *cloudinit.Config) error {
+	os, err := version.GetOSFromSeries(series)
+	if err != nil {
+		return err
+	}
+	switch os {
+	case version.Ubuntu:
+		AddAptCommands(proxySettings, c)
+	default:
+		return fmt.Errorf("cannot manage packages on %s", os)
+	}
+	return nil
+}
+
 // ConfigureJuju updates the provided cloudinit.Config with configuration
 // to initialise a Juju machine agent.
 func ConfigureJuju(cfg *MachineConfig, c *cloudinit.Config) error {
@@ -258,7 +275,10 @@ func ConfigureJuju(cfg *MachineConfig, c *cloudinit.Config) error {
 	}
 
 	if !cfg.DisablePackageCommands {
-		AddAptCommands(cfg.AptProxySettings, c)
+		series := cfg.Tools.Version.Series
+		if err := AddPackageCommands(series, cfg.AptProxySettings, c); err != nil {
+			return err
+		}
 	}
 
 	// Write out the normal proxy settings so that the settings are
@@ -302,6 +322,11 @@ func ConfigureJuju(cfg *MachineConfig, c *cloudinit.Config) error {
 		if cfg.DisableSSLHostnameVerification {
 			curlCommand += " --insecure"
 		}
+		if isIPv6LiteralURL(cfg.Tools.URL) {
+			// curl takes the brackets around an IPv6 literal
+			// for a glob range unless globbing is turned off.
+			curlCommand += " --globoff"
+		}
 		copyCmd = fmt.Sprintf("%s -o $bin/tools.tar.gz %s", curlCommand, shquote(cfg.Tools.URL))
 		c.AddRunCmd(cloudinit.LogProgressCmd("Fetching tools: %s", copyCmd))
 	}
@@ -559,6 +584,13 @@ func shquote(p string) string {
 	return utils.ShQuote(p)
 }
 
+// isIPv6LiteralURL reports whether the host of the given URL is an
+// IPv6 literal, such as http://[2001:db8::1]:8040/.
+func isIPv6LiteralURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && strings.HasPrefix(u.Host, "[")
+}
+
 type requiresError string
 
 func (e requiresError) Error() string {
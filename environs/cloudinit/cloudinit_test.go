@@ -10,6 +10,7 @@ import (
 
 	"github.com/juju/names"
 	jc "github.com/juju/testing/checkers"
+	"github.com/juju/utils/proxy"
 	gc "launchpad.net/gocheck"
 	"launchpad.net/goyaml"
 
@@ -315,6 +316,39 @@ start jujud-machine-2-lxc-1
 		inexactMatch: true,
 		expectScripts: `
 curl -sSfw 'tools from %{url_effective} downloaded: HTTP %{http_code}; time %{time_total}s; size %{size_download} bytes; speed %{speed_download} bytes/s ' --insecure -o \$bin/tools\.tar\.gz 'http://foo\.com/tools/releases/juju1\.2\.3-quantal-amd64\.tgz'
+`,
+	}, {
+		// tools fetched from an IPv6 literal address.
+		cfg: cloudinit.MachineConfig{
+			MachineId:          "99",
+			AuthorizedKeys:     "sshkey1",
+			AgentEnvironment:   map[string]string{agent.ProviderType: "dummy"},
+			DataDir:            environs.DataDir,
+			LogDir:             agent.DefaultLogDir,
+			Jobs:               normalMachineJobs,
+			CloudInitOutputLog: environs.CloudInitOutputLog,
+			Bootstrap:          false,
+			Tools:              newIPv6Tools("1.2.3-quantal-amd64"),
+			MachineNonce:       "FAKE_NONCE",
+			MongoInfo: &authentication.MongoInfo{
+				Tag:      names.NewMachineTag("99"),
+				Password: "arble",
+				Info: mongo.Info{
+					Addrs:  []string{"[2001:db8::1]:12345"},
+					CACert: "CA CERT\n" + testing.CACert,
+				},
+			},
+			APIInfo: &api.Info{
+				Addrs:    []string{"[2001:db8::1]:54321"},
+				Tag:      names.NewMachineTag("99"),
+				Password: "bletch",
+				CACert:   "CA CERT\n" + testing.CACert,
+			},
+			MachineAgentServiceName: "jujud-machine-99",
+		},
+		inexactMatch: true,
+		expectScripts: `
+curl -sSfw 'tools from %{url_effective} downloaded: HTTP %{http_code}; time %{time_total}s; size %{size_download} bytes; speed %{speed_download} bytes/s ' --globoff -o \$bin/tools\.tar\.gz 'http://\[2001:db8::1\]:8040/tools/releases/juju1\.2\.3-quantal-amd64\.tgz'
 `,
 	}, {
 		// empty contraints.
@@ -362,6 +396,12 @@ func newSimpleTools(vers string) *tools.Tools {
 	}
 }
 
+func newIPv6Tools(vers string) *tools.Tools {
+	tools := newSimpleTools(vers)
+	tools.URL = "http://[2001:db8::1]:8040/tools/releases/juju" + vers + ".tgz"
+	return tools
+}
+
 func newFileTools(vers, path string) *tools.Tools {
 	tools := newSimpleTools(vers)
 	tools.URL = "file://" + path
@@ -848,6 +888,18 @@ func (s *cloudinitSuite) TestAptProxyWritten(c *gc.C) {
 	c.Assert(cmds, jc.DeepEquals, []interface{}{expected})
 }
 
+func (s *cloudinitSuite) TestAddPackageCommands(c *gc.C) {
+	ubuntu := coreCloudinit.New()
+	err := cloudinit.AddPackageCommands("precise", proxy.Settings{}, ubuntu)
+	c.Assert(err, gc.IsNil)
+	c.Assert(ubuntu.Packages(), jc.DeepEquals, []string{"curl", "cpu-checker", "bridge-utils", "rsyslog-gnutls"})
+
+	err = cloudinit.AddPackageCommands("win2012r2", proxy.Settings{}, coreCloudinit.New())
+	c.Assert(err, gc.ErrorMatches, "cannot manage packages on windows")
+	err = cloudinit.AddPackageCommands("mountainlion", proxy.Settings{}, coreCloudinit.New())
+	c.Assert(err, gc.ErrorMatches, `invalid series "mountainlion"`)
+}
+
 func (s *cloudinitSuite) TestProxyWritten(c *gc.C) {
 	environConfig := minimalConfig(c)
 	environConfig, err := environConfig.Apply(map[string]interface{}{
@@ -40,6 +40,14 @@ func (e *Environs) Names() (names []string) {
 	return
 }
 
+// Type returns the provider type of the named environment, as given
+// in its configuration, or "" if there is no such environment. The
+// rest of the configuration is not checked.
+func (e *Environs) Type(name string) string {
+	kind, _ := e.rawEnvirons[name]["type"].(string)
+	return kind
+}
+
 func validateEnvironmentKind(rawEnviron map[string]interface{}) error {
 	kind, _ := rawEnviron["type"].(string)
 	if kind == "" {
@@ -6,12 +6,15 @@ package config
 import (
 	"fmt"
 	"io/ioutil"
+	"net"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/juju/charm"
 	"github.com/juju/errors"
@@ -36,6 +39,20 @@ const (
 	// port opened.
 	FwGlobal = "global"
 
+	// FwNone requests that juju leaves the firewall alone, for it to be
+	// managed by the user. Machines still get the firewall rules juju
+	// needs for its own communications.
+	FwNone = "none"
+
+	// AuthBackendState authenticates API users against
+	// the users held in state only.
+	AuthBackendState = "state"
+
+	// AuthBackendLDAP also authenticates API users against an
+	// LDAP directory, granting them access according to the
+	// LDAP groups they are members of.
+	AuthBackendLDAP = "ldap"
+
 	// DefaultStatePort is the default port the state server is listening on.
 	DefaultStatePort int = 37017
 
@@ -59,6 +76,10 @@ const (
 	// refresh addresses from the provider each time.
 	DefaultBootstrapSSHAddressesDelay int = 10
 
+	// DefaultPresenceLivenessWindow is the length of time, in seconds,
+	// within which an agent must ping to be considered alive.
+	DefaultPresenceLivenessWindow int = 30
+
 	// fallbackLtsSeries is the latest LTS series we'll use, if we fail to
 	// obtain this information from the system.
 	fallbackLtsSeries string = "precise"
@@ -323,7 +344,7 @@ func Validate(cfg, old *Config) error {
 	}
 
 	// Check firewall mode.
-	if mode := cfg.FirewallMode(); mode != FwInstance && mode != FwGlobal {
+	if mode := cfg.FirewallMode(); mode != FwInstance && mode != FwGlobal && mode != FwNone {
 		return fmt.Errorf("invalid firewall mode in environment configuration: %q", mode)
 	}
 
@@ -335,6 +356,76 @@ func Validate(cfg, old *Config) error {
 		}
 	}
 
+	// Ensure that the container network type, if set, is one known
+	// to the container package.
+	switch netType := cfg.ContainerNetworkType(); netType {
+	case "", "bridge", "host-bridge", "macvlan", "ovs":
+	default:
+		return fmt.Errorf("invalid container-network-type %q", netType)
+	}
+
+	// Ensure that the user authentication backend is known, and
+	// that the LDAP server is specified if it is used.
+	switch backend := cfg.AuthBackend(); backend {
+	case AuthBackendState:
+	case AuthBackendLDAP:
+		if cfg.LDAPURL() == "" || cfg.LDAPUserDN() == "" {
+			return fmt.Errorf("auth-backend %q requires ldap-url and ldap-user-dn", backend)
+		}
+		if !strings.Contains(cfg.LDAPUserDN(), "%s") {
+			return fmt.Errorf("ldap-user-dn %q does not contain %%s", cfg.LDAPUserDN())
+		}
+	default:
+		return fmt.Errorf("invalid auth-backend %q", backend)
+	}
+
+	// Ensure that the webhook URLs are absolute HTTP URLs.
+	for _, webhookURL := range cfg.WebhookURLs() {
+		u, err := url.Parse(webhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid webhook URL %q: expected an http or https URL", webhookURL)
+		}
+	}
+
+	// Ensure that a storage backend, if used, is given a location.
+	if cfg.StorageBackend() != "" {
+		if cfg.StorageURL() == "" {
+			return fmt.Errorf("storage-backend %q requires storage-url", cfg.StorageBackend())
+		}
+		if u, err := url.Parse(cfg.StorageURL()); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid storage-url %q: expected an absolute URL", cfg.StorageURL())
+		}
+	}
+	if caCert := cfg.StorageCACert(); caCert != "" {
+		if _, err := cert.ParseCert(caCert); err != nil {
+			return fmt.Errorf("bad storage-ca-cert: %v", err)
+		}
+	}
+
+	// Ensure that the image id overrides parse.
+	if _, err := parseImageIds(cfg.asString("image-ids")); err != nil {
+		return err
+	}
+
+	// Ensure that agents ping at least once per liveness window.
+	if v, ok := cfg.defined["presence-liveness-window"].(int); ok && v <= 0 {
+		return fmt.Errorf("presence-liveness-window must be positive, got %d", v)
+	}
+	if v, ok := cfg.defined["presence-ping-interval"].(int); ok && v < 0 {
+		return fmt.Errorf("presence-ping-interval must not be negative, got %d", v)
+	}
+	if timings := cfg.PresenceTimings(); timings.PingInterval >= timings.LivenessWindow {
+		return fmt.Errorf("presence-ping-interval %v must be shorter than presence-liveness-window %v",
+			timings.PingInterval, timings.LivenessWindow)
+	}
+
+	// Ensure that the API server listen network, if set, is a CIDR.
+	if cidr := cfg.APIListenNetwork(); cidr != "" {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("invalid api-listen-network %q: %v", cidr, err)
+		}
+	}
+
 	// Ensure that the auth token is a set of key=value pairs.
 	authToken, _ := cfg.CharmStoreAuth()
 	validAuthToken := regexp.MustCompile(`^([^\s=]+=[^\s=]+(,\s*)?)*$`)
@@ -343,6 +434,20 @@ func Validate(cfg, old *Config) error {
 			" of key-value pairs, not %q", authToken)
 	}
 
+	// Check that hook environment variables have usable names.
+	for attr := range cfg.unknown {
+		name := strings.TrimPrefix(attr, HookEnvPrefix)
+		if name == attr {
+			continue
+		}
+		if !validHookEnvName.MatchString(name) {
+			return fmt.Errorf("invalid hook environment variable name %q", name)
+		}
+		if strings.HasPrefix(name, "JUJU_") {
+			return fmt.Errorf("invalid hook environment variable name %q: names beginning with JUJU_ are reserved", name)
+		}
+	}
+
 	// Check the immutable config values.  These can't change
 	if old != nil {
 		for _, attr := range immutableAttributes {
@@ -508,6 +613,118 @@ func (c *Config) APIPort() int {
 	return c.mustInt("api-port")
 }
 
+// APIListenNetwork returns the network, in CIDR notation, whose
+// addresses the API servers listen on. Each API server listens on its
+// own addresses within the network, as well as on the loopback
+// address. An empty string means all addresses.
+func (c *Config) APIListenNetwork() string {
+	return c.asString("api-listen-network")
+}
+
+// AuthBackend returns the backend against which API users are
+// authenticated, in addition to the users held in state.
+func (c *Config) AuthBackend() string {
+	if backend := c.asString("auth-backend"); backend != "" {
+		return backend
+	}
+	return AuthBackendState
+}
+
+// LDAPURL returns the URL of the LDAP server used to
+// authenticate users when AuthBackend is AuthBackendLDAP.
+func (c *Config) LDAPURL() string {
+	return c.asString("ldap-url")
+}
+
+// LDAPUserDN returns the template of the distinguished name
+// of LDAP users, in which %s is replaced by the user name.
+func (c *Config) LDAPUserDN() string {
+	return c.asString("ldap-user-dn")
+}
+
+// LDAPGroupBase returns the base distinguished name
+// under which the LDAP groups of users are searched.
+func (c *Config) LDAPGroupBase() string {
+	return c.asString("ldap-group-base")
+}
+
+// LDAPAdminGroup returns the LDAP group whose
+// members have full access to the environment.
+func (c *Config) LDAPAdminGroup() string {
+	return c.asString("ldap-admin-group")
+}
+
+// LDAPReadOnlyGroup returns the LDAP group whose members
+// may look at the environment but not change it.
+func (c *Config) LDAPReadOnlyGroup() string {
+	return c.asString("ldap-read-only-group")
+}
+
+// WebhookURLs returns the URLs to which events of the environment,
+// such as units starting and hooks failing, are posted.
+func (c *Config) WebhookURLs() []string {
+	return strings.FieldsFunc(c.asString("webhook-urls"), func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+}
+
+// WebhookSecret returns the key with which the events posted
+// to the webhook URLs are signed, or "" if they are not signed.
+func (c *Config) WebhookSecret() string {
+	return c.asString("webhook-secret")
+}
+
+// SecretAttrs returns the settings of the configuration which are
+// secret but not specific to any provider. Providers include them in
+// their own secret attributes, so that they are only given to the
+// state servers.
+func (c *Config) SecretAttrs() map[string]string {
+	attrs := make(map[string]string)
+	for _, name := range []string{"storage-secret-key", "webhook-secret"} {
+		if value := c.asString(name); value != "" {
+			attrs[name] = value
+		}
+	}
+	return attrs
+}
+
+// StorageBackend returns the name of the backend holding the
+// environment's storage, or "" if the storage provided by the
+// environment's provider is used.
+func (c *Config) StorageBackend() string {
+	return c.asString("storage-backend")
+}
+
+// StorageURL returns the URL of the storage backend's endpoint.
+func (c *Config) StorageURL() string {
+	return c.asString("storage-url")
+}
+
+// StorageContainer returns the name of the bucket, container or
+// directory in which the storage backend holds the environment's files.
+func (c *Config) StorageContainer() string {
+	return c.asString("storage-container")
+}
+
+// StorageAccessKey returns the key or user name with which
+// the storage backend is accessed.
+func (c *Config) StorageAccessKey() string {
+	return c.asString("storage-access-key")
+}
+
+// StorageSecretKey returns the secret key or password with which
+// the storage backend is accessed.
+func (c *Config) StorageSecretKey() string {
+	return c.asString("storage-secret-key")
+}
+
+// StorageCACert returns the certificate of the CA that signed the
+// storage backend's certificate, in PEM format, or "" if the
+// backend's default is used.
+func (c *Config) StorageCACert() string {
+	return c.asString("storage-ca-cert")
+}
+
 // SyslogPort returns the syslog port for the environment.
 func (c *Config) SyslogPort() int {
 	return c.mustInt("syslog-port")
@@ -546,6 +763,26 @@ func (c *Config) ProxySettings() proxy.Settings {
 	}
 }
 
+// HookEnvPrefix prefixes the names of environment attributes whose
+// values are exported to every hook run in the environment. The rest
+// of the attribute name gives the name of the variable; for example,
+// the value of "hook-env-TENANT_ID" is exported as $TENANT_ID.
+const HookEnvPrefix = "hook-env-"
+
+var validHookEnvName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// HookEnvironment returns the values, keyed by variable name, to
+// export to every hook run in the environment.
+func (c *Config) HookEnvironment() map[string]string {
+	vars := make(map[string]string)
+	for attr, value := range c.unknown {
+		if name := strings.TrimPrefix(attr, HookEnvPrefix); name != attr {
+			vars[name] = fmt.Sprint(value)
+		}
+	}
+	return vars
+}
+
 // HttpProxy returns the http proxy for the environment.
 func (c *Config) HttpProxy() string {
 	return c.asString("http-proxy")
@@ -621,6 +858,22 @@ func (c *Config) BootstrapSSHOpts() SSHTimeoutOpts {
 	return opts
 }
 
+// PresenceTimings returns the interval between agent presence pings and
+// the liveness window within which an agent must ping to be considered
+// alive. A zero PingInterval means one is derived from the window.
+func (c *Config) PresenceTimings() PresenceTimings {
+	timings := PresenceTimings{
+		LivenessWindow: time.Duration(DefaultPresenceLivenessWindow) * time.Second,
+	}
+	if v, ok := c.defined["presence-ping-interval"].(int); ok {
+		timings.PingInterval = time.Duration(v) * time.Second
+	}
+	if v, ok := c.defined["presence-liveness-window"].(int); ok && v != 0 {
+		timings.LivenessWindow = time.Duration(v) * time.Second
+	}
+	return timings
+}
+
 // CACert returns the certificate of the CA that signed the state server
 // certificate, in PEM format, and whether the setting is available.
 func (c *Config) CACert() (string, bool) {
@@ -649,8 +902,8 @@ func (c *Config) AdminSecret() string {
 }
 
 // FirewallMode returns whether the firewall should
-// manage ports per machine or global
-// (FwInstance or FwGlobal)
+// manage ports per machine, globally, or not at all
+// (FwInstance, FwGlobal or FwNone)
 func (c *Config) FirewallMode() string {
 	return c.mustString("firewall-mode")
 }
@@ -699,6 +952,14 @@ func (c *Config) PreferIPv6() bool {
 	return v
 }
 
+// ContainerNetworkType returns the type of networking used for
+// containers (e.g. "bridge", "host-bridge", "macvlan" or "ovs"). An
+// empty string means the default bridged networking. The
+// container-network constraint overrides it for a container.
+func (c *Config) ContainerNetworkType() string {
+	return c.asString("container-network-type")
+}
+
 // SSLHostnameVerification returns weather the environment has requested
 // SSL hostname verification to be enabled.
 func (c *Config) SSLHostnameVerification() bool {
@@ -734,6 +995,70 @@ func (c *Config) ImageStream() string {
 	return "released"
 }
 
+// ImageId holds an image id given in the environment configuration,
+// to be used instead of looking up image metadata.
+type ImageId struct {
+	// Region is the region the image is in, or "" if the
+	// image id holds in all regions.
+	Region string
+
+	Series   string
+	Arch     string
+	VirtType string
+	Id       string
+}
+
+// ImageIds returns the image ids which override the image metadata
+// when starting instances.
+func (c *Config) ImageIds() []ImageId {
+	// The value was checked when the configuration was validated.
+	ids, _ := parseImageIds(c.asString("image-ids"))
+	return ids
+}
+
+// parseImageIds parses a list of image ids separated by commas or
+// spaces, each of the form [region:]series[/arch[/virt-type]]=id.
+// The architecture defaults to amd64.
+func parseImageIds(s string) ([]ImageId, error) {
+	var ids []ImageId
+	entries := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+	for _, entry := range entries {
+		invalid := fmt.Errorf("invalid image id %q: expected [region:]series[/arch[/virt-type]]=id", entry)
+		key, id := entry, ""
+		if i := strings.Index(entry, "="); i >= 0 {
+			key, id = entry[:i], entry[i+1:]
+		}
+		if id == "" {
+			return nil, invalid
+		}
+		imageId := ImageId{Arch: "amd64", Id: id}
+		if i := strings.Index(key, ":"); i >= 0 {
+			imageId.Region, key = key[:i], key[i+1:]
+			if imageId.Region == "" {
+				return nil, invalid
+			}
+		}
+		parts := strings.Split(key, "/")
+		if len(parts) > 3 {
+			return nil, invalid
+		}
+		imageId.Series = parts[0]
+		if len(parts) > 1 {
+			imageId.Arch = parts[1]
+		}
+		if len(parts) > 2 {
+			imageId.VirtType = parts[2]
+		}
+		if imageId.Series == "" || imageId.Arch == "" || len(parts) > 2 && imageId.VirtType == "" {
+			return nil, invalid
+		}
+		ids = append(ids, imageId)
+	}
+	return ids, nil
+}
+
 // TestMode indicates if the environment is intended for testing.
 // In this case, accessing the charm store does not affect statistical
 // data of the store.
@@ -802,6 +1127,13 @@ var fields = schema.Fields{
 	"tools-metadata-url":        schema.String(),
 	"image-metadata-url":        schema.String(),
 	"image-stream":              schema.String(),
+	"image-ids":                 schema.String(),
+	"storage-backend":           schema.String(),
+	"storage-url":               schema.String(),
+	"storage-container":         schema.String(),
+	"storage-access-key":        schema.String(),
+	"storage-secret-key":        schema.String(),
+	"storage-ca-cert":           schema.String(),
 	"authorized-keys":           schema.String(),
 	"authorized-keys-path":      schema.String(),
 	"firewall-mode":             schema.String(),
@@ -815,6 +1147,15 @@ var fields = schema.Fields{
 	"ssl-hostname-verification": schema.Bool(),
 	"state-port":                schema.ForceInt(),
 	"api-port":                  schema.ForceInt(),
+	"api-listen-network":        schema.String(),
+	"auth-backend":              schema.String(),
+	"ldap-url":                  schema.String(),
+	"ldap-user-dn":              schema.String(),
+	"ldap-group-base":           schema.String(),
+	"ldap-admin-group":          schema.String(),
+	"ldap-read-only-group":      schema.String(),
+	"webhook-urls":              schema.String(),
+	"webhook-secret":            schema.String(),
 	"syslog-port":               schema.ForceInt(),
 	"rsyslog-ca-cert":           schema.String(),
 	"logging-config":            schema.String(),
@@ -830,11 +1171,14 @@ var fields = schema.Fields{
 	"bootstrap-timeout":         schema.ForceInt(),
 	"bootstrap-retry-delay":     schema.ForceInt(),
 	"bootstrap-addresses-delay": schema.ForceInt(),
+	"presence-ping-interval":    schema.ForceInt(),
+	"presence-liveness-window":  schema.ForceInt(),
 	"test-mode":                 schema.Bool(),
 	"proxy-ssh":                 schema.Bool(),
 	"lxc-clone":                 schema.Bool(),
 	"lxc-clone-aufs":            schema.Bool(),
 	"prefer-ipv6":               schema.Bool(),
+	"container-network-type":    schema.String(),
 
 	// Deprecated fields, retain for backwards compatibility.
 	"tools-url":     schema.String(),
@@ -861,6 +1205,8 @@ var alwaysOptional = schema.Defaults{
 	"bootstrap-timeout":         schema.Omit,
 	"bootstrap-retry-delay":     schema.Omit,
 	"bootstrap-addresses-delay": schema.Omit,
+	"presence-ping-interval":    schema.Omit,
+	"presence-liveness-window":  schema.Omit,
 	"rsyslog-ca-cert":           schema.Omit,
 	"http-proxy":                schema.Omit,
 	"https-proxy":               schema.Omit,
@@ -870,6 +1216,23 @@ var alwaysOptional = schema.Defaults{
 	"apt-https-proxy":           schema.Omit,
 	"apt-ftp-proxy":             schema.Omit,
 	"lxc-clone":                 schema.Omit,
+	"container-network-type":    schema.Omit,
+	"api-listen-network":        schema.Omit,
+	"auth-backend":              schema.Omit,
+	"ldap-url":                  schema.Omit,
+	"ldap-user-dn":              schema.Omit,
+	"ldap-group-base":           schema.Omit,
+	"ldap-admin-group":          schema.Omit,
+	"ldap-read-only-group":      schema.Omit,
+	"webhook-urls":              schema.Omit,
+	"webhook-secret":            schema.Omit,
+	"image-ids":                 schema.Omit,
+	"storage-backend":           schema.Omit,
+	"storage-url":               schema.Omit,
+	"storage-container":         schema.Omit,
+	"storage-access-key":        schema.Omit,
+	"storage-secret-key":        schema.Omit,
+	"storage-ca-cert":           schema.Omit,
 
 	// Deprecated fields, retain for backwards compatibility.
 	"tools-url":     "",
@@ -962,6 +1325,7 @@ var immutableAttributes = []string{
 	"firewall-mode",
 	"state-port",
 	"api-port",
+	"api-listen-network",
 	"bootstrap-timeout",
 	"bootstrap-retry-delay",
 	"bootstrap-addresses-delay",
@@ -969,6 +1333,9 @@ var immutableAttributes = []string{
 	"lxc-clone-aufs",
 	"syslog-port",
 	"prefer-ipv6",
+	"storage-backend",
+	"storage-url",
+	"storage-container",
 }
 
 var (
@@ -994,7 +1361,9 @@ func (cfg *Config) ValidateUnknownAttrs(fields schema.Fields, defaults schema.De
 	result := coerced.(map[string]interface{})
 	for name, value := range attrs {
 		if fields[name] == nil {
-			logger.Warningf("unknown config field %q", name)
+			if !strings.HasPrefix(name, HookEnvPrefix) {
+				logger.Warningf("unknown config field %q", name)
+			}
 			result[name] = value
 		}
 	}
@@ -1054,6 +1423,18 @@ type SSHTimeoutOpts struct {
 	AddressesDelay time.Duration
 }
 
+// PresenceTimings holds the timings used by agent presence pingers and
+// watchers.
+type PresenceTimings struct {
+	// PingInterval is the amount of time between pings. If zero, it
+	// is derived from LivenessWindow.
+	PingInterval time.Duration
+
+	// LivenessWindow is the amount of time within which an agent must
+	// ping to be considered alive.
+	LivenessWindow time.Duration
+}
+
 func addIfNotEmpty(settings map[string]interface{}, key, value string) {
 	if value != "" {
 		settings[key] = value
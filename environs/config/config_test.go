@@ -450,6 +450,14 @@ var configTests = []configTest{
 			"name":          "my-name",
 			"firewall-mode": config.FwGlobal,
 		},
+	}, {
+		about:       "None firewall mode",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":          "my-type",
+			"name":          "my-name",
+			"firewall-mode": config.FwNone,
+		},
 	}, {
 		about:       "Illegal firewall mode",
 		useDefaults: config.UseDefaults,
@@ -459,6 +467,31 @@ var configTests = []configTest{
 			"firewall-mode": "illegal",
 		},
 		err: "invalid firewall mode in environment configuration: .*",
+	}, {
+		about:       "Container network type",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":                   "my-type",
+			"name":                   "my-name",
+			"container-network-type": "macvlan",
+		},
+	}, {
+		about:       "Host bridge container network type",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":                   "my-type",
+			"name":                   "my-name",
+			"container-network-type": "host-bridge",
+		},
+	}, {
+		about:       "Illegal container network type",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":                   "my-type",
+			"name":                   "my-name",
+			"container-network-type": "illegal",
+		},
+		err: `invalid container-network-type "illegal"`,
 	}, {
 		about:       "ssl-hostname-verification off",
 		useDefaults: config.UseDefaults,
@@ -550,6 +583,161 @@ var configTests = []configTest{
 			"api-port": "illegal",
 		},
 		err: `api-port: expected number, got string\("illegal"\)`,
+	}, {
+		about:       "Explicit API listen network",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":               "my-type",
+			"name":               "my-name",
+			"api-listen-network": "10.0.0.0/24",
+		},
+	}, {
+		about:       "Invalid API listen network",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":               "my-type",
+			"name":               "my-name",
+			"api-listen-network": "10.0.0.1",
+		},
+		err: `invalid api-listen-network "10.0.0.1": invalid CIDR address: 10.0.0.1`,
+	}, {
+		about:       "LDAP authentication backend",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":                 "my-type",
+			"name":                 "my-name",
+			"auth-backend":         "ldap",
+			"ldap-url":             "ldap://ldap.example.com",
+			"ldap-user-dn":         "uid=%s,ou=people,dc=example,dc=com",
+			"ldap-group-base":      "ou=groups,dc=example,dc=com",
+			"ldap-admin-group":     "juju-admins",
+			"ldap-read-only-group": "juju-viewers",
+		},
+	}, {
+		about:       "LDAP authentication backend without server",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":         "my-type",
+			"name":         "my-name",
+			"auth-backend": "ldap",
+		},
+		err: `auth-backend "ldap" requires ldap-url and ldap-user-dn`,
+	}, {
+		about:       "LDAP user DN without user name",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":         "my-type",
+			"name":         "my-name",
+			"auth-backend": "ldap",
+			"ldap-url":     "ldap://ldap.example.com",
+			"ldap-user-dn": "ou=people,dc=example,dc=com",
+		},
+		err: `ldap-user-dn "ou=people,dc=example,dc=com" does not contain %s`,
+	}, {
+		about:       "Invalid authentication backend",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":         "my-type",
+			"name":         "my-name",
+			"auth-backend": "pam",
+		},
+		err: `invalid auth-backend "pam"`,
+	}, {
+		about:       "Webhook URLs",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":           "my-type",
+			"name":           "my-name",
+			"webhook-urls":   "https://chat.example.com/hooks/juju, http://tickets.example.com:8080/juju",
+			"webhook-secret": "s3cret",
+		},
+	}, {
+		about:       "Invalid webhook URL",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":         "my-type",
+			"name":         "my-name",
+			"webhook-urls": "https://chat.example.com/hooks/juju,chat.example.com",
+		},
+		err: `invalid webhook URL "chat.example.com": expected an http or https URL`,
+	}, {
+		about:       "Storage backend",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":               "my-type",
+			"name":               "my-name",
+			"storage-backend":    "s3",
+			"storage-url":        "https://s3.example.com",
+			"storage-container":  "juju-storage",
+			"storage-access-key": "key",
+			"storage-secret-key": "s3cret",
+		},
+	}, {
+		about:       "Storage backend without URL",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":            "my-type",
+			"name":            "my-name",
+			"storage-backend": "s3",
+		},
+		err: `storage-backend "s3" requires storage-url`,
+	}, {
+		about:       "Invalid storage URL",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":            "my-type",
+			"name":            "my-name",
+			"storage-backend": "s3",
+			"storage-url":     "s3.example.com",
+		},
+		err: `invalid storage-url "s3.example.com": expected an absolute URL`,
+	}, {
+		about:       "Storage CA certificate",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":            "my-type",
+			"name":            "my-name",
+			"storage-backend": "httpstorage",
+			"storage-url":     "http://10.0.0.1:8040",
+			"storage-ca-cert": caCert2,
+		},
+	}, {
+		about:       "Invalid storage CA certificate",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":            "my-type",
+			"name":            "my-name",
+			"storage-backend": "httpstorage",
+			"storage-url":     "http://10.0.0.1:8040",
+			"storage-ca-cert": "xxx",
+		},
+		err: "bad storage-ca-cert: no certificates found",
+	}, {
+		about:       "Image ids",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":      "my-type",
+			"name":      "my-name",
+			"image-ids": "trusty=ami-00000001, us-east-1:precise/i386=ami-00000002 trusty/amd64/hvm=ami-00000003",
+		},
+	}, {
+		about:       "Invalid image id",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":      "my-type",
+			"name":      "my-name",
+			"image-ids": "trusty=ami-00000001,us-east-1:precise",
+		},
+		err: `invalid image id "us-east-1:precise": expected \[region:\]series\[/arch\[/virt-type\]\]=id`,
+	}, {
+		about:       "Image id with too many parts",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":      "my-type",
+			"name":      "my-name",
+			"image-ids": "trusty/amd64/hvm/ebs=ami-00000001",
+		},
+		err: `invalid image id "trusty/amd64/hvm/ebs=ami-00000001": .*`,
 	}, {
 		about:       "Explicit syslog port",
 		useDefaults: config.UseDefaults,
@@ -618,6 +806,51 @@ var configTests = []configTest{
 			"bootstrap-addresses-delay": "illegal",
 		},
 		err: `bootstrap-addresses-delay: expected number, got string\("illegal"\)`,
+	}, {
+		about:       "Explicit presence timings",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":                     "my-type",
+			"name":                     "my-name",
+			"presence-ping-interval":   10,
+			"presence-liveness-window": 15,
+		},
+	}, {
+		about:       "Explicit presence liveness window",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":                     "my-type",
+			"name":                     "my-name",
+			"presence-liveness-window": 90,
+		},
+	}, {
+		about:       "Invalid presence liveness window",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":                     "my-type",
+			"name":                     "my-name",
+			"presence-liveness-window": 0,
+		},
+		err: `presence-liveness-window must be positive, got 0`,
+	}, {
+		about:       "Negative presence ping interval",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":                   "my-type",
+			"name":                   "my-name",
+			"presence-ping-interval": -1,
+		},
+		err: `presence-ping-interval must not be negative, got -1`,
+	}, {
+		about:       "Presence ping interval longer than liveness window",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":                     "my-type",
+			"name":                     "my-name",
+			"presence-ping-interval":   30,
+			"presence-liveness-window": 20,
+		},
+		err: `presence-ping-interval 30s must be shorter than presence-liveness-window 20s`,
 	}, {
 		about:       "Invalid logging configuration",
 		useDefaults: config.UseDefaults,
@@ -936,9 +1169,54 @@ func (test configTest) check(c *gc.C, home *gitjujutesting.FakeHome) {
 	if apiPort, ok := test.attrs["api-port"]; ok {
 		c.Assert(cfg.APIPort(), gc.Equals, apiPort)
 	}
+	if cidr, ok := test.attrs["api-listen-network"]; ok {
+		c.Assert(cfg.APIListenNetwork(), gc.Equals, cidr)
+	}
+	if backend, ok := test.attrs["auth-backend"]; ok {
+		c.Assert(cfg.AuthBackend(), gc.Equals, backend)
+		c.Assert(cfg.LDAPURL(), gc.Equals, test.attrs["ldap-url"])
+		c.Assert(cfg.LDAPUserDN(), gc.Equals, test.attrs["ldap-user-dn"])
+		c.Assert(cfg.LDAPGroupBase(), gc.Equals, test.attrs["ldap-group-base"])
+		c.Assert(cfg.LDAPAdminGroup(), gc.Equals, test.attrs["ldap-admin-group"])
+		c.Assert(cfg.LDAPReadOnlyGroup(), gc.Equals, test.attrs["ldap-read-only-group"])
+	} else {
+		c.Assert(cfg.AuthBackend(), gc.Equals, config.AuthBackendState)
+	}
 	if syslogPort, ok := test.attrs["syslog-port"]; ok {
 		c.Assert(cfg.SyslogPort(), gc.Equals, syslogPort)
 	}
+	if _, ok := test.attrs["webhook-urls"]; ok {
+		c.Assert(cfg.WebhookURLs(), gc.DeepEquals, []string{
+			"https://chat.example.com/hooks/juju",
+			"http://tickets.example.com:8080/juju",
+		})
+		c.Assert(cfg.WebhookSecret(), gc.Equals, test.attrs["webhook-secret"])
+	} else {
+		c.Assert(cfg.WebhookURLs(), gc.HasLen, 0)
+	}
+	if _, ok := test.attrs["storage-backend"]; ok {
+		c.Assert(cfg.StorageBackend(), gc.Equals, test.attrs["storage-backend"])
+		c.Assert(cfg.StorageURL(), gc.Equals, test.attrs["storage-url"])
+		c.Assert(cfg.StorageContainer(), gc.Equals, test.attrs["storage-container"])
+		c.Assert(cfg.StorageAccessKey(), gc.Equals, test.attrs["storage-access-key"])
+		c.Assert(cfg.StorageSecretKey(), gc.Equals, test.attrs["storage-secret-key"])
+		if caCert, ok := test.attrs["storage-ca-cert"]; ok {
+			c.Assert(cfg.StorageCACert(), gc.Equals, caCert)
+		} else {
+			c.Assert(cfg.StorageCACert(), gc.Equals, "")
+		}
+	} else {
+		c.Assert(cfg.StorageBackend(), gc.Equals, "")
+	}
+	if _, ok := test.attrs["image-ids"]; ok {
+		c.Assert(cfg.ImageIds(), gc.DeepEquals, []config.ImageId{
+			{Series: "trusty", Arch: "amd64", Id: "ami-00000001"},
+			{Region: "us-east-1", Series: "precise", Arch: "i386", Id: "ami-00000002"},
+			{Series: "trusty", Arch: "amd64", VirtType: "hvm", Id: "ami-00000003"},
+		})
+	} else {
+		c.Assert(cfg.ImageIds(), gc.HasLen, 0)
+	}
 	if expected, ok := test.attrs["uuid"]; ok {
 		got, exists := cfg.UUID()
 		c.Assert(exists, gc.Equals, ok)
@@ -1040,6 +1318,19 @@ func (test configTest) check(c *gc.C, home *gitjujutesting.FakeHome) {
 		sshOpts.AddressesDelay,
 		config.DefaultBootstrapSSHAddressesDelay,
 	)
+	presenceTimings := cfg.PresenceTimings()
+	test.assertDuration(
+		c,
+		"presence-liveness-window",
+		presenceTimings.LivenessWindow,
+		config.DefaultPresenceLivenessWindow,
+	)
+	test.assertDuration(
+		c,
+		"presence-ping-interval",
+		presenceTimings.PingInterval,
+		0,
+	)
 
 	if v, ok := test.attrs["image-stream"]; ok {
 		c.Assert(cfg.ImageStream(), gc.Equals, v)
@@ -1203,6 +1494,11 @@ var validationTests = []validationTest{{
 	old:   testing.Attrs{"api-port": config.DefaultAPIPort},
 	new:   testing.Attrs{"api-port": 42},
 	err:   `cannot change api-port from 17070 to 42`,
+}, {
+	about: "Cannot change the api-listen-network",
+	old:   testing.Attrs{"api-listen-network": "10.0.0.0/24"},
+	new:   testing.Attrs{"api-listen-network": "10.0.1.0/24"},
+	err:   `cannot change api-listen-network from "10.0.0.0/24" to "10.0.1.0/24"`,
 }, {
 	about: "Can change the state-port from explicit-default to implicit-default",
 	old:   testing.Attrs{"state-port": config.DefaultStatePort},
@@ -1246,6 +1542,15 @@ var validationTests = []validationTest{{
 	old:   testing.Attrs{"prefer-ipv6": false},
 	new:   testing.Attrs{"prefer-ipv6": true},
 	err:   `cannot change prefer-ipv6 from false to true`,
+}, {
+	about: "Cannot change storage-url",
+	old:   testing.Attrs{"storage-backend": "s3", "storage-url": "https://s3.example.com"},
+	new:   testing.Attrs{"storage-backend": "s3", "storage-url": "https://blobs.example.com"},
+	err:   `cannot change storage-url from "https://s3.example.com" to "https://blobs.example.com"`,
+}, {
+	about: "Can change storage-secret-key",
+	old:   testing.Attrs{"storage-backend": "s3", "storage-url": "https://s3.example.com", "storage-secret-key": "s3cret"},
+	new:   testing.Attrs{"storage-backend": "s3", "storage-url": "https://s3.example.com", "storage-secret-key": "n3w-s3cret"},
 }, {
 	about: "Can change uuid from unset to set",
 	new:   testing.Attrs{"uuid": "dcfbdb4a-bca2-49ad-aa7c-f011424e0fe4"},
@@ -1359,6 +1664,56 @@ func (s *ConfigSuite) TestLoggingConfigFromEnvironment(c *gc.C) {
 	c.Assert(config.LoggingConfig(), gc.Equals, "<root>=INFO;unit=DEBUG")
 }
 
+func (s *ConfigSuite) TestHookEnvironment(c *gc.C) {
+	s.addJujuFiles(c)
+	config := newTestConfig(c, testing.Attrs{
+		"hook-env-TENANT_ID": "tenant-1",
+		"hook-env-region":    "eu",
+		"hook-env-RETRIES":   3,
+		"other-attr":         "ignored",
+	})
+	c.Assert(config.HookEnvironment(), gc.DeepEquals, map[string]string{
+		"TENANT_ID": "tenant-1",
+		"region":    "eu",
+		"RETRIES":   "3",
+	})
+	c.Assert(newTestConfig(c, testing.Attrs{}).HookEnvironment(), gc.HasLen, 0)
+}
+
+func (s *ConfigSuite) TestSecretAttrs(c *gc.C) {
+	s.addJujuFiles(c)
+	c.Assert(newTestConfig(c, nil).SecretAttrs(), gc.HasLen, 0)
+
+	config := newTestConfig(c, testing.Attrs{
+		"storage-backend":    "s3",
+		"storage-url":        "https://s3.example.com/",
+		"storage-access-key": "access",
+		"storage-secret-key": "secret",
+		"webhook-urls":       "https://hooks.example.com/juju",
+		"webhook-secret":     "signing-key",
+	})
+	c.Assert(config.SecretAttrs(), gc.DeepEquals, map[string]string{
+		"storage-secret-key": "secret",
+		"webhook-secret":     "signing-key",
+	})
+}
+
+func (s *ConfigSuite) TestHookEnvironmentInvalidNames(c *gc.C) {
+	s.addJujuFiles(c)
+	for _, test := range []struct {
+		attr, err string
+	}{
+		{"hook-env-", `invalid hook environment variable name ""`},
+		{"hook-env-1ST", `invalid hook environment variable name "1ST"`},
+		{"hook-env-MY-VAR", `invalid hook environment variable name "MY-VAR"`},
+		{"hook-env-JUJU_UNIT_NAME", `invalid hook environment variable name "JUJU_UNIT_NAME": names beginning with JUJU_ are reserved`},
+	} {
+		attrs := testing.Attrs{"type": "my-type", "name": "my-name", test.attr: "value"}
+		_, err := config.New(config.UseDefaults, attrs)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
+
 func (s *ConfigSuite) TestProxyValuesWithFallback(c *gc.C) {
 	s.addJujuFiles(c)
 
@@ -90,6 +90,23 @@ func (*suite) TestInvalidEnv(c *gc.C) {
 	}
 }
 
+func (*suite) TestType(c *gc.C) {
+	es, err := environs.ReadEnvironsBytes([]byte(`
+environments:
+    valid:
+        type: dummy
+    invalid:
+        type: crazy
+    untyped:
+        state-server: false
+`))
+	c.Assert(err, gc.IsNil)
+	c.Assert(es.Type("valid"), gc.Equals, "dummy")
+	c.Assert(es.Type("invalid"), gc.Equals, "crazy")
+	c.Assert(es.Type("untyped"), gc.Equals, "")
+	c.Assert(es.Type("missing"), gc.Equals, "")
+}
+
 func (*suite) TestNoWarningForDeprecatedButUnusedEnv(c *gc.C) {
 	// This tests that a config that has a deprecated field doesn't
 	// generate a Warning if we don't actually ask for that environment.
@@ -11,6 +11,7 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -96,7 +97,7 @@ func (s *storageBackend) handleHead(w http.ResponseWriter, req *http.Request) {
 			http.Error(w, fmt.Sprintf("failed to split host: %v", err), http.StatusBadRequest)
 			return
 		}
-		url := fmt.Sprintf("https://%s:%d%s", host, s.httpsPort, req.URL.Path)
+		url := fmt.Sprintf("https://%s%s", net.JoinHostPort(host, strconv.Itoa(s.httpsPort)), req.URL.Path)
 		w.Header().Set("Location", url)
 	} else {
 		http.Error(w, "method HEAD is not supported", http.StatusMethodNotAllowed)
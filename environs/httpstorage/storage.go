@@ -19,11 +19,42 @@ import (
 	"github.com/juju/loggo"
 	"github.com/juju/utils"
 
+	"github.com/juju/juju/environs/config"
 	"github.com/juju/juju/environs/storage"
 )
 
 var logger = loggo.GetLogger("juju.environs.httpstorage")
 
+func init() {
+	storage.RegisterBackend("httpstorage", backend{})
+}
+
+// backend opens storage served by a juju storage server (see
+// ServeTLS), such as that of a state server of another environment,
+// at the host and port of storage-url. Files are put and removed with
+// the storage-secret-key as authentication key, over TLS verified
+// with the storage-ca-cert, or the environment's CA certificate if it
+// is not set.
+type backend struct{}
+
+func (backend) OpenStorage(cfg *config.Config) (storage.Storage, error) {
+	u, err := url.Parse(cfg.StorageURL())
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" {
+		return nil, fmt.Errorf("storage-url %q is not an http URL", cfg.StorageURL())
+	}
+	caCert := cfg.StorageCACert()
+	if caCert == "" {
+		var ok bool
+		if caCert, ok = cfg.CACert(); !ok {
+			return nil, errors.New("environment has no CA certificate")
+		}
+	}
+	return ClientTLS(u.Host, caCert, cfg.StorageSecretKey())
+}
+
 // storage implements the storage.Storage interface.
 type localStorage struct {
 	addr   string
@@ -10,11 +10,14 @@ import (
 	"io/ioutil"
 	"net/http"
 	"path/filepath"
+	"time"
 
 	"github.com/juju/errors"
 	jc "github.com/juju/testing/checkers"
 	gc "launchpad.net/gocheck"
 
+	"github.com/juju/juju/cert"
+	"github.com/juju/juju/environs/filestorage"
 	"github.com/juju/juju/environs/httpstorage"
 	"github.com/juju/juju/environs/storage"
 	coretesting "github.com/juju/juju/testing"
@@ -69,6 +72,54 @@ func (s *storageSuite) TestClientTLSInvalidAuth(c *gc.C) {
 	c.Assert(stor.RemoveAll(), gc.ErrorMatches, authErrorPattern)
 }
 
+func (s *storageSuite) TestBackend(c *gc.C) {
+	listener, _, storageDir := startServerTLS(c)
+	defer listener.Close()
+	cfg := coretesting.CustomEnvironConfig(c, coretesting.Attrs{
+		"storage-backend":    "httpstorage",
+		"storage-url":        "http://" + listener.Addr().String(),
+		"storage-secret-key": testAuthkey,
+	})
+	stor, err := storage.OpenBackend(cfg)
+	c.Assert(err, gc.IsNil)
+
+	data := []byte("hello")
+	checkPutFile(c, stor, "filename", data)
+	contents, err := ioutil.ReadFile(filepath.Join(storageDir, "filename"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(contents, gc.DeepEquals, data)
+}
+
+func (s *storageSuite) TestBackendStorageCACert(c *gc.C) {
+	// The storage server's certificate is signed by another CA
+	// than the environment's.
+	caCert, caKey, err := cert.NewCA("storage", time.Now().UTC().AddDate(1, 0, 0))
+	c.Assert(err, gc.IsNil)
+	embedded, err := filestorage.NewFileStorageWriter(c.MkDir())
+	c.Assert(err, gc.IsNil)
+	listener, err := httpstorage.ServeTLS("127.0.0.1:0", embedded, caCert, caKey, []string{"127.0.0.1"}, testAuthkey)
+	c.Assert(err, gc.IsNil)
+	defer listener.Close()
+	cfg := coretesting.CustomEnvironConfig(c, coretesting.Attrs{
+		"storage-backend":    "httpstorage",
+		"storage-url":        "http://" + listener.Addr().String(),
+		"storage-secret-key": testAuthkey,
+		"storage-ca-cert":    caCert,
+	})
+	stor, err := storage.OpenBackend(cfg)
+	c.Assert(err, gc.IsNil)
+	checkPutFile(c, stor, "filename", []byte("hello"))
+}
+
+func (s *storageSuite) TestBackendNotHTTP(c *gc.C) {
+	cfg := coretesting.CustomEnvironConfig(c, coretesting.Attrs{
+		"storage-backend": "httpstorage",
+		"storage-url":     "https://10.0.0.1:8040",
+	})
+	_, err := storage.OpenBackend(cfg)
+	c.Assert(err, gc.ErrorMatches, `cannot open httpstorage storage: storage-url "https://10.0.0.1:8040" is not an http URL`)
+}
+
 func (s *storageSuite) TestList(c *gc.C) {
 	listener, _, _ := startServer(c)
 	defer listener.Close()
@@ -0,0 +1,52 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package imagemetadata
+
+import (
+	"github.com/juju/juju/environs/config"
+)
+
+// ConfiguredImages returns the metadata of the images given in the
+// environment configuration for the region, series and architectures,
+// so that they may be used without looking up image metadata. An
+// image id given for the region takes precedence over one given for
+// all regions.
+func ConfiguredImages(ids []config.ImageId, region, series string, arches []string) []*ImageMetadata {
+	type imageKey struct {
+		arch, virtType string
+	}
+	var images []*ImageMetadata
+	found := make(map[imageKey]*ImageMetadata)
+	for _, id := range ids {
+		if id.Series != series || id.Region != "" && id.Region != region || !containsString(arches, id.Arch) {
+			continue
+		}
+		key := imageKey{id.Arch, id.VirtType}
+		if image, ok := found[key]; ok {
+			if id.Region != "" && image.RegionName == "" {
+				image.Id = id.Id
+				image.RegionName = id.Region
+			}
+			continue
+		}
+		image := &ImageMetadata{
+			Id:         id.Id,
+			Arch:       id.Arch,
+			VirtType:   id.VirtType,
+			RegionName: id.Region,
+		}
+		found[key] = image
+		images = append(images, image)
+	}
+	return images
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package imagemetadata_test
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/environs/imagemetadata"
+)
+
+type ConfiguredSuite struct{}
+
+var _ = gc.Suite(&ConfiguredSuite{})
+
+var configuredImageIds = []config.ImageId{
+	{Series: "trusty", Arch: "amd64", Id: "ami-00000001"},
+	{Region: "us-east-1", Series: "trusty", Arch: "amd64", Id: "ami-00000002"},
+	{Series: "trusty", Arch: "amd64", VirtType: "hvm", Id: "ami-00000003"},
+	{Region: "us-west-1", Series: "trusty", Arch: "i386", Id: "ami-00000004"},
+	{Series: "precise", Arch: "amd64", Id: "ami-00000005"},
+}
+
+func (*ConfiguredSuite) TestConfiguredImages(c *gc.C) {
+	images := imagemetadata.ConfiguredImages(configuredImageIds, "us-east-1", "trusty", []string{"amd64", "i386"})
+	c.Assert(images, jc.DeepEquals, []*imagemetadata.ImageMetadata{
+		{Id: "ami-00000002", Arch: "amd64", RegionName: "us-east-1"},
+		{Id: "ami-00000003", Arch: "amd64", VirtType: "hvm"},
+	})
+
+	images = imagemetadata.ConfiguredImages(configuredImageIds, "us-west-1", "trusty", []string{"i386"})
+	c.Assert(images, jc.DeepEquals, []*imagemetadata.ImageMetadata{
+		{Id: "ami-00000004", Arch: "i386", RegionName: "us-west-1"},
+	})
+}
+
+func (*ConfiguredSuite) TestConfiguredImagesNoMatch(c *gc.C) {
+	images := imagemetadata.ConfiguredImages(configuredImageIds, "us-east-1", "utopic", []string{"amd64"})
+	c.Assert(images, gc.HasLen, 0)
+	images = imagemetadata.ConfiguredImages(nil, "us-east-1", "trusty", []string{"amd64"})
+	c.Assert(images, gc.HasLen, 0)
+}
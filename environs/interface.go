@@ -178,6 +178,25 @@ type Environ interface {
 	state.Prechecker
 }
 
+// NetworkFirewaller is implemented by environments that can open
+// ports for the whole environment on one network only. The OpenPorts,
+// ClosePorts and Ports methods of Environ act on the default public
+// network. Like those, its methods must only be used if the
+// environment was setup with the FwGlobal firewall mode.
+type NetworkFirewaller interface {
+	// OpenPortsOnNetwork opens the given ports on the named
+	// network for the whole environment.
+	OpenPortsOnNetwork(networkName string, ports []network.Port) error
+
+	// ClosePortsOnNetwork closes the given ports on the named
+	// network for the whole environment.
+	ClosePortsOnNetwork(networkName string, ports []network.Port) error
+
+	// PortsOnNetwork returns the ports opened on the named
+	// network for the whole environment.
+	PortsOnNetwork(networkName string) ([]network.Port, error)
+}
+
 // BootstrapContext is an interface that is passed to
 // Environ.Bootstrap, providing a means of obtaining
 // information about and manipulating the context in which
@@ -15,6 +15,7 @@ import (
 	"github.com/juju/juju/instance"
 	"github.com/juju/juju/juju/arch"
 	"github.com/juju/juju/utils/ssh"
+	"github.com/juju/juju/version"
 )
 
 // detectionScript is the script to run on the remote machine to
@@ -122,6 +123,25 @@ func detectSeriesAndHardwareCharacteristics(host string) (hc instance.HardwareCh
 	return hc, series, nil
 }
 
+// checkSeriesAndArch returns an error if the detected series or
+// architecture of a host cannot run a juju machine agent.
+func checkSeriesAndArch(series string, hc instance.HardwareCharacteristics) error {
+	if series == "" {
+		return fmt.Errorf("cannot determine series")
+	}
+	if _, err := version.SeriesVersion(series); err != nil {
+		return fmt.Errorf("unsupported series %q", series)
+	}
+	if hc.Arch == nil || !arch.IsSupportedArch(*hc.Arch) {
+		var hostArch string
+		if hc.Arch != nil {
+			hostArch = *hc.Arch
+		}
+		return fmt.Errorf("unsupported architecture %q", hostArch)
+	}
+	return nil
+}
+
 // InitUbuntuUser adds the ubuntu user if it doesn't
 // already exist, updates its ~/.ssh/authorized_keys,
 // and enables passwordless sudo for it.
@@ -135,8 +155,11 @@ func detectSeriesAndHardwareCharacteristics(host string) (hc instance.HardwareCh
 // will be created and left empty.
 //
 // stdin and stdout will be used for remote sudo prompts,
-// if the ubuntu user must be created/updated.
-func InitUbuntuUser(host, login, authorizedKeys string, stdin io.Reader, stdout io.Writer) error {
+// if the ubuntu user must be created/updated. If sudoPassword
+// is non-empty, it is given to sudo instead of prompting, so
+// that a login without passwordless sudo may be used
+// non-interactively.
+func InitUbuntuUser(host, login, authorizedKeys, sudoPassword string, stdin io.Reader, stdout io.Writer) error {
 	logger.Infof("initialising %q, user %q", host, login)
 
 	// To avoid unnecessary prompting for the specified login,
@@ -160,8 +183,16 @@ func InitUbuntuUser(host, login, authorizedKeys string, stdin io.Reader, stdout
 	script := fmt.Sprintf(initUbuntuScript, utils.ShQuote(authorizedKeys))
 	var options ssh.Options
 	options.AllowPasswordAuthentication()
-	options.EnablePTY()
-	cmd = ssh.Command(host, []string{"sudo", "/bin/bash -c " + utils.ShQuote(script)}, &options)
+	sudo := []string{"sudo"}
+	if sudoPassword != "" {
+		// Have sudo read the password from stdin, without
+		// a prompt, rather than from a terminal.
+		sudo = append(sudo, "-S", "-p", "''")
+		stdin = strings.NewReader(sudoPassword + "\n")
+	} else {
+		options.EnablePTY()
+	}
+	cmd = ssh.Command(host, append(sudo, "/bin/bash -c "+utils.ShQuote(script)), &options)
 	var stderr bytes.Buffer
 	cmd.Stdin = stdin
 	cmd.Stdout = stdout // for sudo prompt
@@ -10,6 +10,7 @@ import (
 	gc "launchpad.net/gocheck"
 
 	"github.com/juju/juju/environs/manual"
+	"github.com/juju/juju/instance"
 	"github.com/juju/juju/testing"
 )
 
@@ -32,6 +33,30 @@ func (s *initialisationSuite) TestDetectSeries(c *gc.C) {
 	c.Assert(series, gc.Equals, "edgy")
 }
 
+func (s *initialisationSuite) TestCheckSeriesAndArch(c *gc.C) {
+	amd64, sparc := "amd64", "sparc"
+	for i, test := range []struct {
+		series string
+		arch   *string
+		err    string
+	}{
+		{"precise", &amd64, ""},
+		{"", &amd64, "cannot determine series"},
+		{"edgy", &amd64, `unsupported series "edgy"`},
+		{"precise", &sparc, `unsupported architecture "sparc"`},
+		{"precise", nil, `unsupported architecture ""`},
+	} {
+		c.Logf("test %d: %s/%v", i, test.series, test.arch)
+		hc := instance.HardwareCharacteristics{Arch: test.arch}
+		err := manual.CheckSeriesAndArch(test.series, hc)
+		if test.err == "" {
+			c.Check(err, gc.IsNil)
+		} else {
+			c.Check(err, gc.ErrorMatches, test.err)
+		}
+	}
+}
+
 func (s *initialisationSuite) TestDetectionError(c *gc.C) {
 	scriptResponse := strings.Join([]string{
 		"edgy",
@@ -140,18 +165,25 @@ func (s *initialisationSuite) TestCheckProvisioned(c *gc.C) {
 func (s *initialisationSuite) TestInitUbuntuUserNonExisting(c *gc.C) {
 	defer installFakeSSH(c, "", "", 0)() // successful creation of ubuntu user
 	defer installFakeSSH(c, "", "", 1)() // simulate failure of ubuntu@ login
-	err := manual.InitUbuntuUser("testhost", "testuser", "", nil, nil)
+	err := manual.InitUbuntuUser("testhost", "testuser", "", "", nil, nil)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *initialisationSuite) TestInitUbuntuUserSudoPassword(c *gc.C) {
+	defer installFakeSSH(c, "s3cret\n", "", 0)() // sudo reads the password from stdin
+	defer installFakeSSH(c, "", "", 1)()         // simulate failure of ubuntu@ login
+	err := manual.InitUbuntuUser("testhost", "testuser", "", "s3cret", nil, nil)
 	c.Assert(err, gc.IsNil)
 }
 
 func (s *initialisationSuite) TestInitUbuntuUserExisting(c *gc.C) {
 	defer installFakeSSH(c, "", nil, 0)()
-	manual.InitUbuntuUser("testhost", "testuser", "", nil, nil)
+	manual.InitUbuntuUser("testhost", "testuser", "", "", nil, nil)
 }
 
 func (s *initialisationSuite) TestInitUbuntuUserError(c *gc.C) {
 	defer installFakeSSH(c, "", []string{"", "failed to create ubuntu user"}, 123)()
 	defer installFakeSSH(c, "", "", 1)() // simulate failure of ubuntu@ login
-	err := manual.InitUbuntuUser("testhost", "testuser", "", nil, nil)
+	err := manual.InitUbuntuUser("testhost", "testuser", "", "", nil, nil)
 	c.Assert(err, gc.ErrorMatches, "subprocess encountered error code 123 \\(failed to create ubuntu user\\)")
 }
@@ -60,6 +60,10 @@ type ProvisionMachineArgs struct {
 	// Stdout is required to present sudo prompts to the user.
 	Stdout io.Writer
 
+	// SudoPassword, if non-empty, is given to sudo when
+	// initialising the ubuntu user instead of prompting for it.
+	SudoPassword string
+
 	// Stderr is required to present machine provisioning progress to the user.
 	Stderr io.Writer
 }
@@ -91,7 +95,7 @@ func ProvisionMachine(args ProvisionMachineArgs) (machineId string, err error) {
 	// ubuntu user's authorized_keys.
 	user, hostname := splitUserHost(args.Host)
 	authorizedKeys, err := config.ReadAuthorizedKeys("")
-	if err := InitUbuntuUser(hostname, user, authorizedKeys, args.Stdin, args.Stdout); err != nil {
+	if err := InitUbuntuUser(hostname, user, authorizedKeys, args.SudoPassword, args.Stdin, args.Stdout); err != nil {
 		return "", err
 	}
 
@@ -190,6 +194,9 @@ func gatherMachineParams(hostname string) (*params.AddMachineParams, error) {
 		err = fmt.Errorf("error detecting hardware characteristics: %v", err)
 		return nil, err
 	}
+	if err := checkSeriesAndArch(series, hc); err != nil {
+		return nil, fmt.Errorf("cannot provision %s: %v", hostname, err)
+	}
 
 	// There will never be a corresponding "instance" that any provider
 	// knows about. This is fine, and works well with the provisioner
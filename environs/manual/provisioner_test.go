@@ -115,6 +115,20 @@ func (s *provisionerSuite) TestProvisionMachine(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, "error checking if provisioned: subprocess encountered error code 255")
 }
 
+func (s *provisionerSuite) TestProvisionMachineUnsupportedArch(c *gc.C) {
+	args := s.getArgs(c)
+	hostname := args.Host
+	defer fakeSSH{
+		Series:             "precise",
+		Arch:               "sparc",
+		InitUbuntuUser:     true,
+		SkipProvisionAgent: true,
+	}.install(c).Restore()
+	machineId, err := manual.ProvisionMachine(args)
+	c.Assert(err, gc.ErrorMatches, `cannot provision `+hostname+`: unsupported architecture "sparc"`)
+	c.Assert(machineId, gc.Equals, "")
+}
+
 func (s *provisionerSuite) TestFinishMachineConfig(c *gc.C) {
 	const series = "precise"
 	const arch = "amd64"
@@ -0,0 +1,49 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package storage
+
+import (
+	"fmt"
+
+	"github.com/juju/juju/environs/config"
+)
+
+// A Backend opens storage held in a blob store other than the one
+// provided by the environment's provider.
+type Backend interface {
+	// OpenStorage returns the storage described by the storage-*
+	// settings of the given environment configuration.
+	OpenStorage(cfg *config.Config) (Storage, error)
+}
+
+var backends = make(map[string]Backend)
+
+// RegisterBackend registers a storage backend with the given name,
+// by which environment configurations select it.
+func RegisterBackend(name string, b Backend) {
+	if backends[name] != nil {
+		panic(fmt.Errorf("juju: duplicate storage backend name %q", name))
+	}
+	backends[name] = b
+}
+
+// OpenBackend returns the storage of the backend named by the
+// storage-backend setting of the given environment configuration.
+// It returns nil if no backend is named, in which case the storage
+// provided by the environment's provider should be used.
+func OpenBackend(cfg *config.Config) (Storage, error) {
+	name := cfg.StorageBackend()
+	if name == "" {
+		return nil, nil
+	}
+	b, ok := backends[name]
+	if !ok {
+		return nil, fmt.Errorf("no registered storage backend for %q", name)
+	}
+	stor, err := b.OpenStorage(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open %s storage: %v", name, err)
+	}
+	return stor, nil
+}
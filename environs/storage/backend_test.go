@@ -0,0 +1,80 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package storage_test
+
+import (
+	"fmt"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/environs/filestorage"
+	"github.com/juju/juju/environs/storage"
+	"github.com/juju/juju/testing"
+)
+
+type backendSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&backendSuite{})
+
+// testBackend opens file storage in the directory named
+// by the storage-container setting.
+type testBackend struct{}
+
+func (testBackend) OpenStorage(cfg *config.Config) (storage.Storage, error) {
+	if cfg.StorageContainer() == "" {
+		return nil, fmt.Errorf("no storage-container")
+	}
+	return filestorage.NewFileStorageWriter(cfg.StorageContainer())
+}
+
+func init() {
+	storage.RegisterBackend("test", testBackend{})
+}
+
+func (s *backendSuite) TestOpenBackendNone(c *gc.C) {
+	stor, err := storage.OpenBackend(testing.EnvironConfig(c))
+	c.Assert(err, gc.IsNil)
+	c.Assert(stor, gc.IsNil)
+}
+
+func (s *backendSuite) TestOpenBackend(c *gc.C) {
+	dir := c.MkDir()
+	cfg := testing.CustomEnvironConfig(c, testing.Attrs{
+		"storage-backend":   "test",
+		"storage-url":       "file://localhost/",
+		"storage-container": dir,
+	})
+	stor, err := storage.OpenBackend(cfg)
+	c.Assert(err, gc.IsNil)
+	url, err := stor.URL("foo")
+	c.Assert(err, gc.IsNil)
+	c.Assert(url, gc.Equals, "file://"+dir+"/foo")
+}
+
+func (s *backendSuite) TestOpenBackendError(c *gc.C) {
+	cfg := testing.CustomEnvironConfig(c, testing.Attrs{
+		"storage-backend": "test",
+		"storage-url":     "file://localhost/",
+	})
+	_, err := storage.OpenBackend(cfg)
+	c.Assert(err, gc.ErrorMatches, "cannot open test storage: no storage-container")
+}
+
+func (s *backendSuite) TestOpenBackendUnknown(c *gc.C) {
+	cfg := testing.CustomEnvironConfig(c, testing.Attrs{
+		"storage-backend": "ftp",
+		"storage-url":     "ftp://ftp.example.com/",
+	})
+	_, err := storage.OpenBackend(cfg)
+	c.Assert(err, gc.ErrorMatches, `no registered storage backend for "ftp"`)
+}
+
+func (s *backendSuite) TestRegisterBackendDuplicate(c *gc.C) {
+	c.Assert(func() {
+		storage.RegisterBackend("test", testBackend{})
+	}, gc.PanicMatches, `juju: duplicate storage backend name "test"`)
+}
@@ -38,6 +38,15 @@ type SyncContext struct {
 	// Copy tools with minor version, if MinorVersion > 0.
 	MinorVersion int
 
+	// Copy only tools with this exact version number, if not zero.
+	Number version.Number
+
+	// Copy only tools for this series, if not empty.
+	Series string
+
+	// Copy only tools for this architecture, if not empty.
+	Arch string
+
 	// DryRun controls that nothing is copied. Instead it's logged
 	// what would be coppied.
 	DryRun bool
@@ -62,6 +71,10 @@ func SyncTools(syncContext *SyncContext) error {
 	}
 
 	logger.Infof("listing available tools")
+	if syncContext.Number != version.Zero {
+		syncContext.MajorVersion = syncContext.Number.Major
+		syncContext.MinorVersion = syncContext.Number.Minor
+	}
 	if syncContext.MajorVersion == 0 && syncContext.MinorVersion == 0 {
 		syncContext.MajorVersion = version.Current.Major
 		syncContext.MinorVersion = -1
@@ -77,7 +90,12 @@ func SyncTools(syncContext *SyncContext) error {
 	released := !syncContext.Dev && !version.Current.IsDev()
 	sourceTools, err := envtools.FindToolsForCloud(
 		[]simplestreams.DataSource{sourceDataSource}, simplestreams.CloudSpec{},
-		syncContext.MajorVersion, syncContext.MinorVersion, coretools.Filter{Released: released})
+		syncContext.MajorVersion, syncContext.MinorVersion, coretools.Filter{
+			Released: released,
+			Number:   syncContext.Number,
+			Series:   syncContext.Series,
+			Arch:     syncContext.Arch,
+		})
 	if err != nil {
 		return err
 	}
@@ -106,11 +124,15 @@ func SyncTools(syncContext *SyncContext) error {
 
 	missing := sourceTools.Exclude(targetTools)
 	logger.Infof("found %d tools in target; %d tools to be copied", len(targetTools), len(missing))
-	err = copyTools(missing, syncContext, targetStorage)
+	copied, err := copyTools(missing, syncContext, targetStorage)
 	if err != nil {
 		return err
 	}
-	logger.Infof("copied %d tools", len(missing))
+	if syncContext.DryRun {
+		logger.Infof("would copy %d tools (%dkB)", len(missing), sizeInKB(copied))
+	} else {
+		logger.Infof("copied %d tools (%dkB)", len(missing), sizeInKB(copied))
+	}
 
 	logger.Infof("generating tools metadata")
 	if !syncContext.DryRun {
@@ -142,18 +164,31 @@ func selectSourceDatasource(syncContext *SyncContext) (simplestreams.DataSource,
 	return simplestreams.NewURLDataSource("sync tools source", sourceURL, utils.VerifySSLHostnames), nil
 }
 
-// copyTools copies a set of tools from the source to the target.
-func copyTools(tools []*coretools.Tools, syncContext *SyncContext, dest storage.Storage) error {
-	for _, tool := range tools {
-		logger.Infof("copying %s from %s", tool.Version, tool.URL)
+// copyTools copies a set of tools from the source to the target,
+// and returns the number of bytes copied. With DryRun, nothing is
+// copied and the size of the tools that would be copied is returned.
+func copyTools(tools []*coretools.Tools, syncContext *SyncContext, dest storage.Storage) (int64, error) {
+	var total int64
+	for i, tool := range tools {
+		progress := fmt.Sprintf("%d/%d", i+1, len(tools))
 		if syncContext.DryRun {
+			logger.Infof("would copy %s (%s, %dkB) from %s", tool.Version, progress, sizeInKB(tool.Size), tool.URL)
+			total += tool.Size
 			continue
 		}
+		logger.Infof("copying %s (%s) from %s", tool.Version, progress, tool.URL)
 		if err := copyOneToolsPackage(tool, dest); err != nil {
-			return err
+			return total, err
 		}
+		total += tool.Size
+		logger.Infof("copied %s (%s): %dkB, %dkB in total", tool.Version, progress, sizeInKB(tool.Size), sizeInKB(total))
 	}
-	return nil
+	return total, nil
+}
+
+// sizeInKB returns the given size in bytes rounded to kilobytes.
+func sizeInKB(size int64) int64 {
+	return (size + 512) / 1024
 }
 
 // copyOneToolsPackage copies one tool from the source to the target.
@@ -171,9 +206,7 @@ func copyOneToolsPackage(tool *coretools.Tools, dest storage.Storage) error {
 	if err != nil {
 		return err
 	}
-	sizeInKB := (tool.Size + 512) / 1024
-	logger.Infof("downloaded %v (%dkB), uploading", toolsName, sizeInKB)
-	logger.Infof("download %dkB, uploading", sizeInKB)
+	logger.Infof("downloaded %v (%dkB), uploading", toolsName, sizeInKB(tool.Size))
 	return dest.Put(toolsName, buf, tool.Size)
 }
 
@@ -14,6 +14,7 @@ import (
 	"sort"
 	"testing"
 
+	"github.com/juju/loggo"
 	gitjujutesting "github.com/juju/testing"
 	jc "github.com/juju/testing/checkers"
 	"github.com/juju/utils"
@@ -159,6 +160,28 @@ var tests = []struct {
 		},
 		tools: v1all,
 	},
+	{
+		description: "copy matching series from the dummy environment",
+		ctx: &sync.SyncContext{
+			Series: "quantal",
+		},
+		tools: []version.Binary{v180q64},
+	},
+	{
+		description: "copy matching arch from the dummy environment",
+		ctx: &sync.SyncContext{
+			AllVersions: true,
+			Arch:        "i386",
+		},
+		tools: []version.Binary{v100q32, v180p32},
+	},
+	{
+		description: "copy exact version from the dummy environment",
+		ctx: &sync.SyncContext{
+			Number: version.MustParse("1.0.0"),
+		},
+		tools: v100all,
+	},
 	{
 		description: "write the mirrors files",
 		ctx: &sync.SyncContext{
@@ -203,6 +226,45 @@ func (s *syncSuite) TestSyncing(c *gc.C) {
 	}
 }
 
+func (s *syncSuite) TestSyncingReportsProgress(c *gc.C) {
+	s.setUpTest(c)
+	defer s.tearDownTest(c)
+
+	var tw loggo.TestWriter
+	c.Assert(loggo.RegisterWriter("sync-tester", &tw, loggo.INFO), gc.IsNil)
+	defer loggo.RemoveWriter("sync-tester")
+
+	err := sync.SyncTools(&sync.SyncContext{Target: s.targetEnv.Storage()})
+	c.Assert(err, gc.IsNil)
+	c.Check(tw.Log(), jc.LogMatches, []string{
+		`copied 1\.8\.0-.* \(1/2\): \d+kB, \d+kB in total`,
+		`copied 1\.8\.0-.* \(2/2\): \d+kB, \d+kB in total`,
+		`copied 2 tools \(\d+kB\)`,
+	})
+}
+
+func (s *syncSuite) TestSyncingDryRun(c *gc.C) {
+	s.setUpTest(c)
+	defer s.tearDownTest(c)
+
+	var tw loggo.TestWriter
+	c.Assert(loggo.RegisterWriter("sync-tester", &tw, loggo.INFO), gc.IsNil)
+	defer loggo.RemoveWriter("sync-tester")
+
+	err := sync.SyncTools(&sync.SyncContext{
+		Target: s.targetEnv.Storage(),
+		DryRun: true,
+	})
+	c.Assert(err, gc.IsNil)
+	c.Check(tw.Log(), jc.LogMatches, []string{
+		`would copy 1\.8\.0-.* \(1/2, \d+kB\) from .*`,
+		`would copy 1\.8\.0-.* \(2/2, \d+kB\) from .*`,
+		`would copy 2 tools \(\d+kB\)`,
+	})
+	_, err = envtools.ReadList(s.targetEnv.Storage(), 1, -1)
+	c.Assert(err, gc.NotNil)
+}
+
 var (
 	v100p64 = version.MustParseBinary("1.0.0-precise-amd64")
 	v100q64 = version.MustParseBinary("1.0.0-quantal-amd64")
@@ -0,0 +1,321 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package webdavstorage
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/juju/errors"
+	"github.com/juju/loggo"
+	"github.com/juju/utils"
+
+	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/environs/storage"
+)
+
+var logger = loggo.GetLogger("juju.environs.webdavstorage")
+
+func init() {
+	storage.RegisterBackend("webdav", backend{})
+}
+
+// backend opens storage in the collection named by storage-container
+// within the WebDAV collection at storage-url. Requests are made with
+// basic authentication, with storage-access-key as user name and
+// storage-secret-key as password. The URLs of the files are given to
+// the machines of the environment without any credentials, so the
+// server must allow files to be read anonymously. An https server is
+// verified with the storage-ca-cert, if it is set.
+type backend struct{}
+
+func (backend) OpenStorage(cfg *config.Config) (storage.Storage, error) {
+	u, err := url.Parse(cfg.StorageURL())
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("storage-url %q is not an http or https URL", cfg.StorageURL())
+	}
+	u.User = nil
+	client := utils.GetValidatingHTTPClient()
+	if caCert := cfg.StorageCACert(); caCert != "" {
+		caCerts := x509.NewCertPool()
+		if !caCerts.AppendCertsFromPEM([]byte(caCert)) {
+			return nil, fmt.Errorf("cannot add storage-ca-cert to the certificate pool")
+		}
+		client = &http.Client{
+			Transport: utils.NewHttpTLSTransport(&tls.Config{RootCAs: caCerts}),
+		}
+	}
+	return &davStorage{
+		root:     u,
+		dir:      cfg.StorageContainer(),
+		user:     cfg.StorageAccessKey(),
+		password: cfg.StorageSecretKey(),
+		client:   client,
+		made:     make(map[string]bool),
+	}, nil
+}
+
+// davStorage implements storage.Storage on a WebDAV collection.
+type davStorage struct {
+	// root holds the URL of the collection holding dir.
+	root *url.URL
+	// dir holds the path, relative to root, of the collection
+	// holding the files. It is empty if root holds them.
+	dir string
+
+	user     string
+	password string
+	client   *http.Client
+
+	// made records the collections known to exist, so that they
+	// are only made once for each storage.
+	mu   sync.Mutex
+	made map[string]bool
+}
+
+// url returns the URL of the file or collection with the given name.
+func (s *davStorage) url(name string) *url.URL {
+	u := *s.root
+	u.Path = path.Join("/", u.Path, s.dir, name)
+	if strings.HasSuffix(name, "/") {
+		u.Path += "/"
+	}
+	return &u
+}
+
+// do makes a request with the given method on the file or collection
+// with the given name.
+func (s *davStorage) do(method, name string, body io.Reader, length int64, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequest(method, s.url(name).String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if body != nil {
+		req.ContentLength = length
+	}
+	if s.user != "" {
+		req.SetBasicAuth(s.user, s.password)
+	}
+	return s.client.Do(req)
+}
+
+// Get is specified in the StorageReader interface.
+func (s *davStorage) Get(name string) (io.ReadCloser, error) {
+	resp, err := s.do("GET", name, nil, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, errors.NotFoundf("file %q", name)
+	}
+	resp.Body.Close()
+	return nil, fmt.Errorf("cannot get file %q: %s", name, resp.Status)
+}
+
+// multistatus holds the parts of a PROPFIND response used to list
+// the members of a collection.
+type multistatus struct {
+	Responses []struct {
+		Href       string    `xml:"href"`
+		Collection *struct{} `xml:"propstat>prop>resourcetype>collection"`
+	} `xml:"response"`
+}
+
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<propfind xmlns="DAV:"><prop><resourcetype/></prop></propfind>`
+
+// members returns the names of the members of the collection dir,
+// which is empty or ends in a slash. The names of collections end in
+// a slash.
+func (s *davStorage) members(dir string) ([]string, error) {
+	header := http.Header{
+		"Depth":        {"1"},
+		"Content-Type": {"application/xml"},
+	}
+	resp, err := s.do("PROPFIND", dir, strings.NewReader(propfindBody), int64(len(propfindBody)), header)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusMultiStatus:
+	case http.StatusNotFound:
+		return nil, errors.NotFoundf("collection %q", dir)
+	default:
+		return nil, fmt.Errorf("cannot list collection %q: %s", dir, resp.Status)
+	}
+	var ms multistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, fmt.Errorf("cannot list collection %q: %v", dir, err)
+	}
+	dirPath := s.url(dir).Path
+	if !strings.HasSuffix(dirPath, "/") {
+		dirPath += "/"
+	}
+	var names []string
+	for _, r := range ms.Responses {
+		href, err := url.Parse(r.Href)
+		if err != nil || !strings.HasPrefix(href.Path, dirPath) {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(href.Path, dirPath), "/")
+		if name == "" || strings.Contains(name, "/") {
+			// The collection itself.
+			continue
+		}
+		if r.Collection != nil {
+			name += "/"
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// List is specified in the StorageReader interface.
+func (s *davStorage) List(prefix string) ([]string, error) {
+	var dir string
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		dir = prefix[:i+1]
+	}
+	var names []string
+	if err := s.list(dir, prefix, &names); err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// list appends to names the names of the files with the given prefix
+// within the collection dir and its subcollections.
+func (s *davStorage) list(dir, prefix string, names *[]string) error {
+	members, err := s.members(dir)
+	if errors.IsNotFound(err) {
+		// Collections are only made when the first file is put.
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, member := range members {
+		name := dir + member
+		if !strings.HasSuffix(name, "/") {
+			if strings.HasPrefix(name, prefix) {
+				*names = append(*names, name)
+			}
+		} else if strings.HasPrefix(name, prefix) || strings.HasPrefix(prefix, name) {
+			if err := s.list(name, prefix, names); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// URL is specified in the StorageReader interface.
+func (s *davStorage) URL(name string) (string, error) {
+	return s.url(name).String(), nil
+}
+
+// DefaultConsistencyStrategy is specified in the StorageReader interface.
+func (s *davStorage) DefaultConsistencyStrategy() utils.AttemptStrategy {
+	return utils.AttemptStrategy{}
+}
+
+// ShouldRetry is specified in the StorageReader interface.
+func (s *davStorage) ShouldRetry(err error) bool {
+	return false
+}
+
+// makeCollections makes the collections holding the file with the
+// given name, as WebDAV servers do not make missing collections when
+// a file is put.
+func (s *davStorage) makeCollections(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// The first collection to make is dir itself, unless the files
+	// are held directly in the collection at storage-url.
+	var dirs []string
+	if s.dir != "" {
+		dirs = append(dirs, "")
+	}
+	parts := strings.Split(name, "/")
+	for i := 1; i < len(parts); i++ {
+		dirs = append(dirs, strings.Join(parts[:i], "/")+"/")
+	}
+	for _, dir := range dirs {
+		if s.made[dir] {
+			continue
+		}
+		resp, err := s.do("MKCOL", dir, nil, 0, nil)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		// A collection which already exists cannot be made.
+		if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusMethodNotAllowed {
+			return fmt.Errorf("cannot make collection %q: %s", dir, resp.Status)
+		}
+		s.made[dir] = true
+	}
+	return nil
+}
+
+// Put is specified in the StorageWriter interface.
+func (s *davStorage) Put(name string, r io.Reader, length int64) error {
+	logger.Debugf("putting %q (len %d) to storage", name, length)
+	if err := s.makeCollections(name); err != nil {
+		return err
+	}
+	// The reader is wrapped so that its Close method, if any, is not
+	// called by the http client; callers may reuse it.
+	justReader := struct{ io.Reader }{r}
+	header := http.Header{"Content-Type": {"application/octet-stream"}}
+	resp, err := s.do("PUT", name, justReader, length, header)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		return nil
+	}
+	return fmt.Errorf("cannot put file %q: %s", name, resp.Status)
+}
+
+// Remove is specified in the StorageWriter interface.
+func (s *davStorage) Remove(name string) error {
+	resp, err := s.do("DELETE", name, nil, 0, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	}
+	return fmt.Errorf("cannot remove file %q: %s", name, resp.Status)
+}
+
+// RemoveAll is specified in the StorageWriter interface.
+func (s *davStorage) RemoveAll() error {
+	return storage.RemoveAll(s)
+}
@@ -0,0 +1,319 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package webdavstorage_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	stdtesting "testing"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/environs/storage"
+	_ "github.com/juju/juju/environs/webdavstorage"
+	coretesting "github.com/juju/juju/testing"
+)
+
+func TestPackage(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+type storageSuite struct {
+	coretesting.BaseSuite
+	server *davServer
+	http   *httptest.Server
+}
+
+var _ = gc.Suite(&storageSuite{})
+
+func (s *storageSuite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	s.server = newDAVServer("/dav/")
+	s.http = httptest.NewServer(s.server)
+}
+
+func (s *storageSuite) TearDownTest(c *gc.C) {
+	s.http.Close()
+	s.BaseSuite.TearDownTest(c)
+}
+
+func (s *storageSuite) openStorage(c *gc.C, attrs coretesting.Attrs) storage.Storage {
+	cfg := coretesting.CustomEnvironConfig(c, coretesting.Attrs{
+		"storage-backend":    "webdav",
+		"storage-url":        s.http.URL + "/dav",
+		"storage-container":  "juju",
+		"storage-access-key": "user",
+		"storage-secret-key": "secret",
+	}.Merge(attrs))
+	stor, err := storage.OpenBackend(cfg)
+	c.Assert(err, gc.IsNil)
+	return stor
+}
+
+func (s *storageSuite) TestPutGet(c *gc.C) {
+	stor := s.openStorage(c, nil)
+	err := stor.Put("tools/releases/foo.tgz", bytes.NewReader([]byte("hello")), 5)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.server.file("/dav/juju/tools/releases/foo.tgz"), gc.Equals, "hello")
+
+	r, err := stor.Get("tools/releases/foo.tgz")
+	c.Assert(err, gc.IsNil)
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(data), gc.Equals, "hello")
+}
+
+func (s *storageSuite) TestGetNotFound(c *gc.C) {
+	stor := s.openStorage(c, nil)
+	_, err := stor.Get("foo")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *storageSuite) TestURLIsReadableAnonymously(c *gc.C) {
+	stor := s.openStorage(c, nil)
+	err := stor.Put("foo", bytes.NewReader([]byte("hello")), 5)
+	c.Assert(err, gc.IsNil)
+
+	fooURL, err := stor.URL("foo")
+	c.Assert(err, gc.IsNil)
+	c.Assert(fooURL, gc.Equals, s.http.URL+"/dav/juju/foo")
+	resp, err := http.Get(fooURL)
+	c.Assert(err, gc.IsNil)
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(data), gc.Equals, "hello")
+}
+
+func (s *storageSuite) TestList(c *gc.C) {
+	stor := s.openStorage(c, nil)
+	names, err := stor.List("")
+	c.Assert(err, gc.IsNil)
+	c.Assert(names, gc.HasLen, 0)
+
+	for _, name := range []string{"tools/releases/b", "tools/releases/a", "tools/other", "provider-state", "charms/x"} {
+		err := stor.Put(name, bytes.NewReader(nil), 0)
+		c.Assert(err, gc.IsNil)
+	}
+	for prefix, expected := range map[string][]string{
+		"":                {"charms/x", "provider-state", "tools/other", "tools/releases/a", "tools/releases/b"},
+		"tools/":          {"tools/other", "tools/releases/a", "tools/releases/b"},
+		"tools/rel":       {"tools/releases/a", "tools/releases/b"},
+		"tools/releases/": {"tools/releases/a", "tools/releases/b"},
+		"prov":            {"provider-state"},
+		"missing/":        nil,
+	} {
+		names, err := stor.List(prefix)
+		c.Check(err, gc.IsNil)
+		c.Check(names, gc.DeepEquals, expected, gc.Commentf("prefix %q", prefix))
+	}
+}
+
+func (s *storageSuite) TestRemove(c *gc.C) {
+	stor := s.openStorage(c, nil)
+	err := stor.Put("foo", bytes.NewReader([]byte("hello")), 5)
+	c.Assert(err, gc.IsNil)
+	err = stor.Remove("foo")
+	c.Assert(err, gc.IsNil)
+	_, err = stor.Get("foo")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	// Removing a missing file is not an error.
+	err = stor.Remove("foo")
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *storageSuite) TestRemoveAll(c *gc.C) {
+	stor := s.openStorage(c, nil)
+	for _, name := range []string{"a", "b/c"} {
+		err := stor.Put(name, bytes.NewReader(nil), 0)
+		c.Assert(err, gc.IsNil)
+	}
+	err := stor.RemoveAll()
+	c.Assert(err, gc.IsNil)
+	names, err := stor.List("")
+	c.Assert(err, gc.IsNil)
+	c.Assert(names, gc.HasLen, 0)
+}
+
+func (s *storageSuite) TestWithoutContainer(c *gc.C) {
+	cfg := coretesting.CustomEnvironConfig(c, coretesting.Attrs{
+		"storage-backend":    "webdav",
+		"storage-url":        s.http.URL + "/dav/",
+		"storage-access-key": "user",
+		"storage-secret-key": "secret",
+	})
+	stor, err := storage.OpenBackend(cfg)
+	c.Assert(err, gc.IsNil)
+	err = stor.Put("foo", bytes.NewReader([]byte("hello")), 5)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.server.file("/dav/foo"), gc.Equals, "hello")
+}
+
+func (s *storageSuite) TestUnauthorized(c *gc.C) {
+	stor := s.openStorage(c, coretesting.Attrs{"storage-secret-key": "wrong"})
+	err := stor.Put("foo", bytes.NewReader([]byte("hello")), 5)
+	c.Assert(err, gc.ErrorMatches, `cannot make collection "": 401 Unauthorized`)
+}
+
+func (s *storageSuite) TestNotHTTP(c *gc.C) {
+	cfg := coretesting.CustomEnvironConfig(c, coretesting.Attrs{
+		"storage-backend": "webdav",
+		"storage-url":     "ftp://dav.example.com/",
+	})
+	_, err := storage.OpenBackend(cfg)
+	c.Assert(err, gc.ErrorMatches, `cannot open webdav storage: storage-url "ftp://dav.example.com/" is not an http or https URL`)
+}
+
+// davServer is a minimal WebDAV server holding its files in memory
+// below a root collection. Only reads may be made without the
+// credentials "user" and "secret".
+type davServer struct {
+	mu    sync.Mutex
+	files map[string][]byte
+	// collections holds the paths of the collections, which end
+	// in a slash.
+	collections map[string]bool
+}
+
+func newDAVServer(root string) *davServer {
+	return &davServer{
+		files:       make(map[string][]byte),
+		collections: map[string]bool{root: true},
+	}
+}
+
+func (s *davServer) file(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return string(s.files[name])
+}
+
+func (s *davServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if req.Method != "GET" {
+		if user, password, ok := basicAuth(req); !ok || user != "user" || password != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+	p := req.URL.Path
+	parent := path.Dir(strings.TrimSuffix(p, "/")) + "/"
+	switch req.Method {
+	case "GET":
+		data, ok := s.files[p]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		w.Write(data)
+	case "PUT":
+		if !s.collections[parent] {
+			http.Error(w, "no parent collection", http.StatusConflict)
+			return
+		}
+		data, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		s.files[p] = data
+		w.WriteHeader(http.StatusCreated)
+	case "MKCOL":
+		if !strings.HasSuffix(p, "/") {
+			p += "/"
+		}
+		if s.collections[p] {
+			http.Error(w, "collection exists", http.StatusMethodNotAllowed)
+			return
+		}
+		if !s.collections[parent] {
+			http.Error(w, "no parent collection", http.StatusConflict)
+			return
+		}
+		s.collections[p] = true
+		w.WriteHeader(http.StatusCreated)
+	case "DELETE":
+		if _, ok := s.files[p]; !ok {
+			http.NotFound(w, req)
+			return
+		}
+		delete(s.files, p)
+		w.WriteHeader(http.StatusNoContent)
+	case "PROPFIND":
+		if req.Header.Get("Depth") != "1" {
+			http.Error(w, "only depth 1 is supported", http.StatusForbidden)
+			return
+		}
+		if !strings.HasSuffix(p, "/") {
+			p += "/"
+		}
+		if !s.collections[p] {
+			http.NotFound(w, req)
+			return
+		}
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprint(w, `<?xml version="1.0" encoding="utf-8"?><D:multistatus xmlns:D="DAV:">`)
+		writeResponse(w, p, true)
+		var members []string
+		for name := range s.files {
+			if path.Dir(name)+"/" == p {
+				members = append(members, name)
+			}
+		}
+		for name := range s.collections {
+			if name != p && path.Dir(strings.TrimSuffix(name, "/"))+"/" == p {
+				members = append(members, name)
+			}
+		}
+		sort.Strings(members)
+		for _, name := range members {
+			writeResponse(w, name, strings.HasSuffix(name, "/"))
+		}
+		fmt.Fprint(w, `</D:multistatus>`)
+	default:
+		http.Error(w, "unsupported method", http.StatusMethodNotAllowed)
+	}
+}
+
+// writeResponse writes the multistatus response element of the file
+// or collection with the given path.
+func writeResponse(w http.ResponseWriter, p string, collection bool) {
+	resourceType := "<D:resourcetype/>"
+	if collection {
+		resourceType = "<D:resourcetype><D:collection/></D:resourcetype>"
+	}
+	href := (&url.URL{Path: p}).String()
+	fmt.Fprintf(w, `<D:response><D:href>%s</D:href><D:propstat><D:prop>%s</D:prop><D:status>HTTP/1.1 200 OK</D:status></D:propstat></D:response>`, href, resourceType)
+}
+
+// basicAuth returns the credentials of the request's basic
+// authentication, if any.
+func basicAuth(req *http.Request) (user, password string, ok bool) {
+	auth := req.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Basic ") {
+		return "", "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(auth, "Basic "))
+	if err != nil {
+		return "", "", false
+	}
+	parts := strings.SplitN(string(decoded), ":", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
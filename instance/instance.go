@@ -46,6 +46,27 @@ type Instance interface {
 	Ports(machineId string) ([]network.Port, error)
 }
 
+// NetworkFirewaller is implemented by instances that can open ports
+// on one of their networks only. The OpenPorts, ClosePorts and Ports
+// methods of Instance act on the default public network.
+type NetworkFirewaller interface {
+	// OpenPortsOnNetwork opens the given ports on the named network
+	// of the instance, which should have been started with the
+	// given machine id.
+	OpenPortsOnNetwork(machineId, networkName string, ports []network.Port) error
+
+	// ClosePortsOnNetwork closes the given ports on the named
+	// network of the instance, which should have been started with
+	// the given machine id.
+	ClosePortsOnNetwork(machineId, networkName string, ports []network.Port) error
+
+	// PortsOnNetwork returns the set of ports open on the named
+	// network of the instance, which should have been started with
+	// the given machine id. The ports are returned as sorted by
+	// SortPorts.
+	PortsOnNetwork(machineId, networkName string) ([]network.Port, error)
+}
+
 // HardwareCharacteristics represents the characteristics of the instance (if known).
 // Attributes that are nil are unknown or not supported.
 type HardwareCharacteristics struct {
@@ -0,0 +1,68 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package interfaceschema
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"launchpad.net/goyaml"
+)
+
+// charmMeta holds the part of a charm's metadata.yaml declaring the
+// schemas of the charm's relation interfaces, as in:
+//
+//	interface-schemas:
+//	  mysql:
+//	    strict: true
+//	    fields:
+//	      host: string
+//	      port: int
+//
+// The charm package ignores the section, so charms declaring schemas
+// can still be deployed by older versions of juju.
+type charmMeta struct {
+	Schemas map[string]struct {
+		Fields map[string]FieldType
+		Strict bool
+	} `yaml:"interface-schemas"`
+}
+
+// ParseCharmSchemas returns the interface schemas declared in the
+// given charm metadata, keyed by interface name.
+func ParseCharmSchemas(data []byte) (map[string]*Schema, error) {
+	var meta charmMeta
+	if err := goyaml.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("cannot parse interface schemas: %v", err)
+	}
+	schemas := make(map[string]*Schema)
+	for name, declared := range meta.Schemas {
+		schema := &Schema{
+			Interface: name,
+			Fields:    declared.Fields,
+			Strict:    declared.Strict,
+		}
+		if err := schema.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid schema for interface %q: %v", name, err)
+		}
+		schemas[name] = schema
+	}
+	return schemas, nil
+}
+
+// ReadCharmSchemas returns the interface schemas declared in the
+// metadata of the charm expanded in the given directory, keyed by
+// interface name. No schemas are returned if there is no charm in
+// the directory.
+func ReadCharmSchemas(charmDir string) (map[string]*Schema, error) {
+	data, err := ioutil.ReadFile(filepath.Join(charmDir, "metadata.yaml"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return ParseCharmSchemas(data)
+}
@@ -0,0 +1,78 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package interfaceschema_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/interfaceschema"
+)
+
+type CharmSuite struct{}
+
+var _ = gc.Suite(&CharmSuite{})
+
+const mysqlMeta = `
+name: mysql
+summary: "Database engine"
+description: "A pretty popular database"
+provides:
+  server: mysql
+interface-schemas:
+  mysql:
+    strict: true
+    fields:
+      host: string
+      port: int
+`
+
+func (s *CharmSuite) TestParseCharmSchemas(c *gc.C) {
+	schemas, err := interfaceschema.ParseCharmSchemas([]byte(mysqlMeta))
+	c.Assert(err, gc.IsNil)
+	c.Assert(schemas, jc.DeepEquals, map[string]*interfaceschema.Schema{
+		"mysql": {
+			Interface: "mysql",
+			Fields: map[string]interfaceschema.FieldType{
+				"host": interfaceschema.String,
+				"port": interfaceschema.Int,
+			},
+			Strict: true,
+		},
+	})
+}
+
+func (s *CharmSuite) TestParseCharmSchemasNone(c *gc.C) {
+	schemas, err := interfaceschema.ParseCharmSchemas([]byte("name: mysql\n"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(schemas, gc.HasLen, 0)
+}
+
+func (s *CharmSuite) TestParseCharmSchemasInvalid(c *gc.C) {
+	meta := `
+interface-schemas:
+  mysql:
+    fields:
+      port: uint
+`
+	_, err := interfaceschema.ParseCharmSchemas([]byte(meta))
+	c.Assert(err, gc.ErrorMatches, `invalid schema for interface "mysql": field "port" has unknown type "uint"`)
+}
+
+func (s *CharmSuite) TestReadCharmSchemas(c *gc.C) {
+	dir := c.MkDir()
+	schemas, err := interfaceschema.ReadCharmSchemas(dir)
+	c.Assert(err, gc.IsNil)
+	c.Assert(schemas, gc.HasLen, 0)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "metadata.yaml"), []byte(mysqlMeta), 0644)
+	c.Assert(err, gc.IsNil)
+	schemas, err = interfaceschema.ReadCharmSchemas(dir)
+	c.Assert(err, gc.IsNil)
+	c.Assert(schemas, gc.HasLen, 1)
+	c.Assert(schemas["mysql"].Fields["port"], gc.Equals, interfaceschema.Int)
+}
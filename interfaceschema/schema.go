@@ -0,0 +1,99 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The interfaceschema package describes the relation settings
+// expected of units related over a given interface, and validates
+// the settings written by units against them.
+package interfaceschema
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FieldType is the type of a relation setting declared by a schema.
+type FieldType string
+
+const (
+	String FieldType = "string"
+	Int    FieldType = "int"
+	Float  FieldType = "float"
+	Bool   FieldType = "bool"
+)
+
+// managedSettings holds the relation settings written by juju itself
+// on behalf of every unit. They are never subject to a schema.
+var managedSettings = map[string]bool{
+	"private-address": true,
+}
+
+// Schema describes the relation settings expected of units taking
+// part in relations over a given interface.
+type Schema struct {
+	// Interface is the name of the relation interface.
+	Interface string
+
+	// Fields maps the name of each known setting to its type.
+	Fields map[string]FieldType
+
+	// Strict causes settings not named in Fields to be rejected.
+	Strict bool
+}
+
+// Validate returns an error if the schema is malformed.
+func (s *Schema) Validate() error {
+	if s.Interface == "" {
+		return fmt.Errorf("empty interface name")
+	}
+	for name, fieldType := range s.Fields {
+		if name == "" || strings.ContainsAny(name, ".$") {
+			return fmt.Errorf("invalid field name %q", name)
+		}
+		switch fieldType {
+		case String, Int, Float, Bool:
+		default:
+			return fmt.Errorf("field %q has unknown type %q", name, fieldType)
+		}
+	}
+	return nil
+}
+
+// ValidateSettings returns an error if any of the given relation
+// settings, as written by relation-set, does not conform to the schema.
+// Settings with empty values are deletions and are always allowed, as
+// are the settings juju itself writes, such as private-address.
+func (s *Schema) ValidateSettings(settings map[string]string) error {
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := settings[key]
+		if value == "" || managedSettings[key] {
+			continue
+		}
+		fieldType, ok := s.Fields[key]
+		if !ok {
+			if s.Strict {
+				return fmt.Errorf("setting %q is not declared by interface %q", key, s.Interface)
+			}
+			continue
+		}
+		var err error
+		switch fieldType {
+		case Int:
+			_, err = strconv.ParseInt(value, 10, 64)
+		case Float:
+			_, err = strconv.ParseFloat(value, 64)
+		case Bool:
+			_, err = strconv.ParseBool(value)
+		}
+		if err != nil {
+			return fmt.Errorf("setting %q of interface %q must be of type %s, got %q", key, s.Interface, fieldType, value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package interfaceschema_test
+
+import (
+	"testing"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/interfaceschema"
+)
+
+func TestPackage(t *testing.T) {
+	gc.TestingT(t)
+}
+
+type SchemaSuite struct{}
+
+var _ = gc.Suite(&SchemaSuite{})
+
+var mysqlSchema = interfaceschema.Schema{
+	Interface: "mysql",
+	Fields: map[string]interfaceschema.FieldType{
+		"host":     interfaceschema.String,
+		"port":     interfaceschema.Int,
+		"ratio":    interfaceschema.Float,
+		"slave":    interfaceschema.Bool,
+		"database": interfaceschema.String,
+	},
+}
+
+func (s *SchemaSuite) TestValidate(c *gc.C) {
+	for i, test := range []struct {
+		schema interfaceschema.Schema
+		err    string
+	}{{
+		schema: mysqlSchema,
+	}, {
+		schema: interfaceschema.Schema{},
+		err:    `empty interface name`,
+	}, {
+		schema: interfaceschema.Schema{
+			Interface: "http",
+			Fields:    map[string]interfaceschema.FieldType{"a.b": interfaceschema.String},
+		},
+		err: `invalid field name "a.b"`,
+	}, {
+		schema: interfaceschema.Schema{
+			Interface: "http",
+			Fields:    map[string]interfaceschema.FieldType{"port": "uint"},
+		},
+		err: `field "port" has unknown type "uint"`,
+	}} {
+		c.Logf("test %d", i)
+		err := test.schema.Validate()
+		if test.err == "" {
+			c.Check(err, gc.IsNil)
+		} else {
+			c.Check(err, gc.ErrorMatches, test.err)
+		}
+	}
+}
+
+func (s *SchemaSuite) TestValidateSettings(c *gc.C) {
+	strict := mysqlSchema
+	strict.Strict = true
+	for i, test := range []struct {
+		schema   interfaceschema.Schema
+		settings map[string]string
+		err      string
+	}{{
+		schema:   mysqlSchema,
+		settings: map[string]string{"host": "10.0.0.1", "port": "3306", "ratio": "0.5", "slave": "true"},
+	}, {
+		schema:   mysqlSchema,
+		settings: map[string]string{"port": ""},
+	}, {
+		schema:   mysqlSchema,
+		settings: map[string]string{"undeclared": "anything"},
+	}, {
+		schema:   strict,
+		settings: map[string]string{"undeclared": "anything"},
+		err:      `setting "undeclared" is not declared by interface "mysql"`,
+	}, {
+		// Settings written by juju itself are always allowed.
+		schema:   strict,
+		settings: map[string]string{"private-address": "10.0.0.2", "port": "3306"},
+	}, {
+		schema:   mysqlSchema,
+		settings: map[string]string{"port": "3306/tcp"},
+		err:      `setting "port" of interface "mysql" must be of type int, got "3306/tcp"`,
+	}, {
+		schema:   mysqlSchema,
+		settings: map[string]string{"ratio": "half"},
+		err:      `setting "ratio" of interface "mysql" must be of type float, got "half"`,
+	}, {
+		schema:   mysqlSchema,
+		settings: map[string]string{"slave": "maybe"},
+		err:      `setting "slave" of interface "mysql" must be of type bool, got "maybe"`,
+	}} {
+		c.Logf("test %d", i)
+		err := test.schema.ValidateSettings(test.settings)
+		if test.err == "" {
+			c.Check(err, gc.IsNil)
+		} else {
+			c.Check(err, gc.ErrorMatches, test.err)
+		}
+	}
+}
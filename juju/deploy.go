@@ -32,6 +32,8 @@ type DeployServiceParams struct {
 	ToMachineSpec string
 	// Networks holds a list of networks to required to start on boot.
 	Networks []string
+	// Deployment, if set, records the provenance of the service.
+	Deployment *state.DeploymentRecord
 }
 
 // DeployService takes a charm and various parameters and deploys it.
@@ -69,12 +71,23 @@ func DeployService(st *state.State, args DeployServiceParams) (*state.Service, e
 			return nil, fmt.Errorf("cannot deploy with networks: not suppored by the environment")
 		}
 	}
-	service, err := st.AddService(
-		args.ServiceName,
-		args.ServiceOwner,
-		args.Charm,
-		args.Networks,
-	)
+	var service *state.Service
+	if args.Deployment != nil {
+		service, err = st.AddDeployedService(
+			args.ServiceName,
+			args.ServiceOwner,
+			args.Charm,
+			args.Networks,
+			*args.Deployment,
+		)
+	} else {
+		service, err = st.AddService(
+			args.ServiceName,
+			args.ServiceOwner,
+			args.Charm,
+			args.Networks,
+		)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -100,7 +113,11 @@ func DeployService(st *state.State, args DeployServiceParams) (*state.Service, e
 }
 
 // AddUnits starts n units of the given service and allocates machines
-// to them as necessary.
+// to them as necessary. If machineIdSpec is not empty, the single unit
+// is placed according to it: it may name an existing machine or
+// container, eg 3/lxc/2, a new container on a machine, eg lxc:3, or
+// hold a placement directive for a new machine that is interpreted
+// by the provider, eg zone=us-east-1a.
 func AddUnits(st *state.State, svc *state.Service, n int, machineIdSpec string) ([]*state.Unit, error) {
 	units := make([]*state.Unit, n)
 	// Hard code for now till we implement a different approach.
@@ -120,52 +137,11 @@ func AddUnits(st *state.State, svc *state.Service, n int, machineIdSpec string)
 			if n != 1 {
 				return nil, fmt.Errorf("cannot add multiple units of service %q to a single machine", svc.Name())
 			}
-			// machineIdSpec may be an existing machine or container, eg 3/lxc/2
-			// or a new container on a machine, eg lxc:3
-			mid := machineIdSpec
-			var containerType instance.ContainerType
-			specParts := strings.SplitN(machineIdSpec, ":", 2)
-			if len(specParts) > 1 {
-				firstPart := specParts[0]
-				var err error
-				if containerType, err = instance.ParseContainerType(firstPart); err == nil {
-					mid = specParts[1]
-				} else {
-					mid = machineIdSpec
-				}
-			}
-			if !names.IsValidMachine(mid) {
-				return nil, fmt.Errorf("invalid force machine id %q", mid)
-			}
-			var unitCons *constraints.Value
-			unitCons, err = unit.Constraints()
+			m, err := placeUnit(st, unit, machineIdSpec, networks)
 			if err != nil {
 				return nil, err
 			}
-
-			var err error
-			var m *state.Machine
-			// If a container is to be used, create it.
-			if containerType != "" {
-				// Create the new machine marked as dirty so that
-				// nothing else will grab it before we assign the unit to it.
-				template := state.MachineTemplate{
-					Series:            unit.Series(),
-					Jobs:              []state.MachineJob{state.JobHostUnits},
-					Dirty:             true,
-					Constraints:       *unitCons,
-					RequestedNetworks: networks,
-				}
-				m, err = st.AddMachineInsideMachine(template, mid, containerType)
-			} else {
-				m, err = st.Machine(mid)
-			}
-			if err != nil {
-				return nil, fmt.Errorf("cannot assign unit %q to machine: %v", unit.Name(), err)
-			}
-			err = unit.AssignToMachine(m)
-
-			if err != nil {
+			if err := unit.AssignToMachine(m); err != nil {
 				return nil, err
 			}
 		} else if err := st.AssignUnit(unit, policy); err != nil {
@@ -175,3 +151,60 @@ func AddUnits(st *state.State, svc *state.Service, n int, machineIdSpec string)
 	}
 	return units, nil
 }
+
+// placeUnit returns the machine that the given unit is to be assigned
+// to according to machineIdSpec, adding a new machine or container if
+// the spec calls for one.
+func placeUnit(st *state.State, unit *state.Unit, machineIdSpec string, networks []string) (*state.Machine, error) {
+	unitCons, err := unit.Constraints()
+	if err != nil {
+		return nil, err
+	}
+	// New machines are marked as dirty so that nothing
+	// else will grab them before the unit is assigned.
+	template := state.MachineTemplate{
+		Series:            unit.Series(),
+		Jobs:              []state.MachineJob{state.JobHostUnits},
+		Dirty:             true,
+		Constraints:       *unitCons,
+		RequestedNetworks: networks,
+	}
+	// A spec without a scope, such as zone=us-east-1a, is a placement
+	// directive for a new machine, interpreted by the provider.
+	if _, err := instance.ParsePlacement(machineIdSpec); err == instance.ErrPlacementScopeMissing {
+		template.Placement = machineIdSpec
+		m, err := st.AddOneMachine(template)
+		if err != nil {
+			return nil, fmt.Errorf("cannot add machine for unit %q: %v", unit.Name(), err)
+		}
+		return m, nil
+	}
+	// machineIdSpec may be an existing machine or container, eg 3/lxc/2
+	// or a new container on a machine, eg lxc:3
+	mid := machineIdSpec
+	var containerType instance.ContainerType
+	specParts := strings.SplitN(machineIdSpec, ":", 2)
+	if len(specParts) > 1 {
+		firstPart := specParts[0]
+		var err error
+		if containerType, err = instance.ParseContainerType(firstPart); err == nil {
+			mid = specParts[1]
+		} else {
+			mid = machineIdSpec
+		}
+	}
+	if !names.IsValidMachine(mid) {
+		return nil, fmt.Errorf("invalid force machine id %q", mid)
+	}
+	var m *state.Machine
+	// If a container is to be used, create it.
+	if containerType != "" {
+		m, err = st.AddMachineInsideMachine(template, mid, containerType)
+	} else {
+		m, err = st.Machine(mid)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot assign unit %q to machine: %v", unit.Name(), err)
+	}
+	return m, nil
+}
@@ -213,6 +213,46 @@ func (s *DeployLocalSuite) TestDeployForceMachineIdWithContainer(c *gc.C) {
 	c.Assert(machineCons, gc.DeepEquals, *unitCons)
 }
 
+func (s *DeployLocalSuite) TestDeployPlacementDirective(c *gc.C) {
+	serviceCons := constraints.MustParse("cpu-cores=2")
+	service, err := juju.DeployService(s.State,
+		juju.DeployServiceParams{
+			ServiceName:   "bob",
+			Charm:         s.charm,
+			Constraints:   serviceCons,
+			NumUnits:      1,
+			ToMachineSpec: "valid",
+		})
+	c.Assert(err, gc.IsNil)
+	units, err := service.AllUnits()
+	c.Assert(err, gc.IsNil)
+	c.Assert(units, gc.HasLen, 1)
+
+	// A new machine is added, to be provisioned according to the
+	// directive, which the dummy provider accepts.
+	id, err := units[0].AssignedMachineId()
+	c.Assert(err, gc.IsNil)
+	machine, err := s.State.Machine(id)
+	c.Assert(err, gc.IsNil)
+	c.Assert(machine.Placement(), gc.Equals, "valid")
+	machineCons, err := machine.Constraints()
+	c.Assert(err, gc.IsNil)
+	unitCons, err := units[0].Constraints()
+	c.Assert(err, gc.IsNil)
+	c.Assert(machineCons, gc.DeepEquals, *unitCons)
+}
+
+func (s *DeployLocalSuite) TestDeployInvalidPlacementDirective(c *gc.C) {
+	_, err := juju.DeployService(s.State,
+		juju.DeployServiceParams{
+			ServiceName:   "bob",
+			Charm:         s.charm,
+			NumUnits:      1,
+			ToMachineSpec: "zone=nowhere",
+		})
+	c.Assert(err, gc.ErrorMatches, `cannot add machine for unit "bob/0": .*zone=nowhere placement is invalid`)
+}
+
 func (s *DeployLocalSuite) assertCharm(c *gc.C, service *state.Service, expect *charm.URL) {
 	curl, force := service.CharmURL()
 	c.Assert(curl, gc.DeepEquals, expect)
@@ -10,3 +10,5 @@ func SetPreferIPv6(value bool) {
 func GetPreferIPv6() bool {
 	return preferIPv6
 }
+
+var NetLookupIP = &netLookupIP
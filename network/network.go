@@ -38,6 +38,46 @@ type BasicInfo struct {
 	VLANTag int
 }
 
+// InterfaceType defines the kind of a network interface.
+type InterfaceType string
+
+const (
+	// EthernetInterface is a plain interface, which may be a VLAN
+	// virtual interface. Interfaces with no type are of this kind.
+	EthernetInterface InterfaceType = "ethernet"
+
+	// BondInterface aggregates its member interfaces into a
+	// single logical link.
+	BondInterface InterfaceType = "bond"
+
+	// BridgeInterface forwards traffic between its member
+	// interfaces.
+	BridgeInterface InterfaceType = "bridge"
+)
+
+// bondModes holds the bonding modes supported by the Linux
+// bonding driver.
+var bondModes = []string{
+	"balance-rr",
+	"active-backup",
+	"balance-xor",
+	"broadcast",
+	"802.3ad",
+	"balance-tlb",
+	"balance-alb",
+}
+
+// IsValidBondMode returns whether mode is a bonding
+// mode supported by the Linux bonding driver.
+func IsValidBondMode(mode string) bool {
+	for _, m := range bondModes {
+		if mode == m {
+			return true
+		}
+	}
+	return false
+}
+
 // Info describes a single network interface available on an instance.
 // For providers that support networks, this will be available at
 // StartInstance() time.
@@ -66,6 +106,20 @@ type Info struct {
 	// Disabled is true when the interface needs to be disabled on the
 	// machine, e.g. not to configure it.
 	Disabled bool
+
+	// InterfaceType is the kind of the interface. It is empty for
+	// plain interfaces.
+	InterfaceType InterfaceType
+
+	// ParentInterfaceName is the name of the bond or bridge the
+	// interface is a member of (e.g. "bond0"), or empty if it is
+	// not a member of any.
+	ParentInterfaceName string
+
+	// BondMode is the bonding mode of a bond interface (e.g.
+	// "active-backup" or "802.3ad"). It is empty for other
+	// interfaces.
+	BondMode string
 }
 
 // ActualInterfaceName returns raw interface name for raw interface (e.g. "eth0") and
@@ -78,9 +132,10 @@ func (i *Info) ActualInterfaceName() string {
 }
 
 // IsVirtual returns true when the interface is a virtual device, as
-// opposed to a physical device (e.g. a VLAN or a network alias)
+// opposed to a physical device (e.g. a VLAN, a network alias, a bond
+// or a bridge)
 func (i *Info) IsVirtual() bool {
-	return i.VLANTag > 0
+	return i.VLANTag > 0 || i.InterfaceType == BondInterface || i.InterfaceType == BridgeInterface
 }
 
 // PreferIPv6Getter will be implemented by both the environment and agent
@@ -22,6 +22,8 @@ func (n *InfoSuite) SetUpTest(c *gc.C) {
 		{VLANTag: 1, InterfaceName: "eth0"},
 		{VLANTag: 0, InterfaceName: "eth1"},
 		{VLANTag: 42, InterfaceName: "br2"},
+		{InterfaceName: "bond0", InterfaceType: network.BondInterface, BondMode: "802.3ad"},
+		{InterfaceName: "br0", InterfaceType: network.BridgeInterface},
 	}
 }
 
@@ -35,6 +37,15 @@ func (n *InfoSuite) TestIsVirtual(c *gc.C) {
 	c.Check(n.info[0].IsVirtual(), jc.IsTrue)
 	c.Check(n.info[1].IsVirtual(), jc.IsFalse)
 	c.Check(n.info[2].IsVirtual(), jc.IsTrue)
+	c.Check(n.info[3].IsVirtual(), jc.IsTrue)
+	c.Check(n.info[4].IsVirtual(), jc.IsTrue)
+}
+
+func (n *InfoSuite) TestIsValidBondMode(c *gc.C) {
+	c.Check(network.IsValidBondMode("active-backup"), jc.IsTrue)
+	c.Check(network.IsValidBondMode("802.3ad"), jc.IsTrue)
+	c.Check(network.IsValidBondMode(""), jc.IsFalse)
+	c.Check(network.IsValidBondMode("round-robin"), jc.IsFalse)
 }
 
 type NetworkSuite struct {
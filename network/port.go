@@ -33,6 +33,43 @@ func (hp HostPort) NetAddr() string {
 	return net.JoinHostPort(hp.Value, strconv.Itoa(hp.Port))
 }
 
+var netLookupIP = net.LookupIP
+
+// ResolveHostPorts returns the given host-ports with each hostname
+// replaced by the addresses it resolves to, both IPv4 (A records) and
+// IPv6 (AAAA records), so that an agent on an IPv6-only network is
+// given an address it can reach. Hostnames which cannot be resolved,
+// and "localhost", are kept unchanged.
+func ResolveHostPorts(hps []HostPort) []HostPort {
+	var resolved []HostPort
+	seen := make(map[HostPort]bool)
+	add := func(hp HostPort) {
+		if !seen[hp] {
+			seen[hp] = true
+			resolved = append(resolved, hp)
+		}
+	}
+	for _, hp := range hps {
+		if hp.Type != HostName || hp.Value == "localhost" {
+			add(hp)
+			continue
+		}
+		ips, err := netLookupIP(hp.Value)
+		if err != nil || len(ips) == 0 {
+			logger.Debugf("cannot resolve %q: %v", hp.Value, err)
+			add(hp)
+			continue
+		}
+		for _, ip := range ips {
+			add(HostPort{
+				Address: NewAddress(ip.String(), hp.Scope),
+				Port:    hp.Port,
+			})
+		}
+	}
+	return resolved
+}
+
 // AddressesWithPort returns the given addresses all
 // associated with the given port.
 func AddressesWithPort(addrs []Address, port int) []HostPort {
@@ -4,6 +4,9 @@
 package network_test
 
 import (
+	"errors"
+	"net"
+
 	jc "github.com/juju/testing/checkers"
 	gc "launchpad.net/gocheck"
 
@@ -143,3 +146,37 @@ func (*PortSuite) TestSortHostPorts(c *gc.C) {
 		1234,
 	))
 }
+
+func (s *PortSuite) TestResolveHostPorts(c *gc.C) {
+	s.PatchValue(network.NetLookupIP, func(host string) ([]net.IP, error) {
+		switch host {
+		case "example.com":
+			return []net.IP{net.ParseIP("2001:db8::1"), net.ParseIP("192.0.2.1")}, nil
+		case "v6only.example.com":
+			return []net.IP{net.ParseIP("2001:db8::2")}, nil
+		}
+		return nil, errors.New("no such host")
+	})
+	hps := network.AddressesWithPort(
+		network.NewAddresses(
+			"localhost",
+			"example.com",
+			"v6only.example.com",
+			"unknown.invalid",
+			"10.0.0.1",
+			"192.0.2.1",
+		),
+		1234,
+	)
+	c.Assert(network.ResolveHostPorts(hps), jc.DeepEquals, network.AddressesWithPort(
+		network.NewAddresses(
+			"localhost",
+			"2001:db8::1",
+			"192.0.2.1",
+			"2001:db8::2",
+			"unknown.invalid",
+			"10.0.0.1",
+		),
+		1234,
+	))
+}
@@ -3,8 +3,11 @@
 
 package all
 
-// Register all the available providers.
+// Register all the available providers, and the storage backends
+// not provided by any of them.
 import (
+	_ "github.com/juju/juju/environs/httpstorage"
+	_ "github.com/juju/juju/environs/webdavstorage"
 	_ "github.com/juju/juju/provider/azure"
 	_ "github.com/juju/juju/provider/ec2"
 	_ "github.com/juju/juju/provider/joyent"
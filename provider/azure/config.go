@@ -180,7 +180,7 @@ func (prov azureEnvironProvider) BoilerplateConfig() string {
 
 // SecretAttrs is specified in the EnvironProvider interface.
 func (prov azureEnvironProvider) SecretAttrs(cfg *config.Config) (map[string]string, error) {
-	secretAttrs := make(map[string]string)
+	secretAttrs := cfg.SecretAttrs()
 	azureCfg, err := prov.newConfig(cfg)
 	if err != nil {
 		return nil, err
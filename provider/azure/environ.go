@@ -98,8 +98,14 @@ func NewEnviron(cfg *config.Config) (*azureEnviron, error) {
 	}
 
 	// Set up storage.
-	env.storage = &azureStorage{
-		storageContext: &environStorageContext{environ: &env},
+	env.storage, err = storage.OpenBackend(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if env.storage == nil {
+		env.storage = &azureStorage{
+			storageContext: &environStorageContext{environ: &env},
+		}
 	}
 	return &env, nil
 }
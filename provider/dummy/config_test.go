@@ -40,6 +40,24 @@ func (*ConfigSuite) TestSecretAttrs(c *gc.C) {
 	c.Assert(actual, gc.DeepEquals, expected)
 }
 
+func (*ConfigSuite) TestSecretAttrsEnvironSecrets(c *gc.C) {
+	attrs := dummy.SampleConfig().Merge(testing.Attrs{
+		"storage-secret-key": "crackling",
+		"webhook-secret":     "stuffing",
+	})
+	cfg, err := config.New(config.NoDefaults, attrs)
+	c.Assert(err, gc.IsNil)
+	provider, err := environs.Provider("dummy")
+	c.Assert(err, gc.IsNil)
+	actual, err := provider.SecretAttrs(cfg)
+	c.Assert(err, gc.IsNil)
+	c.Assert(actual, gc.DeepEquals, map[string]string{
+		"secret":             "pork",
+		"storage-secret-key": "crackling",
+		"webhook-secret":     "stuffing",
+	})
+}
+
 var firewallModeTests = []struct {
 	configFirewallMode string
 	firewallMode       string
@@ -164,17 +164,19 @@ type OpStopInstances struct {
 }
 
 type OpOpenPorts struct {
-	Env        string
-	MachineId  string
-	InstanceId instance.Id
-	Ports      []network.Port
+	Env         string
+	MachineId   string
+	InstanceId  instance.Id
+	NetworkName string
+	Ports       []network.Port
 }
 
 type OpClosePorts struct {
-	Env        string
-	MachineId  string
-	InstanceId instance.Id
-	Ports      []network.Port
+	Env         string
+	MachineId   string
+	InstanceId  instance.Id
+	NetworkName string
+	Ports       []network.Port
 }
 
 type OpPutFile struct {
@@ -209,7 +211,7 @@ type environState struct {
 	maxId        int // maximum instance id allocated so far.
 	maxAddr      int // maximum allocated address last byte
 	insts        map[instance.Id]*dummyInstance
-	globalPorts  map[network.Port]bool
+	globalPorts  map[string]map[network.Port]bool
 	bootstrapped bool
 	storageDelay time.Duration
 	storage      *storageServer
@@ -326,7 +328,7 @@ func newState(name string, ops chan<- Operation, policy state.Policy) *environSt
 		ops:         ops,
 		statePolicy: policy,
 		insts:       make(map[instance.Id]*dummyInstance),
-		globalPorts: make(map[network.Port]bool),
+		globalPorts: make(map[string]map[network.Port]bool),
 	}
 	s.storage = newStorageServer(s, "/"+name+"/private")
 	s.listenStorage()
@@ -546,9 +548,9 @@ func (*environProvider) SecretAttrs(cfg *config.Config) (map[string]string, erro
 	if err != nil {
 		return nil, err
 	}
-	return map[string]string{
-		"secret": ecfg.secret(),
-	}, nil
+	secretAttrs := cfg.SecretAttrs()
+	secretAttrs["secret"] = ecfg.secret()
+	return secretAttrs, nil
 }
 
 func (*environProvider) BoilerplateConfig() string {
@@ -654,7 +656,7 @@ func (e *environ) Bootstrap(ctx environs.BootstrapContext, args environs.Bootstr
 	i := &dummyInstance{
 		id:           BootstrapInstanceId,
 		addresses:    network.NewAddresses("localhost"),
-		ports:        make(map[network.Port]bool),
+		ports:        make(map[string]map[network.Port]bool),
 		machineId:    agent.BootstrapMachineId,
 		series:       series,
 		firewallMode: e.Config().FirewallMode(),
@@ -809,7 +811,7 @@ func (e *environ) StartInstance(args environs.StartInstanceParams) (instance.Ins
 	i := &dummyInstance{
 		id:           instance.Id(idString),
 		addresses:    addrs,
-		ports:        make(map[network.Port]bool),
+		ports:        make(map[string]map[network.Port]bool),
 		machineId:    machineId,
 		series:       series,
 		firewallMode: e.Config().FirewallMode(),
@@ -1015,6 +1017,18 @@ func (e *environ) AllInstances() ([]instance.Instance, error) {
 }
 
 func (e *environ) OpenPorts(ports []network.Port) error {
+	return e.OpenPortsOnNetwork(network.DefaultPublic, ports)
+}
+
+func (e *environ) ClosePorts(ports []network.Port) error {
+	return e.ClosePortsOnNetwork(network.DefaultPublic, ports)
+}
+
+func (e *environ) Ports() ([]network.Port, error) {
+	return e.PortsOnNetwork(network.DefaultPublic)
+}
+
+func (e *environ) OpenPortsOnNetwork(networkName string, ports []network.Port) error {
 	if mode := e.ecfg().FirewallMode(); mode != config.FwGlobal {
 		return fmt.Errorf("invalid firewall mode %q for opening ports on environment", mode)
 	}
@@ -1024,13 +1038,16 @@ func (e *environ) OpenPorts(ports []network.Port) error {
 	}
 	estate.mu.Lock()
 	defer estate.mu.Unlock()
+	if estate.globalPorts[networkName] == nil {
+		estate.globalPorts[networkName] = make(map[network.Port]bool)
+	}
 	for _, p := range ports {
-		estate.globalPorts[p] = true
+		estate.globalPorts[networkName][p] = true
 	}
 	return nil
 }
 
-func (e *environ) ClosePorts(ports []network.Port) error {
+func (e *environ) ClosePortsOnNetwork(networkName string, ports []network.Port) error {
 	if mode := e.ecfg().FirewallMode(); mode != config.FwGlobal {
 		return fmt.Errorf("invalid firewall mode %q for closing ports on environment", mode)
 	}
@@ -1041,12 +1058,12 @@ func (e *environ) ClosePorts(ports []network.Port) error {
 	estate.mu.Lock()
 	defer estate.mu.Unlock()
 	for _, p := range ports {
-		delete(estate.globalPorts, p)
+		delete(estate.globalPorts[networkName], p)
 	}
 	return nil
 }
 
-func (e *environ) Ports() (ports []network.Port, err error) {
+func (e *environ) PortsOnNetwork(networkName string) (ports []network.Port, err error) {
 	if mode := e.ecfg().FirewallMode(); mode != config.FwGlobal {
 		return nil, fmt.Errorf("invalid firewall mode %q for retrieving ports from environment", mode)
 	}
@@ -1056,7 +1073,7 @@ func (e *environ) Ports() (ports []network.Port, err error) {
 	}
 	estate.mu.Lock()
 	defer estate.mu.Unlock()
-	for p := range estate.globalPorts {
+	for p := range estate.globalPorts[networkName] {
 		ports = append(ports, p)
 	}
 	network.SortPorts(ports)
@@ -1069,7 +1086,7 @@ func (*environ) Provider() environs.EnvironProvider {
 
 type dummyInstance struct {
 	state        *environState
-	ports        map[network.Port]bool
+	ports        map[string]map[network.Port]bool
 	id           instance.Id
 	status       string
 	machineId    string
@@ -1118,8 +1135,20 @@ func (inst *dummyInstance) Addresses() ([]network.Address, error) {
 }
 
 func (inst *dummyInstance) OpenPorts(machineId string, ports []network.Port) error {
+	return inst.OpenPortsOnNetwork(machineId, network.DefaultPublic, ports)
+}
+
+func (inst *dummyInstance) ClosePorts(machineId string, ports []network.Port) error {
+	return inst.ClosePortsOnNetwork(machineId, network.DefaultPublic, ports)
+}
+
+func (inst *dummyInstance) Ports(machineId string) ([]network.Port, error) {
+	return inst.PortsOnNetwork(machineId, network.DefaultPublic)
+}
+
+func (inst *dummyInstance) OpenPortsOnNetwork(machineId, networkName string, ports []network.Port) error {
 	defer delay()
-	logger.Infof("openPorts %s, %#v", machineId, ports)
+	logger.Infof("openPorts %s on %s, %#v", machineId, networkName, ports)
 	if inst.firewallMode != config.FwInstance {
 		return fmt.Errorf("invalid firewall mode %q for opening ports on instance",
 			inst.firewallMode)
@@ -1130,18 +1159,22 @@ func (inst *dummyInstance) OpenPorts(machineId string, ports []network.Port) err
 	inst.state.mu.Lock()
 	defer inst.state.mu.Unlock()
 	inst.state.ops <- OpOpenPorts{
-		Env:        inst.state.name,
-		MachineId:  machineId,
-		InstanceId: inst.Id(),
-		Ports:      ports,
+		Env:         inst.state.name,
+		MachineId:   machineId,
+		InstanceId:  inst.Id(),
+		NetworkName: networkName,
+		Ports:       ports,
+	}
+	if inst.ports[networkName] == nil {
+		inst.ports[networkName] = make(map[network.Port]bool)
 	}
 	for _, p := range ports {
-		inst.ports[p] = true
+		inst.ports[networkName][p] = true
 	}
 	return nil
 }
 
-func (inst *dummyInstance) ClosePorts(machineId string, ports []network.Port) error {
+func (inst *dummyInstance) ClosePortsOnNetwork(machineId, networkName string, ports []network.Port) error {
 	defer delay()
 	if inst.firewallMode != config.FwInstance {
 		return fmt.Errorf("invalid firewall mode %q for closing ports on instance",
@@ -1153,18 +1186,19 @@ func (inst *dummyInstance) ClosePorts(machineId string, ports []network.Port) er
 	inst.state.mu.Lock()
 	defer inst.state.mu.Unlock()
 	inst.state.ops <- OpClosePorts{
-		Env:        inst.state.name,
-		MachineId:  machineId,
-		InstanceId: inst.Id(),
-		Ports:      ports,
+		Env:         inst.state.name,
+		MachineId:   machineId,
+		InstanceId:  inst.Id(),
+		NetworkName: networkName,
+		Ports:       ports,
 	}
 	for _, p := range ports {
-		delete(inst.ports, p)
+		delete(inst.ports[networkName], p)
 	}
 	return nil
 }
 
-func (inst *dummyInstance) Ports(machineId string) (ports []network.Port, err error) {
+func (inst *dummyInstance) PortsOnNetwork(machineId, networkName string) (ports []network.Port, err error) {
 	defer delay()
 	if inst.firewallMode != config.FwInstance {
 		return nil, fmt.Errorf("invalid firewall mode %q for retrieving ports from instance",
@@ -1175,7 +1209,7 @@ func (inst *dummyInstance) Ports(machineId string) (ports []network.Port, err er
 	}
 	inst.state.mu.Lock()
 	defer inst.state.mu.Unlock()
-	for p := range inst.ports {
+	for p := range inst.ports[networkName] {
 		ports = append(ports, p)
 	}
 	network.SortPorts(ports)
@@ -195,6 +195,15 @@ amazon:
     #
     # image-stream: "released"
 
+    # image-ids gives the ids of images to use instead of looking
+    # them up in the image metadata, so that private images can be
+    # used without publishing metadata for them. Entries are of the
+    # form [region:]series[/arch[/virt-type]]=image-id; the arch
+    # defaults to amd64 and the virt-type to pv. An image id for a
+    # region takes precedence over one for all regions.
+    #
+    # image-ids: trusty=ami-12345678, us-west-2:trusty/amd64/hvm=ami-87654321
+
 `[1:]
 }
 
@@ -243,7 +252,7 @@ func (p environProvider) MetadataLookupParams(region string) (*simplestreams.Met
 }
 
 func (environProvider) SecretAttrs(cfg *config.Config) (map[string]string, error) {
-	m := make(map[string]string)
+	m := cfg.SecretAttrs()
 	ecfg, err := providerInstance.newConfig(cfg)
 	if err != nil {
 		return nil, err
@@ -262,6 +271,10 @@ func (e *environ) SetConfig(cfg *config.Config) error {
 	if err != nil {
 		return err
 	}
+	stor, err := storage.OpenBackend(cfg)
+	if err != nil {
+		return err
+	}
 	e.ecfgMutex.Lock()
 	defer e.ecfgMutex.Unlock()
 	e.ecfgUnlocked = ecfg
@@ -273,9 +286,12 @@ func (e *environ) SetConfig(cfg *config.Config) error {
 
 	// create new storage instances, existing instances continue
 	// to reference their existing configuration.
-	e.storageUnlocked = &ec2storage{
-		bucket: e.s3Unlocked.Bucket(ecfg.controlBucket()),
+	if stor == nil {
+		stor = &ec2storage{
+			bucket: e.s3Unlocked.Bucket(ecfg.controlBucket()),
+		}
 	}
+	e.storageUnlocked = stor
 	return nil
 }
 
@@ -578,7 +594,7 @@ func (e *environ) StartInstance(args environs.StartInstanceParams) (instance.Ins
 	}
 
 	series := args.Tools.OneSeries()
-	spec, err := findInstanceSpec(sources, e.Config().ImageStream(), &instances.InstanceConstraint{
+	spec, err := findInstanceSpec(sources, e.Config().ImageStream(), e.Config().ImageIds(), &instances.InstanceConstraint{
 		Region:      e.ecfg().region(),
 		Series:      series,
 		Arches:      arches,
@@ -895,7 +911,7 @@ func (e *environ) openPortsInGroup(name string, ports []network.Port) error {
 	_, err = e.ec2().AuthorizeSecurityGroup(g, ipPerms)
 	if err != nil && ec2ErrCode(err) == "InvalidPermission.Duplicate" {
 		if len(ports) == 1 {
-			return nil
+			return e.openPortsToIPv6(g, ipPerms)
 		}
 		// If there's more than one port and we get a duplicate error,
 		// then we go through authorizing each port individually,
@@ -907,11 +923,36 @@ func (e *environ) openPortsInGroup(name string, ports []network.Port) error {
 				return fmt.Errorf("cannot open port %v: %v", ipPerms[i], err)
 			}
 		}
-		return nil
+		return e.openPortsToIPv6(g, ipPerms)
 	}
 	if err != nil {
 		return fmt.Errorf("cannot open ports: %v", err)
 	}
+	return e.openPortsToIPv6(g, ipPerms)
+}
+
+// openPortsToIPv6 grants access to the ports in perms of the given group
+// from any IPv6 address when IPv6 is preferred, so that IPv6-only
+// clients can reach them.
+func (e *environ) openPortsToIPv6(g ec2.SecurityGroup, perms []ec2.IPPerm) error {
+	if !e.Config().PreferIPv6() {
+		return nil
+	}
+	err := authorizeIPv6(e.ec2(), g, perms)
+	if err != nil && ec2ErrCode(err) == "InvalidPermission.Duplicate" {
+		// As for IPv4, the ports which are not yet open must be
+		// authorized one at a time.
+		for i := range perms {
+			err := authorizeIPv6(e.ec2(), g, perms[i:i+1])
+			if err != nil && ec2ErrCode(err) != "InvalidPermission.Duplicate" {
+				return fmt.Errorf("cannot open port %v to IPv6: %v", perms[i], err)
+			}
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot open ports to IPv6: %v", err)
+	}
 	return nil
 }
 
@@ -926,10 +967,17 @@ func (e *environ) closePortsInGroup(name string, ports []network.Port) error {
 	if err != nil {
 		return err
 	}
-	_, err = e.ec2().RevokeSecurityGroup(g, portsToIPPerms(ports))
+	ipPerms := portsToIPPerms(ports)
+	_, err = e.ec2().RevokeSecurityGroup(g, ipPerms)
 	if err != nil {
 		return fmt.Errorf("cannot close ports: %v", err)
 	}
+	if e.Config().PreferIPv6() {
+		err := revokeIPv6(e.ec2(), g, ipPerms)
+		if err != nil && ec2ErrCode(err) != "InvalidPermission.NotFound" {
+			return fmt.Errorf("cannot close ports to IPv6: %v", err)
+		}
+	}
 	return nil
 }
 
@@ -1119,12 +1167,23 @@ func (e *environ) setUpGroups(machineId string, statePort, apiPort int) ([]ec2.S
 	if err != nil {
 		return nil, err
 	}
+	err = e.openPortsToIPv6(jujuGroup, []ec2.IPPerm{
+		{Protocol: "tcp", FromPort: 22, ToPort: 22},
+		{Protocol: "tcp", FromPort: statePort, ToPort: statePort},
+		{Protocol: "tcp", FromPort: apiPort, ToPort: apiPort},
+	})
+	if err != nil {
+		return nil, err
+	}
 	var machineGroup ec2.SecurityGroup
 	switch e.Config().FirewallMode() {
 	case config.FwInstance:
 		machineGroup, err = e.ensureGroup(e.machineGroupName(machineId), nil)
 	case config.FwGlobal:
 		machineGroup, err = e.ensureGroup(e.globalGroupName(), nil)
+	case config.FwNone:
+		// The firewall is managed by the user.
+		return []ec2.SecurityGroup{jujuGroup}, nil
 	}
 	if err != nil {
 		return nil, err
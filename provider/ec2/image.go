@@ -6,6 +6,7 @@ package ec2
 import (
 	"fmt"
 
+	"github.com/juju/juju/environs/config"
 	"github.com/juju/juju/environs/imagemetadata"
 	"github.com/juju/juju/environs/instances"
 	"github.com/juju/juju/environs/simplestreams"
@@ -34,29 +35,49 @@ func filterImages(images []*imagemetadata.ImageMetadata) []*imagemetadata.ImageM
 	return result
 }
 
+// configuredImages returns the images given in the environment
+// configuration for the instance constraint. They are taken to be
+// EBS-backed, and paravirtual unless stated otherwise.
+func configuredImages(imageIds []config.ImageId, ic *instances.InstanceConstraint) []*imagemetadata.ImageMetadata {
+	images := imagemetadata.ConfiguredImages(imageIds, ic.Region, ic.Series, ic.Arches)
+	for _, image := range images {
+		image.Storage = ebsStorage
+		if image.VirtType == "" {
+			image.VirtType = paravirtual
+		}
+	}
+	return images
+}
+
 // findInstanceSpec returns an InstanceSpec satisfying the supplied instanceConstraint.
+// Image ids given in the environment configuration are used in preference to
+// the image metadata.
 func findInstanceSpec(
-	sources []simplestreams.DataSource, stream string, ic *instances.InstanceConstraint) (*instances.InstanceSpec, error) {
+	sources []simplestreams.DataSource, stream string, imageIds []config.ImageId,
+	ic *instances.InstanceConstraint) (*instances.InstanceSpec, error) {
 
 	if ic.Constraints.CpuPower == nil {
 		ic.Constraints.CpuPower = instances.CpuPower(defaultCpuPower)
 	}
-	ec2Region := allRegions[ic.Region]
-	imageConstraint := imagemetadata.NewImageConstraint(simplestreams.LookupParams{
-		CloudSpec: simplestreams.CloudSpec{ic.Region, ec2Region.EC2Endpoint},
-		Series:    []string{ic.Series},
-		Arches:    ic.Arches,
-		Stream:    stream,
-	})
-	matchingImages, _, err := imagemetadata.Fetch(
-		sources, simplestreams.DefaultIndexPath, imageConstraint, signedImageDataOnly)
-	if err != nil {
-		return nil, err
-	}
-	if len(matchingImages) == 0 {
-		logger.Warningf("no matching image meta data for constraints: %v", ic)
+	suitableImages := configuredImages(imageIds, ic)
+	if len(suitableImages) == 0 {
+		ec2Region := allRegions[ic.Region]
+		imageConstraint := imagemetadata.NewImageConstraint(simplestreams.LookupParams{
+			CloudSpec: simplestreams.CloudSpec{ic.Region, ec2Region.EC2Endpoint},
+			Series:    []string{ic.Series},
+			Arches:    ic.Arches,
+			Stream:    stream,
+		})
+		matchingImages, _, err := imagemetadata.Fetch(
+			sources, simplestreams.DefaultIndexPath, imageConstraint, signedImageDataOnly)
+		if err != nil {
+			return nil, err
+		}
+		if len(matchingImages) == 0 {
+			logger.Warningf("no matching image meta data for constraints: %v", ic)
+		}
+		suitableImages = filterImages(matchingImages)
 	}
-	suitableImages := filterImages(matchingImages)
 	images := instances.ImageMetadataToImages(suitableImages)
 
 	// Make a copy of the known EC2 instance types, filling in the cost for the specified region.
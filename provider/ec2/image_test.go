@@ -8,6 +8,7 @@ import (
 	gc "launchpad.net/gocheck"
 
 	"github.com/juju/juju/constraints"
+	"github.com/juju/juju/environs/config"
 	"github.com/juju/juju/environs/imagemetadata"
 	"github.com/juju/juju/environs/instances"
 	"github.com/juju/juju/environs/simplestreams"
@@ -134,6 +135,7 @@ func (s *specSuite) TestFindInstanceSpec(c *gc.C) {
 			[]simplestreams.DataSource{
 				simplestreams.NewURLDataSource("test", "test:", utils.VerifySSLHostnames)},
 			"released",
+			nil,
 			&instances.InstanceConstraint{
 				Region:      "test",
 				Series:      test.series,
@@ -176,6 +178,7 @@ func (s *specSuite) TestFindInstanceSpecErrors(c *gc.C) {
 			[]simplestreams.DataSource{
 				simplestreams.NewURLDataSource("test", "test:", utils.VerifySSLHostnames)},
 			"released",
+			nil,
 			&instances.InstanceConstraint{
 				Region:      "test",
 				Series:      t.series,
@@ -186,6 +189,36 @@ func (s *specSuite) TestFindInstanceSpecErrors(c *gc.C) {
 	}
 }
 
+func (s *specSuite) TestFindInstanceSpecConfiguredImage(c *gc.C) {
+	stor := ebsStorage
+	imageIds := []config.ImageId{
+		{Series: "precise", Arch: "amd64", Id: "ami-00000100"},
+		{Region: "test", Series: "precise", Arch: "amd64", VirtType: "hvm", Id: "ami-00000101"},
+		{Region: "other", Series: "precise", Arch: "amd64", VirtType: "hvm", Id: "ami-00000102"},
+	}
+	// No image metadata sources are given, so the
+	// image must come from the configuration.
+	spec, err := findInstanceSpec(nil, "released", imageIds, &instances.InstanceConstraint{
+		Region:      "test",
+		Series:      "precise",
+		Arches:      both,
+		Constraints: constraints.MustParse("cpu-cores=16"),
+		Storage:     &stor,
+	})
+	c.Assert(err, gc.IsNil)
+	c.Check(spec.Image, gc.Equals, instances.Image{Id: "ami-00000101", Arch: "amd64", VirtType: "hvm"})
+
+	spec, err = findInstanceSpec(nil, "released", imageIds, &instances.InstanceConstraint{
+		Region:      "test",
+		Series:      "precise",
+		Arches:      both,
+		Constraints: constraints.MustParse(""),
+		Storage:     &stor,
+	})
+	c.Assert(err, gc.IsNil)
+	c.Check(spec.Image, gc.Equals, instances.Image{Id: "ami-00000100", Arch: "amd64", VirtType: "pv"})
+}
+
 func (*specSuite) TestFilterImagesAcceptsNil(c *gc.C) {
 	c.Check(filterImages(nil), gc.HasLen, 0)
 }
@@ -0,0 +1,121 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package ec2
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"launchpad.net/goamz/ec2"
+)
+
+// The goamz revision in use predates IPv6 security group rules, which
+// are given as Ipv6Ranges rather than IpRanges and need a later API
+// version. Rules allowing access from any IPv6 address are therefore
+// made with requests of our own, signed as goamz signs its requests.
+
+// ipv6APIVersion holds the first EC2 API version supporting IPv6
+// security group rules.
+const ipv6APIVersion = "2016-11-15"
+
+// ipv6AnyCIDR holds the range of all IPv6 addresses.
+const ipv6AnyCIDR = "::/0"
+
+// authorizeIPv6 grants access to the ports in perms of the given group
+// from any IPv6 address. The source ranges of perms are ignored.
+func authorizeIPv6(e *ec2.EC2, g ec2.SecurityGroup, perms []ec2.IPPerm) error {
+	return ipv6PermsRequest(e, "AuthorizeSecurityGroupIngress", g, perms)
+}
+
+// revokeIPv6 revokes access to the ports in perms of the given group
+// from any IPv6 address. The source ranges of perms are ignored.
+func revokeIPv6(e *ec2.EC2, g ec2.SecurityGroup, perms []ec2.IPPerm) error {
+	return ipv6PermsRequest(e, "RevokeSecurityGroupIngress", g, perms)
+}
+
+func ipv6PermsRequest(e *ec2.EC2, action string, g ec2.SecurityGroup, perms []ec2.IPPerm) error {
+	params := map[string]string{
+		"Action":  action,
+		"Version": ipv6APIVersion,
+		"GroupId": g.Id,
+	}
+	for i, p := range perms {
+		prefix := fmt.Sprintf("IpPermissions.%d.", i+1)
+		params[prefix+"IpProtocol"] = p.Protocol
+		params[prefix+"FromPort"] = strconv.Itoa(p.FromPort)
+		params[prefix+"ToPort"] = strconv.Itoa(p.ToPort)
+		params[prefix+"Ipv6Ranges.1.CidrIpv6"] = ipv6AnyCIDR
+	}
+	endpoint, err := url.Parse(e.Region.EC2Endpoint)
+	if err != nil {
+		return err
+	}
+	if endpoint.Path == "" {
+		endpoint.Path = "/"
+	}
+	params["Timestamp"] = time.Now().In(time.UTC).Format(time.RFC3339)
+	endpoint.RawQuery = signIPv6Request(e, endpoint.Host, endpoint.Path, params)
+	resp, err := http.Get(endpoint.String())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	var errResp struct {
+		Errors []ec2.Error `xml:"Errors>Error"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&errResp); err != nil || len(errResp.Errors) == 0 {
+		return fmt.Errorf("cannot %s: %s", action, resp.Status)
+	}
+	ec2err := errResp.Errors[0]
+	ec2err.StatusCode = resp.StatusCode
+	return &ec2err
+}
+
+// signIPv6Request adds the credentials and signature (version 2) of
+// a GET request with the given parameters to the given host and path,
+// and returns the parameters as a query string.
+func signIPv6Request(e *ec2.EC2, host, path string, params map[string]string) string {
+	params["AWSAccessKeyId"] = e.Auth.AccessKey
+	params["SignatureVersion"] = "2"
+	params["SignatureMethod"] = "HmacSHA256"
+	query := make([]string, 0, len(params))
+	for key, value := range params {
+		query = append(query, awsEncode(key)+"="+awsEncode(value))
+	}
+	sort.Strings(query)
+	joined := strings.Join(query, "&")
+	mac := hmac.New(sha256.New, []byte(e.Auth.SecretKey))
+	mac.Write([]byte("GET\n" + host + "\n" + path + "\n" + joined))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return joined + "&Signature=" + awsEncode(signature)
+}
+
+// awsEncode escapes s as AWS expects in signed requests: all bytes
+// other than the unreserved characters of RFC 3986 are escaped.
+func awsEncode(s string) string {
+	var buf []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			buf = append(buf, c)
+		default:
+			buf = append(buf, fmt.Sprintf("%%%02X", c)...)
+		}
+	}
+	return string(buf)
+}
@@ -0,0 +1,135 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package ec2
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+
+	"launchpad.net/goamz/aws"
+	amzec2 "launchpad.net/goamz/ec2"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/testing"
+)
+
+type ipv6Suite struct {
+	testing.BaseSuite
+	server   *httptest.Server
+	requests []*http.Request
+	status   int
+	body     string
+}
+
+var _ = gc.Suite(&ipv6Suite{})
+
+func (s *ipv6Suite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	s.requests = nil
+	s.status = http.StatusOK
+	s.body = "<AuthorizeSecurityGroupIngressResponse/>"
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		s.requests = append(s.requests, req)
+		w.WriteHeader(s.status)
+		fmt.Fprint(w, s.body)
+	}))
+}
+
+func (s *ipv6Suite) TearDownTest(c *gc.C) {
+	s.server.Close()
+	s.BaseSuite.TearDownTest(c)
+}
+
+func (s *ipv6Suite) ec2() *amzec2.EC2 {
+	return amzec2.New(
+		aws.Auth{AccessKey: "access", SecretKey: "secret"},
+		aws.Region{EC2Endpoint: s.server.URL},
+	)
+}
+
+func (s *ipv6Suite) TestAuthorizeIPv6(c *gc.C) {
+	err := authorizeIPv6(s.ec2(), amzec2.SecurityGroup{Id: "sg-1"}, []amzec2.IPPerm{
+		{Protocol: "tcp", FromPort: 80, ToPort: 80, SourceIPs: []string{"0.0.0.0/0"}},
+		{Protocol: "udp", FromPort: 53, ToPort: 54},
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.requests, gc.HasLen, 1)
+	query := s.requests[0].URL.Query()
+	for key, value := range map[string]string{
+		"Action":                                "AuthorizeSecurityGroupIngress",
+		"Version":                               ipv6APIVersion,
+		"GroupId":                               "sg-1",
+		"IpPermissions.1.IpProtocol":            "tcp",
+		"IpPermissions.1.FromPort":              "80",
+		"IpPermissions.1.ToPort":                "80",
+		"IpPermissions.1.Ipv6Ranges.1.CidrIpv6": "::/0",
+		"IpPermissions.2.IpProtocol":            "udp",
+		"IpPermissions.2.FromPort":              "53",
+		"IpPermissions.2.ToPort":                "54",
+		"IpPermissions.2.Ipv6Ranges.1.CidrIpv6": "::/0",
+		"AWSAccessKeyId":                        "access",
+		"SignatureVersion":                      "2",
+		"SignatureMethod":                       "HmacSHA256",
+	} {
+		c.Check(query.Get(key), gc.Equals, value, gc.Commentf("parameter %s", key))
+	}
+	// The IPv4 source range is not used.
+	c.Check(query.Get("IpPermissions.1.IpRanges.1.CidrIp"), gc.Equals, "")
+	c.Check(query.Get("Signature"), gc.Equals, expectedSignature(s.server.URL, query))
+}
+
+func (s *ipv6Suite) TestRevokeIPv6(c *gc.C) {
+	err := revokeIPv6(s.ec2(), amzec2.SecurityGroup{Id: "sg-1"}, []amzec2.IPPerm{
+		{Protocol: "tcp", FromPort: 80, ToPort: 80},
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.requests, gc.HasLen, 1)
+	query := s.requests[0].URL.Query()
+	c.Check(query.Get("Action"), gc.Equals, "RevokeSecurityGroupIngress")
+	c.Check(query.Get("IpPermissions.1.Ipv6Ranges.1.CidrIpv6"), gc.Equals, "::/0")
+}
+
+func (s *ipv6Suite) TestIPv6Error(c *gc.C) {
+	s.status = http.StatusBadRequest
+	s.body = `<Response><Errors><Error><Code>InvalidPermission.Duplicate</Code>` +
+		`<Message>the rule already exists</Message></Error></Errors></Response>`
+	err := authorizeIPv6(s.ec2(), amzec2.SecurityGroup{Id: "sg-1"}, []amzec2.IPPerm{
+		{Protocol: "tcp", FromPort: 80, ToPort: 80},
+	})
+	c.Assert(err, gc.ErrorMatches, "the rule already exists.*")
+	c.Assert(ec2ErrCode(err), gc.Equals, "InvalidPermission.Duplicate")
+}
+
+func (s *ipv6Suite) TestIPv6ErrorWithoutBody(c *gc.C) {
+	s.status = http.StatusInternalServerError
+	s.body = ""
+	err := authorizeIPv6(s.ec2(), amzec2.SecurityGroup{Id: "sg-1"}, nil)
+	c.Assert(err, gc.ErrorMatches, "cannot AuthorizeSecurityGroupIngress: 500 Internal Server Error")
+}
+
+// expectedSignature returns the version 2 signature of a GET request
+// with the given query to the server with the given URL.
+func expectedSignature(serverURL string, query url.Values) string {
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		panic(err)
+	}
+	var params []string
+	for key, values := range query {
+		if key != "Signature" {
+			params = append(params, awsEncode(key)+"="+awsEncode(values[0]))
+		}
+	}
+	sort.Strings(params)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("GET\n" + u.Host + "\n/\n" + strings.Join(params, "&")))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
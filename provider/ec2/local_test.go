@@ -703,6 +703,25 @@ func (t *localServerSuite) TestSupportNetworks(c *gc.C) {
 	c.Assert(env.SupportNetworks(), jc.IsFalse)
 }
 
+func (t *localServerSuite) TestStorageBackend(c *gc.C) {
+	env := t.Prepare(c)
+	attrs := env.Config().AllAttrs()
+	attrs["storage-backend"] = "s3"
+	attrs["storage-url"] = t.srv.s3srv.URL()
+	attrs["storage-container"] = "juju-backend"
+	cfg, err := config.New(config.NoDefaults, attrs)
+	c.Assert(err, gc.IsNil)
+	env, err = environs.New(cfg)
+	c.Assert(err, gc.IsNil)
+
+	err = env.Storage().Put("foo", strings.NewReader("bar"), 3)
+	c.Assert(err, gc.IsNil)
+	bucket := s3.New(aws.Auth{}, aws.Region{S3Endpoint: t.srv.s3srv.URL()}).Bucket("juju-backend")
+	data, err := bucket.Get("foo")
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(data), gc.Equals, "bar")
+}
+
 // localNonUSEastSuite is similar to localServerSuite but the S3 mock server
 // behaves as if it is not in the us-east region.
 type localNonUSEastSuite struct {
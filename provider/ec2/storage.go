@@ -12,8 +12,10 @@ import (
 
 	"github.com/juju/errors"
 	"github.com/juju/utils"
+	"launchpad.net/goamz/aws"
 	"launchpad.net/goamz/s3"
 
+	"github.com/juju/juju/environs/config"
 	"github.com/juju/juju/environs/storage"
 )
 
@@ -22,12 +24,30 @@ func init() {
 	// Sometimes it is expected a file may not exist and we don't want s3
 	// to hold things up by unilaterally deciding to retry for no good reason.
 	s3.RetryAttempts(false)
+
+	storage.RegisterBackend("s3", s3Backend{})
 }
 
 func NewStorage(bucket *s3.Bucket) storage.Storage {
 	return &ec2storage{bucket: bucket}
 }
 
+// s3Backend opens storage in a bucket of any S3-compatible blob
+// store, named by storage-container, at the storage-url endpoint.
+type s3Backend struct{}
+
+func (s3Backend) OpenStorage(cfg *config.Config) (storage.Storage, error) {
+	if cfg.StorageContainer() == "" {
+		return nil, fmt.Errorf("storage-container must name a bucket")
+	}
+	auth := aws.Auth{
+		AccessKey: cfg.StorageAccessKey(),
+		SecretKey: cfg.StorageSecretKey(),
+	}
+	region := aws.Region{S3Endpoint: cfg.StorageURL()}
+	return NewStorage(s3.New(auth, region).Bucket(cfg.StorageContainer())), nil
+}
+
 // ec2storage implements storage.Storage on
 // an ec2.bucket.
 type ec2storage struct {
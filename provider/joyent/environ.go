@@ -52,10 +52,16 @@ func newEnviron(cfg *config.Config) (*joyentEnviron, error) {
 	}
 	env.name = cfg.Name()
 	var err error
-	env.storage, err = newStorage(env.ecfg, "")
+	env.storage, err = storage.OpenBackend(cfg)
 	if err != nil {
 		return nil, err
 	}
+	if env.storage == nil {
+		env.storage, err = newStorage(env.ecfg, "")
+		if err != nil {
+			return nil, err
+		}
+	}
 	env.compute, err = newCompute(env.ecfg)
 	if err != nil {
 		return nil, err
@@ -78,7 +78,7 @@ func (joyentProvider) SecretAttrs(cfg *config.Config) (map[string]string, error)
 	if err != nil {
 		return nil, err
 	}
-	secretAttrs := map[string]string{}
+	secretAttrs := cfg.SecretAttrs()
 	for _, field := range configSecretFields {
 		if value, ok := ecfg.attrs[field]; ok {
 			if stringValue, ok := value.(string); ok {
@@ -266,8 +266,8 @@ local:
 
 // SecretAttrs implements environs.EnvironProvider.SecretAttrs.
 func (environProvider) SecretAttrs(cfg *config.Config) (map[string]string, error) {
-	// don't have any secret attrs
-	return nil, nil
+	// The local provider has no secret attrs of its own.
+	return cfg.SecretAttrs(), nil
 }
 
 func (p environProvider) newConfig(cfg *config.Config) (*environConfig, error) {
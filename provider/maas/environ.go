@@ -79,7 +79,14 @@ func NewEnviron(cfg *config.Config) (*maasEnviron, error) {
 		return nil, err
 	}
 	env.name = cfg.Name()
-	env.storageUnlocked = NewStorage(env)
+	stor, err := storage.OpenBackend(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if stor == nil {
+		stor = NewStorage(env)
+	}
+	env.storageUnlocked = stor
 	return env, nil
 }
 
@@ -379,7 +386,8 @@ func (environ *maasEnviron) setupNetworks(inst instance.Instance, networksToEnab
 	logger.Debugf("node %q has networks %v", inst.Id(), networks)
 	var tempNetworkInfo []network.Info
 	for _, netw := range networks {
-		disabled := !networksToEnable.Contains(netw.Name)
+		netName := jujuNetworkName(netw.Name)
+		disabled := !networksToEnable.Contains(netName)
 		netCIDR := &net.IPNet{
 			IP:   net.ParseIP(netw.IP),
 			Mask: net.IPMask(net.ParseIP(netw.Mask)),
@@ -397,7 +405,7 @@ func (environ *maasEnviron) setupNetworks(inst instance.Instance, networksToEnab
 					CIDR:          netCIDR.String(),
 					VLANTag:       netw.VLANTag,
 					ProviderId:    network.Id(netw.Name),
-					NetworkName:   netw.Name,
+					NetworkName:   netName,
 					Disabled:      disabled,
 				})
 			}
@@ -421,6 +429,33 @@ func (environ *maasEnviron) setupNetworks(inst instance.Instance, networksToEnab
 	return networkInfo, nil
 }
 
+// maasNetworks returns the MAAS names of the given juju networks to
+// include and exclude when acquiring a node. Excluded networks unknown
+// to MAAS are dropped, as no node can be on them anyway.
+func (environ *maasEnviron) maasNetworks(include, exclude []string) ([]string, []string, error) {
+	if len(include) == 0 && len(exclude) == 0 {
+		return nil, nil, nil
+	}
+	maasNames, err := environ.maasNetworkNames()
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot get networks: %v", err)
+	}
+	var maasInclude, maasExclude []string
+	for _, name := range include {
+		maasName, ok := maasNames[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown network %q", name)
+		}
+		maasInclude = append(maasInclude, maasName)
+	}
+	for _, name := range exclude {
+		if maasName, ok := maasNames[name]; ok {
+			maasExclude = append(maasExclude, maasName)
+		}
+	}
+	return maasInclude, maasExclude, nil
+}
+
 // StartInstance is specified in the InstanceBroker interface.
 func (environ *maasEnviron) StartInstance(args environs.StartInstanceParams) (
 	instance.Instance, *instance.HardwareCharacteristics, []network.Info, error,
@@ -429,8 +464,13 @@ func (environ *maasEnviron) StartInstance(args environs.StartInstanceParams) (
 	var err error
 	nodeName := args.Placement
 	requestedNetworks := args.MachineConfig.Networks
-	includeNetworks := append(args.Constraints.IncludeNetworks(), requestedNetworks...)
-	excludeNetworks := args.Constraints.ExcludeNetworks()
+	includeNetworks, excludeNetworks, err := environ.maasNetworks(
+		append(args.Constraints.IncludeNetworks(), requestedNetworks...),
+		args.Constraints.ExcludeNetworks(),
+	)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 	node, tools, err := environ.acquireNode(
 		nodeName,
 		args.Constraints,
@@ -608,9 +648,24 @@ func (*maasEnviron) AllocateAddress(_ instance.Id, _ network.Id) (network.Addres
 // ListNetworks returns basic information about all networks known
 // by the provider for the environment. They may be unknown to juju
 // yet (i.e. when called initially or when a new network was created).
-// This is not implemented by the MAAS provider yet.
-func (*maasEnviron) ListNetworks() ([]network.BasicInfo, error) {
-	return nil, errors.NotImplementedf("ListNetworks")
+func (environ *maasEnviron) ListNetworks() ([]network.BasicInfo, error) {
+	networks, err := environ.getNetworks(nil)
+	if err != nil {
+		return nil, err
+	}
+	info := make([]network.BasicInfo, len(networks))
+	for i, netw := range networks {
+		netCIDR := &net.IPNet{
+			IP:   net.ParseIP(netw.IP),
+			Mask: net.IPMask(net.ParseIP(netw.Mask)),
+		}
+		info[i] = network.BasicInfo{
+			CIDR:       netCIDR.String(),
+			ProviderId: network.Id(netw.Name),
+			VLANTag:    netw.VLANTag,
+		}
+	}
+	return info, nil
 }
 
 // AllInstances returns all the instance.Instance in this provider.
@@ -676,12 +731,17 @@ type networkDetails struct {
 func (environ *maasEnviron) getInstanceNetworks(inst instance.Instance) ([]networkDetails, error) {
 	maasInst := inst.(*maasInstance)
 	maasObj := maasInst.maasObject
-	client := environ.getMAASClient().GetSubObject("networks")
 	nodeId, err := maasObj.GetField("system_id")
 	if err != nil {
 		return nil, err
 	}
-	params := url.Values{"node": {nodeId}}
+	return environ.getNetworks(url.Values{"node": {nodeId}})
+}
+
+// getNetworks returns a list of the MAAS networks matching the given
+// parameters, or of all of them if there are none.
+func (environ *maasEnviron) getNetworks(params url.Values) ([]networkDetails, error) {
+	client := environ.getMAASClient().GetSubObject("networks")
 	json, err := client.CallGet("", params)
 	if err != nil {
 		return nil, err
@@ -735,6 +795,27 @@ func (environ *maasEnviron) getInstanceNetworks(inst instance.Instance) ([]netwo
 	return networks, nil
 }
 
+// jujuNetworkName returns the name juju knows the given MAAS network
+// by. MAAS network names may have upper case letters and underscores,
+// which juju network names cannot.
+func jujuNetworkName(maasName string) string {
+	return strings.Replace(strings.ToLower(maasName), "_", "-", -1)
+}
+
+// maasNetworkNames returns a map from the juju names of the MAAS
+// networks to their MAAS names.
+func (environ *maasEnviron) maasNetworkNames() (map[string]string, error) {
+	networks, err := environ.getNetworks(nil)
+	if err != nil {
+		return nil, err
+	}
+	maasNames := make(map[string]string)
+	for _, netw := range networks {
+		maasNames[jujuNetworkName(netw.Name)] = netw.Name
+	}
+	return maasNames, nil
+}
+
 // getNetworkMACs returns all MAC addresses connected to the given
 // network.
 func (environ *maasEnviron) getNetworkMACs(networkName string) ([]string, error) {
@@ -735,7 +735,7 @@ func (suite *environSuite) TestSetupNetworks(c *gc.C) {
 	suite.testMAASObject.TestServer.ConnectNodeToNetworkWithMACAddress("node1", "Virt", "aa:bb:cc:dd:ee:f2")
 	suite.getNetwork("WLAN", 1, 0)
 	suite.testMAASObject.TestServer.ConnectNodeToNetworkWithMACAddress("node1", "WLAN", "aa:bb:cc:dd:ee:ff")
-	networkInfo, err := suite.makeEnviron().setupNetworks(test_instance, set.NewStrings("LAN", "Virt"))
+	networkInfo, err := suite.makeEnviron().setupNetworks(test_instance, set.NewStrings("lan", "virt"))
 	c.Assert(err, gc.IsNil)
 
 	// Note: order of networks is based on lshwXML
@@ -743,7 +743,7 @@ func (suite *environSuite) TestSetupNetworks(c *gc.C) {
 		network.Info{
 			MACAddress:    "aa:bb:cc:dd:ee:ff",
 			CIDR:          "192.168.1.1/24",
-			NetworkName:   "WLAN",
+			NetworkName:   "wlan",
 			ProviderId:    "WLAN",
 			VLANTag:       0,
 			InterfaceName: "wlan0",
@@ -752,7 +752,7 @@ func (suite *environSuite) TestSetupNetworks(c *gc.C) {
 		network.Info{
 			MACAddress:    "aa:bb:cc:dd:ee:f1",
 			CIDR:          "192.168.2.1/24",
-			NetworkName:   "LAN",
+			NetworkName:   "lan",
 			ProviderId:    "LAN",
 			VLANTag:       42,
 			InterfaceName: "eth0",
@@ -761,7 +761,7 @@ func (suite *environSuite) TestSetupNetworks(c *gc.C) {
 		network.Info{
 			MACAddress:    "aa:bb:cc:dd:ee:f2",
 			CIDR:          "192.168.3.1/24",
-			NetworkName:   "Virt",
+			NetworkName:   "virt",
 			ProviderId:    "Virt",
 			VLANTag:       0,
 			InterfaceName: "vnet1",
@@ -786,7 +786,7 @@ func (suite *environSuite) TestSetupNetworksPartialMatch(c *gc.C) {
 	suite.testMAASObject.TestServer.ConnectNodeToNetworkWithMACAddress("node1", "LAN", "aa:bb:cc:dd:ee:f1")
 	suite.getNetwork("Virt", 3, 0)
 	suite.testMAASObject.TestServer.ConnectNodeToNetworkWithMACAddress("node1", "Virt", "aa:bb:cc:dd:ee:f3")
-	networkInfo, err := suite.makeEnviron().setupNetworks(test_instance, set.NewStrings("LAN"))
+	networkInfo, err := suite.makeEnviron().setupNetworks(test_instance, set.NewStrings("lan"))
 	c.Assert(err, gc.IsNil)
 
 	// Note: order of networks is based on lshwXML
@@ -794,7 +794,7 @@ func (suite *environSuite) TestSetupNetworksPartialMatch(c *gc.C) {
 		network.Info{
 			MACAddress:    "aa:bb:cc:dd:ee:f1",
 			CIDR:          "192.168.2.1/24",
-			NetworkName:   "LAN",
+			NetworkName:   "lan",
 			ProviderId:    "LAN",
 			VLANTag:       42,
 			InterfaceName: "eth0",
@@ -817,13 +817,49 @@ func (suite *environSuite) TestSetupNetworksNoMatch(c *gc.C) {
 	suite.testMAASObject.TestServer.AddNodeDetails("node1", lshwXML)
 	suite.getNetwork("Virt", 3, 0)
 	suite.testMAASObject.TestServer.ConnectNodeToNetworkWithMACAddress("node1", "Virt", "aa:bb:cc:dd:ee:f3")
-	networkInfo, err := suite.makeEnviron().setupNetworks(test_instance, set.NewStrings("Virt"))
+	networkInfo, err := suite.makeEnviron().setupNetworks(test_instance, set.NewStrings("virt"))
 	c.Assert(err, gc.IsNil)
 
 	// Note: order of networks is based on lshwXML
 	c.Check(networkInfo, gc.HasLen, 0)
 }
 
+func (*environSuite) TestJujuNetworkName(c *gc.C) {
+	c.Check(jujuNetworkName("lan"), gc.Equals, "lan")
+	c.Check(jujuNetworkName("LAN"), gc.Equals, "lan")
+	c.Check(jujuNetworkName("Virt_Net_2"), gc.Equals, "virt-net-2")
+}
+
+func (suite *environSuite) TestMAASNetworks(c *gc.C) {
+	suite.getNetwork("LAN", 2, 42)
+	suite.getNetwork("Virt_Net", 3, 0)
+	env := suite.makeEnviron()
+
+	include, exclude, err := env.maasNetworks([]string{"lan"}, []string{"virt-net", "dmz"})
+	c.Assert(err, gc.IsNil)
+	c.Check(include, gc.DeepEquals, []string{"LAN"})
+	c.Check(exclude, gc.DeepEquals, []string{"Virt_Net"})
+
+	_, _, err = env.maasNetworks([]string{"lan", "dmz"}, nil)
+	c.Assert(err, gc.ErrorMatches, `unknown network "dmz"`)
+
+	include, exclude, err = env.maasNetworks(nil, nil)
+	c.Assert(err, gc.IsNil)
+	c.Check(include, gc.HasLen, 0)
+	c.Check(exclude, gc.HasLen, 0)
+}
+
+func (suite *environSuite) TestListNetworks(c *gc.C) {
+	suite.getNetwork("LAN", 2, 42)
+	suite.getNetwork("WLAN", 1, 0)
+	networks, err := suite.makeEnviron().ListNetworks()
+	c.Assert(err, gc.IsNil)
+	c.Check(networks, jc.SameContents, []network.BasicInfo{
+		{CIDR: "192.168.2.1/24", ProviderId: "LAN", VLANTag: 42},
+		{CIDR: "192.168.1.1/24", ProviderId: "WLAN", VLANTag: 0},
+	})
+}
+
 func (suite *environSuite) TestSupportNetworks(c *gc.C) {
 	env := suite.makeEnviron()
 	c.Assert(env.SupportNetworks(), jc.IsTrue)
@@ -87,7 +87,7 @@ func (maasEnvironProvider) BoilerplateConfig() string {
 
 // SecretAttrs is specified in the EnvironProvider interface.
 func (prov maasEnvironProvider) SecretAttrs(cfg *config.Config) (map[string]string, error) {
-	secretAttrs := make(map[string]string)
+	secretAttrs := cfg.SecretAttrs()
 	maasCfg, err := prov.newConfig(cfg)
 	if err != nil {
 		return nil, err
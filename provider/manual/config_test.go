@@ -124,6 +124,16 @@ func (s *configSuite) TestStorageParams(c *gc.C) {
 	c.Assert(testConfig.storageListenAddr(), gc.Equals, "10.0.0.123:1234")
 }
 
+func (s *configSuite) TestStorageParamsIPv6(c *gc.C) {
+	values := MinimalConfigValues()
+	values["bootstrap-host"] = "2001:db8::1"
+	values["storage-listen-ip"] = "::"
+	values["storage-port"] = 1234
+	testConfig := getEnvironConfig(c, values)
+	c.Assert(testConfig.storageAddr(), gc.Equals, "[2001:db8::1]:1234")
+	c.Assert(testConfig.storageListenAddr(), gc.Equals, "[::]:1234")
+}
+
 func (s *configSuite) TestStorageCompat(c *gc.C) {
 	// Older environment configurations will not have the
 	// use-sshstorage attribute. We treat them as if they
@@ -156,22 +156,26 @@ func (e *manualEnviron) SetConfig(cfg *config.Config) error {
 	if err != nil {
 		return err
 	}
-	// Set storage. If "use-sshstorage" is true then use the SSH storage.
-	// Otherwise, use HTTP storage.
+	// Set storage. If a storage backend is configured, use it.
+	// Otherwise, if "use-sshstorage" is true then use the SSH storage,
+	// and if not, use HTTP storage.
 	//
 	// We don't change storage once it's been set. Storage parameters
 	// are fixed at bootstrap time, and it is not possible to change
 	// them.
 	if e.storage == nil {
-		var stor storage.Storage
-		if envConfig.useSSHStorage() {
+		stor, err := storage.OpenBackend(cfg)
+		if err != nil {
+			return err
+		}
+		if stor == nil && envConfig.useSSHStorage() {
 			storageDir := e.StorageDir()
 			storageTmpdir := path.Join(agent.DefaultDataDir, storageTmpSubdir)
 			stor, err = newSSHStorage("ubuntu@"+e.cfg.bootstrapHost(), storageDir, storageTmpdir)
 			if err != nil {
 				return fmt.Errorf("initialising SSH storage failed: %v", err)
 			}
-		} else {
+		} else if stor == nil {
 			caCertPEM, ok := envConfig.CACert()
 			if !ok {
 				// should not be possible to validate base config
@@ -6,12 +6,14 @@ package manual
 import (
 	"errors"
 	"fmt"
+	"os"
 
 	"github.com/juju/utils"
 
 	"github.com/juju/juju/environs"
 	"github.com/juju/juju/environs/config"
 	"github.com/juju/juju/environs/manual"
+	"github.com/juju/juju/juju/osenv"
 )
 
 type manualProvider struct{}
@@ -26,7 +28,8 @@ var errNoBootstrapHost = errors.New("bootstrap-host must be specified")
 var initUbuntuUser = manual.InitUbuntuUser
 
 func ensureBootstrapUbuntuUser(ctx environs.BootstrapContext, cfg *environConfig) error {
-	err := initUbuntuUser(cfg.bootstrapHost(), cfg.bootstrapUser(), cfg.AuthorizedKeys(), ctx.GetStdin(), ctx.GetStdout())
+	sudoPassword := os.Getenv(osenv.JujuSudoPasswordEnvKey)
+	err := initUbuntuUser(cfg.bootstrapHost(), cfg.bootstrapUser(), cfg.AuthorizedKeys(), sudoPassword, ctx.GetStdin(), ctx.GetStdout())
 	if err != nil {
 		logger.Errorf("initializing ubuntu user: %v", err)
 		return err
@@ -143,7 +146,10 @@ manual:
     
     # bootstrap-user specifies the user to authenticate as when
     # connecting to the bootstrap machine. It defaults to
-    # the current user.
+    # the current user. The user need not be root, but must be
+    # able to use sudo; if sudo requires a password, it is prompted
+    # for, unless it is given in the JUJU_SUDO_PASSWORD environment
+    # variable.
     # bootstrap-user: joebloggs
     
     # storage-listen-ip specifies the IP address that the
@@ -166,7 +172,7 @@ func (p manualProvider) SecretAttrs(cfg *config.Config) (map[string]string, erro
 	if err != nil {
 		return nil, err
 	}
-	attrs := make(map[string]string)
+	attrs := cfg.SecretAttrs()
 	attrs["storage-auth-key"] = envConfig.storageAuthKey()
 	return attrs, nil
 }
@@ -32,20 +32,25 @@ func findInstanceSpec(e *environ, ic *instances.InstanceConstraint) (*instances.
 		allInstanceTypes = append(allInstanceTypes, instanceType)
 	}
 
-	imageConstraint := imagemetadata.NewImageConstraint(simplestreams.LookupParams{
-		CloudSpec: simplestreams.CloudSpec{ic.Region, e.ecfg().authURL()},
-		Series:    []string{ic.Series},
-		Arches:    ic.Arches,
-		Stream:    e.Config().ImageStream(),
-	})
-	sources, err := imagemetadata.GetMetadataSources(e)
-	if err != nil {
-		return nil, err
-	}
-	// TODO (wallyworld): use an env parameter (default true) to mandate use of only signed image metadata.
-	matchingImages, _, err := imagemetadata.Fetch(sources, simplestreams.DefaultIndexPath, imageConstraint, false)
-	if err != nil {
-		return nil, err
+	// Image ids given in the environment configuration are used
+	// in preference to the image metadata.
+	matchingImages := imagemetadata.ConfiguredImages(e.Config().ImageIds(), ic.Region, ic.Series, ic.Arches)
+	if len(matchingImages) == 0 {
+		imageConstraint := imagemetadata.NewImageConstraint(simplestreams.LookupParams{
+			CloudSpec: simplestreams.CloudSpec{ic.Region, e.ecfg().authURL()},
+			Series:    []string{ic.Series},
+			Arches:    ic.Arches,
+			Stream:    e.Config().ImageStream(),
+		})
+		sources, err := imagemetadata.GetMetadataSources(e)
+		if err != nil {
+			return nil, err
+		}
+		// TODO (wallyworld): use an env parameter (default true) to mandate use of only signed image metadata.
+		matchingImages, _, err = imagemetadata.Fetch(sources, simplestreams.DefaultIndexPath, imageConstraint, false)
+		if err != nil {
+			return nil, err
+		}
 	}
 	images := instances.ImageMetadataToImages(matchingImages)
 	spec, err := instances.FindInstanceSpec(images, ic, allInstanceTypes)
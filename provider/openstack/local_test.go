@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"sort"
 	"strings"
 
 	jujuerrors "github.com/juju/errors"
@@ -35,6 +36,7 @@ import (
 	"github.com/juju/juju/instance"
 	"github.com/juju/juju/juju/arch"
 	"github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/network"
 	"github.com/juju/juju/provider/common"
 	"github.com/juju/juju/provider/openstack"
 	coretesting "github.com/juju/juju/testing"
@@ -885,6 +887,92 @@ func (s *localServerSuite) TestEnsureGroup(c *gc.C) {
 	assertRule(group)
 }
 
+// groupRules returns the rules of the named security group as sorted
+// "protocol from to cidr" strings.
+func groupRules(c *gc.C, env environs.Environ, name string) []string {
+	group, err := openstack.GetNovaClient(env).SecurityGroupByName(name)
+	c.Assert(err, gc.IsNil)
+	var rules []string
+	for _, rule := range group.Rules {
+		rules = append(rules, fmt.Sprintf("%s %d %d %q",
+			*rule.IPProtocol, *rule.FromPort, *rule.ToPort, rule.IPRange["cidr"]))
+	}
+	sort.Strings(rules)
+	return rules
+}
+
+func (s *localServerSuite) TestSetUpGlobalGroupPreferIPv6(c *gc.C) {
+	cfg, err := config.New(config.NoDefaults, s.TestConfig.Merge(coretesting.Attrs{
+		"prefer-ipv6": true}))
+	c.Assert(err, gc.IsNil)
+	env, err := environs.New(cfg)
+	c.Assert(err, gc.IsNil)
+	_, err = openstack.SetUpGlobalGroup(env, "global-group", 37017, 17070)
+	c.Assert(err, gc.IsNil)
+	c.Assert(groupRules(c, env, "global-group"), jc.SameContents, []string{
+		`icmp -1 -1 ""`,
+		`tcp 1 65535 ""`,
+		`tcp 17070 17070 "0.0.0.0/0"`,
+		`tcp 17070 17070 "::/0"`,
+		`tcp 22 22 "0.0.0.0/0"`,
+		`tcp 22 22 "::/0"`,
+		`tcp 37017 37017 "0.0.0.0/0"`,
+		`tcp 37017 37017 "::/0"`,
+		`udp 1 65535 ""`,
+	})
+}
+
+func (s *localServerSuite) TestSetUpGlobalGroupIPv4Only(c *gc.C) {
+	env := s.Prepare(c)
+	_, err := openstack.SetUpGlobalGroup(env, "global-group", 37017, 17070)
+	c.Assert(err, gc.IsNil)
+	c.Assert(groupRules(c, env, "global-group"), jc.SameContents, []string{
+		`icmp -1 -1 ""`,
+		`tcp 1 65535 ""`,
+		`tcp 17070 17070 "0.0.0.0/0"`,
+		`tcp 22 22 "0.0.0.0/0"`,
+		`tcp 37017 37017 "0.0.0.0/0"`,
+		`udp 1 65535 ""`,
+	})
+}
+
+func (s *localServerSuite) TestOpenClosePortsPreferIPv6(c *gc.C) {
+	cfg, err := config.New(config.NoDefaults, s.TestConfig.Merge(coretesting.Attrs{
+		"firewall-mode": "global",
+		"prefer-ipv6":   true,
+	}))
+	c.Assert(err, gc.IsNil)
+	env, err := environs.New(cfg)
+	c.Assert(err, gc.IsNil)
+	testing.AssertStartInstance(c, env, "100")
+	groupName := fmt.Sprintf("juju-%v-global", env.Config().Name())
+
+	err = env.OpenPorts([]network.Port{{"tcp", 80}, {"udp", 53}})
+	c.Assert(err, gc.IsNil)
+	c.Assert(groupRules(c, env, groupName), jc.SameContents, []string{
+		`tcp 80 80 "0.0.0.0/0"`,
+		`tcp 80 80 "::/0"`,
+		`udp 53 53 "0.0.0.0/0"`,
+		`udp 53 53 "::/0"`,
+	})
+	// Each port is reported once, although it has a rule for each
+	// source range.
+	ports, err := env.Ports()
+	c.Assert(err, gc.IsNil)
+	c.Assert(ports, jc.DeepEquals, []network.Port{{"tcp", 80}, {"udp", 53}})
+
+	// Closing a port removes the rules for both source ranges.
+	err = env.ClosePorts([]network.Port{{"tcp", 80}})
+	c.Assert(err, gc.IsNil)
+	c.Assert(groupRules(c, env, groupName), jc.SameContents, []string{
+		`udp 53 53 "0.0.0.0/0"`,
+		`udp 53 53 "::/0"`,
+	})
+	ports, err = env.Ports()
+	c.Assert(err, gc.IsNil)
+	c.Assert(ports, jc.DeepEquals, []network.Port{{"udp", 53}})
+}
+
 // localHTTPSServerSuite contains tests that run against an Openstack service
 // double connected on an HTTPS port with a self-signed certificate. This
 // service is set up and torn down for every test.  This should only test
@@ -0,0 +1,286 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package openstack
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	jujuerrors "github.com/juju/errors"
+	"github.com/juju/names"
+	"launchpad.net/goose/client"
+	gooseerrors "launchpad.net/goose/errors"
+	goosehttp "launchpad.net/goose/http"
+
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/network"
+)
+
+// goose has no Neutron client, so the few Neutron calls needed to
+// start instances on networks are made here.
+
+const neutronServiceType = "network"
+
+type neutronNetwork struct {
+	Id      string   `json:"id"`
+	Name    string   `json:"name"`
+	Subnets []string `json:"subnets"`
+}
+
+type neutronSubnet struct {
+	Id        string `json:"id"`
+	NetworkId string `json:"network_id"`
+	CIDR      string `json:"cidr"`
+	IPVersion int    `json:"ip_version"`
+}
+
+type neutronFixedIP struct {
+	SubnetId  string `json:"subnet_id"`
+	IPAddress string `json:"ip_address"`
+}
+
+type neutronPort struct {
+	Id         string           `json:"id"`
+	Name       string           `json:"name"`
+	NetworkId  string           `json:"network_id"`
+	MACAddress string           `json:"mac_address"`
+	FixedIPs   []neutronFixedIP `json:"fixed_ips"`
+	DeviceId   string           `json:"device_id"`
+}
+
+// neutronClient makes calls to the Neutron API.
+type neutronClient struct {
+	client client.Client
+}
+
+func (c *neutronClient) listNetworks() ([]neutronNetwork, error) {
+	var resp struct {
+		Networks []neutronNetwork `json:"networks"`
+	}
+	requestData := goosehttp.RequestData{RespValue: &resp}
+	if err := c.client.SendRequest(client.GET, neutronServiceType, "v2.0/networks", &requestData); err != nil {
+		return nil, gooseerrors.Newf(err, "failed to get list of networks")
+	}
+	return resp.Networks, nil
+}
+
+func (c *neutronClient) listSubnets() ([]neutronSubnet, error) {
+	var resp struct {
+		Subnets []neutronSubnet `json:"subnets"`
+	}
+	requestData := goosehttp.RequestData{RespValue: &resp}
+	if err := c.client.SendRequest(client.GET, neutronServiceType, "v2.0/subnets", &requestData); err != nil {
+		return nil, gooseerrors.Newf(err, "failed to get list of subnets")
+	}
+	return resp.Subnets, nil
+}
+
+// listPorts returns the ports attached to the given device.
+func (c *neutronClient) listPorts(deviceId string) ([]neutronPort, error) {
+	var resp struct {
+		Ports []neutronPort `json:"ports"`
+	}
+	params := &url.Values{"device_id": {deviceId}}
+	requestData := goosehttp.RequestData{RespValue: &resp, Params: params}
+	if err := c.client.SendRequest(client.GET, neutronServiceType, "v2.0/ports", &requestData); err != nil {
+		return nil, gooseerrors.Newf(err, "failed to get list of ports for %q", deviceId)
+	}
+	return resp.Ports, nil
+}
+
+// createPort creates a port on the given network, in the given
+// security groups.
+func (c *neutronClient) createPort(name, networkId string, groupIds []string) (*neutronPort, error) {
+	var req struct {
+		Port struct {
+			Name           string   `json:"name"`
+			NetworkId      string   `json:"network_id"`
+			SecurityGroups []string `json:"security_groups"`
+		} `json:"port"`
+	}
+	req.Port.Name = name
+	req.Port.NetworkId = networkId
+	req.Port.SecurityGroups = groupIds
+	var resp struct {
+		Port neutronPort `json:"port"`
+	}
+	requestData := goosehttp.RequestData{ReqValue: req, RespValue: &resp, ExpectedStatus: []int{http.StatusCreated}}
+	if err := c.client.SendRequest(client.POST, neutronServiceType, "v2.0/ports", &requestData); err != nil {
+		return nil, gooseerrors.Newf(err, "failed to create port on network %q", networkId)
+	}
+	return &resp.Port, nil
+}
+
+func (c *neutronClient) deletePort(id string) error {
+	requestData := goosehttp.RequestData{ExpectedStatus: []int{http.StatusNoContent}}
+	if err := c.client.SendRequest(client.DELETE, neutronServiceType, "v2.0/ports/"+id, &requestData); err != nil {
+		return gooseerrors.Newf(err, "failed to delete port %q", id)
+	}
+	return nil
+}
+
+// neutron returns a Neutron client, or an error satisfying
+// errors.IsNotSupported if the cloud has no Neutron endpoint.
+func (e *environ) neutron() (*neutronClient, error) {
+	if !e.client.IsAuthenticated() {
+		if err := e.client.Authenticate(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := e.client.MakeServiceURL(neutronServiceType, nil); err != nil {
+		return nil, jujuerrors.NotSupportedf("networks without Neutron")
+	}
+	return &neutronClient{e.client}, nil
+}
+
+// instanceNetwork is a Neutron network an instance is started on,
+// along with the juju name of the network.
+type instanceNetwork struct {
+	name string
+	neutronNetwork
+}
+
+// jujuNetworkName returns the name juju knows the network by: its
+// Neutron name if that is a valid juju network name, or its id.
+func jujuNetworkName(net neutronNetwork) string {
+	if names.IsValidNetwork(net.Name) {
+		return net.Name
+	}
+	return net.Id
+}
+
+// findNetwork returns the network with the given name or id.
+func findNetwork(networks []neutronNetwork, nameOrId string) (neutronNetwork, error) {
+	var found []neutronNetwork
+	for _, net := range networks {
+		if net.Id == nameOrId {
+			return net, nil
+		}
+		if net.Name == nameOrId {
+			found = append(found, net)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return neutronNetwork{}, fmt.Errorf("network %q not found", nameOrId)
+	case 1:
+		return found[0], nil
+	}
+	return neutronNetwork{}, fmt.Errorf("multiple networks named %q", nameOrId)
+}
+
+// selectNetworks returns the networks to start an instance on: the
+// configured network, if any, followed by the included ones. None of
+// them may be excluded.
+func selectNetworks(networks []neutronNetwork, configured string, include, exclude []string) ([]instanceNetwork, error) {
+	var selected []instanceNetwork
+	add := func(name string, net neutronNetwork) {
+		for _, inst := range selected {
+			if inst.Id == net.Id {
+				return
+			}
+		}
+		selected = append(selected, instanceNetwork{name, net})
+	}
+	if configured != "" {
+		net, err := findNetwork(networks, configured)
+		if err != nil {
+			return nil, err
+		}
+		add(jujuNetworkName(net), net)
+	}
+	for _, name := range include {
+		net, err := findNetwork(networks, name)
+		if err != nil {
+			return nil, err
+		}
+		add(name, net)
+	}
+	for _, name := range exclude {
+		for _, inst := range selected {
+			if name == inst.name || name == inst.Name || name == inst.Id {
+				return nil, fmt.Errorf("network %q is both requested and excluded", name)
+			}
+		}
+	}
+	return selected, nil
+}
+
+// portNetworkInfo returns the network information of the given ports,
+// created on the given networks and attached in turn to an instance.
+func portNetworkInfo(networks []instanceNetwork, ports []neutronPort, subnets []neutronSubnet) []network.Info {
+	cidrs := make(map[string]string)
+	for _, subnet := range subnets {
+		cidrs[subnet.Id] = subnet.CIDR
+	}
+	info := make([]network.Info, len(ports))
+	for i, port := range ports {
+		var cidr string
+		if len(port.FixedIPs) > 0 {
+			cidr = cidrs[port.FixedIPs[0].SubnetId]
+		}
+		info[i] = network.Info{
+			MACAddress:    port.MACAddress,
+			CIDR:          cidr,
+			NetworkName:   networks[i].name,
+			ProviderId:    network.Id(networks[i].Id),
+			InterfaceName: fmt.Sprintf("eth%d", i),
+		}
+	}
+	return info
+}
+
+// portNamePrefix returns the prefix of the names of the ports created
+// for the instances of the environment.
+func (e *environ) portNamePrefix() string {
+	return fmt.Sprintf("juju-%s-", e.Config().Name())
+}
+
+// createPorts creates a port on each of the given networks for the
+// machine to be started, in the given security groups. On failure,
+// the ports already created are deleted.
+func (e *environ) createPorts(neutron *neutronClient, machineId string, networks []instanceNetwork, groupIds []string) ([]neutronPort, error) {
+	var ports []neutronPort
+	for _, net := range networks {
+		port, err := neutron.createPort(e.machineFullName(machineId), net.Id, groupIds)
+		if err != nil {
+			e.deletePorts(neutron, ports)
+			return nil, err
+		}
+		logger.Debugf("created port %q on network %q", port.Id, net.Id)
+		ports = append(ports, *port)
+	}
+	return ports, nil
+}
+
+// deletePorts deletes the given ports, only logging failures.
+func (e *environ) deletePorts(neutron *neutronClient, ports []neutronPort) {
+	for _, port := range ports {
+		if err := neutron.deletePort(port.Id); err != nil && !gooseerrors.IsNotFound(err) {
+			logger.Warningf("cannot delete port %q: %v", port.Id, err)
+		}
+	}
+}
+
+// instancePorts returns the ports juju created for the given instances,
+// so they can be deleted along with them: unlike the ports created by
+// Nova, they outlive the instances.
+func (e *environ) instancePorts(neutron *neutronClient, ids []instance.Id) []neutronPort {
+	var ports []neutronPort
+	for _, id := range ids {
+		instPorts, err := neutron.listPorts(string(id))
+		if err != nil {
+			logger.Warningf("cannot list ports of instance %q: %v", id, err)
+			continue
+		}
+		for _, port := range instPorts {
+			if strings.HasPrefix(port.Name, e.portNamePrefix()) {
+				ports = append(ports, port)
+			}
+		}
+	}
+	return ports
+}
@@ -0,0 +1,105 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package openstack
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/network"
+)
+
+type neutronSuite struct{}
+
+var _ = gc.Suite(&neutronSuite{})
+
+var testNetworks = []neutronNetwork{
+	{Id: "a1b2c3d4-0001", Name: "private"},
+	{Id: "a1b2c3d4-0002", Name: "Public Net"},
+	{Id: "a1b2c3d4-0003", Name: "db"},
+	{Id: "a1b2c3d4-0004", Name: "db"},
+}
+
+func (*neutronSuite) TestJujuNetworkName(c *gc.C) {
+	c.Assert(jujuNetworkName(testNetworks[0]), gc.Equals, "private")
+	c.Assert(jujuNetworkName(testNetworks[1]), gc.Equals, "a1b2c3d4-0002")
+}
+
+func (*neutronSuite) TestSelectNetworks(c *gc.C) {
+	for i, test := range []struct {
+		configured       string
+		include, exclude []string
+		expect           []string
+		err              string
+	}{{
+		configured: "private",
+		expect:     []string{"private/a1b2c3d4-0001"},
+	}, {
+		configured: "Public Net",
+		include:    []string{"private", "a1b2c3d4-0003"},
+		expect:     []string{"a1b2c3d4-0002/a1b2c3d4-0002", "private/a1b2c3d4-0001", "a1b2c3d4-0003/a1b2c3d4-0003"},
+	}, {
+		configured: "private",
+		include:    []string{"private", "a1b2c3d4-0001"},
+		expect:     []string{"private/a1b2c3d4-0001"},
+	}, {
+		include: []string{"db"},
+		err:     `multiple networks named "db"`,
+	}, {
+		include: []string{"dmz"},
+		err:     `network "dmz" not found`,
+	}, {
+		configured: "private",
+		exclude:    []string{"private"},
+		err:        `network "private" is both requested and excluded`,
+	}, {
+		configured: "private",
+		include:    []string{"a1b2c3d4-0003"},
+		exclude:    []string{"a1b2c3d4-0004"},
+		expect:     []string{"private/a1b2c3d4-0001", "a1b2c3d4-0003/a1b2c3d4-0003"},
+	}} {
+		c.Logf("test %d", i)
+		selected, err := selectNetworks(testNetworks, test.configured, test.include, test.exclude)
+		if test.err != "" {
+			c.Check(err, gc.ErrorMatches, test.err)
+			continue
+		}
+		c.Assert(err, gc.IsNil)
+		var got []string
+		for _, net := range selected {
+			got = append(got, net.name+"/"+net.Id)
+		}
+		c.Check(got, gc.DeepEquals, test.expect)
+	}
+}
+
+func (*neutronSuite) TestPortNetworkInfo(c *gc.C) {
+	networks := []instanceNetwork{
+		{"private", testNetworks[0]},
+		{"db", testNetworks[2]},
+	}
+	ports := []neutronPort{{
+		Id:         "port-0",
+		MACAddress: "fa:16:3e:00:00:01",
+		FixedIPs:   []neutronFixedIP{{SubnetId: "subnet-0", IPAddress: "10.0.0.5"}},
+	}, {
+		Id:         "port-1",
+		MACAddress: "fa:16:3e:00:00:02",
+	}}
+	subnets := []neutronSubnet{
+		{Id: "subnet-0", NetworkId: "a1b2c3d4-0001", CIDR: "10.0.0.0/24"},
+	}
+	c.Assert(portNetworkInfo(networks, ports, subnets), jc.DeepEquals, []network.Info{{
+		MACAddress:    "fa:16:3e:00:00:01",
+		CIDR:          "10.0.0.0/24",
+		NetworkName:   "private",
+		ProviderId:    "a1b2c3d4-0001",
+		InterfaceName: "eth0",
+	}, {
+		MACAddress:    "fa:16:3e:00:00:02",
+		NetworkName:   "db",
+		ProviderId:    "a1b2c3d4-0003",
+		InterfaceName: "eth1",
+	}})
+}
@@ -82,7 +82,8 @@ openstack:
 
     # network specifies the network label or uuid to bring machines up
     # on, in the case where multiple networks exist. It may be omitted
-    # otherwise.
+    # otherwise. On clouds with Neutron, machines are also started on
+    # the networks requested when deploying, given by name or uuid.
     #
     # network: <your network label or uuid>
 
@@ -104,6 +105,15 @@ openstack:
     #
     # image-stream: "released"
 
+    # image-ids gives the ids of images to use instead of looking
+    # them up in the image metadata, so that private images can be
+    # used without publishing metadata for them. Entries are of the
+    # form [region:]series[/arch]=image-id; the arch defaults to
+    # amd64. An image id for a region takes precedence over one for
+    # all regions.
+    #
+    # image-ids: trusty=<image id>, RegionOne:precise/i386=<image id>
+
     # auth-url defaults to the value of the environment variable
     # OS_AUTH_URL, but can be specified here.
     #
@@ -168,6 +178,15 @@ hpcloud:
     #
     # image-stream: "released"
 
+    # image-ids gives the ids of images to use instead of looking
+    # them up in the image metadata, so that private images can be
+    # used without publishing metadata for them. Entries are of the
+    # form [region:]series[/arch]=image-id; the arch defaults to
+    # amd64. An image id for a region takes precedence over one for
+    # all regions.
+    #
+    # image-ids: trusty=<image id>, RegionOne:precise/i386=<image id>
+
     # auth-url holds the keystone url for authentication. It defaults
     # to the value of the environment variable OS_AUTH_URL.
     #
@@ -239,7 +258,7 @@ func (p environProvider) MetadataLookupParams(region string) (*simplestreams.Met
 }
 
 func (p environProvider) SecretAttrs(cfg *config.Config) (map[string]string, error) {
-	m := make(map[string]string)
+	m := cfg.SecretAttrs()
 	ecfg, err := providerInstance.newConfig(cfg)
 	if err != nil {
 		return nil, err
@@ -500,9 +519,9 @@ func (e *environ) SupportedArchitectures() ([]string, error) {
 
 // SupportNetworks is specified on the EnvironCapability interface.
 func (e *environ) SupportNetworks() bool {
-	// TODO(dimitern) Once we have support for networking, inquire
-	// about capabilities and return true if supported.
-	return false
+	// Networks can only be requested through Neutron.
+	_, err := e.neutron()
+	return err == nil
 }
 
 var unsupportedConstraints = []string{
@@ -696,6 +715,10 @@ func (e *environ) SetConfig(cfg *config.Config) error {
 	if err != nil {
 		return err
 	}
+	stor, err := storage.OpenBackend(cfg)
+	if err != nil {
+		return err
+	}
 	// At this point, the authentication method config value has been validated so we extract it's value here
 	// to avoid having to validate again each time when creating the OpenStack client.
 	var authModeCfg AuthMode
@@ -711,12 +734,15 @@ func (e *environ) SetConfig(cfg *config.Config) error {
 	// to reference their existing configuration.
 	// public storage instance creation is deferred until needed since authenticated
 	// access to the identity service is required so that any juju-tools endpoint can be used.
-	e.storageUnlocked = &openstackstorage{
-		containerName: ecfg.controlBucket(),
-		// this is possibly just a hack - if the ACL is swift.Private,
-		// the machine won't be able to get the tools (401 error)
-		containerACL: swift.PublicRead,
-		swift:        swift.New(e.client)}
+	if stor == nil {
+		stor = &openstackstorage{
+			containerName: ecfg.controlBucket(),
+			// this is possibly just a hack - if the ACL is swift.Private,
+			// the machine won't be able to get the tools (401 error)
+			containerACL: swift.PublicRead,
+			swift:        swift.New(e.client)}
+	}
+	e.storageUnlocked = stor
 	return nil
 }
 
@@ -907,8 +933,32 @@ func (e *environ) StartInstance(args environs.StartInstanceParams) (instance.Ins
 		}
 	}
 
-	if args.MachineConfig.HasNetworks() {
-		return nil, nil, nil, fmt.Errorf("starting instances with networks is not supported yet.")
+	// Instances are started on requested networks through ports
+	// created beforehand, which tell the interfaces of the instance.
+	var neutron *neutronClient
+	var instNetworks []instanceNetwork
+	var subnets []neutronSubnet
+	usingNetwork := e.ecfg().network()
+	include := append(append([]string(nil), args.MachineConfig.Networks...), args.Constraints.IncludeNetworks()...)
+	exclude := args.Constraints.ExcludeNetworks()
+	if len(include) > 0 || len(exclude) > 0 {
+		if len(include) == 0 && usingNetwork == "" {
+			return nil, nil, nil, fmt.Errorf("cannot exclude networks without a configured or requested network")
+		}
+		var err error
+		if neutron, err = e.neutron(); err != nil {
+			return nil, nil, nil, fmt.Errorf("cannot start instance on networks: %v", err)
+		}
+		available, err := neutron.listNetworks()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if instNetworks, err = selectNetworks(available, usingNetwork, include, exclude); err != nil {
+			return nil, nil, nil, err
+		}
+		if subnets, err = neutron.listSubnets(); err != nil {
+			return nil, nil, nil, err
+		}
 	}
 
 	series := args.Tools.OneSeries()
@@ -938,8 +988,7 @@ func (e *environ) StartInstance(args environs.StartInstanceParams) (instance.Ins
 	}
 	logger.Debugf("openstack user data; %d bytes", len(userData))
 	var networks = []nova.ServerNetworks{}
-	usingNetwork := e.ecfg().network()
-	if usingNetwork != "" {
+	if usingNetwork != "" && instNetworks == nil {
 		networkId, err := e.resolveNetwork(usingNetwork)
 		if err != nil {
 			return nil, nil, nil, err
@@ -964,8 +1013,21 @@ func (e *environ) StartInstance(args environs.StartInstanceParams) (instance.Ins
 		return nil, nil, nil, fmt.Errorf("cannot set up groups: %v", err)
 	}
 	var groupNames = make([]nova.SecurityGroupName, len(groups))
+	var groupIds = make([]string, len(groups))
 	for i, g := range groups {
 		groupNames[i] = nova.SecurityGroupName{g.Name}
+		groupIds[i] = g.Id
+	}
+	var ports []neutronPort
+	if instNetworks != nil {
+		// Nova does not apply security groups to given ports.
+		ports, err = e.createPorts(neutron, args.MachineConfig.MachineId, instNetworks, groupIds)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("cannot create ports: %v", err)
+		}
+		for _, port := range ports {
+			networks = append(networks, nova.ServerNetworks{PortId: port.Id})
+		}
 	}
 	var opts = nova.RunServerOpts{
 		Name:               e.machineFullName(args.MachineConfig.MachineId),
@@ -984,6 +1046,9 @@ func (e *environ) StartInstance(args environs.StartInstanceParams) (instance.Ins
 		}
 	}
 	if err != nil {
+		if ports != nil {
+			e.deletePorts(neutron, ports)
+		}
 		return nil, nil, nil, fmt.Errorf("cannot run instance: %v", err)
 	}
 	detail, err := e.nova().GetServer(server.Id)
@@ -1007,7 +1072,11 @@ func (e *environ) StartInstance(args environs.StartInstanceParams) (instance.Ins
 		}
 		logger.Infof("assigned public IP %s to %q", publicIP.IP, inst.Id())
 	}
-	return inst, inst.hardwareCharacteristics(), nil, nil
+	var networkInfo []network.Info
+	if ports != nil {
+		networkInfo = portNetworkInfo(instNetworks, ports, subnets)
+	}
+	return inst, inst.hardwareCharacteristics(), networkInfo, nil
 }
 
 func (e *environ) StopInstances(ids ...instance.Id) error {
@@ -1120,9 +1189,35 @@ func (*environ) AllocateAddress(_ instance.Id, _ network.Id) (network.Address, e
 // ListNetworks returns basic information about all networks known
 // by the provider for the environment. They may be unknown to juju
 // yet (i.e. when called initially or when a new network was created).
-// This is not implemented by the OpenStack provider yet.
-func (*environ) ListNetworks() ([]network.BasicInfo, error) {
-	return nil, jujuerrors.NotImplementedf("ListNetworks")
+// Networks are only known on clouds with Neutron.
+func (e *environ) ListNetworks() ([]network.BasicInfo, error) {
+	neutron, err := e.neutron()
+	if err != nil {
+		return nil, err
+	}
+	networks, err := neutron.listNetworks()
+	if err != nil {
+		return nil, err
+	}
+	subnets, err := neutron.listSubnets()
+	if err != nil {
+		return nil, err
+	}
+	cidrs := make(map[string]string)
+	for _, subnet := range subnets {
+		// Prefer the IPv4 subnet of networks having several.
+		if _, ok := cidrs[subnet.NetworkId]; !ok || subnet.IPVersion == 4 {
+			cidrs[subnet.NetworkId] = subnet.CIDR
+		}
+	}
+	info := make([]network.BasicInfo, len(networks))
+	for i, net := range networks {
+		info[i] = network.BasicInfo{
+			CIDR:       cidrs[net.Id],
+			ProviderId: network.Id(net.Id),
+		}
+	}
+	return info, nil
 }
 
 func (e *environ) AllInstances() (insts []instance.Instance, err error) {
@@ -1199,21 +1294,33 @@ func (e *environ) openPortsInGroup(name string, ports []network.Port) error {
 		return err
 	}
 	for _, port := range ports {
-		_, err := novaclient.CreateSecurityGroupRule(nova.RuleInfo{
-			ParentGroupId: group.Id,
-			FromPort:      port.Number,
-			ToPort:        port.Number,
-			IPProtocol:    port.Protocol,
-			Cidr:          "0.0.0.0/0",
-		})
-		if err != nil {
-			// TODO: if err is not rule already exists, raise?
-			logger.Debugf("error creating security group rule: %v", err.Error())
+		for _, cidr := range e.sourceCIDRs() {
+			_, err := novaclient.CreateSecurityGroupRule(nova.RuleInfo{
+				ParentGroupId: group.Id,
+				FromPort:      port.Number,
+				ToPort:        port.Number,
+				IPProtocol:    port.Protocol,
+				Cidr:          cidr,
+			})
+			if err != nil {
+				// TODO: if err is not rule already exists, raise?
+				logger.Debugf("error creating security group rule: %v", err.Error())
+			}
 		}
 	}
 	return nil
 }
 
+// sourceCIDRs returns the CIDRs from which opened ports are
+// accessible. When IPv6 is preferred, rules are also created for
+// the IPv6 "any" range, so IPv6-only clients can reach the ports.
+func (e *environ) sourceCIDRs() []string {
+	if e.Config().PreferIPv6() {
+		return []string{"0.0.0.0/0", "::/0"}
+	}
+	return []string{"0.0.0.0/0"}
+}
+
 func (e *environ) closePortsInGroup(name string, ports []network.Port) error {
 	if len(ports) == 0 {
 		return nil
@@ -1231,11 +1338,12 @@ func (e *environ) closePortsInGroup(name string, ports []network.Port) error {
 				p.ToPort == nil || *p.ToPort != port.Number {
 				continue
 			}
+			// There may be one rule per source CIDR (e.g. both
+			// IPv4 and IPv6), so remove all matching rules.
 			err := novaclient.DeleteSecurityGroupRule(p.Id)
 			if err != nil {
 				return err
 			}
-			break
 		}
 	}
 	return nil
@@ -1246,12 +1354,19 @@ func (e *environ) portsInGroup(name string) (ports []network.Port, err error) {
 	if err != nil {
 		return nil, err
 	}
+	seen := make(map[network.Port]bool)
 	for _, p := range (*group).Rules {
 		for i := *p.FromPort; i <= *p.ToPort; i++ {
-			ports = append(ports, network.Port{
+			port := network.Port{
 				Protocol: *p.IPProtocol,
 				Number:   i,
-			})
+			}
+			// The same port may be opened for several source CIDRs.
+			if seen[port] {
+				continue
+			}
+			seen[port] = true
+			ports = append(ports, port)
 		}
 	}
 	network.SortPorts(ports)
@@ -1297,26 +1412,19 @@ func (e *environ) Provider() environs.EnvironProvider {
 }
 
 func (e *environ) setUpGlobalGroup(groupName string, statePort, apiPort int) (nova.SecurityGroup, error) {
-	return e.ensureGroup(groupName,
-		[]nova.RuleInfo{
-			{
-				IPProtocol: "tcp",
-				FromPort:   22,
-				ToPort:     22,
-				Cidr:       "0.0.0.0/0",
-			},
-			{
-				IPProtocol: "tcp",
-				FromPort:   statePort,
-				ToPort:     statePort,
-				Cidr:       "0.0.0.0/0",
-			},
-			{
+	var rules []nova.RuleInfo
+	for _, cidr := range e.sourceCIDRs() {
+		for _, port := range []int{22, statePort, apiPort} {
+			rules = append(rules, nova.RuleInfo{
 				IPProtocol: "tcp",
-				FromPort:   apiPort,
-				ToPort:     apiPort,
-				Cidr:       "0.0.0.0/0",
-			},
+				FromPort:   port,
+				ToPort:     port,
+				Cidr:       cidr,
+			})
+		}
+	}
+	return e.ensureGroup(groupName,
+		append(rules, []nova.RuleInfo{
 			{
 				IPProtocol: "tcp",
 				FromPort:   1,
@@ -1332,7 +1440,7 @@ func (e *environ) setUpGlobalGroup(groupName string, statePort, apiPort int) (no
 				FromPort:   -1,
 				ToPort:     -1,
 			},
-		})
+		}...))
 }
 
 // setUpGroups creates the security groups for the new machine, and
@@ -1351,6 +1459,7 @@ func (e *environ) setUpGroups(machineId string, statePort, apiPort int) ([]nova.
 	if err != nil {
 		return nil, err
 	}
+	groups := []nova.SecurityGroup{jujuGroup}
 	var machineGroup nova.SecurityGroup
 	switch e.Config().FirewallMode() {
 	case config.FwInstance:
@@ -1361,7 +1470,10 @@ func (e *environ) setUpGroups(machineId string, statePort, apiPort int) ([]nova.
 	if err != nil {
 		return nil, err
 	}
-	groups := []nova.SecurityGroup{jujuGroup, machineGroup}
+	// In none mode, the firewall is managed by the user.
+	if e.Config().FirewallMode() != config.FwNone {
+		groups = append(groups, machineGroup)
+	}
 	if e.ecfg().useDefaultSecurityGroup() {
 		defaultGroup, err := e.nova().SecurityGroupByName("default")
 		if err != nil {
@@ -1452,6 +1564,11 @@ func (e *environ) terminateInstances(ids []instance.Id) error {
 		return nil
 	}
 	var firstErr error
+	var ports []neutronPort
+	neutron, err := e.neutron()
+	if err == nil {
+		ports = e.instancePorts(neutron, ids)
+	}
 	novaClient := e.nova()
 	for _, id := range ids {
 		err := novaClient.DeleteServer(string(id))
@@ -1463,6 +1580,9 @@ func (e *environ) terminateInstances(ids []instance.Id) error {
 			firstErr = err
 		}
 	}
+	// Ports are deleted after the servers, so that their security
+	// groups can be deleted next.
+	e.deletePorts(neutron, ports)
 	return firstErr
 }
 
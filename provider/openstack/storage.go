@@ -11,12 +11,45 @@ import (
 
 	jujuerrors "github.com/juju/errors"
 	"github.com/juju/utils"
+	"launchpad.net/goose/client"
 	gooseerrors "launchpad.net/goose/errors"
+	"launchpad.net/goose/identity"
 	"launchpad.net/goose/swift"
 
+	"github.com/juju/juju/environs/config"
 	"github.com/juju/juju/environs/storage"
 )
 
+func init() {
+	storage.RegisterBackend("swift", swiftBackend{})
+}
+
+// swiftBackend opens storage in a container of any Swift blob store,
+// named by storage-container. The storage-url gives the Swift
+// authentication endpoint, which is accessed with the v1 (legacy)
+// authentication protocol.
+type swiftBackend struct{}
+
+func (swiftBackend) OpenStorage(cfg *config.Config) (storage.Storage, error) {
+	if cfg.StorageContainer() == "" {
+		return nil, fmt.Errorf("storage-container must name a container")
+	}
+	cred := &identity.Credentials{
+		URL:     cfg.StorageURL(),
+		User:    cfg.StorageAccessKey(),
+		Secrets: cfg.StorageSecretKey(),
+	}
+	newClient := client.NewClient
+	if !cfg.SSLHostnameVerification() {
+		newClient = client.NewNonValidatingClient
+	}
+	return &openstackstorage{
+		containerName: cfg.StorageContainer(),
+		containerACL:  swift.PublicRead,
+		swift:         swift.New(newClient(cred, identity.AuthLegacy, nil)),
+	}, nil
+}
+
 // openstackstorage implements storage.Storage on an OpenStack container.
 type openstackstorage struct {
 	sync.Mutex
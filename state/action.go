@@ -8,7 +8,9 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/juju/errors"
 	"github.com/juju/names"
+	"gopkg.in/mgo.v2/bson"
 	"gopkg.in/mgo.v2/txn"
 )
 
@@ -63,6 +65,15 @@ type actionDoc struct {
 	// Payload holds the action's parameters, if any; it should validate
 	// against the schema defined by the named action in the unit's charm
 	Payload map[string]interface{}
+
+	// Status holds whether the action is pending or running. Actions
+	// queued by older versions of juju have no status, and are pending.
+	Status ActionStatus `bson:",omitempty"`
+
+	// Unknown holds the fields of the document added by newer versions
+	// of juju, so that they are not lost when the action is replaced
+	// by its result during an upgrade.
+	Unknown bson.M `bson:",inline"`
 }
 
 // Action represents an instruction to do some "action" and is expected
@@ -74,12 +85,12 @@ type Action struct {
 
 // Id returns the id of the Action
 func (a *Action) Id() string {
-	return a.doc.Id
+	return a.st.localID(a.doc.Id)
 }
 
 // Prefix extracts the name of the unit or service from the encoded _id
 func (a *Action) Prefix() string {
-	if prefix, ok := extractPrefixName(a.doc.Id); ok {
+	if prefix, ok := extractPrefixName(a.Id()); ok {
 		return prefix
 	}
 	return ""
@@ -118,6 +129,39 @@ func (a *Action) Payload() map[string]interface{} {
 	return a.doc.Payload
 }
 
+// Status returns whether the action is pending or running.
+func (a *Action) Status() ActionStatus {
+	if a.doc.Status == "" {
+		return ActionPending
+	}
+	return a.doc.Status
+}
+
+// Begin marks the pending action as running, so that it is not
+// started again by its receiver. It fails if the action has already
+// been started, or if it has finished and been removed from the queue.
+func (a *Action) Begin() (err error) {
+	defer errors.Maskf(&err, "cannot begin action %q", a.Id())
+	ops := []txn.Op{{
+		C:      actionsC,
+		Id:     a.doc.Id,
+		Assert: bson.D{{"status", bson.D{{"$ne", ActionRunning}}}},
+		Update: bson.D{{"$set", bson.D{{"status", ActionRunning}}}},
+	}}
+	if err := a.st.runTransaction(ops); err != txn.ErrAborted {
+		if err == nil {
+			a.doc.Status = ActionRunning
+		}
+		return err
+	}
+	if _, err := a.st.Action(a.doc.Id); errors.IsNotFound(err) {
+		return fmt.Errorf("action has finished")
+	} else if err != nil {
+		return err
+	}
+	return fmt.Errorf("action is already running")
+}
+
 // Complete removes action from the pending queue and creates an ActionResult
 // to capture the output and end state of the action.
 func (a *Action) Complete(output string) error {
@@ -163,14 +207,19 @@ func newActionDoc(st *State, ar ActionReceiver, actionName string, parameters ma
 	if err != nil {
 		return actionDoc{}, err
 	}
-	return actionDoc{Id: actionId, Name: actionName, Payload: parameters}, nil
+	return actionDoc{
+		Id:      actionId,
+		Name:    actionName,
+		Payload: parameters,
+		Status:  ActionPending,
+	}, nil
 }
 
 var ensureActionMarker = ensureSuffixFn(actionMarker)
 
 // newActionId generates a new id for an action on the given ActionReceiver
 func newActionId(st *State, ar ActionReceiver) (string, error) {
-	prefix := ensureActionMarker(ar.Name())
+	prefix := ensureActionMarker(st.docID(ar.Name()))
 	sequence, err := st.sequence(prefix)
 	if err != nil {
 		return "", err
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	jc "github.com/juju/testing/checkers"
+	"gopkg.in/mgo.v2/bson"
 	gc "launchpad.net/gocheck"
 
 	"github.com/juju/juju/state"
@@ -153,6 +154,59 @@ func (s *ActionSuite) TestAddActionFailsOnDeadUnitInTransaction(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, "unit .* is dead")
 }
 
+func (s *ActionSuite) TestBegin(c *gc.C) {
+	unit, err := s.State.Unit(s.unit.Name())
+	c.Assert(err, gc.IsNil)
+	preventUnitDestroyRemove(c, unit)
+
+	a, err := unit.AddAction("action1", nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(a.Status(), gc.Equals, state.ActionPending)
+
+	err = a.Begin()
+	c.Assert(err, gc.IsNil)
+	c.Assert(a.Status(), gc.Equals, state.ActionRunning)
+	action, err := s.State.Action(a.Id())
+	c.Assert(err, gc.IsNil)
+	c.Assert(action.Status(), gc.Equals, state.ActionRunning)
+
+	// A running action cannot be started again.
+	err = action.Begin()
+	c.Assert(err, gc.ErrorMatches, `cannot begin action ".*": action is already running`)
+
+	// A running action can be completed, after
+	// which it cannot be started any more.
+	err = action.Complete("done")
+	c.Assert(err, gc.IsNil)
+	results, err := unit.ActionResults()
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.HasLen, 1)
+	c.Assert(results[0].Status(), gc.Equals, state.ActionCompleted)
+	err = a.Begin()
+	c.Assert(err, gc.ErrorMatches, `cannot begin action ".*": action has finished`)
+}
+
+func (s *ActionSuite) TestBeginActionWithoutStatus(c *gc.C) {
+	unit, err := s.State.Unit(s.unit.Name())
+	c.Assert(err, gc.IsNil)
+	preventUnitDestroyRemove(c, unit)
+
+	a, err := unit.AddAction("action1", nil)
+	c.Assert(err, gc.IsNil)
+
+	// Simulate an action queued by an older version of juju.
+	db := s.State.MongoSession().DB("juju")
+	err = db.C("actions").UpdateId(a.Id(), bson.D{{"$unset", bson.D{{"status", ""}}}})
+	c.Assert(err, gc.IsNil)
+
+	action, err := s.State.Action(a.Id())
+	c.Assert(err, gc.IsNil)
+	c.Assert(action.Status(), gc.Equals, state.ActionPending)
+	err = action.Begin()
+	c.Assert(err, gc.IsNil)
+	c.Assert(action.Status(), gc.Equals, state.ActionRunning)
+}
+
 func (s *ActionSuite) TestFail(c *gc.C) {
 	// get unit, add an action, retrieve that action
 	unit, err := s.State.Unit(s.unit.Name())
@@ -227,6 +281,40 @@ func (s *ActionSuite) TestComplete(c *gc.C) {
 	c.Assert(len(actions), gc.Equals, 0)
 }
 
+func (s *ActionSuite) TestCompleteKeepsUnknownFields(c *gc.C) {
+	unit, err := s.State.Unit(s.unit.Name())
+	c.Assert(err, gc.IsNil)
+	preventUnitDestroyRemove(c, unit)
+
+	a, err := unit.AddAction("action1", nil)
+	c.Assert(err, gc.IsNil)
+
+	// Simulate fields written by a newer version of juju,
+	// one of which clashes with a field of the result.
+	db := s.State.MongoSession().DB("juju")
+	err = db.C("actions").UpdateId(a.Id(), bson.D{{"$set", bson.D{
+		{"requester", "user-admin"},
+		{"output", "ignored"},
+	}}})
+	c.Assert(err, gc.IsNil)
+
+	action, err := s.State.Action(a.Id())
+	c.Assert(err, gc.IsNil)
+	err = action.Complete("done")
+	c.Assert(err, gc.IsNil)
+
+	results, err := unit.ActionResults()
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.HasLen, 1)
+	c.Assert(results[0].Output(), gc.Equals, "done")
+
+	var doc bson.M
+	err = db.C("actionresults").FindId(results[0].Id()).One(&doc)
+	c.Assert(err, gc.IsNil)
+	c.Assert(doc["requester"], gc.Equals, "user-admin")
+	c.Assert(doc["output"], gc.Equals, "done")
+}
+
 func (s *ActionSuite) TestUnitWatchActions(c *gc.C) {
 	// get units
 	unit1, err := s.State.Unit(s.unit.Name())
@@ -8,13 +8,22 @@ import (
 	"strings"
 
 	"github.com/juju/names"
+	"gopkg.in/mgo.v2/bson"
 	"gopkg.in/mgo.v2/txn"
 )
 
-// ActionStatus represents the possible end states for an action.
+// ActionStatus represents the possible states of an action.
 type ActionStatus string
 
 const (
+	// ActionPending is the status of an action that is queued
+	// and has not been started yet.
+	ActionPending ActionStatus = "pending"
+
+	// ActionRunning is the status of an action that has been
+	// started by its receiver and has not finished yet.
+	ActionRunning ActionStatus = "running"
+
 	// ActionFailed signifies that the action did not complete successfully.
 	ActionFailed ActionStatus = "fail"
 
@@ -44,6 +53,21 @@ type actionResultDoc struct {
 
 	// Output captures any text emitted by the action.
 	Output string
+
+	// Unknown holds the fields of the document added by newer versions
+	// of juju, including those carried over from the action.
+	Unknown bson.M `bson:",inline"`
+}
+
+// actionResultFields holds the keys of the fields of actionResultDoc,
+// which are not carried over from the action's unknown fields; nor
+// are the fields used by the transaction runner, prefixed by "txn-".
+var actionResultFields = map[string]bool{
+	"_id":        true,
+	"actionname": true,
+	"payload":    true,
+	"status":     true,
+	"output":     true,
 }
 
 // ActionResult represents an instruction to do some "action" and is
@@ -55,7 +79,7 @@ type ActionResult struct {
 
 // Id returns the id of the ActionResult.
 func (a *ActionResult) Id() string {
-	return a.doc.Id
+	return a.st.localID(a.doc.Id)
 }
 
 // Tag implements the Entity interface and returns a names.Tag that
@@ -113,18 +137,28 @@ func newActionResult(st *State, adoc actionResultDoc) *ActionResult {
 // newActionResultDoc converts an Action into an actionResultDoc given
 // the finalStatus and the output of the action
 func newActionResultDoc(a *Action, finalStatus ActionStatus, output string) actionResultDoc {
-	actionId := a.Id()
+	actionId := a.doc.Id
 	id, ok := convertActionIdToActionResultId(actionId)
 	if !ok {
 		panic(fmt.Sprintf("cannot convert actionId to actionResultId: %v", actionId))
 	}
-	return actionResultDoc{
+	doc := actionResultDoc{
 		Id:         id,
 		ActionName: a.doc.Name,
 		Payload:    a.doc.Payload,
 		Status:     finalStatus,
 		Output:     output,
 	}
+	for key, value := range a.doc.Unknown {
+		if actionResultFields[key] || strings.HasPrefix(key, "txn-") {
+			continue
+		}
+		if doc.Unknown == nil {
+			doc.Unknown = make(bson.M)
+		}
+		doc.Unknown[key] = value
+	}
+	return doc
 }
 
 // convertActionIdToActionResultId builds an actionResultId from an actionId
@@ -59,6 +59,10 @@ type MachineTemplate struct {
 	// should be part of.
 	RequestedNetworks []string
 
+	// ExcludedNetworks holds a list of network names the machine
+	// must not be part of.
+	ExcludedNetworks []string
+
 	// Nonce holds a unique value that can be used to check
 	// if a new instance was really started for this machine.
 	// See Machine.SetProvisioned. This must be set if InstanceId is set.
@@ -75,6 +79,10 @@ type MachineTemplate struct {
 	// principals holds the principal units that will
 	// associated with the machine.
 	principals []string
+
+	// service holds the name of the service whose unit
+	// the machine is created for, if any.
+	service string
 }
 
 // AddMachineInsideNewMachine creates a new machine within a container
@@ -199,6 +207,10 @@ func (st *State) effectiveMachineTemplate(p MachineTemplate, allowStateServer bo
 		return tmpl, fmt.Errorf("cannot specify a nonce without an instance id")
 	}
 
+	if err := validateRequestedNetworks(p.RequestedNetworks, p.ExcludedNetworks); err != nil {
+		return tmpl, err
+	}
+
 	p.Constraints, err = st.resolveConstraints(p.Constraints)
 	if err != nil {
 		return tmpl, err
@@ -241,11 +253,11 @@ func (st *State) addMachineOps(template MachineTemplate) (*machineDoc, []txn.Op,
 			return nil, nil, err
 		}
 	}
-	seq, err := st.sequence("machine")
+	seq, err := st.sequence(st.docID("machine"))
 	if err != nil {
 		return nil, nil, err
 	}
-	mdoc := machineDocForTemplate(template, strconv.Itoa(seq))
+	mdoc := st.machineDocForTemplate(template, strconv.Itoa(seq))
 	var ops []txn.Op
 	ops = append(ops, st.insertNewMachineOps(mdoc, template)...)
 	ops = append(ops, st.insertNewContainerRefOp(mdoc.Id))
@@ -315,25 +327,25 @@ func (st *State) addMachineInsideMachineOps(template MachineTemplate, parentId s
 	if !parent.supportsContainerType(containerType) {
 		return nil, nil, fmt.Errorf("machine %s cannot host %s containers", parentId, containerType)
 	}
-	newId, err := st.newContainerId(parentId, containerType)
+	newId, err := st.newContainerId(parent.doc.Id, containerType)
 	if err != nil {
 		return nil, nil, err
 	}
-	mdoc := machineDocForTemplate(template, newId)
+	mdoc := st.machineDocForTemplate(template, newId)
 	mdoc.ContainerType = string(containerType)
 	var ops []txn.Op
 	ops = append(ops, st.insertNewMachineOps(mdoc, template)...)
 	ops = append(ops,
 		// Update containers record for host machine.
-		st.addChildToContainerRefOp(parentId, mdoc.Id),
+		st.addChildToContainerRefOp(parent.doc.Id, mdoc.Id),
 		// Create a containers reference document for the container itself.
 		st.insertNewContainerRefOp(mdoc.Id),
 	)
 	return mdoc, ops, nil
 }
 
-// newContainerId returns a new id for a machine within the machine
-// with id parentId and the given container type.
+// newContainerId returns a new document id for a machine within the
+// machine with document id parentId and the given container type.
 func (st *State) newContainerId(parentId string, containerType instance.ContainerType) (string, error) {
 	seq, err := st.sequence(fmt.Sprintf("machine%s%sContainer", parentId, containerType))
 	if err != nil {
@@ -350,7 +362,7 @@ func (st *State) addMachineInsideNewMachineOps(template, parentTemplate MachineT
 	if template.InstanceId != "" || parentTemplate.InstanceId != "" {
 		return nil, nil, fmt.Errorf("cannot specify instance id for a new container")
 	}
-	seq, err := st.sequence("machine")
+	seq, err := st.sequence(st.docID("machine"))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -371,7 +383,7 @@ func (st *State) addMachineInsideNewMachineOps(template, parentTemplate MachineT
 		}
 	}
 
-	parentDoc := machineDocForTemplate(parentTemplate, strconv.Itoa(seq))
+	parentDoc := st.machineDocForTemplate(parentTemplate, strconv.Itoa(seq))
 	newId, err := st.newContainerId(parentDoc.Id, containerType)
 	if err != nil {
 		return nil, nil, err
@@ -380,7 +392,7 @@ func (st *State) addMachineInsideNewMachineOps(template, parentTemplate MachineT
 	if err != nil {
 		return nil, nil, err
 	}
-	mdoc := machineDocForTemplate(template, newId)
+	mdoc := st.machineDocForTemplate(template, newId)
 	mdoc.ContainerType = string(containerType)
 	var ops []txn.Op
 	ops = append(ops, st.insertNewMachineOps(parentDoc, parentTemplate)...)
@@ -394,19 +406,22 @@ func (st *State) addMachineInsideNewMachineOps(template, parentTemplate MachineT
 	return mdoc, ops, nil
 }
 
-func machineDocForTemplate(template MachineTemplate, id string) *machineDoc {
+func (st *State) machineDocForTemplate(template MachineTemplate, id string) *machineDoc {
 	return &machineDoc{
-		Id:         id,
-		Series:     template.Series,
-		Jobs:       template.Jobs,
-		Clean:      !template.Dirty,
-		Principals: template.principals,
-		Life:       Alive,
-		InstanceId: template.InstanceId,
-		Nonce:      template.Nonce,
-		Addresses:  instanceAddressesToAddresses(template.Addresses),
-		NoVote:     template.NoVote,
-		Placement:  template.Placement,
+		Id:          st.docID(id),
+		EnvUUID:     st.environTag.Id(),
+		Series:      template.Series,
+		Jobs:        template.Jobs,
+		Clean:       !template.Dirty,
+		Principals:  template.principals,
+		Service:     template.service,
+		Life:        Alive,
+		InstanceId:  template.InstanceId,
+		Nonce:       template.Nonce,
+		Addresses:   instanceAddressesToAddresses(template.Addresses),
+		NoVote:      template.NoVote,
+		Placement:   template.Placement,
+		DateCreated: nowToTheSecond(),
 	}
 }
 
@@ -429,7 +444,7 @@ func (st *State) insertNewMachineOps(mdoc *machineDoc, template MachineTemplate)
 		// Once we can add networks independently of machine
 		// provisioning, we should check the given networks are valid
 		// and known before setting them.
-		createRequestedNetworksOp(st, machineGlobalKey(mdoc.Id), template.RequestedNetworks),
+		createRequestedNetworksOp(st, machineGlobalKey(mdoc.Id), template.RequestedNetworks, template.ExcludedNetworks),
 	}
 }
 
@@ -546,7 +561,7 @@ func (st *State) EnsureAvailability(numStateServers int, cons constraints.Value,
 		ops, change, err = st.ensureAvailabilityIntentionOps(intent, currentInfo, cons, series)
 		return ops, err
 	}
-	if err := st.run(buildTxn); err != nil {
+	if err := st.runDiagnosed(buildTxn); err != nil {
 		err = errors.Annotate(err, "failed to create new state server machines")
 		return StateServersChanges{}, err
 	}
@@ -5,6 +5,8 @@ package state
 
 import (
 	"fmt"
+	"net"
+	"strconv"
 
 	"github.com/juju/errors"
 	"gopkg.in/mgo.v2/bson"
@@ -47,7 +49,7 @@ func (st *State) stateServerAddresses() ([]string, error) {
 func appendPort(addrs []string, port int) []string {
 	newAddrs := make([]string, len(addrs))
 	for i, addr := range addrs {
-		newAddrs[i] = fmt.Sprintf("%s:%d", addr, port)
+		newAddrs[i] = net.JoinHostPort(addr, strconv.Itoa(port))
 	}
 	return newAddrs
 }
@@ -113,7 +115,7 @@ func (st *State) SetAPIHostPorts(hps [][]network.HostPort) error {
 		}
 		return []txn.Op{op}, nil
 	}
-	if err := st.run(buildTxn); err != nil {
+	if err := st.runDiagnosed(buildTxn); err != nil {
 		return errors.Annotate(err, "cannot set API addresses")
 	}
 	return nil
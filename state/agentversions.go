@@ -0,0 +1,178 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/juju/names"
+	"gopkg.in/mgo.v2/bson"
+
+	"github.com/juju/juju/version"
+)
+
+// AgentVersionReport describes the version of the tools
+// an agent last reported running.
+type AgentVersionReport struct {
+	// Tag identifies the machine or unit the agent runs for.
+	Tag names.Tag
+
+	// Version holds the version of the tools last reported.
+	// It is the zero value if the agent never reported.
+	Version version.Binary
+
+	// Reported holds the time of the last report.
+	Reported time.Time
+}
+
+// agentVersionDoc records the last version reported by the agent of
+// the machine or unit with the given global key. It is frequently
+// written and so, like utilization samples, is not written
+// transactionally.
+type agentVersionDoc struct {
+	Id       string `bson:"_id"`
+	Version  version.Binary
+	Reported time.Time
+}
+
+// SetAgentVersionReported records that the machine's agent is running
+// the given version of the tools, as reported now. The agent version
+// of the machine is set if it has changed.
+func (m *Machine) SetAgentVersionReported(v version.Binary) error {
+	if m.doc.Tools == nil || m.doc.Tools.Version != v {
+		if err := m.SetAgentVersion(v); err != nil {
+			return err
+		}
+	}
+	if err := m.st.reportAgentVersion(m.globalKey(), v); err != nil {
+		return fmt.Errorf("cannot record agent version for machine %v: %v", m, err)
+	}
+	return nil
+}
+
+// SetAgentVersionReported records that the unit's agent is running
+// the given version of the tools, as reported now. The agent version
+// of the unit is set if it has changed.
+func (u *Unit) SetAgentVersionReported(v version.Binary) error {
+	if u.doc.Tools == nil || u.doc.Tools.Version != v {
+		if err := u.SetAgentVersion(v); err != nil {
+			return err
+		}
+	}
+	if err := u.st.reportAgentVersion(u.globalKey(), v); err != nil {
+		return fmt.Errorf("cannot record agent version for unit %q: %v", u, err)
+	}
+	return nil
+}
+
+func (st *State) reportAgentVersion(key string, v version.Binary) error {
+	reports, closer := st.getCollection(agentVersionsC)
+	defer closer()
+
+	_, err := reports.UpsertId(key, &agentVersionDoc{
+		Id:       key,
+		Version:  v,
+		Reported: nowToTheSecond(),
+	})
+	return err
+}
+
+func (st *State) removeAgentVersion(key string) error {
+	reports, closer := st.getCollection(agentVersionsC)
+	defer closer()
+
+	_, err := reports.RemoveAll(bson.D{{"_id", key}})
+	return err
+}
+
+// LaggingAgents returns reports for the agents of the machines and
+// units of the environment that are not dead and that last reported
+// running a version of the tools older than the environment's
+// agent-version, or never reported at all. The reports are ordered
+// by tag.
+func (st *State) LaggingAgents() ([]AgentVersionReport, error) {
+	cfg, err := st.EnvironConfig()
+	if err != nil {
+		return nil, err
+	}
+	target, ok := cfg.AgentVersion()
+	if !ok {
+		return nil, fmt.Errorf("no agent version set in the environment configuration")
+	}
+	tags, err := st.agentTags()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get lagging agents: %v", err)
+	}
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+
+	reports, closer := st.getCollection(agentVersionsC)
+	defer closer()
+
+	var docs []agentVersionDoc
+	err = reports.Find(bson.D{{"_id", bson.D{{"$in", keys}}}}).All(&docs)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get lagging agents: %v", err)
+	}
+	reported := make(map[string]agentVersionDoc)
+	for _, doc := range docs {
+		reported[doc.Id] = doc
+	}
+	var result []AgentVersionReport
+	for key, tag := range tags {
+		doc, ok := reported[key]
+		if ok && doc.Version.Number.Compare(target) >= 0 {
+			continue
+		}
+		result = append(result, AgentVersionReport{
+			Tag:      tag,
+			Version:  doc.Version,
+			Reported: doc.Reported,
+		})
+	}
+	sort.Sort(agentVersionReportsByTag(result))
+	return result, nil
+}
+
+// agentTags returns the tags of the machines and units of the
+// environment that are not dead, keyed by their global keys.
+func (st *State) agentTags() (map[string]names.Tag, error) {
+	tags := make(map[string]names.Tag)
+	sel := append(st.environSelector(), notDeadDoc...)
+
+	machines, closer := st.getCollection(machinesC)
+	defer closer()
+	var mdoc machineDoc
+	iter := machines.Find(sel).Select(bson.D{{"_id", 1}}).Iter()
+	for iter.Next(&mdoc) {
+		tags[machineGlobalKey(mdoc.Id)] = names.NewMachineTag(st.localID(mdoc.Id))
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	units, closer := st.getCollection(unitsC)
+	defer closer()
+	var udoc unitDoc
+	iter = units.Find(sel).Select(bson.D{{"_id", 1}}).Iter()
+	for iter.Next(&udoc) {
+		tags[unitGlobalKey(udoc.Name)] = names.NewUnitTag(st.localID(udoc.Name))
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+type agentVersionReportsByTag []AgentVersionReport
+
+func (r agentVersionReportsByTag) Len() int      { return len(r) }
+func (r agentVersionReportsByTag) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
+func (r agentVersionReportsByTag) Less(i, j int) bool {
+	return r[i].Tag.String() < r[j].Tag.String()
+}
@@ -0,0 +1,116 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"github.com/juju/names"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/version"
+)
+
+type AgentVersionSuite struct {
+	ConnSuite
+	machine *state.Machine
+	unit    *state.Unit
+	current version.Number
+}
+
+var _ = gc.Suite(&AgentVersionSuite{})
+
+func (s *AgentVersionSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	var err error
+	s.machine, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	svc := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	s.unit, err = svc.AddUnit()
+	c.Assert(err, gc.IsNil)
+	cfg, err := s.State.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	var ok bool
+	s.current, ok = cfg.AgentVersion()
+	c.Assert(ok, gc.Equals, true)
+}
+
+func (s *AgentVersionSuite) binary(number version.Number) version.Binary {
+	return version.Binary{Number: number, Series: "quantal", Arch: "amd64"}
+}
+
+func laggingTags(reports []state.AgentVersionReport) []names.Tag {
+	var tags []names.Tag
+	for _, report := range reports {
+		tags = append(tags, report.Tag)
+	}
+	return tags
+}
+
+func (s *AgentVersionSuite) TestLaggingAgentsNeverReported(c *gc.C) {
+	reports, err := s.State.LaggingAgents()
+	c.Assert(err, gc.IsNil)
+	c.Assert(reports, gc.DeepEquals, []state.AgentVersionReport{
+		{Tag: names.NewMachineTag("0")},
+		{Tag: names.NewUnitTag("wordpress/0")},
+	})
+}
+
+func (s *AgentVersionSuite) TestSetAgentVersionReported(c *gc.C) {
+	current := s.binary(s.current)
+	err := s.machine.SetAgentVersionReported(current)
+	c.Assert(err, gc.IsNil)
+	tools, err := s.machine.AgentTools()
+	c.Assert(err, gc.IsNil)
+	c.Assert(tools.Version, gc.Equals, current)
+	reports, err := s.State.LaggingAgents()
+	c.Assert(err, gc.IsNil)
+	c.Assert(laggingTags(reports), gc.DeepEquals, []names.Tag{names.NewUnitTag("wordpress/0")})
+
+	err = s.unit.SetAgentVersionReported(current)
+	c.Assert(err, gc.IsNil)
+	tools, err = s.unit.AgentTools()
+	c.Assert(err, gc.IsNil)
+	c.Assert(tools.Version, gc.Equals, current)
+	reports, err = s.State.LaggingAgents()
+	c.Assert(err, gc.IsNil)
+	c.Assert(reports, gc.HasLen, 0)
+
+	// Once the environment is upgraded, both agents lag behind.
+	next := s.current
+	next.Patch++
+	err = s.State.SetEnvironAgentVersion(next)
+	c.Assert(err, gc.IsNil)
+	reports, err = s.State.LaggingAgents()
+	c.Assert(err, gc.IsNil)
+	c.Assert(laggingTags(reports), gc.DeepEquals, []names.Tag{
+		names.NewMachineTag("0"),
+		names.NewUnitTag("wordpress/0"),
+	})
+	for _, report := range reports {
+		c.Check(report.Version, gc.Equals, current)
+		c.Check(report.Reported.IsZero(), gc.Equals, false)
+	}
+
+	// The agents catch up as they report the new version.
+	err = s.machine.SetAgentVersionReported(s.binary(next))
+	c.Assert(err, gc.IsNil)
+	reports, err = s.State.LaggingAgents()
+	c.Assert(err, gc.IsNil)
+	c.Assert(laggingTags(reports), gc.DeepEquals, []names.Tag{names.NewUnitTag("wordpress/0")})
+}
+
+func (s *AgentVersionSuite) TestSetAgentVersionReportedInvalid(c *gc.C) {
+	err := s.machine.SetAgentVersionReported(version.Binary{Number: s.current})
+	c.Assert(err, gc.ErrorMatches, "cannot set agent version for machine 0: empty series or arch")
+}
+
+func (s *AgentVersionSuite) TestLaggingAgentsSkipsDeadEntities(c *gc.C) {
+	err := s.unit.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = s.machine.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	reports, err := s.State.LaggingAgents()
+	c.Assert(err, gc.IsNil)
+	c.Assert(reports, gc.HasLen, 0)
+}
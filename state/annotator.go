@@ -74,7 +74,7 @@ func (a *annotator) SetAnnotations(pairs map[string]string) (err error) {
 		}
 		return a.updateOps(toUpdate, toRemove), nil
 	}
-	return a.st.run(buildTxn)
+	return a.st.runDiagnosed(buildTxn)
 }
 
 // insertOps returns the operations required to insert annotations in MongoDB.
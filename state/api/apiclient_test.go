@@ -14,6 +14,7 @@ import (
 	gc "launchpad.net/gocheck"
 
 	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
 	"github.com/juju/juju/state/api"
 	"github.com/juju/juju/state/api/params"
 	coretesting "github.com/juju/juju/testing"
@@ -154,6 +155,28 @@ func (s *apiclientSuite) TestOpenPassesEnvironTag(c *gc.C) {
 	st.Close()
 }
 
+func (s *apiclientSuite) TestOpenHostedEnvironment(c *gc.C) {
+	cfg, err := coretesting.EnvironConfig(c).Apply(map[string]interface{}{"name": "hosted"})
+	c.Assert(err, gc.IsNil)
+	hostedSt, err := s.State.NewEnvironment(cfg)
+	c.Assert(err, gc.IsNil)
+	defer hostedSt.Close()
+	user, err := hostedSt.User("admin")
+	c.Assert(err, gc.IsNil)
+	err = user.SetEnvironmentAccess(state.EnvironmentAdminAccess)
+	c.Assert(err, gc.IsNil)
+
+	info := s.APIInfo(c)
+	info.EnvironTag = hostedSt.EnvironTag()
+	st, err := api.Open(info, api.DialOpts{})
+	c.Assert(err, gc.IsNil)
+	defer st.Close()
+	envInfo, err := st.Client().EnvironmentInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(envInfo.Name, gc.Equals, "hosted")
+	c.Assert(envInfo.UUID, gc.Equals, hostedSt.EnvironTag().Id())
+}
+
 func (s *apiclientSuite) TestDialWebsocketStopped(c *gc.C) {
 	stopped := make(chan struct{})
 	f := api.NewWebsocketDialer(nil, api.DialOpts{})
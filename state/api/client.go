@@ -80,6 +80,7 @@ type MachineStatus struct {
 	Jobs          []params.MachineJob
 	HasVote       bool
 	WantsVote     bool
+	Maintenance   bool
 }
 
 // ServiceStatus holds status info about a service.
@@ -93,6 +94,17 @@ type ServiceStatus struct {
 	CanUpgradeTo  string
 	SubordinateTo []string
 	Units         map[string]UnitStatus
+	Deployment    *DeploymentRecord
+}
+
+// DeploymentRecord holds the provenance of a service.
+type DeploymentRecord struct {
+	User        string
+	ClientHost  string
+	CharmURL    string
+	CharmSha256 string
+	Bundle      string
+	Deployed    time.Time
 }
 
 // UnitStatus holds status info about a unit.
@@ -149,6 +161,16 @@ type Status struct {
 	Services        map[string]ServiceStatus
 	Networks        map[string]NetworkStatus
 	Relations       []RelationStatus
+
+	// Validator identifies this version of the status. It may be
+	// passed to StatusIfModified to avoid fetching an unchanged
+	// status again.
+	Validator string
+
+	// NotModified is set when the status is unchanged since the
+	// validator given in the request was issued. In that case
+	// only Validator is filled in.
+	NotModified bool
 }
 
 // Status returns the status of the juju environment.
@@ -161,6 +183,19 @@ func (c *Client) Status(patterns []string) (*Status, error) {
 	return &result, nil
 }
 
+// StatusIfModified returns the status of the juju environment unless
+// it is unchanged since the given validator was issued, in which case
+// the returned status has NotModified set and holds no other details.
+// An empty validator always fetches the full status.
+func (c *Client) StatusIfModified(patterns []string, validator string) (*Status, error) {
+	var result Status
+	p := params.StatusParams{Patterns: patterns, Validator: validator}
+	if err := c.call("FullStatus", p, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
 // LegacyMachineStatus holds just the instance-id of a machine.
 type LegacyMachineStatus struct {
 	InstanceId string // Not type instance.Id just to match original api.
@@ -223,6 +258,85 @@ func (c *Client) RetryProvisioning(machines ...string) ([]params.ErrorResult, er
 	return results.Results, err
 }
 
+// MachineUtilization returns the recorded resource utilization
+// samples of the specified machines.
+func (c *Client) MachineUtilization(machineIds ...string) ([]params.MachineUtilizationResult, error) {
+	p := params.Entities{}
+	p.Entities = make([]params.Entity, len(machineIds))
+	for i, id := range machineIds {
+		p.Entities[i] = params.Entity{Tag: names.NewMachineTag(id).String()}
+	}
+	var results params.MachineUtilizationResults
+	err := c.call("MachineUtilization", p, &results)
+	return results.Results, err
+}
+
+// FindStatus returns the recent statuses of machines and units
+// that mention the given text, newest first. If since is not
+// zero, only the statuses set after that time are returned.
+func (c *Client) FindStatus(text string, since time.Time) ([]params.StatusHistoryEntry, error) {
+	p := params.FindStatusParams{Text: text}
+	if !since.IsZero() {
+		p.Since = &since
+	}
+	var results params.FindStatusResults
+	err := c.call("FindStatus", p, &results)
+	return results.Results, err
+}
+
+// APIActivity returns the number of API requests served by the
+// API server since it started, and the number of open connections
+// to it.
+func (c *Client) APIActivity() (params.APIActivity, error) {
+	var result params.APIActivity
+	err := c.call("APIActivity", nil, &result)
+	return result, err
+}
+
+// SetInterfaceSchema registers the schema against which relation
+// settings of the schema's interface are validated, replacing any
+// schema previously registered for it.
+func (c *Client) SetInterfaceSchema(schema params.InterfaceSchema) error {
+	return c.call("SetInterfaceSchema", schema, nil)
+}
+
+// GetInterfaceSchema returns the schema registered for the named
+// relation interface.
+func (c *Client) GetInterfaceSchema(name string) (params.InterfaceSchema, error) {
+	var result params.InterfaceSchema
+	args := params.InterfaceSchemaName{Interface: name}
+	err := c.call("GetInterfaceSchema", args, &result)
+	return result, err
+}
+
+// RemoveInterfaceSchema removes the schema registered for the
+// named relation interface.
+func (c *Client) RemoveInterfaceSchema(name string) error {
+	args := params.InterfaceSchemaName{Interface: name}
+	return c.call("RemoveInterfaceSchema", args, nil)
+}
+
+// SwitchBlockOn blocks the operations of the given type
+// ("destroy-environment", "remove-object" or "all-changes"),
+// recording the message to be reported when they are refused.
+func (c *Client) SwitchBlockOn(blockType, message string) error {
+	args := params.BlockSwitchParams{Type: blockType, Message: message}
+	return c.call("SwitchBlockOn", args, nil)
+}
+
+// SwitchBlockOff unblocks the operations of the given type.
+func (c *Client) SwitchBlockOff(blockType string) error {
+	args := params.BlockSwitchParams{Type: blockType}
+	return c.call("SwitchBlockOff", args, nil)
+}
+
+// ListBlocks returns the blocks set in the environment.
+func (c *Client) ListBlocks() ([]params.BlockResult, error) {
+	var result params.BlockResults
+	err := c.call("ListBlocks", nil, &result)
+	return result.Results, err
+}
+
 // PublicAddress returns the public address of the specified
 // machine or unit.
 func (c *Client) PublicAddress(target string) (string, error) {
@@ -314,6 +428,14 @@ func (c *Client) ProvisioningScript(args params.ProvisioningScriptParams) (scrip
 	return result.Script, nil
 }
 
+// UnusedMachines returns the machines that host no units
+// and no containers.
+func (c *Client) UnusedMachines() ([]params.UnusedMachine, error) {
+	var results params.UnusedMachinesResults
+	err := c.call("UnusedMachines", nil, &results)
+	return results.Machines, err
+}
+
 // DestroyMachines removes a given set of machines.
 func (c *Client) DestroyMachines(machines ...string) error {
 	params := params.DestroyMachines{MachineNames: machines}
@@ -340,11 +462,32 @@ func (c *Client) ServiceUnexpose(service string) error {
 	return c.call("ServiceUnexpose", params, nil)
 }
 
+// ServiceExposePorts changes the juju-managed firewall to expose the
+// given ports, if they are explicitly marked by units as open.
+func (c *Client) ServiceExposePorts(service string, ports []network.Port) error {
+	params := params.ServiceExpose{ServiceName: service, Ports: ports}
+	return c.call("ServiceExpose", params, nil)
+}
+
+// ServiceUnexposePorts changes the juju-managed firewall to stop
+// exposing the given ports of the service.
+func (c *Client) ServiceUnexposePorts(service string, ports []network.Port) error {
+	params := params.ServiceUnexpose{ServiceName: service, Ports: ports}
+	return c.call("ServiceUnexpose", params, nil)
+}
+
 // ServiceDeployWithNetworks works exactly like ServiceDeploy, but
 // allows the specification of requested networks that must be present
 // on the machines where the service is deployed. Another way to specify
 // networks to include/exclude is using constraints.
 func (c *Client) ServiceDeployWithNetworks(charmURL string, serviceName string, numUnits int, configYAML string, cons constraints.Value, toMachineSpec string, networks []string) error {
+	return c.ServiceDeployInBundle("", charmURL, serviceName, numUnits, configYAML, cons, toMachineSpec, networks)
+}
+
+// ServiceDeployInBundle works exactly like ServiceDeployWithNetworks,
+// but records in the service's deployment record that it is deployed
+// as part of the named bundle.
+func (c *Client) ServiceDeployInBundle(bundle, charmURL string, serviceName string, numUnits int, configYAML string, cons constraints.Value, toMachineSpec string, networks []string) error {
 	params := params.ServiceDeploy{
 		ServiceName:   serviceName,
 		CharmUrl:      charmURL,
@@ -353,7 +496,9 @@ func (c *Client) ServiceDeployWithNetworks(charmURL string, serviceName string,
 		Constraints:   cons,
 		ToMachineSpec: toMachineSpec,
 		Networks:      networks,
+		Bundle:        bundle,
 	}
+	params.ClientHost, _ = os.Hostname()
 	return c.st.Call("Client", "", "ServiceDeployWithNetworks", params, nil)
 }
 
@@ -368,6 +513,9 @@ func (c *Client) ServiceDeploy(charmURL string, serviceName string, numUnits int
 		Constraints:   cons,
 		ToMachineSpec: toMachineSpec,
 	}
+	// The host name is only recorded for information,
+	// so failing to get it is not an error.
+	params.ClientHost, _ = os.Hostname()
 	return c.call("ServiceDeploy", params, nil)
 }
 
@@ -433,6 +581,17 @@ func (c *Client) GetServiceConstraints(service string) (constraints.Value, error
 	return results.Constraints, err
 }
 
+// GetServiceEffectiveConstraints returns the constraints for the given
+// service merged with the environment constraints, as they are used for
+// the machines added for the service's units. If the API server does not
+// support it, an error satisfying params.IsCodeNotImplemented() is
+// returned.
+func (c *Client) GetServiceEffectiveConstraints(service string) (constraints.Value, error) {
+	results := new(params.GetConstraintsResults)
+	err := c.call("GetServiceEffectiveConstraints", params.GetServiceConstraints{service}, results)
+	return results.Constraints, err
+}
+
 // GetEnvironmentConstraints returns the constraints for the environment.
 func (c *Client) GetEnvironmentConstraints() (constraints.Value, error) {
 	results := new(params.GetConstraintsResults)
@@ -463,6 +622,16 @@ type CharmInfo struct {
 	URL      string
 	Config   *charm.Config
 	Meta     *charm.Meta
+
+	// Validator identifies this version of the charm. It may be
+	// passed to CharmInfoIfModified to avoid fetching unchanged
+	// charm details again.
+	Validator string
+
+	// NotModified is set when the charm is unchanged since the
+	// validator given in the request was issued. In that case
+	// only URL and Validator are filled in.
+	NotModified bool
 }
 
 // CharmInfo returns information about the requested charm.
@@ -475,6 +644,18 @@ func (c *Client) CharmInfo(charmURL string) (*CharmInfo, error) {
 	return info, nil
 }
 
+// CharmInfoIfModified returns information about the requested charm
+// unless it is unchanged since the given validator was issued, in
+// which case the returned info has NotModified set.
+func (c *Client) CharmInfoIfModified(charmURL, validator string) (*CharmInfo, error) {
+	args := params.CharmInfo{CharmURL: charmURL, Validator: validator}
+	info := new(CharmInfo)
+	if err := c.call("CharmInfo", args, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
 // EnvironmentInfo holds information about the Juju environment.
 type EnvironmentInfo struct {
 	DefaultSeries string
@@ -529,7 +710,7 @@ func (c *Client) GetAnnotations(tag string) (map[string]string, error) {
 
 // SetAnnotations sets the annotation pairs on the given entity.
 // Currently annotations are supported on machines, services,
-// units and the environment itself.
+// units, networks and the environment itself.
 func (c *Client) SetAnnotations(tag string, pairs map[string]string) error {
 	args := params.SetAnnotations{tag, pairs}
 	return c.call("SetAnnotations", args, nil)
@@ -600,6 +781,18 @@ func (c *Client) Run(run params.RunParams) ([]params.RunResult, error) {
 	return results.Results, err
 }
 
+// RunTargets runs the Commands specified on the machines identified
+// through the ids provided in the machines, services and units slices.
+// Targets that cannot be run on are reported by results holding the
+// target and the error, rather than failing the whole call. If the API
+// server does not support RunTargets, an error satisfying
+// params.IsCodeNotImplemented() is returned.
+func (c *Client) RunTargets(run params.RunParams) ([]params.RunResult, error) {
+	var results params.RunResults
+	err := c.call("RunTargets", run, &results)
+	return results.Results, err
+}
+
 // DestroyEnvironment puts the environment into a "dying" state,
 // and removes all non-manager machine instances. DestroyEnvironment
 // will fail if there are any manually-provisioned non-manager machines
@@ -8,6 +8,7 @@ import (
 
 	"github.com/juju/names"
 
+	"github.com/juju/juju/network"
 	"github.com/juju/juju/state/api/common"
 	"github.com/juju/juju/state/api/params"
 	"github.com/juju/juju/state/api/watcher"
@@ -70,3 +71,25 @@ func (s *Service) IsExposed() (bool, error) {
 	}
 	return result.Result, nil
 }
+
+// ExposedPorts returns the ports that are accessible when the service
+// is exposed. If it returns no ports, all the ports opened by the
+// service's units are accessible.
+func (s *Service) ExposedPorts() ([]network.Port, error) {
+	var results params.PortsResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: s.tag.String()}},
+	}
+	err := s.st.call("GetExposedPorts", args, &results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results.Results) != 1 {
+		return nil, fmt.Errorf("expected 1 result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return result.Ports, nil
+}
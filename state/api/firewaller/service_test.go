@@ -8,6 +8,7 @@ import (
 	jc "github.com/juju/testing/checkers"
 	gc "launchpad.net/gocheck"
 
+	"github.com/juju/juju/network"
 	"github.com/juju/juju/state/api/firewaller"
 	"github.com/juju/juju/state/api/params"
 	statetesting "github.com/juju/juju/state/testing"
@@ -90,3 +91,16 @@ func (s *serviceSuite) TestIsExposed(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	c.Assert(isExposed, jc.IsFalse)
 }
+
+func (s *serviceSuite) TestExposedPorts(c *gc.C) {
+	ports, err := s.apiService.ExposedPorts()
+	c.Assert(err, gc.IsNil)
+	c.Assert(ports, gc.HasLen, 0)
+
+	err = s.service.SetExposedPorts([]network.Port{{"tcp", 80}, {"udp", 53}})
+	c.Assert(err, gc.IsNil)
+
+	ports, err = s.apiService.ExposedPorts()
+	c.Assert(err, gc.IsNil)
+	c.Assert(ports, jc.DeepEquals, []network.Port{{"tcp", 80}, {"udp", 53}})
+}
@@ -87,6 +87,43 @@ func (u *Unit) OpenedPorts() ([]network.Port, error) {
 	return result.Ports, nil
 }
 
+// OpenedPortsOnNetworks returns the ports opened by this unit on each
+// network, keyed by network name. When talking to an API server that
+// does not track ports per network, all the ports are reported as
+// opened on the default public network.
+func (u *Unit) OpenedPortsOnNetworks() (map[string][]network.Port, error) {
+	var results params.NetworkPortsResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: u.tag.String()}},
+	}
+	err := u.st.call("OpenedPortsOnNetworks", args, &results)
+	if params.IsCodeNotImplemented(err) {
+		ports, err := u.OpenedPorts()
+		if err != nil {
+			return nil, err
+		}
+		result := make(map[string][]network.Port)
+		if len(ports) > 0 {
+			result[network.DefaultPublic] = ports
+		}
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(results.Results) != 1 {
+		return nil, fmt.Errorf("expected 1 result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.Ports == nil {
+		return make(map[string][]network.Port), nil
+	}
+	return result.Ports, nil
+}
+
 // AssignedMachine returns the tag of this unit's assigned machine (if
 // any), or a CodeNotAssigned error.
 func (u *Unit) AssignedMachine() (names.Tag, error) {
@@ -112,6 +112,24 @@ func (s *unitSuite) TestOpenedPorts(c *gc.C) {
 	c.Assert(ports, jc.DeepEquals, []network.Port{{"tcp", 1234}, {"tcp", 4321}})
 }
 
+func (s *unitSuite) TestOpenedPortsOnNetworks(c *gc.C) {
+	ports, err := s.apiUnit.OpenedPortsOnNetworks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(ports, gc.HasLen, 0)
+
+	// Open some ports on two networks and check again.
+	err = s.units[0].OpenPort("tcp", 1234)
+	c.Assert(err, gc.IsNil)
+	err = s.units[0].OpenPortOnNetwork("net1", "tcp", 4321)
+	c.Assert(err, gc.IsNil)
+	ports, err = s.apiUnit.OpenedPortsOnNetworks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(ports, jc.DeepEquals, map[string][]network.Port{
+		network.DefaultPublic: {{"tcp", 1234}},
+		"net1":                {{"tcp", 4321}},
+	})
+}
+
 func (s *unitSuite) TestService(c *gc.C) {
 	service, err := s.apiUnit.Service()
 	c.Assert(err, gc.IsNil)
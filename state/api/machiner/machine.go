@@ -69,6 +69,22 @@ func (m *Machine) SetMachineAddresses(addresses []network.Address) error {
 	return result.OneError()
 }
 
+// RecordUtilization records a resource utilization sample for the
+// machine.
+func (m *Machine) RecordUtilization(sample params.UtilizationSample) error {
+	var result params.ErrorResults
+	args := params.RecordMachinesUtilization{
+		Machines: []params.MachineUtilization{
+			{Tag: m.Tag().String(), Sample: sample},
+		},
+	}
+	err := m.st.call("RecordUtilization", args, &result)
+	if err != nil {
+		return err
+	}
+	return result.OneError()
+}
+
 // EnsureDead sets the machine lifecycle to Dead if it is Alive or
 // Dying. It does nothing otherwise.
 func (m *Machine) EnsureDead() error {
@@ -5,6 +5,7 @@ package machiner_test
 
 import (
 	stdtesting "testing"
+	"time"
 
 	"github.com/juju/errors"
 	"github.com/juju/names"
@@ -147,6 +148,25 @@ func (s *machinerSuite) TestSetMachineAddresses(c *gc.C) {
 	c.Assert(s.machine.MachineAddresses(), gc.DeepEquals, addresses)
 }
 
+func (s *machinerSuite) TestRecordUtilization(c *gc.C) {
+	machine, err := s.machiner.Machine(names.NewMachineTag("1"))
+	c.Assert(err, gc.IsNil)
+
+	err = machine.RecordUtilization(params.UtilizationSample{
+		Time:       time.Now(),
+		CPUPercent: 42,
+		MemUsed:    1,
+		MemTotal:   2,
+	})
+	c.Assert(err, gc.IsNil)
+
+	samples, err := s.machine.Utilization()
+	c.Assert(err, gc.IsNil)
+	c.Assert(samples, gc.HasLen, 1)
+	c.Assert(samples[0].CPUPercent, gc.Equals, 42.0)
+	c.Assert(samples[0].MemTotal, gc.Equals, uint64(2))
+}
+
 func (s *machinerSuite) TestWatch(c *gc.C) {
 	machine, err := s.machiner.Machine(names.NewMachineTag("1"))
 	c.Assert(err, gc.IsNil)
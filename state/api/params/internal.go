@@ -70,6 +70,19 @@ type PortsResult struct {
 	Ports []network.Port
 }
 
+// NetworkPortsResults holds the bulk operation result of an API call
+// that returns the ports opened on each network.
+type NetworkPortsResults struct {
+	Results []NetworkPortsResult
+}
+
+// NetworkPortsResult holds the ports opened on each network, keyed
+// by network name, or an error.
+type NetworkPortsResult struct {
+	Error *Error
+	Ports map[string][]network.Port
+}
+
 // StringsResults holds the bulk operation result of an API call
 // that returns a slice of strings or an error.
 type StringsResults struct {
@@ -352,6 +365,10 @@ type Network struct {
 	// VLANTag needs to be between 1 and 4094 for VLANs and 0 for
 	// normal networks. It's defined by IEEE 802.1Q standard.
 	VLANTag int
+
+	// AvailabilityZone is the availability zone the network
+	// belongs to, if the provider has any.
+	AvailabilityZone string `json:",omitempty"`
 }
 
 // NetworkInterface describes a single network interface available on
@@ -374,6 +391,18 @@ type NetworkInterface struct {
 
 	// Disabled returns whether the interface is disabled.
 	Disabled bool
+
+	// InterfaceType is the kind of the interface. It is empty for
+	// plain interfaces.
+	InterfaceType network.InterfaceType
+
+	// ParentInterfaceName is the name of the bond or bridge the
+	// interface is a member of, or empty if it is not a member
+	// of any.
+	ParentInterfaceName string
+
+	// BondMode is the bonding mode of a bond interface.
+	BondMode string
 }
 
 // InstanceInfo holds a machine tag, provider-specific instance id, a
@@ -455,6 +484,18 @@ type SetMachinesAddresses struct {
 	MachineAddresses []MachineAddresses
 }
 
+// MachineUtilization holds a utilization sample for a machine.
+type MachineUtilization struct {
+	Tag    string
+	Sample UtilizationSample
+}
+
+// RecordMachinesUtilization holds the parameters for making a
+// RecordUtilization call.
+type RecordMachinesUtilization struct {
+	Machines []MachineUtilization
+}
+
 // ConstraintsResult holds machine constraints or an error.
 type ConstraintsResult struct {
 	Error       *Error
@@ -625,11 +666,14 @@ type RunParams struct {
 
 // RunResult contains the result from an individual run call on a machine.
 // UnitId is populated if the command was run inside the unit context.
+// ServiceName is populated only when a service target could not be
+// expanded into its units.
 type RunResult struct {
 	exec.ExecResponse
-	MachineId string
-	UnitId    string
-	Error     string
+	MachineId   string
+	UnitId      string
+	ServiceName string
+	Error       string
 }
 
 // RunResults is used to return the slice of results.  API server side calls
@@ -651,6 +695,7 @@ type ProvisioningInfo struct {
 	Placement   string
 	Networks    []string
 	Jobs        []MachineJob
+	Maintenance bool
 }
 
 // ProvisioningInfoResult holds machine provisioning info or an error.
@@ -158,6 +158,13 @@ type ServiceDeploy struct {
 	Constraints   constraints.Value
 	ToMachineSpec string
 	Networks      []string
+
+	// ClientHost and Bundle are recorded as part of the
+	// service's deployment record. ClientHost names the
+	// host the client runs on; Bundle names the bundle the
+	// service is deployed as part of, if any.
+	ClientHost string
+	Bundle     string
 }
 
 // ServiceUpdate holds the parameters for making the ServiceUpdate call.
@@ -179,8 +186,11 @@ type ServiceSetCharm struct {
 }
 
 // ServiceExpose holds the parameters for making the ServiceExpose call.
+// If Ports is empty, all the ports opened by the service's units
+// are exposed.
 type ServiceExpose struct {
 	ServiceName string
+	Ports       []network.Port
 }
 
 // ServiceSet holds the parameters for a ServiceSet
@@ -231,8 +241,10 @@ type ServiceCharmRelationsResults struct {
 }
 
 // ServiceUnexpose holds parameters for the ServiceUnexpose call.
+// If Ports is empty, the service is unexposed entirely.
 type ServiceUnexpose struct {
 	ServiceName string
+	Ports       []network.Port
 }
 
 // PublicAddress holds parameters for the PublicAddress call.
@@ -333,6 +345,11 @@ type SetConstraints struct {
 // CharmInfo stores parameters for a CharmInfo call.
 type CharmInfo struct {
 	CharmURL string
+
+	// Validator, if set, holds the validator returned by an earlier
+	// CharmInfo call. If the charm has not changed since, the
+	// result will have NotModified set and no other details.
+	Validator string
 }
 
 // ResolveCharms stores charm references for a ResolveCharms call.
@@ -488,6 +505,29 @@ type StateServingInfo struct {
 	// this will be passed as the KeyFile argument to MongoDB
 	SharedSecret   string
 	SystemIdentity string
+
+	// Unknown holds the fields added by newer versions of juju,
+	// which are kept when the value is encoded again.
+	Unknown UnknownFields `json:"-" bson:",inline"`
+}
+
+// stateServingInfo has the fields of StateServingInfo
+// without its methods.
+type stateServingInfo StateServingInfo
+
+// MarshalJSON implements json.Marshaler.
+func (info StateServingInfo) MarshalJSON() ([]byte, error) {
+	return marshalWithUnknown(stateServingInfo(info), info.Unknown)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (info *StateServingInfo) UnmarshalJSON(data []byte) error {
+	unknown, err := unmarshalKeepingUnknown(data, (*stateServingInfo)(info))
+	if err != nil {
+		return err
+	}
+	info.Unknown = unknown
+	return nil
 }
 
 // IsMasterResult holds the result of an IsMaster API call.
@@ -612,6 +652,7 @@ type ContainerConfig struct {
 	Proxy                   proxy.Settings
 	AptProxy                proxy.Settings
 	PreferIPv6              bool
+	ContainerNetworkType    string
 }
 
 // ProvisioningScriptParams contains the parameters for the
@@ -669,6 +710,11 @@ type DeployerConnectionValues struct {
 // StatusParams holds parameters for the Status call.
 type StatusParams struct {
 	Patterns []string
+
+	// Validator, if set, holds the validator returned by an earlier
+	// FullStatus call. If the status has not changed since, the
+	// result will have NotModified set and no other details.
+	Validator string
 }
 
 // SetRsyslogCertParams holds parameters for the SetRsyslogCert call.
@@ -768,3 +814,114 @@ type StateServersChanges struct {
 	Promoted   []string `json:promoted,omitempty`
 	Demoted    []string `json:demoted,omitempty`
 }
+
+// UtilizationSample holds a single sample of the resources
+// in use on a machine.
+type UtilizationSample struct {
+	Time       time.Time
+	CPUPercent float64
+	MemUsed    uint64
+	MemTotal   uint64
+	DiskUsed   uint64
+	DiskTotal  uint64
+}
+
+// MachineUtilizationResult holds the utilization samples
+// of a single machine, or an error.
+type MachineUtilizationResult struct {
+	Samples []UtilizationSample
+	Error   *Error
+}
+
+// MachineUtilizationResults holds the results of a
+// MachineUtilization API call.
+type MachineUtilizationResults struct {
+	Results []MachineUtilizationResult
+}
+
+// InterfaceSchema describes the relation settings expected
+// of units related over the named interface.
+type InterfaceSchema struct {
+	Interface string
+	Fields    map[string]string
+	Strict    bool
+}
+
+// InterfaceSchemaName identifies a relation interface in
+// the GetInterfaceSchema and RemoveInterfaceSchema calls.
+type InterfaceSchemaName struct {
+	Interface string
+}
+
+// BlockSwitchParams holds the arguments for the SwitchBlockOn
+// and SwitchBlockOff calls.
+type BlockSwitchParams struct {
+	// Type is the kind of block: "destroy-environment",
+	// "remove-object" or "all-changes".
+	Type string
+
+	// Message explains why the block is set. It is ignored
+	// by SwitchBlockOff.
+	Message string
+}
+
+// BlockResult describes a block set in the environment.
+type BlockResult struct {
+	Type    string
+	Message string
+}
+
+// BlockResults holds the result of a ListBlocks call.
+type BlockResults struct {
+	Results []BlockResult
+}
+
+// FindStatusParams holds the parameters for a FindStatus call.
+type FindStatusParams struct {
+	// Text holds the text searched for in the status history
+	// of machines and units, ignoring case.
+	Text string
+
+	// Since, if not nil, restricts the search to the
+	// statuses set after that time.
+	Since *time.Time
+}
+
+// StatusHistoryEntry records a status set on a machine or unit.
+type StatusHistoryEntry struct {
+	Entity string
+	Status Status
+	Info   string
+	Time   time.Time
+}
+
+// FindStatusResults holds the results of a FindStatus call,
+// newest first.
+type FindStatusResults struct {
+	Results []StatusHistoryEntry
+}
+
+// APIActivity holds the activity of the API server
+// answering an APIActivity call.
+type APIActivity struct {
+	// Calls holds the number of API requests
+	// served since the API server started.
+	Calls int64
+
+	// Connections holds the number of open API connections.
+	Connections int64
+}
+
+// UnusedMachine describes a machine hosting no units and no containers.
+type UnusedMachine struct {
+	Id string
+
+	// DateCreated holds when the machine was added, or
+	// nil if this was not recorded.
+	DateCreated *time.Time
+}
+
+// UnusedMachinesResults holds the results of an UnusedMachines call.
+type UnusedMachinesResults struct {
+	Machines []UnusedMachine
+}
@@ -165,3 +165,46 @@ func (s *MarshalSuite) TestDeltaMarshalJSONUnknownEntity(c *gc.C) {
 	err := json.Unmarshal([]byte(`["qwan","change",{}]`), new(params.Delta))
 	c.Check(err, gc.ErrorMatches, `Unexpected entity name "qwan"`)
 }
+
+func (s *MarshalSuite) TestStateServingInfoKeepsUnknownFields(c *gc.C) {
+	data := []byte(`{"APIPort":17070,"statePort":37017,"Cert":"cert","NewField":{"a":[1,2]},"Other":"x"}`)
+	var info params.StateServingInfo
+	err := json.Unmarshal(data, &info)
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.APIPort, gc.Equals, 17070)
+	c.Assert(info.StatePort, gc.Equals, 37017)
+	c.Assert(info.Cert, gc.Equals, "cert")
+	c.Assert(info.Unknown, gc.DeepEquals, params.UnknownFields{
+		"NewField": map[string]interface{}{"a": []interface{}{1.0, 2.0}},
+		"Other":    "x",
+	})
+
+	info.Cert = "new cert"
+	data, err = json.Marshal(info)
+	c.Assert(err, gc.IsNil)
+	var fields map[string]interface{}
+	err = json.Unmarshal(data, &fields)
+	c.Assert(err, gc.IsNil)
+	c.Assert(fields, gc.DeepEquals, map[string]interface{}{
+		"APIPort":        17070.0,
+		"StatePort":      37017.0,
+		"Cert":           "new cert",
+		"PrivateKey":     "",
+		"SharedSecret":   "",
+		"SystemIdentity": "",
+		"NewField":       map[string]interface{}{"a": []interface{}{1.0, 2.0}},
+		"Other":          "x",
+	})
+}
+
+func (s *MarshalSuite) TestStateServingInfoWithoutUnknownFields(c *gc.C) {
+	info := params.StateServingInfo{APIPort: 1, StatePort: 2, Cert: "cert"}
+	data, err := json.Marshal(info)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(data), gc.Equals,
+		`{"APIPort":1,"StatePort":2,"Cert":"cert","PrivateKey":"","SharedSecret":"","SystemIdentity":""}`)
+	var decoded params.StateServingInfo
+	err = json.Unmarshal(data, &decoded)
+	c.Assert(err, gc.IsNil)
+	c.Assert(decoded, gc.DeepEquals, info)
+}
@@ -0,0 +1,114 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package params
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// UnknownFields holds the fields of a JSON object or BSON document
+// that are not known to the type it was decoded into. Types that keep
+// them can be passed through older versions of juju, during the upgrade
+// of an environment, without losing the fields added by newer versions.
+// Embedded with the ",inline" bson tag, it keeps the unknown fields of
+// BSON documents too.
+type UnknownFields map[string]interface{}
+
+// unmarshalKeepingUnknown decodes data into v, which must be a pointer
+// to a struct, and returns the fields of the object in data that do not
+// correspond to any field of v.
+func unmarshalKeepingUnknown(data []byte, v interface{}) (UnknownFields, error) {
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	known := jsonFieldNames(reflect.TypeOf(v).Elem())
+	var unknown UnknownFields
+	for name, value := range fields {
+		if isKnownField(known, name) {
+			continue
+		}
+		if unknown == nil {
+			unknown = make(UnknownFields)
+		}
+		unknown[name] = value
+	}
+	return unknown, nil
+}
+
+// marshalWithUnknown encodes v, which must encode as a JSON object,
+// adding the given unknown fields to it.
+func marshalWithUnknown(v interface{}, unknown UnknownFields) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(unknown) == 0 {
+		return data, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool)
+	for name := range fields {
+		known[name] = true
+	}
+	for name, value := range unknown {
+		if !isKnownField(known, name) {
+			raw, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			fields[name] = raw
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// jsonFieldNames returns the names of the JSON object
+// fields that correspond to the fields of the given struct.
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Name
+		if tag := field.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if tagName := strings.Split(tag, ",")[0]; tagName != "" {
+				name = tagName
+			}
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+			for name := range jsonFieldNames(field.Type) {
+				names[name] = true
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			// Unexported fields are not encoded.
+			continue
+		}
+		names[name] = true
+	}
+	return names
+}
+
+// isKnownField reports whether the given name matches one of the
+// known names, ignoring case as encoding/json does when decoding.
+func isKnownField(known map[string]bool, name string) bool {
+	if known[name] {
+		return true
+	}
+	for knownName := range known {
+		if strings.EqualFold(knownName, name) {
+			return true
+		}
+	}
+	return false
+}
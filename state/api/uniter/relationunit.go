@@ -103,6 +103,25 @@ func (ru *RelationUnit) LeaveScope() error {
 	return result.OneError()
 }
 
+// ValidateSettings returns an error if the given changes to the unit's
+// settings within the relation do not conform to the schema registered
+// for the relation's interface. The settings are left unchanged.
+func (ru *RelationUnit) ValidateSettings(settings params.RelationSettings) error {
+	var result params.ErrorResults
+	args := params.RelationUnitsSettings{
+		RelationUnits: []params.RelationUnitSettings{{
+			Relation: ru.relation.tag.String(),
+			Unit:     ru.unit.tag.String(),
+			Settings: settings,
+		}},
+	}
+	err := ru.st.call("ValidateSettings", args, &result)
+	if err != nil {
+		return err
+	}
+	return result.OneError()
+}
+
 // Settings returns a Settings which allows access to the unit's settings
 // within the relation.
 func (ru *RelationUnit) Settings() (*Settings, error) {
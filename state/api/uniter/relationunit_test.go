@@ -9,6 +9,7 @@ import (
 	jc "github.com/juju/testing/checkers"
 	gc "launchpad.net/gocheck"
 
+	"github.com/juju/juju/interfaceschema"
 	"github.com/juju/juju/network"
 	"github.com/juju/juju/state"
 	"github.com/juju/juju/state/api/params"
@@ -205,6 +206,26 @@ func (s *relationUnitSuite) TestSettings(c *gc.C) {
 	})
 }
 
+func (s *relationUnitSuite) TestValidateSettings(c *gc.C) {
+	wpRelUnit, apiRelUnit := s.getRelationUnits(c)
+	err := wpRelUnit.EnterScope(nil)
+	c.Assert(err, gc.IsNil)
+
+	// Without a registered schema, any settings are valid.
+	err = apiRelUnit.ValidateSettings(params.RelationSettings{"port": "mysql"})
+	c.Assert(err, gc.IsNil)
+
+	err = s.State.SetInterfaceSchema(interfaceschema.Schema{
+		Interface: "mysql",
+		Fields:    map[string]interfaceschema.FieldType{"port": interfaceschema.Int},
+	})
+	c.Assert(err, gc.IsNil)
+	err = apiRelUnit.ValidateSettings(params.RelationSettings{"port": "3306"})
+	c.Assert(err, gc.IsNil)
+	err = apiRelUnit.ValidateSettings(params.RelationSettings{"port": "mysql"})
+	c.Assert(err, gc.ErrorMatches, `setting "port" of interface "mysql" must be of type int, got "mysql"`)
+}
+
 func (s *relationUnitSuite) TestReadSettings(c *gc.C) {
 	// First try to read the settings which are not set.
 	myRelUnit, err := s.stateRelation.Unit(s.mysqlUnit)
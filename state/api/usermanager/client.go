@@ -89,3 +89,19 @@ func (c *Client) SetPassword(username, password string) error {
 	}
 	return results.OneError()
 }
+
+// SetAccess sets the access the named user is granted
+// to the environment, either "admin" or "read".
+func (c *Client) SetAccess(username, access string) error {
+	userArgs := usermanager.ModifyUsers{
+		Changes: []usermanager.ModifyUser{{
+			Username: username,
+			Access:   access}},
+	}
+	results := new(params.ErrorResults)
+	err := call(c.st, "SetAccess", userArgs, results)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return results.OneError()
+}
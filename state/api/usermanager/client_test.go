@@ -90,6 +90,7 @@ func (s *usermanagerSuite) TestUserInfo(c *gc.C) {
 			DisplayName: "Foo Bar",
 			CreatedBy:   "admin",
 			DateCreated: user.DateCreated(),
+			Access:      "admin",
 		},
 	}
 
@@ -124,3 +125,14 @@ func (s *usermanagerSuite) TestSetUserPassword(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	c.Assert(user.PasswordValid("new-password"), gc.Equals, true)
 }
+
+func (s *usermanagerSuite) TestSetAccess(c *gc.C) {
+	s.Factory.MakeUser(factory.UserParams{Username: "foobar"})
+	err := s.usermanager.SetAccess("foobar", "read")
+	c.Assert(err, gc.IsNil)
+	user, err := s.State.User("foobar")
+	c.Assert(err, gc.IsNil)
+	access, err := user.EnvironmentAccess()
+	c.Assert(err, gc.IsNil)
+	c.Assert(access, gc.Equals, state.EnvironmentReadAccess)
+}
@@ -4,6 +4,7 @@
 package apiserver
 
 import (
+	"strings"
 	"sync"
 	"time"
 
@@ -19,11 +20,12 @@ import (
 	"github.com/juju/juju/state/presence"
 )
 
-func newStateServer(srv *Server, rpcConn *rpc.Conn, reqNotifier *requestNotifier, limiter utils.Limiter) *initialRoot {
+func newStateServer(srv *Server, st *state.State, rpcConn *rpc.Conn, reqNotifier *requestNotifier, limiter utils.Limiter) *initialRoot {
 	r := &initialRoot{
 		srv:     srv,
 		rpcConn: rpcConn,
 	}
+	r.setState(st)
 	r.admin = &srvAdmin{
 		root:        r,
 		limiter:     limiter,
@@ -37,12 +39,36 @@ func newStateServer(srv *Server, rpcConn *rpc.Conn, reqNotifier *requestNotifier
 // when connecting to the API. We start serving a different
 // API once the user has logged in.
 type initialRoot struct {
-	srv     *Server
+	srv *Server
+	// state holds the state of the environment
+	// the client connected to.
+	state   *state.State
+	opened  []*state.State
 	rpcConn *rpc.Conn
 
 	admin *srvAdmin
 }
 
+// setState makes the root serve the given state. States other than
+// the server's own were opened for the connection, and are closed
+// with it.
+func (r *initialRoot) setState(st *state.State) {
+	if st != r.srv.state {
+		r.opened = append(r.opened, st)
+	}
+	r.state = st
+}
+
+// closeStates closes the states opened for the connection.
+func (r *initialRoot) closeStates() {
+	for _, st := range r.opened {
+		if err := st.Close(); err != nil {
+			logger.Errorf("error closing state for connection: %v", err)
+		}
+	}
+	r.opened = nil
+}
+
 // Admin returns an object that provides API access
 // to methods that can be called even when not
 // authenticated.
@@ -100,18 +126,30 @@ func (a *srvAdmin) Login(c params.Creds) (params.LoginResult, error) {
 		}
 		defer a.limiter.Release()
 	}
-	entity, err := doCheckCreds(a.root.srv.state, c)
+	entity, access, err := doCheckCreds(a.root.state, c, inUpgrade)
 	if err != nil {
 		return params.LoginResult{}, err
 	}
 	if a.reqNotifier != nil {
 		a.reqNotifier.login(entity.Tag().String())
 	}
+	if tag, ok := entity.Tag().(names.UserTag); ok {
+		// Changes made by users are recorded in the audit
+		// trail, so serve them with a state acting on their
+		// behalf.
+		userSt, err := a.root.state.ForUser(tag)
+		if err != nil {
+			return params.LoginResult{}, errors.Trace(err)
+		}
+		a.root.setState(userSt)
+	}
 	// We have authenticated the user; now choose an appropriate API
 	// to serve to them.
 	var newRoot apiRoot
 	if inUpgrade {
 		newRoot = newUpgradingRoot(a.root, entity)
+	} else if access == authentication.ReadOnlyAccess {
+		newRoot = newReadOnlyRoot(a.root, entity)
 	} else {
 		newRoot = newSrvRoot(a.root, entity)
 	}
@@ -120,13 +158,13 @@ func (a *srvAdmin) Login(c params.Creds) (params.LoginResult, error) {
 	}
 
 	// Fetch the API server addresses from state.
-	hostPorts, err := a.root.srv.state.APIHostPorts()
+	hostPorts, err := a.root.state.APIHostPorts()
 	if err != nil {
 		return params.LoginResult{}, err
 	}
 	logger.Debugf("hostPorts: %v", hostPorts)
 
-	environ, err := a.root.srv.state.Environment()
+	environ, err := a.root.state.Environment()
 	if err != nil {
 		return params.LoginResult{}, err
 	}
@@ -141,9 +179,66 @@ func (a *srvAdmin) Login(c params.Creds) (params.LoginResult, error) {
 	}, nil
 }
 
-var doCheckCreds = checkCreds
+var (
+	doCheckCreds   = checkCreds
+	newUserBackend = authentication.NewUserBackend
+)
+
+// checkCreds returns the entity identified by the given credentials,
+// and the access granted to it. Users whose names carry the
+// authentication.LDAPUserPrefix are authenticated against the
+// environment's user backend, if one is configured; all other entities
+// are only ever authenticated against state, and users are granted the
+// access recorded for them in the environment.
+func checkCreds(st *state.State, c params.Creds, inUpgrade bool) (state.Entity, authentication.Access, error) {
+	if tag, err := names.ParseTag(c.AuthTag); err == nil && tag.Kind() == names.UserTagKind {
+		if strings.HasPrefix(tag.Id(), authentication.LDAPUserPrefix) {
+			return checkBackendCreds(st, tag.Id(), c.Password)
+		}
+	}
+	entity, err := checkStateCreds(st, c)
+	if err != nil {
+		return nil, "", err
+	}
+	access, err := entityAccess(entity, inUpgrade)
+	if err != nil {
+		return nil, "", err
+	}
+	return entity, access, nil
+}
+
+// entityAccess returns the access granted to the authenticated
+// entity. Agents are granted admin access; users are granted the
+// access recorded for them in the environment, and may not log in
+// to environments they have not been granted access to.
+//
+// While the environment is being upgraded, the access of existing
+// users may not be recorded yet. Until it is, they keep the admin
+// access all users had before access was recorded per environment.
+func entityAccess(entity state.Entity, inUpgrade bool) (authentication.Access, error) {
+	user, ok := entity.(*state.User)
+	if !ok {
+		return authentication.AdminAccess, nil
+	}
+	access, err := user.EnvironmentAccess()
+	if errors.IsNotFound(err) {
+		if inUpgrade {
+			return authentication.AdminAccess, nil
+		}
+		return "", common.ErrPerm
+	} else if err != nil {
+		return "", errors.Trace(err)
+	}
+	switch access {
+	case state.EnvironmentAdminAccess:
+		return authentication.AdminAccess, nil
+	case state.EnvironmentReadAccess:
+		return authentication.ReadOnlyAccess, nil
+	}
+	return "", errors.Errorf("unknown environment access %q", access)
+}
 
-func checkCreds(st *state.State, c params.Creds) (state.Entity, error) {
+func checkStateCreds(st *state.State, c params.Creds) (state.Entity, error) {
 	entity, err := st.FindEntity(c.AuthTag)
 	if errors.IsNotFound(err) {
 		// We return the same error when an entity does not exist as for a bad
@@ -167,6 +262,50 @@ func checkCreds(st *state.State, c params.Creds) (state.Entity, error) {
 	return entity, nil
 }
 
+// checkBackendCreds authenticates the named user, whose name carries
+// the authentication.LDAPUserPrefix, against the environment's user
+// backend. Users logging in for the first time are added to state,
+// with a random password, so that they can be listed and deactivated
+// like any other user.
+func checkBackendCreds(st *state.State, name, password string) (state.Entity, authentication.Access, error) {
+	envConfig, err := st.EnvironConfig()
+	if err != nil {
+		return nil, "", errors.Trace(err)
+	}
+	backend := newUserBackend(envConfig)
+	if backend == nil {
+		return nil, "", common.ErrBadCreds
+	}
+	access, err := backend.AuthenticateUser(strings.TrimPrefix(name, authentication.LDAPUserPrefix), password)
+	if err != nil {
+		return nil, "", err
+	}
+	user, err := st.User(name)
+	if errors.IsNotFound(err) {
+		var randomPassword string
+		if randomPassword, err = utils.RandomPassword(); err != nil {
+			return nil, "", errors.Trace(err)
+		}
+		user, err = st.AddUserWithAccess(name, "", randomPassword, "", stateAccess(access))
+	}
+	if err != nil {
+		return nil, "", errors.Trace(err)
+	}
+	if user.IsDeactivated() {
+		return nil, "", common.ErrBadCreds
+	}
+	return user, access, nil
+}
+
+// stateAccess returns the environment access
+// recorded in state for the given access.
+func stateAccess(access authentication.Access) state.EnvironmentAccess {
+	if access == authentication.AdminAccess {
+		return state.EnvironmentAdminAccess
+	}
+	return state.EnvironmentReadAccess
+}
+
 func getAndUpdateLastConnectionForEntity(entity state.Entity) *time.Time {
 	if user, ok := entity.(*state.User); ok {
 		result := user.LastConnection()
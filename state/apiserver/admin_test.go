@@ -16,12 +16,15 @@ import (
 	"github.com/juju/utils"
 	gc "launchpad.net/gocheck"
 
+	"github.com/juju/juju/environs/config"
 	jujutesting "github.com/juju/juju/juju/testing"
 	"github.com/juju/juju/network"
 	"github.com/juju/juju/state"
 	"github.com/juju/juju/state/api"
 	"github.com/juju/juju/state/api/params"
 	"github.com/juju/juju/state/apiserver"
+	"github.com/juju/juju/state/apiserver/authentication"
+	"github.com/juju/juju/state/apiserver/common"
 	coretesting "github.com/juju/juju/testing"
 	"github.com/juju/juju/testing/factory"
 )
@@ -130,6 +133,168 @@ func (s *loginSuite) TestLoginAsDeactivatedUser(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, `unknown object type "Client"`)
 }
 
+func (s *loginSuite) loginAsStateUser(c *gc.C, access state.EnvironmentAccess) (*api.State, error) {
+	info, cleanup := s.setupServer(c)
+	s.AddCleanup(func(*gc.C) { cleanup() })
+	info.Tag = nil
+	info.Password = ""
+	st, err := api.Open(info, fastDialOpts)
+	c.Assert(err, gc.IsNil)
+	s.AddCleanup(func(*gc.C) { st.Close() })
+	u := s.Factory.MakeUser(factory.UserParams{Password: "password", Access: access})
+	return st, st.Login(u.Tag().String(), "password", "")
+}
+
+func (s *loginSuite) TestLoginWithReadAccess(c *gc.C) {
+	st, err := s.loginAsStateUser(c, state.EnvironmentReadAccess)
+	c.Assert(err, gc.IsNil)
+
+	var statusResult api.Status
+	err = st.Call("Client", "", "FullStatus", params.StatusParams{}, &statusResult)
+	c.Assert(err, gc.IsNil)
+
+	err = st.Call("Client", "", "DestroyEnvironment", nil, nil)
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+}
+
+func (s *loginSuite) TestLoginWithAdminAccess(c *gc.C) {
+	st, err := s.loginAsStateUser(c, state.EnvironmentAdminAccess)
+	c.Assert(err, gc.IsNil)
+
+	err = st.Call("Client", "", "DestroyEnvironment", nil, nil)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *loginSuite) TestUserChangesAudited(c *gc.C) {
+	st, err := s.loginAsStateUser(c, state.EnvironmentAdminAccess)
+	c.Assert(err, gc.IsNil)
+	machines, err := st.Client().AddMachines([]params.AddMachineParams{{
+		Series: "quantal",
+		Jobs:   []params.MachineJob{params.JobHostUnits},
+	}})
+	c.Assert(err, gc.IsNil)
+	c.Assert(machines, gc.HasLen, 1)
+	c.Assert(machines[0].Error, gc.IsNil)
+
+	entries, err := s.State.AuditTrail(state.AuditQuery{
+		Collection: "machines",
+		Id:         machines[0].Machine,
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(entries, gc.HasLen, 1)
+	c.Assert(entries[0].Who, gc.Matches, "user-.*")
+}
+
+func (s *loginSuite) TestLoginWithoutAccess(c *gc.C) {
+	info, cleanup := s.setupServer(c)
+	defer cleanup()
+	info.Tag = nil
+	info.Password = ""
+	st, err := api.Open(info, fastDialOpts)
+	c.Assert(err, gc.IsNil)
+	defer st.Close()
+	u := s.Factory.MakeUser(factory.UserParams{Password: "password"})
+	err = u.RevokeEnvironmentAccess()
+	c.Assert(err, gc.IsNil)
+
+	err = st.Login(u.Tag().String(), "password", "")
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+	c.Assert(params.ErrCode(err), gc.Equals, params.CodeUnauthorized)
+}
+
+// fakeUserBackend grants the access it holds to users
+// whose password is "sekrit".
+type fakeUserBackend struct {
+	access authentication.Access
+}
+
+func (b fakeUserBackend) AuthenticateUser(name, password string) (authentication.Access, error) {
+	if password != "sekrit" {
+		return "", common.ErrBadCreds
+	}
+	return b.access, nil
+}
+
+func (s *loginSuite) loginWithUserBackend(c *gc.C, access authentication.Access, tag, password string) (*api.State, error) {
+	s.PatchValue(apiserver.NewUserBackend, func(*config.Config) authentication.UserBackend {
+		return fakeUserBackend{access}
+	})
+	info, cleanup := s.setupServer(c)
+	s.AddCleanup(func(*gc.C) { cleanup() })
+	st, err := api.Open(info, fastDialOpts)
+	c.Assert(err, gc.IsNil)
+	s.AddCleanup(func(*gc.C) { st.Close() })
+	return st, st.Login(tag, password, "")
+}
+
+func (s *loginSuite) TestLoginWithUserBackendAddsUser(c *gc.C) {
+	st, err := s.loginWithUserBackend(c, authentication.AdminAccess, "user-ldap+bob", "sekrit")
+	c.Assert(err, gc.IsNil)
+	user, err := s.State.User("ldap+bob")
+	c.Assert(err, gc.IsNil)
+	c.Assert(user.PasswordValid("sekrit"), jc.IsFalse)
+	access, err := user.EnvironmentAccess()
+	c.Assert(err, gc.IsNil)
+	c.Assert(access, gc.Equals, state.EnvironmentAdminAccess)
+
+	err = st.Call("Client", "", "DestroyEnvironment", nil, nil)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *loginSuite) TestLoginWithUserBackendReadOnly(c *gc.C) {
+	st, err := s.loginWithUserBackend(c, authentication.ReadOnlyAccess, "user-ldap+bob", "sekrit")
+	c.Assert(err, gc.IsNil)
+
+	var statusResult api.Status
+	err = st.Call("Client", "", "FullStatus", params.StatusParams{}, &statusResult)
+	c.Assert(err, gc.IsNil)
+
+	err = st.Call("Client", "", "DestroyEnvironment", nil, nil)
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+}
+
+func (s *loginSuite) TestLoginWithUserBackendBadPassword(c *gc.C) {
+	_, err := s.loginWithUserBackend(c, authentication.AdminAccess, "user-ldap+bob", "wrong")
+	c.Assert(err, gc.ErrorMatches, "invalid entity name or password")
+	_, err = s.State.User("ldap+bob")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *loginSuite) TestLoginWithUserBackendPrefersStateUsers(c *gc.C) {
+	_, err := s.loginWithUserBackend(c, authentication.ReadOnlyAccess, "user-admin", "dummy-secret")
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *loginSuite) TestLoginWithUserBackendNeverAsStateUser(c *gc.C) {
+	// The backend accepts "sekrit" for any user, but the local
+	// admin is only ever authenticated against state.
+	_, err := s.loginWithUserBackend(c, authentication.AdminAccess, "user-admin", "sekrit")
+	c.Assert(err, gc.ErrorMatches, "invalid entity name or password")
+}
+
+func (s *loginSuite) TestLoginWithUserBackendNotConfigured(c *gc.C) {
+	info, cleanup := s.setupServer(c)
+	defer cleanup()
+	st, err := api.Open(info, fastDialOpts)
+	c.Assert(err, gc.IsNil)
+	defer st.Close()
+	err = st.Login("user-ldap+bob", "sekrit", "")
+	c.Assert(err, gc.ErrorMatches, "invalid entity name or password")
+}
+
+func (s *loginSuite) TestLoginWithUserBackendAsDeactivatedUser(c *gc.C) {
+	u := s.Factory.MakeUser(factory.UserParams{Username: "ldap+bob", Password: "password"})
+	err := u.Deactivate()
+	c.Assert(err, gc.IsNil)
+	_, err = s.loginWithUserBackend(c, authentication.AdminAccess, "user-ldap+bob", "sekrit")
+	c.Assert(err, gc.ErrorMatches, "invalid entity name or password")
+}
+
+func (s *loginSuite) TestLoginWithUserBackendNotForAgents(c *gc.C) {
+	_, err := s.loginWithUserBackend(c, authentication.AdminAccess, "machine-0", "sekrit")
+	c.Assert(err, gc.ErrorMatches, "invalid entity name or password")
+}
+
 func (s *loginSuite) TestLoginSetsLogIdentifier(c *gc.C) {
 	info, cleanup := s.setupServer(c)
 	defer cleanup()
@@ -507,6 +672,30 @@ func (s *loginSuite) TestLoginValidationDuringUpgrade(c *gc.C) {
 	s.checkLoginWithValidator(c, validator, checker)
 }
 
+func (s *loginSuite) TestLoginWithoutRecordedAccessDuringUpgrade(c *gc.C) {
+	validator := func(params.Creds) error {
+		return apiserver.UpgradeInProgressError
+	}
+	info, cleanup := s.setupServerWithValidator(c, validator)
+	defer cleanup()
+	info.Tag = nil
+	info.Password = ""
+	st, err := api.Open(info, fastDialOpts)
+	c.Assert(err, gc.IsNil)
+	defer st.Close()
+	u := s.Factory.MakeUser(factory.UserParams{Password: "password"})
+	err = u.RevokeEnvironmentAccess()
+	c.Assert(err, gc.IsNil)
+
+	// The upgrade step recording the access of existing
+	// users may not have run yet.
+	err = st.Login(u.Tag().String(), "password", "")
+	c.Assert(err, gc.IsNil)
+	var statusResult api.Status
+	err = st.Call("Client", "", "FullStatus", params.StatusParams{}, &statusResult)
+	c.Assert(err, gc.IsNil)
+}
+
 type validationChecker func(c *gc.C, err error, st *api.State)
 
 func (s *loginSuite) checkLoginWithValidator(c *gc.C, validator apiserver.LoginValidator, checker validationChecker) {
@@ -14,7 +14,9 @@ import (
 
 	"code.google.com/p/go.net/websocket"
 	"github.com/bmizerany/pat"
+	"github.com/juju/errors"
 	"github.com/juju/loggo"
+	"github.com/juju/names"
 	"github.com/juju/utils"
 	"launchpad.net/tomb"
 
@@ -245,6 +247,7 @@ func (srv *Server) apiHandler(w http.ResponseWriter, req *http.Request) {
 	reqNotifier := newRequestNotifier()
 	reqNotifier.join(req)
 	defer reqNotifier.leave()
+	defer common.RecordAPIConnection()()
 	wsServer := websocket.Server{
 		Handler: func(conn *websocket.Conn) {
 			srv.wg.Add(1)
@@ -327,18 +330,45 @@ func (srv *Server) serveConn(wsConn *websocket.Conn, reqNotifier *requestNotifie
 		notifier = reqNotifier
 	}
 	conn := rpc.NewConn(codec, notifier)
-	err := srv.validateEnvironUUID(envUUID)
+	var root *initialRoot
+	st, err := srv.stateForEnviron(envUUID)
 	if err != nil {
 		conn.Serve(&errRoot{err}, serverError)
 	} else {
-		conn.Serve(newStateServer(srv, conn, reqNotifier, srv.limiter), serverError)
+		root = newStateServer(srv, st, conn, reqNotifier, srv.limiter)
+		conn.Serve(root, serverError)
 	}
 	conn.Start()
 	select {
 	case <-conn.Dead():
 	case <-srv.tomb.Dying():
 	}
-	return conn.Close()
+	err = conn.Close()
+	if root != nil {
+		root.closeStates()
+	}
+	return err
+}
+
+// stateForEnviron returns the state to serve a connection to the
+// environment with the given UUID. Connections to the state server's
+// environment are served with the server's own state; connections to
+// an environment hosted by the state server are served with a new
+// state for that environment, which the connection's root closes
+// once the connection is done with.
+func (srv *Server) stateForEnviron(envUUID string) (*state.State, error) {
+	err := srv.validateEnvironUUID(envUUID)
+	if err == nil {
+		return srv.state, nil
+	}
+	if !common.IsUnknownEnviromentError(err) {
+		return nil, err
+	}
+	st, openErr := srv.state.ForEnviron(names.NewEnvironTag(envUUID))
+	if errors.IsNotFound(openErr) {
+		return nil, err
+	}
+	return st, openErr
 }
 
 func (srv *Server) mongoPinger() error {
@@ -0,0 +1,6 @@
+// Copyright 2014 Canonical Ltd. All rights reserved.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package authentication
+
+var RunCommand = &runCommand
@@ -13,3 +13,31 @@ type EntityAuthenticator interface {
 	// Authenticate authenticates the given entity
 	Authenticate(entity state.Entity, password, nonce string) error
 }
+
+// Access describes what an authenticated user may do.
+type Access string
+
+const (
+	// AdminAccess grants full access to the environment.
+	AdminAccess Access = "admin"
+
+	// ReadOnlyAccess only grants access to the API calls
+	// that look at the environment without changing it.
+	ReadOnlyAccess Access = "read-only"
+)
+
+// LDAPUserPrefix prefixes the names of users authenticated against
+// an LDAP directory. Such users log in and are recorded in state under
+// the prefixed name, so that they can never be taken for users held
+// in state, such as "admin".
+const LDAPUserPrefix = "ldap+"
+
+// UserBackend is the interface implemented by external directories
+// of users, against which API users can be authenticated in
+// addition to the users held in state.
+type UserBackend interface {
+	// AuthenticateUser checks the password of the named user and
+	// returns the access granted to them. It returns
+	// common.ErrBadCreds if the password is not valid.
+	AuthenticateUser(name, password string) (Access, error)
+}
@@ -0,0 +1,139 @@
+// Copyright 2014 Canonical Ltd. All rights reserved.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package authentication
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/juju/loggo"
+	"github.com/juju/utils"
+
+	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/state/apiserver/common"
+)
+
+var logger = loggo.GetLogger("juju.state.apiserver.authentication")
+
+// runCommand runs the LDAP client commands;
+// it is a variable so that tests can replace it.
+var runCommand = utils.RunCommand
+
+// LDAPBackend authenticates users by binding to an LDAP server with
+// the ldap-utils client commands, and grants them access according
+// to the LDAP groups they are members of.
+type LDAPBackend struct {
+	// URL holds the URL of the LDAP server.
+	URL string
+
+	// UserDN holds the template of the distinguished name
+	// of users, in which %s is replaced by the user name.
+	UserDN string
+
+	// GroupBase holds the base distinguished name under which
+	// groups are searched. If it is empty, every user that
+	// binds successfully is granted admin access.
+	GroupBase string
+
+	// AdminGroup and ReadOnlyGroup hold the names of the groups
+	// whose members are granted admin and read-only access.
+	AdminGroup    string
+	ReadOnlyGroup string
+}
+
+var _ UserBackend = (*LDAPBackend)(nil)
+
+// NewUserBackend returns the user backend configured in the given
+// environment configuration, or nil if only the users held in
+// state are to be authenticated.
+func NewUserBackend(cfg *config.Config) UserBackend {
+	if cfg.AuthBackend() != config.AuthBackendLDAP {
+		return nil
+	}
+	return &LDAPBackend{
+		URL:           cfg.LDAPURL(),
+		UserDN:        cfg.LDAPUserDN(),
+		GroupBase:     cfg.LDAPGroupBase(),
+		AdminGroup:    cfg.LDAPAdminGroup(),
+		ReadOnlyGroup: cfg.LDAPReadOnlyGroup(),
+	}
+}
+
+// AuthenticateUser implements UserBackend.
+func (b *LDAPBackend) AuthenticateUser(name, password string) (Access, error) {
+	// An LDAP bind with an empty password is an anonymous
+	// bind, which succeeds whatever the user.
+	if password == "" {
+		return "", common.ErrBadCreds
+	}
+	dn := fmt.Sprintf(b.UserDN, name)
+	// The password is passed in a file so that it
+	// does not show in the list of processes.
+	passwordFile, err := writePasswordFile(password)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(passwordFile)
+
+	bindArgs := []string{"-x", "-H", b.URL, "-D", dn, "-y", passwordFile}
+	if out, err := runCommand("ldapwhoami", bindArgs...); err != nil {
+		logger.Debugf("cannot bind to %s as %q: %v: %s", b.URL, dn, err, out)
+		return "", common.ErrBadCreds
+	}
+	if b.GroupBase == "" {
+		return AdminAccess, nil
+	}
+	filter := fmt.Sprintf("(|(member=%s)(uniqueMember=%s)(memberUid=%s))", dn, dn, name)
+	searchArgs := append(bindArgs, "-LLL", "-b", b.GroupBase, filter, "cn")
+	out, err := runCommand("ldapsearch", searchArgs...)
+	if err != nil {
+		return "", fmt.Errorf("cannot find the LDAP groups of %q: %v", name, err)
+	}
+	groups := parseLDAPGroups(out)
+	switch {
+	case b.AdminGroup != "" && groups[b.AdminGroup]:
+		return AdminAccess, nil
+	case b.ReadOnlyGroup != "" && groups[b.ReadOnlyGroup]:
+		return ReadOnlyAccess, nil
+	}
+	return "", common.ErrPerm
+}
+
+// writePasswordFile writes the given password to a file only
+// readable by the current user and returns its name.
+func writePasswordFile(password string) (string, error) {
+	f, err := ioutil.TempFile("", "juju-ldap")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if err := f.Chmod(0600); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	// ldap-utils use the whole file as the password,
+	// so no newline is written.
+	if _, err := f.WriteString(password); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// parseLDAPGroups returns the names of the groups
+// listed in the LDIF output of ldapsearch.
+func parseLDAPGroups(ldif string) map[string]bool {
+	groups := make(map[string]bool)
+	scanner := bufio.NewScanner(strings.NewReader(ldif))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "cn: ") {
+			groups[strings.TrimSpace(line[len("cn: "):])] = true
+		}
+	}
+	return groups
+}
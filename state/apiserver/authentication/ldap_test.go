@@ -0,0 +1,143 @@
+// Copyright 2014 Canonical Ltd. All rights reserved.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package authentication_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/state/apiserver/authentication"
+	coretesting "github.com/juju/juju/testing"
+)
+
+type ldapBackendSuite struct {
+	coretesting.BaseSuite
+	backend   *authentication.LDAPBackend
+	calls     []string
+	passwords []string
+	groups    string
+}
+
+var _ = gc.Suite(&ldapBackendSuite{})
+
+const ldapGroups = `
+dn: cn=juju-viewers,ou=groups,dc=example,dc=com
+cn: juju-viewers
+
+dn: cn=developers,ou=groups,dc=example,dc=com
+cn: developers
+`
+
+func (s *ldapBackendSuite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	s.backend = &authentication.LDAPBackend{
+		URL:           "ldap://ldap.example.com",
+		UserDN:        "uid=%s,ou=people,dc=example,dc=com",
+		GroupBase:     "ou=groups,dc=example,dc=com",
+		AdminGroup:    "juju-admins",
+		ReadOnlyGroup: "juju-viewers",
+	}
+	s.calls = nil
+	s.passwords = nil
+	s.groups = ldapGroups
+	s.PatchValue(authentication.RunCommand, func(command string, args ...string) (string, error) {
+		s.calls = append(s.calls, command)
+		for i, arg := range args {
+			if arg == "-y" {
+				data, err := ioutil.ReadFile(args[i+1])
+				c.Assert(err, gc.IsNil)
+				s.passwords = append(s.passwords, string(data))
+			}
+		}
+		switch {
+		case s.passwords[len(s.passwords)-1] != "sekrit":
+			return "ldap_bind: Invalid credentials (49)", fmt.Errorf("exit status 49")
+		case command == "ldapsearch":
+			c.Assert(args[len(args)-2], gc.Equals, "(|"+
+				"(member=uid=bob,ou=people,dc=example,dc=com)"+
+				"(uniqueMember=uid=bob,ou=people,dc=example,dc=com)"+
+				"(memberUid=bob))")
+			return s.groups, nil
+		}
+		return "dn:uid=bob,ou=people,dc=example,dc=com", nil
+	})
+}
+
+func (s *ldapBackendSuite) TestReadOnlyAccess(c *gc.C) {
+	access, err := s.backend.AuthenticateUser("bob", "sekrit")
+	c.Assert(err, gc.IsNil)
+	c.Assert(access, gc.Equals, authentication.ReadOnlyAccess)
+	c.Assert(s.calls, gc.DeepEquals, []string{"ldapwhoami", "ldapsearch"})
+	c.Assert(s.passwords, gc.DeepEquals, []string{"sekrit", "sekrit"})
+}
+
+func (s *ldapBackendSuite) TestAdminAccess(c *gc.C) {
+	s.groups += "\ndn: cn=juju-admins,ou=groups,dc=example,dc=com\ncn: juju-admins\n"
+	access, err := s.backend.AuthenticateUser("bob", "sekrit")
+	c.Assert(err, gc.IsNil)
+	c.Assert(access, gc.Equals, authentication.AdminAccess)
+}
+
+func (s *ldapBackendSuite) TestNoGroupBase(c *gc.C) {
+	s.backend.GroupBase = ""
+	access, err := s.backend.AuthenticateUser("bob", "sekrit")
+	c.Assert(err, gc.IsNil)
+	c.Assert(access, gc.Equals, authentication.AdminAccess)
+	c.Assert(s.calls, gc.DeepEquals, []string{"ldapwhoami"})
+}
+
+func (s *ldapBackendSuite) TestNotInAnyGroup(c *gc.C) {
+	s.groups = "dn: cn=developers,ou=groups,dc=example,dc=com\ncn: developers\n"
+	_, err := s.backend.AuthenticateUser("bob", "sekrit")
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+}
+
+func (s *ldapBackendSuite) TestBadPassword(c *gc.C) {
+	_, err := s.backend.AuthenticateUser("bob", "wrong")
+	c.Assert(err, gc.ErrorMatches, "invalid entity name or password")
+	c.Assert(s.calls, gc.DeepEquals, []string{"ldapwhoami"})
+}
+
+func (s *ldapBackendSuite) TestEmptyPassword(c *gc.C) {
+	_, err := s.backend.AuthenticateUser("bob", "")
+	c.Assert(err, gc.ErrorMatches, "invalid entity name or password")
+	c.Assert(s.calls, gc.HasLen, 0)
+}
+
+func (s *ldapBackendSuite) TestPasswordFileRemoved(c *gc.C) {
+	var passwordFile string
+	s.PatchValue(authentication.RunCommand, func(command string, args ...string) (string, error) {
+		passwordFile = args[len(args)-1]
+		return "", nil
+	})
+	s.backend.GroupBase = ""
+	_, err := s.backend.AuthenticateUser("bob", "sekrit")
+	c.Assert(err, gc.IsNil)
+	_, err = os.Stat(passwordFile)
+	c.Assert(os.IsNotExist(err), jc.IsTrue)
+}
+
+func (s *ldapBackendSuite) TestNewUserBackend(c *gc.C) {
+	cfg, err := config.New(config.UseDefaults, coretesting.FakeConfig())
+	c.Assert(err, gc.IsNil)
+	c.Assert(authentication.NewUserBackend(cfg), gc.IsNil)
+
+	cfg, err = cfg.Apply(map[string]interface{}{
+		"auth-backend":     "ldap",
+		"ldap-url":         "ldap://ldap.example.com",
+		"ldap-user-dn":     "uid=%s,ou=people,dc=example,dc=com",
+		"ldap-admin-group": "juju-admins",
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(authentication.NewUserBackend(cfg), jc.DeepEquals, &authentication.LDAPBackend{
+		URL:        "ldap://ldap.example.com",
+		UserDN:     "uid=%s,ou=people,dc=example,dc=com",
+		AdminGroup: "juju-admins",
+	})
+}
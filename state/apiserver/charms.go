@@ -13,7 +13,6 @@ import (
 	"io/ioutil"
 	"mime"
 	"net/http"
-	"net/url"
 	"os"
 	"path"
 	"path/filepath"
@@ -27,6 +26,7 @@ import (
 
 	"github.com/juju/juju/environs"
 	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/state/apiserver/common"
 )
 
 // charmsHandler handles charm upload through HTTPS in the API server.
@@ -311,7 +311,8 @@ func (d byDepth) Less(i, j int) bool { return depth(d[i]) < depth(d[j]) }
 
 // repackageAndUploadCharm expands the given charm archive to a
 // temporary directoy, repackages it with the given curl's revision,
-// then uploads it to providr storage, and finally updates the state.
+// then uploads it to providr storage unless an identical archive is
+// already stored, and finally updates the state.
 func (h *charmsHandler) repackageAndUploadCharm(archive *charm.Bundle, curl *charm.URL) error {
 	// Create a temp dir to contain the extracted charm
 	// dir and the repackaged archive.
@@ -359,22 +360,17 @@ func (h *charmsHandler) repackageAndUploadCharm(archive *charm.Bundle, curl *cha
 	if err != nil {
 		return errors.Annotate(err, "cannot access provider storage")
 	}
-	name := charm.Quote(curl.String())
-	if err := storage.Put(name, repackagedArchive, size); err != nil {
-		return errors.Annotate(err, "cannot upload charm to provider storage")
-	}
-	storageURL, err := storage.URL(name)
-	if err != nil {
-		return errors.Annotate(err, "cannot get storage URL for charm")
-	}
-	bundleURL, err := url.Parse(storageURL)
+	bundleURL, err := common.StoreCharmArchive(h.state, storage, repackagedArchive, size, bundleSHA256)
 	if err != nil {
-		return errors.Annotate(err, "cannot parse storage URL")
+		return errors.Annotate(err, "cannot store uploaded charm")
 	}
 
 	// And finally, update state.
 	_, err = h.state.UpdateUploadedCharm(archive, curl, bundleURL, bundleSHA256)
 	if err != nil {
+		if err := common.ReleaseCharmArchive(h.state, storage, bundleSHA256); err != nil {
+			logger.Errorf("cannot release uploaded charm %q: %v", curl, err)
+		}
 		return errors.Annotate(err, "cannot update uploaded charm in state")
 	}
 	return nil
@@ -405,7 +401,7 @@ func (h *charmsHandler) processGet(r *http.Request) (string, string, error) {
 	// Check if the charm archive is already in the cache.
 	if _, err := os.Stat(charmArchivePath); os.IsNotExist(err) {
 		// Download the charm archive and save it to the cache.
-		if err = h.downloadCharm(name, charmArchivePath); err != nil {
+		if err = h.downloadCharm(curl, charmArchivePath); err != nil {
 			return "", "", fmt.Errorf("unable to retrieve and save the charm: %v", err)
 		}
 	} else if err != nil {
@@ -414,15 +410,23 @@ func (h *charmsHandler) processGet(r *http.Request) (string, string, error) {
 	return charmArchivePath, filePath, nil
 }
 
-// downloadCharm downloads the given charm name from the provider storage and
-// saves the corresponding zip archive to the given charmArchivePath.
-func (h *charmsHandler) downloadCharm(name, charmArchivePath string) error {
+// downloadCharm downloads the charm with the given URL from the provider
+// storage and saves the corresponding zip archive to the given
+// charmArchivePath.
+func (h *charmsHandler) downloadCharm(curl, charmArchivePath string) error {
 	// Get the provider storage.
 	storage, err := environs.GetStorage(h.state)
 	if err != nil {
 		return errors.Annotate(err, "cannot access provider storage")
 	}
 
+	// Uploaded archives are stored under their content hash; fall
+	// back to the charm URL for archives stored before that.
+	name, err := h.charmStorageName(curl)
+	if err != nil {
+		return err
+	}
+
 	// Use the storage to retrieve and save the charm archive.
 	reader, err := storage.Get(name)
 	if err != nil {
@@ -455,3 +459,26 @@ func (h *charmsHandler) downloadCharm(name, charmArchivePath string) error {
 	}
 	return nil
 }
+
+// charmStorageName returns the provider storage name of the archive
+// of the charm with the given URL.
+func (h *charmsHandler) charmStorageName(curl string) (string, error) {
+	name := charm.Quote(curl)
+	charmURL, err := charm.ParseURL(curl)
+	if err != nil {
+		return name, nil
+	}
+	sch, err := h.state.Charm(charmURL)
+	if errors.IsNotFound(err) {
+		return name, nil
+	} else if err != nil {
+		return "", errors.Annotate(err, "cannot get charm from state")
+	}
+	archive, err := h.state.CharmArchive(sch.BundleSha256())
+	if errors.IsNotFound(err) {
+		return name, nil
+	} else if err != nil {
+		return "", err
+	}
+	return archive.Path(), nil
+}
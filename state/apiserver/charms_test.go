@@ -220,7 +220,9 @@ func (s *charmsSuite) TestUploadRespectsLocalRevision(c *gc.C) {
 	// Finally, verify the SHA256 and uploaded URL.
 	expectedSHA256, _, err := utils.ReadSHA256(tempFile)
 	c.Assert(err, gc.IsNil)
-	name := charm.Quote(expectedURL.String())
+	archive, err := s.State.CharmArchive(expectedSHA256)
+	c.Assert(err, gc.IsNil)
+	name := archive.Path()
 	storage, err := environs.GetStorage(s.State)
 	c.Assert(err, gc.IsNil)
 	expectedUploadURL, err := storage.URL(name)
@@ -237,6 +239,35 @@ func (s *charmsSuite) TestUploadRespectsLocalRevision(c *gc.C) {
 	c.Assert(downloadedSHA256, gc.Equals, expectedSHA256)
 }
 
+func (s *charmsSuite) TestUploadSharesIdenticalArchives(c *gc.C) {
+	// Upload the same charm for two series; the repackaged archives
+	// are identical, so they should be stored only once.
+	ch := charmtesting.Charms.Bundle(c.MkDir(), "dummy")
+	var charms []*state.Charm
+	for _, series := range []string{"quantal", "precise"} {
+		resp, err := s.uploadRequest(c, s.charmsURI(c, "?series="+series), true, ch.Path)
+		c.Assert(err, gc.IsNil)
+		expectedURL := charm.MustParseURL("local:" + series + "/dummy-1")
+		s.assertUploadResponse(c, resp, expectedURL.String())
+		sch, err := s.State.Charm(expectedURL)
+		c.Assert(err, gc.IsNil)
+		charms = append(charms, sch)
+	}
+	c.Assert(charms[1].BundleSha256(), gc.Equals, charms[0].BundleSha256())
+	c.Assert(charms[1].BundleURL(), gc.DeepEquals, charms[0].BundleURL())
+
+	archive, err := s.State.CharmArchive(charms[0].BundleSha256())
+	c.Assert(err, gc.IsNil)
+	c.Assert(archive.Path(), gc.Matches, "charms/sha256-"+charms[0].BundleSha256()+"-[0-9]+")
+	c.Assert(archive.RefCount(), gc.Equals, 2)
+
+	storage, err := environs.GetStorage(s.State)
+	c.Assert(err, gc.IsNil)
+	stored, err := storage.List("charms/")
+	c.Assert(err, gc.IsNil)
+	c.Assert(stored, jc.DeepEquals, []string{archive.Path()})
+}
+
 func (s *charmsSuite) TestUploadAllowsTopLevelPath(c *gc.C) {
 	ch := charmtesting.Charms.Bundle(c.MkDir(), "dummy")
 	// Backwards compatibility check, that we can upload charms to
@@ -5,9 +5,9 @@ package client
 
 import (
 	"fmt"
-	"net/url"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/juju/charm"
 	"github.com/juju/errors"
@@ -20,6 +20,7 @@ import (
 	"github.com/juju/juju/environs/manual"
 	envtools "github.com/juju/juju/environs/tools"
 	"github.com/juju/juju/instance"
+	"github.com/juju/juju/interfaceschema"
 	"github.com/juju/juju/juju"
 	"github.com/juju/juju/network"
 	"github.com/juju/juju/state"
@@ -76,6 +77,9 @@ func (c *Client) WatchAll() (params.AllWatcherId, error) {
 // (Deprecated) Use NewServiceSetForClientAPI instead, to preserve values set to
 // an empty string, and use ServiceUnset to unset values.
 func (c *Client) ServiceSet(p params.ServiceSet) error {
+	if err := c.api.state.CheckChangeAllowed(); err != nil {
+		return err
+	}
 	svc, err := c.api.state.Service(p.ServiceName)
 	if err != nil {
 		return err
@@ -91,6 +95,9 @@ func (c *Client) ServiceSet(p params.ServiceSet) error {
 // TODO(Nate): rename this to ServiceSet (and remove the deprecated ServiceSet)
 // when the GUI handles the new behavior.
 func (c *Client) NewServiceSetForClientAPI(p params.ServiceSet) error {
+	if err := c.api.state.CheckChangeAllowed(); err != nil {
+		return err
+	}
 	svc, err := c.api.state.Service(p.ServiceName)
 	if err != nil {
 		return err
@@ -100,6 +107,9 @@ func (c *Client) NewServiceSetForClientAPI(p params.ServiceSet) error {
 
 // ServiceUnset implements the server side of Client.ServiceUnset.
 func (c *Client) ServiceUnset(p params.ServiceUnset) error {
+	if err := c.api.state.CheckChangeAllowed(); err != nil {
+		return err
+	}
 	svc, err := c.api.state.Service(p.ServiceName)
 	if err != nil {
 		return err
@@ -113,6 +123,9 @@ func (c *Client) ServiceUnset(p params.ServiceUnset) error {
 
 // ServiceSetYAML implements the server side of Client.ServerSetYAML.
 func (c *Client) ServiceSetYAML(p params.ServiceSetYAML) error {
+	if err := c.api.state.CheckChangeAllowed(); err != nil {
+		return err
+	}
 	svc, err := c.api.state.Service(p.ServiceName)
 	if err != nil {
 		return err
@@ -140,6 +153,9 @@ func (c *Client) ServiceCharmRelations(p params.ServiceCharmRelations) (params.S
 
 // Resolved implements the server side of Client.Resolved.
 func (c *Client) Resolved(p params.Resolved) error {
+	if err := c.api.state.CheckChangeAllowed(); err != nil {
+		return err
+	}
 	unit, err := c.api.state.Unit(p.UnitName)
 	if err != nil {
 		return err
@@ -205,22 +221,75 @@ func (c *Client) PrivateAddress(p params.PrivateAddress) (results params.Private
 
 // ServiceExpose changes the juju-managed firewall to expose any ports that
 // were also explicitly marked by units as open.
+//
+// If ports are given, only those ports are exposed. They are added to
+// the ports already exposed, unless all the service's ports are
+// currently exposed, in which case they replace them.
 func (c *Client) ServiceExpose(args params.ServiceExpose) error {
+	if err := c.api.state.CheckChangeAllowed(); err != nil {
+		return err
+	}
 	svc, err := c.api.state.Service(args.ServiceName)
 	if err != nil {
 		return err
 	}
-	return svc.SetExposed()
+	if len(args.Ports) == 0 {
+		return svc.SetExposed()
+	}
+	ports := args.Ports
+	if svc.IsExposed() {
+		ports = append(svc.ExposedPorts(), ports...)
+	}
+	return svc.SetExposedPorts(uniquePorts(ports))
 }
 
 // ServiceUnexpose changes the juju-managed firewall to unexpose any ports that
 // were also explicitly marked by units as open.
+//
+// If ports are given, only those ports are unexposed; the service is
+// unexposed entirely once none of its exposed ports remain.
 func (c *Client) ServiceUnexpose(args params.ServiceUnexpose) error {
+	if err := c.api.state.CheckChangeAllowed(); err != nil {
+		return err
+	}
 	svc, err := c.api.state.Service(args.ServiceName)
 	if err != nil {
 		return err
 	}
-	return svc.ClearExposed()
+	if len(args.Ports) == 0 || !svc.IsExposed() {
+		return svc.ClearExposed()
+	}
+	exposed := svc.ExposedPorts()
+	if exposed == nil {
+		return fmt.Errorf("service %q exposes all its ports; expose the ports to keep instead", svc.Name())
+	}
+	unexpose := make(map[network.Port]bool)
+	for _, port := range args.Ports {
+		unexpose[port] = true
+	}
+	var remaining []network.Port
+	for _, port := range exposed {
+		if !unexpose[port] {
+			remaining = append(remaining, port)
+		}
+	}
+	if len(remaining) == 0 {
+		return svc.ClearExposed()
+	}
+	return svc.SetExposedPorts(remaining)
+}
+
+// uniquePorts returns the given ports without duplicates.
+func uniquePorts(ports []network.Port) []network.Port {
+	seen := make(map[network.Port]bool)
+	var unique []network.Port
+	for _, port := range ports {
+		if !seen[port] {
+			seen[port] = true
+			unique = append(unique, port)
+		}
+	}
+	return unique
 }
 
 var CharmStore charm.Repository = charm.Store
@@ -242,6 +311,9 @@ func networkTagsToNames(tags []string) ([]string, error) {
 // before calling ServiceDeploy, although for backward compatibility
 // this is not necessary until 1.16 support is removed.
 func (c *Client) ServiceDeploy(args params.ServiceDeploy) error {
+	if err := c.api.state.CheckChangeAllowed(); err != nil {
+		return err
+	}
 	curl, err := charm.ParseURL(args.CharmUrl)
 	if err != nil {
 		return err
@@ -303,6 +375,14 @@ func (c *Client) ServiceDeploy(args params.ServiceDeploy) error {
 			Constraints:    args.Constraints,
 			ToMachineSpec:  args.ToMachineSpec,
 			Networks:       requestedNetworks,
+			Deployment: &state.DeploymentRecord{
+				User:        c.api.auth.GetAuthTag().String(),
+				ClientHost:  args.ClientHost,
+				CharmURL:    ch.URL().String(),
+				CharmSha256: ch.BundleSha256(),
+				Bundle:      args.Bundle,
+				Deployed:    time.Now(),
+			},
 		})
 	return err
 }
@@ -319,6 +399,9 @@ func (c *Client) ServiceDeployWithNetworks(args params.ServiceDeploy) error {
 // minimum number of units, settings and constraints.
 // All parameters in params.ServiceUpdate except the service name are optional.
 func (c *Client) ServiceUpdate(args params.ServiceUpdate) error {
+	if err := c.api.state.CheckChangeAllowed(); err != nil {
+		return err
+	}
 	service, err := c.api.state.Service(args.ServiceName)
 	if err != nil {
 		return err
@@ -442,6 +525,9 @@ func newServiceSetSettingsStringsForClientAPI(service *state.Service, settings m
 
 // ServiceSetCharm sets the charm for a given service.
 func (c *Client) ServiceSetCharm(args params.ServiceSetCharm) error {
+	if err := c.api.state.CheckChangeAllowed(); err != nil {
+		return err
+	}
 	service, err := c.api.state.Service(args.ServiceName)
 	if err != nil {
 		return err
@@ -466,6 +552,9 @@ func addServiceUnits(state *state.State, args params.AddServiceUnits) ([]*state.
 
 // AddServiceUnits adds a given number of units to a service.
 func (c *Client) AddServiceUnits(args params.AddServiceUnits) (params.AddServiceUnitsResults, error) {
+	if err := c.api.state.CheckChangeAllowed(); err != nil {
+		return params.AddServiceUnitsResults{}, err
+	}
 	units, err := addServiceUnits(c.api.state, args)
 	if err != nil {
 		return params.AddServiceUnitsResults{}, err
@@ -479,6 +568,9 @@ func (c *Client) AddServiceUnits(args params.AddServiceUnits) (params.AddService
 
 // DestroyServiceUnits removes a given set of service units.
 func (c *Client) DestroyServiceUnits(args params.DestroyServiceUnits) error {
+	if err := c.api.state.CheckRemoveAllowed(); err != nil {
+		return err
+	}
 	var errs []string
 	for _, name := range args.UnitNames {
 		unit, err := c.api.state.Unit(name)
@@ -502,6 +594,9 @@ func (c *Client) DestroyServiceUnits(args params.DestroyServiceUnits) error {
 
 // ServiceDestroy destroys a given service.
 func (c *Client) ServiceDestroy(args params.ServiceDestroy) error {
+	if err := c.api.state.CheckRemoveAllowed(); err != nil {
+		return err
+	}
 	svc, err := c.api.state.Service(args.ServiceName)
 	if err != nil {
 		return err
@@ -519,6 +614,18 @@ func (c *Client) GetServiceConstraints(args params.GetServiceConstraints) (param
 	return params.GetConstraintsResults{cons}, err
 }
 
+// GetServiceEffectiveConstraints returns the constraints for a given
+// service merged with the environment constraints, as they are used
+// for the machines added for the service's units.
+func (c *Client) GetServiceEffectiveConstraints(args params.GetServiceConstraints) (params.GetConstraintsResults, error) {
+	svc, err := c.api.state.Service(args.ServiceName)
+	if err != nil {
+		return params.GetConstraintsResults{}, err
+	}
+	cons, err := svc.EffectiveConstraints()
+	return params.GetConstraintsResults{cons}, err
+}
+
 // GetEnvironmentConstraints returns the constraints for the environment.
 func (c *Client) GetEnvironmentConstraints() (params.GetConstraintsResults, error) {
 	cons, err := c.api.state.EnvironConstraints()
@@ -530,6 +637,9 @@ func (c *Client) GetEnvironmentConstraints() (params.GetConstraintsResults, erro
 
 // SetServiceConstraints sets the constraints for a given service.
 func (c *Client) SetServiceConstraints(args params.SetConstraints) error {
+	if err := c.api.state.CheckChangeAllowed(); err != nil {
+		return err
+	}
 	svc, err := c.api.state.Service(args.ServiceName)
 	if err != nil {
 		return err
@@ -539,11 +649,17 @@ func (c *Client) SetServiceConstraints(args params.SetConstraints) error {
 
 // SetEnvironmentConstraints sets the constraints for the environment.
 func (c *Client) SetEnvironmentConstraints(args params.SetConstraints) error {
+	if err := c.api.state.CheckChangeAllowed(); err != nil {
+		return err
+	}
 	return c.api.state.SetEnvironConstraints(args.Constraints)
 }
 
 // AddRelation adds a relation between the specified endpoints and returns the relation info.
 func (c *Client) AddRelation(args params.AddRelation) (params.AddRelationResults, error) {
+	if err := c.api.state.CheckChangeAllowed(); err != nil {
+		return params.AddRelationResults{}, err
+	}
 	inEps, err := c.api.state.InferEndpoints(args.Endpoints)
 	if err != nil {
 		return params.AddRelationResults{}, err
@@ -565,6 +681,9 @@ func (c *Client) AddRelation(args params.AddRelation) (params.AddRelationResults
 
 // DestroyRelation removes the relation between the specified endpoints.
 func (c *Client) DestroyRelation(args params.DestroyRelation) error {
+	if err := c.api.state.CheckRemoveAllowed(); err != nil {
+		return err
+	}
 	eps, err := c.api.state.InferEndpoints(args.Endpoints)
 	if err != nil {
 		return err
@@ -583,6 +702,9 @@ func (c *Client) AddMachines(args params.AddMachines) (params.AddMachinesResults
 
 // AddMachinesV2 adds new machines with the supplied parameters.
 func (c *Client) AddMachinesV2(args params.AddMachines) (params.AddMachinesResults, error) {
+	if err := c.api.state.CheckChangeAllowed(); err != nil {
+		return params.AddMachinesResults{}, err
+	}
 	results := params.AddMachinesResults{
 		Machines: make([]params.AddMachinesResult, len(args.MachineParams)),
 	}
@@ -598,6 +720,9 @@ func (c *Client) AddMachinesV2(args params.AddMachines) (params.AddMachinesResul
 
 // InjectMachines injects a machine into state with provisioned status.
 func (c *Client) InjectMachines(args params.AddMachines) (params.AddMachinesResults, error) {
+	if err := c.api.state.CheckChangeAllowed(); err != nil {
+		return params.AddMachinesResults{}, err
+	}
 	return c.AddMachines(args)
 }
 
@@ -698,8 +823,44 @@ func (c *Client) ProvisioningScript(args params.ProvisioningScriptParams) (param
 	return result, err
 }
 
+// UnusedMachines returns the live machines that host no units and
+// no containers, ordered by id. Machines that manage the environment
+// are never unused.
+func (c *Client) UnusedMachines() (params.UnusedMachinesResults, error) {
+	machines, err := c.api.state.AllMachines()
+	if err != nil {
+		return params.UnusedMachinesResults{}, err
+	}
+	var results params.UnusedMachinesResults
+	for _, m := range machines {
+		if m.Life() != state.Alive || m.IsManager() {
+			continue
+		}
+		units, err := m.Units()
+		if err != nil {
+			return params.UnusedMachinesResults{}, err
+		}
+		containers, err := m.Containers()
+		if err != nil {
+			return params.UnusedMachinesResults{}, err
+		}
+		if len(units) > 0 || len(containers) > 0 {
+			continue
+		}
+		unused := params.UnusedMachine{Id: m.Id()}
+		if created := m.DateCreated(); !created.IsZero() {
+			unused.DateCreated = &created
+		}
+		results.Machines = append(results.Machines, unused)
+	}
+	return results, nil
+}
+
 // DestroyMachines removes a given set of machines.
 func (c *Client) DestroyMachines(args params.DestroyMachines) error {
+	if err := c.api.state.CheckRemoveAllowed(); err != nil {
+		return err
+	}
 	var errs []string
 	for _, id := range args.MachineNames {
 		machine, err := c.api.state.Machine(id)
@@ -731,11 +892,20 @@ func (c *Client) CharmInfo(args params.CharmInfo) (api.CharmInfo, error) {
 	if err != nil {
 		return api.CharmInfo{}, err
 	}
+	validator := charmValidator(charm)
+	if args.Validator != "" && args.Validator == validator {
+		return api.CharmInfo{
+			URL:         curl.String(),
+			Validator:   validator,
+			NotModified: true,
+		}, nil
+	}
 	info := api.CharmInfo{
-		Revision: charm.Revision(),
-		URL:      curl.String(),
-		Config:   charm.Config(),
-		Meta:     charm.Meta(),
+		Revision:  charm.Revision(),
+		URL:       curl.String(),
+		Config:    charm.Config(),
+		Meta:      charm.Meta(),
+		Validator: validator,
 	}
 	return info, nil
 }
@@ -790,6 +960,9 @@ func (c *Client) findEntity(tag string) (state.Annotator, error) {
 
 // SetAnnotations stores annotations about a given entity.
 func (c *Client) SetAnnotations(args params.SetAnnotations) error {
+	if err := c.api.state.CheckChangeAllowed(); err != nil {
+		return err
+	}
 	entity, err := c.findEntity(args.Tag)
 	if err != nil {
 		return err
@@ -851,6 +1024,9 @@ func (c *Client) EnvironmentGet() (params.EnvironmentGetResults, error) {
 // EnvironmentSet implements the server-side part of the
 // set-environment CLI command.
 func (c *Client) EnvironmentSet(args params.EnvironmentSet) error {
+	if err := c.api.state.CheckChangeAllowed(); err != nil {
+		return err
+	}
 	// Make sure we don't allow changing agent-version.
 	checkAgentVersion := func(updateAttrs map[string]interface{}, removeAttrs []string, oldConfig *config.Config) error {
 		if v, found := updateAttrs["agent-version"]; found {
@@ -870,6 +1046,9 @@ func (c *Client) EnvironmentSet(args params.EnvironmentSet) error {
 // EnvironmentUnset implements the server-side part of the
 // set-environment CLI command.
 func (c *Client) EnvironmentUnset(args params.EnvironmentUnset) error {
+	if err := c.api.state.CheckChangeAllowed(); err != nil {
+		return err
+	}
 	// TODO(waigani) 2014-3-11 #1167616
 	// Add a txn retry loop to ensure that the settings on disk have not
 	// changed underneath us.
@@ -878,6 +1057,9 @@ func (c *Client) EnvironmentUnset(args params.EnvironmentUnset) error {
 
 // SetEnvironAgentVersion sets the environment agent version.
 func (c *Client) SetEnvironAgentVersion(args params.SetEnvironAgentVersion) error {
+	if err := c.api.state.CheckChangeAllowed(); err != nil {
+		return err
+	}
 	return c.api.state.SetEnvironAgentVersion(args.Version)
 }
 
@@ -918,6 +1100,9 @@ func destroyErr(desc string, ids, errs []string) error {
 // the environment, if it does not exist yet. Local charms are not
 // supported, only charm store URLs. See also AddLocalCharm().
 func (c *Client) AddCharm(args params.CharmURL) error {
+	if err := c.api.state.CheckChangeAllowed(); err != nil {
+		return err
+	}
 	charmURL, err := charm.ParseURL(args.URL)
 	if err != nil {
 		return err
@@ -973,20 +1158,9 @@ func (c *Client) AddCharm(args params.CharmURL) error {
 		return errors.Annotate(err, "cannot access environment")
 	}
 	storage := env.Storage()
-	archiveName, err := CharmArchiveName(charmURL.Name, charmURL.Revision)
-	if err != nil {
-		return errors.Annotate(err, "cannot generate charm archive name")
-	}
-	if err := storage.Put(archiveName, archive, size); err != nil {
-		return errors.Annotate(err, "cannot upload charm to provider storage")
-	}
-	storageURL, err := storage.URL(archiveName)
+	bundleURL, err := common.StoreCharmArchive(c.api.state, storage, archive, size, bundleSHA256)
 	if err != nil {
-		return errors.Annotate(err, "cannot get storage URL for charm")
-	}
-	bundleURL, err := url.Parse(storageURL)
-	if err != nil {
-		return errors.Annotate(err, "cannot parse storage URL")
+		return errors.Annotate(err, "cannot store charm")
 	}
 
 	// Finally, update the charm data in state and mark it as no longer pending.
@@ -995,12 +1169,16 @@ func (c *Client) AddCharm(args params.CharmURL) error {
 		state.IsCharmAlreadyUploadedError(err) {
 		// This is not an error, it just signifies somebody else
 		// managed to upload and update the charm in state before
-		// us. This means we have to delete what we just uploaded
-		// to storage.
-		if err := storage.Remove(archiveName); err != nil {
-			errors.Annotate(err, "cannot remove duplicated charm from storage")
+		// us. This means we have to drop the reference we just
+		// added to the stored archive.
+		if err := common.ReleaseCharmArchive(c.api.state, storage, bundleSHA256); err != nil {
+			return errors.Annotate(err, "cannot release duplicated charm")
 		}
 		return nil
+	} else if err != nil {
+		if err := common.ReleaseCharmArchive(c.api.state, storage, bundleSHA256); err != nil {
+			logger.Errorf("cannot release charm %q: %v", charmURL, err)
+		}
 	}
 	return err
 }
@@ -1036,19 +1214,11 @@ func (c *Client) resolveCharm(ref charm.Reference, repo charm.Repository) (*char
 	return repo.Resolve(ref)
 }
 
-// CharmArchiveName returns a string that is suitable as a file name
-// in a storage URL. It is constructed from the charm name, revision
-// and a random UUID string.
-func CharmArchiveName(name string, revision int) (string, error) {
-	uuid, err := utils.NewUUID()
-	if err != nil {
-		return "", err
-	}
-	return charm.Quote(fmt.Sprintf("%s-%d-%s", name, revision, uuid)), nil
-}
-
 // RetryProvisioning marks a provisioning error as transient on the machines.
 func (c *Client) RetryProvisioning(p params.Entities) (params.ErrorResults, error) {
+	if err := c.api.state.CheckChangeAllowed(); err != nil {
+		return params.ErrorResults{}, err
+	}
 	entityStatus := make([]params.EntityStatus, len(p.Entities))
 	for i, entity := range p.Entities {
 		entityStatus[i] = params.EntityStatus{Tag: entity.Tag, Data: params.StatusData{"transient": true}}
@@ -1058,6 +1228,162 @@ func (c *Client) RetryProvisioning(p params.Entities) (params.ErrorResults, erro
 	})
 }
 
+// MachineUtilization returns the recorded resource utilization
+// samples of the given machines.
+func (c *Client) MachineUtilization(args params.Entities) (params.MachineUtilizationResults, error) {
+	results := params.MachineUtilizationResults{
+		Results: make([]params.MachineUtilizationResult, len(args.Entities)),
+	}
+	for i, entity := range args.Entities {
+		samples, err := c.machineUtilization(entity.Tag)
+		results.Results[i].Samples = samples
+		results.Results[i].Error = common.ServerError(err)
+	}
+	return results, nil
+}
+
+// SetInterfaceSchema registers the schema against which relation
+// settings of the given interface are validated.
+func (c *Client) SetInterfaceSchema(args params.InterfaceSchema) error {
+	if err := c.api.state.CheckChangeAllowed(); err != nil {
+		return err
+	}
+	schema := interfaceschema.Schema{
+		Interface: args.Interface,
+		Fields:    make(map[string]interfaceschema.FieldType),
+		Strict:    args.Strict,
+	}
+	for name, fieldType := range args.Fields {
+		schema.Fields[name] = interfaceschema.FieldType(fieldType)
+	}
+	return c.api.state.SetInterfaceSchema(schema)
+}
+
+// GetInterfaceSchema returns the schema registered for the
+// given interface.
+func (c *Client) GetInterfaceSchema(args params.InterfaceSchemaName) (params.InterfaceSchema, error) {
+	schema, err := c.api.state.InterfaceSchema(args.Interface)
+	if err != nil {
+		return params.InterfaceSchema{}, err
+	}
+	result := params.InterfaceSchema{
+		Interface: schema.Interface,
+		Fields:    make(map[string]string),
+		Strict:    schema.Strict,
+	}
+	for name, fieldType := range schema.Fields {
+		result.Fields[name] = string(fieldType)
+	}
+	return result, nil
+}
+
+// RemoveInterfaceSchema removes the schema registered for the
+// given interface.
+func (c *Client) RemoveInterfaceSchema(args params.InterfaceSchemaName) error {
+	if err := c.api.state.CheckRemoveAllowed(); err != nil {
+		return err
+	}
+	return c.api.state.RemoveInterfaceSchema(args.Interface)
+}
+
+// SwitchBlockOn sets a block on the operations of the given type.
+func (c *Client) SwitchBlockOn(args params.BlockSwitchParams) error {
+	blockType, err := state.ParseBlockType(args.Type)
+	if err != nil {
+		return err
+	}
+	return c.api.state.SwitchBlockOn(blockType, args.Message)
+}
+
+// SwitchBlockOff removes the block on the operations of the given type.
+func (c *Client) SwitchBlockOff(args params.BlockSwitchParams) error {
+	blockType, err := state.ParseBlockType(args.Type)
+	if err != nil {
+		return err
+	}
+	return c.api.state.SwitchBlockOff(blockType)
+}
+
+// ListBlocks returns the blocks set in the environment.
+func (c *Client) ListBlocks() (params.BlockResults, error) {
+	blocks, err := c.api.state.AllBlocks()
+	if err != nil {
+		return params.BlockResults{}, err
+	}
+	result := params.BlockResults{
+		Results: make([]params.BlockResult, len(blocks)),
+	}
+	for i, block := range blocks {
+		result.Results[i] = params.BlockResult{
+			Type:    string(block.Type()),
+			Message: block.Message(),
+		}
+	}
+	return result, nil
+}
+
+func (c *Client) machineUtilization(tag string) ([]params.UtilizationSample, error) {
+	machineTag, err := names.ParseMachineTag(tag)
+	if err != nil {
+		return nil, err
+	}
+	machine, err := c.api.state.Machine(machineTag.Id())
+	if err != nil {
+		return nil, err
+	}
+	samples, err := machine.Utilization()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]params.UtilizationSample, len(samples))
+	for i, sample := range samples {
+		result[i] = params.UtilizationSample{
+			Time:       sample.Time,
+			CPUPercent: sample.CPUPercent,
+			MemUsed:    sample.MemUsed,
+			MemTotal:   sample.MemTotal,
+			DiskUsed:   sample.DiskUsed,
+			DiskTotal:  sample.DiskTotal,
+		}
+	}
+	return result, nil
+}
+
+// FindStatus returns the status history entries of machines and
+// units that mention the given text, newest first.
+func (c *Client) FindStatus(args params.FindStatusParams) (params.FindStatusResults, error) {
+	var since time.Time
+	if args.Since != nil {
+		since = *args.Since
+	}
+	entries, err := c.api.state.FindStatus(args.Text, since)
+	if err != nil {
+		return params.FindStatusResults{}, err
+	}
+	results := params.FindStatusResults{
+		Results: make([]params.StatusHistoryEntry, len(entries)),
+	}
+	for i, entry := range entries {
+		results.Results[i] = params.StatusHistoryEntry{
+			Entity: entry.Entity,
+			Status: entry.Status,
+			Info:   entry.Info,
+			Time:   entry.Time,
+		}
+	}
+	return results, nil
+}
+
+// APIActivity returns the activity of the API server answering
+// the call. Each state server counts only the requests it serves.
+func (c *Client) APIActivity() (params.APIActivity, error) {
+	calls, connections := common.APIActivity()
+	return params.APIActivity{
+		Calls:       calls,
+		Connections: connections,
+	}, nil
+}
+
 // APIHostPorts returns the API host/port addresses stored in state.
 func (c *Client) APIHostPorts() (result params.APIHostPortsResult, err error) {
 	if result.Servers, err = c.api.state.APIHostPorts(); err != nil {
@@ -1088,6 +1414,9 @@ func stateServersChanges(change state.StateServersChanges) params.StateServersCh
 
 // EnsureAvailability ensures the availability of Juju state servers.
 func (c *Client) EnsureAvailability(args params.StateServersSpecs) (params.StateServersChangeResults, error) {
+	if err := c.api.state.CheckChangeAllowed(); err != nil {
+		return params.StateServersChangeResults{}, err
+	}
 	results := params.StateServersChangeResults{Results: make([]params.StateServersChangeResult, len(args.Specs))}
 	for i, stateServersSpec := range args.Specs {
 		result, err := c.ensureAvailabilitySingle(stateServersSpec)
@@ -6,6 +6,7 @@ package client_test
 import (
 	"fmt"
 	"net/url"
+	"os"
 	"strconv"
 	"strings"
 	"sync"
@@ -26,6 +27,7 @@ import (
 	envstorage "github.com/juju/juju/environs/storage"
 	toolstesting "github.com/juju/juju/environs/tools/testing"
 	"github.com/juju/juju/instance"
+	"github.com/juju/juju/interfaceschema"
 	"github.com/juju/juju/network"
 	"github.com/juju/juju/provider/dummy"
 	"github.com/juju/juju/state"
@@ -52,9 +54,24 @@ func (s *clientSuite) TestClientStatus(c *gc.C) {
 	s.setUpScenario(c)
 	status, err := s.APIState.Client().Status(nil)
 	c.Assert(err, gc.IsNil)
+	c.Assert(status.Validator, gc.Not(gc.Equals), "")
+	status.Validator = ""
 	c.Assert(status, jc.DeepEquals, scenarioStatus)
 }
 
+func (s *clientSuite) TestClientAPIActivity(c *gc.C) {
+	client := s.APIState.Client()
+	first, err := client.APIActivity()
+	c.Assert(err, gc.IsNil)
+	c.Assert(first.Connections >= 1, jc.IsTrue)
+	_, err = client.EnvironmentGet()
+	c.Assert(err, gc.IsNil)
+	second, err := client.APIActivity()
+	c.Assert(err, gc.IsNil)
+	// Both the EnvironmentGet call and the APIActivity call are counted.
+	c.Assert(second.Calls-first.Calls >= 2, jc.IsTrue)
+}
+
 func (s *clientSuite) TestCompatibleSettingsParsing(c *gc.C) {
 	// Test the exported settings parsing in a compatible way.
 	s.AddTestingService(c, "dummy", s.AddTestingCharm(c, "dummy"))
@@ -275,16 +292,37 @@ func (s *clientSuite) TestClientCharmInfo(c *gc.C) {
 			continue
 		}
 		c.Assert(err, gc.IsNil)
+		c.Assert(info.Validator, gc.Not(gc.Equals), "")
 		expected := &api.CharmInfo{
-			Revision: charm.Revision(),
-			URL:      charm.URL().String(),
-			Config:   charm.Config(),
-			Meta:     charm.Meta(),
+			Revision:  charm.Revision(),
+			URL:       charm.URL().String(),
+			Config:    charm.Config(),
+			Meta:      charm.Meta(),
+			Validator: info.Validator,
 		}
 		c.Assert(info, gc.DeepEquals, expected)
 	}
 }
 
+func (s *clientSuite) TestClientCharmInfoIfModified(c *gc.C) {
+	charm := s.AddTestingCharm(c, "wordpress")
+	client := s.APIState.Client()
+	info, err := client.CharmInfo(charm.String())
+	c.Assert(err, gc.IsNil)
+
+	unchanged, err := client.CharmInfoIfModified(charm.String(), info.Validator)
+	c.Assert(err, gc.IsNil)
+	c.Assert(unchanged, gc.DeepEquals, &api.CharmInfo{
+		URL:         charm.URL().String(),
+		Validator:   info.Validator,
+		NotModified: true,
+	})
+
+	stale, err := client.CharmInfoIfModified(charm.String(), "stale")
+	c.Assert(err, gc.IsNil)
+	c.Assert(stale, gc.DeepEquals, info)
+}
+
 func (s *clientSuite) TestClientEnvironmentInfo(c *gc.C) {
 	conf, _ := s.State.EnvironConfig()
 	info, err := s.APIState.Client().EnvironmentInfo()
@@ -336,11 +374,13 @@ func (s *clientSuite) TestClientAnnotations(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	environment, err := s.State.Environment()
 	c.Assert(err, gc.IsNil)
+	network, err := s.State.AddNetwork(state.NetworkInfo{Name: "net1", ProviderId: "net1"})
+	c.Assert(err, gc.IsNil)
 	type taggedAnnotator interface {
 		state.Annotator
 		state.Entity
 	}
-	entities := []taggedAnnotator{service, unit, machine, environment}
+	entities := []taggedAnnotator{service, unit, machine, environment, network}
 	for i, t := range clientAnnotationsTests {
 		for _, entity := range entities {
 			id := entity.Tag().String() // this is WRONG, it should be Tag().Id() but the code is wrong.
@@ -482,6 +522,46 @@ func (s *clientSuite) TestClientServiceUnexpose(c *gc.C) {
 	}
 }
 
+func (s *clientSuite) TestClientServiceExposePorts(c *gc.C) {
+	svc := s.AddTestingService(c, "dummy-service", s.AddTestingCharm(c, "dummy"))
+	client := s.APIState.Client()
+	assertExposedPorts := func(exposed bool, ports []network.Port) {
+		err := svc.Refresh()
+		c.Assert(err, gc.IsNil)
+		c.Assert(svc.IsExposed(), gc.Equals, exposed)
+		c.Assert(svc.ExposedPorts(), jc.DeepEquals, ports)
+	}
+
+	err := client.ServiceExposePorts("dummy-service", []network.Port{{"tcp", 443}})
+	c.Assert(err, gc.IsNil)
+	assertExposedPorts(true, []network.Port{{"tcp", 443}})
+
+	// Further ports are added to those already exposed.
+	err = client.ServiceExposePorts("dummy-service", []network.Port{{"tcp", 80}, {"tcp", 443}})
+	c.Assert(err, gc.IsNil)
+	assertExposedPorts(true, []network.Port{{"tcp", 80}, {"tcp", 443}})
+
+	err = client.ServiceUnexposePorts("dummy-service", []network.Port{{"tcp", 80}})
+	c.Assert(err, gc.IsNil)
+	assertExposedPorts(true, []network.Port{{"tcp", 443}})
+
+	// Unexposing the last port unexposes the service.
+	err = client.ServiceUnexposePorts("dummy-service", []network.Port{{"tcp", 443}})
+	c.Assert(err, gc.IsNil)
+	assertExposedPorts(false, nil)
+
+	// Exposing ports of a service that exposes all its
+	// ports restricts it to those ports.
+	err = client.ServiceExpose("dummy-service")
+	c.Assert(err, gc.IsNil)
+	assertExposedPorts(true, nil)
+	err = client.ServiceUnexposePorts("dummy-service", []network.Port{{"tcp", 80}})
+	c.Assert(err, gc.ErrorMatches, `service "dummy-service" exposes all its ports; expose the ports to keep instead`)
+	err = client.ServiceExposePorts("dummy-service", []network.Port{{"udp", 53}})
+	c.Assert(err, gc.IsNil)
+	assertExposedPorts(true, []network.Port{{"udp", 53}})
+}
+
 var serviceDestroyTests = []struct {
 	about   string
 	service string
@@ -580,6 +660,28 @@ func (s *clientSuite) TestDestroyMachines(c *gc.C) {
 	assertLife(c, m2, state.Dying)
 }
 
+func (s *clientSuite) TestUnusedMachines(c *gc.C) {
+	_, _, m2, _ := s.setupDestroyMachinesTest(c)
+	host, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	container, err := s.State.AddMachineInsideMachine(state.MachineTemplate{
+		Series: "quantal",
+		Jobs:   []state.MachineJob{state.JobHostUnits},
+	}, host.Id(), instance.LXC)
+	c.Assert(err, gc.IsNil)
+	dying, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = dying.Destroy()
+	c.Assert(err, gc.IsNil)
+
+	unused, err := s.APIState.Client().UnusedMachines()
+	c.Assert(err, gc.IsNil)
+	c.Assert(unused, gc.HasLen, 2)
+	c.Assert(unused[0].Id, gc.Equals, m2.Id())
+	c.Assert(*unused[0].DateCreated, gc.Equals, m2.DateCreated())
+	c.Assert(unused[1].Id, gc.Equals, container.Id())
+}
+
 func (s *clientSuite) TestForceDestroyMachines(c *gc.C) {
 	m0, m1, m2, u := s.setupDestroyMachinesTest(c)
 
@@ -892,6 +994,35 @@ func (s *clientSuite) TestClientServiceDeployServiceOwner(c *gc.C) {
 	c.Assert(service.GetOwnerTag(), gc.Equals, user.Tag().String())
 }
 
+func (s *clientSuite) TestClientServiceDeployRecordsProvenance(c *gc.C) {
+	store, restore := makeMockCharmStore()
+	defer restore()
+	curl, _ := addCharm(c, store, "dummy")
+
+	before := time.Now()
+	err := s.APIState.Client().ServiceDeploy(
+		curl.String(), "service", 1, "", constraints.Value{}, "",
+	)
+	c.Assert(err, gc.IsNil)
+	after := time.Now()
+
+	service, err := s.State.Service("service")
+	c.Assert(err, gc.IsNil)
+	ch, err := s.State.Charm(curl)
+	c.Assert(err, gc.IsNil)
+	hostname, err := os.Hostname()
+	c.Assert(err, gc.IsNil)
+	record := service.DeploymentRecord()
+	c.Assert(record, gc.NotNil)
+	c.Check(record.User, gc.Equals, "user-admin")
+	c.Check(record.ClientHost, gc.Equals, hostname)
+	c.Check(record.CharmURL, gc.Equals, curl.String())
+	c.Check(record.CharmSha256, gc.Equals, ch.BundleSha256())
+	c.Check(record.Bundle, gc.Equals, "")
+	c.Check(record.Deployed.Before(before.Add(-time.Second)), jc.IsFalse)
+	c.Check(record.Deployed.After(after), jc.IsFalse)
+}
+
 func (s *clientSuite) deployServiceForTests(c *gc.C, store *charmtesting.MockCharmStore) {
 	curl, _ := addCharm(c, store, "dummy")
 	err := s.APIState.Client().ServiceDeploy(curl.String(),
@@ -1422,6 +1553,21 @@ func (s *clientSuite) TestClientGetServiceConstraints(c *gc.C) {
 	c.Assert(obtained, gc.DeepEquals, cons)
 }
 
+func (s *clientSuite) TestClientGetServiceEffectiveConstraints(c *gc.C) {
+	service := s.AddTestingService(c, "dummy", s.AddTestingCharm(c, "dummy"))
+	err := s.State.SetEnvironConstraints(constraints.MustParse("mem=4096 arch=amd64"))
+	c.Assert(err, gc.IsNil)
+	err = service.SetConstraints(constraints.MustParse("cpu-cores=2"))
+	c.Assert(err, gc.IsNil)
+
+	obtained, err := s.APIState.Client().GetServiceEffectiveConstraints("dummy")
+	c.Assert(err, gc.IsNil)
+	c.Assert(obtained, gc.DeepEquals, constraints.MustParse("arch=amd64 cpu-cores=2 mem=4096"))
+
+	_, err = s.APIState.Client().GetServiceEffectiveConstraints("missing")
+	c.Assert(err, gc.ErrorMatches, `service "missing" not found`)
+}
+
 func (s *clientSuite) TestClientSetEnvironmentConstraints(c *gc.C) {
 	// Set constraints for the environment.
 	cons, err := constraints.Parse("mem=4096", "cpu-cores=2")
@@ -2115,8 +2261,9 @@ func (s *clientSuite) TestAddCharmConcurrently(c *gc.C) {
 			sch, err := s.State.Charm(curl)
 			c.Assert(err, gc.IsNil, gc.Commentf("goroutine %d", index))
 			c.Assert(sch.URL(), jc.DeepEquals, curl, gc.Commentf("goroutine %d", index))
-			expectedName := fmt.Sprintf("%s-%d-[0-9a-f-]+", curl.Name, curl.Revision)
-			c.Assert(getArchiveName(sch.BundleURL()), gc.Matches, expectedName)
+			archive, err := s.State.CharmArchive(sch.BundleSha256())
+			c.Assert(err, gc.IsNil, gc.Commentf("goroutine %d", index))
+			c.Assert(getArchiveName(sch.BundleURL()), gc.Equals, archive.Path())
 		}(i)
 	}
 	wg.Wait()
@@ -2128,11 +2275,16 @@ func (s *clientSuite) TestAddCharmConcurrently(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	storage, err := environs.GetStorage(s.State)
 	c.Assert(err, gc.IsNil)
-	uploads, err := storage.List(fmt.Sprintf("%s-%d-", curl.Name, curl.Revision))
+	uploads, err := storage.List("charms/")
 	c.Assert(err, gc.IsNil)
 	c.Assert(uploads, gc.HasLen, 1)
 	c.Assert(getArchiveName(sch.BundleURL()), gc.Equals, uploads[0])
 	s.assertUploaded(c, storage, sch.BundleURL(), sch.BundleSha256())
+
+	// Only the winning upload keeps a reference to the archive.
+	archive, err := s.State.CharmArchive(sch.BundleSha256())
+	c.Assert(err, gc.IsNil)
+	c.Assert(archive.RefCount(), gc.Equals, 1)
 }
 
 func (s *clientSuite) TestAddCharmOverwritesPlaceholders(c *gc.C) {
@@ -2161,15 +2313,6 @@ func (s *clientSuite) TestAddCharmOverwritesPlaceholders(c *gc.C) {
 	c.Assert(sch.IsUploaded(), jc.IsTrue)
 }
 
-func (s *clientSuite) TestCharmArchiveName(c *gc.C) {
-	for rev, name := range []string{"Foo", "bar", "wordpress", "mysql"} {
-		archiveFormat := fmt.Sprintf("%s-%d-[0-9a-f-]+", name, rev)
-		archiveName, err := client.CharmArchiveName(name, rev)
-		c.Check(err, gc.IsNil)
-		c.Check(archiveName, gc.Matches, archiveFormat)
-	}
-}
-
 func (s *clientSuite) assertPutCalled(c *gc.C, ops chan dummy.Operation, numCalls int) {
 	calls := 0
 	select {
@@ -2183,7 +2326,7 @@ func (s *clientSuite) assertPutCalled(c *gc.C, ops chan dummy.Operation, numCall
 				c.Fatalf("storage Put() called %d times, expected %d times", calls, numCalls)
 				return
 			}
-			nameFormat := "[0-9a-z-]+-[0-9]+-[0-9a-f-]+"
+			nameFormat := "charms/sha256-[0-9a-f]+-[0-9]+"
 			c.Assert(op.FileName, gc.Matches, nameFormat)
 		}
 	case <-time.After(coretesting.LongWait):
@@ -2440,3 +2583,100 @@ func (s *clientSuite) TestClientAgentVersion(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	c.Assert(result, gc.Equals, current)
 }
+
+func (s *clientSuite) TestClientInterfaceSchema(c *gc.C) {
+	client := s.APIState.Client()
+	_, err := client.GetInterfaceSchema("mysql")
+	c.Assert(err, jc.Satisfies, params.IsCodeNotFound)
+
+	schema := params.InterfaceSchema{
+		Interface: "mysql",
+		Fields:    map[string]string{"port": "int", "host": "string"},
+		Strict:    true,
+	}
+	err = client.SetInterfaceSchema(schema)
+	c.Assert(err, gc.IsNil)
+	result, err := client.GetInterfaceSchema("mysql")
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, jc.DeepEquals, schema)
+
+	stateSchema, err := s.State.InterfaceSchema("mysql")
+	c.Assert(err, gc.IsNil)
+	c.Assert(stateSchema.Fields["port"], gc.Equals, interfaceschema.Int)
+
+	err = client.SetInterfaceSchema(params.InterfaceSchema{
+		Interface: "mysql",
+		Fields:    map[string]string{"port": "uint"},
+	})
+	c.Assert(err, gc.ErrorMatches, `cannot set schema for interface "mysql": field "port" has unknown type "uint"`)
+
+	err = client.RemoveInterfaceSchema("mysql")
+	c.Assert(err, gc.IsNil)
+	_, err = client.GetInterfaceSchema("mysql")
+	c.Assert(err, jc.Satisfies, params.IsCodeNotFound)
+}
+
+func (s *clientSuite) TestClientBlocks(c *gc.C) {
+	client := s.APIState.Client()
+	blocks, err := client.ListBlocks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(blocks, gc.HasLen, 0)
+
+	err = client.SwitchBlockOn("all-changes", "frozen for the holidays")
+	c.Assert(err, gc.IsNil)
+	err = client.SwitchBlockOn("destroy-environment", "")
+	c.Assert(err, gc.IsNil)
+	blocks, err = client.ListBlocks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(blocks, jc.DeepEquals, []params.BlockResult{
+		{Type: "destroy-environment"},
+		{Type: "all-changes", Message: "frozen for the holidays"},
+	})
+
+	err = client.SwitchBlockOff("all-changes")
+	c.Assert(err, gc.IsNil)
+	blocks, err = client.ListBlocks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(blocks, jc.DeepEquals, []params.BlockResult{{Type: "destroy-environment"}})
+
+	err = client.SwitchBlockOn("everything", "")
+	c.Assert(err, gc.ErrorMatches, `unknown block type "everything"`)
+}
+
+func (s *clientSuite) TestClientBlockedOperations(c *gc.C) {
+	s.setUpScenario(c)
+	client := s.APIState.Client()
+
+	// A remove block refuses removals and environment destruction...
+	err := s.State.SwitchBlockOn(state.RemoveBlock, "production")
+	c.Assert(err, gc.IsNil)
+	err = client.DestroyServiceUnits("wordpress/0")
+	c.Assert(err, jc.Satisfies, params.IsCodeOperationBlocked)
+	c.Assert(err, gc.ErrorMatches, "the operation has been blocked: production")
+	err = client.ServiceDestroy("wordpress")
+	c.Assert(err, jc.Satisfies, params.IsCodeOperationBlocked)
+	err = client.DestroyEnvironment()
+	c.Assert(err, jc.Satisfies, params.IsCodeOperationBlocked)
+
+	// ...but allows other changes.
+	err = client.ServiceExpose("wordpress")
+	c.Assert(err, gc.IsNil)
+
+	// A change block refuses those too.
+	err = s.State.SwitchBlockOn(state.ChangeBlock, "")
+	c.Assert(err, gc.IsNil)
+	err = client.ServiceUnexpose("wordpress")
+	c.Assert(err, jc.Satisfies, params.IsCodeOperationBlocked)
+	_, err = client.AddServiceUnits("wordpress", 1, "")
+	c.Assert(err, jc.Satisfies, params.IsCodeOperationBlocked)
+
+	// Once the blocks are removed, the operations succeed.
+	err = s.State.SwitchBlockOff(state.ChangeBlock)
+	c.Assert(err, gc.IsNil)
+	err = s.State.SwitchBlockOff(state.RemoveBlock)
+	c.Assert(err, gc.IsNil)
+	err = client.ServiceUnexpose("wordpress")
+	c.Assert(err, gc.IsNil)
+	err = client.DestroyServiceUnits("wordpress/0")
+	c.Assert(err, gc.IsNil)
+}
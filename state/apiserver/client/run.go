@@ -123,6 +123,77 @@ func (c *Client) Run(run params.RunParams) (results params.RunResults, err error
 	return ParallelExecute(c.getDataDir(), params), nil
 }
 
+// RunTargets runs the commands specified on the machines identified
+// through the list of machines, units and services. Unlike Run, it
+// does not fail when some of the targets are invalid: the commands are
+// run on the valid targets, and each invalid target is reported by a
+// result holding the target and the error.
+func (c *Client) RunTargets(run params.RunParams) (params.RunResults, error) {
+	var execParams []*RemoteExec
+	var failed []params.RunResult
+	quotedCommands := utils.ShQuote(run.Commands)
+	unitsSet := set.NewStrings(run.Units...)
+	for _, name := range run.Services {
+		units, err := serviceUnits(c.api.state, name)
+		if err != nil {
+			failed = append(failed, params.RunResult{ServiceName: name, Error: err.Error()})
+			continue
+		}
+		for _, unit := range units {
+			unitsSet.Add(unit.Name())
+		}
+	}
+	for _, unitName := range unitsSet.SortedValues() {
+		machine, err := unitMachine(c.api.state, unitName)
+		if err != nil {
+			failed = append(failed, params.RunResult{UnitId: unitName, Error: err.Error()})
+			continue
+		}
+		command := fmt.Sprintf("juju-run %s %s", unitName, quotedCommands)
+		execParam := remoteParamsForMachine(machine, command, run.Timeout)
+		execParam.UnitId = unitName
+		execParams = append(execParams, execParam)
+	}
+	for _, machineId := range run.Machines {
+		machine, err := c.api.state.Machine(machineId)
+		if err != nil {
+			failed = append(failed, params.RunResult{MachineId: machineId, Error: err.Error()})
+			continue
+		}
+		command := fmt.Sprintf("juju-run --no-context %s", quotedCommands)
+		execParams = append(execParams, remoteParamsForMachine(machine, command, run.Timeout))
+	}
+	results := ParallelExecute(c.getDataDir(), execParams)
+	results.Results = append(results.Results, failed...)
+	return results, nil
+}
+
+// serviceUnits returns the units of the named service.
+func serviceUnits(st *state.State, name string) ([]*state.Unit, error) {
+	service, err := st.Service(name)
+	if err != nil {
+		return nil, err
+	}
+	return service.AllUnits()
+}
+
+// unitMachine returns the machine the named unit is assigned to. Only
+// principal units can be targeted.
+func unitMachine(st *state.State, unitName string) (*state.Machine, error) {
+	unit, err := st.Unit(unitName)
+	if err != nil {
+		return nil, err
+	}
+	if !unit.IsPrincipal() {
+		return nil, fmt.Errorf("%s is not a principal unit", unit)
+	}
+	machineId, err := unit.AssignedMachineId()
+	if err != nil {
+		return nil, err
+	}
+	return st.Machine(machineId)
+}
+
 // RunOnAllMachines attempts to run the specified command on all the machines.
 func (c *Client) RunOnAllMachines(run params.RunParams) (params.RunResults, error) {
 	machines, err := c.api.state.AllMachines()
@@ -280,6 +280,52 @@ func (s *runSuite) TestRunMachineAndService(c *gc.C) {
 	c.Assert(results, jc.DeepEquals, expectedResults)
 }
 
+func (s *runSuite) TestRunTargetsReportsMissingTargets(c *gc.C) {
+	s.addMachineWithAddress(c, "10.3.2.1")
+
+	charm := s.AddTestingCharm(c, "dummy")
+	magic, err := s.State.AddService("magic", "user-admin", charm, nil)
+	c.Assert(err, gc.IsNil)
+	s.addUnit(c, magic)
+
+	s.mockSSH(c, echoInput)
+
+	client := s.APIState.Client()
+	results, err := client.RunTargets(
+		params.RunParams{
+			Commands: "hostname",
+			Timeout:  testing.LongWait,
+			Machines: []string{"0", "42"},
+			Services: []string{"nosuch"},
+			Units:    []string{"magic/0", "magic/7"},
+		})
+	c.Assert(err, gc.IsNil)
+	expectedResults := []params.RunResult{
+		params.RunResult{
+			ExecResponse: exec.ExecResponse{Stdout: []byte("juju-run --no-context 'hostname'\n")},
+			MachineId:    "0",
+		},
+		params.RunResult{
+			ExecResponse: exec.ExecResponse{Stdout: []byte("juju-run magic/0 'hostname'\n")},
+			MachineId:    "1",
+			UnitId:       "magic/0",
+		},
+		params.RunResult{
+			ServiceName: "nosuch",
+			Error:       `service "nosuch" not found`,
+		},
+		params.RunResult{
+			UnitId: "magic/7",
+			Error:  `unit "magic/7" not found`,
+		},
+		params.RunResult{
+			MachineId: "42",
+			Error:     "machine 42 not found",
+		},
+	}
+	c.Assert(results, jc.DeepEquals, expectedResults)
+}
+
 var echoInputShowArgs = `#!/bin/bash
 # Write the args to stderr
 echo "$*" >&2
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"path"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/juju/charm"
@@ -23,16 +24,30 @@ import (
 
 // FullStatus gives the information needed for juju status over the api
 func (c *Client) FullStatus(args params.StatusParams) (api.Status, error) {
-	cfg, err := c.api.state.EnvironConfig()
-	if err != nil {
-		return api.Status{}, err
-	}
 	var noStatus api.Status
 	var context statusContext
 	unitMatcher, err := NewUnitMatcher(args.Patterns)
 	if err != nil {
 		return noStatus, err
 	}
+
+	// Check the validator before fetching anything, so that
+	// unchanged statuses are cheap to answer.
+	validator, err := statusValidator(c.api.state, args.Patterns)
+	if err != nil {
+		return noStatus, err
+	}
+	if args.Validator != "" && args.Validator == validator {
+		return api.Status{
+			Validator:   validator,
+			NotModified: true,
+		}, nil
+	}
+
+	cfg, err := c.api.state.EnvironConfig()
+	if err != nil {
+		return noStatus, err
+	}
 	if context.services,
 		context.units, context.latestCharms, err = fetchAllServicesAndUnits(c.api.state, unitMatcher); err != nil {
 		return noStatus, err
@@ -62,6 +77,7 @@ func (c *Client) FullStatus(args params.StatusParams) (api.Status, error) {
 		Services:        context.processServices(),
 		Networks:        context.processNetworks(),
 		Relations:       context.processRelations(),
+		Validator:       validator,
 	}, nil
 }
 
@@ -358,6 +374,7 @@ func (context *statusContext) makeMachineStatus(machine *state.Machine) (status
 	status.Jobs = paramsJobsFromJobs(machine.Jobs())
 	status.WantsVote = machine.WantsVote()
 	status.HasVote = machine.HasVote()
+	status.Maintenance = machine.InMaintenance()
 	instid, err := machine.InstanceId()
 	if err == nil {
 		status.InstanceId = instid
@@ -417,9 +434,20 @@ func (context *statusContext) processRelations() []api.RelationStatus {
 		}
 		out = append(out, relStatus)
 	}
+	// Relations are loaded by service, so return them in a
+	// stable order to make successive results comparable.
+	sort.Sort(relationStatusById(out))
 	return out
 }
 
+// relationStatusById implements sort.Interface, ordering
+// relations by id.
+type relationStatusById []api.RelationStatus
+
+func (r relationStatusById) Len() int           { return len(r) }
+func (r relationStatusById) Less(i, j int) bool { return r[i].Id < r[j].Id }
+func (r relationStatusById) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+
 // This method exists only to dedup the loaded relations as they will
 // appear multiple times in context.relations.
 func (context *statusContext) getAllRelations() []*state.Relation {
@@ -486,6 +514,16 @@ func (context *statusContext) processService(service *state.Service) (status api
 	status.Charm = serviceCharmURL.String()
 	status.Exposed = service.IsExposed()
 	status.Life = processLife(service)
+	if record := service.DeploymentRecord(); record != nil {
+		status.Deployment = &api.DeploymentRecord{
+			User:        record.User,
+			ClientHost:  record.ClientHost,
+			CharmURL:    record.CharmURL,
+			CharmSha256: record.CharmSha256,
+			Bundle:      record.Bundle,
+			Deployed:    record.Deployed,
+		}
+	}
 
 	latestCharm, ok := context.latestCharms[*serviceCharmURL.WithRevision(-1)]
 	if ok && latestCharm != serviceCharmURL.String() {
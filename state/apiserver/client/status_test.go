@@ -4,18 +4,32 @@
 package client_test
 
 import (
+	"time"
+
+	jc "github.com/juju/testing/checkers"
 	gc "launchpad.net/gocheck"
 
 	"github.com/juju/juju/instance"
 	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api"
+	apiserverclient "github.com/juju/juju/state/apiserver/client"
 )
 
 type statusSuite struct {
 	baseSuite
+	now time.Time
 }
 
 var _ = gc.Suite(&statusSuite{})
 
+func (s *statusSuite) SetUpTest(c *gc.C) {
+	s.baseSuite.SetUpTest(c)
+	// Keep validators from changing with the presence period
+	// while the tests run.
+	s.now = time.Date(2014, 6, 1, 12, 0, 0, 0, time.UTC)
+	s.PatchValue(apiserverclient.ValidatorNow, func() time.Time { return s.now })
+}
+
 func (s *statusSuite) addMachine(c *gc.C) *state.Machine {
 	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
 	c.Assert(err, gc.IsNil)
@@ -42,6 +56,47 @@ func (s *statusSuite) TestFullStatus(c *gc.C) {
 	c.Check(resultMachine.Series, gc.Equals, machine.Series())
 }
 
+func (s *statusSuite) TestStatusIfModified(c *gc.C) {
+	s.addMachine(c)
+	client := s.APIState.Client()
+	status, err := client.Status(nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(status.Validator, gc.Not(gc.Equals), "")
+	c.Assert(status.NotModified, jc.IsFalse)
+
+	// Nothing has changed, so only the validator is returned.
+	unchanged, err := client.StatusIfModified(nil, status.Validator)
+	c.Assert(err, gc.IsNil)
+	c.Assert(unchanged, gc.DeepEquals, &api.Status{
+		Validator:   status.Validator,
+		NotModified: true,
+	})
+
+	// Adding a machine changes the status.
+	s.addMachine(c)
+	changed, err := client.StatusIfModified(nil, status.Validator)
+	c.Assert(err, gc.IsNil)
+	c.Assert(changed.NotModified, jc.IsFalse)
+	c.Assert(changed.Validator, gc.Not(gc.Equals), status.Validator)
+	c.Assert(changed.Machines, gc.HasLen, 2)
+}
+
+func (s *statusSuite) TestStatusIfModifiedPresencePeriod(c *gc.C) {
+	s.addMachine(c)
+	client := s.APIState.Client()
+	status, err := client.Status(nil)
+	c.Assert(err, gc.IsNil)
+
+	// Agent presence is not covered by transactions, so the
+	// validator changes once every presence period.
+	s.now = s.now.Add(*apiserverclient.ValidatorPresencePeriod)
+	changed, err := client.StatusIfModified(nil, status.Validator)
+	c.Assert(err, gc.IsNil)
+	c.Assert(changed.NotModified, jc.IsFalse)
+	c.Assert(changed.Validator, gc.Not(gc.Equals), status.Validator)
+	c.Assert(changed.Machines, gc.HasLen, 1)
+}
+
 func (s *statusSuite) TestLegacyStatus(c *gc.C) {
 	machine := s.addMachine(c)
 	instanceId := "i-fakeinstance"
@@ -57,3 +112,24 @@ func (s *statusSuite) TestLegacyStatus(c *gc.C) {
 	}
 	c.Check(resultMachine.InstanceId, gc.Equals, instanceId)
 }
+
+func (s *statusSuite) TestFullStatusRelationOrder(c *gc.C) {
+	s.setUpScenario(c)
+	eps, err := s.State.InferEndpoints([]string{"wordpress", "mysql"})
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.AddRelation(eps...)
+	c.Assert(err, gc.IsNil)
+
+	client := s.APIState.Client()
+	status, err := client.Status(nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(status.Relations, gc.HasLen, 2)
+	c.Assert(status.Relations[0].Id < status.Relations[1].Id, jc.IsTrue)
+
+	// The ordering is stable, so an unchanged status
+	// always produces the same validator.
+	again, err := client.Status(nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(again.Relations, jc.DeepEquals, status.Relations)
+	c.Assert(again.Validator, gc.Equals, status.Validator)
+}
@@ -0,0 +1,57 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/state"
+)
+
+// validatorPresencePeriod holds the period over which a status
+// validator stays the same while no transactions are run. It matches
+// the period at which agents ping their presence.
+var validatorPresencePeriod = 30 * time.Second
+
+// validatorNow returns the current time; it is patched in tests.
+var validatorNow = time.Now
+
+// statusValidator returns an opaque value that changes whenever the
+// status may change. Clients polling FullStatus send back the last
+// validator they saw, so that an unchanged status need not be built
+// or sent over the wire again.
+//
+// Everything reported in the status other than agent presence is
+// changed through transactions, so the validator covers the most
+// recent transaction and the patterns used to filter the status. It
+// is cheap to compute, so that it can be checked before anything is
+// fetched. Agent presence is not queried; instead the validator also
+// changes every presence period, so a change in presence is reported
+// within that period.
+func statusValidator(st *state.State, patterns []string) (string, error) {
+	lastTxnId, err := st.LastTxnId()
+	if err != nil {
+		return "", errors.Annotate(err, "cannot compute status validator")
+	}
+	period := validatorNow().UnixNano() / int64(validatorPresencePeriod)
+	data := fmt.Sprintf("%s\n%s\n%d", lastTxnId, strings.Join(patterns, " "), period)
+	return hashValidator([]byte(data)), nil
+}
+
+// charmValidator returns an opaque value that changes whenever the
+// given charm's URL or bundle changes.
+func charmValidator(ch *state.Charm) string {
+	return hashValidator([]byte(ch.URL().String() + "\n" + ch.BundleSha256()))
+}
+
+func hashValidator(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
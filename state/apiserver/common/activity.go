@@ -0,0 +1,35 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package common
+
+import (
+	"sync/atomic"
+)
+
+var (
+	// apiCalls and apiConnections count the API requests served
+	// and the API connections open in this process.
+	apiCalls       int64
+	apiConnections int64
+)
+
+// RecordAPICall records that an API request has been served.
+func RecordAPICall() {
+	atomic.AddInt64(&apiCalls, 1)
+}
+
+// RecordAPIConnection records that an API connection has been
+// opened, and returns a function to record that it has been closed.
+func RecordAPIConnection() func() {
+	atomic.AddInt64(&apiConnections, 1)
+	return func() {
+		atomic.AddInt64(&apiConnections, -1)
+	}
+}
+
+// APIActivity returns the number of API requests served since the
+// process started, and the number of API connections open now.
+func APIActivity() (calls, connections int64) {
+	return atomic.LoadInt64(&apiCalls), atomic.LoadInt64(&apiConnections)
+}
@@ -0,0 +1,29 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package common_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state/apiserver/common"
+)
+
+type activitySuite struct{}
+
+var _ = gc.Suite(&activitySuite{})
+
+func (*activitySuite) TestAPIActivity(c *gc.C) {
+	calls, connections := common.APIActivity()
+	common.RecordAPICall()
+	common.RecordAPICall()
+	closed := common.RecordAPIConnection()
+	newCalls, newConnections := common.APIActivity()
+	c.Assert(newCalls, gc.Equals, calls+2)
+	c.Assert(newConnections, gc.Equals, connections+1)
+
+	closed()
+	newCalls, newConnections = common.APIActivity()
+	c.Assert(newCalls, gc.Equals, calls+2)
+	c.Assert(newConnections, gc.Equals, connections)
+}
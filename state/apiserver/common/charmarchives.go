@@ -0,0 +1,68 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package common
+
+import (
+	"io"
+	"net/url"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/environs/storage"
+	"github.com/juju/juju/state"
+)
+
+// StoreCharmArchive makes the charm archive read from r, of the given
+// size and SHA256 hash, available in provider storage and records a
+// reference to it. The reference is taken first, and the archive is
+// only uploaded if no archive with the same content was recorded
+// already. It returns the storage URL of the archive; callers that do
+// not end up using it must drop the reference with ReleaseCharmArchive.
+func StoreCharmArchive(st *state.State, stor storage.Storage, r io.Reader, size int64, sha256 string) (*url.URL, error) {
+	archive, created, err := st.AddCharmArchiveRef(sha256)
+	if err != nil {
+		return nil, err
+	}
+	if created {
+		if err := stor.Put(archive.Path(), r, size); err != nil {
+			if err := ReleaseCharmArchive(st, stor, sha256); err != nil {
+				logger.Errorf("cannot release charm archive %q: %v", sha256, err)
+			}
+			return nil, errors.Annotate(err, "cannot upload charm to provider storage")
+		}
+	}
+	storageURL, err := stor.URL(archive.Path())
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot get storage URL for charm")
+	}
+	bundleURL, err := url.Parse(storageURL)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot parse storage URL")
+	}
+	return bundleURL, nil
+}
+
+// ReleaseCharmArchive drops a reference to the charm archive with the
+// given SHA256 hash. When no references remain, the archive is removed
+// from provider storage once its record has been removed; if it has
+// been referenced again in the meantime, it is kept.
+func ReleaseCharmArchive(st *state.State, stor storage.Storage, sha256 string) error {
+	archive, err := st.ReleaseCharmArchiveRef(sha256)
+	if err != nil {
+		return err
+	}
+	if archive.RefCount() > 0 {
+		return nil
+	}
+	switch err := archive.Remove(); {
+	case err == state.ErrCharmArchiveReferenced || errors.IsNotFound(err):
+		return nil
+	case err != nil:
+		return errors.Annotatef(err, "cannot remove charm archive %q", sha256)
+	}
+	if err := stor.Remove(archive.Path()); err != nil {
+		return errors.Annotate(err, "cannot remove charm from provider storage")
+	}
+	return nil
+}
@@ -123,6 +123,8 @@ func ServerError(err error) *params.Error {
 		code = params.CodeNoAddressSet
 	case state.IsNotProvisionedError(err):
 		code = params.CodeNotProvisioned
+	case state.IsOperationBlockedError(err):
+		code = params.CodeOperationBlocked
 	case IsUnknownEnviromentError(err):
 		code = params.CodeNotFound
 	default:
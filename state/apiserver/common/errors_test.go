@@ -79,6 +79,10 @@ var errorTransformTests = []struct {
 	err:        errors.AlreadyExistsf("blah"),
 	code:       params.CodeAlreadyExists,
 	helperFunc: params.IsCodeAlreadyExists,
+}, {
+	err:        &state.OperationBlockedError{Type: state.ChangeBlock, Message: "frozen"},
+	code:       params.CodeOperationBlocked,
+	helperFunc: params.IsCodeOperationBlocked,
 }, {
 	err:        common.ErrUnknownWatcher,
 	code:       params.CodeNotFound,
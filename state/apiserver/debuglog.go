@@ -50,7 +50,8 @@ func (h *debugLogHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	server := websocket.Server{
 		Handler: func(socket *websocket.Conn) {
 			logger.Infof("debug log handler starting")
-			if err := h.authenticate(req); err != nil {
+			// The log can be followed by read-only users.
+			if _, err := h.authenticateUser(req); err != nil {
 				h.sendError(socket, fmt.Errorf("auth failed: %v", err))
 				socket.Close()
 				return
@@ -8,6 +8,7 @@ import (
 
 	"github.com/juju/juju/state"
 	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/state/apiserver/authentication"
 	"github.com/juju/juju/state/apiserver/common"
 )
 
@@ -17,6 +18,7 @@ var (
 	MaxClientPingInterval = &maxClientPingInterval
 	MongoPingInterval     = &mongoPingInterval
 	UploadBackupToStorage = &uploadBackupToStorage
+	NewUserBackend        = &newUserBackend
 )
 
 const LoginRateLimit = loginRateLimit
@@ -31,9 +33,9 @@ func DelayLogins() (nextChan chan struct{}, cleanup func()) {
 	cleanup = func() {
 		doCheckCreds = checkCreds
 	}
-	delayedCheckCreds := func(st *state.State, c params.Creds) (state.Entity, error) {
+	delayedCheckCreds := func(st *state.State, c params.Creds, inUpgrade bool) (state.Entity, authentication.Access, error) {
 		<-nextChan
-		return checkCreds(st, c)
+		return checkCreds(st, c, inUpgrade)
 	}
 	doCheckCreds = delayedCheckCreds
 	return
@@ -63,3 +65,11 @@ func TestingUpgradingRoot(st *state.State) *upgradingRoot {
 		srvRoot: *TestingSrvRoot(st),
 	}
 }
+
+// TestingReadOnlyRoot returns a limited readOnlyRoot
+// containing a srvRoot as returned by TestingSrvRoot.
+func TestingReadOnlyRoot(st *state.State) *readOnlyRoot {
+	return &readOnlyRoot{
+		srvRoot: *TestingSrvRoot(st),
+	}
+}
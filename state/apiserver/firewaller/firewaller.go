@@ -118,6 +118,27 @@ func (f *FirewallerAPI) OpenedPorts(args params.Entities) (params.PortsResults,
 	return result, nil
 }
 
+// OpenedPortsOnNetworks returns the ports opened on each network by
+// each given unit.
+func (f *FirewallerAPI) OpenedPortsOnNetworks(args params.Entities) (params.NetworkPortsResults, error) {
+	result := params.NetworkPortsResults{
+		Results: make([]params.NetworkPortsResult, len(args.Entities)),
+	}
+	canAccess, err := f.accessUnit()
+	if err != nil {
+		return params.NetworkPortsResults{}, err
+	}
+	for i, entity := range args.Entities {
+		var unit *state.Unit
+		unit, err = f.getUnit(canAccess, entity.Tag)
+		if err == nil {
+			result.Results[i].Ports, err = unit.OpenedPortsOnNetworks()
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
 // GetExposed returns the exposed flag value for each given service.
 func (f *FirewallerAPI) GetExposed(args params.Entities) (params.BoolResults, error) {
 	result := params.BoolResults{
@@ -138,6 +159,28 @@ func (f *FirewallerAPI) GetExposed(args params.Entities) (params.BoolResults, er
 	return result, nil
 }
 
+// GetExposedPorts returns the ports exposed by each given service.
+// An empty result means that all the ports opened by the service's
+// units are exposed.
+func (f *FirewallerAPI) GetExposedPorts(args params.Entities) (params.PortsResults, error) {
+	result := params.PortsResults{
+		Results: make([]params.PortsResult, len(args.Entities)),
+	}
+	canAccess, err := f.accessService()
+	if err != nil {
+		return params.PortsResults{}, err
+	}
+	for i, entity := range args.Entities {
+		var service *state.Service
+		service, err = f.getService(canAccess, entity.Tag)
+		if err == nil {
+			result.Results[i].Ports = service.ExposedPorts()
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
 // GetAssignedMachine returns the assigned machine tag (if any) for
 // each given unit.
 func (f *FirewallerAPI) GetAssignedMachine(args params.Entities) (params.StringResults, error) {
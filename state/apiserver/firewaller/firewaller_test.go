@@ -325,6 +325,40 @@ func (s *firewallerSuite) TestGetExposed(c *gc.C) {
 	})
 }
 
+func (s *firewallerSuite) TestGetExposedPorts(c *gc.C) {
+	err := s.service.SetExposedPorts([]network.Port{{"tcp", 443}})
+	c.Assert(err, gc.IsNil)
+
+	args := addFakeEntities(params.Entities{Entities: []params.Entity{
+		{Tag: s.service.Tag().String()},
+	}})
+	result, err := s.firewaller.GetExposedPorts(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, jc.DeepEquals, params.PortsResults{
+		Results: []params.PortsResult{
+			{Ports: []network.Port{{"tcp", 443}}},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.NotFoundError(`service "bar"`)},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	// Exposing all ports gives no ports.
+	err = s.service.SetExposed()
+	c.Assert(err, gc.IsNil)
+	args = params.Entities{Entities: []params.Entity{
+		{Tag: s.service.Tag().String()},
+	}}
+	result, err = s.firewaller.GetExposedPorts(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, jc.DeepEquals, params.PortsResults{
+		Results: []params.PortsResult{{}},
+	})
+}
+
 func (s *firewallerSuite) TestOpenedPorts(c *gc.C) {
 	// Open some ports on two of the units.
 	err := s.units[0].OpenPort("tcp", 1234)
@@ -371,6 +405,42 @@ func (s *firewallerSuite) TestOpenedPorts(c *gc.C) {
 	})
 }
 
+func (s *firewallerSuite) TestOpenedPortsOnNetworks(c *gc.C) {
+	// Open some ports on two of the units, on two networks.
+	err := s.units[0].OpenPort("tcp", 1234)
+	c.Assert(err, gc.IsNil)
+	err = s.units[0].OpenPortOnNetwork("net1", "tcp", 4321)
+	c.Assert(err, gc.IsNil)
+	err = s.units[2].OpenPortOnNetwork("net1", "udp", 1111)
+	c.Assert(err, gc.IsNil)
+
+	args := addFakeEntities(params.Entities{Entities: []params.Entity{
+		{Tag: s.units[0].Tag().String()},
+		{Tag: s.units[1].Tag().String()},
+		{Tag: s.units[2].Tag().String()},
+	}})
+	result, err := s.firewaller.OpenedPortsOnNetworks(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, jc.DeepEquals, params.NetworkPortsResults{
+		Results: []params.NetworkPortsResult{
+			{Ports: map[string][]network.Port{
+				network.DefaultPublic: {{"tcp", 1234}},
+				"net1":                {{"tcp", 4321}},
+			}},
+			{Ports: map[string][]network.Port{}},
+			{Ports: map[string][]network.Port{
+				"net1": {{"udp", 1111}},
+			}},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.NotFoundError(`unit "foo/0"`)},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+}
+
 func (s *firewallerSuite) TestGetAssignedMachine(c *gc.C) {
 	// Unassign a unit first.
 	err := s.units[2].UnassignFromMachine()
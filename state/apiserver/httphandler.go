@@ -13,6 +13,7 @@ import (
 
 	"github.com/juju/juju/state"
 	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/state/apiserver/authentication"
 	"github.com/juju/juju/state/apiserver/common"
 )
 
@@ -28,32 +29,47 @@ type httpHandler struct {
 
 // authenticate parses HTTP basic authentication and authorizes the
 // request by looking up the provided tag and password against state.
+// The HTTP handlers that change the environment are not available
+// to read-only users.
 func (h *httpHandler) authenticate(r *http.Request) error {
+	access, err := h.authenticateUser(r)
+	if err != nil {
+		return err
+	}
+	if access != authentication.AdminAccess {
+		return common.ErrPerm
+	}
+	return nil
+}
+
+// authenticateUser parses HTTP basic authentication and returns
+// the access granted to the user whose credentials are provided.
+func (h *httpHandler) authenticateUser(r *http.Request) (authentication.Access, error) {
 	parts := strings.Fields(r.Header.Get("Authorization"))
 	if len(parts) != 2 || parts[0] != "Basic" {
 		// Invalid header format or no header provided.
-		return fmt.Errorf("invalid request format")
+		return "", fmt.Errorf("invalid request format")
 	}
 	// Challenge is a base64-encoded "tag:pass" string.
 	// See RFC 2617, Section 2.
 	challenge, err := base64.StdEncoding.DecodeString(parts[1])
 	if err != nil {
-		return fmt.Errorf("invalid request format")
+		return "", fmt.Errorf("invalid request format")
 	}
 	tagPass := strings.SplitN(string(challenge), ":", 2)
 	if len(tagPass) != 2 {
-		return fmt.Errorf("invalid request format")
+		return "", fmt.Errorf("invalid request format")
 	}
 	// Only allow users, not agents.
 	if _, err := names.ParseUserTag(tagPass[0]); err != nil {
-		return common.ErrBadCreds
+		return "", common.ErrBadCreds
 	}
 	// Ensure the credentials are correct.
-	_, err = checkCreds(h.state, params.Creds{
+	_, access, err := checkCreds(h.state, params.Creds{
 		AuthTag:  tagPass[0],
 		Password: tagPass[1],
-	})
-	return err
+	}, false)
+	return access, err
 }
 
 func (h *httpHandler) getEnvironUUID(r *http.Request) string {
@@ -85,3 +85,36 @@ func (api *MachinerAPI) SetMachineAddresses(args params.SetMachinesAddresses) (p
 	}
 	return results, nil
 }
+
+// RecordUtilization records resource utilization samples taken
+// by the machine agents.
+func (api *MachinerAPI) RecordUtilization(args params.RecordMachinesUtilization) (params.ErrorResults, error) {
+	results := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Machines)),
+	}
+	canModify, err := api.getCanModify()
+	if err != nil {
+		return params.ErrorResults{}, err
+	}
+	for i, arg := range args.Machines {
+		err := common.ErrPerm
+		if canModify(arg.Tag) {
+			var m *state.Machine
+			m, err = api.getMachine(arg.Tag)
+			if err == nil {
+				err = m.RecordUtilization(state.UtilizationSample{
+					Time:       arg.Sample.Time,
+					CPUPercent: arg.Sample.CPUPercent,
+					MemUsed:    arg.Sample.MemUsed,
+					MemTotal:   arg.Sample.MemTotal,
+					DiskUsed:   arg.Sample.DiskUsed,
+					DiskTotal:  arg.Sample.DiskTotal,
+				})
+			} else if errors.IsNotFound(err) {
+				err = common.ErrPerm
+			}
+		}
+		results.Results[i].Error = common.ServerError(err)
+	}
+	return results, nil
+}
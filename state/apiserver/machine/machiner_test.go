@@ -4,6 +4,8 @@
 package machine_test
 
 import (
+	"time"
+
 	gc "launchpad.net/gocheck"
 
 	"github.com/juju/juju/network"
@@ -182,6 +184,41 @@ func (s *machinerSuite) TestSetMachineAddresses(c *gc.C) {
 	c.Assert(s.machine0.MachineAddresses(), gc.HasLen, 0)
 }
 
+func (s *machinerSuite) TestRecordUtilization(c *gc.C) {
+	sample := params.UtilizationSample{
+		Time:       time.Now().UTC().Truncate(time.Second),
+		CPUPercent: 12.5,
+		MemUsed:    100,
+		MemTotal:   200,
+		DiskUsed:   300,
+		DiskTotal:  400,
+	}
+	args := params.RecordMachinesUtilization{Machines: []params.MachineUtilization{
+		{Tag: "machine-1", Sample: sample},
+		{Tag: "machine-0", Sample: sample},
+		{Tag: "machine-42", Sample: sample},
+	}}
+
+	result, err := s.machiner.RecordUtilization(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{nil},
+			{apiservertesting.ErrUnauthorized},
+			{apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	samples, err := s.machine1.Utilization()
+	c.Assert(err, gc.IsNil)
+	c.Assert(samples, gc.HasLen, 1)
+	c.Assert(samples[0].CPUPercent, gc.Equals, 12.5)
+	c.Assert(samples[0].DiskTotal, gc.Equals, uint64(400))
+	samples, err = s.machine0.Utilization()
+	c.Assert(err, gc.IsNil)
+	c.Assert(samples, gc.HasLen, 0)
+}
+
 func (s *machinerSuite) TestWatch(c *gc.C) {
 	c.Assert(s.resources.Count(), gc.Equals, 0)
 
@@ -101,6 +101,10 @@ func (n *NetworkerAPI) oneMachineInfo(id string) ([]network.Info, error) {
 			VLANTag:       nw.VLANTag(),
 			InterfaceName: iface.RawInterfaceName(),
 			Disabled:      iface.IsDisabled(),
+
+			InterfaceType:       iface.InterfaceType(),
+			ParentInterfaceName: iface.ParentInterfaceName(),
+			BondMode:            iface.BondMode(),
 		}
 	}
 	return info, nil
@@ -233,6 +233,7 @@ func (p *ProvisionerAPI) ContainerConfig() (params.ContainerConfig, error) {
 	result.Proxy = config.ProxySettings()
 	result.AptProxy = config.AptProxySettings()
 	result.PreferIPv6 = config.PreferIPv6()
+	result.ContainerNetworkType = config.ContainerNetworkType()
 	return result, nil
 }
 
@@ -359,6 +360,7 @@ func getProvisioningInfo(m *state.Machine) (*params.ProvisioningInfo, error) {
 		Placement:   m.Placement(),
 		Networks:    networks,
 		Jobs:        jobs,
+		Maintenance: m.InMaintenance(),
 	}, nil
 }
 
@@ -475,10 +477,11 @@ func networkParamsToStateParams(networks []params.Network, ifaces []params.Netwo
 			return nil, nil, err
 		}
 		stateNetworks[i] = state.NetworkInfo{
-			Name:       tag.Id(),
-			ProviderId: network.ProviderId,
-			CIDR:       network.CIDR,
-			VLANTag:    network.VLANTag,
+			Name:             tag.Id(),
+			ProviderId:       network.ProviderId,
+			CIDR:             network.CIDR,
+			VLANTag:          network.VLANTag,
+			AvailabilityZone: network.AvailabilityZone,
 		}
 	}
 	stateInterfaces := make([]state.NetworkInterfaceInfo, len(ifaces))
@@ -493,6 +496,10 @@ func networkParamsToStateParams(networks []params.Network, ifaces []params.Netwo
 			InterfaceName: iface.InterfaceName,
 			IsVirtual:     iface.IsVirtual,
 			Disabled:      iface.Disabled,
+
+			InterfaceType:       iface.InterfaceType,
+			ParentInterfaceName: iface.ParentInterfaceName,
+			BondMode:            iface.BondMode,
 		}
 	}
 	return stateNetworks, stateInterfaces, nil
@@ -739,6 +739,8 @@ func (s *withoutStateServerSuite) TestProvisioningInfo(c *gc.C) {
 	}
 	placementMachine, err := s.State.AddOneMachine(template)
 	c.Assert(err, gc.IsNil)
+	err = placementMachine.SetMaintenance()
+	c.Assert(err, gc.IsNil)
 
 	args := params.Entities{Entities: []params.Entity{
 		{Tag: s.machines[0].Tag().String()},
@@ -762,6 +764,7 @@ func (s *withoutStateServerSuite) TestProvisioningInfo(c *gc.C) {
 				Placement:   template.Placement,
 				Networks:    template.RequestedNetworks,
 				Jobs:        []params.MachineJob{params.JobHostUnits},
+				Maintenance: true,
 			}},
 			{Error: apiservertesting.NotFoundError("machine 42")},
 			{Error: apiservertesting.ErrUnauthorized},
@@ -0,0 +1,75 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"github.com/juju/utils/set"
+
+	"github.com/juju/juju/rpc/rpcreflect"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/apiserver/common"
+)
+
+type readOnlyRoot struct {
+	srvRoot
+}
+
+var _ apiRoot = (*readOnlyRoot)(nil)
+
+// newReadOnlyRoot creates a root for users granted read-only
+// access, where all API calls that change the environment
+// fail with common.ErrPerm.
+func newReadOnlyRoot(root *initialRoot, entity state.Entity) *readOnlyRoot {
+	return &readOnlyRoot{
+		srvRoot: *newSrvRoot(root, entity),
+	}
+}
+
+// FindMethod extends srvRoot.FindMethod. It returns common.ErrPerm
+// for all API calls except those that only look at the environment.
+func (r *readOnlyRoot) FindMethod(rootName string, version int, methodName string) (rpcreflect.MethodCaller, error) {
+	if _, _, err := r.lookupMethod(rootName, version, methodName); err != nil {
+		return nil, err
+	}
+	if !isMethodAllowedReadOnly(rootName, methodName) {
+		return nil, common.ErrPerm
+	}
+	return r.srvRoot.FindMethod(rootName, version, methodName)
+}
+
+var allowedMethodsReadOnly = set.NewStrings(
+	"AllWatcher.Next",
+	"AllWatcher.Stop",
+	"Client.APIActivity",
+	"Client.APIHostPorts",
+	"Client.AgentVersion",
+	"Client.CharmInfo",
+	"Client.EnvironmentGet",
+	"Client.EnvironmentInfo",
+	"Client.FindStatus",
+	"Client.FullStatus",
+	"Client.GetAnnotations",
+	"Client.GetEnvironmentConstraints",
+	"Client.GetInterfaceSchema",
+	"Client.GetServiceConstraints",
+	"Client.GetServiceEffectiveConstraints",
+	"Client.ListBlocks",
+	"Client.MachineUtilization",
+	"Client.PrivateAddress",
+	"Client.PublicAddress",
+	"Client.ServiceCharmRelations",
+	"Client.ServiceGet",
+	"Client.ServiceGetCharmURL",
+	"Client.Status",
+	"Client.UnusedMachines",
+	"Client.WatchAll",
+	"KeyManager.ListKeys",
+	"Pinger.Ping",
+	"UserManager.UserInfo",
+)
+
+func isMethodAllowedReadOnly(rootName, methodName string) bool {
+	fullName := rootName + "." + methodName
+	return allowedMethodsReadOnly.Contains(fullName)
+}
@@ -0,0 +1,44 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state/apiserver"
+	"github.com/juju/juju/testing"
+)
+
+type readOnlyRootSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&readOnlyRootSuite{})
+
+func (r *readOnlyRootSuite) TestFindAllowedMethod(c *gc.C) {
+	root := apiserver.TestingReadOnlyRoot(nil)
+
+	caller, err := root.FindMethod("Client", 0, "FullStatus")
+
+	c.Assert(err, gc.IsNil)
+	c.Assert(caller, gc.NotNil)
+}
+
+func (r *readOnlyRootSuite) TestFindDisallowedMethod(c *gc.C) {
+	root := apiserver.TestingReadOnlyRoot(nil)
+
+	caller, err := root.FindMethod("Client", 0, "ServiceDeploy")
+
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+	c.Assert(caller, gc.IsNil)
+}
+
+func (r *readOnlyRootSuite) TestFindNonExistentMethod(c *gc.C) {
+	root := apiserver.TestingReadOnlyRoot(nil)
+
+	caller, err := root.FindMethod("Foo", 0, "Bar")
+
+	c.Assert(err, gc.ErrorMatches, "unknown object type \"Foo\"")
+	c.Assert(caller, gc.IsNil)
+}
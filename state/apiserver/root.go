@@ -56,7 +56,7 @@ var _ apiRoot = (*srvRoot)(nil)
 // connection.
 func newSrvRoot(root *initialRoot, entity state.Entity) *srvRoot {
 	r := &srvRoot{
-		state:       root.srv.state,
+		state:       root.state,
 		rpcConn:     root.rpcConn,
 		resources:   common.NewResources(),
 		entity:      entity,
@@ -123,6 +123,9 @@ func (r *srvRoot) FindMethod(rootName string, version int, methodName string) (r
 	if err != nil {
 		return nil, err
 	}
+	if rootName != "Pinger" || methodName != "Ping" {
+		common.RecordAPICall()
+	}
 
 	creator := func(id string) (reflect.Value, error) {
 		objKey := objectKey{name: rootName, version: version, objId: id}
@@ -136,6 +136,30 @@ func (r *rootSuite) TestFindMethodUnknownVersion(c *gc.C) {
 	c.Check(err, gc.ErrorMatches, `unknown version \(1\) of interface "my-testing-facade"`)
 }
 
+func (r *rootSuite) TestFindMethodRecordsAPICall(c *gc.C) {
+	srvRoot := apiserver.TestingSrvRoot(nil)
+	defer common.Facades.Discard("my-testing-facade", 0)
+	myGoodFacade := func(
+		*state.State, *common.Resources, common.Authorizer,
+	) (
+		*testingType, error,
+	) {
+		return &testingType{}, nil
+	}
+	common.RegisterStandardFacade("my-testing-facade", 0, myGoodFacade)
+	before, _ := common.APIActivity()
+	_, err := srvRoot.FindMethod("my-testing-facade", 0, "Exposed")
+	c.Assert(err, gc.IsNil)
+	after, _ := common.APIActivity()
+	c.Assert(after, gc.Equals, before+1)
+
+	// Unknown methods are not counted.
+	_, err = srvRoot.FindMethod("my-testing-facade", 0, "Unknown")
+	c.Assert(err, gc.NotNil)
+	unchanged, _ := common.APIActivity()
+	c.Assert(unchanged, gc.Equals, after)
+}
+
 func (r *rootSuite) TestFindMethodEnsuresTypeMatch(c *gc.C) {
 	srvRoot := apiserver.TestingSrvRoot(nil)
 	defer common.Facades.Discard("my-testing-facade", 0)
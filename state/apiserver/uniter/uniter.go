@@ -1082,6 +1082,9 @@ func (u *UniterAPI) UpdateSettings(args params.RelationUnitsSettings) (params.Er
 	}
 	for i, arg := range args.RelationUnits {
 		relUnit, err := u.getRelationUnit(canAccess, arg.Relation, arg.Unit)
+		if err == nil {
+			err = u.checkInterfaceSchema(relUnit, arg.Settings)
+		}
 		if err == nil {
 			var settings *state.Settings
 			settings, err = relUnit.Settings()
@@ -1101,6 +1104,40 @@ func (u *UniterAPI) UpdateSettings(args params.RelationUnitsSettings) (params.Er
 	return result, nil
 }
 
+// ValidateSettings checks the given changes to the local settings of
+// all given pairs of relation and unit against the schema registered
+// for each relation's interface, without persisting them.
+func (u *UniterAPI) ValidateSettings(args params.RelationUnitsSettings) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.RelationUnits)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.ErrorResults{}, err
+	}
+	for i, arg := range args.RelationUnits {
+		relUnit, err := u.getRelationUnit(canAccess, arg.Relation, arg.Unit)
+		if err == nil {
+			err = u.checkInterfaceSchema(relUnit, arg.Settings)
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// checkInterfaceSchema returns an error if the given settings do not
+// conform to the schema registered for the relation unit's interface.
+// Interfaces without a registered schema accept any settings.
+func (u *UniterAPI) checkInterfaceSchema(relUnit *state.RelationUnit, settings params.RelationSettings) error {
+	schema, err := u.st.InterfaceSchema(relUnit.Endpoint().Interface)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return schema.ValidateSettings(settings)
+}
+
 func (u *UniterAPI) watchOneRelationUnit(relUnit *state.RelationUnit) (params.RelationUnitsWatchResult, error) {
 	watch := relUnit.Watch()
 	// Consume the initial event and forward it to the result.
@@ -14,6 +14,7 @@ import (
 	gc "launchpad.net/gocheck"
 
 	envtesting "github.com/juju/juju/environs/testing"
+	"github.com/juju/juju/interfaceschema"
 	"github.com/juju/juju/juju/testing"
 	"github.com/juju/juju/network"
 	"github.com/juju/juju/state"
@@ -1624,6 +1625,97 @@ func (s *uniterSuite) TestUpdateSettings(c *gc.C) {
 	})
 }
 
+func (s *uniterSuite) TestUpdateSettingsValidatesInterfaceSchema(c *gc.C) {
+	rel := s.addRelation(c, "wordpress", "mysql")
+	relUnit, err := rel.Unit(s.wordpressUnit)
+	c.Assert(err, gc.IsNil)
+	err = relUnit.EnterScope(nil)
+	c.Assert(err, gc.IsNil)
+	err = s.State.SetInterfaceSchema(interfaceschema.Schema{
+		Interface: "mysql",
+		Fields:    map[string]interfaceschema.FieldType{"port": interfaceschema.Int},
+	})
+	c.Assert(err, gc.IsNil)
+
+	args := params.RelationUnitsSettings{RelationUnits: []params.RelationUnitSettings{
+		{Relation: rel.Tag().String(), Unit: "unit-wordpress-0", Settings: params.RelationSettings{"port": "3306"}},
+		{Relation: rel.Tag().String(), Unit: "unit-wordpress-0", Settings: params.RelationSettings{"port": "mysql"}},
+	}}
+	result, err := s.uniter.UpdateSettings(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Results, gc.HasLen, 2)
+	c.Assert(result.Results[0].Error, gc.IsNil)
+	c.Assert(result.Results[1].Error, gc.ErrorMatches, `setting "port" of interface "mysql" must be of type int, got "mysql"`)
+
+	readSettings, err := relUnit.ReadSettings(s.wordpressUnit.Name())
+	c.Assert(err, gc.IsNil)
+	c.Assert(readSettings, gc.DeepEquals, map[string]interface{}{
+		"port": "3306",
+	})
+}
+
+func (s *uniterSuite) TestUpdateSettingsAllowsManagedSettings(c *gc.C) {
+	rel := s.addRelation(c, "wordpress", "mysql")
+	relUnit, err := rel.Unit(s.wordpressUnit)
+	c.Assert(err, gc.IsNil)
+	err = relUnit.EnterScope(nil)
+	c.Assert(err, gc.IsNil)
+	err = s.State.SetInterfaceSchema(interfaceschema.Schema{
+		Interface: "mysql",
+		Fields:    map[string]interfaceschema.FieldType{"port": interfaceschema.Int},
+		Strict:    true,
+	})
+	c.Assert(err, gc.IsNil)
+
+	// The uniter writes private-address on behalf of every unit,
+	// so a strict schema need not declare it.
+	args := params.RelationUnitsSettings{RelationUnits: []params.RelationUnitSettings{{
+		Relation: rel.Tag().String(),
+		Unit:     "unit-wordpress-0",
+		Settings: params.RelationSettings{"private-address": "10.0.0.1", "port": "3306"},
+	}}}
+	result, err := s.uniter.UpdateSettings(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.OneError(), gc.IsNil)
+}
+
+func (s *uniterSuite) TestValidateSettings(c *gc.C) {
+	rel := s.addRelation(c, "wordpress", "mysql")
+	relUnit, err := rel.Unit(s.wordpressUnit)
+	c.Assert(err, gc.IsNil)
+	err = relUnit.EnterScope(map[string]interface{}{"port": "3306"})
+	c.Assert(err, gc.IsNil)
+	err = s.State.SetInterfaceSchema(interfaceschema.Schema{
+		Interface: "mysql",
+		Fields:    map[string]interfaceschema.FieldType{"port": interfaceschema.Int},
+	})
+	c.Assert(err, gc.IsNil)
+
+	args := params.RelationUnitsSettings{RelationUnits: []params.RelationUnitSettings{
+		{Relation: rel.Tag().String(), Unit: "unit-wordpress-0", Settings: params.RelationSettings{"port": "3307"}},
+		{Relation: rel.Tag().String(), Unit: "unit-wordpress-0", Settings: params.RelationSettings{"port": "mysql"}},
+		{Relation: rel.Tag().String(), Unit: "unit-mysql-0", Settings: params.RelationSettings{"port": "3307"}},
+		{Relation: "relation-42", Unit: "unit-wordpress-0", Settings: nil},
+	}}
+	result, err := s.uniter.ValidateSettings(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{nil},
+			{&params.Error{Message: `setting "port" of interface "mysql" must be of type int, got "mysql"`}},
+			{apiservertesting.ErrUnauthorized},
+			{apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	// The settings are only validated, not changed.
+	readSettings, err := relUnit.ReadSettings(s.wordpressUnit.Name())
+	c.Assert(err, gc.IsNil)
+	c.Assert(readSettings, gc.DeepEquals, map[string]interface{}{
+		"port": "3306",
+	})
+}
+
 func (s *uniterSuite) TestWatchRelationUnits(c *gc.C) {
 	// Add a relation between wordpress and mysql and enter scope with
 	// mysqlUnit.
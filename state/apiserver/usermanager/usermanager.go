@@ -5,6 +5,7 @@ package usermanager
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/juju/errors"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/juju/juju/state"
 	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/state/apiserver/authentication"
 	"github.com/juju/juju/state/apiserver/common"
 )
 
@@ -27,6 +29,7 @@ type UserManager interface {
 	AddUser(arg ModifyUsers) (params.ErrorResults, error)
 	RemoveUser(arg params.Entities) (params.ErrorResults, error)
 	SetPassword(args ModifyUsers) (params.ErrorResults, error)
+	SetAccess(args ModifyUsers) (params.ErrorResults, error)
 }
 
 // UserInfo holds information on a user.
@@ -36,6 +39,7 @@ type UserInfo struct {
 	CreatedBy      string     `json:created-by`
 	DateCreated    time.Time  `json:date-created`
 	LastConnection *time.Time `json:last-connection`
+	Access         string     `json:"access"`
 }
 
 // UserInfoResult holds the result of a UserInfo call.
@@ -63,6 +67,11 @@ type ModifyUser struct {
 	Username    string
 	DisplayName string
 	Password    string
+
+	// Access holds the access the user is granted to the
+	// environment. Users added without it are granted admin
+	// access, as they were by older servers.
+	Access string
 }
 
 // UserManagerAPI implements the user manager interface and is the concrete
@@ -106,7 +115,16 @@ func (api *UserManagerAPI) AddUser(args ModifyUsers) (params.ErrorResults, error
 		if username == "" {
 			username = arg.Tag
 		}
-		_, err := api.state.AddUser(username, arg.DisplayName, arg.Password, user.Name())
+		if strings.HasPrefix(username, authentication.LDAPUserPrefix) {
+			err := errors.Errorf("failed to create user: names starting with %q are reserved for LDAP users", authentication.LDAPUserPrefix)
+			result.Results[i].Error = common.ServerError(err)
+			continue
+		}
+		access := state.EnvironmentAccess(arg.Access)
+		if access == "" {
+			access = state.EnvironmentAdminAccess
+		}
+		_, err := api.state.AddUserWithAccess(username, arg.DisplayName, arg.Password, user.Name(), access)
 		if err != nil {
 			err = errors.Annotate(err, "failed to create user")
 			result.Results[i].Error = common.ServerError(err)
@@ -161,6 +179,8 @@ func (api *UserManagerAPI) UserInfo(args params.Entities) (UserInfoResults, erro
 			} else {
 				result.Error = common.ServerError(err)
 			}
+		} else if access, err := user.EnvironmentAccess(); err != nil && !errors.IsNotFound(err) {
+			result.Error = common.ServerError(err)
 		} else {
 			info := UserInfo{
 				Username:       username,
@@ -168,6 +188,7 @@ func (api *UserManagerAPI) UserInfo(args params.Entities) (UserInfoResults, erro
 				CreatedBy:      user.CreatedBy(),
 				DateCreated:    user.DateCreated(),
 				LastConnection: user.LastConnection(),
+				Access:         string(access),
 			}
 			result.Result = &info
 		}
@@ -211,6 +232,28 @@ func (api *UserManagerAPI) SetPassword(args ModifyUsers) (params.ErrorResults, e
 	return result, nil
 }
 
+// SetAccess sets the access the users are granted to the environment.
+func (api *UserManagerAPI) SetAccess(args ModifyUsers) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Changes)),
+	}
+	for i, arg := range args.Changes {
+		username := arg.Username
+		if username == "" {
+			username = arg.Tag
+		}
+		user, err := api.state.User(username)
+		if errors.IsNotFound(err) {
+			err = common.ErrPerm
+		}
+		if err == nil {
+			err = user.SetEnvironmentAccess(state.EnvironmentAccess(arg.Access))
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
 func (api *UserManagerAPI) getLoggedInUser() *state.User {
 	entity := api.authorizer.GetAuthEntity()
 	if user, ok := entity.(*state.User); ok {
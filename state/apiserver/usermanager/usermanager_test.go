@@ -4,6 +4,7 @@
 package usermanager_test
 
 import (
+	"github.com/juju/errors"
 	"github.com/juju/names"
 	jc "github.com/juju/testing/checkers"
 	gc "launchpad.net/gocheck"
@@ -68,6 +69,50 @@ func (s *userManagerSuite) TestAddUser(c *gc.C) {
 	c.Assert(user, gc.NotNil)
 	c.Assert(user.Name(), gc.Equals, "foobar")
 	c.Assert(user.DisplayName(), gc.Equals, "Foo Bar")
+	access, err := user.EnvironmentAccess()
+	c.Assert(err, gc.IsNil)
+	c.Assert(access, gc.Equals, state.EnvironmentAdminAccess)
+}
+
+func (s *userManagerSuite) TestAddUserReservedForLDAP(c *gc.C) {
+	args := usermanager.ModifyUsers{
+		Changes: []usermanager.ModifyUser{{
+			Username: "ldap+admin",
+			Password: "password",
+		}}}
+
+	result, err := s.usermanager.AddUser(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Results, gc.HasLen, 1)
+	c.Assert(result.Results[0].Error, gc.ErrorMatches, `failed to create user: names starting with "ldap\+" are reserved for LDAP users`)
+	_, err = s.State.User("ldap+admin")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *userManagerSuite) TestAddUserWithAccess(c *gc.C) {
+	args := usermanager.ModifyUsers{
+		Changes: []usermanager.ModifyUser{{
+			Username: "foobar",
+			Password: "password",
+			Access:   "read",
+		}, {
+			Username: "barfoo",
+			Password: "password",
+			Access:   "superuser",
+		}}}
+
+	result, err := s.usermanager.AddUser(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Results, gc.HasLen, 2)
+	c.Assert(result.Results[0], gc.DeepEquals, params.ErrorResult{Error: nil})
+	c.Assert(result.Results[1].Error, gc.ErrorMatches, `failed to create user: unknown environment access "superuser"`)
+	user, err := s.State.User("foobar")
+	c.Assert(err, gc.IsNil)
+	access, err := user.EnvironmentAccess()
+	c.Assert(err, gc.IsNil)
+	c.Assert(access, gc.Equals, state.EnvironmentReadAccess)
+	_, err = s.State.User("barfoo")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
 }
 
 func (s *userManagerSuite) TestRemoveUser(c *gc.C) {
@@ -145,6 +190,7 @@ func (s *userManagerSuite) TestUserInfoUsersExist(c *gc.C) {
 					CreatedBy:      "admin",
 					DateCreated:    userFoo.DateCreated(),
 					LastConnection: userFoo.LastConnection(),
+					Access:         "admin",
 				},
 			}, {
 				Result: &usermanager.UserInfo{
@@ -153,6 +199,7 @@ func (s *userManagerSuite) TestUserInfoUsersExist(c *gc.C) {
 					CreatedBy:      "admin",
 					DateCreated:    userBar.DateCreated(),
 					LastConnection: userBar.LastConnection(),
+					Access:         "admin",
 				},
 			}},
 	}
@@ -179,6 +226,7 @@ func (s *userManagerSuite) TestUserInfoUserExists(c *gc.C) {
 					CreatedBy:      "admin",
 					DateCreated:    user.DateCreated(),
 					LastConnection: user.LastConnection(),
+					Access:         "admin",
 				},
 			},
 		},
@@ -309,6 +357,41 @@ func (s *userManagerSuite) TestSetMultiplePasswords(c *gc.C) {
 // Because at present all user are admins problems could be caused by allowing
 // users to change other users passwords. For the time being we only allow
 // the password of the current user to be changed
+func (s *userManagerSuite) TestSetAccess(c *gc.C) {
+	s.Factory.MakeUser(factory.UserParams{Username: "foobar"})
+	args := usermanager.ModifyUsers{
+		Changes: []usermanager.ModifyUser{{
+			Username: "foobar",
+			Access:   "read",
+		}, {
+			Username: "admin",
+			Access:   "read",
+		}, {
+			Username: "nobody",
+			Access:   "read",
+		}}}
+	results, err := s.usermanager.SetAccess(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{Error: nil},
+			{Error: &params.Error{
+				Message: `cannot set environment access of user "admin": cannot restrict admin user`,
+			}},
+			{Error: &params.Error{
+				Message: "permission denied",
+				Code:    params.CodeUnauthorized,
+			}},
+		},
+	})
+
+	user, err := s.State.User("foobar")
+	c.Assert(err, gc.IsNil)
+	access, err := user.EnvironmentAccess()
+	c.Assert(err, gc.IsNil)
+	c.Assert(access, gc.Equals, state.EnvironmentReadAccess)
+}
+
 func (s *userManagerSuite) TestSetPasswordOnDifferentUser(c *gc.C) {
 	s.Factory.MakeUser(factory.UserParams{Username: "foobar"})
 	args := usermanager.ModifyUsers{
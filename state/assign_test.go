@@ -99,6 +99,23 @@ func (s *AssignSuite) TestAssignUnitToMachineAgainFails(c *gc.C) {
 	c.Assert(machineId, gc.Equals, "0")
 }
 
+func (s *AssignSuite) TestAssignUnitToMachineInMaintenance(c *gc.C) {
+	unit, err := s.wordpress.AddUnit()
+	c.Assert(err, gc.IsNil)
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = machine.SetMaintenance()
+	c.Assert(err, gc.IsNil)
+
+	err = unit.AssignToMachine(machine)
+	c.Assert(err, gc.ErrorMatches, `cannot assign unit "wordpress/0" to machine 0: machine "0" is in maintenance`)
+
+	err = machine.ClearMaintenance()
+	c.Assert(err, gc.IsNil)
+	err = unit.AssignToMachine(machine)
+	c.Assert(err, gc.IsNil)
+}
+
 func (s *AssignSuite) TestAssignedMachineIdWhenNotAlive(c *gc.C) {
 	unit, err := s.wordpress.AddUnit()
 	c.Assert(err, gc.IsNil)
@@ -1070,6 +1087,24 @@ func (s *assignCleanSuite) TestAssignUnitPolicy(c *gc.C) {
 	c.Assert(got, gc.DeepEquals, expectedMachines)
 }
 
+func (s *assignCleanSuite) TestAssignUnitPolicySkipsMachineInMaintenance(c *gc.C) {
+	_, err := s.State.AddMachine("quantal", state.JobManageEnviron) // bootstrap machine
+	c.Assert(err, gc.IsNil)
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = machine.SetMaintenance()
+	c.Assert(err, gc.IsNil)
+
+	unit, err := s.wordpress.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = s.State.AssignUnit(unit, s.policy)
+	c.Assert(err, gc.IsNil)
+	mid, err := unit.AssignedMachineId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(mid, gc.Not(gc.Equals), machine.Id())
+	s.assertMachineEmpty(c, machine)
+}
+
 func (s *assignCleanSuite) TestAssignUnitPolicyWithContainers(c *gc.C) {
 	_, err := s.State.AddMachine("quantal", state.JobManageEnviron) // bootstrap machine
 	c.Assert(err, gc.IsNil)
@@ -0,0 +1,240 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+)
+
+// AuditOperation describes how a document was changed.
+type AuditOperation string
+
+const (
+	AuditInsert AuditOperation = "insert"
+	AuditUpdate AuditOperation = "update"
+	AuditRemove AuditOperation = "remove"
+)
+
+// AuditChange records the change of a single document.
+type AuditChange struct {
+	// Collection and Id identify the changed document.
+	Collection string
+	Id         string
+
+	// Operation holds how the document was changed.
+	Operation AuditOperation
+
+	// Fields holds the names of the fields set or unset by an
+	// update. The values are not recorded, so that secrets such
+	// as password hashes do not end up in the audit trail.
+	Fields []string
+}
+
+// AuditEntry records the changes made to state by a single
+// transaction run on behalf of a user. Changes made by agents
+// and by the state server's own workers are not recorded.
+type AuditEntry struct {
+	Time time.Time
+
+	// Who holds the tag of the user on whose behalf
+	// the changes were made.
+	Who string
+
+	Changes []AuditChange
+}
+
+// AuditQuery selects entries of the audit trail.
+// Zero fields select all entries.
+type AuditQuery struct {
+	// Since selects the entries recorded at or after the given time.
+	Since time.Time
+
+	// Who selects the entries of changes made by the given entity.
+	Who string
+
+	// Collection and Id select the entries of changes to the
+	// documents of a collection, or to a single document.
+	Collection string
+	Id         string
+
+	// Limit caps the number of entries returned.
+	Limit int
+}
+
+// unauditedCollections holds the collections whose changes are not
+// recorded in the audit trail, because they are frequent and of no
+// interest to anybody reviewing changes to the environment.
+var unauditedCollections = map[string]bool{
+	auditC:  true,
+	leasesC: true,
+}
+
+type auditDoc struct {
+	Id      bson.ObjectId `bson:"_id"`
+	Time    time.Time
+	Who     string
+	Changes []auditChangeDoc
+}
+
+type auditChangeDoc struct {
+	Collection string
+	Id         string `bson:"id"`
+	Operation  AuditOperation
+	Fields     []string `bson:",omitempty"`
+}
+
+func (doc *auditDoc) entry() AuditEntry {
+	entry := AuditEntry{
+		Time:    doc.Time,
+		Who:     doc.Who,
+		Changes: make([]AuditChange, len(doc.Changes)),
+	}
+	for i, change := range doc.Changes {
+		entry.Changes[i] = AuditChange{
+			Collection: change.Collection,
+			Id:         change.Id,
+			Operation:  change.Operation,
+			Fields:     change.Fields,
+		}
+	}
+	return entry
+}
+
+// withAuditOp returns the given operations followed by the one that
+// records their changes in the audit trail, so that the record is
+// written by the same transaction as the changes. The operations are
+// returned unchanged if the state does not act on behalf of a user,
+// or if none of them changes an audited document.
+func (st *State) withAuditOp(ops []txn.Op) []txn.Op {
+	if st.auditTag.Id() == "" {
+		return ops
+	}
+	var changes []auditChangeDoc
+	for _, op := range ops {
+		if unauditedCollections[op.C] {
+			continue
+		}
+		change := auditChangeDoc{
+			Collection: op.C,
+			Id:         fmt.Sprint(op.Id),
+		}
+		switch {
+		case op.Insert != nil:
+			change.Operation = AuditInsert
+		case op.Update != nil:
+			change.Operation = AuditUpdate
+			change.Fields = updatedFields(op.Update)
+		case op.Remove:
+			change.Operation = AuditRemove
+		default:
+			// The operation only asserts.
+			continue
+		}
+		changes = append(changes, change)
+	}
+	if len(changes) == 0 {
+		return ops
+	}
+	doc := &auditDoc{
+		Id:      bson.NewObjectId(),
+		Time:    time.Now().UTC(),
+		Who:     st.auditTag.String(),
+		Changes: changes,
+	}
+	audited := make([]txn.Op, len(ops), len(ops)+1)
+	copy(audited, ops)
+	return append(audited, txn.Op{
+		C:      auditC,
+		Id:     doc.Id,
+		Assert: txn.DocMissing,
+		Insert: doc,
+	})
+}
+
+// updatedFields returns the sorted names of the fields changed by
+// the given update document, or nil if they cannot be determined.
+func updatedFields(update interface{}) []string {
+	var operators []bson.DocElem
+	switch update := update.(type) {
+	case bson.D:
+		operators = update
+	case bson.M:
+		for name, value := range update {
+			operators = append(operators, bson.DocElem{Name: name, Value: value})
+		}
+	default:
+		return nil
+	}
+	var fields []string
+	for _, operator := range operators {
+		switch changes := operator.Value.(type) {
+		case bson.D:
+			for _, change := range changes {
+				fields = append(fields, change.Name)
+			}
+		case bson.M:
+			for name := range changes {
+				fields = append(fields, name)
+			}
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// AuditTrail returns the entries of the audit trail selected by the
+// query, newest first.
+func (st *State) AuditTrail(query AuditQuery) ([]AuditEntry, error) {
+	audit, closer := st.getCollection(auditC)
+	defer closer()
+
+	var sel bson.D
+	if !query.Since.IsZero() {
+		sel = append(sel, bson.DocElem{Name: "time", Value: bson.D{{"$gte", query.Since.UTC()}}})
+	}
+	if query.Who != "" {
+		sel = append(sel, bson.DocElem{Name: "who", Value: query.Who})
+	}
+	if query.Collection != "" || query.Id != "" {
+		var match bson.D
+		if query.Collection != "" {
+			match = append(match, bson.DocElem{Name: "collection", Value: query.Collection})
+		}
+		if query.Id != "" {
+			match = append(match, bson.DocElem{Name: "id", Value: query.Id})
+		}
+		sel = append(sel, bson.DocElem{Name: "changes", Value: bson.D{{"$elemMatch", match}}})
+	}
+	q := audit.Find(sel).Sort("-time", "-_id")
+	if query.Limit > 0 {
+		q = q.Limit(query.Limit)
+	}
+	var docs []auditDoc
+	if err := q.All(&docs); err != nil {
+		return nil, fmt.Errorf("cannot get audit trail: %v", err)
+	}
+	entries := make([]AuditEntry, len(docs))
+	for i, doc := range docs {
+		entries[i] = doc.entry()
+	}
+	return entries, nil
+}
+
+// PruneAuditTrail removes the entries of the audit trail
+// recorded before the given time.
+func (st *State) PruneAuditTrail(before time.Time) error {
+	audit, closer := st.getCollection(auditC)
+	defer closer()
+
+	_, err := audit.RemoveAll(bson.D{{"time", bson.D{{"$lt", before.UTC()}}}})
+	if err != nil {
+		return fmt.Errorf("cannot prune audit trail: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"time"
+
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+)
+
+type AuditSuite struct {
+	ConnSuite
+	// userState changes state on behalf of user-bob.
+	userState *state.State
+}
+
+var _ = gc.Suite(&AuditSuite{})
+
+func (s *AuditSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	st, err := s.State.ForUser(names.NewUserTag("bob"))
+	c.Assert(err, gc.IsNil)
+	s.userState = st
+}
+
+func (s *AuditSuite) TearDownTest(c *gc.C) {
+	if s.userState != nil {
+		s.userState.Close()
+	}
+	s.ConnSuite.TearDownTest(c)
+}
+
+func (s *AuditSuite) TestAddMachineRecorded(c *gc.C) {
+	before := time.Now().Add(-time.Second)
+	_, err := s.userState.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	entries, err := s.State.AuditTrail(state.AuditQuery{
+		Collection: "machines",
+		Id:         "0",
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(entries, gc.HasLen, 1)
+	entry := entries[0]
+	c.Assert(entry.Time.Before(before), jc.IsFalse)
+	c.Assert(entry.Who, gc.Equals, "user-bob")
+	assertHasChange(c, entry, state.AuditChange{
+		Collection: "machines",
+		Id:         "0",
+		Operation:  state.AuditInsert,
+	})
+}
+
+func (s *AuditSuite) TestUpdateRecordsFieldNames(c *gc.C) {
+	svc := state.AddTestingService(c, s.userState, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	err := svc.SetExposed()
+	c.Assert(err, gc.IsNil)
+
+	entries, err := s.State.AuditTrail(state.AuditQuery{
+		Collection: "services",
+		Id:         "wordpress",
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(entries, gc.HasLen, 2)
+	// The newest entry comes first.
+	c.Assert(entries[0].Changes, jc.DeepEquals, []state.AuditChange{{
+		Collection: "services",
+		Id:         "wordpress",
+		Operation:  state.AuditUpdate,
+		Fields:     []string{"exposed", "exposedports"},
+	}})
+	assertHasChange(c, entries[1], state.AuditChange{
+		Collection: "services",
+		Id:         "wordpress",
+		Operation:  state.AuditInsert,
+	})
+}
+
+func (s *AuditSuite) TestQuerySinceAndLimit(c *gc.C) {
+	_, err := s.userState.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	since := time.Now()
+	_, err = s.userState.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	_, err = s.userState.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	entries, err := s.State.AuditTrail(state.AuditQuery{
+		Since:      since,
+		Collection: "machines",
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(entries, gc.HasLen, 2)
+	assertHasChange(c, entries[0], state.AuditChange{
+		Collection: "machines",
+		Id:         "2",
+		Operation:  state.AuditInsert,
+	})
+	assertHasChange(c, entries[1], state.AuditChange{
+		Collection: "machines",
+		Id:         "1",
+		Operation:  state.AuditInsert,
+	})
+
+	entries, err = s.State.AuditTrail(state.AuditQuery{
+		Collection: "machines",
+		Limit:      1,
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(entries, gc.HasLen, 1)
+	assertHasChange(c, entries[0], state.AuditChange{
+		Collection: "machines",
+		Id:         "2",
+		Operation:  state.AuditInsert,
+	})
+}
+
+func (s *AuditSuite) TestQueryWho(c *gc.C) {
+	_, err := s.userState.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	entries, err := s.State.AuditTrail(state.AuditQuery{Who: "user-bob"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(entries, gc.HasLen, 1)
+	entries, err = s.State.AuditTrail(state.AuditQuery{Who: "user-mary"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(entries, gc.HasLen, 0)
+}
+
+func (s *AuditSuite) TestChangesNotOnBehalfOfUserNotRecorded(c *gc.C) {
+	_, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	entries, err := s.State.AuditTrail(state.AuditQuery{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(entries, gc.HasLen, 0)
+}
+
+func (s *AuditSuite) TestLeasesNotRecorded(c *gc.C) {
+	held, err := s.userState.ClaimLease("some-lease", "machine-0", time.Minute)
+	c.Assert(err, gc.IsNil)
+	c.Assert(held, jc.IsTrue)
+
+	entries, err := s.State.AuditTrail(state.AuditQuery{Collection: "leases"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(entries, gc.HasLen, 0)
+}
+
+func (s *AuditSuite) TestPruneAuditTrail(c *gc.C) {
+	_, err := s.userState.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	entries, err := s.State.AuditTrail(state.AuditQuery{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(entries, gc.Not(gc.HasLen), 0)
+
+	err = s.State.PruneAuditTrail(time.Now().Add(-time.Hour))
+	c.Assert(err, gc.IsNil)
+	pruned, err := s.State.AuditTrail(state.AuditQuery{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(pruned, gc.HasLen, len(entries))
+
+	err = s.State.PruneAuditTrail(time.Now().Add(time.Second))
+	c.Assert(err, gc.IsNil)
+	pruned, err = s.State.AuditTrail(state.AuditQuery{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(pruned, gc.HasLen, 0)
+}
+
+// assertHasChange asserts that the audit entry records the given change.
+func assertHasChange(c *gc.C, entry state.AuditEntry, expect state.AuditChange) {
+	for _, change := range entry.Changes {
+		if change.Collection == expect.Collection && change.Id == expect.Id {
+			c.Assert(change, jc.DeepEquals, expect)
+			return
+		}
+	}
+	c.Fatalf("change %#v not found in %#v", expect, entry.Changes)
+}
@@ -0,0 +1,184 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+
+	"github.com/juju/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+)
+
+// BlockType identifies the kind of operations prevented by a block.
+type BlockType string
+
+const (
+	// DestroyBlock prevents the environment from being destroyed.
+	DestroyBlock BlockType = "destroy-environment"
+
+	// RemoveBlock prevents machines, services, units and relations
+	// from being removed, as well as the environment from being
+	// destroyed.
+	RemoveBlock BlockType = "remove-object"
+
+	// ChangeBlock prevents all changes to the environment.
+	ChangeBlock BlockType = "all-changes"
+)
+
+// AllBlockTypes holds every known block type, ordered from the least
+// to the most restrictive.
+var AllBlockTypes = []BlockType{DestroyBlock, RemoveBlock, ChangeBlock}
+
+// ParseBlockType returns the block type with the given name.
+func ParseBlockType(name string) (BlockType, error) {
+	for _, t := range AllBlockTypes {
+		if string(t) == name {
+			return t, nil
+		}
+	}
+	return "", errors.Errorf("unknown block type %q", name)
+}
+
+// OperationBlockedError is returned when an operation is refused
+// because of a block.
+type OperationBlockedError struct {
+	Type    BlockType
+	Message string
+}
+
+func (e *OperationBlockedError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("the operation has been blocked: %s", e.Message)
+	}
+	return fmt.Sprintf("the operation has been blocked by %q", string(e.Type))
+}
+
+// IsOperationBlockedError returns whether err is an
+// OperationBlockedError.
+func IsOperationBlockedError(err error) bool {
+	_, ok := errors.Cause(err).(*OperationBlockedError)
+	return ok
+}
+
+// Block represents a block on some kind of operations in the
+// environment.
+type Block struct {
+	doc blockDoc
+}
+
+// blockDoc records a block, keyed on its type.
+type blockDoc struct {
+	Type    BlockType `bson:"_id"`
+	Message string
+}
+
+// Type returns the kind of operations prevented by the block.
+func (b *Block) Type() BlockType {
+	return b.doc.Type
+}
+
+// Message returns the message explaining why the block was set.
+func (b *Block) Message() string {
+	return b.doc.Message
+}
+
+// SwitchBlockOn sets a block of the given type, replacing the message
+// of any such block already set.
+func (st *State) SwitchBlockOn(t BlockType, message string) (err error) {
+	defer errors.Maskf(&err, "cannot switch on %q block", string(t))
+	if _, err := ParseBlockType(string(t)); err != nil {
+		return err
+	}
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		blocks, closer := st.getCollection(blocksC)
+		defer closer()
+		count, err := blocks.FindId(t).Count()
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return []txn.Op{{
+				C:      blocksC,
+				Id:     t,
+				Assert: txn.DocMissing,
+				Insert: &blockDoc{Type: t, Message: message},
+			}}, nil
+		}
+		return []txn.Op{{
+			C:      blocksC,
+			Id:     t,
+			Assert: txn.DocExists,
+			Update: bson.D{{"$set", bson.D{{"message", message}}}},
+		}}, nil
+	}
+	return st.runDiagnosed(buildTxn)
+}
+
+// SwitchBlockOff removes the block of the given type. It is not an
+// error to remove a block that is not set.
+func (st *State) SwitchBlockOff(t BlockType) error {
+	ops := []txn.Op{{
+		C:      blocksC,
+		Id:     t,
+		Remove: true,
+	}}
+	if err := st.runTransaction(ops); err != nil {
+		return errors.Annotatef(err, "cannot switch off %q block", string(t))
+	}
+	return nil
+}
+
+// AllBlocks returns all the blocks currently set, ordered from the
+// least to the most restrictive.
+func (st *State) AllBlocks() ([]*Block, error) {
+	blocks, closer := st.getCollection(blocksC)
+	defer closer()
+	var result []*Block
+	for _, t := range AllBlockTypes {
+		var doc blockDoc
+		err := blocks.FindId(t).One(&doc)
+		if err == mgo.ErrNotFound {
+			continue
+		} else if err != nil {
+			return nil, errors.Annotate(err, "cannot read blocks")
+		}
+		result = append(result, &Block{doc: doc})
+	}
+	return result, nil
+}
+
+// CheckChangeAllowed returns an OperationBlockedError if changes to
+// the environment are blocked.
+func (st *State) CheckChangeAllowed() error {
+	return st.checkBlocks(ChangeBlock)
+}
+
+// CheckRemoveAllowed returns an OperationBlockedError if removing
+// entities from the environment is blocked.
+func (st *State) CheckRemoveAllowed() error {
+	return st.checkBlocks(RemoveBlock, ChangeBlock)
+}
+
+// CheckDestroyAllowed returns an OperationBlockedError if destroying
+// the environment is blocked.
+func (st *State) CheckDestroyAllowed() error {
+	return st.checkBlocks(DestroyBlock, RemoveBlock, ChangeBlock)
+}
+
+// checkBlocks returns an OperationBlockedError for the first block
+// set of the given types.
+func (st *State) checkBlocks(types ...BlockType) error {
+	blocks, closer := st.getCollection(blocksC)
+	defer closer()
+	var doc blockDoc
+	err := blocks.Find(bson.D{{"_id", bson.D{{"$in", types}}}}).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return errors.Annotate(err, "cannot read blocks")
+	}
+	return &OperationBlockedError{Type: doc.Type, Message: doc.Message}
+}
@@ -0,0 +1,119 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+)
+
+type BlockSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&BlockSuite{})
+
+func (s *BlockSuite) assertBlocks(c *gc.C, expected map[state.BlockType]string) {
+	blocks, err := s.State.AllBlocks()
+	c.Assert(err, gc.IsNil)
+	actual := make(map[state.BlockType]string)
+	for _, block := range blocks {
+		actual[block.Type()] = block.Message()
+	}
+	c.Assert(actual, jc.DeepEquals, expected)
+}
+
+func (s *BlockSuite) TestParseBlockType(c *gc.C) {
+	for _, t := range state.AllBlockTypes {
+		parsed, err := state.ParseBlockType(string(t))
+		c.Assert(err, gc.IsNil)
+		c.Assert(parsed, gc.Equals, t)
+	}
+	_, err := state.ParseBlockType("everything")
+	c.Assert(err, gc.ErrorMatches, `unknown block type "everything"`)
+}
+
+func (s *BlockSuite) TestSwitchBlockOnOff(c *gc.C) {
+	s.assertBlocks(c, map[state.BlockType]string{})
+
+	err := s.State.SwitchBlockOn(state.RemoveBlock, "production")
+	c.Assert(err, gc.IsNil)
+	s.assertBlocks(c, map[state.BlockType]string{state.RemoveBlock: "production"})
+
+	// Switching a block on again replaces its message.
+	err = s.State.SwitchBlockOn(state.RemoveBlock, "really production")
+	c.Assert(err, gc.IsNil)
+	err = s.State.SwitchBlockOn(state.DestroyBlock, "")
+	c.Assert(err, gc.IsNil)
+	s.assertBlocks(c, map[state.BlockType]string{
+		state.DestroyBlock: "",
+		state.RemoveBlock:  "really production",
+	})
+
+	err = s.State.SwitchBlockOff(state.RemoveBlock)
+	c.Assert(err, gc.IsNil)
+	s.assertBlocks(c, map[state.BlockType]string{state.DestroyBlock: ""})
+
+	// Switching off a block that is not set is fine.
+	err = s.State.SwitchBlockOff(state.RemoveBlock)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *BlockSuite) TestSwitchBlockOnInvalidType(c *gc.C) {
+	err := s.State.SwitchBlockOn(state.BlockType("everything"), "")
+	c.Assert(err, gc.ErrorMatches, `cannot switch on "everything" block: unknown block type "everything"`)
+	s.assertBlocks(c, map[state.BlockType]string{})
+}
+
+func (s *BlockSuite) assertAllowed(c *gc.C, destroy, remove, change bool) {
+	for _, check := range []struct {
+		allowed bool
+		check   func() error
+	}{
+		{destroy, s.State.CheckDestroyAllowed},
+		{remove, s.State.CheckRemoveAllowed},
+		{change, s.State.CheckChangeAllowed},
+	} {
+		err := check.check()
+		if check.allowed {
+			c.Assert(err, gc.IsNil)
+		} else {
+			c.Assert(err, jc.Satisfies, state.IsOperationBlockedError)
+		}
+	}
+}
+
+func (s *BlockSuite) TestChecks(c *gc.C) {
+	s.assertAllowed(c, true, true, true)
+
+	err := s.State.SwitchBlockOn(state.DestroyBlock, "")
+	c.Assert(err, gc.IsNil)
+	s.assertAllowed(c, false, true, true)
+
+	err = s.State.SwitchBlockOn(state.RemoveBlock, "")
+	c.Assert(err, gc.IsNil)
+	s.assertAllowed(c, false, false, true)
+
+	err = s.State.SwitchBlockOff(state.DestroyBlock)
+	c.Assert(err, gc.IsNil)
+	err = s.State.SwitchBlockOff(state.RemoveBlock)
+	c.Assert(err, gc.IsNil)
+	err = s.State.SwitchBlockOn(state.ChangeBlock, "")
+	c.Assert(err, gc.IsNil)
+	s.assertAllowed(c, false, false, false)
+}
+
+func (s *BlockSuite) TestOperationBlockedError(c *gc.C) {
+	err := s.State.SwitchBlockOn(state.RemoveBlock, "do not touch production")
+	c.Assert(err, gc.IsNil)
+	err = s.State.CheckRemoveAllowed()
+	c.Assert(err, gc.ErrorMatches, "the operation has been blocked: do not touch production")
+
+	err = s.State.SwitchBlockOn(state.RemoveBlock, "")
+	c.Assert(err, gc.IsNil)
+	err = s.State.CheckRemoveAllowed()
+	c.Assert(err, gc.ErrorMatches, `the operation has been blocked by "remove-object"`)
+}
@@ -0,0 +1,366 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/juju/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+)
+
+// BlockDevice represents the state of a disk or volume attached to a
+// machine. A block device is requested with a size and, optionally, a
+// filesystem and mount point; the provider volume backing it and the
+// name of the device on the machine are recorded once it has been
+// provisioned.
+type BlockDevice struct {
+	st  *State
+	doc blockDeviceDoc
+}
+
+// BlockDeviceParams describes a block device to be added to a machine.
+type BlockDeviceParams struct {
+	// Size is the size of the block device, in MiB.
+	Size uint64
+
+	// Filesystem is the type of filesystem to create on the block
+	// device, or empty if the device is to be left unformatted.
+	Filesystem string
+
+	// MountPoint is the path the filesystem is to be mounted on. It
+	// must be empty if Filesystem is.
+	MountPoint string
+
+	// StorageInstanceId is the id of the storage instance the block
+	// device provides, or empty if it provides none.
+	StorageInstanceId string
+}
+
+// blockDeviceDoc represents a block device attached to a machine. The
+// id is made of the machine id and a sequence number, so that changes
+// to the block devices of a machine can be filtered by id.
+type blockDeviceDoc struct {
+	Id         string `bson:"_id"`
+	MachineId  string
+	Size       uint64
+	Filesystem string `bson:",omitempty"`
+	MountPoint string `bson:",omitempty"`
+	DeviceName string `bson:",omitempty"`
+	VolumeId   string `bson:",omitempty"`
+	Life       Life
+
+	StorageInstanceId string `bson:",omitempty"`
+}
+
+func newBlockDevice(st *State, doc *blockDeviceDoc) *BlockDevice {
+	return &BlockDevice{st, *doc}
+}
+
+// validate returns an error if the block device parameters are invalid.
+func (p BlockDeviceParams) validate() error {
+	if p.Size == 0 {
+		return fmt.Errorf("size must be positive")
+	}
+	if p.MountPoint != "" {
+		if p.Filesystem == "" {
+			return fmt.Errorf("mount point %q given without a filesystem", p.MountPoint)
+		}
+		if !strings.HasPrefix(p.MountPoint, "/") {
+			return fmt.Errorf("mount point %q is not an absolute path", p.MountPoint)
+		}
+	}
+	return nil
+}
+
+// blockDeviceIdPrefix returns the prefix of the ids
+// of the block devices of the given machine.
+func blockDeviceIdPrefix(machineId string) string {
+	return machineId + "#"
+}
+
+// GoString implements fmt.GoStringer.
+func (b *BlockDevice) GoString() string {
+	return fmt.Sprintf(
+		"&state.BlockDevice{id: %q, machineId: %q, size: %d, deviceName: %q, volumeId: %q}",
+		b.Id(), b.MachineId(), b.Size(), b.DeviceName(), b.VolumeId())
+}
+
+// Id returns the id of the block device, which is unique
+// within the environment.
+func (b *BlockDevice) Id() string {
+	return b.st.localID(b.doc.Id)
+}
+
+// MachineId returns the id of the machine the block device is attached to.
+func (b *BlockDevice) MachineId() string {
+	return b.st.localID(b.doc.MachineId)
+}
+
+// Size returns the size of the block device, in MiB.
+func (b *BlockDevice) Size() uint64 {
+	return b.doc.Size
+}
+
+// Filesystem returns the type of filesystem to create on the
+// block device, or an empty string if there is none.
+func (b *BlockDevice) Filesystem() string {
+	return b.doc.Filesystem
+}
+
+// MountPoint returns the path the block device's filesystem
+// is mounted on, or an empty string if there is none.
+func (b *BlockDevice) MountPoint() string {
+	return b.doc.MountPoint
+}
+
+// DeviceName returns the name of the block device on its machine
+// (e.g. "xvdf"), or an empty string if it is not provisioned yet.
+func (b *BlockDevice) DeviceName() string {
+	return b.doc.DeviceName
+}
+
+// VolumeId returns the provider-specific id of the volume backing
+// the block device, or an empty string if it is not provisioned yet.
+func (b *BlockDevice) VolumeId() string {
+	return b.doc.VolumeId
+}
+
+// StorageInstanceId returns the id of the storage instance the
+// block device provides, or an empty string if it provides none.
+func (b *BlockDevice) StorageInstanceId() string {
+	return b.doc.StorageInstanceId
+}
+
+// Provisioned returns whether the volume backing
+// the block device has been provisioned.
+func (b *BlockDevice) Provisioned() bool {
+	return b.doc.VolumeId != ""
+}
+
+// Life returns whether the block device is Alive, Dying or Dead.
+func (b *BlockDevice) Life() Life {
+	return b.doc.Life
+}
+
+// SetProvisioned records the provider id of the volume backing the
+// block device and the name of the device on its machine. It fails
+// if the block device is dead or has already been provisioned.
+func (b *BlockDevice) SetProvisioned(volumeId, deviceName string) (err error) {
+	defer errors.Maskf(&err, "cannot set block device %q provisioned", b.Id())
+	if volumeId == "" || deviceName == "" {
+		return fmt.Errorf("volume id and device name must be not empty")
+	}
+	notProvisionedDoc := append(bson.D{{"volumeid", bson.D{{"$exists", false}}}}, notDeadDoc...)
+	ops := []txn.Op{{
+		C:      blockDevicesC,
+		Id:     b.doc.Id,
+		Assert: notProvisionedDoc,
+		Update: bson.D{{"$set", bson.D{
+			{"volumeid", volumeId},
+			{"devicename", deviceName},
+		}}},
+	}}
+	if err := b.st.runTransaction(ops); err != txn.ErrAborted {
+		if err == nil {
+			b.doc.VolumeId = volumeId
+			b.doc.DeviceName = deviceName
+		}
+		return err
+	}
+	if err := b.Refresh(); err != nil {
+		return err
+	}
+	if b.doc.Life == Dead {
+		return fmt.Errorf("block device is dead")
+	}
+	return fmt.Errorf("already provisioned")
+}
+
+// Destroy sets the block device to Dying, signalling that the volume
+// backing it should be detached from its machine and released.
+func (b *BlockDevice) Destroy() (err error) {
+	defer errors.Maskf(&err, "cannot destroy block device %q", b.Id())
+	if b.doc.Life != Alive {
+		return nil
+	}
+	ops := []txn.Op{{
+		C:      blockDevicesC,
+		Id:     b.doc.Id,
+		Assert: isAliveDoc,
+		Update: bson.D{{"$set", bson.D{{"life", Dying}}}},
+	}}
+	// The only abort conditions in play indicate that the block
+	// device is already dying or has been removed.
+	if err := onAbort(b.st.runTransaction(ops), nil); err != nil {
+		return err
+	}
+	b.doc.Life = Dying
+	return nil
+}
+
+// EnsureDead sets the block device to Dead, once the volume
+// backing it has been released. It does nothing if the block
+// device is already dead.
+func (b *BlockDevice) EnsureDead() (err error) {
+	defer errors.Maskf(&err, "cannot set block device %q to dead", b.Id())
+	if b.doc.Life == Dead {
+		return nil
+	}
+	ops := []txn.Op{{
+		C:      blockDevicesC,
+		Id:     b.doc.Id,
+		Assert: txn.DocExists,
+		Update: bson.D{{"$set", bson.D{{"life", Dead}}}},
+	}}
+	if err := b.st.runTransaction(ops); err != nil {
+		return onAbort(err, errors.NotFoundf("block device %q", b.Id()))
+	}
+	b.doc.Life = Dead
+	return nil
+}
+
+// Remove removes the dead block device from state.
+func (b *BlockDevice) Remove() (err error) {
+	defer errors.Maskf(&err, "cannot remove block device %q", b.Id())
+	if b.doc.Life != Dead {
+		return fmt.Errorf("block device is not dead")
+	}
+	ops := []txn.Op{{
+		C:      blockDevicesC,
+		Id:     b.doc.Id,
+		Assert: isDeadDoc,
+		Remove: true,
+	}}
+	// The only abort conditions in play indicate that the block
+	// device has already been removed.
+	return onAbort(b.st.runTransaction(ops), nil)
+}
+
+// Refresh refreshes the contents of the block device from the
+// underlying state. It returns an error that satisfies
+// errors.IsNotFound if the block device has been removed.
+func (b *BlockDevice) Refresh() error {
+	dev, err := b.st.BlockDevice(b.Id())
+	if err != nil {
+		return err
+	}
+	b.doc = dev.doc
+	return nil
+}
+
+// AddBlockDevice requests a new block device with the given
+// parameters for the machine, which must be alive. The storage
+// instance the block device provides, if any, must be alive too.
+func (m *Machine) AddBlockDevice(params BlockDeviceParams) (dev *BlockDevice, err error) {
+	defer errors.Contextf(&err, "cannot add block device to machine %q", m.Id())
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+	seq, err := m.st.sequence("blockdevice")
+	if err != nil {
+		return nil, err
+	}
+	doc := &blockDeviceDoc{
+		Id:         fmt.Sprintf("%s%d", blockDeviceIdPrefix(m.doc.Id), seq),
+		MachineId:  m.doc.Id,
+		Size:       params.Size,
+		Filesystem: params.Filesystem,
+		MountPoint: params.MountPoint,
+		Life:       Alive,
+
+		StorageInstanceId: params.StorageInstanceId,
+	}
+	ops := []txn.Op{{
+		C:      machinesC,
+		Id:     m.doc.Id,
+		Assert: isAliveDoc,
+	}, {
+		C:      blockDevicesC,
+		Id:     doc.Id,
+		Assert: txn.DocMissing,
+		Insert: doc,
+	}}
+	if params.StorageInstanceId != "" {
+		ops = append(ops, txn.Op{
+			C:      storageInstancesC,
+			Id:     params.StorageInstanceId,
+			Assert: isAliveDoc,
+		})
+	}
+	switch err := m.st.runTransaction(ops); err {
+	case txn.ErrAborted:
+		if params.StorageInstanceId != "" {
+			if alive, err := isAlive(m.st.db, storageInstancesC, params.StorageInstanceId); err != nil {
+				return nil, err
+			} else if !alive {
+				return nil, fmt.Errorf("storage instance %q is not found or not alive", params.StorageInstanceId)
+			}
+		}
+		return nil, fmt.Errorf("machine is not found or not alive")
+	case nil:
+		return newBlockDevice(m.st, doc), nil
+	default:
+		return nil, err
+	}
+}
+
+// BlockDevices returns the block devices of the machine.
+func (m *Machine) BlockDevices() ([]*BlockDevice, error) {
+	return m.st.blockDevices(bson.D{{"machineid", m.doc.Id}})
+}
+
+func (st *State) blockDevices(sel bson.D) ([]*BlockDevice, error) {
+	blockDevices, closer := st.getCollection(blockDevicesC)
+	defer closer()
+
+	docs := []blockDeviceDoc{}
+	if err := blockDevices.Find(sel).All(&docs); err != nil {
+		return nil, fmt.Errorf("cannot get block devices: %v", err)
+	}
+	result := make([]*BlockDevice, len(docs))
+	for i, doc := range docs {
+		result[i] = newBlockDevice(st, &doc)
+	}
+	return result, nil
+}
+
+// removeBlockDevicesOps returns the operations that remove
+// all the block devices of the machine.
+func (m *Machine) removeBlockDevicesOps() ([]txn.Op, error) {
+	blockDevices, closer := m.st.getCollection(blockDevicesC)
+	defer closer()
+
+	var ops []txn.Op
+	iter := blockDevices.Find(bson.D{{"machineid", m.doc.Id}}).Select(bson.D{{"_id", 1}}).Iter()
+	var doc blockDeviceDoc
+	for iter.Next(&doc) {
+		ops = append(ops, txn.Op{
+			C:      blockDevicesC,
+			Id:     doc.Id,
+			Remove: true,
+		})
+	}
+	return ops, iter.Close()
+}
+
+// BlockDevice returns the block device with the given id.
+func (st *State) BlockDevice(id string) (*BlockDevice, error) {
+	blockDevices, closer := st.getCollection(blockDevicesC)
+	defer closer()
+
+	id = st.localID(id)
+	doc := &blockDeviceDoc{}
+	err := blockDevices.FindId(st.docID(id)).One(doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("block device %q", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get block device %q: %v", id, err)
+	}
+	return newBlockDevice(st, doc), nil
+}
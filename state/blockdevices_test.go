@@ -0,0 +1,217 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+	statetesting "github.com/juju/juju/state/testing"
+)
+
+type BlockDeviceSuite struct {
+	ConnSuite
+	machine *state.Machine
+}
+
+var _ = gc.Suite(&BlockDeviceSuite{})
+
+func (s *BlockDeviceSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	var err error
+	s.machine, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *BlockDeviceSuite) TestAddBlockDevice(c *gc.C) {
+	dev, err := s.machine.AddBlockDevice(state.BlockDeviceParams{
+		Size:       1024,
+		Filesystem: "ext4",
+		MountPoint: "/srv/data",
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(dev.Id(), gc.Equals, "0#0")
+	c.Assert(dev.MachineId(), gc.Equals, "0")
+	c.Assert(dev.Size(), gc.Equals, uint64(1024))
+	c.Assert(dev.Filesystem(), gc.Equals, "ext4")
+	c.Assert(dev.MountPoint(), gc.Equals, "/srv/data")
+	c.Assert(dev.DeviceName(), gc.Equals, "")
+	c.Assert(dev.VolumeId(), gc.Equals, "")
+	c.Assert(dev.Provisioned(), jc.IsFalse)
+	c.Assert(dev.Life(), gc.Equals, state.Alive)
+
+	found, err := s.State.BlockDevice(dev.Id())
+	c.Assert(err, gc.IsNil)
+	c.Assert(found, jc.DeepEquals, dev)
+
+	other, err := s.machine.AddBlockDevice(state.BlockDeviceParams{Size: 2048})
+	c.Assert(err, gc.IsNil)
+	c.Assert(other.Id(), gc.Equals, "0#1")
+	devs, err := s.machine.BlockDevices()
+	c.Assert(err, gc.IsNil)
+	c.Assert(devs, jc.SameContents, []*state.BlockDevice{dev, other})
+}
+
+func (s *BlockDeviceSuite) TestAddBlockDeviceInvalidParams(c *gc.C) {
+	for i, test := range []struct {
+		params state.BlockDeviceParams
+		err    string
+	}{{
+		params: state.BlockDeviceParams{},
+		err:    "size must be positive",
+	}, {
+		params: state.BlockDeviceParams{Size: 1024, MountPoint: "/srv"},
+		err:    `mount point "/srv" given without a filesystem`,
+	}, {
+		params: state.BlockDeviceParams{Size: 1024, Filesystem: "ext4", MountPoint: "srv"},
+		err:    `mount point "srv" is not an absolute path`,
+	}} {
+		c.Logf("test %d", i)
+		_, err := s.machine.AddBlockDevice(test.params)
+		c.Assert(err, gc.ErrorMatches, `cannot add block device to machine "0": `+test.err)
+	}
+}
+
+func (s *BlockDeviceSuite) TestAddBlockDeviceMachineNotAlive(c *gc.C) {
+	err := s.machine.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	_, err = s.machine.AddBlockDevice(state.BlockDeviceParams{Size: 1024})
+	c.Assert(err, gc.ErrorMatches, `cannot add block device to machine "0": machine is not found or not alive`)
+}
+
+func (s *BlockDeviceSuite) TestBlockDeviceNotFound(c *gc.C) {
+	_, err := s.State.BlockDevice("0#42")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	c.Assert(err, gc.ErrorMatches, `block device "0#42" not found`)
+}
+
+func (s *BlockDeviceSuite) TestSetProvisioned(c *gc.C) {
+	dev, err := s.machine.AddBlockDevice(state.BlockDeviceParams{Size: 1024})
+	c.Assert(err, gc.IsNil)
+
+	err = dev.SetProvisioned("", "xvdf")
+	c.Assert(err, gc.ErrorMatches, `cannot set block device "0#0" provisioned: volume id and device name must be not empty`)
+
+	err = dev.SetProvisioned("vol-123", "xvdf")
+	c.Assert(err, gc.IsNil)
+	c.Assert(dev.VolumeId(), gc.Equals, "vol-123")
+	c.Assert(dev.DeviceName(), gc.Equals, "xvdf")
+	c.Assert(dev.Provisioned(), jc.IsTrue)
+
+	err = dev.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(dev.VolumeId(), gc.Equals, "vol-123")
+	c.Assert(dev.DeviceName(), gc.Equals, "xvdf")
+
+	err = dev.SetProvisioned("vol-456", "xvdg")
+	c.Assert(err, gc.ErrorMatches, `cannot set block device "0#0" provisioned: already provisioned`)
+}
+
+func (s *BlockDeviceSuite) TestSetProvisionedDead(c *gc.C) {
+	dev, err := s.machine.AddBlockDevice(state.BlockDeviceParams{Size: 1024})
+	c.Assert(err, gc.IsNil)
+	err = dev.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = dev.SetProvisioned("vol-123", "xvdf")
+	c.Assert(err, gc.ErrorMatches, `cannot set block device "0#0" provisioned: block device is dead`)
+}
+
+func (s *BlockDeviceSuite) TestLifecycle(c *gc.C) {
+	dev, err := s.machine.AddBlockDevice(state.BlockDeviceParams{Size: 1024})
+	c.Assert(err, gc.IsNil)
+
+	err = dev.Remove()
+	c.Assert(err, gc.ErrorMatches, `cannot remove block device "0#0": block device is not dead`)
+
+	err = dev.Destroy()
+	c.Assert(err, gc.IsNil)
+	c.Assert(dev.Life(), gc.Equals, state.Dying)
+	err = dev.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(dev.Life(), gc.Equals, state.Dying)
+
+	err = dev.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	c.Assert(dev.Life(), gc.Equals, state.Dead)
+	err = dev.Destroy()
+	c.Assert(err, gc.IsNil)
+	c.Assert(dev.Life(), gc.Equals, state.Dead)
+
+	err = dev.Remove()
+	c.Assert(err, gc.IsNil)
+	err = dev.Refresh()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	err = dev.Remove()
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *BlockDeviceSuite) TestRemovedWithMachine(c *gc.C) {
+	dev, err := s.machine.AddBlockDevice(state.BlockDeviceParams{Size: 1024})
+	c.Assert(err, gc.IsNil)
+
+	err = s.machine.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = s.machine.Remove()
+	c.Assert(err, gc.IsNil)
+
+	_, err = s.State.BlockDevice(dev.Id())
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *BlockDeviceSuite) TestWatchBlockDevices(c *gc.C) {
+	other, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	dev0, err := s.machine.AddBlockDevice(state.BlockDeviceParams{Size: 1024})
+	c.Assert(err, gc.IsNil)
+
+	w := s.State.WatchBlockDevices()
+	defer statetesting.AssertStop(c, w)
+	wc := statetesting.NewStringsWatcherC(c, s.State, w)
+	mw := s.machine.WatchBlockDevices()
+	defer statetesting.AssertStop(c, mw)
+	mwc := statetesting.NewStringsWatcherC(c, s.State, mw)
+	wc.AssertChange("0#0")
+	wc.AssertNoChange()
+	mwc.AssertChange("0#0")
+	mwc.AssertNoChange()
+
+	// Block devices of other machines are reported by the
+	// environment watcher only.
+	dev1, err := other.AddBlockDevice(state.BlockDeviceParams{Size: 1024})
+	c.Assert(err, gc.IsNil)
+	wc.AssertChange(dev1.Id())
+	wc.AssertNoChange()
+	mwc.AssertNoChange()
+
+	// Provisioning a block device does not change its life.
+	err = dev0.SetProvisioned("vol-123", "xvdf")
+	c.Assert(err, gc.IsNil)
+	wc.AssertNoChange()
+	mwc.AssertNoChange()
+
+	err = dev0.Destroy()
+	c.Assert(err, gc.IsNil)
+	wc.AssertChange("0#0")
+	wc.AssertNoChange()
+	mwc.AssertChange("0#0")
+	mwc.AssertNoChange()
+
+	err = dev0.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	wc.AssertChange("0#0")
+	wc.AssertNoChange()
+	mwc.AssertChange("0#0")
+	mwc.AssertNoChange()
+
+	// Dead block devices are not reported again when removed.
+	err = dev0.Remove()
+	c.Assert(err, gc.IsNil)
+	wc.AssertNoChange()
+	mwc.AssertNoChange()
+
+	statetesting.AssertStop(c, mw)
+	mwc.AssertClosed()
+}
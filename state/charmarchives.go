@@ -0,0 +1,175 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+
+	"github.com/juju/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+)
+
+// CharmArchiveStoragePath returns the provider storage path under
+// which the given upload of the charm archive with the given SHA256
+// hash is stored. Archives are keyed on their content so that charms
+// with identical archives share a single stored copy; each upload
+// gets its own path, so that removing an archive whose last reference
+// was dropped never removes a later upload of the same content.
+func CharmArchiveStoragePath(sha256 string, upload int) string {
+	return fmt.Sprintf("charms/sha256-%s-%d", sha256, upload)
+}
+
+// ErrCharmArchiveReferenced is returned when removing a charm archive
+// that has been referenced again since its last reference was dropped.
+var ErrCharmArchiveReferenced = fmt.Errorf("charm archive is referenced")
+
+// CharmArchive represents a charm archive held in provider storage,
+// shared by all the charms whose archives have the same content.
+type CharmArchive struct {
+	st  *State
+	doc charmArchiveDoc
+}
+
+// charmArchiveDoc records a stored charm archive, keyed on the SHA256
+// hash of its content, and the number of charms referring to it.
+type charmArchiveDoc struct {
+	SHA256   string `bson:"_id"`
+	Path     string
+	RefCount int
+}
+
+// SHA256 returns the hex-encoded SHA256 hash of the archive content.
+func (a *CharmArchive) SHA256() string {
+	return a.doc.SHA256
+}
+
+// Path returns the provider storage path of the archive.
+func (a *CharmArchive) Path() string {
+	return a.doc.Path
+}
+
+// RefCount returns the number of references to the archive.
+func (a *CharmArchive) RefCount() int {
+	return a.doc.RefCount
+}
+
+// CharmArchive returns the stored charm archive with the given SHA256
+// hash.
+func (st *State) CharmArchive(sha256 string) (*CharmArchive, error) {
+	archives, closer := st.getCollection(charmArchivesC)
+	defer closer()
+	var doc charmArchiveDoc
+	err := archives.FindId(sha256).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("charm archive %q", sha256)
+	} else if err != nil {
+		return nil, errors.Annotatef(err, "cannot get charm archive %q", sha256)
+	}
+	return &CharmArchive{st: st, doc: doc}, nil
+}
+
+// AddCharmArchiveRef records a new reference to the charm archive with
+// the given SHA256 hash. If the archive is not yet known, it is
+// recorded under a new storage path and created is true: the caller
+// must then upload the archive to that path, and drop the reference
+// if it cannot. Otherwise the path of the existing archive is kept.
+func (st *State) AddCharmArchiveRef(sha256 string) (archive *CharmArchive, created bool, err error) {
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		existing, err := st.CharmArchive(sha256)
+		if errors.IsNotFound(err) {
+			upload, err := st.sequence("charmarchive")
+			if err != nil {
+				return nil, err
+			}
+			archive = &CharmArchive{st: st, doc: charmArchiveDoc{
+				SHA256:   sha256,
+				Path:     CharmArchiveStoragePath(sha256, upload),
+				RefCount: 1,
+			}}
+			created = true
+			return []txn.Op{{
+				C:      charmArchivesC,
+				Id:     sha256,
+				Assert: txn.DocMissing,
+				Insert: &archive.doc,
+			}}, nil
+		} else if err != nil {
+			return nil, err
+		}
+		// An archive with no references is still stored until its
+		// record is removed, so it can be referenced again.
+		refCount := existing.doc.RefCount
+		archive = existing
+		archive.doc.RefCount++
+		created = false
+		return []txn.Op{{
+			C:      charmArchivesC,
+			Id:     sha256,
+			Assert: bson.D{{"refcount", refCount}},
+			Update: bson.D{{"$inc", bson.D{{"refcount", 1}}}},
+		}}, nil
+	}
+	if err := st.runDiagnosed(buildTxn); err != nil {
+		return nil, false, errors.Annotatef(err, "cannot add reference to charm archive %q", sha256)
+	}
+	return archive, created, nil
+}
+
+// ReleaseCharmArchiveRef drops a reference to the charm archive with
+// the given SHA256 hash. When the last reference is dropped the
+// returned archive reports a zero RefCount, signalling that the caller
+// should remove the archive; see CharmArchive.Remove.
+func (st *State) ReleaseCharmArchiveRef(sha256 string) (*CharmArchive, error) {
+	var archive *CharmArchive
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		existing, err := st.CharmArchive(sha256)
+		if err != nil {
+			return nil, err
+		}
+		refCount := existing.doc.RefCount
+		if refCount <= 0 {
+			return nil, errors.Errorf("charm archive %q has no references", sha256)
+		}
+		archive = existing
+		archive.doc.RefCount--
+		return []txn.Op{{
+			C:      charmArchivesC,
+			Id:     sha256,
+			Assert: bson.D{{"refcount", refCount}},
+			Update: bson.D{{"$inc", bson.D{{"refcount", -1}}}},
+		}}, nil
+	}
+	if err := st.runDiagnosed(buildTxn); err != nil {
+		return nil, errors.Annotatef(err, "cannot release reference to charm archive %q", sha256)
+	}
+	return archive, nil
+}
+
+// Remove removes the record of the charm archive, which must have no
+// references. Once it has been removed, the caller must remove the
+// archive from provider storage; the path is never used again. If the
+// archive has been referenced again, Remove returns
+// ErrCharmArchiveReferenced and the archive must be kept.
+func (a *CharmArchive) Remove() error {
+	ops := []txn.Op{{
+		C:      charmArchivesC,
+		Id:     a.doc.SHA256,
+		Assert: bson.D{{"path", a.doc.Path}, {"refcount", 0}},
+		Remove: true,
+	}}
+	err := a.st.runTransaction(ops)
+	if err != txn.ErrAborted {
+		return err
+	}
+	existing, err := a.st.CharmArchive(a.doc.SHA256)
+	if err != nil {
+		return err
+	}
+	if existing.doc.Path != a.doc.Path {
+		return errors.NotFoundf("charm archive %q at %q", a.doc.SHA256, a.doc.Path)
+	}
+	return ErrCharmArchiveReferenced
+}
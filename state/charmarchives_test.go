@@ -0,0 +1,116 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+)
+
+type CharmArchiveSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&CharmArchiveSuite{})
+
+func (s *CharmArchiveSuite) TestCharmArchiveStoragePath(c *gc.C) {
+	c.Assert(state.CharmArchiveStoragePath("abc123", 3), gc.Equals, "charms/sha256-abc123-3")
+}
+
+func (s *CharmArchiveSuite) TestCharmArchiveNotFound(c *gc.C) {
+	_, err := s.State.CharmArchive("abc123")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	c.Assert(err, gc.ErrorMatches, `charm archive "abc123" not found`)
+}
+
+func (s *CharmArchiveSuite) TestAddCharmArchiveRef(c *gc.C) {
+	archive, created, err := s.State.AddCharmArchiveRef("abc123")
+	c.Assert(err, gc.IsNil)
+	c.Assert(created, jc.IsTrue)
+	c.Assert(archive.SHA256(), gc.Equals, "abc123")
+	c.Assert(archive.Path(), gc.Matches, "charms/sha256-abc123-[0-9]+")
+	c.Assert(archive.RefCount(), gc.Equals, 1)
+	path := archive.Path()
+
+	// A further reference shares the archive already recorded.
+	archive, created, err = s.State.AddCharmArchiveRef("abc123")
+	c.Assert(err, gc.IsNil)
+	c.Assert(created, jc.IsFalse)
+	c.Assert(archive.Path(), gc.Equals, path)
+	c.Assert(archive.RefCount(), gc.Equals, 2)
+
+	archive, err = s.State.CharmArchive("abc123")
+	c.Assert(err, gc.IsNil)
+	c.Assert(archive.Path(), gc.Equals, path)
+	c.Assert(archive.RefCount(), gc.Equals, 2)
+}
+
+func (s *CharmArchiveSuite) TestReleaseCharmArchiveRef(c *gc.C) {
+	for i := 0; i < 2; i++ {
+		_, _, err := s.State.AddCharmArchiveRef("abc123")
+		c.Assert(err, gc.IsNil)
+	}
+
+	archive, err := s.State.ReleaseCharmArchiveRef("abc123")
+	c.Assert(err, gc.IsNil)
+	c.Assert(archive.RefCount(), gc.Equals, 1)
+	archive, err = s.State.CharmArchive("abc123")
+	c.Assert(err, gc.IsNil)
+	c.Assert(archive.RefCount(), gc.Equals, 1)
+
+	// Dropping the last reference keeps the archive record
+	// until it is removed.
+	archive, err = s.State.ReleaseCharmArchiveRef("abc123")
+	c.Assert(err, gc.IsNil)
+	c.Assert(archive.RefCount(), gc.Equals, 0)
+	archive, err = s.State.CharmArchive("abc123")
+	c.Assert(err, gc.IsNil)
+	c.Assert(archive.RefCount(), gc.Equals, 0)
+
+	_, err = s.State.ReleaseCharmArchiveRef("abc123")
+	c.Assert(err, gc.ErrorMatches, `cannot release reference to charm archive "abc123": charm archive "abc123" has no references`)
+}
+
+func (s *CharmArchiveSuite) TestRemoveCharmArchive(c *gc.C) {
+	archive, _, err := s.State.AddCharmArchiveRef("abc123")
+	c.Assert(err, gc.IsNil)
+	path := archive.Path()
+	archive, err = s.State.ReleaseCharmArchiveRef("abc123")
+	c.Assert(err, gc.IsNil)
+
+	err = archive.Remove()
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.CharmArchive("abc123")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	err = archive.Remove()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	// A new reference records a new upload, at a new path.
+	archive, created, err := s.State.AddCharmArchiveRef("abc123")
+	c.Assert(err, gc.IsNil)
+	c.Assert(created, jc.IsTrue)
+	c.Assert(archive.Path(), gc.Not(gc.Equals), path)
+}
+
+func (s *CharmArchiveSuite) TestRemoveCharmArchiveReferencedAgain(c *gc.C) {
+	archive, _, err := s.State.AddCharmArchiveRef("abc123")
+	c.Assert(err, gc.IsNil)
+	released, err := s.State.ReleaseCharmArchiveRef("abc123")
+	c.Assert(err, gc.IsNil)
+
+	// The archive is still stored, so it is reused rather than
+	// uploaded again, and it must not be removed.
+	again, created, err := s.State.AddCharmArchiveRef("abc123")
+	c.Assert(err, gc.IsNil)
+	c.Assert(created, jc.IsFalse)
+	c.Assert(again.Path(), gc.Equals, archive.Path())
+	err = released.Remove()
+	c.Assert(err, gc.Equals, state.ErrCharmArchiveReferenced)
+	again, err = s.State.CharmArchive("abc123")
+	c.Assert(err, gc.IsNil)
+	c.Assert(again.RefCount(), gc.Equals, 1)
+}
@@ -21,23 +21,29 @@ const (
 	cleanupRemovedUnit                 cleanupKind = "removedUnit"
 	cleanupServicesForDyingEnvironment cleanupKind = "services"
 	cleanupForceDestroyedMachine       cleanupKind = "machine"
+	cleanupDyingNetwork                cleanupKind = "dyingNetwork"
+	cleanupRelationsForDyingService    cleanupKind = "serviceRelations"
+	cleanupSubordinatesForDyingUnit    cleanupKind = "dyingUnitSubordinates"
+	cleanupOrphanedMachine             cleanupKind = "orphanedMachine"
 )
 
 // cleanupDoc represents a potentially large set of documents that should be
 // removed.
 type cleanupDoc struct {
-	Id     bson.ObjectId `bson:"_id"`
-	Kind   cleanupKind
-	Prefix string
+	Id      bson.ObjectId `bson:"_id"`
+	Kind    cleanupKind
+	Prefix  string
+	EnvUUID string `bson:"env-uuid,omitempty"`
 }
 
 // newCleanupOp returns a txn.Op that creates a cleanup document with a unique
 // id and the supplied kind and prefix.
 func (st *State) newCleanupOp(kind cleanupKind, prefix string) txn.Op {
 	doc := &cleanupDoc{
-		Id:     bson.NewObjectId(),
-		Kind:   kind,
-		Prefix: prefix,
+		Id:      bson.NewObjectId(),
+		Kind:    kind,
+		Prefix:  prefix,
+		EnvUUID: st.environTag.Id(),
 	}
 	return txn.Op{
 		C:      cleanupsC,
@@ -50,7 +56,7 @@ func (st *State) newCleanupOp(kind cleanupKind, prefix string) txn.Op {
 func (st *State) NeedsCleanup() (bool, error) {
 	cleanups, closer := st.getCollection(cleanupsC)
 	defer closer()
-	count, err := cleanups.Count()
+	count, err := cleanups.Find(st.environSelector()).Count()
 	if err != nil {
 		return false, err
 	}
@@ -64,7 +70,7 @@ func (st *State) Cleanup() error {
 	var doc cleanupDoc
 	cleanups, closer := st.getCollection(cleanupsC)
 	defer closer()
-	iter := cleanups.Find(nil).Iter()
+	iter := cleanups.Find(st.environSelector()).Iter()
 	for iter.Next(&doc) {
 		var err error
 		logger.Debugf("running %q cleanup: %q", doc.Kind, doc.Prefix)
@@ -81,6 +87,14 @@ func (st *State) Cleanup() error {
 			err = st.cleanupServicesForDyingEnvironment()
 		case cleanupForceDestroyedMachine:
 			err = st.cleanupForceDestroyedMachine(doc.Prefix)
+		case cleanupDyingNetwork:
+			err = st.cleanupDyingNetwork(doc.Prefix)
+		case cleanupRelationsForDyingService:
+			err = st.cleanupRelationsForDyingService(doc.Prefix)
+		case cleanupSubordinatesForDyingUnit:
+			err = st.cleanupSubordinatesForDyingUnit(doc.Prefix)
+		case cleanupOrphanedMachine:
+			err = st.cleanupOrphanedMachine(doc.Prefix)
 		default:
 			err = fmt.Errorf("unknown cleanup kind %q", doc.Kind)
 		}
@@ -130,7 +144,7 @@ func (st *State) cleanupServicesForDyingEnvironment() error {
 	services, closer := st.getCollection(servicesC)
 	defer closer()
 	service := Service{st: st}
-	sel := bson.D{{"life", Alive}}
+	sel := append(bson.D{{"life", Alive}}, st.environSelector()...)
 	iter := services.Find(sel).Iter()
 	for iter.Next(&service.doc) {
 		if err := service.Destroy(); err != nil {
@@ -159,6 +173,16 @@ func (st *State) cleanupUnitsForDyingService(prefix string) error {
 		if err := unit.Destroy(); err != nil {
 			return err
 		}
+		// Subordinates are torn down only once their principal is
+		// dying, so that they're never left without a principal that
+		// is still doing useful work.
+		if len(unit.doc.Subordinates) == 0 {
+			continue
+		}
+		ops := []txn.Op{st.newCleanupOp(cleanupSubordinatesForDyingUnit, unit.doc.Name)}
+		if err := st.runTransaction(ops); err != nil {
+			return err
+		}
 	}
 	if err := iter.Close(); err != nil {
 		return errors.Errorf("cannot read unit document: %v", err)
@@ -166,6 +190,133 @@ func (st *State) cleanupUnitsForDyingService(prefix string) error {
 	return nil
 }
 
+// cleanupSubordinatesForDyingUnit sets all subordinates of the named unit to
+// Dying, if they are not already Dying or Dead. It's expected to be used when
+// the unit's service is destroyed.
+func (st *State) cleanupSubordinatesForDyingUnit(name string) error {
+	unit, err := st.Unit(name)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, subName := range unit.SubordinateNames() {
+		subordinate, err := st.Unit(subName)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if err := subordinate.Destroy(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cleanupRelationsForDyingService marks every unit in scope of the named
+// service's dying relations as departing, on both sides of each relation,
+// so that the relations can be removed as soon as the units' agents have
+// left scope rather than when they next notice the relation is dying.
+func (st *State) cleanupRelationsForDyingService(serviceName string) error {
+	service, err := st.Service(serviceName)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	relations, err := service.Relations()
+	if err != nil {
+		return err
+	}
+	for _, relation := range relations {
+		if relation.Life() == Alive {
+			continue
+		}
+		for _, ep := range relation.Endpoints() {
+			endpointService, err := st.Service(ep.ServiceName)
+			if errors.IsNotFound(err) {
+				continue
+			} else if err != nil {
+				return err
+			}
+			units, err := endpointService.AllUnits()
+			if err != nil {
+				return err
+			}
+			for _, unit := range units {
+				relationUnit, err := relation.Unit(unit)
+				if err != nil {
+					return err
+				}
+				if err := relationUnit.PrepareLeaveScope(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// cleanupOrphanedMachine destroys the machine with the given id once it no
+// longer hosts any units or containers. It's expected to be used when a
+// dying service's unit is removed from one of the machine's containers:
+// the containers themselves are destroyed along with their last unit, but
+// the host cannot be destroyed until they have been removed entirely. Until
+// then the cleanup fails, and is retried on subsequent runs. Only machines
+// created for the units of a service that is no longer alive are destroyed;
+// machines added by the user are left alone.
+func (st *State) cleanupOrphanedMachine(machineId string) error {
+	machine, err := st.Machine(machineId)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if machine.Life() != Alive || machine.IsManager() || machine.HasVote() {
+		return nil
+	}
+	if machine.doc.Service == "" {
+		return nil
+	}
+	service, err := st.Service(machine.doc.Service)
+	if err == nil && service.Life() == Alive {
+		return nil
+	} else if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if len(machine.doc.Principals) != 0 {
+		return nil
+	}
+	containerIds, err := machine.Containers()
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	var remaining []string
+	for _, containerId := range containerIds {
+		container, err := st.Machine(containerId)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if container.Life() == Alive {
+			// The machine is still in use.
+			return nil
+		}
+		remaining = append(remaining, containerId)
+	}
+	if len(remaining) != 0 {
+		return errors.Errorf("machine %s is still hosting containers %q", machine, remaining)
+	}
+	// A unit may have been assigned to the machine since the checks
+	// above; in that case the machine is no longer orphaned.
+	if err := machine.Destroy(); err != nil && !IsHasAssignedUnitsError(err) {
+		return err
+	}
+	return nil
+}
+
 // cleanupDyingUnit marks the unit as departing from all its joined relations,
 // allowing related units to start converging to a state in which that unit is
 // gone as quickly as possible.
@@ -206,6 +357,35 @@ func (st *State) cleanupRemovedUnit(unitId string) error {
 			return err
 		}
 	}
+	storageInstances, err := st.unitStorageInstances(unitId)
+	if err != nil {
+		return err
+	}
+	for _, instance := range storageInstances {
+		if err := instance.Destroy(); err != nil {
+			return err
+		}
+	}
+	return st.removeAgentVersion(unitGlobalKey(unitId))
+}
+
+// cleanupDyingNetwork removes the dying network with the given name
+// if no network interface uses it any more. A network still in use is
+// left alone: another cleanup is scheduled when any of its interfaces
+// is removed.
+func (st *State) cleanupDyingNetwork(networkName string) error {
+	network, err := st.Network(networkName)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if network.Life() == Alive {
+		return nil
+	}
+	if err := network.Remove(); err != nil && !IsNetworkInUseError(err) {
+		return err
+	}
 	return nil
 }
 
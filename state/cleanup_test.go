@@ -11,6 +11,7 @@ import (
 	jc "github.com/juju/testing/checkers"
 	gc "launchpad.net/gocheck"
 
+	"github.com/juju/juju/constraints"
 	"github.com/juju/juju/instance"
 	"github.com/juju/juju/state"
 )
@@ -60,6 +61,173 @@ func (s *CleanupSuite) TestCleanupDyingServiceUnits(c *gc.C) {
 	s.assertCleanupCount(c, 1)
 }
 
+func (s *CleanupSuite) TestCleanupDyingServiceSubordinates(c *gc.C) {
+	// Create principal units with subordinates, in relation scope.
+	prr := NewProReqRelation(c, &s.ConnSuite, charm.ScopeContainer)
+	err := prr.pru0.EnterScope(nil)
+	c.Assert(err, gc.IsNil)
+	err = prr.rru0.EnterScope(nil)
+	c.Assert(err, gc.IsNil)
+	s.assertDoesNotNeedCleanup(c)
+
+	// Destroy the principal service, and check the first cleanup destroys
+	// the principal units only.
+	err = prr.psvc.Destroy()
+	c.Assert(err, gc.IsNil)
+	assertLife(c, prr.pu0, state.Alive)
+	s.assertCleanupRuns(c)
+	assertLife(c, prr.pu0, state.Dying)
+	assertLife(c, prr.pu1, state.Dying)
+
+	// The subordinates are destroyed once their principals are dying.
+	s.assertCleanupRuns(c)
+	assertLife(c, prr.ru0, state.Dying)
+	assertLife(c, prr.ru1, state.Dying)
+
+	// Run a final cleanup to clear the cleanups scheduled for the
+	// subordinates that became dying.
+	s.assertCleanupCount(c, 1)
+}
+
+func (s *CleanupSuite) TestCleanupDyingServiceRelations(c *gc.C) {
+	// Create units of both services, in relation scope.
+	prr := NewProReqRelation(c, &s.ConnSuite, charm.ScopeGlobal)
+	for _, ru := range []*state.RelationUnit{prr.pru0, prr.pru1, prr.rru0, prr.rru1} {
+		err := ru.EnterScope(nil)
+		c.Assert(err, gc.IsNil)
+	}
+	preventUnitDestroyRemove(c, prr.pu0)
+	preventUnitDestroyRemove(c, prr.pu1)
+
+	// Destroy the provider service; the relation is dying, but all the
+	// units are still joined.
+	err := prr.psvc.Destroy()
+	c.Assert(err, gc.IsNil)
+	assertLife(c, prr.rel, state.Dying)
+	assertJoined(c, prr.rru0)
+	assertJoined(c, prr.rru1)
+
+	// Run the cleanup, and check the requirer units are departing even
+	// though they're still alive.
+	s.assertCleanupRuns(c)
+	assertLife(c, prr.ru0, state.Alive)
+	assertInScope(c, prr.rru0)
+	assertNotJoined(c, prr.rru0)
+	assertInScope(c, prr.rru1)
+	assertNotJoined(c, prr.rru1)
+
+	// Once every unit has left scope, the relation is removed.
+	for _, ru := range []*state.RelationUnit{prr.pru0, prr.pru1, prr.rru0, prr.rru1} {
+		err := ru.LeaveScope()
+		c.Assert(err, gc.IsNil)
+	}
+	assertRemoved(c, prr.rel)
+}
+
+func (s *CleanupSuite) TestCleanupDyingServiceOrphanedMachine(c *gc.C) {
+	// Create a unit inside a container on a new machine.
+	mysql := s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	err := mysql.SetConstraints(constraints.MustParse("container=lxc"))
+	c.Assert(err, gc.IsNil)
+	unit, err := mysql.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit.AssignToNewMachine()
+	c.Assert(err, gc.IsNil)
+	containerId, err := unit.AssignedMachineId()
+	c.Assert(err, gc.IsNil)
+	container, err := s.State.Machine(containerId)
+	c.Assert(err, gc.IsNil)
+	machine, err := s.State.Machine(state.ParentId(containerId))
+	c.Assert(err, gc.IsNil)
+
+	// Destroy the service, and check the unit is removed and its
+	// container destroyed, while the host is left alone.
+	err = mysql.Destroy()
+	c.Assert(err, gc.IsNil)
+	s.assertCleanupRuns(c)
+	assertRemoved(c, unit)
+	assertLife(c, container, state.Dying)
+	assertLife(c, machine, state.Alive)
+
+	// The host cannot be destroyed until the container is removed.
+	s.assertCleanupRuns(c)
+	assertLife(c, machine, state.Alive)
+	s.assertNeedsCleanup(c)
+
+	err = container.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = container.Remove()
+	c.Assert(err, gc.IsNil)
+	s.assertCleanupCount(c, 1)
+	assertLife(c, machine, state.Dying)
+}
+
+func (s *CleanupSuite) TestCleanupDyingServiceLeavesAddedMachine(c *gc.C) {
+	// Create a unit inside a container on a machine added by the user.
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	container, err := s.State.AddMachineInsideMachine(state.MachineTemplate{
+		Series: "quantal",
+		Jobs:   []state.MachineJob{state.JobHostUnits},
+	}, machine.Id(), instance.LXC)
+	c.Assert(err, gc.IsNil)
+	mysql := s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	unit, err := mysql.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit.AssignToMachine(container)
+	c.Assert(err, gc.IsNil)
+
+	// Destroy the service; the host was not created for the
+	// service, so the cleanup leaves it alone without waiting
+	// for the container to be removed.
+	err = mysql.Destroy()
+	c.Assert(err, gc.IsNil)
+	s.assertCleanupRuns(c)
+	assertRemoved(c, unit)
+	assertLife(c, container, state.Dying)
+	s.assertCleanupCount(c, 1)
+	assertLife(c, machine, state.Alive)
+
+	err = container.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = container.Remove()
+	c.Assert(err, gc.IsNil)
+	s.assertDoesNotNeedCleanup(c)
+	assertLife(c, machine, state.Alive)
+}
+
+func (s *CleanupSuite) TestCleanupDyingServiceMachineStillInUse(c *gc.C) {
+	// Create units of two services inside containers on the same host.
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	template := state.MachineTemplate{
+		Series: "quantal",
+		Jobs:   []state.MachineJob{state.JobHostUnits},
+	}
+	container0, err := s.State.AddMachineInsideMachine(template, machine.Id(), instance.LXC)
+	c.Assert(err, gc.IsNil)
+	container1, err := s.State.AddMachineInsideMachine(template, machine.Id(), instance.LXC)
+	c.Assert(err, gc.IsNil)
+	mysql := s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	unit0, err := mysql.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit0.AssignToMachine(container0)
+	c.Assert(err, gc.IsNil)
+	wordpress := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	unit1, err := wordpress.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit1.AssignToMachine(container1)
+	c.Assert(err, gc.IsNil)
+
+	// Destroy one service, and check the host survives.
+	err = mysql.Destroy()
+	c.Assert(err, gc.IsNil)
+	s.assertCleanupCount(c, 2)
+	assertLife(c, container0, state.Dying)
+	assertLife(c, container1, state.Alive)
+	assertLife(c, machine, state.Alive)
+}
+
 func (s *CleanupSuite) TestCleanupEnvironmentServices(c *gc.C) {
 	s.assertDoesNotNeedCleanup(c)
 
@@ -212,3 +212,27 @@ func (s *compatSuite) TestMigratePortsOnClose(c *gc.C) {
 	ports := unit.OpenedPorts()
 	c.Assert(ports, gc.DeepEquals, []network.Port{})
 }
+
+func (s *compatSuite) TestMachineWithoutEnvUUIDBelongsToStateServerEnvironment(c *gc.C) {
+	machine, err := s.state.AddMachine("quantal", JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	c.Assert(machine.doc.EnvUUID, gc.Equals, s.env.UUID())
+
+	// Machines added before environments were recorded on
+	// documents have no env-uuid field.
+	ops := []txn.Op{{
+		C:      machinesC,
+		Id:     machine.doc.Id,
+		Update: bson.D{{"$unset", bson.D{{"env-uuid", nil}}}},
+	}}
+	err = s.state.runTransaction(ops)
+	c.Assert(err, gc.IsNil)
+
+	found, err := s.state.Machine(machine.Id())
+	c.Assert(err, gc.IsNil)
+	c.Assert(found.doc.EnvUUID, gc.Equals, "")
+	machines, err := s.state.AllMachines()
+	c.Assert(err, gc.IsNil)
+	c.Assert(machines, gc.HasLen, 1)
+	c.Assert(machines[0].Id(), gc.Equals, machine.Id())
+}
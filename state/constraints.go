@@ -17,42 +17,45 @@ import (
 
 // constraintsDoc is the mongodb representation of a constraints.Value.
 type constraintsDoc struct {
-	Arch         *string
-	CpuCores     *uint64
-	CpuPower     *uint64
-	Mem          *uint64
-	RootDisk     *uint64
-	InstanceType *string
-	Container    *instance.ContainerType
-	Tags         *[]string `bson:",omitempty"`
-	Networks     *[]string `bson:",omitempty"`
+	Arch             *string
+	CpuCores         *uint64
+	CpuPower         *uint64
+	Mem              *uint64
+	RootDisk         *uint64
+	InstanceType     *string
+	Container        *instance.ContainerType
+	Tags             *[]string `bson:",omitempty"`
+	Networks         *[]string `bson:",omitempty"`
+	ContainerNetwork *string   `bson:",omitempty"`
 }
 
 func (doc constraintsDoc) value() constraints.Value {
 	return constraints.Value{
-		Arch:         doc.Arch,
-		CpuCores:     doc.CpuCores,
-		CpuPower:     doc.CpuPower,
-		Mem:          doc.Mem,
-		RootDisk:     doc.RootDisk,
-		InstanceType: doc.InstanceType,
-		Container:    doc.Container,
-		Tags:         doc.Tags,
-		Networks:     doc.Networks,
+		Arch:             doc.Arch,
+		CpuCores:         doc.CpuCores,
+		CpuPower:         doc.CpuPower,
+		Mem:              doc.Mem,
+		RootDisk:         doc.RootDisk,
+		InstanceType:     doc.InstanceType,
+		Container:        doc.Container,
+		Tags:             doc.Tags,
+		Networks:         doc.Networks,
+		ContainerNetwork: doc.ContainerNetwork,
 	}
 }
 
 func newConstraintsDoc(cons constraints.Value) constraintsDoc {
 	return constraintsDoc{
-		Arch:         cons.Arch,
-		CpuCores:     cons.CpuCores,
-		CpuPower:     cons.CpuPower,
-		Mem:          cons.Mem,
-		RootDisk:     cons.RootDisk,
-		InstanceType: cons.InstanceType,
-		Container:    cons.Container,
-		Tags:         cons.Tags,
-		Networks:     cons.Networks,
+		Arch:             cons.Arch,
+		CpuCores:         cons.CpuCores,
+		CpuPower:         cons.CpuPower,
+		Mem:              cons.Mem,
+		RootDisk:         cons.RootDisk,
+		InstanceType:     cons.InstanceType,
+		Container:        cons.Container,
+		Tags:             cons.Tags,
+		Networks:         cons.Networks,
+		ContainerNetwork: cons.ContainerNetwork,
 	}
 }
 
@@ -0,0 +1,160 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+
+	"github.com/juju/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+)
+
+// ContainerAddress records that an IP address allocated to a
+// container is reached through a network interface, usually a
+// bridge, of the container's host machine. The records allow the
+// networking of addressable containers to be set up again after
+// their agents or hosts restart.
+type ContainerAddress struct {
+	st  *State
+	doc containerAddressDoc
+}
+
+// containerAddressDoc records the host interface through which an
+// allocated address is reached. The address is the document id, so
+// that the record is removed together with the address.
+type containerAddressDoc struct {
+	Address       string `bson:"_id"`
+	MachineId     string
+	HostId        string
+	HostInterface string
+}
+
+func newContainerAddress(st *State, doc *containerAddressDoc) *ContainerAddress {
+	return &ContainerAddress{st, *doc}
+}
+
+// Address returns the IP address given to the container.
+func (a *ContainerAddress) Address() string {
+	return a.doc.Address
+}
+
+// MachineId returns the id of the container.
+func (a *ContainerAddress) MachineId() string {
+	return a.st.localID(a.doc.MachineId)
+}
+
+// HostId returns the id of the machine hosting the container.
+func (a *ContainerAddress) HostId() string {
+	return a.st.localID(a.doc.HostId)
+}
+
+// HostInterface returns the name of the network interface
+// of the host through which the address is reached.
+func (a *ContainerAddress) HostInterface() string {
+	return a.doc.HostInterface
+}
+
+// RecordContainerAddress records that the address, which must be
+// allocated to a container, is reached through the given network
+// interface of the container's host. Both the container and its host
+// must be alive. The record is removed when the address is released
+// or removed, including when the container is removed.
+func (a *IPAddress) RecordContainerAddress(hostInterface string) (_ *ContainerAddress, err error) {
+	defer errors.Maskf(&err, "cannot record container address %q", a.doc.Value)
+	if hostInterface == "" {
+		return nil, fmt.Errorf("host interface must be not empty")
+	}
+	hostId := ParentId(a.doc.MachineId)
+	if hostId == "" {
+		return nil, fmt.Errorf("machine %q is not a container", a.MachineId())
+	}
+	doc := &containerAddressDoc{
+		Address:       a.doc.Value,
+		MachineId:     a.doc.MachineId,
+		HostId:        hostId,
+		HostInterface: hostInterface,
+	}
+	ops := []txn.Op{{
+		C:      ipAddressesC,
+		Id:     a.doc.Value,
+		Assert: append(isAliveDoc, bson.DocElem{"machineid", a.doc.MachineId}),
+	}, {
+		C:      machinesC,
+		Id:     a.doc.MachineId,
+		Assert: isAliveDoc,
+	}, {
+		C:      machinesC,
+		Id:     hostId,
+		Assert: isAliveDoc,
+	}, {
+		C:      containerAddressesC,
+		Id:     a.doc.Value,
+		Assert: txn.DocMissing,
+		Insert: doc,
+	}}
+	err = a.st.runTransaction(ops)
+	if err == txn.ErrAborted {
+		if cause := a.st.abortCause(ops); cause != nil {
+			return nil, cause
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newContainerAddress(a.st, doc), nil
+}
+
+// ContainerAddress returns the record of the given address
+// being given to a container.
+func (st *State) ContainerAddress(address string) (*ContainerAddress, error) {
+	containerAddresses, closer := st.getCollection(containerAddressesC)
+	defer closer()
+
+	doc := &containerAddressDoc{}
+	err := containerAddresses.FindId(address).One(doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("container address %q", address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get container address %q: %v", address, err)
+	}
+	return newContainerAddress(st, doc), nil
+}
+
+// ContainerAddresses returns the records of the
+// addresses given to the machine as a container.
+func (m *Machine) ContainerAddresses() ([]*ContainerAddress, error) {
+	return m.st.containerAddresses(bson.D{{"machineid", m.doc.Id}})
+}
+
+// HostedContainerAddresses returns the records of the addresses
+// given to the containers hosted by the machine.
+func (m *Machine) HostedContainerAddresses() ([]*ContainerAddress, error) {
+	return m.st.containerAddresses(bson.D{{"hostid", m.doc.Id}})
+}
+
+func (st *State) containerAddresses(sel bson.D) ([]*ContainerAddress, error) {
+	containerAddresses, closer := st.getCollection(containerAddressesC)
+	defer closer()
+
+	docs := []containerAddressDoc{}
+	if err := containerAddresses.Find(sel).All(&docs); err != nil {
+		return nil, fmt.Errorf("cannot get container addresses: %v", err)
+	}
+	addrs := make([]*ContainerAddress, len(docs))
+	for i, doc := range docs {
+		addrs[i] = newContainerAddress(st, &doc)
+	}
+	return addrs, nil
+}
+
+func removeContainerAddressOp(address string) txn.Op {
+	return txn.Op{
+		C:      containerAddressesC,
+		Id:     address,
+		Remove: true,
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/state"
+)
+
+type ContainerAddressSuite struct {
+	ConnSuite
+	host      *state.Machine
+	container *state.Machine
+	subnet    *state.Subnet
+}
+
+var _ = gc.Suite(&ContainerAddressSuite{})
+
+func (s *ContainerAddressSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	var err error
+	s.host, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	s.container, err = s.State.AddMachineInsideMachine(state.MachineTemplate{
+		Series: "quantal",
+		Jobs:   []state.MachineJob{state.JobHostUnits},
+	}, s.host.Id(), instance.LXC)
+	c.Assert(err, gc.IsNil)
+	network, err := s.State.AddNetwork(state.NetworkInfo{"net1", "net1", "10.0.0.0/16", 0, ""})
+	c.Assert(err, gc.IsNil)
+	s.subnet, err = network.AddSubnet(state.SubnetInfo{
+		CIDR:              "10.0.1.0/24",
+		AllocatableIPLow:  "10.0.1.10",
+		AllocatableIPHigh: "10.0.1.12",
+	})
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *ContainerAddressSuite) recordAddress(c *gc.C) *state.ContainerAddress {
+	addr, err := s.subnet.AllocateAddress(s.container.Id(), "eth0")
+	c.Assert(err, gc.IsNil)
+	record, err := addr.RecordContainerAddress("lxcbr0")
+	c.Assert(err, gc.IsNil)
+	return record
+}
+
+func (s *ContainerAddressSuite) TestRecordContainerAddress(c *gc.C) {
+	record := s.recordAddress(c)
+	c.Assert(record.Address(), gc.Equals, "10.0.1.10")
+	c.Assert(record.MachineId(), gc.Equals, s.container.Id())
+	c.Assert(record.HostId(), gc.Equals, s.host.Id())
+	c.Assert(record.HostInterface(), gc.Equals, "lxcbr0")
+
+	found, err := s.State.ContainerAddress("10.0.1.10")
+	c.Assert(err, gc.IsNil)
+	c.Assert(found, jc.DeepEquals, record)
+	records, err := s.container.ContainerAddresses()
+	c.Assert(err, gc.IsNil)
+	c.Assert(records, jc.DeepEquals, []*state.ContainerAddress{record})
+	records, err = s.host.HostedContainerAddresses()
+	c.Assert(err, gc.IsNil)
+	c.Assert(records, jc.DeepEquals, []*state.ContainerAddress{record})
+	records, err = s.host.ContainerAddresses()
+	c.Assert(err, gc.IsNil)
+	c.Assert(records, gc.HasLen, 0)
+}
+
+func (s *ContainerAddressSuite) TestRecordContainerAddressTwice(c *gc.C) {
+	s.recordAddress(c)
+	addr, err := s.State.IPAddress("10.0.1.10")
+	c.Assert(err, gc.IsNil)
+	_, err = addr.RecordContainerAddress("br0")
+	c.Assert(err, gc.ErrorMatches, `cannot record container address "10.0.1.10": container address 10.0.1.10 already exists`)
+}
+
+func (s *ContainerAddressSuite) TestRecordContainerAddressNotContainer(c *gc.C) {
+	addr, err := s.subnet.AllocateAddress(s.host.Id(), "eth0")
+	c.Assert(err, gc.IsNil)
+	_, err = addr.RecordContainerAddress("lxcbr0")
+	c.Assert(err, gc.ErrorMatches, `cannot record container address "10.0.1.10": machine "0" is not a container`)
+}
+
+func (s *ContainerAddressSuite) TestRecordContainerAddressNoInterface(c *gc.C) {
+	addr, err := s.subnet.AllocateAddress(s.container.Id(), "eth0")
+	c.Assert(err, gc.IsNil)
+	_, err = addr.RecordContainerAddress("")
+	c.Assert(err, gc.ErrorMatches, `cannot record container address "10.0.1.10": host interface must be not empty`)
+}
+
+func (s *ContainerAddressSuite) TestRecordContainerAddressReleased(c *gc.C) {
+	addr, err := s.subnet.AllocateAddress(s.container.Id(), "eth0")
+	c.Assert(err, gc.IsNil)
+	err = addr.Release()
+	c.Assert(err, gc.IsNil)
+	_, err = addr.RecordContainerAddress("lxcbr0")
+	c.Assert(err, gc.ErrorMatches, `cannot record container address "10.0.1.10": IP address 10.0.1.10 is dead`)
+}
+
+func (s *ContainerAddressSuite) TestRecordContainerAddressDyingContainer(c *gc.C) {
+	addr, err := s.subnet.AllocateAddress(s.container.Id(), "eth0")
+	c.Assert(err, gc.IsNil)
+	err = s.container.Destroy()
+	c.Assert(err, gc.IsNil)
+	_, err = addr.RecordContainerAddress("lxcbr0")
+	c.Assert(err, gc.ErrorMatches, `cannot record container address "10.0.1.10": machine 0/lxc/0 is dying`)
+}
+
+func (s *ContainerAddressSuite) TestReleasedWithAddress(c *gc.C) {
+	s.recordAddress(c)
+	addr, err := s.State.IPAddress("10.0.1.10")
+	c.Assert(err, gc.IsNil)
+	err = addr.Release()
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.ContainerAddress("10.0.1.10")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *ContainerAddressSuite) TestRemovedWithContainer(c *gc.C) {
+	s.recordAddress(c)
+	err := s.container.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = s.container.Remove()
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.ContainerAddress("10.0.1.10")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	records, err := s.host.HostedContainerAddresses()
+	c.Assert(err, gc.IsNil)
+	c.Assert(records, gc.HasLen, 0)
+}
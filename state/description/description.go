@@ -0,0 +1,218 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package description defines the format environments are exported
+// in, so that they can be imported into another state server.
+//
+// A description holds the logical model of an environment rather than
+// the documents it is stored as, so that it remains importable after
+// the storage schema changes. Descriptions are serialized as YAML and
+// carry a format version, which is checked when they are read.
+package description
+
+import (
+	"fmt"
+
+	"launchpad.net/goyaml"
+)
+
+// Version is the version of the description format
+// written by Serialize.
+const Version = 1
+
+// Environment describes an environment and everything in it.
+type Environment struct {
+	// Version holds the version of the description format.
+	Version int `yaml:"version"`
+
+	// UUID and Name identify the exported environment.
+	UUID string `yaml:"uuid"`
+	Name string `yaml:"name"`
+
+	// Config holds the environment configuration the environment
+	// was exported with.
+	Config map[string]interface{} `yaml:"config"`
+
+	// Constraints holds the environment constraints.
+	Constraints string `yaml:"constraints,omitempty"`
+
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+
+	// Charms holds the URLs of the charms used by the services.
+	Charms []string `yaml:"charms,omitempty"`
+
+	Networks  []Network  `yaml:"networks,omitempty"`
+	Machines  []Machine  `yaml:"machines,omitempty"`
+	Services  []Service  `yaml:"services,omitempty"`
+	Relations []Relation `yaml:"relations,omitempty"`
+}
+
+// Network describes a network known to the environment.
+type Network struct {
+	Name       string `yaml:"name"`
+	ProviderId string `yaml:"provider-id"`
+	CIDR       string `yaml:"cidr,omitempty"`
+	VLANTag    int    `yaml:"vlan-tag,omitempty"`
+}
+
+// Machine describes a machine or a container.
+type Machine struct {
+	Id            string   `yaml:"id"`
+	Series        string   `yaml:"series"`
+	ContainerType string   `yaml:"container-type,omitempty"`
+	Jobs          []string `yaml:"jobs"`
+	Placement     string   `yaml:"placement,omitempty"`
+
+	// Constraints and Networks hold what the machine
+	// was requested with.
+	Constraints string   `yaml:"constraints,omitempty"`
+	Networks    []string `yaml:"networks,omitempty"`
+
+	// Nonce, InstanceId and Hardware are only set for
+	// provisioned machines.
+	Nonce      string `yaml:"nonce,omitempty"`
+	InstanceId string `yaml:"instance-id,omitempty"`
+	Hardware   string `yaml:"hardware,omitempty"`
+
+	Addresses []Address `yaml:"addresses,omitempty"`
+
+	// OpenedPorts holds the ports opened on the machine
+	// by its units, by network.
+	OpenedPorts []OpenedPorts `yaml:"opened-ports,omitempty"`
+
+	// PasswordHash holds the hash of the password
+	// of the machine's agent.
+	PasswordHash string `yaml:"password-hash,omitempty"`
+
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+
+	Status     string `yaml:"status"`
+	StatusInfo string `yaml:"status-info,omitempty"`
+}
+
+// OpenedPorts describes the ports opened
+// on a machine on one of its networks.
+type OpenedPorts struct {
+	Network string      `yaml:"network,omitempty"`
+	Ports   []PortRange `yaml:"ports"`
+}
+
+// PortRange describes a range of ports opened by a unit.
+type PortRange struct {
+	Unit     string `yaml:"unit"`
+	FromPort int    `yaml:"from-port"`
+	ToPort   int    `yaml:"to-port"`
+	Protocol string `yaml:"protocol"`
+}
+
+// Address describes an address of a machine.
+type Address struct {
+	Value       string `yaml:"value"`
+	Type        string `yaml:"type"`
+	NetworkName string `yaml:"network-name,omitempty"`
+	Scope       string `yaml:"scope,omitempty"`
+}
+
+// Service describes a service and its units.
+type Service struct {
+	Name        string `yaml:"name"`
+	Series      string `yaml:"series"`
+	Subordinate bool   `yaml:"subordinate,omitempty"`
+	CharmURL    string `yaml:"charm-url"`
+	ForceCharm  bool   `yaml:"force-charm,omitempty"`
+	Owner       string `yaml:"owner"`
+	Exposed     bool   `yaml:"exposed,omitempty"`
+	MinUnits    int    `yaml:"min-units,omitempty"`
+
+	// Settings holds the charm settings of the service.
+	Settings map[string]interface{} `yaml:"settings,omitempty"`
+
+	Constraints string   `yaml:"constraints,omitempty"`
+	Networks    []string `yaml:"networks,omitempty"`
+
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+
+	// UnitSeq holds the number the name of the
+	// next unit of the service is given.
+	UnitSeq int    `yaml:"unit-seq"`
+	Units   []Unit `yaml:"units,omitempty"`
+}
+
+// Unit describes a unit of a service.
+type Unit struct {
+	Name string `yaml:"name"`
+
+	// Machine holds the id of the machine the unit is assigned to,
+	// and Principal the name of the principal of a subordinate unit.
+	Machine   string `yaml:"machine,omitempty"`
+	Principal string `yaml:"principal,omitempty"`
+
+	// CharmURL holds the URL of the charm the unit is running,
+	// if it has been deployed.
+	CharmURL string `yaml:"charm-url,omitempty"`
+
+	// PasswordHash holds the hash of the password
+	// of the unit's agent.
+	PasswordHash string `yaml:"password-hash,omitempty"`
+
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+
+	Status     string `yaml:"status"`
+	StatusInfo string `yaml:"status-info,omitempty"`
+}
+
+// Relation describes a relation between services.
+type Relation struct {
+	Id        int        `yaml:"id"`
+	Key       string     `yaml:"key"`
+	Endpoints []Endpoint `yaml:"endpoints"`
+
+	// Units holds the units that have entered the relation's
+	// scope, and the settings they hold in the relation.
+	Units []RelationUnit `yaml:"units,omitempty"`
+}
+
+// RelationUnit describes a unit in the scope of a relation.
+type RelationUnit struct {
+	Name     string                 `yaml:"name"`
+	Settings map[string]interface{} `yaml:"settings,omitempty"`
+}
+
+// Endpoint describes an endpoint of a relation.
+type Endpoint struct {
+	Service   string `yaml:"service"`
+	Name      string `yaml:"name"`
+	Interface string `yaml:"interface"`
+	Role      string `yaml:"role"`
+	Scope     string `yaml:"scope"`
+	Optional  bool   `yaml:"optional,omitempty"`
+	Limit     int    `yaml:"limit,omitempty"`
+
+	// Network holds the name of the network the
+	// service's endpoint is bound to, if any.
+	Network string `yaml:"network,omitempty"`
+}
+
+// Serialize returns the YAML form of the description, marked
+// with the current format version.
+func Serialize(env *Environment) ([]byte, error) {
+	env.Version = Version
+	data, err := goyaml.Marshal(env)
+	if err != nil {
+		return nil, fmt.Errorf("cannot serialize environment description: %v", err)
+	}
+	return data, nil
+}
+
+// Deserialize reads a description from its YAML form. It fails if
+// the description was written in an unsupported format version.
+func Deserialize(data []byte) (*Environment, error) {
+	var env Environment
+	if err := goyaml.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("cannot read environment description: %v", err)
+	}
+	if env.Version != Version {
+		return nil, fmt.Errorf("unsupported environment description version %d", env.Version)
+	}
+	return &env, nil
+}
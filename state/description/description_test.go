@@ -0,0 +1,91 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package description_test
+
+import (
+	stdtesting "testing"
+
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state/description"
+	"github.com/juju/juju/testing"
+)
+
+func TestPackage(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+type DescriptionSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&DescriptionSuite{})
+
+func (*DescriptionSuite) TestRoundTrip(c *gc.C) {
+	env := &description.Environment{
+		UUID:        "deadbeef-0bad-400d-8000-4b1d0d06f00d",
+		Name:        "sample",
+		Config:      map[string]interface{}{"name": "sample", "type": "dummy"},
+		Constraints: "mem=4096M",
+		Charms:      []string{"cs:quantal/wordpress-3"},
+		Machines: []description.Machine{{
+			Id:         "0",
+			Series:     "quantal",
+			Jobs:       []string{"JobHostUnits"},
+			InstanceId: "i-0",
+			Addresses: []description.Address{{
+				Value: "10.0.0.1",
+				Type:  "ipv4",
+				Scope: "local-cloud",
+			}},
+			Status: "started",
+		}},
+		Services: []description.Service{{
+			Name:     "wordpress",
+			Series:   "quantal",
+			CharmURL: "cs:quantal/wordpress-3",
+			Owner:    "user-admin",
+			Settings: map[string]interface{}{"blog-title": "My Title"},
+			UnitSeq:  1,
+			Units: []description.Unit{{
+				Name:    "wordpress/0",
+				Machine: "0",
+				Status:  "started",
+			}},
+		}},
+		Relations: []description.Relation{{
+			Id:  0,
+			Key: "wordpress:loadbalancer",
+			Endpoints: []description.Endpoint{{
+				Service:   "wordpress",
+				Name:      "loadbalancer",
+				Interface: "phony",
+				Role:      "peer",
+				Scope:     "global",
+				Limit:     1,
+			}},
+		}},
+	}
+	data, err := description.Serialize(env)
+	c.Assert(err, gc.IsNil)
+	c.Assert(env.Version, gc.Equals, description.Version)
+
+	read, err := description.Deserialize(data)
+	c.Assert(err, gc.IsNil)
+	c.Assert(read, jc.DeepEquals, env)
+}
+
+func (*DescriptionSuite) TestDeserializeUnsupportedVersion(c *gc.C) {
+	_, err := description.Deserialize([]byte("version: 42\nuuid: foo\n"))
+	c.Assert(err, gc.ErrorMatches, "unsupported environment description version 42")
+
+	_, err = description.Deserialize([]byte("uuid: foo\n"))
+	c.Assert(err, gc.ErrorMatches, "unsupported environment description version 0")
+}
+
+func (*DescriptionSuite) TestDeserializeInvalid(c *gc.C) {
+	_, err := description.Deserialize([]byte("version: [\n"))
+	c.Assert(err, gc.ErrorMatches, "cannot read environment description: .*")
+}
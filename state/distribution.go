@@ -48,7 +48,7 @@ func distributeUnit(u *Unit, candidates []instance.Id) ([]instance.Id, error) {
 // ServiceInstances returns the instance IDs of provisioned
 // machines that are assigned units of the specified service.
 func ServiceInstances(st *State, service string) ([]instance.Id, error) {
-	units, err := allUnits(st, service)
+	units, err := allUnits(st, st.docID(service))
 	if err != nil {
 		return nil, err
 	}
@@ -4,11 +4,16 @@
 package state
 
 import (
+	"strings"
+
 	"github.com/juju/errors"
 	"github.com/juju/names"
 	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 	"gopkg.in/mgo.v2/txn"
+
+	"github.com/juju/juju/constraints"
+	"github.com/juju/juju/environs/config"
 )
 
 // environGlobalKey is the key for the environment, its
@@ -27,6 +32,11 @@ type environmentDoc struct {
 	UUID string `bson:"_id"`
 	Name string
 	Life Life
+
+	// ServerUUID holds the UUID of the state server environment
+	// hosting the environment. It is empty for the state server
+	// environment itself.
+	ServerUUID string `bson:"server-uuid,omitempty"`
 }
 
 // Environment returns the environment entity.
@@ -35,7 +45,13 @@ func (st *State) Environment() (*Environment, error) {
 	defer closer()
 
 	env := &Environment{st: st}
-	if err := env.refresh(environments.Find(nil)); err != nil {
+	query := environments.Find(nil)
+	if uuid := st.environTag.Id(); uuid != "" {
+		query = environments.FindId(uuid)
+	}
+	if err := st.timeQuery(environmentsC, func() error {
+		return env.refresh(query)
+	}); err != nil {
 		return nil, err
 	}
 	env.annotator = annotator{
@@ -46,6 +62,53 @@ func (st *State) Environment() (*Environment, error) {
 	return env, nil
 }
 
+// NewEnvironment creates a new environment with the given
+// configuration, hosted by the state server of st, and returns a
+// state handle for it, which must be closed independently of st.
+// The configuration must hold a UUID not used by any other
+// environment; if it does, an error satisfying errors.IsAlreadyExists
+// is returned.
+func (st *State) NewEnvironment(cfg *config.Config) (_ *State, err error) {
+	defer errors.Contextf(&err, "cannot create environment %q", cfg.Name())
+	if err := checkEnvironConfig(cfg); err != nil {
+		return nil, err
+	}
+	uuid, ok := cfg.UUID()
+	if !ok {
+		return nil, errors.Errorf("environment uuid was not supplied")
+	}
+	serverUUID := st.serverTag.Id()
+	if serverUUID == "" {
+		return nil, errors.Errorf("state is not initialized")
+	}
+	key := hostedEnvironGlobalKey(uuid)
+	ops := []txn.Op{
+		createConstraintsOp(st, key, constraints.Value{}),
+		createSettingsOp(st, key, cfg.AllAttrs()),
+		createEnvironmentOp(st, cfg.Name(), uuid, serverUUID),
+		{
+			C:      environmentsC,
+			Id:     serverUUID,
+			Assert: isEnvAliveDoc,
+		},
+	}
+	switch err := st.runTransaction(ops); err {
+	case txn.ErrAborted:
+		environments, closer := st.getCollection(environmentsC)
+		defer closer()
+		if n, err := environments.FindId(uuid).Count(); err != nil {
+			return nil, err
+		} else if n > 0 {
+			return nil, errors.AlreadyExistsf("environment %q", uuid)
+		}
+		return nil, errors.Errorf("state server environment is no longer alive")
+	case nil:
+		return st.ForEnviron(names.NewEnvironTag(uuid))
+	default:
+		return nil, err
+	}
+}
+
 // Tag returns a name identifying the environment.
 // The returned name will be different from other Tag values returned
 // by any other entities from the same state.
@@ -68,9 +131,106 @@ func (e *Environment) Life() Life {
 	return e.doc.Life
 }
 
+// ServerTag returns the tag of the state server environment
+// hosting the environment, which is the environment's own tag
+// for the state server environment.
+func (e *Environment) ServerTag() names.EnvironTag {
+	if e.doc.ServerUUID == "" {
+		return names.NewEnvironTag(e.doc.UUID)
+	}
+	return names.NewEnvironTag(e.doc.ServerUUID)
+}
+
 // globalKey returns the global database key for the environment.
 func (e *Environment) globalKey() string {
-	return environGlobalKey
+	if e.doc.ServerUUID == "" {
+		return environGlobalKey
+	}
+	return hostedEnvironGlobalKey(e.doc.UUID)
+}
+
+// hostedEnvironGlobalKey returns the key for the settings,
+// constraints and annotations of the hosted environment
+// with the given UUID.
+func hostedEnvironGlobalKey(uuid string) string {
+	return environGlobalKey + "#" + uuid
+}
+
+// environKey returns the key for the settings and constraints of the
+// environment of the state handle. The state server environment keeps
+// the key it had before other environments could be hosted alongside
+// it.
+func (st *State) environKey() string {
+	if st.environTag == st.serverTag {
+		return environGlobalKey
+	}
+	return hostedEnvironGlobalKey(st.environTag.Id())
+}
+
+// environSelector returns a selector matching the documents that
+// belong to the environment of the state handle. Documents written
+// before environments were recorded on them belong to the state
+// server environment.
+func (st *State) environSelector() bson.D {
+	uuid := st.environTag.Id()
+	switch {
+	case uuid == "":
+		return nil
+	case st.environTag == st.serverTag:
+		return bson.D{{"env-uuid", bson.D{{"$in", []interface{}{uuid, nil}}}}}
+	}
+	return bson.D{{"env-uuid", uuid}}
+}
+
+// docID returns the id of the document, unique across the state
+// server, that holds the entity with the given id in the environment
+// of the state handle. Entities of the state server environment keep
+// the ids they had before other environments could be hosted
+// alongside it; those of hosted environments are prefixed with the
+// environment UUID, so that each environment may name its machines,
+// services and units independently. Ids that are already prefixed,
+// and empty ids, are returned unchanged.
+func (st *State) docID(localID string) string {
+	if localID == "" || st.environTag == st.serverTag {
+		return localID
+	}
+	prefix := st.environTag.Id() + ":"
+	if strings.HasPrefix(localID, prefix) {
+		return localID
+	}
+	return prefix + localID
+}
+
+// localID returns the id, within the environment of the state
+// handle, of the entity held in the document with the given id.
+func (st *State) localID(docID string) string {
+	if st.environTag == st.serverTag {
+		return docID
+	}
+	return strings.TrimPrefix(docID, st.environTag.Id()+":")
+}
+
+// localIDs returns the local ids of the entities held in the
+// documents with the given ids.
+func (st *State) localIDs(docIDs []string) []string {
+	if docIDs == nil {
+		return nil
+	}
+	ids := make([]string, len(docIDs))
+	for i, docID := range docIDs {
+		ids[i] = st.localID(docID)
+	}
+	return ids
+}
+
+// ownsDocID reports whether the document with the given id, which must
+// hold an entity whose local id never contains a colon, belongs to the
+// environment of the state handle.
+func (st *State) ownsDocID(docID string) bool {
+	if st.environTag == st.serverTag {
+		return !strings.Contains(docID, ":")
+	}
+	return strings.HasPrefix(docID, st.environTag.Id()+":")
 }
 
 func (e *Environment) Refresh() error {
@@ -124,10 +284,11 @@ func (e *Environment) Destroy() error {
 	return err
 }
 
-// createEnvironmentOp returns the operation needed to create
-// an environment document with the given name and UUID.
-func createEnvironmentOp(st *State, name, uuid string) txn.Op {
-	doc := &environmentDoc{uuid, name, Alive}
+// createEnvironmentOp returns the operation needed to create an
+// environment document with the given name and UUID, hosted by
+// the state server environment with the given UUID, if any.
+func createEnvironmentOp(st *State, name, uuid, serverUUID string) txn.Op {
+	doc := &environmentDoc{uuid, name, Alive, serverUUID}
 	return txn.Op{
 		C:      environmentsC,
 		Id:     uuid,
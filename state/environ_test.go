@@ -4,10 +4,17 @@
 package state_test
 
 import (
+	"github.com/juju/errors"
 	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
 	gc "launchpad.net/gocheck"
 
+	"github.com/juju/juju/constraints"
 	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/state/multiwatcher"
+	statetesting "github.com/juju/juju/state/testing"
+	coretesting "github.com/juju/juju/testing"
 )
 
 type EnvironSuite struct {
@@ -53,3 +60,334 @@ func (s *EnvironSuite) TestAnnotatorForEnvironment(c *gc.C) {
 		return s.State.Environment()
 	})
 }
+
+// newHostedEnvironment creates an environment hosted by the
+// state server of the suite and returns a state handle for it.
+func (s *EnvironSuite) newHostedEnvironment(c *gc.C) *state.State {
+	cfg, err := coretesting.EnvironConfig(c).Apply(map[string]interface{}{"name": "hosted"})
+	c.Assert(err, gc.IsNil)
+	st, err := s.State.NewEnvironment(cfg)
+	c.Assert(err, gc.IsNil)
+	return st
+}
+
+func (s *EnvironSuite) TestNewEnvironment(c *gc.C) {
+	c.Assert(s.env.ServerTag(), gc.Equals, s.env.Tag())
+	c.Assert(s.State.ServerTag(), gc.Equals, s.State.EnvironTag())
+
+	st := s.newHostedEnvironment(c)
+	defer st.Close()
+	c.Assert(st.EnvironTag(), gc.Not(gc.Equals), s.State.EnvironTag())
+	c.Assert(st.ServerTag(), gc.Equals, s.State.EnvironTag())
+
+	env, err := st.Environment()
+	c.Assert(err, gc.IsNil)
+	c.Assert(env.Tag(), gc.Equals, st.EnvironTag())
+	c.Assert(env.Name(), gc.Equals, "hosted")
+	c.Assert(env.Life(), gc.Equals, state.Alive)
+	c.Assert(env.ServerTag(), gc.Equals, s.env.Tag())
+
+	// Each environment has its own configuration and constraints.
+	cfg, err := st.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	c.Assert(cfg.Name(), gc.Equals, "hosted")
+	cfg, err = s.State.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	c.Assert(cfg.Name(), gc.Equals, "testenv")
+	err = st.SetEnvironConstraints(constraints.MustParse("mem=4G"))
+	c.Assert(err, gc.IsNil)
+	cons, err := s.State.EnvironConstraints()
+	c.Assert(err, gc.IsNil)
+	c.Assert(cons, gc.DeepEquals, constraints.Value{})
+
+	// The state server environment is still found from a new handle.
+	env, err = s.State.Environment()
+	c.Assert(err, gc.IsNil)
+	c.Assert(env.Name(), gc.Equals, "testenv")
+}
+
+func (s *EnvironSuite) TestNewEnvironmentSameUUID(c *gc.C) {
+	st := s.newHostedEnvironment(c)
+	defer st.Close()
+	env, err := st.Environment()
+	c.Assert(err, gc.IsNil)
+
+	cfg, err := st.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.NewEnvironment(cfg)
+	c.Assert(err, jc.Satisfies, errors.IsAlreadyExists)
+	c.Assert(err, gc.ErrorMatches, `cannot create environment "hosted": environment "`+env.UUID()+`" already exists`)
+}
+
+func (s *EnvironSuite) TestForEnviron(c *gc.C) {
+	st := s.newHostedEnvironment(c)
+	defer st.Close()
+
+	other, err := s.State.ForEnviron(st.EnvironTag())
+	c.Assert(err, gc.IsNil)
+	defer other.Close()
+	c.Assert(other.EnvironTag(), gc.Equals, st.EnvironTag())
+	env, err := other.Environment()
+	c.Assert(err, gc.IsNil)
+	c.Assert(env.Name(), gc.Equals, "hosted")
+
+	missing := names.NewEnvironTag("f47ac10b-58cc-4372-a567-0e02b2c3d479")
+	_, err = s.State.ForEnviron(missing)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	c.Assert(err, gc.ErrorMatches, `cannot open environment "f47ac10b-58cc-4372-a567-0e02b2c3d479": environment not found`)
+}
+
+func (s *EnvironSuite) TestHostedEnvironmentSharesWatcher(c *gc.C) {
+	st := s.newHostedEnvironment(c)
+	c.Assert(state.SharesWatchers(s.State, st), jc.IsTrue)
+
+	w := st.WatchEnvironMachines()
+	wc := statetesting.NewStringsWatcherC(c, s.State, w)
+	wc.AssertChange()
+	wc.AssertNoChange()
+	serverW := s.State.WatchEnvironMachines()
+	defer statetesting.AssertStop(c, serverW)
+	serverWC := statetesting.NewStringsWatcherC(c, s.State, serverW)
+	serverWC.AssertChange()
+	serverWC.AssertNoChange()
+
+	m, err := st.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	wc.AssertChange(m.Id())
+	wc.AssertNoChange()
+	serverWC.AssertNoChange()
+
+	// Closing the hosted environment's handle stops its
+	// watchers, but not those of the state server's.
+	err = st.Close()
+	c.Assert(err, gc.IsNil)
+	wc.AssertClosed()
+	c.Assert(w.Err(), gc.Equals, state.ErrStateClosed)
+	m, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	serverWC.AssertChange(m.Id())
+	serverWC.AssertNoChange()
+}
+
+func (s *EnvironSuite) TestUserStateSharesWatchers(c *gc.C) {
+	st, err := s.State.ForUser(names.NewUserTag("admin"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(state.SharesWatchers(s.State, st), jc.IsTrue)
+
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	userM, err := st.Machine(m.Id())
+	c.Assert(err, gc.IsNil)
+	pinger, err := m.SetAgentPresence()
+	c.Assert(err, gc.IsNil)
+	defer pinger.Stop()
+	s.State.StartSync()
+	err = userM.WaitAgentPresence(coretesting.LongWait)
+	c.Assert(err, gc.IsNil)
+
+	// Closing the user's handle leaves the presence
+	// watcher of the state server's handle running.
+	err = st.Close()
+	c.Assert(err, gc.IsNil)
+	s.State.StartSync()
+	err = m.WaitAgentPresence(coretesting.LongWait)
+	c.Assert(err, gc.IsNil)
+	alive, err := m.AgentPresence()
+	c.Assert(err, gc.IsNil)
+	c.Assert(alive, jc.IsTrue)
+}
+
+func (s *EnvironSuite) TestHostedEnvironmentIsolation(c *gc.C) {
+	st := s.newHostedEnvironment(c)
+	defer st.Close()
+
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	hostedMachine, err := st.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	charm := s.AddTestingCharm(c, "wordpress")
+	state.AddTestingService(c, s.State, "wordpress", charm)
+	hostedService := state.AddTestingService(c, st, "blog", charm)
+	unit, err := hostedService.AddUnit()
+	c.Assert(err, gc.IsNil)
+	_, err = st.AddNetwork(state.NetworkInfo{"net1", "net1", "0.1.2.0/24", 0, ""})
+	c.Assert(err, gc.IsNil)
+
+	machines, err := s.State.AllMachines()
+	c.Assert(err, gc.IsNil)
+	c.Assert(machines, gc.HasLen, 1)
+	c.Assert(machines[0].Id(), gc.Equals, machine.Id())
+	machines, err = st.AllMachines()
+	c.Assert(err, gc.IsNil)
+	c.Assert(machines, gc.HasLen, 1)
+	c.Assert(machines[0].Id(), gc.Equals, hostedMachine.Id())
+	_, err = s.State.Machine(hostedMachine.Id())
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	_, err = st.Machine(machine.Id())
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	services, err := s.State.AllServices()
+	c.Assert(err, gc.IsNil)
+	c.Assert(services, gc.HasLen, 1)
+	c.Assert(services[0].Name(), gc.Equals, "wordpress")
+	services, err = st.AllServices()
+	c.Assert(err, gc.IsNil)
+	c.Assert(services, gc.HasLen, 1)
+	c.Assert(services[0].Name(), gc.Equals, "blog")
+	_, err = s.State.Service("blog")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	_, err = st.Service("wordpress")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	_, err = s.State.Unit(unit.Name())
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	_, err = st.Unit(unit.Name())
+	c.Assert(err, gc.IsNil)
+
+	networks, err := s.State.AllNetworks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(networks, gc.HasLen, 0)
+	_, err = s.State.Network("net1")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	_, err = st.Network("net1")
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *EnvironSuite) TestHostedEnvironmentRelationIsolation(c *gc.C) {
+	st := s.newHostedEnvironment(c)
+	defer st.Close()
+	state.AddTestingService(c, st, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	state.AddTestingService(c, st, "mysql", s.AddTestingCharm(c, "mysql"))
+	eps, err := st.InferEndpoints([]string{"wordpress", "mysql"})
+	c.Assert(err, gc.IsNil)
+	rel, err := st.AddRelation(eps...)
+	c.Assert(err, gc.IsNil)
+
+	relations, err := st.AllRelations()
+	c.Assert(err, gc.IsNil)
+	c.Assert(relations, gc.HasLen, 1)
+	c.Assert(relations[0].Id(), gc.Equals, rel.Id())
+	_, err = st.Relation(rel.Id())
+	c.Assert(err, gc.IsNil)
+
+	relations, err = s.State.AllRelations()
+	c.Assert(err, gc.IsNil)
+	c.Assert(relations, gc.HasLen, 0)
+	_, err = s.State.Relation(rel.Id())
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	_, err = s.State.KeyRelation(rel.String())
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *EnvironSuite) TestHostedEnvironmentSameNames(c *gc.C) {
+	st := s.newHostedEnvironment(c)
+	defer st.Close()
+	charm := s.AddTestingCharm(c, "wordpress")
+
+	var units []*state.Unit
+	for _, st := range []*state.State{s.State, st} {
+		machine, err := st.AddMachine("quantal", state.JobHostUnits)
+		c.Assert(err, gc.IsNil)
+		c.Assert(machine.Id(), gc.Equals, "0")
+		service := state.AddTestingService(c, st, "wordpress", charm)
+		c.Assert(service.Name(), gc.Equals, "wordpress")
+		unit, err := service.AddUnit()
+		c.Assert(err, gc.IsNil)
+		c.Assert(unit.Name(), gc.Equals, "wordpress/0")
+		c.Assert(unit.ServiceName(), gc.Equals, "wordpress")
+		err = unit.AssignToMachine(machine)
+		c.Assert(err, gc.IsNil)
+		machineId, err := unit.AssignedMachineId()
+		c.Assert(err, gc.IsNil)
+		c.Assert(machineId, gc.Equals, "0")
+		units = append(units, unit)
+	}
+
+	// Destroying the hosted service leaves the one of
+	// the state server's environment alone.
+	err := units[1].Destroy()
+	c.Assert(err, gc.IsNil)
+	service, err := st.Service("wordpress")
+	c.Assert(err, gc.IsNil)
+	err = service.Destroy()
+	c.Assert(err, gc.IsNil)
+	_, err = st.Service("wordpress")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	service, err = s.State.Service("wordpress")
+	c.Assert(err, gc.IsNil)
+	c.Assert(service.Life(), gc.Equals, state.Alive)
+	unit, err := s.State.Unit("wordpress/0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(unit.Life(), gc.Equals, state.Alive)
+	machine, err := st.Machine("0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(machine.Life(), gc.Equals, state.Alive)
+	units, err = machine.Units()
+	c.Assert(err, gc.IsNil)
+	c.Assert(units, gc.HasLen, 0)
+}
+
+func (s *EnvironSuite) TestAllWatcherOfHostedEnvironment(c *gc.C) {
+	st := s.newHostedEnvironment(c)
+	defer st.Close()
+	machine0, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	hostedMachine0, err := st.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	w := s.State.Watch()
+	defer w.Stop()
+	c.Assert(nextMachineIds(c, w), jc.SameContents, []string{machine0.Id()})
+
+	// Changes to the hosted environment are not reported
+	// to watchers of the state server's environment.
+	hostedMachine1, err := st.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	machine1, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	c.Assert(nextMachineIds(c, w), jc.SameContents, []string{machine1.Id()})
+
+	hw := st.Watch()
+	defer hw.Stop()
+	c.Assert(nextMachineIds(c, hw), jc.SameContents, []string{hostedMachine0.Id(), hostedMachine1.Id()})
+}
+
+// nextMachineIds returns the ids of the machines
+// in the next deltas reported by w.
+func nextMachineIds(c *gc.C, w *multiwatcher.Watcher) []string {
+	deltas, err := w.Next()
+	c.Assert(err, gc.IsNil)
+	var ids []string
+	for _, delta := range deltas {
+		ids = append(ids, delta.Entity.(*params.MachineInfo).Id)
+	}
+	return ids
+}
+
+func (s *EnvironSuite) TestWatchServicesOfHostedEnvironment(c *gc.C) {
+	st := s.newHostedEnvironment(c)
+	defer st.Close()
+	charm := s.AddTestingCharm(c, "wordpress")
+
+	w := s.State.WatchServices()
+	defer statetesting.AssertStop(c, w)
+	wc := statetesting.NewStringsWatcherC(c, s.State, w)
+	wc.AssertChange()
+	wc.AssertNoChange()
+	hw := st.WatchServices()
+	defer statetesting.AssertStop(c, hw)
+	hwc := statetesting.NewStringsWatcherC(c, st, hw)
+	hwc.AssertChange()
+	hwc.AssertNoChange()
+
+	state.AddTestingService(c, st, "blog", charm)
+	wc.AssertNoChange()
+	hwc.AssertChange("blog")
+	hwc.AssertNoChange()
+
+	state.AddTestingService(c, s.State, "wordpress", charm)
+	wc.AssertChange("wordpress")
+	wc.AssertNoChange()
+	hwc.AssertNoChange()
+}
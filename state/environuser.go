@@ -0,0 +1,137 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+
+	"github.com/juju/errors"
+	jujutxn "github.com/juju/txn"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+)
+
+// EnvironmentAccess describes what a user may do in an environment.
+type EnvironmentAccess string
+
+const (
+	// EnvironmentAdminAccess allows the user to change the environment.
+	EnvironmentAdminAccess EnvironmentAccess = "admin"
+
+	// EnvironmentReadAccess only allows the user to look at the
+	// environment.
+	EnvironmentReadAccess EnvironmentAccess = "read"
+)
+
+// Validate returns an error if the access is not known.
+func (a EnvironmentAccess) Validate() error {
+	switch a {
+	case EnvironmentAdminAccess, EnvironmentReadAccess:
+		return nil
+	}
+	return fmt.Errorf("unknown environment access %q", a)
+}
+
+// environUserDoc records the access a user has been granted to an
+// environment. Users have no access to the environments they have
+// no document for.
+type environUserDoc struct {
+	Id       string `bson:"_id"`
+	EnvUUID  string
+	UserName string
+	Access   EnvironmentAccess
+}
+
+// environUserId returns the id of the document recording
+// the access of the named user to the state's environment.
+func (st *State) environUserId(userName string) string {
+	return st.environTag.Id() + ":" + userName
+}
+
+// addEnvironUserOp returns the operation that grants the named user
+// the given access to the state's environment.
+func (st *State) addEnvironUserOp(userName string, access EnvironmentAccess) txn.Op {
+	return txn.Op{
+		C:      environUsersC,
+		Id:     st.environUserId(userName),
+		Assert: txn.DocMissing,
+		Insert: &environUserDoc{
+			Id:       st.environUserId(userName),
+			EnvUUID:  st.environTag.Id(),
+			UserName: userName,
+			Access:   access,
+		},
+	}
+}
+
+// EnvironmentAccess returns the access the user has been granted to
+// the environment of the state the user was obtained from. It returns
+// an error satisfying errors.IsNotFound if the user has no access.
+func (u *User) EnvironmentAccess() (EnvironmentAccess, error) {
+	environUsers, closer := u.st.getCollection(environUsersC)
+	defer closer()
+
+	var doc environUserDoc
+	err := environUsers.FindId(u.st.environUserId(u.doc.Name)).One(&doc)
+	if err == mgo.ErrNotFound {
+		return "", errors.NotFoundf("access of user %q to environment %q", u.doc.Name, u.st.environTag.Id())
+	}
+	if err != nil {
+		return "", fmt.Errorf("cannot get access of user %q: %v", u.doc.Name, err)
+	}
+	return doc.Access, nil
+}
+
+// SetEnvironmentAccess grants the user the given access to the
+// environment of the state the user was obtained from, replacing
+// any access granted before.
+func (u *User) SetEnvironmentAccess(access EnvironmentAccess) (err error) {
+	defer errors.Maskf(&err, "cannot set environment access of user %q", u.doc.Name)
+	if err := access.Validate(); err != nil {
+		return err
+	}
+	if u.doc.Name == AdminUser && access != EnvironmentAdminAccess {
+		return errors.Unauthorizedf("cannot restrict admin user")
+	}
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		current, err := u.EnvironmentAccess()
+		if errors.IsNotFound(err) {
+			return []txn.Op{{
+				C:      usersC,
+				Id:     u.doc.Name,
+				Assert: txn.DocExists,
+			}, u.st.addEnvironUserOp(u.doc.Name, access)}, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if current == access {
+			return nil, jujutxn.ErrNoOperations
+		}
+		return []txn.Op{{
+			C:      environUsersC,
+			Id:     u.st.environUserId(u.doc.Name),
+			Assert: txn.DocExists,
+			Update: bson.D{{"$set", bson.D{{"access", access}}}},
+		}}, nil
+	}
+	return u.st.runDiagnosed(buildTxn)
+}
+
+// RevokeEnvironmentAccess removes the access the user has been
+// granted to the environment of the state the user was obtained from,
+// so that the user can no longer log in to it. Access of the admin
+// user cannot be revoked.
+func (u *User) RevokeEnvironmentAccess() (err error) {
+	defer errors.Maskf(&err, "cannot revoke environment access of user %q", u.doc.Name)
+	if u.doc.Name == AdminUser {
+		return errors.Unauthorizedf("cannot revoke access of admin user")
+	}
+	ops := []txn.Op{{
+		C:      environUsersC,
+		Id:     u.st.environUserId(u.doc.Name),
+		Remove: true,
+	}}
+	return u.st.runTransaction(ops)
+}
@@ -0,0 +1,135 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/testing/factory"
+)
+
+type EnvironUserSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&EnvironUserSuite{})
+
+func (s *EnvironUserSuite) TestAddUserGrantsAdminAccess(c *gc.C) {
+	user, err := s.State.AddUser("bob", "", "password", "admin")
+	c.Assert(err, gc.IsNil)
+	access, err := user.EnvironmentAccess()
+	c.Assert(err, gc.IsNil)
+	c.Assert(access, gc.Equals, state.EnvironmentAdminAccess)
+}
+
+func (s *EnvironUserSuite) TestAddUserWithAccess(c *gc.C) {
+	user, err := s.State.AddUserWithAccess("bob", "", "password", "admin", state.EnvironmentReadAccess)
+	c.Assert(err, gc.IsNil)
+	access, err := user.EnvironmentAccess()
+	c.Assert(err, gc.IsNil)
+	c.Assert(access, gc.Equals, state.EnvironmentReadAccess)
+
+	user, err = s.State.User("bob")
+	c.Assert(err, gc.IsNil)
+	access, err = user.EnvironmentAccess()
+	c.Assert(err, gc.IsNil)
+	c.Assert(access, gc.Equals, state.EnvironmentReadAccess)
+}
+
+func (s *EnvironUserSuite) TestAddUserWithInvalidAccess(c *gc.C) {
+	_, err := s.State.AddUserWithAccess("bob", "", "password", "admin", "superuser")
+	c.Assert(err, gc.ErrorMatches, `unknown environment access "superuser"`)
+	_, err = s.State.User("bob")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *EnvironUserSuite) TestSetEnvironmentAccess(c *gc.C) {
+	user := s.factory.MakeUser()
+	err := user.SetEnvironmentAccess(state.EnvironmentReadAccess)
+	c.Assert(err, gc.IsNil)
+	access, err := user.EnvironmentAccess()
+	c.Assert(err, gc.IsNil)
+	c.Assert(access, gc.Equals, state.EnvironmentReadAccess)
+
+	// Setting the same access again is fine.
+	err = user.SetEnvironmentAccess(state.EnvironmentReadAccess)
+	c.Assert(err, gc.IsNil)
+
+	err = user.SetEnvironmentAccess("superuser")
+	c.Assert(err, gc.ErrorMatches, `cannot set environment access of user ".*": unknown environment access "superuser"`)
+}
+
+func (s *EnvironUserSuite) TestRevokeEnvironmentAccess(c *gc.C) {
+	user := s.factory.MakeUser()
+	err := user.RevokeEnvironmentAccess()
+	c.Assert(err, gc.IsNil)
+	_, err = user.EnvironmentAccess()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	// Revoking access twice is fine.
+	err = user.RevokeEnvironmentAccess()
+	c.Assert(err, gc.IsNil)
+
+	// Access can be granted again.
+	err = user.SetEnvironmentAccess(state.EnvironmentAdminAccess)
+	c.Assert(err, gc.IsNil)
+	access, err := user.EnvironmentAccess()
+	c.Assert(err, gc.IsNil)
+	c.Assert(access, gc.Equals, state.EnvironmentAdminAccess)
+}
+
+func (s *EnvironUserSuite) TestAdminUserCannotBeRestricted(c *gc.C) {
+	admin, err := s.State.User(state.AdminUser)
+	c.Assert(err, gc.IsNil)
+	err = admin.SetEnvironmentAccess(state.EnvironmentReadAccess)
+	c.Assert(err, gc.ErrorMatches, `cannot set environment access of user "admin": cannot restrict admin user`)
+	err = admin.RevokeEnvironmentAccess()
+	c.Assert(err, gc.ErrorMatches, `cannot revoke environment access of user "admin": cannot revoke access of admin user`)
+	access, err := admin.EnvironmentAccess()
+	c.Assert(err, gc.IsNil)
+	c.Assert(access, gc.Equals, state.EnvironmentAdminAccess)
+}
+
+func (s *EnvironUserSuite) TestAccessIsPerEnvironment(c *gc.C) {
+	s.factory.MakeUser(factory.UserParams{Username: "bob"})
+
+	cfg, err := coretesting.EnvironConfig(c).Apply(map[string]interface{}{"name": "hosted"})
+	c.Assert(err, gc.IsNil)
+	hosted, err := s.State.NewEnvironment(cfg)
+	c.Assert(err, gc.IsNil)
+	defer hosted.Close()
+
+	user, err := hosted.User("bob")
+	c.Assert(err, gc.IsNil)
+	_, err = user.EnvironmentAccess()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	err = user.SetEnvironmentAccess(state.EnvironmentReadAccess)
+	c.Assert(err, gc.IsNil)
+	access, err := user.EnvironmentAccess()
+	c.Assert(err, gc.IsNil)
+	c.Assert(access, gc.Equals, state.EnvironmentReadAccess)
+
+	user, err = s.State.User("bob")
+	c.Assert(err, gc.IsNil)
+	access, err = user.EnvironmentAccess()
+	c.Assert(err, gc.IsNil)
+	c.Assert(access, gc.Equals, state.EnvironmentAdminAccess)
+}
+
+func (s *EnvironUserSuite) TestAddEnvironmentAccessForUsers(c *gc.C) {
+	bob := s.factory.MakeUser(factory.UserParams{Username: "bob"})
+	err := bob.RevokeEnvironmentAccess()
+	c.Assert(err, gc.IsNil)
+
+	err = state.AddEnvironmentAccessForUsers(s.State)
+	c.Assert(err, gc.IsNil)
+	access, err := bob.EnvironmentAccess()
+	c.Assert(err, gc.IsNil)
+	c.Assert(access, gc.Equals, state.EnvironmentAdminAccess)
+}
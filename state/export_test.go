@@ -251,3 +251,9 @@ var (
 	GetOrCreatePorts = getOrCreatePorts
 	GetPorts         = getPorts
 )
+
+// SharesWatchers returns whether the given state handles use
+// the same transaction log and presence watchers.
+func SharesWatchers(st1, st2 *State) bool {
+	return st1.shared == st2.shared
+}
@@ -0,0 +1,99 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"github.com/juju/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+
+	"github.com/juju/juju/interfaceschema"
+)
+
+// interfaceSchemaDoc is the persistent representation
+// of an interfaceschema.Schema.
+type interfaceSchemaDoc struct {
+	Interface string `bson:"_id"`
+	Fields    map[string]interfaceschema.FieldType
+	Strict    bool
+}
+
+// InterfaceSchema returns the schema registered for the named
+// relation interface.
+func (st *State) InterfaceSchema(name string) (*interfaceschema.Schema, error) {
+	schemas, closer := st.getCollection(interfaceSchemasC)
+	defer closer()
+
+	var doc interfaceSchemaDoc
+	if err := schemas.FindId(name).One(&doc); err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("schema for interface %q", name)
+	} else if err != nil {
+		return nil, errors.Annotatef(err, "cannot get schema for interface %q", name)
+	}
+	return &interfaceschema.Schema{
+		Interface: doc.Interface,
+		Fields:    doc.Fields,
+		Strict:    doc.Strict,
+	}, nil
+}
+
+// SetInterfaceSchema registers the given schema, replacing any schema
+// previously registered for the same interface. Relation settings
+// written by units are validated against the schema of the
+// relation's interface.
+func (st *State) SetInterfaceSchema(schema interfaceschema.Schema) (err error) {
+	defer errors.Maskf(&err, "cannot set schema for interface %q", schema.Interface)
+	if err := schema.Validate(); err != nil {
+		return err
+	}
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		schemas, closer := st.getCollection(interfaceSchemasC)
+		defer closer()
+		count, err := schemas.FindId(schema.Interface).Count()
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return []txn.Op{{
+				C:      interfaceSchemasC,
+				Id:     schema.Interface,
+				Assert: txn.DocMissing,
+				Insert: &interfaceSchemaDoc{
+					Interface: schema.Interface,
+					Fields:    schema.Fields,
+					Strict:    schema.Strict,
+				},
+			}}, nil
+		}
+		return []txn.Op{{
+			C:      interfaceSchemasC,
+			Id:     schema.Interface,
+			Assert: txn.DocExists,
+			Update: bson.D{{"$set", bson.D{
+				{"fields", schema.Fields},
+				{"strict", schema.Strict},
+			}}},
+		}}, nil
+	}
+	return st.runDiagnosed(buildTxn)
+}
+
+// RemoveInterfaceSchema removes the schema registered for the
+// named interface, so that its relation settings are no longer
+// validated.
+func (st *State) RemoveInterfaceSchema(name string) error {
+	ops := []txn.Op{{
+		C:      interfaceSchemasC,
+		Id:     name,
+		Assert: txn.DocExists,
+		Remove: true,
+	}}
+	if err := st.runTransaction(ops); err == txn.ErrAborted {
+		return errors.NotFoundf("schema for interface %q", name)
+	} else if err != nil {
+		return errors.Annotatef(err, "cannot remove schema for interface %q", name)
+	}
+	return nil
+}
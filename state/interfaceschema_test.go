@@ -0,0 +1,91 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/interfaceschema"
+)
+
+type InterfaceSchemaSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&InterfaceSchemaSuite{})
+
+var mysqlSchema = interfaceschema.Schema{
+	Interface: "mysql",
+	Fields: map[string]interfaceschema.FieldType{
+		"host":     interfaceschema.String,
+		"port":     interfaceschema.Int,
+		"ratio":    interfaceschema.Float,
+		"slave":    interfaceschema.Bool,
+		"database": interfaceschema.String,
+	},
+}
+
+func (s *InterfaceSchemaSuite) TestSetAndGetSchema(c *gc.C) {
+	_, err := s.State.InterfaceSchema("mysql")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	c.Assert(err, gc.ErrorMatches, `schema for interface "mysql" not found`)
+
+	err = s.State.SetInterfaceSchema(mysqlSchema)
+	c.Assert(err, gc.IsNil)
+	schema, err := s.State.InterfaceSchema("mysql")
+	c.Assert(err, gc.IsNil)
+	c.Assert(*schema, jc.DeepEquals, mysqlSchema)
+
+	// Setting the schema again replaces it.
+	replacement := interfaceschema.Schema{
+		Interface: "mysql",
+		Fields:    map[string]interfaceschema.FieldType{"port": interfaceschema.Int},
+		Strict:    true,
+	}
+	err = s.State.SetInterfaceSchema(replacement)
+	c.Assert(err, gc.IsNil)
+	schema, err = s.State.InterfaceSchema("mysql")
+	c.Assert(err, gc.IsNil)
+	c.Assert(*schema, jc.DeepEquals, replacement)
+}
+
+func (s *InterfaceSchemaSuite) TestSetInvalidSchema(c *gc.C) {
+	for i, test := range []struct {
+		schema interfaceschema.Schema
+		err    string
+	}{{
+		schema: interfaceschema.Schema{},
+		err:    `cannot set schema for interface "": empty interface name`,
+	}, {
+		schema: interfaceschema.Schema{
+			Interface: "http",
+			Fields:    map[string]interfaceschema.FieldType{"a.b": interfaceschema.String},
+		},
+		err: `cannot set schema for interface "http": invalid field name "a.b"`,
+	}, {
+		schema: interfaceschema.Schema{
+			Interface: "http",
+			Fields:    map[string]interfaceschema.FieldType{"port": "uint"},
+		},
+		err: `cannot set schema for interface "http": field "port" has unknown type "uint"`,
+	}} {
+		c.Logf("test %d", i)
+		err := s.State.SetInterfaceSchema(test.schema)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
+
+func (s *InterfaceSchemaSuite) TestRemoveSchema(c *gc.C) {
+	err := s.State.RemoveInterfaceSchema("mysql")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	err = s.State.SetInterfaceSchema(mysqlSchema)
+	c.Assert(err, gc.IsNil)
+	err = s.State.RemoveInterfaceSchema("mysql")
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.InterfaceSchema("mysql")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
@@ -0,0 +1,329 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+
+	"github.com/juju/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+)
+
+// IPAddress represents the state of an IP address allocated
+// from a subnet to a machine.
+type IPAddress struct {
+	st  *State
+	doc ipAddressDoc
+}
+
+// ipAddressDoc records the allocation of an IP address. The
+// address is the document id, so that each address can only
+// be allocated once.
+type ipAddressDoc struct {
+	Value         string `bson:"_id"`
+	SubnetCIDR    string
+	MachineId     string
+	InterfaceName string `bson:",omitempty"`
+	Life          Life
+}
+
+func newIPAddress(st *State, doc *ipAddressDoc) *IPAddress {
+	return &IPAddress{st, *doc}
+}
+
+// GoString implements fmt.GoStringer.
+func (a *IPAddress) GoString() string {
+	return fmt.Sprintf(
+		"&state.IPAddress{value: %q, subnet: %q, machineId: %q, interfaceName: %q}",
+		a.Value(), a.SubnetCIDR(), a.MachineId(), a.InterfaceName())
+}
+
+// Value returns the IP address.
+func (a *IPAddress) Value() string {
+	return a.doc.Value
+}
+
+// SubnetCIDR returns the CIDR of the subnet the address
+// was allocated from.
+func (a *IPAddress) SubnetCIDR() string {
+	return a.doc.SubnetCIDR
+}
+
+// MachineId returns the id of the machine the
+// address is allocated to.
+func (a *IPAddress) MachineId() string {
+	return a.st.localID(a.doc.MachineId)
+}
+
+// InterfaceName returns the name of the network interface of the
+// machine the address is allocated to, or an empty string if the
+// address is not bound to a particular interface.
+func (a *IPAddress) InterfaceName() string {
+	return a.doc.InterfaceName
+}
+
+// Life returns whether the address is Alive, while it is allocated,
+// or Dead, once it has been released.
+func (a *IPAddress) Life() Life {
+	return a.doc.Life
+}
+
+// Release marks the address as released. A released address is not
+// allocated again until it is removed, so that it can be unconfigured
+// from its machine first. Any record of the address being given to a
+// container is removed.
+func (a *IPAddress) Release() (err error) {
+	defer errors.Maskf(&err, "cannot release IP address %q", a.doc.Value)
+	if a.doc.Life == Dead {
+		return nil
+	}
+	ops := []txn.Op{{
+		C:      ipAddressesC,
+		Id:     a.doc.Value,
+		Assert: txn.DocExists,
+		Update: bson.D{{"$set", bson.D{{"life", Dead}}}},
+	}, removeContainerAddressOp(a.doc.Value)}
+	if err := a.st.runTransaction(ops); err != nil {
+		return onAbort(err, errors.NotFoundf("IP address %q", a.doc.Value))
+	}
+	a.doc.Life = Dead
+	return nil
+}
+
+// Remove removes the released address from state,
+// making it available for allocation again.
+func (a *IPAddress) Remove() (err error) {
+	defer errors.Maskf(&err, "cannot remove IP address %q", a.doc.Value)
+	if a.doc.Life != Dead {
+		return fmt.Errorf("IP address is not released")
+	}
+	ops := []txn.Op{{
+		C:      ipAddressesC,
+		Id:     a.doc.Value,
+		Assert: isDeadDoc,
+		Remove: true,
+	}}
+	// The only abort conditions in play indicate that the address
+	// has already been removed.
+	return onAbort(a.st.runTransaction(ops), nil)
+}
+
+// Refresh refreshes the contents of the address from the underlying
+// state. It returns an error that satisfies errors.IsNotFound if the
+// address has been removed.
+func (a *IPAddress) Refresh() error {
+	addr, err := a.st.IPAddress(a.doc.Value)
+	if err != nil {
+		return err
+	}
+	a.doc = addr.doc
+	return nil
+}
+
+// IPAddress returns the allocated or released IP address
+// with the given value.
+func (st *State) IPAddress(value string) (*IPAddress, error) {
+	ipAddresses, closer := st.getCollection(ipAddressesC)
+	defer closer()
+
+	doc := &ipAddressDoc{}
+	err := ipAddresses.FindId(value).One(doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("IP address %q", value)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get IP address %q: %v", value, err)
+	}
+	return newIPAddress(st, doc), nil
+}
+
+// IPAddresses returns the addresses allocated from the subnet,
+// including the released ones not yet removed.
+func (s *Subnet) IPAddresses() ([]*IPAddress, error) {
+	return s.st.ipAddresses(bson.D{{"subnetcidr", s.doc.CIDR}})
+}
+
+// IPAddresses returns the addresses allocated to the machine,
+// including the released ones not yet removed.
+func (m *Machine) IPAddresses() ([]*IPAddress, error) {
+	return m.st.ipAddresses(bson.D{{"machineid", m.doc.Id}})
+}
+
+// IPAddresses returns the addresses allocated to the network
+// interface, including the released ones not yet removed.
+func (ni *NetworkInterface) IPAddresses() ([]*IPAddress, error) {
+	return ni.st.ipAddresses(bson.D{
+		{"machineid", ni.doc.MachineId},
+		{"interfacename", ni.doc.InterfaceName},
+	})
+}
+
+func (st *State) ipAddresses(sel bson.D) ([]*IPAddress, error) {
+	ipAddresses, closer := st.getCollection(ipAddressesC)
+	defer closer()
+
+	docs := []ipAddressDoc{}
+	if err := ipAddresses.Find(sel).All(&docs); err != nil {
+		return nil, fmt.Errorf("cannot get IP addresses: %v", err)
+	}
+	addrs := make([]*IPAddress, len(docs))
+	for i, doc := range docs {
+		addrs[i] = newIPAddress(st, &doc)
+	}
+	return addrs, nil
+}
+
+// AllocateAddress allocates the first free address of the subnet's
+// allocatable range to the given machine, which must be alive, and
+// optionally to one of its network interfaces. Each address is
+// recorded as a document with the address as id, so that concurrent
+// allocations never hand out the same address. If the whole range is
+// allocated, a NoAddressAvailableError is returned.
+func (s *Subnet) AllocateAddress(machineId, interfaceName string) (*IPAddress, error) {
+	if s.doc.AllocatableIPLow == "" {
+		return nil, &NoAddressAvailableError{s.doc.CIDR}
+	}
+	addr, err := s.allocateAddress(s.st.docID(machineId), interfaceName)
+	if err != nil && !IsNoAddressAvailableError(err) {
+		return nil, fmt.Errorf("cannot allocate IP address from subnet %q: %v", s.doc.CIDR, err)
+	}
+	return addr, err
+}
+
+func (s *Subnet) allocateAddress(machineId, interfaceName string) (*IPAddress, error) {
+	low := net.ParseIP(s.doc.AllocatableIPLow)
+	high := net.ParseIP(s.doc.AllocatableIPHigh)
+	if low == nil || high == nil {
+		return nil, fmt.Errorf("invalid allocatable range %s-%s", s.doc.AllocatableIPLow, s.doc.AllocatableIPHigh)
+	}
+	if low.To4() != nil {
+		low, high = low.To4(), high.To4()
+	}
+	var doc *ipAddressDoc
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if attempt > 0 {
+			// Find out whether the address was taken,
+			// or whether the allocation cannot succeed.
+			if _, err := s.st.Subnet(s.doc.CIDR); err != nil {
+				return nil, err
+			}
+			if alive, err := isAlive(s.st.db, machinesC, machineId); err != nil {
+				return nil, err
+			} else if !alive {
+				return nil, fmt.Errorf("machine %q is not found or not alive", s.st.localID(machineId))
+			}
+		}
+		taken, err := s.allocatedValues()
+		if err != nil {
+			return nil, err
+		}
+		value := firstFreeIP(low, high, taken)
+		if value == "" {
+			return nil, &NoAddressAvailableError{s.doc.CIDR}
+		}
+		doc = &ipAddressDoc{
+			Value:         value,
+			SubnetCIDR:    s.doc.CIDR,
+			MachineId:     machineId,
+			InterfaceName: interfaceName,
+		}
+		return []txn.Op{{
+			C:      subnetsC,
+			Id:     s.doc.CIDR,
+			Assert: txn.DocExists,
+		}, {
+			C:      machinesC,
+			Id:     machineId,
+			Assert: isAliveDoc,
+		}, {
+			C:      ipAddressesC,
+			Id:     doc.Value,
+			Assert: txn.DocMissing,
+			Insert: doc,
+		}}, nil
+	}
+	if err := s.st.runDiagnosed(buildTxn); err != nil {
+		return nil, err
+	}
+	return newIPAddress(s.st, doc), nil
+}
+
+// allocatedValues returns the set of addresses allocated from
+// the subnet, including the released ones not yet removed.
+func (s *Subnet) allocatedValues() (map[string]bool, error) {
+	ipAddresses, closer := s.st.getCollection(ipAddressesC)
+	defer closer()
+
+	taken := make(map[string]bool)
+	iter := ipAddresses.Find(bson.D{{"subnetcidr", s.doc.CIDR}}).Select(bson.D{{"_id", 1}}).Iter()
+	var doc ipAddressDoc
+	for iter.Next(&doc) {
+		taken[doc.Value] = true
+	}
+	return taken, iter.Close()
+}
+
+// firstFreeIP returns the first address between low and high,
+// inclusive, that is not taken, or "" if they are all taken.
+func firstFreeIP(low, high net.IP, taken map[string]bool) string {
+	for ip := low; bytes.Compare(ip, high) <= 0; ip = nextIP(ip) {
+		if !taken[ip.String()] {
+			return ip.String()
+		}
+		if ip.Equal(high) {
+			break
+		}
+	}
+	return ""
+}
+
+// nextIP returns the address following the given one.
+func nextIP(ip net.IP) net.IP {
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			break
+		}
+	}
+	return next
+}
+
+// removeIPAddressesOps returns the operations that remove all the
+// addresses matching the given selector, along with the records of
+// them being given to containers.
+func (st *State) removeIPAddressesOps(sel bson.D) ([]txn.Op, error) {
+	ipAddresses, closer := st.getCollection(ipAddressesC)
+	defer closer()
+
+	var ops []txn.Op
+	iter := ipAddresses.Find(sel).Select(bson.D{{"_id", 1}}).Iter()
+	var doc ipAddressDoc
+	for iter.Next(&doc) {
+		ops = append(ops, txn.Op{
+			C:      ipAddressesC,
+			Id:     doc.Value,
+			Remove: true,
+		}, removeContainerAddressOp(doc.Value))
+	}
+	return ops, iter.Close()
+}
+
+type NoAddressAvailableError struct {
+	SubnetCIDR string
+}
+
+func (e *NoAddressAvailableError) Error() string {
+	return fmt.Sprintf("no IP address available in subnet %q", e.SubnetCIDR)
+}
+
+func IsNoAddressAvailableError(err error) bool {
+	_, ok := err.(*NoAddressAvailableError)
+	return ok
+}
@@ -0,0 +1,150 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+)
+
+type IPAddressSuite struct {
+	ConnSuite
+	machine *state.Machine
+	subnet  *state.Subnet
+}
+
+var _ = gc.Suite(&IPAddressSuite{})
+
+func (s *IPAddressSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	var err error
+	s.machine, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	network, err := s.State.AddNetwork(state.NetworkInfo{"net1", "net1", "10.0.0.0/16", 0, ""})
+	c.Assert(err, gc.IsNil)
+	s.subnet, err = network.AddSubnet(state.SubnetInfo{
+		CIDR:              "10.0.1.0/24",
+		AllocatableIPLow:  "10.0.1.10",
+		AllocatableIPHigh: "10.0.1.12",
+	})
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *IPAddressSuite) TestAllocateAddress(c *gc.C) {
+	addr, err := s.subnet.AllocateAddress(s.machine.Id(), "eth0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(addr.Value(), gc.Equals, "10.0.1.10")
+	c.Assert(addr.SubnetCIDR(), gc.Equals, "10.0.1.0/24")
+	c.Assert(addr.MachineId(), gc.Equals, s.machine.Id())
+	c.Assert(addr.InterfaceName(), gc.Equals, "eth0")
+	c.Assert(addr.Life(), gc.Equals, state.Alive)
+
+	found, err := s.State.IPAddress("10.0.1.10")
+	c.Assert(err, gc.IsNil)
+	c.Assert(found, jc.DeepEquals, addr)
+}
+
+func (s *IPAddressSuite) TestNetworkInterfaceIPAddresses(c *gc.C) {
+	iface, err := s.machine.AddNetworkInterface(state.NetworkInterfaceInfo{
+		MACAddress:    "aa:bb:cc:dd:ee:ff",
+		InterfaceName: "eth0",
+		NetworkName:   "net1",
+	})
+	c.Assert(err, gc.IsNil)
+	addr, err := s.subnet.AllocateAddress(s.machine.Id(), "eth0")
+	c.Assert(err, gc.IsNil)
+	_, err = s.subnet.AllocateAddress(s.machine.Id(), "")
+	c.Assert(err, gc.IsNil)
+
+	addrs, err := iface.IPAddresses()
+	c.Assert(err, gc.IsNil)
+	c.Assert(addrs, jc.DeepEquals, []*state.IPAddress{addr})
+}
+
+func (s *IPAddressSuite) TestAllocateAddressExhausted(c *gc.C) {
+	var values []string
+	for i := 0; i < 3; i++ {
+		addr, err := s.subnet.AllocateAddress(s.machine.Id(), "")
+		c.Assert(err, gc.IsNil)
+		values = append(values, addr.Value())
+	}
+	c.Assert(values, gc.DeepEquals, []string{"10.0.1.10", "10.0.1.11", "10.0.1.12"})
+
+	_, err := s.subnet.AllocateAddress(s.machine.Id(), "")
+	c.Assert(err, jc.Satisfies, state.IsNoAddressAvailableError)
+	c.Assert(err, gc.ErrorMatches, `no IP address available in subnet "10.0.1.0/24"`)
+
+	addrs, err := s.subnet.IPAddresses()
+	c.Assert(err, gc.IsNil)
+	c.Assert(addrs, gc.HasLen, 3)
+	addrs, err = s.machine.IPAddresses()
+	c.Assert(err, gc.IsNil)
+	c.Assert(addrs, gc.HasLen, 3)
+}
+
+func (s *IPAddressSuite) TestAllocateAddressNoRange(c *gc.C) {
+	network, err := s.subnet.Network()
+	c.Assert(err, gc.IsNil)
+	subnet, err := network.AddSubnet(state.SubnetInfo{CIDR: "10.0.2.0/24"})
+	c.Assert(err, gc.IsNil)
+	_, err = subnet.AllocateAddress(s.machine.Id(), "")
+	c.Assert(err, jc.Satisfies, state.IsNoAddressAvailableError)
+}
+
+func (s *IPAddressSuite) TestAllocateAddressDeadMachine(c *gc.C) {
+	err := s.machine.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	_, err = s.subnet.AllocateAddress(s.machine.Id(), "")
+	c.Assert(err, gc.ErrorMatches, `cannot allocate IP address from subnet "10.0.1.0/24": machine "0" is not found or not alive`)
+}
+
+func (s *IPAddressSuite) TestReleaseAndRemove(c *gc.C) {
+	addr, err := s.subnet.AllocateAddress(s.machine.Id(), "")
+	c.Assert(err, gc.IsNil)
+	err = addr.Remove()
+	c.Assert(err, gc.ErrorMatches, `cannot remove IP address "10.0.1.10": IP address is not released`)
+
+	err = addr.Release()
+	c.Assert(err, gc.IsNil)
+	c.Assert(addr.Life(), gc.Equals, state.Dead)
+	err = addr.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(addr.Life(), gc.Equals, state.Dead)
+
+	// A released address is not allocated again until it is removed.
+	next, err := s.subnet.AllocateAddress(s.machine.Id(), "")
+	c.Assert(err, gc.IsNil)
+	c.Assert(next.Value(), gc.Equals, "10.0.1.11")
+
+	err = addr.Remove()
+	c.Assert(err, gc.IsNil)
+	err = addr.Refresh()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	next, err = s.subnet.AllocateAddress(s.machine.Id(), "")
+	c.Assert(err, gc.IsNil)
+	c.Assert(next.Value(), gc.Equals, "10.0.1.10")
+}
+
+func (s *IPAddressSuite) TestRemovedWithMachine(c *gc.C) {
+	_, err := s.subnet.AllocateAddress(s.machine.Id(), "")
+	c.Assert(err, gc.IsNil)
+	err = s.machine.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = s.machine.Remove()
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.IPAddress("10.0.1.10")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *IPAddressSuite) TestRemovedWithSubnet(c *gc.C) {
+	_, err := s.subnet.AllocateAddress(s.machine.Id(), "")
+	c.Assert(err, gc.IsNil)
+	err = s.subnet.Remove()
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.IPAddress("10.0.1.10")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
@@ -0,0 +1,245 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+)
+
+// validLabelKey matches the keys allowed for labels.
+var validLabelKey = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._/-]*$`)
+
+// labelsDoc holds the labels of an entity. Unlike annotations, which
+// are opaque to juju, labels are indexed so that the entities with a
+// given label can be found.
+type labelsDoc struct {
+	GlobalKey string `bson:"_id"`
+	Kind      string
+	EntityId  string
+	Labels    []labelDoc
+}
+
+// labelDoc holds a single label of an entity.
+type labelDoc struct {
+	Key   string
+	Value string
+}
+
+// labeller implements label-related methods for any entity that
+// wishes to use it.
+type labeller struct {
+	st        *State
+	globalKey string
+	tag       names.Tag
+
+	// collection and id identify the document of the entity.
+	collection string
+	id         string
+}
+
+// SetLabels adds, changes or, for empty values, removes the labels
+// with the given keys on the entity.
+func (l *labeller) SetLabels(labels map[string]string) (err error) {
+	defer errors.Maskf(&err, "cannot update labels on %s", l.tag)
+	if len(labels) == 0 {
+		return nil
+	}
+	for key := range labels {
+		if !validLabelKey.MatchString(key) {
+			return fmt.Errorf("invalid label key %q", key)
+		}
+	}
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		coll, closer := l.st.getCollection(labelsC)
+		defer closer()
+		var doc labelsDoc
+		err := coll.FindId(l.globalKey).One(&doc)
+		if err == mgo.ErrNotFound {
+			// Check that the entity was not destroyed meanwhile.
+			if attempt != 0 {
+				entities, closer := l.st.getCollection(l.collection)
+				defer closer()
+				if count, err := entities.FindId(l.id).Count(); err != nil {
+					return nil, err
+				} else if count == 0 {
+					return nil, fmt.Errorf("%s no longer exists", l.tag)
+				}
+			}
+			doc = labelsDoc{
+				GlobalKey: l.globalKey,
+				Kind:      l.tag.Kind(),
+				EntityId:  l.id,
+				Labels:    mergeLabels(nil, labels),
+			}
+			return []txn.Op{{
+				C:      l.collection,
+				Id:     l.id,
+				Assert: txn.DocExists,
+			}, {
+				C:      labelsC,
+				Id:     l.globalKey,
+				Assert: txn.DocMissing,
+				Insert: &doc,
+			}}, nil
+		} else if err != nil {
+			return nil, err
+		}
+		return []txn.Op{{
+			C:      labelsC,
+			Id:     l.globalKey,
+			Assert: bson.D{{"labels", doc.Labels}},
+			Update: bson.D{{"$set", bson.D{{"labels", mergeLabels(doc.Labels, labels)}}}},
+		}}, nil
+	}
+	return l.st.runDiagnosed(buildTxn)
+}
+
+// Labels returns all the labels of the entity.
+func (l *labeller) Labels() (map[string]string, error) {
+	coll, closer := l.st.getCollection(labelsC)
+	defer closer()
+	var doc labelsDoc
+	err := coll.FindId(l.globalKey).One(&doc)
+	if err != nil && err != mgo.ErrNotFound {
+		return nil, fmt.Errorf("cannot get labels of %s: %v", l.tag, err)
+	}
+	labels := make(map[string]string)
+	for _, label := range doc.Labels {
+		labels[label.Key] = label.Value
+	}
+	return labels, nil
+}
+
+// mergeLabels returns the existing labels updated with the given
+// changes, ordered by key.
+func mergeLabels(existing []labelDoc, changes map[string]string) []labelDoc {
+	merged := make(map[string]string)
+	for _, label := range existing {
+		merged[label.Key] = label.Value
+	}
+	for key, value := range changes {
+		if value == "" {
+			delete(merged, key)
+		} else {
+			merged[key] = value
+		}
+	}
+	keys := make([]string, 0, len(merged))
+	for key := range merged {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	labels := make([]labelDoc, len(keys))
+	for i, key := range keys {
+		labels[i] = labelDoc{Key: key, Value: merged[key]}
+	}
+	return labels
+}
+
+// labelsRemoveOp returns an operation to remove the labels document
+// of the entity with the given global key.
+func labelsRemoveOp(globalKey string) txn.Op {
+	return txn.Op{
+		C:      labelsC,
+		Id:     globalKey,
+		Remove: true,
+	}
+}
+
+// labelledEntityIds returns the ids of the entities of the given kind
+// that have a label with the given key and, unless it is empty, the
+// given value.
+func (st *State) labelledEntityIds(kind, key, value string) ([]string, error) {
+	coll, closer := st.getCollection(labelsC)
+	defer closer()
+	match := bson.D{{"key", key}}
+	if value != "" {
+		match = append(match, bson.DocElem{"value", value})
+	}
+	sel := bson.D{
+		{"kind", kind},
+		{"labels", bson.D{{"$elemMatch", match}}},
+	}
+	var docs []labelsDoc
+	if err := coll.Find(sel).Select(bson.D{{"entityid", 1}}).All(&docs); err != nil {
+		return nil, fmt.Errorf("cannot find entities labelled %q: %v", key, err)
+	}
+	ids := make([]string, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.EntityId
+	}
+	return ids, nil
+}
+
+// FindMachinesByLabel returns the machines that have a label with the
+// given key and, unless it is empty, the given value.
+func (st *State) FindMachinesByLabel(key, value string) ([]*Machine, error) {
+	ids, err := st.labelledEntityIds(names.MachineTagKind, key, value)
+	if err != nil {
+		return nil, err
+	}
+	machines, closer := st.getCollection(machinesC)
+	defer closer()
+	docs := machineDocSlice{}
+	sel := append(bson.D{{"_id", bson.D{{"$in", ids}}}}, st.environSelector()...)
+	if err := machines.Find(sel).All(&docs); err != nil {
+		return nil, fmt.Errorf("cannot get labelled machines: %v", err)
+	}
+	sort.Sort(docs)
+	result := make([]*Machine, len(docs))
+	for i, doc := range docs {
+		result[i] = newMachine(st, &doc)
+	}
+	return result, nil
+}
+
+// FindServicesByLabel returns the services that have a label with the
+// given key and, unless it is empty, the given value.
+func (st *State) FindServicesByLabel(key, value string) ([]*Service, error) {
+	ids, err := st.labelledEntityIds(names.ServiceTagKind, key, value)
+	if err != nil {
+		return nil, err
+	}
+	services, closer := st.getCollection(servicesC)
+	defer closer()
+	var docs []serviceDoc
+	sel := bson.D{{"_id", bson.D{{"$in", ids}}}}
+	if err := services.Find(sel).Sort("_id").All(&docs); err != nil {
+		return nil, fmt.Errorf("cannot get labelled services: %v", err)
+	}
+	result := make([]*Service, len(docs))
+	for i, doc := range docs {
+		result[i] = newService(st, &doc)
+	}
+	return result, nil
+}
+
+// FindNetworksByLabel returns the networks that have a label with the
+// given key and, unless it is empty, the given value.
+func (st *State) FindNetworksByLabel(key, value string) ([]*Network, error) {
+	ids, err := st.labelledEntityIds(names.NetworkTagKind, key, value)
+	if err != nil {
+		return nil, err
+	}
+	networks, closer := st.getCollection(networksC)
+	defer closer()
+	var docs []networkDoc
+	sel := bson.D{{"_id", bson.D{{"$in", ids}}}}
+	if err := networks.Find(sel).Sort("_id").All(&docs); err != nil {
+		return nil, fmt.Errorf("cannot get labelled networks: %v", err)
+	}
+	result := make([]*Network, len(docs))
+	for i, doc := range docs {
+		result[i] = newNetwork(st, &doc)
+	}
+	return result, nil
+}
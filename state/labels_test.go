@@ -0,0 +1,138 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+)
+
+type LabelsSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&LabelsSuite{})
+
+func (s *LabelsSuite) TestSetLabels(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	labels, err := machine.Labels()
+	c.Assert(err, gc.IsNil)
+	c.Assert(labels, gc.HasLen, 0)
+
+	err = machine.SetLabels(map[string]string{"rack": "r1", "tier": "web"})
+	c.Assert(err, gc.IsNil)
+	labels, err = machine.Labels()
+	c.Assert(err, gc.IsNil)
+	c.Assert(labels, jc.DeepEquals, map[string]string{"rack": "r1", "tier": "web"})
+
+	// Labels are changed, added and, with empty values, removed.
+	err = machine.SetLabels(map[string]string{"rack": "r2", "tier": "", "zone": "a"})
+	c.Assert(err, gc.IsNil)
+	labels, err = machine.Labels()
+	c.Assert(err, gc.IsNil)
+	c.Assert(labels, jc.DeepEquals, map[string]string{"rack": "r2", "zone": "a"})
+
+	// Labels are distinct from annotations.
+	annotations, err := machine.Annotations()
+	c.Assert(err, gc.IsNil)
+	c.Assert(annotations, gc.HasLen, 0)
+}
+
+func (s *LabelsSuite) TestSetLabelsInvalidKey(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	for _, key := range []string{"", "has space", "-leading", "a=b", "a$b"} {
+		err = machine.SetLabels(map[string]string{key: "value"})
+		c.Check(err, gc.ErrorMatches, `cannot update labels on machine-0: invalid label key ".*"`)
+	}
+}
+
+func (s *LabelsSuite) TestSetLabelsRemovedEntity(c *gc.C) {
+	network, err := s.State.AddNetwork(state.NetworkInfo{"net1", "net1", "0.1.2.0/24", 0, ""})
+	c.Assert(err, gc.IsNil)
+	err = network.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = network.Remove()
+	c.Assert(err, gc.IsNil)
+	err = network.SetLabels(map[string]string{"tier": "web"})
+	c.Assert(err, gc.ErrorMatches, "cannot update labels on network-net1: network-net1 no longer exists")
+}
+
+func (s *LabelsSuite) TestFindMachinesByLabel(c *gc.C) {
+	var machines []*state.Machine
+	for i := 0; i < 3; i++ {
+		machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+		c.Assert(err, gc.IsNil)
+		machines = append(machines, machine)
+	}
+	err := machines[0].SetLabels(map[string]string{"rack": "r1"})
+	c.Assert(err, gc.IsNil)
+	err = machines[2].SetLabels(map[string]string{"rack": "r2"})
+	c.Assert(err, gc.IsNil)
+
+	assertFound := func(key, value string, expected ...string) {
+		found, err := s.State.FindMachinesByLabel(key, value)
+		c.Assert(err, gc.IsNil)
+		ids := make([]string, len(found))
+		for i, machine := range found {
+			ids[i] = machine.Id()
+		}
+		c.Assert(ids, jc.DeepEquals, expected)
+	}
+	assertFound("rack", "r1", "0")
+	assertFound("rack", "r2", "2")
+	assertFound("rack", "", "0", "2")
+	assertFound("rack", "r3")
+	assertFound("zone", "")
+
+	// Removed machines are no longer found.
+	err = machines[2].EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = machines[2].Remove()
+	c.Assert(err, gc.IsNil)
+	assertFound("rack", "", "0")
+}
+
+func (s *LabelsSuite) TestFindServicesByLabel(c *gc.C) {
+	wordpress := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	mysql := s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	err := wordpress.SetLabels(map[string]string{"tier": "web"})
+	c.Assert(err, gc.IsNil)
+	err = mysql.SetLabels(map[string]string{"tier": "db"})
+	c.Assert(err, gc.IsNil)
+
+	// Labels of different kinds of entity are kept apart.
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = machine.SetLabels(map[string]string{"tier": "web"})
+	c.Assert(err, gc.IsNil)
+
+	found, err := s.State.FindServicesByLabel("tier", "web")
+	c.Assert(err, gc.IsNil)
+	c.Assert(found, gc.HasLen, 1)
+	c.Assert(found[0].Name(), gc.Equals, "wordpress")
+
+	found, err = s.State.FindServicesByLabel("tier", "")
+	c.Assert(err, gc.IsNil)
+	c.Assert(found, gc.HasLen, 2)
+	c.Assert(found[0].Name(), gc.Equals, "mysql")
+	c.Assert(found[1].Name(), gc.Equals, "wordpress")
+}
+
+func (s *LabelsSuite) TestFindNetworksByLabel(c *gc.C) {
+	net1, err := s.State.AddNetwork(state.NetworkInfo{"net1", "net1", "0.1.2.0/24", 0, ""})
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.AddNetwork(state.NetworkInfo{"net2", "net2", "0.1.3.0/24", 0, ""})
+	c.Assert(err, gc.IsNil)
+	err = net1.SetLabels(map[string]string{"public": "true"})
+	c.Assert(err, gc.IsNil)
+
+	found, err := s.State.FindNetworksByLabel("public", "true")
+	c.Assert(err, gc.IsNil)
+	c.Assert(found, gc.HasLen, 1)
+	c.Assert(found[0].Name(), gc.Equals, "net1")
+}
@@ -0,0 +1,66 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// leadershipLeaseName returns the name of the lease
+// held by the leader unit of the named service.
+func leadershipLeaseName(serviceName string) string {
+	return "leadership#" + serviceName
+}
+
+// Leader returns the name of the unit currently leading the service.
+// It returns an error satisfying errors.IsNotFound if no unit holds
+// the service's leadership, or if the leadership has expired.
+func (s *Service) Leader() (string, error) {
+	leader, err := s.st.LeaseHolder(leadershipLeaseName(s.doc.Name))
+	if errors.IsNotFound(err) {
+		return "", errors.NotFoundf("leader of service %q", s)
+	}
+	return s.st.localID(leader), err
+}
+
+// WatchLeadership returns a watcher that notifies whenever the
+// leadership of the service is claimed, extended or resigned.
+// Leadership that expires without being claimed again does not
+// trigger a notification.
+func (s *Service) WatchLeadership() NotifyWatcher {
+	return newEntityWatcher(s.st, leasesC, leadershipLeaseName(s.doc.Name))
+}
+
+// ClaimLeadership attempts to make the unit the leader of its service
+// for the given duration, and reports whether it is now the leader.
+// The claim succeeds if no unit leads the service, or if the unit
+// already leads it, in which case its leadership is extended. Only
+// alive units can claim leadership.
+func (u *Unit) ClaimLeadership(duration time.Duration) (bool, error) {
+	if u.doc.Life != Alive {
+		return false, fmt.Errorf("cannot claim leadership of service %q: unit %q is not alive", u.ServiceName(), u)
+	}
+	return u.st.ClaimLease(leadershipLeaseName(u.doc.Service), u.doc.Name, duration)
+}
+
+// ResignLeadership gives up the unit's leadership of its service, so
+// that another unit can claim it immediately. It does nothing if the
+// unit does not lead the service.
+func (u *Unit) ResignLeadership() error {
+	return u.st.ReleaseLease(leadershipLeaseName(u.doc.Service), u.doc.Name)
+}
+
+// IsLeader returns whether the unit currently leads its service.
+func (u *Unit) IsLeader() (bool, error) {
+	leader, err := u.st.LeaseHolder(leadershipLeaseName(u.doc.Service))
+	if errors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return leader == u.doc.Name, nil
+}
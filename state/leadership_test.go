@@ -0,0 +1,164 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+	statetesting "github.com/juju/juju/state/testing"
+)
+
+type LeadershipSuite struct {
+	ConnSuite
+	service *state.Service
+	unit0   *state.Unit
+	unit1   *state.Unit
+}
+
+var _ = gc.Suite(&LeadershipSuite{})
+
+func (s *LeadershipSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	var err error
+	s.service = s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	s.unit0, err = s.service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	s.unit1, err = s.service.AddUnit()
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *LeadershipSuite) assertLeader(c *gc.C, leader *state.Unit) {
+	name, err := s.service.Leader()
+	c.Assert(err, gc.IsNil)
+	c.Assert(name, gc.Equals, leader.Name())
+	for _, unit := range []*state.Unit{s.unit0, s.unit1} {
+		isLeader, err := unit.IsLeader()
+		c.Assert(err, gc.IsNil)
+		c.Assert(isLeader, gc.Equals, unit == leader)
+	}
+}
+
+func (s *LeadershipSuite) TestClaimLeadership(c *gc.C) {
+	_, err := s.service.Leader()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	c.Assert(err, gc.ErrorMatches, `leader of service "wordpress" not found`)
+	isLeader, err := s.unit0.IsLeader()
+	c.Assert(err, gc.IsNil)
+	c.Assert(isLeader, jc.IsFalse)
+
+	claimed, err := s.unit0.ClaimLeadership(time.Minute)
+	c.Assert(err, gc.IsNil)
+	c.Assert(claimed, jc.IsTrue)
+	s.assertLeader(c, s.unit0)
+
+	// Another unit cannot claim leadership while it is held.
+	claimed, err = s.unit1.ClaimLeadership(time.Minute)
+	c.Assert(err, gc.IsNil)
+	c.Assert(claimed, jc.IsFalse)
+	s.assertLeader(c, s.unit0)
+
+	// The leader can extend its leadership.
+	claimed, err = s.unit0.ClaimLeadership(time.Minute)
+	c.Assert(err, gc.IsNil)
+	c.Assert(claimed, jc.IsTrue)
+	s.assertLeader(c, s.unit0)
+}
+
+func (s *LeadershipSuite) TestLeadershipExpires(c *gc.C) {
+	claimed, err := s.unit0.ClaimLeadership(time.Millisecond)
+	c.Assert(err, gc.IsNil)
+	c.Assert(claimed, jc.IsTrue)
+	time.Sleep(10 * time.Millisecond)
+
+	isLeader, err := s.unit0.IsLeader()
+	c.Assert(err, gc.IsNil)
+	c.Assert(isLeader, jc.IsFalse)
+	claimed, err = s.unit1.ClaimLeadership(time.Minute)
+	c.Assert(err, gc.IsNil)
+	c.Assert(claimed, jc.IsTrue)
+	s.assertLeader(c, s.unit1)
+}
+
+func (s *LeadershipSuite) TestResignLeadership(c *gc.C) {
+	claimed, err := s.unit0.ClaimLeadership(time.Minute)
+	c.Assert(err, gc.IsNil)
+	c.Assert(claimed, jc.IsTrue)
+
+	// Resigning leadership the unit does not hold does nothing.
+	err = s.unit1.ResignLeadership()
+	c.Assert(err, gc.IsNil)
+	s.assertLeader(c, s.unit0)
+
+	err = s.unit0.ResignLeadership()
+	c.Assert(err, gc.IsNil)
+	_, err = s.service.Leader()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	claimed, err = s.unit1.ClaimLeadership(time.Minute)
+	c.Assert(err, gc.IsNil)
+	c.Assert(claimed, jc.IsTrue)
+	s.assertLeader(c, s.unit1)
+}
+
+func (s *LeadershipSuite) TestClaimLeadershipUnitNotAlive(c *gc.C) {
+	err := s.unit0.Destroy()
+	c.Assert(err, gc.IsNil)
+	claimed, err := s.unit0.ClaimLeadership(time.Minute)
+	c.Assert(err, gc.ErrorMatches, `cannot claim leadership of service "wordpress": unit "wordpress/0" is not alive`)
+	c.Assert(claimed, jc.IsFalse)
+}
+
+func (s *LeadershipSuite) TestLeadershipRemovedWithService(c *gc.C) {
+	claimed, err := s.unit0.ClaimLeadership(time.Minute)
+	c.Assert(err, gc.IsNil)
+	c.Assert(claimed, jc.IsTrue)
+	for _, unit := range []*state.Unit{s.unit0, s.unit1} {
+		err = unit.Destroy()
+		c.Assert(err, gc.IsNil)
+	}
+	err = s.service.Destroy()
+	c.Assert(err, gc.IsNil)
+
+	_, err = s.service.Leader()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *LeadershipSuite) TestWatchLeadership(c *gc.C) {
+	w := s.service.WatchLeadership()
+	defer statetesting.AssertStop(c, w)
+	wc := statetesting.NewNotifyWatcherC(c, s.State, w)
+	wc.AssertOneChange()
+
+	claimed, err := s.unit0.ClaimLeadership(time.Minute)
+	c.Assert(err, gc.IsNil)
+	c.Assert(claimed, jc.IsTrue)
+	wc.AssertOneChange()
+
+	// A failed claim changes nothing.
+	claimed, err = s.unit1.ClaimLeadership(time.Minute)
+	c.Assert(err, gc.IsNil)
+	c.Assert(claimed, jc.IsFalse)
+	wc.AssertNoChange()
+
+	err = s.unit0.ResignLeadership()
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+
+	// Leadership of other services is not reported.
+	other := s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	unit, err := other.AddUnit()
+	c.Assert(err, gc.IsNil)
+	claimed, err = unit.ClaimLeadership(time.Minute)
+	c.Assert(err, gc.IsNil)
+	c.Assert(claimed, jc.IsTrue)
+	wc.AssertNoChange()
+
+	statetesting.AssertStop(c, w)
+	wc.AssertClosed()
+}
@@ -0,0 +1,133 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+	jujutxn "github.com/juju/txn"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+)
+
+// leaseDoc records the holder of a named lease and when the
+// lease expires, in nanoseconds since the Unix epoch.
+type leaseDoc struct {
+	Name   string `bson:"_id"`
+	Holder string
+	Expiry int64
+}
+
+// ClaimLease attempts to claim the named lease on behalf of the given
+// holder for the given duration, and reports whether the holder now
+// holds the lease. The lease is granted if nobody holds it, if it has
+// expired, or if the holder already holds it, in which case it is
+// extended.
+//
+// Leases are used to ensure that a worker runs on exactly one state
+// server at a time. Expiry times are judged by the clocks of the
+// claiming machines, which are assumed to be roughly synchronised;
+// holders should renew their leases well before they expire.
+func (st *State) ClaimLease(name, holder string, duration time.Duration) (held bool, err error) {
+	defer errors.Maskf(&err, "cannot claim lease %q", name)
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		held = false
+		leases, closer := st.getCollection(leasesC)
+		defer closer()
+
+		now := time.Now()
+		newDoc := &leaseDoc{
+			Name:   name,
+			Holder: holder,
+			Expiry: now.Add(duration).UnixNano(),
+		}
+		var doc leaseDoc
+		err := leases.FindId(name).One(&doc)
+		if err == mgo.ErrNotFound {
+			held = true
+			return []txn.Op{{
+				C:      leasesC,
+				Id:     name,
+				Assert: txn.DocMissing,
+				Insert: newDoc,
+			}}, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if doc.Holder != holder && doc.Expiry > now.UnixNano() {
+			return nil, jujutxn.ErrNoOperations
+		}
+		held = true
+		return []txn.Op{{
+			C:  leasesC,
+			Id: name,
+			Assert: bson.D{
+				{"holder", doc.Holder},
+				{"expiry", doc.Expiry},
+			},
+			Update: bson.D{{"$set", bson.D{
+				{"holder", newDoc.Holder},
+				{"expiry", newDoc.Expiry},
+			}}},
+		}}, nil
+	}
+	if err := st.runDiagnosed(buildTxn); err != nil {
+		return false, err
+	}
+	return held, nil
+}
+
+// LeaseHolder returns the current holder of the named lease.
+// It returns an error satisfying errors.IsNotFound if the lease
+// has never been claimed or has expired.
+func (st *State) LeaseHolder(name string) (string, error) {
+	leases, closer := st.getCollection(leasesC)
+	defer closer()
+
+	var doc leaseDoc
+	if err := leases.FindId(name).One(&doc); err == mgo.ErrNotFound {
+		return "", errors.NotFoundf("lease %q", name)
+	} else if err != nil {
+		return "", errors.Annotatef(err, "cannot get lease %q", name)
+	}
+	if doc.Expiry <= time.Now().UnixNano() {
+		return "", errors.NotFoundf("lease %q", name)
+	}
+	return doc.Holder, nil
+}
+
+// ReleaseLease expires the named lease if it is held by the given
+// holder, so that it can be claimed by anybody else straight away.
+// It does nothing if the lease is not held by the holder.
+func (st *State) ReleaseLease(name, holder string) (err error) {
+	defer errors.Maskf(&err, "cannot release lease %q", name)
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		leases, closer := st.getCollection(leasesC)
+		defer closer()
+
+		now := time.Now().UnixNano()
+		var doc leaseDoc
+		err := leases.FindId(name).One(&doc)
+		if err == mgo.ErrNotFound {
+			return nil, jujutxn.ErrNoOperations
+		} else if err != nil {
+			return nil, err
+		}
+		if doc.Holder != holder || doc.Expiry <= now {
+			return nil, jujutxn.ErrNoOperations
+		}
+		return []txn.Op{{
+			C:  leasesC,
+			Id: name,
+			Assert: bson.D{
+				{"holder", doc.Holder},
+				{"expiry", doc.Expiry},
+			},
+			Update: bson.D{{"$set", bson.D{{"expiry", now}}}},
+		}}, nil
+	}
+	return st.runDiagnosed(buildTxn)
+}
@@ -0,0 +1,97 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+)
+
+type LeaseSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&LeaseSuite{})
+
+func (s *LeaseSuite) TestClaimLease(c *gc.C) {
+	_, err := s.State.LeaseHolder("cleaner")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	c.Assert(err, gc.ErrorMatches, `lease "cleaner" not found`)
+
+	held, err := s.State.ClaimLease("cleaner", "machine-0", time.Minute)
+	c.Assert(err, gc.IsNil)
+	c.Assert(held, jc.IsTrue)
+	holder, err := s.State.LeaseHolder("cleaner")
+	c.Assert(err, gc.IsNil)
+	c.Assert(holder, gc.Equals, "machine-0")
+
+	// Another holder cannot claim the lease while it is held.
+	held, err = s.State.ClaimLease("cleaner", "machine-1", time.Minute)
+	c.Assert(err, gc.IsNil)
+	c.Assert(held, jc.IsFalse)
+
+	// The holder can renew it.
+	held, err = s.State.ClaimLease("cleaner", "machine-0", time.Minute)
+	c.Assert(err, gc.IsNil)
+	c.Assert(held, jc.IsTrue)
+
+	// Leases are independent of one another.
+	held, err = s.State.ClaimLease("resumer", "machine-1", time.Minute)
+	c.Assert(err, gc.IsNil)
+	c.Assert(held, jc.IsTrue)
+	holder, err = s.State.LeaseHolder("cleaner")
+	c.Assert(err, gc.IsNil)
+	c.Assert(holder, gc.Equals, "machine-0")
+}
+
+func (s *LeaseSuite) TestClaimExpiredLease(c *gc.C) {
+	held, err := s.State.ClaimLease("cleaner", "machine-0", time.Millisecond)
+	c.Assert(err, gc.IsNil)
+	c.Assert(held, jc.IsTrue)
+	time.Sleep(10 * time.Millisecond)
+
+	_, err = s.State.LeaseHolder("cleaner")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	held, err = s.State.ClaimLease("cleaner", "machine-1", time.Minute)
+	c.Assert(err, gc.IsNil)
+	c.Assert(held, jc.IsTrue)
+	holder, err := s.State.LeaseHolder("cleaner")
+	c.Assert(err, gc.IsNil)
+	c.Assert(holder, gc.Equals, "machine-1")
+
+	// The previous holder has lost the lease.
+	held, err = s.State.ClaimLease("cleaner", "machine-0", time.Minute)
+	c.Assert(err, gc.IsNil)
+	c.Assert(held, jc.IsFalse)
+}
+
+func (s *LeaseSuite) TestReleaseLease(c *gc.C) {
+	// Releasing a lease that was never claimed does nothing.
+	err := s.State.ReleaseLease("cleaner", "machine-0")
+	c.Assert(err, gc.IsNil)
+
+	held, err := s.State.ClaimLease("cleaner", "machine-0", time.Minute)
+	c.Assert(err, gc.IsNil)
+	c.Assert(held, jc.IsTrue)
+
+	// Only the holder can release the lease.
+	err = s.State.ReleaseLease("cleaner", "machine-1")
+	c.Assert(err, gc.IsNil)
+	holder, err := s.State.LeaseHolder("cleaner")
+	c.Assert(err, gc.IsNil)
+	c.Assert(holder, gc.Equals, "machine-0")
+
+	err = s.State.ReleaseLease("cleaner", "machine-0")
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.LeaseHolder("cleaner")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	// Anybody can claim the released lease.
+	held, err = s.State.ClaimLease("cleaner", "machine-1", time.Minute)
+	c.Assert(err, gc.IsNil)
+	c.Assert(held, jc.IsTrue)
+}
@@ -32,6 +32,7 @@ type Machine struct {
 	st  *State
 	doc machineDoc
 	annotator
+	labeller
 	presence.Presencer
 }
 
@@ -96,6 +97,7 @@ func (job MachineJob) String() string {
 // Note the correspondence with MachineInfo in state/api/params.
 type machineDoc struct {
 	Id            string `bson:"_id"`
+	EnvUUID       string `bson:"env-uuid,omitempty"`
 	Nonce         string
 	Series        string
 	ContainerType string
@@ -107,6 +109,14 @@ type machineDoc struct {
 	HasVote       bool
 	PasswordHash  string
 	Clean         bool
+	// Maintenance is set while the machine is being serviced.
+	// Units are not assigned to a machine in maintenance, and
+	// no instance is started for it.
+	Maintenance bool `bson:",omitempty"`
+	// Service holds the name of the service whose unit the machine
+	// was created for, if it was created by assigning a unit to a
+	// new machine.
+	Service string `bson:",omitempty"`
 	// We store 2 different sets of addresses for the machine, obtained
 	// from different sources.
 	// Addresses is the set of addresses obtained by asking the provider.
@@ -120,6 +130,9 @@ type machineDoc struct {
 	// Placement is the placement directive that should be used when provisioning
 	// an instance for the machine.
 	Placement string `bson:",omitempty"`
+	// DateCreated holds when the machine was added. It is zero
+	// for machines added before it was recorded.
+	DateCreated time.Time
 	// Deprecated. InstanceId, now lives on instanceData.
 	// This attribute is retained so that data from existing machines can be read.
 	// SCHEMACHANGE
@@ -137,12 +150,19 @@ func newMachine(st *State, doc *machineDoc) *Machine {
 		tag:       machine.Tag(),
 		st:        st,
 	}
+	machine.labeller = labeller{
+		st:         st,
+		globalKey:  machine.globalKey(),
+		tag:        machine.Tag(),
+		collection: machinesC,
+		id:         doc.Id,
+	}
 	return machine
 }
 
 // Id returns the machine id.
 func (m *Machine) Id() string {
-	return m.doc.Id
+	return m.st.localID(m.doc.Id)
 }
 
 // Series returns the operating system series running on the machine.
@@ -150,6 +170,12 @@ func (m *Machine) Series() string {
 	return m.doc.Series
 }
 
+// DateCreated returns when the machine was added, in UTC. It returns
+// the zero time for machines added before this was recorded.
+func (m *Machine) DateCreated() time.Time {
+	return m.doc.DateCreated.UTC()
+}
+
 // ContainerType returns the type of container hosting this machine.
 func (m *Machine) ContainerType() instance.ContainerType {
 	return instance.ContainerType(m.doc.ContainerType)
@@ -176,6 +202,7 @@ type instanceData struct {
 	CpuCores   *uint64     `bson:"cpucores,omitempty"`
 	CpuPower   *uint64     `bson:"cpupower,omitempty"`
 	Tags       *[]string   `bson:"tags,omitempty"`
+	Networks   []string    `bson:"networks,omitempty"`
 }
 
 func hardwareCharacteristics(instData instanceData) *instance.HardwareCharacteristics {
@@ -191,7 +218,7 @@ func hardwareCharacteristics(instData instanceData) *instance.HardwareCharacteri
 
 // TODO(wallyworld): move this method to a service.
 func (m *Machine) HardwareCharacteristics() (*instance.HardwareCharacteristics, error) {
-	instData, err := getInstanceData(m.st, m.Id())
+	instData, err := getInstanceData(m.st, m.doc.Id)
 	if err != nil {
 		return nil, err
 	}
@@ -205,10 +232,10 @@ func getInstanceData(st *State, id string) (instanceData, error) {
 	var instData instanceData
 	err := instanceDataCollection.FindId(id).One(&instData)
 	if err == mgo.ErrNotFound {
-		return instanceData{}, errors.NotFoundf("instance data for machine %v", id)
+		return instanceData{}, errors.NotFoundf("instance data for machine %v", st.localID(id))
 	}
 	if err != nil {
-		return instanceData{}, fmt.Errorf("cannot get instance data for machine %v: %v", id, err)
+		return instanceData{}, fmt.Errorf("cannot get instance data for machine %v: %v", st.localID(id), err)
 	}
 	return instData, nil
 }
@@ -260,6 +287,44 @@ func (m *Machine) SetHasVote(hasVote bool) error {
 	return nil
 }
 
+// InMaintenance returns whether the machine is in maintenance mode.
+func (m *Machine) InMaintenance() bool {
+	return m.doc.Maintenance
+}
+
+// SetMaintenance puts the machine in maintenance mode, so that no
+// units are assigned to it and no instance is started for it until
+// ClearMaintenance is called. Units already on the machine are left
+// alone.
+func (m *Machine) SetMaintenance() error {
+	if err := m.setMaintenance(true); err != nil {
+		return fmt.Errorf("cannot set maintenance mode of machine %v: %v", m, err)
+	}
+	return nil
+}
+
+// ClearMaintenance takes the machine out of maintenance mode.
+func (m *Machine) ClearMaintenance() error {
+	if err := m.setMaintenance(false); err != nil {
+		return fmt.Errorf("cannot clear maintenance mode of machine %v: %v", m, err)
+	}
+	return nil
+}
+
+func (m *Machine) setMaintenance(maintenance bool) error {
+	ops := []txn.Op{{
+		C:      machinesC,
+		Id:     m.doc.Id,
+		Assert: notDeadDoc,
+		Update: bson.D{{"$set", bson.D{{"maintenance", maintenance}}}},
+	}}
+	if err := m.st.runTransaction(ops); err != nil {
+		return onAbort(err, errDead)
+	}
+	m.doc.Maintenance = maintenance
+	return nil
+}
+
 // IsManager returns true if the machine has JobManageEnviron.
 func (m *Machine) IsManager() bool {
 	return hasJob(m.doc.Jobs, JobManageEnviron)
@@ -410,7 +475,7 @@ func (m *Machine) ForceDestroy() error {
 			return err
 		}
 	}
-	return fmt.Errorf("machine %s is required by the environment", m.doc.Id)
+	return fmt.Errorf("machine %s is required by the environment", m)
 }
 
 // EnsureDead sets the machine lifecycle to Dead if it is Alive or Dying.
@@ -443,9 +508,9 @@ func (m *Machine) Containers() ([]string, error) {
 	defer closer()
 
 	var mc machineContainers
-	err := containerRefs.FindId(m.Id()).One(&mc)
+	err := containerRefs.FindId(m.doc.Id).One(&mc)
 	if err == nil {
-		return mc.Children, nil
+		return m.st.localIDs(mc.Children), nil
 	}
 	if err == mgo.ErrNotFound {
 		return nil, errors.NotFoundf("container info for machine %v", m.Id())
@@ -485,7 +550,7 @@ func (original *Machine) advanceLifecycle(life Life) (err error) {
 	}
 	if len(containers) > 0 {
 		return &HasContainersError{
-			MachineId:    original.doc.Id,
+			MachineId:    original.Id(),
 			ContainerIds: containers,
 		}
 	}
@@ -555,15 +620,15 @@ func (original *Machine) advanceLifecycle(life Life) (err error) {
 			// (NOTE: When we enable multiple JobManageEnviron machines,
 			// this restriction will be lifted, but we will assert that the
 			// machine is not voting)
-			return nil, fmt.Errorf("machine %s is required by the environment", m.doc.Id)
+			return nil, fmt.Errorf("machine %s is required by the environment", m)
 		}
 		if m.doc.HasVote {
-			return nil, fmt.Errorf("machine %s is a voting replica set member", m.doc.Id)
+			return nil, fmt.Errorf("machine %s is a voting replica set member", m)
 		}
 		if len(m.doc.Principals) != 0 {
 			return nil, &HasAssignedUnitsError{
-				MachineId: m.doc.Id,
-				UnitNames: m.doc.Principals,
+				MachineId: m.Id(),
+				UnitNames: m.st.localIDs(m.doc.Principals),
 			}
 		}
 		return []txn.Op{op}, nil
@@ -602,22 +667,31 @@ func (m *Machine) removeNetworkInterfacesOps() ([]txn.Op, error) {
 	networkInterfaces, closer := m.st.getCollection(networkInterfacesC)
 	defer closer()
 
-	iter := networkInterfaces.Find(sel).Select(bson.D{{"_id", 1}}).Iter()
+	iter := networkInterfaces.Find(sel).Select(bson.D{{"_id", 1}, {"networkname", 1}}).Iter()
 	var doc networkInterfaceDoc
+	var networkNames []string
 	for iter.Next(&doc) {
 		ops = append(ops, txn.Op{
 			C:      networkInterfacesC,
 			Id:     doc.Id,
 			Remove: true,
 		})
+		networkNames = append(networkNames, doc.NetworkName)
 	}
-	return ops, iter.Close()
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	cleanupOps, err := m.st.dyingNetworkCleanupOps(networkNames...)
+	if err != nil {
+		return nil, err
+	}
+	return append(ops, cleanupOps...), nil
 }
 
 // Remove removes the machine from state. It will fail if the machine
 // is not Dead.
 func (m *Machine) Remove() (err error) {
-	defer errors.Maskf(&err, "cannot remove machine %s", m.doc.Id)
+	defer errors.Maskf(&err, "cannot remove machine %s", m)
 	if m.doc.Life != Dead {
 		return fmt.Errorf("machine is not dead")
 	}
@@ -642,6 +716,7 @@ func (m *Machine) Remove() (err error) {
 		removeConstraintsOp(m.st, m.globalKey()),
 		removeRequestedNetworksOp(m.st, m.globalKey()),
 		annotationRemoveOp(m.st, m.globalKey()),
+		labelsRemoveOp(m.globalKey()),
 	}
 	ifacesOps, err := m.removeNetworkInterfacesOps()
 	if err != nil {
@@ -651,12 +726,34 @@ func (m *Machine) Remove() (err error) {
 	if err != nil {
 		return err
 	}
+	addressesOps, err := m.st.removeIPAddressesOps(bson.D{{"machineid", m.doc.Id}})
+	if err != nil {
+		return err
+	}
+	blockDevicesOps, err := m.removeBlockDevicesOps()
+	if err != nil {
+		return err
+	}
 	ops = append(ops, ifacesOps...)
 	ops = append(ops, portsOps...)
-	ops = append(ops, removeContainerRefOps(m.st, m.Id())...)
+	ops = append(ops, addressesOps...)
+	ops = append(ops, blockDevicesOps...)
+	ops = append(ops, removeContainerRefOps(m.st, m.doc.Id)...)
 	// The only abort conditions in play indicate that the machine has already
 	// been removed.
-	return onAbort(m.st.runTransaction(ops), nil)
+	if err := onAbort(m.st.runTransaction(ops), nil); err != nil {
+		return err
+	}
+	// Utilization samples, agent version reports and status history
+	// are not written transactionally, so they are removed only once
+	// the machine itself is gone.
+	if err := m.removeUtilization(); err != nil {
+		return err
+	}
+	if err := m.st.removeAgentVersion(m.globalKey()); err != nil {
+		return err
+	}
+	return removeStatusHistory(m.st, m.Tag())
 }
 
 // Refresh refreshes the contents of the machine from the underlying
@@ -736,7 +833,7 @@ func (m *Machine) InstanceId() (instance.Id, error) {
 	if m.doc.InstanceId != "" {
 		return m.doc.InstanceId, nil
 	}
-	instData, err := getInstanceData(m.st, m.Id())
+	instData, err := getInstanceData(m.st, m.doc.Id)
 	if (err == nil && instData.InstanceId == "") || errors.IsNotFound(err) {
 		err = NotProvisionedError(m.Id())
 	}
@@ -757,7 +854,7 @@ func (m *Machine) InstanceStatus() (string, error) {
 	if err != nil {
 		return "", err
 	}
-	instData, err := getInstanceData(m.st, m.Id())
+	instData, err := getInstanceData(m.st, m.doc.Id)
 	if (err == nil && instId == "") || errors.IsNotFound(err) {
 		err = NotProvisionedError(m.Id())
 	}
@@ -824,7 +921,16 @@ func (m *Machine) Units() (units []*Unit, err error) {
 // that if the provisioner crashes (or its connection to the state is
 // lost) after starting the instance, we can be sure that only a single
 // instance will be able to act for that machine.
-func (m *Machine) SetProvisioned(id instance.Id, nonce string, characteristics *instance.HardwareCharacteristics) (err error) {
+func (m *Machine) SetProvisioned(id instance.Id, nonce string, characteristics *instance.HardwareCharacteristics) error {
+	return m.setProvisioned(id, nonce, characteristics, nil)
+}
+
+// setProvisioned sets the provider specific machine id, nonce, hardware
+// characteristics and instance networks of the machine in a single
+// transaction.
+func (m *Machine) setProvisioned(
+	id instance.Id, nonce string, characteristics *instance.HardwareCharacteristics, networks []string,
+) (err error) {
 	defer errors.Maskf(&err, "cannot set instance data for machine %q", m)
 
 	if id == "" || nonce == "" {
@@ -843,6 +949,7 @@ func (m *Machine) SetProvisioned(id instance.Id, nonce string, characteristics *
 		CpuCores:   characteristics.CpuCores,
 		CpuPower:   characteristics.CpuPower,
 		Tags:       characteristics.Tags,
+		Networks:   networks,
 	}
 	// SCHEMACHANGE
 	// TODO(wallyworld) - do not check instanceId on machineDoc after schema is upgraded
@@ -880,13 +987,14 @@ func (m *Machine) SetProvisioned(id instance.Id, nonce string, characteristics *
 
 // SetInstanceInfo is used to provision a machine and in one steps set
 // it's instance id, nonce, hardware characteristics, add networks and
-// network interfaces as needed.
+// network interfaces as needed. The instance networks are recorded in
+// the same transaction as the instance id, so that a provisioned
+// machine always has them.
 //
-// TODO(dimitern) Do all the operations described in a single
-// transaction, rather than using separate calls. Alternatively,
+// TODO(dimitern) Add the networks and network interfaces in the same
+// transaction too, rather than using separate calls. Alternatively,
 // we can add all the things to create/set in a document in some
 // collection and have a worker that takes care of the actual work.
-// Merge SetProvisioned() in here or drop it at that point.
 func (m *Machine) SetInstanceInfo(
 	id instance.Id, nonce string, characteristics *instance.HardwareCharacteristics,
 	networks []NetworkInfo, interfaces []NetworkInterfaceInfo) error {
@@ -910,7 +1018,11 @@ func (m *Machine) SetInstanceInfo(
 			return err
 		}
 	}
-	return m.SetProvisioned(id, nonce, characteristics)
+	var networkNames []string
+	for _, network := range networks {
+		networkNames = append(networkNames, network.Name)
+	}
+	return m.setProvisioned(id, nonce, characteristics, networkNames)
 }
 
 // notProvisionedError records an error when a machine is not provisioned.
@@ -1040,6 +1152,92 @@ func (m *Machine) RequestedNetworks() ([]string, error) {
 	return readRequestedNetworks(m.st, m.globalKey())
 }
 
+// ExcludedNetworks returns the list of network names the machine
+// must not be on.
+func (m *Machine) ExcludedNetworks() ([]string, error) {
+	doc, _, err := readRequestedNetworksDoc(m.st, m.globalKey())
+	return doc.Exclude, err
+}
+
+// SetRequestedNetworks sets the networks the machine must be on and
+// the networks it must not be on. It fails if the machine is not alive
+// or has already been provisioned.
+func (m *Machine) SetRequestedNetworks(include, exclude []string) (err error) {
+	defer errors.Maskf(&err, "cannot set requested networks for machine %q", m)
+	if err := validateRequestedNetworks(include, exclude); err != nil {
+		return err
+	}
+	notSetYet := bson.D{{"nonce", ""}}
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if attempt > 0 {
+			if m, err = m.st.Machine(m.doc.Id); err != nil {
+				return nil, err
+			}
+		}
+		if m.doc.Life != Alive {
+			return nil, errNotAlive
+		}
+		if _, err := m.InstanceId(); err == nil {
+			return nil, fmt.Errorf("machine is already provisioned")
+		} else if !IsNotProvisionedError(err) {
+			return nil, err
+		}
+		_, exists, err := readRequestedNetworksDoc(m.st, m.globalKey())
+		if err != nil {
+			return nil, err
+		}
+		return []txn.Op{{
+			C:      machinesC,
+			Id:     m.doc.Id,
+			Assert: append(isAliveDoc, notSetYet...),
+		}, setRequestedNetworksOp(m.st, m.globalKey(), exists, include, exclude)}, nil
+	}
+	return m.st.runDiagnosed(buildTxn)
+}
+
+// InstanceNetworks returns the names of the networks the provider
+// attached to the machine's instance, as recorded by
+// SetInstanceNetworks.
+func (m *Machine) InstanceNetworks() ([]string, error) {
+	instData, err := getInstanceData(m.st, m.doc.Id)
+	if err != nil {
+		return nil, err
+	}
+	return instData.Networks, nil
+}
+
+// SetInstanceNetworks records the names of the networks the provider
+// attached to the machine's instance, replacing any previously
+// recorded. The machine must have been provisioned.
+func (m *Machine) SetInstanceNetworks(networks []string) (err error) {
+	defer errors.Maskf(&err, "cannot set instance networks for machine %q", m)
+	for _, name := range networks {
+		if !names.IsValidNetwork(name) {
+			return fmt.Errorf("invalid network name %q", name)
+		}
+	}
+	ops := []txn.Op{{
+		C:      machinesC,
+		Id:     m.doc.Id,
+		Assert: notDeadDoc,
+	}, {
+		C:      instanceDataC,
+		Id:     m.doc.Id,
+		Assert: txn.DocExists,
+		Update: bson.D{{"$set", bson.D{{"networks", networks}}}},
+	}}
+	if err = m.st.runTransaction(ops); err != txn.ErrAborted {
+		return err
+	}
+	if err := m.Refresh(); err != nil {
+		return err
+	}
+	if m.doc.Life == Dead {
+		return errDead
+	}
+	return NotProvisionedError(m.Id())
+}
+
 // Networks returns the list of configured networks on the machine.
 // The configured and requested networks on a machine must match.
 func (m *Machine) Networks() ([]*Network, error) {
@@ -1052,7 +1250,11 @@ func (m *Machine) Networks() ([]*Network, error) {
 	networksCollection, closer := m.st.getCollection(networksC)
 	defer closer()
 
-	sel := bson.D{{"_id", bson.D{{"$in", requestedNetworks}}}}
+	networkIds := make([]string, len(requestedNetworks))
+	for i, name := range requestedNetworks {
+		networkIds[i] = m.st.docID(name)
+	}
+	sel := bson.D{{"_id", bson.D{{"$in", networkIds}}}}
 	err = networksCollection.Find(sel).All(&docs)
 	if err != nil {
 		return nil, err
@@ -1082,14 +1284,33 @@ func (m *Machine) NetworkInterfaces() ([]*NetworkInterface, error) {
 	return ifaces, nil
 }
 
+// PrimaryNetworkInterface returns the primary network interface of
+// the machine. It returns an error satisfying errors.IsNotFound if no
+// interface has been made primary.
+func (m *Machine) PrimaryNetworkInterface() (*NetworkInterface, error) {
+	networkInterfaces, closer := m.st.getCollection(networkInterfacesC)
+	defer closer()
+
+	doc := networkInterfaceDoc{}
+	sel := bson.D{{"machineid", m.doc.Id}, {"isprimary", true}}
+	err := networkInterfaces.Find(sel).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("primary network interface of machine %q", m)
+	} else if err != nil {
+		return nil, fmt.Errorf("cannot get primary network interface of machine %q: %v", m, err)
+	}
+	return newNetworkInterface(m.st, &doc), nil
+}
+
 // AddNetworkInterface creates a new network interface with the given
 // args for this machine. The machine must be alive and not yet
 // provisioned, and there must be no other interface with the same MAC
 // address on the same network, or the same name on that machine for
-// this to succeed. If a network interface already exists, the
+// this to succeed. A bond or bridge may share its MAC address with
+// one of its members. If a network interface already exists, the
 // returned error satisfies errors.IsAlreadyExists.
 func (m *Machine) AddNetworkInterface(args NetworkInterfaceInfo) (iface *NetworkInterface, err error) {
-	defer errors.Contextf(&err, "cannot add network interface %q to machine %q", args.InterfaceName, m.doc.Id)
+	defer errors.Contextf(&err, "cannot add network interface %q to machine %q", args.InterfaceName, m)
 
 	if args.MACAddress == "" {
 		return nil, fmt.Errorf("MAC address must be not empty")
@@ -1100,13 +1321,17 @@ func (m *Machine) AddNetworkInterface(args NetworkInterfaceInfo) (iface *Network
 	if args.InterfaceName == "" {
 		return nil, fmt.Errorf("interface name must be not empty")
 	}
+	if err = args.validate(); err != nil {
+		return nil, err
+	}
 	doc := newNetworkInterfaceDoc(args)
+	doc.NetworkName = m.st.docID(args.NetworkName)
 	doc.MachineId = m.doc.Id
 	doc.Id = bson.NewObjectId()
 	ops := []txn.Op{{
 		C:      networksC,
-		Id:     args.NetworkName,
-		Assert: txn.DocExists,
+		Id:     doc.NetworkName,
+		Assert: networkAliveDoc,
 	}, {
 		C:      machinesC,
 		Id:     m.doc.Id,
@@ -1121,8 +1346,10 @@ func (m *Machine) AddNetworkInterface(args NetworkInterfaceInfo) (iface *Network
 	err = m.st.runTransaction(ops)
 	switch err {
 	case txn.ErrAborted:
-		if _, err = m.st.Network(args.NetworkName); err != nil {
+		if network, err := m.st.Network(args.NetworkName); err != nil {
 			return nil, err
+		} else if network.Life() != Alive {
+			return nil, fmt.Errorf("network %q is not alive", args.NetworkName)
 		}
 		if err = m.Refresh(); err != nil {
 			return nil, err
@@ -1134,7 +1361,7 @@ func (m *Machine) AddNetworkInterface(args NetworkInterfaceInfo) (iface *Network
 	case nil:
 		// We have a unique key restrictions on the following fields:
 		// - InterfaceName, MachineId
-		// - MACAddress, NetworkName
+		// - MACAddress, NetworkName, ParentInterfaceName
 		// These will cause the insert to fail if there is another record
 		// with the same combination of values in the table.
 		// The txn logic does not report insertion errors, so we check
@@ -1148,9 +1375,17 @@ func (m *Machine) AddNetworkInterface(args NetworkInterfaceInfo) (iface *Network
 		}
 		sel := bson.D{{"interfacename", args.InterfaceName}, {"machineid", m.doc.Id}}
 		if err = networkInterfaces.Find(sel).One(nil); err == nil {
-			return nil, errors.AlreadyExistsf("%q on machine %q", args.InterfaceName, m.doc.Id)
+			return nil, errors.AlreadyExistsf("%q on machine %q", args.InterfaceName, m)
+		}
+		var parent interface{}
+		if args.ParentInterfaceName != "" {
+			parent = args.ParentInterfaceName
+		}
+		sel = bson.D{
+			{"macaddress", args.MACAddress},
+			{"networkname", doc.NetworkName},
+			{"parentinterfacename", parent},
 		}
-		sel = bson.D{{"macaddress", args.MACAddress}, {"networkname", args.NetworkName}}
 		if err = networkInterfaces.Find(sel).One(nil); err == nil {
 			return nil, errors.AlreadyExistsf("MAC address %q on network %q", args.MACAddress, args.NetworkName)
 		}
@@ -1167,7 +1402,7 @@ func (m *Machine) CheckProvisioned(nonce string) bool {
 
 // String returns a unique description of this machine.
 func (m *Machine) String() string {
-	return m.doc.Id
+	return m.Id()
 }
 
 // Placement returns the machine's Placement structure that should be used when
@@ -1224,7 +1459,7 @@ func (m *Machine) SetConstraints(cons constraints.Value) (err error) {
 		}
 		return ops, nil
 	}
-	return m.st.run(buildTxn)
+	return m.st.runDiagnosed(buildTxn)
 }
 
 // Status returns the status of the machine.
@@ -1263,6 +1498,7 @@ func (m *Machine) SetStatus(status params.Status, info string, data params.Statu
 	if err := m.st.runTransaction(ops); err != nil {
 		return fmt.Errorf("cannot set status of machine %q: %v", m, onAbort(err, errNotAlive))
 	}
+	recordStatusHistory(m.st, m.Tag(), doc)
 	return nil
 }
 
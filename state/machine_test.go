@@ -6,6 +6,7 @@ package state_test
 import (
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/juju/errors"
 	"github.com/juju/loggo"
@@ -55,6 +56,19 @@ func (s *MachineSuite) TestContainerDefaults(c *gc.C) {
 	c.Assert(containers, gc.DeepEquals, []string(nil))
 }
 
+func (s *MachineSuite) TestDateCreated(c *gc.C) {
+	now := time.Now().UTC()
+	created := s.machine.DateCreated()
+	c.Assert(created.Location(), gc.Equals, time.UTC)
+	c.Assert(created.After(now.Add(-time.Minute)), jc.IsTrue)
+	c.Assert(created.After(now.Add(time.Second)), jc.IsFalse)
+
+	// The time is stored with the machine.
+	m, err := s.State.Machine(s.machine.Id())
+	c.Assert(err, gc.IsNil)
+	c.Assert(m.DateCreated(), gc.Equals, created)
+}
+
 func (s *MachineSuite) TestMachineJobFromParams(c *gc.C) {
 	for stateMachineJob, paramsMachineJob := range state.JobNames {
 		job, err := state.MachineJobFromParams(paramsMachineJob)
@@ -194,7 +208,7 @@ func (s *MachineSuite) TestDestroyRemovePorts(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	err = unit.OpenPort("tcp", 8080)
 	c.Assert(err, gc.IsNil)
-	ports, err := state.GetPorts(s.State, s.machine.Id())
+	ports, err := state.GetPorts(s.State, s.machine.Id(), network.DefaultPublic)
 	c.Assert(ports, gc.NotNil)
 	c.Assert(err, gc.IsNil)
 	err = unit.UnassignFromMachine()
@@ -206,7 +220,7 @@ func (s *MachineSuite) TestDestroyRemovePorts(c *gc.C) {
 	err = s.machine.Remove()
 	c.Assert(err, gc.IsNil)
 	// once the machine is destroyed, there should be no ports documents present for it
-	ports, err = state.GetPorts(s.State, s.machine.Id())
+	ports, err = state.GetPorts(s.State, s.machine.Id(), network.DefaultPublic)
 	c.Assert(ports, gc.IsNil)
 	c.Assert(err, jc.Satisfies, errors.IsNotFound)
 }
@@ -524,37 +538,57 @@ var addNetworkInterfaceErrorsTests = []struct {
 	beforeAdding func(*gc.C, *state.Machine)
 	expectErr    string
 }{{
-	state.NetworkInterfaceInfo{"", "eth1", "net1", false, false},
+	state.NetworkInterfaceInfo{"", "eth1", "net1", false, false, "", "", ""},
 	nil,
 	`cannot add network interface "eth1" to machine "2": MAC address must be not empty`,
 }, {
-	state.NetworkInterfaceInfo{"invalid", "eth1", "net1", false, false},
+	state.NetworkInterfaceInfo{"invalid", "eth1", "net1", false, false, "", "", ""},
 	nil,
 	`cannot add network interface "eth1" to machine "2": invalid MAC address: invalid`,
 }, {
-	state.NetworkInterfaceInfo{"aa:bb:cc:dd:ee:f0", "eth1", "net1", false, false},
+	state.NetworkInterfaceInfo{"aa:bb:cc:dd:ee:f0", "eth1", "net1", false, false, "", "", ""},
 	nil,
 	`cannot add network interface "eth1" to machine "2": MAC address "aa:bb:cc:dd:ee:f0" on network "net1" already exists`,
 }, {
-	state.NetworkInterfaceInfo{"aa:bb:cc:dd:ee:ff", "", "net1", false, false},
+	state.NetworkInterfaceInfo{"aa:bb:cc:dd:ee:ff", "", "net1", false, false, "", "", ""},
 	nil,
 	`cannot add network interface "" to machine "2": interface name must be not empty`,
 }, {
-	state.NetworkInterfaceInfo{"aa:bb:cc:dd:ee:ff", "eth0", "net1", false, false},
+	state.NetworkInterfaceInfo{"aa:bb:cc:dd:ee:ff", "eth0", "net1", false, false, "", "", ""},
 	nil,
 	`cannot add network interface "eth0" to machine "2": "eth0" on machine "2" already exists`,
 }, {
-	state.NetworkInterfaceInfo{"aa:bb:cc:dd:ee:ff", "eth1", "missing", false, false},
+	state.NetworkInterfaceInfo{"aa:bb:cc:dd:ee:ff", "eth1", "missing", false, false, "", "", ""},
 	nil,
 	`cannot add network interface "eth1" to machine "2": network "missing" not found`,
 }, {
-	state.NetworkInterfaceInfo{"aa:bb:cc:dd:ee:f1", "eth1", "net1", false, false},
+	state.NetworkInterfaceInfo{"aa:bb:cc:dd:ee:ff", "eth1", "net1", false, false, "wifi", "", ""},
+	nil,
+	`cannot add network interface "eth1" to machine "2": invalid interface type "wifi"`,
+}, {
+	state.NetworkInterfaceInfo{"aa:bb:cc:dd:ee:ff", "bond0", "net1", true, false, network.BondInterface, "", "fast"},
+	nil,
+	`cannot add network interface "bond0" to machine "2": invalid bond mode "fast"`,
+}, {
+	state.NetworkInterfaceInfo{"aa:bb:cc:dd:ee:ff", "br0", "net1", true, false, network.BridgeInterface, "", "802.3ad"},
+	nil,
+	`cannot add network interface "br0" to machine "2": bond mode set on bridge interface`,
+}, {
+	state.NetworkInterfaceInfo{"aa:bb:cc:dd:ee:ff", "eth1", "net1", false, false, "", "", "802.3ad"},
+	nil,
+	`cannot add network interface "eth1" to machine "2": bond mode set on ethernet interface`,
+}, {
+	state.NetworkInterfaceInfo{"aa:bb:cc:dd:ee:ff", "bond0", "net1", true, false, network.BondInterface, "bond0", "802.3ad"},
+	nil,
+	`cannot add network interface "bond0" to machine "2": interface cannot be a member of itself`,
+}, {
+	state.NetworkInterfaceInfo{"aa:bb:cc:dd:ee:f1", "eth1", "net1", false, false, "", "", ""},
 	func(c *gc.C, m *state.Machine) {
 		c.Check(m.EnsureDead(), gc.IsNil)
 	},
 	`cannot add network interface "eth1" to machine "2": machine is not alive`,
 }, {
-	state.NetworkInterfaceInfo{"aa:bb:cc:dd:ee:f1", "eth1", "net1", false, false},
+	state.NetworkInterfaceInfo{"aa:bb:cc:dd:ee:f1", "eth1", "net1", false, false, "", "", ""},
 	func(c *gc.C, m *state.Machine) {
 		c.Check(m.Remove(), gc.IsNil)
 	},
@@ -746,6 +780,67 @@ func (s *MachineSuite) TestMachineSetInstanceInfoSuccess(c *gc.C) {
 	c.Check(ifaces[0].MACAddress(), gc.Equals, interfaces[0].MACAddress)
 	c.Check(ifaces[0].MachineTag(), gc.Equals, s.machine.Tag().String())
 	c.Check(ifaces[0].IsVirtual(), gc.Equals, interfaces[0].IsVirtual)
+	instanceNetworks, err := s.machine.InstanceNetworks()
+	c.Assert(err, gc.IsNil)
+	c.Check(instanceNetworks, gc.DeepEquals, []string{"net1"})
+}
+
+func (s *MachineSuite) TestMachineSetInstanceInfoRecordsNetworksWithInstance(c *gc.C) {
+	networks := []state.NetworkInfo{
+		{Name: "net1", ProviderId: "net1", CIDR: "0.1.2.0/24", VLANTag: 0},
+	}
+	defer state.SetBeforeHooks(c, s.State, func() {
+		err := s.machine.EnsureDead()
+		c.Assert(err, gc.IsNil)
+	}).Check()
+
+	// The network is added, but as the machine cannot be
+	// provisioned, no instance networks are recorded.
+	err := s.machine.SetInstanceInfo("umbrella/0", "fake_nonce", nil, networks, nil)
+	c.Assert(err, gc.ErrorMatches, `cannot set instance data for machine "1": not found or not alive`)
+	_, err = s.State.Network("net1")
+	c.Assert(err, gc.IsNil)
+	err = s.machine.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.machine.CheckProvisioned("fake_nonce"), gc.Equals, false)
+	_, err = s.machine.InstanceNetworks()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *MachineSuite) TestSetInstanceNetworks(c *gc.C) {
+	err := s.machine.SetInstanceNetworks([]string{"net1"})
+	c.Assert(err, gc.ErrorMatches, `cannot set instance networks for machine "1": machine 1 is not provisioned`)
+
+	err = s.machine.SetProvisioned("umbrella/0", "fake_nonce", nil)
+	c.Assert(err, gc.IsNil)
+	networks, err := s.machine.InstanceNetworks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(networks, gc.HasLen, 0)
+
+	err = s.machine.SetInstanceNetworks([]string{"net1", "vlan42"})
+	c.Assert(err, gc.IsNil)
+	networks, err = s.machine.InstanceNetworks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(networks, gc.DeepEquals, []string{"net1", "vlan42"})
+
+	// The recorded networks are replaced, not merged.
+	err = s.machine.SetInstanceNetworks([]string{"net2"})
+	c.Assert(err, gc.IsNil)
+	networks, err = s.machine.InstanceNetworks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(networks, gc.DeepEquals, []string{"net2"})
+
+	err = s.machine.SetInstanceNetworks([]string{"bad net"})
+	c.Assert(err, gc.ErrorMatches, `cannot set instance networks for machine "1": invalid network name "bad net"`)
+}
+
+func (s *MachineSuite) TestSetInstanceNetworksWhenDead(c *gc.C) {
+	err := s.machine.SetProvisioned("umbrella/0", "fake_nonce", nil)
+	c.Assert(err, gc.IsNil)
+	err = s.machine.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = s.machine.SetInstanceNetworks([]string{"net1"})
+	c.Assert(err, gc.ErrorMatches, `cannot set instance networks for machine "1": not found or dead`)
 }
 
 func (s *MachineSuite) TestMachineSetProvisionedWhenNotAlive(c *gc.C) {
@@ -1279,6 +1374,89 @@ func (s *MachineSuite) TestSetConstraints(c *gc.C) {
 	c.Assert(mcons, gc.DeepEquals, cons1)
 }
 
+func (s *MachineSuite) TestSetMaintenance(c *gc.C) {
+	c.Assert(s.machine.InMaintenance(), jc.IsFalse)
+	err := s.machine.SetMaintenance()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.machine.InMaintenance(), jc.IsTrue)
+	machine, err := s.State.Machine(s.machine.Id())
+	c.Assert(err, gc.IsNil)
+	c.Assert(machine.InMaintenance(), jc.IsTrue)
+
+	err = s.machine.ClearMaintenance()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.machine.InMaintenance(), jc.IsFalse)
+	err = machine.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(machine.InMaintenance(), jc.IsFalse)
+}
+
+func (s *MachineSuite) TestSetMaintenanceWhenDead(c *gc.C) {
+	err := s.machine.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = s.machine.SetMaintenance()
+	c.Assert(err, gc.ErrorMatches, "cannot set maintenance mode of machine 1: not found or dead")
+	err = s.machine.ClearMaintenance()
+	c.Assert(err, gc.ErrorMatches, "cannot clear maintenance mode of machine 1: not found or dead")
+}
+
+func (s *MachineSuite) TestSetRequestedNetworks(c *gc.C) {
+	machine, err := s.State.AddOneMachine(state.MachineTemplate{
+		Series:            "quantal",
+		Jobs:              []state.MachineJob{state.JobHostUnits},
+		RequestedNetworks: []string{"net1"},
+		ExcludedNetworks:  []string{"net2"},
+	})
+	c.Assert(err, gc.IsNil)
+	include, err := machine.RequestedNetworks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(include, gc.DeepEquals, []string{"net1"})
+	exclude, err := machine.ExcludedNetworks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(exclude, gc.DeepEquals, []string{"net2"})
+
+	// Requested networks can be changed...
+	err = machine.SetRequestedNetworks([]string{"net3", "net4"}, nil)
+	c.Assert(err, gc.IsNil)
+	include, err = machine.RequestedNetworks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(include, gc.DeepEquals, []string{"net3", "net4"})
+	exclude, err = machine.ExcludedNetworks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(exclude, gc.HasLen, 0)
+
+	// ...until the machine is provisioned.
+	err = machine.SetProvisioned("i-mstuck", "fake_nonce", nil)
+	c.Assert(err, gc.IsNil)
+	err = machine.SetRequestedNetworks([]string{"net1"}, nil)
+	c.Assert(err, gc.ErrorMatches, `cannot set requested networks for machine "2": machine is already provisioned`)
+	include, err = machine.RequestedNetworks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(include, gc.DeepEquals, []string{"net3", "net4"})
+}
+
+func (s *MachineSuite) TestSetRequestedNetworksInvalid(c *gc.C) {
+	err := s.machine.SetRequestedNetworks([]string{"net1"}, []string{"net2", "net1"})
+	c.Assert(err, gc.ErrorMatches, `cannot set requested networks for machine "1": network "net1" is both included and excluded`)
+	err = s.machine.SetRequestedNetworks(nil, []string{"bad net"})
+	c.Assert(err, gc.ErrorMatches, `cannot set requested networks for machine "1": invalid network name "bad net"`)
+
+	_, err = s.State.AddOneMachine(state.MachineTemplate{
+		Series:            "quantal",
+		Jobs:              []state.MachineJob{state.JobHostUnits},
+		RequestedNetworks: []string{"net1"},
+		ExcludedNetworks:  []string{"net1"},
+	})
+	c.Assert(err, gc.ErrorMatches, `cannot add a new machine: network "net1" is both included and excluded`)
+}
+
+func (s *MachineSuite) TestSetRequestedNetworksWhenNotAlive(c *gc.C) {
+	err := s.machine.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = s.machine.SetRequestedNetworks([]string{"net1"}, nil)
+	c.Assert(err, gc.ErrorMatches, `cannot set requested networks for machine "1": not found or not alive`)
+}
+
 func (s *MachineSuite) TestSetAmbiguousConstraints(c *gc.C) {
 	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
 	c.Assert(err, gc.IsNil)
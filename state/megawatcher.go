@@ -9,7 +9,9 @@ import (
 	"strings"
 
 	"github.com/juju/errors"
+	"github.com/juju/utils"
 	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
 
 	"github.com/juju/juju/state/api/params"
 	"github.com/juju/juju/state/multiwatcher"
@@ -28,7 +30,7 @@ type backingMachine machineDoc
 
 func (m *backingMachine) updated(st *State, store *multiwatcher.Store, id interface{}) error {
 	info := &params.MachineInfo{
-		Id:                       m.Id,
+		Id:                       st.localID(m.Id),
 		Life:                     params.Life(m.Life.String()),
 		Series:                   m.Series,
 		Jobs:                     paramsJobsFromJobs(m.Jobs),
@@ -74,7 +76,7 @@ func (m *backingMachine) updated(st *State, store *multiwatcher.Store, id interf
 func (svc *backingMachine) removed(st *State, store *multiwatcher.Store, id interface{}) error {
 	store.Remove(params.EntityId{
 		Kind: "machine",
-		Id:   id,
+		Id:   st.localID(id.(string)),
 	})
 	return nil
 }
@@ -87,10 +89,10 @@ type backingUnit unitDoc
 
 func (u *backingUnit) updated(st *State, store *multiwatcher.Store, id interface{}) error {
 	info := &params.UnitInfo{
-		Name:      u.Name,
-		Service:   u.Service,
+		Name:      st.localID(u.Name),
+		Service:   st.localID(u.Service),
 		Series:    u.Series,
-		MachineId: u.MachineId,
+		MachineId: st.localID(u.MachineId),
 		Ports:     u.Ports,
 	}
 	if u.CharmURL != nil {
@@ -138,7 +140,7 @@ func getUnitAddresses(st *State, unitName string) (publicAddress, privateAddress
 func (svc *backingUnit) removed(st *State, store *multiwatcher.Store, id interface{}) error {
 	store.Remove(params.EntityId{
 		Kind: "unit",
-		Id:   id,
+		Id:   st.localID(id.(string)),
 	})
 	return nil
 }
@@ -152,7 +154,7 @@ type backingService serviceDoc
 func (svc *backingService) updated(st *State, store *multiwatcher.Store, id interface{}) error {
 
 	info := &params.ServiceInfo{
-		Name:     svc.Name,
+		Name:     st.localID(svc.Name),
 		Exposed:  svc.Exposed,
 		CharmURL: svc.CharmURL.String(),
 		OwnerTag: svc.fixOwnerTag(),
@@ -198,7 +200,7 @@ func (svc *backingService) updated(st *State, store *multiwatcher.Store, id inte
 func (svc *backingService) removed(st *State, store *multiwatcher.Store, id interface{}) error {
 	store.Remove(params.EntityId{
 		Kind: "service",
-		Id:   id,
+		Id:   st.localID(id.(string)),
 	})
 	return nil
 }
@@ -227,7 +229,7 @@ func (r *backingRelation) updated(st *State, store *multiwatcher.Store, id inter
 		}
 	}
 	info := &params.RelationInfo{
-		Key:       r.Key,
+		Key:       st.localID(r.Key),
 		Id:        r.Id,
 		Endpoints: eps,
 	}
@@ -238,7 +240,7 @@ func (r *backingRelation) updated(st *State, store *multiwatcher.Store, id inter
 func (svc *backingRelation) removed(st *State, store *multiwatcher.Store, id interface{}) error {
 	store.Remove(params.EntityId{
 		Kind: "relation",
-		Id:   id,
+		Id:   st.localID(id.(string)),
 	})
 	return nil
 }
@@ -250,6 +252,9 @@ func (m *backingRelation) mongoId() interface{} {
 type backingAnnotation annotatorDoc
 
 func (a *backingAnnotation) updated(st *State, store *multiwatcher.Store, id interface{}) error {
+	if _, ok := localGlobalKey(st, id.(string)); !ok {
+		return nil
+	}
 	info := &params.AnnotationInfo{
 		Tag:         a.Tag,
 		Annotations: a.Annotations,
@@ -259,7 +264,11 @@ func (a *backingAnnotation) updated(st *State, store *multiwatcher.Store, id int
 }
 
 func (svc *backingAnnotation) removed(st *State, store *multiwatcher.Store, id interface{}) error {
-	tag, ok := tagForGlobalKey(id.(string))
+	key, ok := localGlobalKey(st, id.(string))
+	if !ok {
+		return nil
+	}
+	tag, ok := tagForGlobalKey(key)
 	if !ok {
 		panic(fmt.Errorf("unknown global key %q in state", id))
 	}
@@ -277,7 +286,11 @@ func (a *backingAnnotation) mongoId() interface{} {
 type backingStatus statusDoc
 
 func (s *backingStatus) updated(st *State, store *multiwatcher.Store, id interface{}) error {
-	parentId, ok := backingEntityIdForGlobalKey(id.(string))
+	key, ok := localGlobalKey(st, id.(string))
+	if !ok {
+		return nil
+	}
+	parentId, ok := backingEntityIdForGlobalKey(key)
 	if !ok {
 		return nil
 	}
@@ -318,7 +331,11 @@ func (a *backingStatus) mongoId() interface{} {
 type backingConstraints constraintsDoc
 
 func (s *backingConstraints) updated(st *State, store *multiwatcher.Store, id interface{}) error {
-	parentId, ok := backingEntityIdForGlobalKey(id.(string))
+	key, ok := localGlobalKey(st, id.(string))
+	if !ok {
+		return nil
+	}
+	parentId, ok := backingEntityIdForGlobalKey(key)
 	if !ok {
 		return nil
 	}
@@ -352,7 +369,11 @@ func (a *backingConstraints) mongoId() interface{} {
 type backingSettings map[string]interface{}
 
 func (s *backingSettings) updated(st *State, store *multiwatcher.Store, id interface{}) error {
-	parentId, url, ok := backingEntityIdForSettingsKey(id.(string))
+	key, ok := localGlobalKey(st, id.(string))
+	if !ok {
+		return nil
+	}
+	parentId, url, ok := backingEntityIdForSettingsKey(key)
 	if !ok {
 		return nil
 	}
@@ -408,6 +429,29 @@ func backingEntityIdForSettingsKey(key string) (eid params.EntityId, extra strin
 	return
 }
 
+// localGlobalKey returns the global key, or settings key, with the
+// document id it holds replaced by the local id of the entity. It
+// returns false if the key belongs to an entity of another
+// environment than the watched one.
+func localGlobalKey(st *State, key string) (string, bool) {
+	if len(key) < 3 || key[1] != '#' {
+		return key, true
+	}
+	id := key[2:]
+	if i := strings.Index(id, ":"); i != -1 && utils.IsValidUUIDString(id[:i]) {
+		if id[:i] != st.environTag.Id() {
+			return "", false
+		}
+		return key[:2] + id[i+1:], true
+	}
+	if st.environTag != st.serverTag && strings.ContainsRune("mus", rune(key[0])) {
+		// Machines, units and services of the state
+		// server environment are not prefixed.
+		return "", false
+	}
+	return key, true
+}
+
 // backingEntityIdForGlobalKey returns the entity id for the given global key.
 // It returns false if the key is not recognized.
 func backingEntityIdForGlobalKey(key string) (params.EntityId, bool) {
@@ -467,6 +511,10 @@ type allWatcherStateCollection struct {
 	// subsidiary is true if the collection is used only
 	// to modify a primary entity.
 	subsidiary bool
+	// envScoped is true if the documents in the collection record
+	// the environment they belong to, so that only those of the
+	// watched environment are reported.
+	envScoped bool
 }
 
 func newAllWatcherStateBacking(st *State) multiwatcher.Backing {
@@ -479,15 +527,19 @@ func newAllWatcherStateBacking(st *State) multiwatcher.Backing {
 	collections := []allWatcherStateCollection{{
 		Collection: st.db.C(machinesC),
 		infoType:   reflect.TypeOf(backingMachine{}),
+		envScoped:  true,
 	}, {
 		Collection: st.db.C(unitsC),
 		infoType:   reflect.TypeOf(backingUnit{}),
+		envScoped:  true,
 	}, {
 		Collection: st.db.C(servicesC),
 		infoType:   reflect.TypeOf(backingService{}),
+		envScoped:  true,
 	}, {
 		Collection: st.db.C(relationsC),
 		infoType:   reflect.TypeOf(backingRelation{}),
+		envScoped:  true,
 	}, {
 		Collection: st.db.C(annotationsC),
 		infoType:   reflect.TypeOf(backingAnnotation{}),
@@ -545,7 +597,7 @@ func (b *allWatcherStateBacking) GetAll(all *multiwatcher.Store) error {
 		}
 		col := db.C(c.Name)
 		infoSlicePtr := reflect.New(reflect.SliceOf(c.infoType))
-		if err := col.Find(nil).All(infoSlicePtr.Interface()); err != nil {
+		if err := col.Find(b.selector(c, nil)).All(infoSlicePtr.Interface()); err != nil {
 			return fmt.Errorf("cannot get all %s: %v", c.Name, err)
 		}
 		infos := infoSlicePtr.Elem()
@@ -557,6 +609,17 @@ func (b *allWatcherStateBacking) GetAll(all *multiwatcher.Store) error {
 	return nil
 }
 
+// selector returns sel restricted, for collections holding documents
+// of several environments, to the documents of the watched environment.
+// Changes to documents of other environments are then seen as removals
+// of entities the watcher never reported, which are ignored.
+func (b *allWatcherStateBacking) selector(c allWatcherStateCollection, sel bson.D) bson.D {
+	if !c.envScoped {
+		return sel
+	}
+	return append(sel, b.st.environSelector()...)
+}
+
 // Changed updates the allWatcher's idea of the current state
 // in response to the given change.
 func (b *allWatcherStateBacking) Changed(all *multiwatcher.Store, change watcher.Change) error {
@@ -573,7 +636,7 @@ func (b *allWatcherStateBacking) Changed(all *multiwatcher.Store, change watcher
 	// than simply fetching each entity in turn.
 	// TODO(rog) avoid fetching documents that we have no interest
 	// in, such as settings changes to entities we don't care about.
-	err := col.FindId(change.Id).One(doc)
+	err := col.Find(b.selector(c, bson.D{{"_id", change.Id}})).One(doc)
 	if err == mgo.ErrNotFound {
 		return doc.removed(b.st, all, change.Id)
 	}
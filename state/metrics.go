@@ -0,0 +1,92 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"time"
+)
+
+// The names of the metrics reported by state.
+const (
+	// MetricTxnAttempts counts the transactions attempted,
+	// including the retries of aborted ones.
+	MetricTxnAttempts = "state.txn.attempts"
+
+	// MetricTxnRetries counts the transactions built again
+	// and retried after being aborted.
+	MetricTxnRetries = "state.txn.retries"
+
+	// MetricTxnAborts counts the transactions aborted
+	// because of a failed assertion.
+	MetricTxnAborts = "state.txn.aborts"
+
+	// MetricTxnDuration times the running of transactions,
+	// retries included.
+	MetricTxnDuration = "state.txn.duration"
+
+	// MetricQueryDurationPrefix, followed by the name of a
+	// collection, times the queries of the collection made
+	// when getting the environment, machines, services, units,
+	// relations and charms.
+	MetricQueryDurationPrefix = "state.query.duration."
+
+	// MetricWatcherQueueDepth reports the number of changes
+	// queued by the transaction log watcher for delivery to
+	// the watchers of state.
+	MetricWatcherQueueDepth = "state.watcher.queue-depth"
+)
+
+// MetricsSink receives the metrics reported by state, so that the
+// performance of a state server can be diagnosed. Implementations
+// must be safe for concurrent use, and must not block.
+type MetricsSink interface {
+	// IncCounter adds delta to the named counter.
+	IncCounter(name string, delta int64)
+
+	// ObserveDuration records a duration measured by the named timer.
+	ObserveDuration(name string, d time.Duration)
+
+	// SetGauge sets the named gauge to the given value.
+	SetGauge(name string, value int64)
+}
+
+// discardMetrics is a MetricsSink that ignores all metrics.
+type discardMetrics struct{}
+
+func (discardMetrics) IncCounter(string, int64)              {}
+func (discardMetrics) ObserveDuration(string, time.Duration) {}
+func (discardMetrics) SetGauge(string, int64)                {}
+
+// SetMetricsSink sets the sink the metrics of the state handle are
+// reported to, and returns the previous one. Handles opened with
+// ForEnviron or ForUser start with the sink of the handle they are
+// opened from. Metrics are discarded if the sink is nil, as they are
+// by default.
+func (st *State) SetMetricsSink(sink MetricsSink) MetricsSink {
+	if sink == nil {
+		sink = discardMetrics{}
+	}
+	st.metricsMu.Lock()
+	defer st.metricsMu.Unlock()
+	previous := st.metricsSink
+	st.metricsSink = sink
+	return previous
+}
+
+// metrics returns the current metrics sink of the state handle.
+func (st *State) metrics() MetricsSink {
+	st.metricsMu.RLock()
+	defer st.metricsMu.RUnlock()
+	return st.metricsSink
+}
+
+// timeQuery calls query, reporting the time it took as the
+// duration of a query of the named collection, and returns
+// its error.
+func (st *State) timeQuery(collection string, query func() error) error {
+	start := time.Now()
+	err := query()
+	st.metrics().ObserveDuration(MetricQueryDurationPrefix+collection, time.Since(start))
+	return err
+}
@@ -0,0 +1,179 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+	statetesting "github.com/juju/juju/state/testing"
+	"github.com/juju/juju/testing/factory"
+)
+
+type MetricsSuite struct {
+	ConnSuite
+	sink *recordingSink
+}
+
+var _ = gc.Suite(&MetricsSuite{})
+
+func (s *MetricsSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	s.sink = newRecordingSink()
+	s.State.SetMetricsSink(s.sink)
+}
+
+func (s *MetricsSuite) TestTransactionMetrics(c *gc.C) {
+	_, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	counters, durations, _ := s.sink.snapshot()
+	c.Assert(counters[state.MetricTxnAttempts] > 0, jc.IsTrue)
+	c.Assert(counters[state.MetricTxnRetries], gc.Equals, int64(0))
+	c.Assert(counters[state.MetricTxnAborts], gc.Equals, int64(0))
+	c.Assert(durations[state.MetricTxnDuration] > 0, jc.IsTrue)
+}
+
+func (s *MetricsSuite) TestQueryMetrics(c *gc.C) {
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	s.sink.reset()
+
+	_, err = s.State.Machine(m.Id())
+	c.Assert(err, gc.IsNil)
+	_, durations, _ := s.sink.snapshot()
+	c.Assert(durations, gc.HasLen, 1)
+	c.Assert(durations[state.MetricQueryDurationPrefix+"machines"] > 0, jc.IsTrue)
+}
+
+func (s *MetricsSuite) TestUserStateReportsToSink(c *gc.C) {
+	st, err := s.State.ForUser(names.NewUserTag("admin"))
+	c.Assert(err, gc.IsNil)
+	defer st.Close()
+	s.sink.reset()
+
+	_, err = st.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	counters, _, _ := s.sink.snapshot()
+	c.Assert(counters[state.MetricTxnAttempts] > 0, jc.IsTrue)
+
+	// Other state handles report to their own sinks.
+	other := newRecordingSink()
+	previous := st.SetMetricsSink(other)
+	c.Assert(previous, gc.Equals, state.MetricsSink(s.sink))
+	s.sink.reset()
+	_, err = st.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	counters, _, _ = s.sink.snapshot()
+	c.Assert(counters, gc.HasLen, 0)
+	counters, _, _ = other.snapshot()
+	c.Assert(counters[state.MetricTxnAttempts] > 0, jc.IsTrue)
+}
+
+func (s *MetricsSuite) TestRetryMetrics(c *gc.C) {
+	user := s.factory.MakeUser(factory.UserParams{Username: "bob"})
+	defer state.SetBeforeHooks(c, s.State, func() {
+		err := user.RevokeEnvironmentAccess()
+		c.Assert(err, gc.IsNil)
+	}).Check()
+	s.sink.reset()
+
+	err := user.SetEnvironmentAccess(state.EnvironmentReadAccess)
+	c.Assert(err, gc.IsNil)
+	counters, _, _ := s.sink.snapshot()
+	c.Assert(counters[state.MetricTxnRetries], gc.Equals, int64(1))
+	c.Assert(counters[state.MetricTxnAborts], gc.Equals, int64(1))
+	// Two attempts to set the access, and the revocation.
+	c.Assert(counters[state.MetricTxnAttempts], gc.Equals, int64(3))
+}
+
+func (s *MetricsSuite) TestWatcherQueueDepth(c *gc.C) {
+	w := s.State.WatchEnvironMachines()
+	defer statetesting.AssertStop(c, w)
+	wc := statetesting.NewStringsWatcherC(c, s.State, w)
+	wc.AssertChange()
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	// The depth is reported before the change is delivered.
+	wc.AssertChange(m.Id())
+	_, _, gauges := s.sink.snapshot()
+	_, ok := gauges[state.MetricWatcherQueueDepth]
+	c.Assert(ok, jc.IsTrue)
+}
+
+func (s *MetricsSuite) TestSetMetricsSinkNil(c *gc.C) {
+	previous := s.State.SetMetricsSink(nil)
+	c.Assert(previous, gc.Equals, state.MetricsSink(s.sink))
+	_, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	counters, durations, _ := s.sink.snapshot()
+	c.Assert(counters, gc.HasLen, 0)
+	for name := range durations {
+		c.Assert(strings.HasPrefix(name, "state.txn"), jc.IsFalse)
+	}
+}
+
+// recordingSink is a state.MetricsSink recording
+// the totals of counters and timers, and gauge values.
+type recordingSink struct {
+	mu        sync.Mutex
+	counters  map[string]int64
+	durations map[string]time.Duration
+	gauges    map[string]int64
+}
+
+func newRecordingSink() *recordingSink {
+	sink := &recordingSink{}
+	sink.reset()
+	return sink
+}
+
+func (s *recordingSink) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counters = make(map[string]int64)
+	s.durations = make(map[string]time.Duration)
+	s.gauges = make(map[string]int64)
+}
+
+func (s *recordingSink) snapshot() (map[string]int64, map[string]time.Duration, map[string]int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counters := make(map[string]int64)
+	for name, value := range s.counters {
+		counters[name] = value
+	}
+	durations := make(map[string]time.Duration)
+	for name, value := range s.durations {
+		durations[name] = value
+	}
+	gauges := make(map[string]int64)
+	for name, value := range s.gauges {
+		gauges[name] = value
+	}
+	return counters, durations, gauges
+}
+
+func (s *recordingSink) IncCounter(name string, delta int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counters[name] += delta
+}
+
+func (s *recordingSink) ObserveDuration(name string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.durations[name] += d
+}
+
+func (s *recordingSink) SetGauge(name string, value int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gauges[name] = value
+}
@@ -0,0 +1,1038 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/juju/charm"
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	"github.com/juju/utils/set"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+
+	"github.com/juju/juju/constraints"
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/state/description"
+)
+
+// Export returns a description of the environment of the state and
+// of the machines, networks, services, units and relations in it,
+// including the password hashes of the agents, the ports opened by
+// the units and the settings of the units in their relations.
+// Entities that are no longer alive are left out.
+func (st *State) Export() (_ *description.Environment, err error) {
+	defer errors.Maskf(&err, "cannot export environment")
+
+	env, err := st.Environment()
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := st.EnvironConfig()
+	if err != nil {
+		return nil, err
+	}
+	cons, err := st.EnvironConstraints()
+	if err != nil {
+		return nil, err
+	}
+	result := &description.Environment{
+		Version:     description.Version,
+		UUID:        env.UUID(),
+		Name:        env.Name(),
+		Config:      cfg.AllAttrs(),
+		Constraints: cons.String(),
+	}
+	if result.Annotations, err = exportAnnotations(env); err != nil {
+		return nil, err
+	}
+	if result.Networks, err = st.exportNetworks(); err != nil {
+		return nil, err
+	}
+	if result.Machines, err = st.exportMachines(); err != nil {
+		return nil, err
+	}
+	if result.Services, err = st.exportServices(); err != nil {
+		return nil, err
+	}
+	if result.Relations, err = st.exportRelations(); err != nil {
+		return nil, err
+	}
+	charms := make(map[string]bool)
+	for _, svc := range result.Services {
+		charms[svc.CharmURL] = true
+		for _, unit := range svc.Units {
+			if unit.CharmURL != "" {
+				charms[unit.CharmURL] = true
+			}
+		}
+	}
+	for curl := range charms {
+		result.Charms = append(result.Charms, curl)
+	}
+	sort.Strings(result.Charms)
+	return result, nil
+}
+
+// exportAnnotations returns the annotations of the
+// entity, or nil if it has none.
+func exportAnnotations(entity Annotator) (map[string]string, error) {
+	annotations, err := entity.Annotations()
+	if err != nil || len(annotations) == 0 {
+		return nil, err
+	}
+	return annotations, nil
+}
+
+func (st *State) exportNetworks() ([]description.Network, error) {
+	networks, err := st.AllNetworks()
+	if err != nil {
+		return nil, err
+	}
+	var result []description.Network
+	for _, n := range networks {
+		if n.Life() != Alive {
+			continue
+		}
+		result = append(result, description.Network{
+			Name:       n.Name(),
+			ProviderId: string(n.ProviderId()),
+			CIDR:       n.CIDR(),
+			VLANTag:    n.VLANTag(),
+		})
+	}
+	return result, nil
+}
+
+func (st *State) exportMachines() ([]description.Machine, error) {
+	machines, err := st.AllMachines()
+	if err != nil {
+		return nil, err
+	}
+	var result []description.Machine
+	for _, m := range machines {
+		if m.Life() != Alive {
+			continue
+		}
+		exported := description.Machine{
+			Id:            m.Id(),
+			Series:        m.Series(),
+			ContainerType: m.doc.ContainerType,
+			Placement:     m.Placement(),
+			Nonce:         m.doc.Nonce,
+			PasswordHash:  m.doc.PasswordHash,
+		}
+		for _, job := range m.Jobs() {
+			exported.Jobs = append(exported.Jobs, job.String())
+		}
+		cons, err := m.Constraints()
+		if err != nil {
+			return nil, err
+		}
+		exported.Constraints = cons.String()
+		if exported.Networks, err = m.RequestedNetworks(); err != nil {
+			return nil, err
+		}
+		instId, err := m.InstanceId()
+		if err == nil {
+			exported.InstanceId = string(instId)
+			hc, err := m.HardwareCharacteristics()
+			if err != nil {
+				return nil, err
+			}
+			exported.Hardware = hc.String()
+		} else if !IsNotProvisionedError(err) {
+			return nil, err
+		}
+		for _, addr := range m.doc.Addresses {
+			exported.Addresses = append(exported.Addresses, description.Address{
+				Value:       addr.Value,
+				Type:        string(addr.AddressType),
+				NetworkName: addr.NetworkName,
+				Scope:       string(addr.Scope),
+			})
+		}
+		if exported.OpenedPorts, err = st.exportOpenedPorts(m); err != nil {
+			return nil, err
+		}
+		if exported.Annotations, err = exportAnnotations(m); err != nil {
+			return nil, err
+		}
+		status, info, _, err := m.Status()
+		if err != nil {
+			return nil, err
+		}
+		exported.Status = string(status)
+		exported.StatusInfo = info
+		result = append(result, exported)
+	}
+	return result, nil
+}
+
+func (st *State) exportOpenedPorts(m *Machine) ([]description.OpenedPorts, error) {
+	allPorts, err := m.OpenedPorts(st)
+	if err != nil {
+		return nil, err
+	}
+	var result []description.OpenedPorts
+	for _, ports := range allPorts {
+		if len(ports.doc.Ports) == 0 {
+			continue
+		}
+		networkName, err := ports.NetworkName()
+		if err != nil {
+			return nil, err
+		}
+		exported := description.OpenedPorts{Network: networkName}
+		for _, p := range ports.doc.Ports {
+			exported.Ports = append(exported.Ports, description.PortRange{
+				Unit:     p.UnitName,
+				FromPort: p.FromPort,
+				ToPort:   p.ToPort,
+				Protocol: p.Protocol,
+			})
+		}
+		result = append(result, exported)
+	}
+	return result, nil
+}
+
+func (st *State) exportServices() ([]description.Service, error) {
+	services, err := st.AllServices()
+	if err != nil {
+		return nil, err
+	}
+	var result []description.Service
+	for _, svc := range services {
+		if svc.Life() != Alive {
+			continue
+		}
+		exported := description.Service{
+			Name:        svc.Name(),
+			Series:      svc.doc.Series,
+			Subordinate: svc.doc.Subordinate,
+			CharmURL:    svc.doc.CharmURL.String(),
+			ForceCharm:  svc.doc.ForceCharm,
+			Owner:       svc.doc.OwnerTag,
+			Exposed:     svc.doc.Exposed,
+			MinUnits:    svc.doc.MinUnits,
+			UnitSeq:     svc.doc.UnitSeq,
+		}
+		settings, _, err := readSettingsDoc(st, svc.settingsKey())
+		if err != nil {
+			return nil, fmt.Errorf("cannot read settings of service %q: %v", svc.Name(), err)
+		}
+		if len(settings) > 0 {
+			exported.Settings = settings
+		}
+		if !svc.doc.Subordinate {
+			cons, err := svc.Constraints()
+			if err != nil {
+				return nil, err
+			}
+			exported.Constraints = cons.String()
+		}
+		if exported.Networks, err = svc.Networks(); err != nil {
+			return nil, err
+		}
+		if exported.Annotations, err = exportAnnotations(svc); err != nil {
+			return nil, err
+		}
+		units, err := svc.AllUnits()
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range units {
+			if u.Life() != Alive {
+				continue
+			}
+			exportedUnit := description.Unit{
+				Name:         u.Name(),
+				Machine:      st.localID(u.doc.MachineId),
+				Principal:    st.localID(u.doc.Principal),
+				PasswordHash: u.doc.PasswordHash,
+			}
+			if u.doc.CharmURL != nil {
+				exportedUnit.CharmURL = u.doc.CharmURL.String()
+			}
+			if exportedUnit.Annotations, err = exportAnnotations(u); err != nil {
+				return nil, err
+			}
+			status, info, _, err := u.Status()
+			if err != nil {
+				return nil, err
+			}
+			exportedUnit.Status = string(status)
+			exportedUnit.StatusInfo = info
+			exported.Units = append(exported.Units, exportedUnit)
+		}
+		result = append(result, exported)
+	}
+	return result, nil
+}
+
+func (st *State) exportRelations() ([]description.Relation, error) {
+	relations, err := st.AllRelations()
+	if err != nil {
+		return nil, err
+	}
+	var result []description.Relation
+	for _, rel := range relations {
+		if rel.Life() != Alive {
+			continue
+		}
+		exported := description.Relation{
+			Id:  rel.Id(),
+			Key: rel.String(),
+		}
+		for _, ep := range rel.Endpoints() {
+			exported.Endpoints = append(exported.Endpoints, description.Endpoint{
+				Service:   ep.ServiceName,
+				Name:      ep.Name,
+				Interface: ep.Interface,
+				Role:      string(ep.Role),
+				Scope:     string(ep.Scope),
+				Optional:  ep.Optional,
+				Limit:     ep.Limit,
+				Network:   rel.doc.Networks[ep.ServiceName],
+			})
+		}
+		if exported.Units, err = st.exportRelationUnits(rel); err != nil {
+			return nil, err
+		}
+		result = append(result, exported)
+	}
+	return result, nil
+}
+
+// exportRelationUnits returns the units in the scope of the relation
+// that are not departing it, with their settings in the relation.
+func (st *State) exportRelationUnits(rel *Relation) ([]description.RelationUnit, error) {
+	relationScopes, closer := st.getCollection(relationScopesC)
+	defer closer()
+
+	var docs []relationScopeDoc
+	sel := bson.D{
+		{"_id", bson.D{{"$regex", fmt.Sprintf("^r#%d#", rel.Id())}}},
+		{"departing", bson.D{{"$ne", true}}},
+	}
+	if err := relationScopes.Find(sel).Sort("_id").All(&docs); err != nil {
+		return nil, fmt.Errorf("cannot get units of relation %q: %v", rel, err)
+	}
+	var result []description.RelationUnit
+	for _, doc := range docs {
+		settings, _, err := readSettingsDoc(st, doc.Key)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read settings of unit %q in relation %q: %v", doc.unitName(), rel, err)
+		}
+		exported := description.RelationUnit{Name: doc.unitName()}
+		if len(settings) > 0 {
+			exported.Settings = settings
+		}
+		result = append(result, exported)
+	}
+	return result, nil
+}
+
+// Import adds the networks, machines, services, units and relations
+// described to the environment of the state, keeping their names and
+// ids, and sets the environment constraints, all in one transaction.
+// The environment must not have any services yet.
+//
+// The environment configuration is not imported, as the environment
+// is expected to have been created with it. Neither are the charms:
+// their archives must be copied to the environment's storage and the
+// charms added to state before the description is imported. State
+// server machines are not imported either, as their role is taken by
+// the state servers of the importing environment; units and
+// containers assigned to them are assigned to the machines with the
+// same ids in the environment.
+func (st *State) Import(env *description.Environment) (err error) {
+	defer errors.Maskf(&err, "cannot import environment %q", env.Name)
+
+	if env.Version != description.Version {
+		return fmt.Errorf("unsupported environment description version %d", env.Version)
+	}
+	services, closer := st.getCollection(servicesC)
+	defer closer()
+	if count, err := services.Find(st.environSelector()).Count(); err != nil {
+		return err
+	} else if count > 0 {
+		return fmt.Errorf("environment already has services")
+	}
+	for _, curl := range env.Charms {
+		url, err := charm.ParseURL(curl)
+		if err != nil {
+			return err
+		}
+		if _, err := st.Charm(url); err != nil {
+			return err
+		}
+	}
+
+	imp := &importer{
+		st:       st,
+		env:      env,
+		machines: make(map[string]*machineDoc),
+	}
+	ops, err := imp.ops()
+	if err != nil {
+		return err
+	}
+	if err := st.runTransaction(ops); err == txn.ErrAborted {
+		if cause := st.abortCause(ops); cause != nil {
+			return cause
+		}
+		return err
+	} else if err != nil {
+		return err
+	}
+	return imp.updateSequences()
+}
+
+// importer builds the operations that add the
+// entities of an environment description to state.
+type importer struct {
+	st  *State
+	env *description.Environment
+
+	// machines holds the documents of the imported machines.
+	machines map[string]*machineDoc
+
+	// sequences holds the values the sequences
+	// must have after the import.
+	sequences map[string]int
+}
+
+func (imp *importer) ops() ([]txn.Op, error) {
+	ops, err := imp.environOps()
+	if err != nil {
+		return nil, err
+	}
+	networkOps, err := imp.networkOps()
+	if err != nil {
+		return nil, err
+	}
+	ops = append(ops, networkOps...)
+	machineOps, err := imp.machineOps()
+	if err != nil {
+		return nil, err
+	}
+	ops = append(ops, machineOps...)
+	for _, svc := range imp.env.Services {
+		serviceOps, err := imp.serviceOps(svc)
+		if err != nil {
+			return nil, fmt.Errorf("cannot import service %q: %v", svc.Name, err)
+		}
+		ops = append(ops, serviceOps...)
+	}
+	for _, rel := range imp.env.Relations {
+		relationOps, err := imp.relationOps(rel)
+		if err != nil {
+			return nil, fmt.Errorf("cannot import relation %q: %v", rel.Key, err)
+		}
+		ops = append(ops, relationOps...)
+	}
+	return ops, nil
+}
+
+// environOps returns the operations that set the
+// environment constraints and annotations.
+func (imp *importer) environOps() ([]txn.Op, error) {
+	cons, err := constraints.Parse(imp.env.Constraints)
+	if err != nil {
+		return nil, err
+	}
+	unsupported, err := imp.st.validateConstraints(cons)
+	if len(unsupported) > 0 {
+		logger.Warningf(
+			"importing environment constraints: unsupported constraints: %v", strings.Join(unsupported, ","))
+	} else if err != nil {
+		return nil, err
+	}
+	ops := []txn.Op{setConstraintsOp(imp.st, imp.st.environKey(), cons)}
+	if len(imp.env.Annotations) > 0 {
+		env, err := imp.st.Environment()
+		if err != nil {
+			return nil, err
+		}
+		op, err := imp.annotationsOp(env.globalKey(), env.Tag(), imp.env.Annotations, true)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+// networkOps returns the operations that add the described networks.
+func (imp *importer) networkOps() ([]txn.Op, error) {
+	networks, closer := imp.st.getCollection(networksC)
+	defer closer()
+
+	var ops []txn.Op
+	providerIds := make(set.Strings)
+	for _, n := range imp.env.Networks {
+		args := NetworkInfo{
+			Name:       n.Name,
+			ProviderId: network.Id(n.ProviderId),
+			CIDR:       n.CIDR,
+			VLANTag:    n.VLANTag,
+		}
+		if err := args.validate(); err != nil {
+			return nil, fmt.Errorf("cannot import network %q: %v", n.Name, err)
+		}
+		// Provider ids are unique, but transactions do not report
+		// inserts rejected by the unique index, so check first.
+		if providerIds.Contains(n.ProviderId) {
+			return nil, errors.AlreadyExistsf("network with provider id %q", args.ProviderId)
+		}
+		providerIds.Add(n.ProviderId)
+		if count, err := networks.Find(bson.D{{"providerid", args.ProviderId}}).Count(); err != nil {
+			return nil, err
+		} else if count > 0 {
+			return nil, errors.AlreadyExistsf("network with provider id %q", args.ProviderId)
+		}
+		doc := newNetworkDoc(imp.st, args)
+		ops = append(ops, txn.Op{
+			C:      networksC,
+			Id:     doc.Name,
+			Assert: txn.DocMissing,
+			Insert: doc,
+		})
+	}
+	return ops, nil
+}
+
+// annotationsOp returns the operation that sets the annotations of the
+// entity with the given global key and tag. The annotations of entities
+// added by the import are inserted; those of existing entities replace
+// any the entities have.
+func (imp *importer) annotationsOp(globalKey string, tag names.Tag, annotations map[string]string, exists bool) (txn.Op, error) {
+	if exists {
+		coll, closer := imp.st.getCollection(annotationsC)
+		defer closer()
+		if count, err := coll.FindId(globalKey).Count(); err != nil {
+			return txn.Op{}, err
+		} else if count > 0 {
+			return txn.Op{
+				C:      annotationsC,
+				Id:     globalKey,
+				Assert: txn.DocExists,
+				Update: bson.D{{"$set", bson.D{{"annotations", annotations}}}},
+			}, nil
+		}
+	}
+	return txn.Op{
+		C:      annotationsC,
+		Id:     globalKey,
+		Assert: txn.DocMissing,
+		Insert: &annotatorDoc{globalKey, tag.String(), annotations},
+	}, nil
+}
+
+// isStateServerMachine reports whether the described
+// machine is a state server.
+func isStateServerMachine(m description.Machine) bool {
+	for _, job := range m.Jobs {
+		if job == JobManageEnviron.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// machineOps returns the operations that add the described machines.
+// The machine documents are recorded in imp.machines, so that the
+// units assigned to the machines can be added to them before the
+// operations are run.
+func (imp *importer) machineOps() ([]txn.Op, error) {
+	children := make(map[string][]string)
+	for _, m := range imp.env.Machines {
+		if parentId := ParentId(m.Id); parentId != "" {
+			children[parentId] = append(children[parentId], m.Id)
+		}
+	}
+	var ops []txn.Op
+	for _, m := range imp.env.Machines {
+		id := imp.st.docID(m.Id)
+		if isStateServerMachine(m) {
+			// The machine must exist in the environment,
+			// and record the containers imported into it.
+			ops = append(ops, txn.Op{
+				C:      machinesC,
+				Id:     id,
+				Assert: isAliveDoc,
+			})
+			for _, childId := range children[m.Id] {
+				ops = append(ops, imp.st.addChildToContainerRefOp(id, imp.st.docID(childId)))
+			}
+			portsOps, err := imp.portsOps(m, true)
+			if err != nil {
+				return nil, fmt.Errorf("cannot import machine %q: %v", m.Id, err)
+			}
+			ops = append(ops, portsOps...)
+			if len(m.Annotations) > 0 {
+				op, err := imp.annotationsOp(machineGlobalKey(id), names.NewMachineTag(m.Id), m.Annotations, true)
+				if err != nil {
+					return nil, fmt.Errorf("cannot import machine %q: %v", m.Id, err)
+				}
+				ops = append(ops, op)
+			}
+			continue
+		}
+		mdoc, err := imp.machineDoc(m)
+		if err != nil {
+			return nil, fmt.Errorf("cannot import machine %q: %v", m.Id, err)
+		}
+		imp.machines[m.Id] = mdoc
+		cons, err := constraints.Parse(m.Constraints)
+		if err != nil {
+			return nil, fmt.Errorf("cannot import machine %q: %v", m.Id, err)
+		}
+		globalKey := machineGlobalKey(id)
+		ops = append(ops, txn.Op{
+			C:      machinesC,
+			Id:     id,
+			Assert: txn.DocMissing,
+			Insert: mdoc,
+		},
+			createConstraintsOp(imp.st, globalKey, cons),
+			createStatusOp(imp.st, globalKey, statusDoc{
+				Status:     params.Status(m.Status),
+				StatusInfo: m.StatusInfo,
+			}),
+			createRequestedNetworksOp(imp.st, globalKey, m.Networks, nil),
+			imp.st.insertNewContainerRefOp(id, imp.docIDs(children[m.Id])...),
+		)
+		if m.InstanceId != "" {
+			hc, err := instance.ParseHardware(m.Hardware)
+			if err != nil {
+				return nil, fmt.Errorf("cannot import machine %q: %v", m.Id, err)
+			}
+			ops = append(ops, txn.Op{
+				C:      instanceDataC,
+				Id:     id,
+				Assert: txn.DocMissing,
+				Insert: &instanceData{
+					Id:         id,
+					InstanceId: instance.Id(m.InstanceId),
+					Arch:       hc.Arch,
+					Mem:        hc.Mem,
+					RootDisk:   hc.RootDisk,
+					CpuCores:   hc.CpuCores,
+					CpuPower:   hc.CpuPower,
+					Tags:       hc.Tags,
+				},
+			})
+		}
+		portsOps, err := imp.portsOps(m, false)
+		if err != nil {
+			return nil, fmt.Errorf("cannot import machine %q: %v", m.Id, err)
+		}
+		ops = append(ops, portsOps...)
+		if len(m.Annotations) > 0 {
+			op, err := imp.annotationsOp(globalKey, names.NewMachineTag(m.Id), m.Annotations, false)
+			if err != nil {
+				return nil, fmt.Errorf("cannot import machine %q: %v", m.Id, err)
+			}
+			ops = append(ops, op)
+		}
+		imp.useSequence(imp.st.machineSequence(id))
+	}
+	return ops, nil
+}
+
+// portsOps returns the operations that open the ports described as
+// opened on the machine. The ports are added to those already opened
+// on existing machines.
+func (imp *importer) portsOps(m description.Machine, exists bool) ([]txn.Op, error) {
+	var ops []txn.Op
+	for _, opened := range m.OpenedPorts {
+		var ports []PortRange
+		for _, p := range opened.Ports {
+			ports = append(ports, PortRange{
+				UnitName: p.Unit,
+				FromPort: p.FromPort,
+				ToPort:   p.ToPort,
+				Protocol: p.Protocol,
+			})
+		}
+		doc := newPortsDoc(imp.st.docID(m.Id), opened.Network, ports...)
+		if exists {
+			coll, closer := imp.st.getCollection(openedPortsC)
+			count, err := coll.FindId(doc.Id).Count()
+			closer()
+			if err != nil {
+				return nil, err
+			}
+			if count > 0 {
+				ops = append(ops, txn.Op{
+					C:      openedPortsC,
+					Id:     doc.Id,
+					Assert: txn.DocExists,
+					Update: bson.D{{"$addToSet", bson.D{{"ports", bson.D{{"$each", ports}}}}}},
+				})
+				continue
+			}
+		}
+		ops = append(ops, txn.Op{
+			C:      openedPortsC,
+			Id:     doc.Id,
+			Assert: txn.DocMissing,
+			Insert: doc,
+		})
+	}
+	return ops, nil
+}
+
+func (imp *importer) machineDoc(m description.Machine) (*machineDoc, error) {
+	mdoc := &machineDoc{
+		Id:            imp.st.docID(m.Id),
+		EnvUUID:       imp.st.environTag.Id(),
+		Nonce:         m.Nonce,
+		Series:        m.Series,
+		ContainerType: m.ContainerType,
+		Life:          Alive,
+		Clean:         true,
+		Placement:     m.Placement,
+		PasswordHash:  m.PasswordHash,
+		DateCreated:   nowToTheSecond(),
+	}
+	for _, name := range m.Jobs {
+		job, err := MachineJobFromParams(params.MachineJob(name))
+		if err != nil {
+			return nil, err
+		}
+		mdoc.Jobs = append(mdoc.Jobs, job)
+	}
+	for _, addr := range m.Addresses {
+		mdoc.Addresses = append(mdoc.Addresses, address{
+			Value:       addr.Value,
+			AddressType: network.AddressType(addr.Type),
+			NetworkName: addr.NetworkName,
+			Scope:       network.Scope(addr.Scope),
+		})
+	}
+	return mdoc, nil
+}
+
+func (imp *importer) serviceOps(svc description.Service) ([]txn.Op, error) {
+	curl, err := charm.ParseURL(svc.CharmURL)
+	if err != nil {
+		return nil, err
+	}
+	owner, err := names.ParseUserTag(svc.Owner)
+	if err != nil {
+		return nil, err
+	}
+	name := imp.st.docID(svc.Name)
+	sdoc := &serviceDoc{
+		Name:        name,
+		EnvUUID:     imp.st.environTag.Id(),
+		Series:      svc.Series,
+		Subordinate: svc.Subordinate,
+		CharmURL:    curl,
+		ForceCharm:  svc.ForceCharm,
+		Life:        Alive,
+		UnitSeq:     svc.UnitSeq,
+		UnitCount:   len(svc.Units),
+		Exposed:     svc.Exposed,
+		MinUnits:    svc.MinUnits,
+		OwnerTag:    svc.Owner,
+	}
+	for _, rel := range imp.env.Relations {
+		for _, ep := range rel.Endpoints {
+			if ep.Service == svc.Name {
+				sdoc.RelationCount++
+				break
+			}
+		}
+	}
+	globalKey := serviceGlobalKey(name)
+	settingsKey := serviceSettingsKey(name, curl)
+	settingsRefs := 1
+	var unitOps []txn.Op
+	for _, u := range svc.Units {
+		ops, err := imp.unitOps(svc, u)
+		if err != nil {
+			return nil, fmt.Errorf("cannot import unit %q: %v", u.Name, err)
+		}
+		unitOps = append(unitOps, ops...)
+		if u.CharmURL == svc.CharmURL {
+			settingsRefs++
+		}
+	}
+	ops := []txn.Op{{
+		C:      usersC,
+		Id:     owner.Id(),
+		Assert: txn.DocExists,
+	}, {
+		C:      servicesC,
+		Id:     name,
+		Assert: txn.DocMissing,
+		Insert: sdoc,
+	},
+		createSettingsOp(imp.st, settingsKey, svc.Settings),
+		{
+			C:      settingsrefsC,
+			Id:     settingsKey,
+			Assert: txn.DocMissing,
+			Insert: settingsRefsDoc{settingsRefs},
+		},
+		createRequestedNetworksOp(imp.st, globalKey, svc.Networks, nil),
+	}
+	if !svc.Subordinate {
+		cons, err := constraints.Parse(svc.Constraints)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, createConstraintsOp(imp.st, globalKey, cons))
+	}
+	if len(svc.Annotations) > 0 {
+		op, err := imp.annotationsOp(globalKey, names.NewServiceTag(svc.Name), svc.Annotations, false)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+	if svc.MinUnits > 0 {
+		ops = append(ops, txn.Op{
+			C:      minUnitsC,
+			Id:     name,
+			Assert: txn.DocMissing,
+			Insert: &minUnitsDoc{ServiceName: name},
+		})
+	}
+	return append(ops, unitOps...), nil
+}
+
+func (imp *importer) unitOps(svc description.Service, u description.Unit) ([]txn.Op, error) {
+	name := imp.st.docID(u.Name)
+	udoc := &unitDoc{
+		Name:         name,
+		EnvUUID:      imp.st.environTag.Id(),
+		Service:      imp.st.docID(svc.Name),
+		Series:       svc.Series,
+		Principal:    imp.st.docID(u.Principal),
+		MachineId:    imp.st.docID(u.Machine),
+		Life:         Alive,
+		PasswordHash: u.PasswordHash,
+	}
+	// Units running another version of the charm than their service
+	// set their charm URL again when their agent starts.
+	if u.CharmURL == svc.CharmURL {
+		curl, err := charm.ParseURL(u.CharmURL)
+		if err != nil {
+			return nil, err
+		}
+		udoc.CharmURL = curl
+	}
+	for _, other := range imp.env.Services {
+		for _, sub := range other.Units {
+			if sub.Principal == u.Name {
+				udoc.Subordinates = append(udoc.Subordinates, imp.st.docID(sub.Name))
+			}
+		}
+	}
+	globalKey := unitGlobalKey(name)
+	ops := []txn.Op{{
+		C:      unitsC,
+		Id:     name,
+		Assert: txn.DocMissing,
+		Insert: udoc,
+	},
+		createStatusOp(imp.st, globalKey, statusDoc{
+			Status:     params.Status(u.Status),
+			StatusInfo: u.StatusInfo,
+		}),
+	}
+	if len(u.Annotations) > 0 {
+		op, err := imp.annotationsOp(globalKey, names.NewUnitTag(u.Name), u.Annotations, false)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+	if u.Principal == "" {
+		// Unit constraints are those of the service
+		// merged with the environment constraints.
+		scons, err := constraints.Parse(svc.Constraints)
+		if err != nil {
+			return nil, err
+		}
+		cons, err := imp.st.resolveConstraints(scons)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, createConstraintsOp(imp.st, globalKey, cons))
+	}
+	if u.Machine != "" && u.Principal == "" {
+		if mdoc := imp.machines[u.Machine]; mdoc != nil {
+			mdoc.Principals = append(mdoc.Principals, name)
+			mdoc.Clean = false
+		} else {
+			ops = append(ops, txn.Op{
+				C:      machinesC,
+				Id:     imp.st.docID(u.Machine),
+				Assert: isAliveDoc,
+				Update: bson.D{
+					{"$addToSet", bson.D{{"principals", name}}},
+					{"$set", bson.D{{"clean", false}}},
+				},
+			})
+		}
+	}
+	return ops, nil
+}
+
+func (imp *importer) relationOps(rel description.Relation) ([]txn.Op, error) {
+	rdoc := &relationDoc{
+		Key:       imp.st.docID(rel.Key),
+		Id:        rel.Id,
+		Life:      Alive,
+		UnitCount: len(rel.Units),
+		EnvUUID:   imp.st.environTag.Id(),
+	}
+	for _, ep := range rel.Endpoints {
+		if ep.Network != "" {
+			if !imp.hasNetwork(ep.Network) {
+				return nil, fmt.Errorf("endpoint of service %q bound to unknown network %q", ep.Service, ep.Network)
+			}
+			if rdoc.Networks == nil {
+				rdoc.Networks = make(map[string]string)
+			}
+			rdoc.Networks[ep.Service] = ep.Network
+		}
+		rdoc.Endpoints = append(rdoc.Endpoints, Endpoint{
+			ServiceName: ep.Service,
+			Relation: charm.Relation{
+				Name:      ep.Name,
+				Interface: ep.Interface,
+				Role:      charm.RelationRole(ep.Role),
+				Scope:     charm.RelationScope(ep.Scope),
+				Optional:  ep.Optional,
+				Limit:     ep.Limit,
+			},
+		})
+	}
+	imp.useSequence("relation", rel.Id)
+	ops := []txn.Op{{
+		C:      relationsC,
+		Id:     rdoc.Key,
+		Assert: txn.DocMissing,
+		Insert: rdoc,
+	}}
+	for _, ru := range rel.Units {
+		key, err := imp.relationScopeKey(rdoc, ru.Name)
+		if err != nil {
+			return nil, fmt.Errorf("cannot import unit %q in scope: %v", ru.Name, err)
+		}
+		ops = append(ops, createSettingsOp(imp.st, key, ru.Settings), txn.Op{
+			C:      relationScopesC,
+			Id:     key,
+			Assert: txn.DocMissing,
+			Insert: relationScopeDoc{Key: key},
+		})
+	}
+	return ops, nil
+}
+
+// hasNetwork reports whether the named network is described.
+func (imp *importer) hasNetwork(name string) bool {
+	for _, n := range imp.env.Networks {
+		if n.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// relationScopeKey returns the key of the scope document of the named
+// unit in the relation, which is also the key of the unit's settings
+// in the relation. It matches the key used by RelationUnit.
+func (imp *importer) relationScopeKey(rdoc *relationDoc, unitName string) (string, error) {
+	serviceName := strings.Split(unitName, "/")[0]
+	var ep *Endpoint
+	for i := range rdoc.Endpoints {
+		if rdoc.Endpoints[i].ServiceName == serviceName {
+			ep = &rdoc.Endpoints[i]
+			break
+		}
+	}
+	if ep == nil {
+		return "", fmt.Errorf("service %q is not in the relation", serviceName)
+	}
+	parts := []string{"r", strconv.Itoa(rdoc.Id)}
+	if ep.Scope == charm.ScopeContainer {
+		container := unitName
+		for _, svc := range imp.env.Services {
+			for _, u := range svc.Units {
+				if u.Name == unitName && u.Principal != "" {
+					container = u.Principal
+				}
+			}
+		}
+		parts = append(parts, container)
+	}
+	parts = append(parts, string(ep.Role), unitName)
+	return strings.Join(parts, "#"), nil
+}
+
+// docIDs returns the ids of the documents of the described
+// entities with the given ids.
+func (imp *importer) docIDs(ids []string) []string {
+	var docIDs []string
+	for _, id := range ids {
+		docIDs = append(docIDs, imp.st.docID(id))
+	}
+	return docIDs
+}
+
+// machineSequence returns the name of the sequence the number of the
+// machine with the given document id was taken from, and the number.
+func (st *State) machineSequence(id string) (string, int) {
+	parts := strings.Split(id, "/")
+	n, _ := strconv.Atoi(st.localID(parts[len(parts)-1]))
+	if len(parts) == 1 {
+		return st.docID("machine"), n
+	}
+	parentId := strings.Join(parts[:len(parts)-2], "/")
+	containerType := parts[len(parts)-2]
+	return fmt.Sprintf("machine%s%sContainer", parentId, containerType), n
+}
+
+// useSequence records that the given number of
+// the named sequence is used by imported entities.
+func (imp *importer) useSequence(name string, n int) {
+	if imp.sequences == nil {
+		imp.sequences = make(map[string]int)
+	}
+	if next, ok := imp.sequences[name]; !ok || n >= next {
+		imp.sequences[name] = n + 1
+	}
+}
+
+// updateSequences ensures the sequences do not hand
+// out the numbers used by the imported entities.
+func (imp *importer) updateSequences() error {
+	sequences := imp.st.db.C("sequence")
+	for name, next := range imp.sequences {
+		_, err := sequences.Upsert(
+			bson.D{{"_id", name}, {"counter", bson.D{{"$lt", next}}}},
+			bson.D{{"$set", bson.D{{"counter", next}}}},
+		)
+		if err != nil && !mgo.IsDup(err) {
+			return fmt.Errorf("cannot update %q sequence number: %v", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"github.com/juju/charm"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/constraints"
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/description"
+)
+
+type MigrationSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&MigrationSuite{})
+
+// addEntities adds a network, a machine with a container, two
+// related services and a unit of each to the environment. The
+// wordpress unit opens a port and enters the relation's scope.
+func (s *MigrationSuite) addEntities(c *gc.C) {
+	err := s.State.SetEnvironConstraints(constraints.MustParse("mem=2G"))
+	c.Assert(err, gc.IsNil)
+	env, err := s.State.Environment()
+	c.Assert(err, gc.IsNil)
+	err = env.SetAnnotations(map[string]string{"owner": "ops"})
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.AddNetwork(state.NetworkInfo{
+		Name:       "db",
+		ProviderId: "db-0",
+		CIDR:       "10.0.1.0/24",
+	})
+	c.Assert(err, gc.IsNil)
+	m0, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	hc := instance.MustParseHardware("arch=amd64 mem=4096M")
+	err = m0.SetProvisioned("i-0", "fake-nonce", &hc)
+	c.Assert(err, gc.IsNil)
+	err = m0.SetPassword("machine-password-000000")
+	c.Assert(err, gc.IsNil)
+	err = m0.SetAnnotations(map[string]string{"rack": "a1"})
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.AddMachineInsideMachine(state.MachineTemplate{
+		Series: "quantal",
+		Jobs:   []state.MachineJob{state.JobHostUnits},
+	}, m0.Id(), instance.LXC)
+	c.Assert(err, gc.IsNil)
+
+	wordpress := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	err = wordpress.UpdateConfigSettings(charm.Settings{"blog-title": "migrated"})
+	c.Assert(err, gc.IsNil)
+	err = wordpress.SetConstraints(constraints.MustParse("cpu-cores=2"))
+	c.Assert(err, gc.IsNil)
+	wu, err := wordpress.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = wu.AssignToMachine(m0)
+	c.Assert(err, gc.IsNil)
+	err = wu.SetPassword("unit-password-000000")
+	c.Assert(err, gc.IsNil)
+	err = wu.OpenPort("tcp", 80)
+	c.Assert(err, gc.IsNil)
+	err = wordpress.SetAnnotations(map[string]string{"tier": "web"})
+	c.Assert(err, gc.IsNil)
+
+	mysql := s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	_, err = mysql.AddUnit()
+	c.Assert(err, gc.IsNil)
+	eps, err := s.State.InferEndpoints([]string{"wordpress", "mysql"})
+	c.Assert(err, gc.IsNil)
+	rel, err := s.State.AddRelation(eps...)
+	c.Assert(err, gc.IsNil)
+	err = rel.SetEndpointNetwork("mysql", "db")
+	c.Assert(err, gc.IsNil)
+	ru, err := rel.Unit(wu)
+	c.Assert(err, gc.IsNil)
+	err = ru.EnterScope(map[string]interface{}{"user": "wp"})
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *MigrationSuite) serviceCharmURL(c *gc.C, name string) string {
+	svc, err := s.State.Service(name)
+	c.Assert(err, gc.IsNil)
+	curl, _ := svc.CharmURL()
+	return curl.String()
+}
+
+func servicesByName(services []description.Service) map[string]description.Service {
+	result := make(map[string]description.Service)
+	for _, svc := range services {
+		result[svc.Name] = svc
+	}
+	return result
+}
+
+func (s *MigrationSuite) TestExport(c *gc.C) {
+	s.addEntities(c)
+	env, err := s.State.Export()
+	c.Assert(err, gc.IsNil)
+
+	c.Assert(env.Version, gc.Equals, description.Version)
+	c.Assert(env.UUID, gc.Equals, s.State.EnvironTag().Id())
+	c.Assert(env.Config["name"], gc.Equals, "testenv")
+	c.Assert(env.Constraints, gc.Equals, "mem=2048M")
+	c.Assert(env.Annotations, gc.DeepEquals, map[string]string{"owner": "ops"})
+	c.Assert(env.Networks, gc.HasLen, 1)
+	c.Assert(env.Networks[0].Name, gc.Equals, "db")
+	mysqlURL := s.serviceCharmURL(c, "mysql")
+	wordpressURL := s.serviceCharmURL(c, "wordpress")
+	c.Assert(env.Charms, gc.DeepEquals, []string{mysqlURL, wordpressURL})
+
+	c.Assert(env.Machines, gc.HasLen, 2)
+	m0 := env.Machines[0]
+	c.Assert(m0.Id, gc.Equals, "0")
+	c.Assert(m0.Jobs, gc.DeepEquals, []string{"JobHostUnits"})
+	c.Assert(m0.InstanceId, gc.Equals, "i-0")
+	c.Assert(m0.Nonce, gc.Equals, "fake-nonce")
+	c.Assert(m0.Hardware, gc.Equals, "arch=amd64 mem=4096M")
+	c.Assert(m0.Status, gc.Equals, "pending")
+	c.Assert(m0.PasswordHash, gc.Not(gc.Equals), "")
+	c.Assert(m0.Annotations, gc.DeepEquals, map[string]string{"rack": "a1"})
+	c.Assert(m0.OpenedPorts, gc.DeepEquals, []description.OpenedPorts{{
+		Ports: []description.PortRange{{
+			Unit:     "wordpress/0",
+			FromPort: 80,
+			ToPort:   80,
+			Protocol: "tcp",
+		}},
+	}})
+	c.Assert(env.Machines[1].Id, gc.Equals, "0/lxc/0")
+	c.Assert(env.Machines[1].ContainerType, gc.Equals, "lxc")
+
+	c.Assert(env.Services, gc.HasLen, 2)
+	wordpress := servicesByName(env.Services)["wordpress"]
+	c.Assert(wordpress.CharmURL, gc.Equals, wordpressURL)
+	c.Assert(wordpress.Owner, gc.Equals, "user-admin")
+	c.Assert(wordpress.Settings, gc.DeepEquals, map[string]interface{}{"blog-title": "migrated"})
+	c.Assert(wordpress.Constraints, gc.Equals, "cpu-cores=2")
+	c.Assert(wordpress.UnitSeq, gc.Equals, 1)
+	c.Assert(wordpress.Annotations, gc.DeepEquals, map[string]string{"tier": "web"})
+	c.Assert(wordpress.Units, gc.HasLen, 1)
+	wu := wordpress.Units[0]
+	c.Assert(wu.PasswordHash, gc.Not(gc.Equals), "")
+	wu.PasswordHash = ""
+	c.Assert(wu, gc.DeepEquals, description.Unit{
+		Name:    "wordpress/0",
+		Machine: "0",
+		Status:  "pending",
+	})
+
+	c.Assert(env.Relations, gc.HasLen, 1)
+	rel := env.Relations[0]
+	c.Assert(rel.Key, gc.Equals, "wordpress:db mysql:server")
+	c.Assert(rel.Endpoints, gc.HasLen, 2)
+	for _, ep := range rel.Endpoints {
+		if ep.Service == "mysql" {
+			c.Assert(ep.Network, gc.Equals, "db")
+		} else {
+			c.Assert(ep.Network, gc.Equals, "")
+		}
+	}
+	c.Assert(rel.Units, gc.DeepEquals, []description.RelationUnit{{
+		Name:     "wordpress/0",
+		Settings: map[string]interface{}{"user": "wp"},
+	}})
+}
+
+func (s *MigrationSuite) TestExportSkipsDyingEntities(c *gc.C) {
+	svc := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	_, err := svc.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = svc.Destroy()
+	c.Assert(err, gc.IsNil)
+
+	env, err := s.State.Export()
+	c.Assert(err, gc.IsNil)
+	c.Assert(env.Services, gc.HasLen, 0)
+	c.Assert(env.Charms, gc.HasLen, 0)
+}
+
+func (s *MigrationSuite) TestImportRoundTrip(c *gc.C) {
+	s.addEntities(c)
+	exported, err := s.State.Export()
+	c.Assert(err, gc.IsNil)
+
+	// Start again with an empty environment holding the charms.
+	s.TearDownTest(c)
+	s.SetUpTest(c)
+	s.AddTestingCharm(c, "wordpress")
+	s.AddTestingCharm(c, "mysql")
+
+	err = s.State.Import(exported)
+	c.Assert(err, gc.IsNil)
+	imported, err := s.State.Export()
+	c.Assert(err, gc.IsNil)
+	c.Assert(imported.Constraints, gc.Equals, exported.Constraints)
+	c.Assert(imported.Annotations, jc.DeepEquals, exported.Annotations)
+	c.Assert(imported.Networks, jc.DeepEquals, exported.Networks)
+	c.Assert(imported.Charms, jc.DeepEquals, exported.Charms)
+	c.Assert(imported.Machines, jc.DeepEquals, exported.Machines)
+	c.Assert(servicesByName(imported.Services), jc.DeepEquals, servicesByName(exported.Services))
+	c.Assert(imported.Relations, jc.DeepEquals, exported.Relations)
+
+	// The imported entities are usable.
+	m0, err := s.State.Machine("0")
+	c.Assert(err, gc.IsNil)
+	units, err := m0.Units()
+	c.Assert(err, gc.IsNil)
+	c.Assert(units, gc.HasLen, 1)
+	c.Assert(units[0].Name(), gc.Equals, "wordpress/0")
+	err = units[0].Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(units[0].PasswordValid("unit-password-000000"), jc.IsTrue)
+	c.Assert(m0.PasswordValid("machine-password-000000"), jc.IsTrue)
+	rel, err := s.State.KeyRelation("wordpress:db mysql:server")
+	c.Assert(err, gc.IsNil)
+	c.Assert(rel.Id(), gc.Equals, exported.Relations[0].Id)
+	ru, err := rel.Unit(units[0])
+	c.Assert(err, gc.IsNil)
+	inScope, err := ru.InScope()
+	c.Assert(err, gc.IsNil)
+	c.Assert(inScope, jc.IsTrue)
+	settings, err := ru.ReadSettings("wordpress/0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings, gc.DeepEquals, map[string]interface{}{"user": "wp"})
+
+	// New entities do not take the numbers of the imported ones.
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	c.Assert(m.Id(), gc.Equals, "1")
+	wordpress, err := s.State.Service("wordpress")
+	c.Assert(err, gc.IsNil)
+	u, err := wordpress.AddUnit()
+	c.Assert(err, gc.IsNil)
+	c.Assert(u.Name(), gc.Equals, "wordpress/1")
+}
+
+func (s *MigrationSuite) TestImportEnvironmentWithServices(c *gc.C) {
+	s.addEntities(c)
+	env, err := s.State.Export()
+	c.Assert(err, gc.IsNil)
+	err = s.State.Import(env)
+	c.Assert(err, gc.ErrorMatches, `cannot import environment "testenv": environment already has services`)
+}
+
+func (s *MigrationSuite) TestImportConflictingMachine(c *gc.C) {
+	_, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	env, err := s.State.Export()
+	c.Assert(err, gc.IsNil)
+	err = s.State.Import(env)
+	c.Assert(err, gc.ErrorMatches, `cannot import environment "testenv": machine 0 already exists`)
+}
+
+func (s *MigrationSuite) TestImportMissingCharm(c *gc.C) {
+	s.addEntities(c)
+	env, err := s.State.Export()
+	c.Assert(err, gc.IsNil)
+
+	s.TearDownTest(c)
+	s.SetUpTest(c)
+	s.AddTestingCharm(c, "wordpress")
+	err = s.State.Import(env)
+	c.Assert(err, gc.ErrorMatches, `cannot import environment "testenv": charm "local:quantal/quantal-mysql-[0-9]+" not found`)
+}
+
+func (s *MigrationSuite) TestImportUnsupportedVersion(c *gc.C) {
+	err := s.State.Import(&description.Environment{Name: "testenv", Version: 42})
+	c.Assert(err, gc.ErrorMatches, `cannot import environment "testenv": unsupported environment description version 42`)
+}
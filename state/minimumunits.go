@@ -59,14 +59,14 @@ func (s *Service) SetMinUnits(minUnits int) (err error) {
 		}
 		return setMinUnitsOps(service, minUnits), nil
 	}
-	return s.st.run(buildTxn)
+	return s.st.runDiagnosed(buildTxn)
 }
 
 // setMinUnitsOps returns the operations required to set MinUnits on the
 // service and to create/update/remove the minUnits document in MongoDB.
 func setMinUnitsOps(service *Service, minUnits int) []txn.Op {
 	state := service.st
-	serviceName := service.Name()
+	serviceName := service.doc.Name
 	ops := []txn.Op{{
 		C:      servicesC,
 		Id:     serviceName,
@@ -12,6 +12,8 @@ import (
 	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 	"gopkg.in/mgo.v2/txn"
+
+	"github.com/juju/juju/network"
 )
 
 // NetworkInterface represents the state of a machine network
@@ -42,6 +44,18 @@ type NetworkInterfaceInfo struct {
 
 	// Disabled returns whether the interface is disabled.
 	Disabled bool
+
+	// InterfaceType is the kind of the interface. It is empty for
+	// plain interfaces.
+	InterfaceType network.InterfaceType
+
+	// ParentInterfaceName is the name of the bond or bridge the
+	// interface is a member of, or empty if it is not a member
+	// of any.
+	ParentInterfaceName string
+
+	// BondMode is the bonding mode of a bond interface.
+	BondMode string
 }
 
 // networkInterfaceDoc represents a network interface for a machine on
@@ -54,6 +68,11 @@ type networkInterfaceDoc struct {
 	MachineId     string
 	IsVirtual     bool
 	IsDisabled    bool
+	IsPrimary     bool `bson:",omitempty"`
+
+	InterfaceType       network.InterfaceType `bson:",omitempty"`
+	ParentInterfaceName string                `bson:",omitempty"`
+	BondMode            string                `bson:",omitempty"`
 }
 
 func newNetworkInterface(st *State, doc *networkInterfaceDoc) *NetworkInterface {
@@ -68,7 +87,36 @@ func newNetworkInterfaceDoc(args NetworkInterfaceInfo) *networkInterfaceDoc {
 		NetworkName:   args.NetworkName,
 		IsVirtual:     args.IsVirtual,
 		IsDisabled:    args.Disabled,
+
+		InterfaceType:       args.InterfaceType,
+		ParentInterfaceName: args.ParentInterfaceName,
+		BondMode:            args.BondMode,
+	}
+}
+
+// validate returns an error if the bond and bridge
+// details of the interface are inconsistent.
+func (args NetworkInterfaceInfo) validate() error {
+	switch args.InterfaceType {
+	case "", network.EthernetInterface, network.BridgeInterface:
+		if args.BondMode != "" {
+			kind := args.InterfaceType
+			if kind == "" {
+				kind = network.EthernetInterface
+			}
+			return fmt.Errorf("bond mode set on %s interface", kind)
+		}
+	case network.BondInterface:
+		if !network.IsValidBondMode(args.BondMode) {
+			return fmt.Errorf("invalid bond mode %q", args.BondMode)
+		}
+	default:
+		return fmt.Errorf("invalid interface type %q", args.InterfaceType)
+	}
+	if args.ParentInterfaceName == args.InterfaceName {
+		return fmt.Errorf("interface cannot be a member of itself")
 	}
+	return nil
 }
 
 // GoString implements fmt.GoStringer.
@@ -104,22 +152,22 @@ func (ni *NetworkInterface) RawInterfaceName() string {
 
 // NetworkName returns the network name of the interface.
 func (ni *NetworkInterface) NetworkName() string {
-	return ni.doc.NetworkName
+	return ni.st.localID(ni.doc.NetworkName)
 }
 
 // NetworkTag returns the network tag of the interface.
 func (ni *NetworkInterface) NetworkTag() string {
-	return names.NewNetworkTag(ni.doc.NetworkName).String()
+	return names.NewNetworkTag(ni.NetworkName()).String()
 }
 
 // MachineId returns the machine id of the interface.
 func (ni *NetworkInterface) MachineId() string {
-	return ni.doc.MachineId
+	return ni.st.localID(ni.doc.MachineId)
 }
 
 // MachineTag returns the machine tag of the interface.
 func (ni *NetworkInterface) MachineTag() string {
-	return names.NewMachineTag(ni.doc.MachineId).String()
+	return names.NewMachineTag(ni.MachineId()).String()
 }
 
 // IsVirtual returns whether the interface represents a virtual
@@ -139,6 +187,54 @@ func (ni *NetworkInterface) IsDisabled() bool {
 	return ni.doc.IsDisabled
 }
 
+// IsPrimary returns whether the interface is the primary interface
+// of its machine, carrying its default route and public-facing
+// addresses.
+func (ni *NetworkInterface) IsPrimary() bool {
+	return ni.doc.IsPrimary
+}
+
+// InterfaceType returns the kind of the interface, or an empty
+// string for plain interfaces.
+func (ni *NetworkInterface) InterfaceType() network.InterfaceType {
+	return ni.doc.InterfaceType
+}
+
+// ParentInterfaceName returns the name of the bond or bridge the
+// interface is a member of, or an empty string if it is not a
+// member of any.
+func (ni *NetworkInterface) ParentInterfaceName() string {
+	return ni.doc.ParentInterfaceName
+}
+
+// BondMode returns the bonding mode of a bond interface, or an
+// empty string for other interfaces.
+func (ni *NetworkInterface) BondMode() string {
+	return ni.doc.BondMode
+}
+
+// Members returns the interfaces of the same machine that are
+// members of the interface, which is a bond or a bridge.
+func (ni *NetworkInterface) Members() ([]*NetworkInterface, error) {
+	networkInterfaces, closer := ni.st.getCollection(networkInterfacesC)
+	defer closer()
+
+	docs := []networkInterfaceDoc{}
+	sel := bson.D{
+		{"machineid", ni.doc.MachineId},
+		{"parentinterfacename", ni.doc.InterfaceName},
+	}
+	if err := networkInterfaces.Find(sel).All(&docs); err != nil {
+		return nil, fmt.Errorf("cannot get members of network interface %q on machine %q: %v",
+			ni.InterfaceName(), ni.MachineId(), err)
+	}
+	members := make([]*NetworkInterface, len(docs))
+	for i, doc := range docs {
+		members[i] = newNetworkInterface(ni.st, &doc)
+	}
+	return members, nil
+}
+
 // Remove removes the network interface from state.
 func (ni *NetworkInterface) Remove() (err error) {
 	defer errors.Maskf(&err, "cannot remove network interface %q", ni)
@@ -148,6 +244,11 @@ func (ni *NetworkInterface) Remove() (err error) {
 		Id:     ni.doc.Id,
 		Remove: true,
 	}}
+	cleanupOps, err := ni.st.dyingNetworkCleanupOps(ni.doc.NetworkName)
+	if err != nil {
+		return err
+	}
+	ops = append(ops, cleanupOps...)
 	// The only abort conditions in play indicate that the network interface
 	// has already been removed.
 	return onAbort(ni.st.runTransaction(ops), nil)
@@ -170,6 +271,79 @@ func (ni *NetworkInterface) SetDisabled(isDisabled bool) (err error) {
 	return nil
 }
 
+// SetPrimary makes the network interface the primary interface of
+// its machine, which must not be dead. Any other interface of the
+// machine that was primary ceases to be, so that a machine never has
+// more than one primary interface.
+func (ni *NetworkInterface) SetPrimary() (err error) {
+	defer errors.Maskf(&err, "cannot set primary network interface %q on machine %q",
+		ni.doc.InterfaceName, ni.MachineId())
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if attempt > 0 {
+			if err := ni.Refresh(); err != nil {
+				return nil, err
+			}
+		}
+		machine, err := ni.st.Machine(ni.doc.MachineId)
+		if err != nil {
+			return nil, err
+		}
+		if machine.Life() == Dead {
+			return nil, fmt.Errorf("machine is dead")
+		}
+		ifaces, err := machine.NetworkInterfaces()
+		if err != nil {
+			return nil, err
+		}
+		ops := []txn.Op{{
+			C:      machinesC,
+			Id:     ni.doc.MachineId,
+			Assert: notDeadDoc,
+		}, {
+			C:      networkInterfacesC,
+			Id:     ni.doc.Id,
+			Assert: txn.DocExists,
+			Update: bson.D{{"$set", bson.D{{"isprimary", true}}}},
+		}}
+		for _, iface := range ifaces {
+			if iface.doc.Id == ni.doc.Id {
+				continue
+			}
+			// Every other interface is checked, not just the
+			// current primary, so that concurrent changes cannot
+			// leave two primary interfaces.
+			op := txn.Op{
+				C:      networkInterfacesC,
+				Id:     iface.doc.Id,
+				Assert: bson.D{{"isprimary", bson.D{{"$ne", true}}}},
+			}
+			if iface.doc.IsPrimary {
+				op.Assert = bson.D{{"isprimary", true}}
+				op.Update = bson.D{{"$unset", bson.D{{"isprimary", nil}}}}
+			}
+			ops = append(ops, op)
+		}
+		return ops, nil
+	}
+	if err := ni.st.runDiagnosed(buildTxn); err != nil {
+		return err
+	}
+	ni.doc.IsPrimary = true
+	return nil
+}
+
+// Enable enables the network interface, so the networker
+// brings it up.
+func (ni *NetworkInterface) Enable() error {
+	return ni.SetDisabled(false)
+}
+
+// Disable disables the network interface, so the networker
+// brings it down.
+func (ni *NetworkInterface) Disable() error {
+	return ni.SetDisabled(true)
+}
+
 // Refresh refreshes the contents of the network interface from the underlying
 // state. It returns an error that satisfies errors.IsNotFound if the
 // machine has been removed.
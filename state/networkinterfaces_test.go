@@ -8,6 +8,7 @@ import (
 	jc "github.com/juju/testing/checkers"
 	gc "launchpad.net/gocheck"
 
+	"github.com/juju/juju/network"
 	"github.com/juju/juju/state"
 )
 
@@ -25,7 +26,7 @@ func (s *NetworkInterfaceSuite) SetUpTest(c *gc.C) {
 	var err error
 	s.machine, err = s.State.AddMachine("quantal", state.JobHostUnits)
 	c.Assert(err, gc.IsNil)
-	s.network, err = s.State.AddNetwork(state.NetworkInfo{"net1", "net1", "0.1.2.3/24", 42})
+	s.network, err = s.State.AddNetwork(state.NetworkInfo{"net1", "net1", "0.1.2.3/24", 42, ""})
 	c.Assert(err, gc.IsNil)
 	s.iface, err = s.machine.AddNetworkInterface(state.NetworkInterfaceInfo{
 		MACAddress:    "aa:bb:cc:dd:ee:ff",
@@ -49,6 +50,56 @@ func (s *NetworkInterfaceSuite) TestGetterMethods(c *gc.C) {
 	c.Assert(s.iface.IsDisabled(), jc.IsFalse)
 }
 
+func (s *NetworkInterfaceSuite) TestBondMembers(c *gc.C) {
+	c.Assert(s.iface.InterfaceType(), gc.Equals, network.InterfaceType(""))
+	c.Assert(s.iface.ParentInterfaceName(), gc.Equals, "")
+	c.Assert(s.iface.BondMode(), gc.Equals, "")
+
+	addInterface := func(args state.NetworkInterfaceInfo) *state.NetworkInterface {
+		args.NetworkName = "net1"
+		iface, err := s.machine.AddNetworkInterface(args)
+		c.Assert(err, gc.IsNil)
+		return iface
+	}
+	eth1 := addInterface(state.NetworkInterfaceInfo{
+		MACAddress:          "aa:bb:cc:dd:ee:f1",
+		InterfaceName:       "eth1",
+		ParentInterfaceName: "bond0",
+	})
+	eth2 := addInterface(state.NetworkInterfaceInfo{
+		MACAddress:          "aa:bb:cc:dd:ee:f2",
+		InterfaceName:       "eth2",
+		ParentInterfaceName: "bond0",
+	})
+	// The bond takes the MAC address of one of its members.
+	bond := addInterface(state.NetworkInterfaceInfo{
+		MACAddress:    "aa:bb:cc:dd:ee:f1",
+		InterfaceName: "bond0",
+		IsVirtual:     true,
+		InterfaceType: network.BondInterface,
+		BondMode:      "active-backup",
+	})
+	c.Assert(bond.InterfaceType(), gc.Equals, network.BondInterface)
+	c.Assert(bond.BondMode(), gc.Equals, "active-backup")
+	c.Assert(eth1.ParentInterfaceName(), gc.Equals, "bond0")
+
+	members, err := bond.Members()
+	c.Assert(err, gc.IsNil)
+	c.Assert(members, jc.SameContents, []*state.NetworkInterface{eth1, eth2})
+	members, err = s.iface.Members()
+	c.Assert(err, gc.IsNil)
+	c.Assert(members, gc.HasLen, 0)
+
+	// Interfaces that are not members still cannot share a MAC address.
+	_, err = s.machine.AddNetworkInterface(state.NetworkInterfaceInfo{
+		MACAddress:    "aa:bb:cc:dd:ee:f1",
+		InterfaceName: "br0",
+		NetworkName:   "net1",
+		InterfaceType: network.BridgeInterface,
+	})
+	c.Assert(err, jc.Satisfies, errors.IsAlreadyExists)
+}
+
 func (s *NetworkInterfaceSuite) TestSetAndIsDisabled(c *gc.C) {
 	err := s.iface.SetDisabled(true)
 	c.Assert(err, gc.IsNil)
@@ -59,6 +110,47 @@ func (s *NetworkInterfaceSuite) TestSetAndIsDisabled(c *gc.C) {
 	c.Assert(s.iface.IsDisabled(), jc.IsFalse)
 }
 
+func (s *NetworkInterfaceSuite) TestEnableAndDisable(c *gc.C) {
+	err := s.iface.Disable()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.iface.IsDisabled(), jc.IsTrue)
+	err = s.iface.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.iface.IsDisabled(), jc.IsTrue)
+
+	err = s.iface.Enable()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.iface.IsDisabled(), jc.IsFalse)
+	err = s.iface.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.iface.IsDisabled(), jc.IsFalse)
+
+	err = s.iface.Remove()
+	c.Assert(err, gc.IsNil)
+	err = s.iface.Disable()
+	c.Assert(err, gc.ErrorMatches, "cannot change disabled state on network interface: network interface not found")
+}
+
+func (s *NetworkInterfaceSuite) TestNetworkInterfacesByMACAddress(c *gc.C) {
+	_, err := s.State.AddNetwork(state.NetworkInfo{"vlan", "vlan", "0.1.3.0/24", 43, ""})
+	c.Assert(err, gc.IsNil)
+	iface2, err := s.machine.AddNetworkInterface(state.NetworkInterfaceInfo{
+		MACAddress:    "aa:bb:cc:dd:ee:ff",
+		InterfaceName: "eth0.43",
+		NetworkName:   "vlan",
+		IsVirtual:     true,
+	})
+	c.Assert(err, gc.IsNil)
+
+	ifaces, err := s.State.NetworkInterfacesByMACAddress("aa:bb:cc:dd:ee:ff")
+	c.Assert(err, gc.IsNil)
+	c.Assert(ifaces, jc.DeepEquals, []*state.NetworkInterface{s.iface, iface2})
+
+	ifaces, err = s.State.NetworkInterfacesByMACAddress("aa:bb:cc:dd:ee:00")
+	c.Assert(err, gc.IsNil)
+	c.Assert(ifaces, gc.HasLen, 0)
+}
+
 func (s *NetworkInterfaceSuite) TestRefresh(c *gc.C) {
 	ifaceCopy := *s.iface
 	err := s.iface.SetDisabled(true)
@@ -77,3 +169,75 @@ func (s *NetworkInterfaceSuite) TestRemove(c *gc.C) {
 	c.Check(err, gc.ErrorMatches, errMatch)
 	c.Assert(err, jc.Satisfies, errors.IsNotFound)
 }
+
+func (s *NetworkInterfaceSuite) TestSetPrimary(c *gc.C) {
+	c.Assert(s.iface.IsPrimary(), jc.IsFalse)
+	_, err := s.machine.PrimaryNetworkInterface()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	c.Assert(err, gc.ErrorMatches, `primary network interface of machine "0" not found`)
+
+	err = s.iface.SetPrimary()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.iface.IsPrimary(), jc.IsTrue)
+	primary, err := s.machine.PrimaryNetworkInterface()
+	c.Assert(err, gc.IsNil)
+	c.Assert(primary.Id(), gc.Equals, s.iface.Id())
+
+	// Making another interface primary demotes the first one.
+	iface2, err := s.machine.AddNetworkInterface(state.NetworkInterfaceInfo{
+		MACAddress:    "aa:bb:cc:dd:ee:f1",
+		InterfaceName: "eth1",
+		NetworkName:   "net1",
+	})
+	c.Assert(err, gc.IsNil)
+	err = iface2.SetPrimary()
+	c.Assert(err, gc.IsNil)
+	c.Assert(iface2.IsPrimary(), jc.IsTrue)
+	err = s.iface.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.iface.IsPrimary(), jc.IsFalse)
+	primary, err = s.machine.PrimaryNetworkInterface()
+	c.Assert(err, gc.IsNil)
+	c.Assert(primary.Id(), gc.Equals, iface2.Id())
+
+	// Setting it again is fine.
+	err = iface2.SetPrimary()
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *NetworkInterfaceSuite) TestSetPrimaryConcurrently(c *gc.C) {
+	iface2, err := s.machine.AddNetworkInterface(state.NetworkInterfaceInfo{
+		MACAddress:    "aa:bb:cc:dd:ee:f1",
+		InterfaceName: "eth1",
+		NetworkName:   "net1",
+	})
+	c.Assert(err, gc.IsNil)
+
+	defer state.SetBeforeHooks(c, s.State, func() {
+		err := iface2.SetPrimary()
+		c.Assert(err, gc.IsNil)
+	}).Check()
+
+	err = s.iface.SetPrimary()
+	c.Assert(err, gc.IsNil)
+	err = iface2.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(iface2.IsPrimary(), jc.IsFalse)
+	primary, err := s.machine.PrimaryNetworkInterface()
+	c.Assert(err, gc.IsNil)
+	c.Assert(primary.Id(), gc.Equals, s.iface.Id())
+}
+
+func (s *NetworkInterfaceSuite) TestSetPrimaryDeadMachine(c *gc.C) {
+	err := s.machine.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = s.iface.SetPrimary()
+	c.Assert(err, gc.ErrorMatches, `cannot set primary network interface "eth0" on machine "0": machine is dead`)
+}
+
+func (s *NetworkInterfaceSuite) TestSetPrimaryRemoved(c *gc.C) {
+	err := s.iface.Remove()
+	c.Assert(err, gc.IsNil)
+	err = s.iface.SetPrimary()
+	c.Assert(err, gc.ErrorMatches, `cannot set primary network interface "eth0" on machine "0": .*`)
+}
@@ -5,9 +5,13 @@ package state
 
 import (
 	"fmt"
+	"net"
 
+	"github.com/juju/errors"
 	"github.com/juju/names"
+	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
 
 	"github.com/juju/juju/network"
 )
@@ -16,6 +20,8 @@ import (
 type Network struct {
 	st  *State
 	doc networkDoc
+	annotator
+	labeller
 }
 
 // NetworkInfo describes a single network.
@@ -32,6 +38,32 @@ type NetworkInfo struct {
 	// VLANTag needs to be between 1 and 4094 for VLANs and 0 for
 	// normal networks. It's defined by IEEE 802.1Q standard.
 	VLANTag int
+
+	// AvailabilityZone is the availability zone the network
+	// belongs to, if the provider has any.
+	AvailabilityZone string
+}
+
+// validate returns an error if the network cannot be added to state.
+func (args NetworkInfo) validate() error {
+	if args.CIDR != "" {
+		if _, _, err := net.ParseCIDR(args.CIDR); err != nil {
+			return err
+		}
+	}
+	if args.Name == "" {
+		return fmt.Errorf("name must be not empty")
+	}
+	if !names.IsValidNetwork(args.Name) {
+		return fmt.Errorf("invalid name")
+	}
+	if args.ProviderId == "" {
+		return fmt.Errorf("provider id must be not empty")
+	}
+	if args.VLANTag < 0 || args.VLANTag > 4094 {
+		return fmt.Errorf("invalid VLAN tag %d: must be between 0 and 4094", args.VLANTag)
+	}
+	return nil
 }
 
 // networkDoc represents a configured network that a machine can be a
@@ -41,21 +73,58 @@ type networkDoc struct {
 	// included networks.
 	Name string `bson:"_id"`
 
-	ProviderId network.Id
-	CIDR       string
-	VLANTag    int
+	// EnvUUID is the UUID of the environment the network belongs
+	// to. It is empty for networks added before it was recorded.
+	EnvUUID string `bson:"env-uuid,omitempty"`
+
+	// ProviderId is unique among networks, so that networks
+	// discovered by the provider are only recorded once.
+	ProviderId       network.Id
+	CIDR             string
+	VLANTag          int
+	AvailabilityZone string `bson:",omitempty"`
+	Life             Life
 }
 
 func newNetwork(st *State, doc *networkDoc) *Network {
-	return &Network{st, *doc}
+	network := &Network{
+		st:  st,
+		doc: *doc,
+	}
+	network.annotator = annotator{
+		globalKey: network.globalKey(),
+		tag:       network.Tag(),
+		st:        st,
+	}
+	network.labeller = labeller{
+		st:         st,
+		globalKey:  network.globalKey(),
+		tag:        network.Tag(),
+		collection: networksC,
+		id:         doc.Name,
+	}
+	return network
+}
+
+// networkGlobalKey returns the global database key for the network
+// with the given name.
+func networkGlobalKey(name string) string {
+	return "n#" + name
 }
 
-func newNetworkDoc(args NetworkInfo) *networkDoc {
+// globalKey returns the global database key for the network.
+func (n *Network) globalKey() string {
+	return networkGlobalKey(n.doc.Name)
+}
+
+func newNetworkDoc(st *State, args NetworkInfo) *networkDoc {
 	return &networkDoc{
-		Name:       args.Name,
-		ProviderId: args.ProviderId,
-		CIDR:       args.CIDR,
-		VLANTag:    args.VLANTag,
+		Name:             st.docID(args.Name),
+		EnvUUID:          st.environTag.Id(),
+		ProviderId:       args.ProviderId,
+		CIDR:             args.CIDR,
+		VLANTag:          args.VLANTag,
+		AvailabilityZone: args.AvailabilityZone,
 	}
 }
 
@@ -68,7 +137,7 @@ func (n *Network) GoString() string {
 
 // Name returns the network name.
 func (n *Network) Name() string {
-	return n.doc.Name
+	return n.st.localID(n.doc.Name)
 }
 
 // ProviderId returns the provider-specific id of the network.
@@ -78,7 +147,7 @@ func (n *Network) ProviderId() network.Id {
 
 // Tag returns the network tag.
 func (n *Network) Tag() names.Tag {
-	return names.NewNetworkTag(n.doc.Name)
+	return names.NewNetworkTag(n.Name())
 }
 
 // CIDR returns the network CIDR (e.g. 192.168.50.0/24).
@@ -92,12 +161,153 @@ func (n *Network) VLANTag() int {
 	return n.doc.VLANTag
 }
 
+// AvailabilityZone returns the availability zone
+// of the network, if the provider has any.
+func (n *Network) AvailabilityZone() string {
+	return n.doc.AvailabilityZone
+}
+
 // IsVLAN returns whether the network is a VLAN (has tag > 0) or a
 // normal network.
 func (n *Network) IsVLAN() bool {
 	return n.doc.VLANTag > 0
 }
 
+// networkAliveDoc asserts that a network is alive. Networks added
+// before they had a life have no life field, and are alive.
+var networkAliveDoc = bson.D{{"life", bson.D{{"$nin", []Life{Dying, Dead}}}}}
+
+// Life returns whether the network is Alive or Dying. Networks are
+// removed from state rather than becoming Dead.
+func (n *Network) Life() Life {
+	return n.doc.Life
+}
+
+// Refresh refreshes the contents of the network from the underlying
+// state. It returns an error that satisfies errors.IsNotFound if the
+// network has been removed.
+func (n *Network) Refresh() error {
+	networks, closer := n.st.getCollection(networksC)
+	defer closer()
+
+	doc := networkDoc{}
+	err := networks.FindId(n.doc.Name).One(&doc)
+	if err == mgo.ErrNotFound {
+		return errors.NotFoundf("network %q", n.Name())
+	}
+	if err != nil {
+		return fmt.Errorf("cannot refresh network %q: %v", n.Name(), err)
+	}
+	n.doc = doc
+	return nil
+}
+
+// Destroy sets the network to Dying, so that no more network
+// interfaces can be added to it, and schedules its removal. The
+// network is removed by the next cleanup after the last network
+// interface that references it has been removed.
+func (n *Network) Destroy() (err error) {
+	defer errors.Maskf(&err, "cannot destroy network %q", n.Name())
+	if n.doc.Life != Alive {
+		return nil
+	}
+	ops := []txn.Op{{
+		C:      networksC,
+		Id:     n.doc.Name,
+		Assert: networkAliveDoc,
+		Update: bson.D{{"$set", bson.D{{"life", Dying}}}},
+	}, n.st.newCleanupOp(cleanupDyingNetwork, n.doc.Name)}
+	// The only abort conditions in play indicate that the network
+	// is already dying or has been removed.
+	if err := onAbort(n.st.runTransaction(ops), nil); err != nil {
+		return err
+	}
+	n.doc.Life = Dying
+	return nil
+}
+
+// Remove removes the network, its subnets, annotations and labels from
+// state. The network must be Dying and must not be used by any
+// network interface; if it is, Remove returns a NetworkInUseError.
+func (n *Network) Remove() error {
+	if n.doc.Life == Alive {
+		return fmt.Errorf("cannot remove network %q: network is not dying", n.Name())
+	}
+	// Interfaces cannot be added to a network that is not alive,
+	// so the count of interfaces cannot grow past this point.
+	count, err := n.interfaceCount()
+	if err != nil {
+		return fmt.Errorf("cannot remove network %q: %v", n.Name(), err)
+	}
+	if count > 0 {
+		return &NetworkInUseError{NetworkName: n.Name(), InterfaceCount: count}
+	}
+	ops, err := n.removeSubnetsOps()
+	if err != nil {
+		return fmt.Errorf("cannot remove network %q: %v", n.Name(), err)
+	}
+	ops = append(ops, txn.Op{
+		C:      networksC,
+		Id:     n.doc.Name,
+		Assert: bson.D{{"life", Dying}},
+		Remove: true,
+	}, annotationRemoveOp(n.st, n.globalKey()), labelsRemoveOp(n.globalKey()))
+	// The only abort condition in play indicates that the network
+	// has already been removed.
+	if err := onAbort(n.st.runTransaction(ops), nil); err != nil {
+		return fmt.Errorf("cannot remove network %q: %v", n.Name(), err)
+	}
+	return nil
+}
+
+// interfaceCount returns the number of network
+// interfaces that use the network.
+func (n *Network) interfaceCount() (int, error) {
+	networkInterfaces, closer := n.st.getCollection(networkInterfacesC)
+	defer closer()
+
+	return networkInterfaces.Find(bson.D{{"networkname", n.doc.Name}}).Count()
+}
+
+// dyingNetworkCleanupOps returns the operations that schedule the
+// cleanup of those of the named networks that are dying, so that they
+// are removed once their network interfaces have been removed.
+func (st *State) dyingNetworkCleanupOps(networkNames ...string) ([]txn.Op, error) {
+	networks, closer := st.getCollection(networksC)
+	defer closer()
+
+	var ops []txn.Op
+	seen := make(map[string]bool)
+	for _, name := range networkNames {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		n, err := networks.Find(bson.D{{"_id", st.docID(name)}, {"life", Dying}}).Count()
+		if err != nil {
+			return nil, err
+		}
+		if n > 0 {
+			ops = append(ops, st.newCleanupOp(cleanupDyingNetwork, st.docID(name)))
+		}
+	}
+	return ops, nil
+}
+
+type NetworkInUseError struct {
+	NetworkName    string
+	InterfaceCount int
+}
+
+func (e *NetworkInUseError) Error() string {
+	return fmt.Sprintf("cannot remove network %q: still used by %d network interface(s)", e.NetworkName, e.InterfaceCount)
+}
+
+func IsNetworkInUseError(err error) bool {
+	_, ok := err.(*NetworkInUseError)
+	return ok
+}
+
 // Interfaces returns all network interfaces on the network.
 func (n *Network) Interfaces() ([]*NetworkInterface, error) {
 	networkInterfaces, closer := n.st.getCollection(networkInterfacesC)
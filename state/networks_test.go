@@ -4,10 +4,13 @@
 package state_test
 
 import (
+	"github.com/juju/errors"
 	jc "github.com/juju/testing/checkers"
+	"gopkg.in/mgo.v2/bson"
 	gc "launchpad.net/gocheck"
 
 	"github.com/juju/juju/state"
+	statetesting "github.com/juju/juju/state/testing"
 )
 
 type NetworkSuite struct {
@@ -24,9 +27,9 @@ func (s *NetworkSuite) SetUpTest(c *gc.C) {
 	var err error
 	s.machine, err = s.State.AddMachine("quantal", state.JobHostUnits)
 	c.Assert(err, gc.IsNil)
-	s.network, err = s.State.AddNetwork(state.NetworkInfo{"net1", "net1", "0.1.2.3/24", 0})
+	s.network, err = s.State.AddNetwork(state.NetworkInfo{"net1", "net1", "0.1.2.3/24", 0, ""})
 	c.Assert(err, gc.IsNil)
-	s.vlan, err = s.State.AddNetwork(state.NetworkInfo{"vlan", "vlan", "0.1.2.3/30", 42})
+	s.vlan, err = s.State.AddNetwork(state.NetworkInfo{"vlan", "vlan", "0.1.2.3/30", 42, "zone1"})
 	c.Assert(err, gc.IsNil)
 }
 
@@ -39,6 +42,18 @@ func (s *NetworkSuite) TestGetterMethods(c *gc.C) {
 	c.Assert(s.vlan.VLANTag(), gc.Equals, 42)
 	c.Assert(s.network.IsVLAN(), jc.IsFalse)
 	c.Assert(s.vlan.IsVLAN(), jc.IsTrue)
+	c.Assert(s.network.AvailabilityZone(), gc.Equals, "")
+	c.Assert(s.vlan.AvailabilityZone(), gc.Equals, "zone1")
+}
+
+func (s *NetworkSuite) TestNetworkByProviderId(c *gc.C) {
+	network, err := s.State.NetworkByProviderId("vlan")
+	c.Assert(err, gc.IsNil)
+	c.Assert(network, jc.DeepEquals, s.vlan)
+
+	_, err = s.State.NetworkByProviderId("missing")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	c.Assert(err, gc.ErrorMatches, `network with provider id "missing" not found`)
 }
 
 func (s *NetworkSuite) TestInterfaces(c *gc.C) {
@@ -66,3 +81,194 @@ func (s *NetworkSuite) TestInterfaces(c *gc.C) {
 	c.Assert(ifaces, gc.HasLen, 2)
 	c.Assert(ifaces, jc.DeepEquals, []*state.NetworkInterface{iface0, iface1})
 }
+
+func (s *NetworkSuite) addInterface(c *gc.C, name, mac string) *state.NetworkInterface {
+	iface, err := s.machine.AddNetworkInterface(state.NetworkInterfaceInfo{
+		MACAddress:    mac,
+		InterfaceName: name,
+		NetworkName:   "net1",
+	})
+	c.Assert(err, gc.IsNil)
+	return iface
+}
+
+func (s *NetworkSuite) TestNetworkWithoutLifeIsAlive(c *gc.C) {
+	// Networks added before they had a life have no life field.
+	networks := s.MgoSuite.Session.DB("juju").C("networks")
+	err := networks.UpdateId("net1", bson.D{{"$unset", bson.D{{"life", nil}}}})
+	c.Assert(err, gc.IsNil)
+	network, err := s.State.Network("net1")
+	c.Assert(err, gc.IsNil)
+	c.Assert(network.Life(), gc.Equals, state.Alive)
+
+	s.addInterface(c, "eth0", "aa:bb:cc:dd:ee:f0")
+	err = network.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = network.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(network.Life(), gc.Equals, state.Dying)
+}
+
+func (s *NetworkSuite) TestAddInterfaceToDyingNetwork(c *gc.C) {
+	err := s.network.Destroy()
+	c.Assert(err, gc.IsNil)
+	_, err = s.machine.AddNetworkInterface(state.NetworkInterfaceInfo{
+		MACAddress:    "aa:bb:cc:dd:ee:f0",
+		InterfaceName: "eth0",
+		NetworkName:   "net1",
+	})
+	c.Assert(err, gc.ErrorMatches, `cannot add network interface "eth0" to machine "0": network "net1" is not alive`)
+}
+
+func (s *NetworkSuite) TestDestroyUnused(c *gc.C) {
+	c.Assert(s.network.Life(), gc.Equals, state.Alive)
+	err := s.network.Destroy()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.network.Life(), gc.Equals, state.Dying)
+
+	// The network is removed by the cleanup.
+	err = s.State.Cleanup()
+	c.Assert(err, gc.IsNil)
+	err = s.network.Refresh()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	// Destroying it again does nothing.
+	err = s.network.Destroy()
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *NetworkSuite) TestDestroyWaitsForInterfaces(c *gc.C) {
+	iface0 := s.addInterface(c, "eth0", "aa:bb:cc:dd:ee:f0")
+	iface1 := s.addInterface(c, "eth1", "aa:bb:cc:dd:ee:f1")
+	err := s.network.Destroy()
+	c.Assert(err, gc.IsNil)
+
+	// No interface can be added to a dying network.
+	_, err = s.machine.AddNetworkInterface(state.NetworkInterfaceInfo{
+		MACAddress:    "aa:bb:cc:dd:ee:f2",
+		InterfaceName: "eth2",
+		NetworkName:   "net1",
+	})
+	c.Assert(err, gc.ErrorMatches, `cannot add network interface "eth2" to machine "0": network "net1" is not alive`)
+
+	// The network is kept while interfaces use it.
+	err = s.State.Cleanup()
+	c.Assert(err, gc.IsNil)
+	err = s.network.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.network.Life(), gc.Equals, state.Dying)
+
+	err = iface0.Remove()
+	c.Assert(err, gc.IsNil)
+	err = s.State.Cleanup()
+	c.Assert(err, gc.IsNil)
+	err = s.network.Refresh()
+	c.Assert(err, gc.IsNil)
+
+	// Removing the last interface leads to the removal of the network.
+	err = iface1.Remove()
+	c.Assert(err, gc.IsNil)
+	err = s.State.Cleanup()
+	c.Assert(err, gc.IsNil)
+	err = s.network.Refresh()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *NetworkSuite) TestDestroyRemovedWithMachine(c *gc.C) {
+	s.addInterface(c, "eth0", "aa:bb:cc:dd:ee:f0")
+	err := s.network.Destroy()
+	c.Assert(err, gc.IsNil)
+
+	err = s.machine.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = s.machine.Remove()
+	c.Assert(err, gc.IsNil)
+	err = s.State.Cleanup()
+	c.Assert(err, gc.IsNil)
+	err = s.network.Refresh()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *NetworkSuite) TestRemove(c *gc.C) {
+	err := s.network.Remove()
+	c.Assert(err, gc.ErrorMatches, `cannot remove network "net1": network is not dying`)
+
+	iface := s.addInterface(c, "eth0", "aa:bb:cc:dd:ee:f0")
+	err = s.network.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = s.network.Remove()
+	c.Assert(err, jc.Satisfies, state.IsNetworkInUseError)
+	c.Assert(err, gc.ErrorMatches, `cannot remove network "net1": still used by 1 network interface\(s\)`)
+
+	err = iface.Remove()
+	c.Assert(err, gc.IsNil)
+	err = s.network.Remove()
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.Network("net1")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	// Removing it again does nothing.
+	err = s.network.Remove()
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *NetworkSuite) TestWatchNetworks(c *gc.C) {
+	// The initial event holds the existing networks.
+	w := s.State.WatchNetworks()
+	defer statetesting.AssertStop(c, w)
+	wc := statetesting.NewStringsWatcherC(c, s.State, w)
+	wc.AssertChange("net1", "vlan")
+	wc.AssertNoChange()
+
+	// Adding a network is reported.
+	net2, err := s.State.AddNetwork(state.NetworkInfo{"net2", "net2", "0.1.3.0/24", 0, ""})
+	c.Assert(err, gc.IsNil)
+	wc.AssertChange("net2")
+	wc.AssertNoChange()
+
+	// Destroying a network is reported, and so is its removal.
+	iface := s.addInterface(c, "eth0", "aa:bb:cc:dd:ee:f0")
+	wc.AssertNoChange()
+	err = s.network.Destroy()
+	c.Assert(err, gc.IsNil)
+	wc.AssertChange("net1")
+	wc.AssertNoChange()
+	err = iface.Remove()
+	c.Assert(err, gc.IsNil)
+	err = s.State.Cleanup()
+	c.Assert(err, gc.IsNil)
+	wc.AssertChange("net1")
+	wc.AssertNoChange()
+
+	// The same goes for an unused network.
+	err = net2.Destroy()
+	c.Assert(err, gc.IsNil)
+	wc.AssertChange("net2")
+	wc.AssertNoChange()
+	err = s.State.Cleanup()
+	c.Assert(err, gc.IsNil)
+	wc.AssertChange("net2")
+	wc.AssertNoChange()
+
+	statetesting.AssertStop(c, w)
+	wc.AssertClosed()
+}
+
+func (s *NetworkSuite) TestAnnotatorForNetwork(c *gc.C) {
+	testAnnotator(c, func() (state.Annotator, error) {
+		return s.State.Network("net1")
+	})
+}
+
+func (s *NetworkSuite) TestAnnotationRemovalForNetwork(c *gc.C) {
+	annotations := map[string]string{"owner": "netops"}
+	err := s.network.SetAnnotations(annotations)
+	c.Assert(err, gc.IsNil)
+	err = s.network.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = s.network.Remove()
+	c.Assert(err, gc.IsNil)
+	ann, err := s.network.Annotations()
+	c.Assert(err, gc.IsNil)
+	c.Assert(ann, gc.DeepEquals, make(map[string]string))
+}
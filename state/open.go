@@ -5,6 +5,7 @@ package state
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/juju/errors"
 	"github.com/juju/names"
@@ -18,8 +19,6 @@ import (
 	"github.com/juju/juju/mongo"
 	"github.com/juju/juju/replicaset"
 	"github.com/juju/juju/state/api/params"
-	"github.com/juju/juju/state/presence"
-	"github.com/juju/juju/state/watcher"
 )
 
 // Open connects to the server described by the given
@@ -53,7 +52,7 @@ func Open(info *authentication.MongoInfo, opts mongo.DialOpts, policy Policy) (*
 	}
 	session.SetSafe(safe)
 
-	st, err := newState(session, info, policy)
+	st, err := newState(session, info, policy, nil)
 	if err != nil {
 		session.Close()
 		return nil, err
@@ -92,10 +91,11 @@ func Initialize(info *authentication.MongoInfo, cfg *config.Config, opts mongo.D
 		return nil, errors.Errorf("environment uuid was not supplied")
 	}
 	st.environTag = names.NewEnvironTag(uuid)
+	st.serverTag = st.environTag
 	ops := []txn.Op{
 		createConstraintsOp(st, environGlobalKey, constraints.Value{}),
 		createSettingsOp(st, environGlobalKey, cfg.AllAttrs()),
-		createEnvironmentOp(st, cfg.Name(), uuid),
+		createEnvironmentOp(st, cfg.Name(), uuid, ""),
 		{
 			C:      stateServersC,
 			Id:     environGlobalKey,
@@ -130,11 +130,37 @@ var indexes = []struct {
 	{unitsC, []string{"machineid"}, false},
 	// TODO(thumper): schema change to remove this index.
 	{usersC, []string{"name"}, false},
+	{environUsersC, []string{"username"}, false},
 	{networksC, []string{"providerid"}, true},
 	{networkInterfacesC, []string{"interfacename", "machineid"}, true},
-	{networkInterfacesC, []string{"macaddress", "networkname"}, true},
+	{networkInterfacesC, []string{"macaddress", "networkname", "parentinterfacename"}, true},
 	{networkInterfacesC, []string{"networkname"}, false},
 	{networkInterfacesC, []string{"machineid"}, false},
+	{subnetsC, []string{"networkname"}, false},
+	{ipAddressesC, []string{"subnetcidr"}, false},
+	{ipAddressesC, []string{"machineid"}, false},
+	{containerAddressesC, []string{"machineid"}, false},
+	{containerAddressesC, []string{"hostid"}, false},
+	{blockDevicesC, []string{"machineid"}, false},
+	{blockDevicesC, []string{"storageinstanceid"}, false},
+	{storageInstancesC, []string{"owner"}, false},
+	{auditC, []string{"time"}, false},
+	{auditC, []string{"changes.collection", "changes.id"}, false},
+	{machineUtilizationC, []string{"machineid", "time"}, false},
+	{statusHistoryC, []string{"entity", "time"}, false},
+	{statusHistoryC, []string{"time"}, false},
+	{labelsC, []string{"kind", "labels.key", "labels.value"}, false},
+}
+
+// droppedIndexes holds indexes created by earlier versions that
+// have since been replaced, so they are dropped from old databases.
+var droppedIndexes = []struct {
+	collection string
+	key        []string
+}{
+	// Replaced to allow bonds and bridges to share the MAC
+	// address of one of their members.
+	{networkInterfacesC, []string{"macaddress", "networkname"}},
 }
 
 // The capped collection used for transaction logs defaults to 10MB.
@@ -155,6 +181,12 @@ func maybeUnauthorized(err error, msg string) error {
 	return fmt.Errorf("%s: %v", msg, err)
 }
 
+// isIndexNotFound returns whether err reports that
+// an index to be dropped does not exist.
+func isIndexNotFound(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "index not found")
+}
+
 func isUnauthorized(err error) bool {
 	if err == nil {
 		return false
@@ -172,7 +204,10 @@ func isUnauthorized(err error) bool {
 	return false
 }
 
-func newState(session *mgo.Session, mongoInfo *authentication.MongoInfo, policy Policy) (*State, error) {
+// newState returns a state handle using the given session. The handle
+// shares the given transaction log and presence watchers, or starts its
+// own if shared is nil.
+func newState(session *mgo.Session, mongoInfo *authentication.MongoInfo, policy Policy, shared *sharedWatchers) (*State, error) {
 	db := session.DB("juju")
 	pdb := session.DB("presence")
 	admin := session.DB("admin")
@@ -200,6 +235,7 @@ func newState(session *mgo.Session, mongoInfo *authentication.MongoInfo, policy
 		policy:        policy,
 		authenticated: authenticated,
 		db:            db,
+		metricsSink:   discardMetrics{},
 	}
 	log := db.C(txnLogC)
 	logInfo := mgo.CollectionInfo{Capped: true, MaxBytes: logSize}
@@ -215,14 +251,22 @@ func newState(session *mgo.Session, mongoInfo *authentication.MongoInfo, policy
 		return nil, maybeUnauthorized(err, "cannot create transaction collection")
 	}
 
-	st.watcher = watcher.New(log)
-	st.pwatcher = presence.NewWatcher(pdb.C(presenceC))
 	for _, item := range indexes {
 		index := mgo.Index{Key: item.key, Unique: item.unique}
 		if err := db.C(item.collection).EnsureIndex(index); err != nil {
 			return nil, fmt.Errorf("cannot create database index: %v", err)
 		}
 	}
+	for _, item := range droppedIndexes {
+		err := db.C(item.collection).DropIndex(item.key...)
+		if err != nil && !isIndexNotFound(err) {
+			return nil, fmt.Errorf("cannot drop database index: %v", err)
+		}
+	}
+
+	if err := st.loadServerEnvironment(); err != nil {
+		return nil, fmt.Errorf("cannot load state server environment: %v", err)
+	}
 
 	// TODO(rog) delete this when we can assume there are no
 	// pre-1.18 environments running.
@@ -235,9 +279,70 @@ func newState(session *mgo.Session, mongoInfo *authentication.MongoInfo, policy
 	if err := st.createStateServingInfoDoc(); err != nil {
 		return nil, fmt.Errorf("cannot create state serving info document: %v", err)
 	}
+	if shared == nil {
+		shared = newSharedWatchers(st, db)
+	}
+	shared.acquire()
+	st.shared = shared
+	st.watcher = shared.newStateWatcher()
+	st.pwatcher = shared.newPresenceWatcher()
 	return st, nil
 }
 
+// loadServerEnvironment records the tag of the state server
+// environment, which is the environment of the state handle unless
+// it is opened with ForEnviron. It does nothing if state has not
+// been initialized yet.
+func (st *State) loadServerEnvironment() error {
+	environments, closer := st.getCollection(environmentsC)
+	defer closer()
+
+	var doc environmentDoc
+	err := environments.Find(bson.D{{"server-uuid", bson.D{{"$exists", false}}}}).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	st.serverTag = names.NewEnvironTag(doc.UUID)
+	st.environTag = st.serverTag
+	return nil
+}
+
+// ForEnviron returns a new state handle for the environment with
+// the given tag, which must be hosted by the same state server as
+// st. The new handle uses its own connection, and must be closed
+// independently of st. The handles share the watchers of the
+// transaction log and of agent presence.
+func (st *State) ForEnviron(env names.EnvironTag) (*State, error) {
+	session := st.db.Session.Copy()
+	envSt, err := newState(session, st.mongoInfo, st.policy, st.shared)
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	envSt.environTag = env
+	envSt.SetMetricsSink(st.metrics())
+	if _, err := envSt.Environment(); err != nil {
+		envSt.Close()
+		return nil, errors.Annotatef(err, "cannot open environment %q", env.Id())
+	}
+	return envSt, nil
+}
+
+// ForUser returns a new state handle for the environment of st, which
+// changes state on behalf of the given user: its changes are recorded
+// in the audit trail as made by the user. The new handle must be
+// closed independently of st.
+func (st *State) ForUser(user names.UserTag) (*State, error) {
+	userSt, err := st.ForEnviron(st.environTag)
+	if err != nil {
+		return nil, err
+	}
+	userSt.auditTag = user
+	return userSt, nil
+}
+
 // createStateServersDoc creates the state servers document
 // if it does not already exist. This is necessary to cope with
 // legacy environments that have not created the document
@@ -337,8 +442,9 @@ func (st *State) Close() error {
 		err3 = st.allManager.Stop()
 	}
 	st.mu.Unlock()
+	err4 := st.shared.release()
 	st.db.Session.Close()
-	for _, err := range []error{err1, err2, err3} {
+	for _, err := range []error{err1, err2, err3, err4} {
 		if err != nil {
 			return err
 		}
@@ -15,8 +15,6 @@ import (
 	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 	"gopkg.in/mgo.v2/txn"
-
-	"github.com/juju/juju/network"
 )
 
 var portLogger = loggo.GetLogger("juju.state.ports")
@@ -91,9 +89,11 @@ func (p PortRange) String() string {
 
 // portsDoc represents the state of ports opened on machines for networks
 type portsDoc struct {
-	Id       string `bson:"_id"`
-	Ports    []PortRange
-	TxnRevno int64 `bson:"txn-revno"`
+	Id          string `bson:"_id"`
+	MachineId   string
+	NetworkName string
+	Ports       []PortRange
+	TxnRevno    int64 `bson:"txn-revno"`
 }
 
 // Ports represents the state of ports on a machine.
@@ -131,11 +131,29 @@ func (p *Ports) extractPortIdPart(part portIdPart) (string, error) {
 
 // MachineId returns the machine id associated with this port document.
 func (p *Ports) MachineId() (string, error) {
+	machineId, err := p.machineDocId()
+	if err != nil {
+		return "", err
+	}
+	return p.st.localID(machineId), nil
+}
+
+// machineDocId returns the id of the document of the machine
+// associated with this port document.
+func (p *Ports) machineDocId() (string, error) {
+	if p.doc.MachineId != "" {
+		return p.doc.MachineId, nil
+	}
+	// Documents written before the machine id was recorded
+	// only hold it in their id.
 	return p.extractPortIdPart(machineIdPart)
 }
 
 // NetworkName returns the network name associated with this port document.
 func (p *Ports) NetworkName() (string, error) {
+	if p.doc.NetworkName != "" {
+		return p.doc.NetworkName, nil
+	}
 	return p.extractPortIdPart(networkIdPart)
 }
 
@@ -146,7 +164,11 @@ func (p *Ports) OpenPorts(portRange PortRange) error {
 	}
 	ports := Ports{st: p.st, doc: p.doc, new: p.new}
 	buildTxn := func(attempt int) ([]txn.Op, error) {
-		machineId, err := ports.MachineId()
+		machineId, err := ports.machineDocId()
+		if err != nil {
+			return nil, err
+		}
+		networkName, err := ports.NetworkName()
 		if err != nil {
 			return nil, err
 		}
@@ -155,7 +177,7 @@ func (p *Ports) OpenPorts(portRange PortRange) error {
 			if err := ports.Refresh(); errors.IsNotFound(err) {
 				// the ports document no longer exists
 				if !ports.new {
-					return nil, fmt.Errorf("ports document not found for machine %v", machineId)
+					return nil, fmt.Errorf("ports document not found for machine %v on network %v", ports.st.localID(machineId), networkName)
 				}
 			} else if err != nil {
 				return nil, err
@@ -166,18 +188,19 @@ func (p *Ports) OpenPorts(portRange PortRange) error {
 		}
 
 		if !ports.canOpenPorts(portRange) {
-			return nil, fmt.Errorf("cannot open ports %v on machine %v due to conflict", portRange, machineId)
+			return nil, fmt.Errorf("cannot open ports %v on machine %v due to conflict", portRange, ports.st.localID(machineId))
 		}
 
 		// a new ports document being created
 		if ports.new {
 			return addPortsDocOps(ports.st,
 				machineId,
+				networkName,
 				portRange), nil
 		}
 		ops := []txn.Op{{
 			C:      unitsC,
-			Id:     portRange.UnitName,
+			Id:     ports.st.docID(portRange.UnitName),
 			Assert: notDeadDoc,
 		}, {
 			C:      machinesC,
@@ -192,7 +215,7 @@ func (p *Ports) OpenPorts(portRange PortRange) error {
 		return ops, nil
 	}
 	// Run the transaction using the state transaction runner.
-	err := p.st.run(buildTxn)
+	err := p.st.runDiagnosed(buildTxn)
 	if err != nil {
 		return err
 	}
@@ -230,7 +253,7 @@ func (p *Ports) ClosePorts(portRange PortRange) error {
 		}
 		ops := []txn.Op{{
 			C:      unitsC,
-			Id:     portRange.UnitName,
+			Id:     ports.st.docID(portRange.UnitName),
 			Assert: notDeadDoc,
 		}, {
 			C:      openedPortsC,
@@ -240,14 +263,18 @@ func (p *Ports) ClosePorts(portRange PortRange) error {
 		}}
 		return ops, nil
 	}
-	return p.st.run(buildTxn)
+	return p.st.runDiagnosed(buildTxn)
 }
 
 // migratePorts migrates old-style unit ports collection to the ports document.
 func (p *Ports) migratePorts(u *Unit) error {
 	ports := Ports{st: p.st, doc: p.doc, new: p.new}
 	buildTxn := func(attempt int) ([]txn.Op, error) {
-		machineId, err := ports.MachineId()
+		machineId, err := ports.machineDocId()
+		if err != nil {
+			return nil, err
+		}
+		networkName, err := ports.NetworkName()
 		if err != nil {
 			return nil, err
 		}
@@ -256,7 +283,7 @@ func (p *Ports) migratePorts(u *Unit) error {
 			if err := ports.Refresh(); errors.IsNotFound(err) {
 				// the ports document no longer exists
 				if !ports.new {
-					return nil, fmt.Errorf("ports document not found for machine %v", machineId)
+					return nil, fmt.Errorf("ports document not found for machine %v on network %v", ports.st.localID(machineId), networkName)
 				}
 			} else if err != nil {
 				return nil, err
@@ -280,7 +307,7 @@ func (p *Ports) migratePorts(u *Unit) error {
 
 		// a new ports document being created
 		if ports.new {
-			return addPortsDocOps(ports.st, machineId, migratedPorts...), nil
+			return addPortsDocOps(ports.st, machineId, networkName, migratedPorts...), nil
 		}
 
 		// updating existing ports document
@@ -304,7 +331,7 @@ func (p *Ports) migratePorts(u *Unit) error {
 		// once the firewaller no longer depends on the unit ports list.
 		return ops, nil
 	}
-	err := p.st.run(buildTxn)
+	err := p.st.runDiagnosed(buildTxn)
 	if err != nil {
 		return err
 	}
@@ -355,7 +382,7 @@ func (p *Ports) Remove() error {
 		ops := prts.removeOps()
 		return ops, nil
 	}
-	return p.st.run(buildTxn)
+	return p.st.runDiagnosed(buildTxn)
 }
 
 // removeOps returns the ops for removing the ports document from mongo.
@@ -367,12 +394,19 @@ func (p *Ports) removeOps() []txn.Op {
 	}}
 }
 
+// OpenedPortsOnNetwork returns the ports document of the machine for
+// the given network. It returns an error satisfying errors.IsNotFound
+// if no ports have been opened on that network.
+func (m *Machine) OpenedPortsOnNetwork(networkName string) (*Ports, error) {
+	return getPorts(m.st, m.doc.Id, networkName)
+}
+
 // OpenedPorts returns ports documents associated with specified machine.
 func (m *Machine) OpenedPorts(st *State) ([]*Ports, error) {
 	openedPorts, closer := m.st.getCollection(openedPortsC)
 	defer closer()
 
-	idRegex := fmt.Sprintf("m#%s#n#.*", m.Id())
+	idRegex := fmt.Sprintf("^m#%s#n#.*", regexp.QuoteMeta(m.doc.Id))
 	docs := []portsDoc{}
 	err := openedPorts.Find(bson.M{"_id": bson.M{"$regex": idRegex}}).All(&docs)
 	if err != nil {
@@ -385,18 +419,61 @@ func (m *Machine) OpenedPorts(st *State) ([]*Ports, error) {
 	return results, nil
 }
 
+// AddMachineAndNetworkToPortsDocs records the machine id and network
+// name on the ports documents written before they were recorded, when
+// both were only held in the document id.
+func AddMachineAndNetworkToPortsDocs(st *State) error {
+	openedPorts, closer := st.getCollection(openedPortsC)
+	defer closer()
+
+	var ops []txn.Op
+	sel := bson.D{{"machineid", bson.D{{"$exists", false}}}}
+	iter := openedPorts.Find(sel).Iter()
+	var doc portsDoc
+	for iter.Next(&doc) {
+		ports := &Ports{st: st, doc: doc}
+		machineId, err := ports.machineDocId()
+		if err != nil {
+			iter.Close()
+			return errors.Trace(err)
+		}
+		networkName, err := ports.NetworkName()
+		if err != nil {
+			iter.Close()
+			return errors.Trace(err)
+		}
+		ops = append(ops, txn.Op{
+			C:      openedPortsC,
+			Id:     doc.Id,
+			Assert: txn.DocExists,
+			Update: bson.D{{"$set", bson.D{
+				{"machineid", machineId},
+				{"networkname", networkName},
+			}}},
+		})
+	}
+	if err := iter.Close(); err != nil {
+		return errors.Trace(err)
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+	return errors.Trace(st.runTransaction(ops))
+}
+
 // portsDocId generates the id of a ports document given the machine id and network name.
 func portsDocId(machineId string, networkName string) string {
 	return fmt.Sprintf("m#%s#n#%s", machineId, networkName)
 }
 
+// addPortsDocOps returns the operations to create the ports document
+// of the given machine and network, holding the given ports.
 func addPortsDocOps(st *State,
-	// TODO(domas) 2014-07-04 bug #1337804: network id is hard-coded until multiple network support lands.
-	//network string,
 	machineId string,
+	networkName string,
 	ports ...PortRange) []txn.Op {
 
-	id := portsDocId(machineId, network.DefaultPublic)
+	id := portsDocId(machineId, networkName)
 
 	ops := []txn.Op{{
 		C:      machinesC,
@@ -406,29 +483,39 @@ func addPortsDocOps(st *State,
 		C:      openedPortsC,
 		Id:     id,
 		Assert: txn.DocMissing,
-		Insert: portsDoc{Id: id, Ports: ports},
+		Insert: newPortsDoc(machineId, networkName, ports...),
 	}}
 	return ops
 }
 
+// newPortsDoc returns a ports document for the given machine and
+// network.
+func newPortsDoc(machineId string, networkName string, ports ...PortRange) portsDoc {
+	return portsDoc{
+		Id:          portsDocId(machineId, networkName),
+		MachineId:   machineId,
+		NetworkName: networkName,
+		Ports:       ports,
+	}
+}
+
 // getPorts returns the ports document for the specified
 // machine and network.
 func getPorts(st *State,
-	// TODO(domas) 2014-07-04 bug #1337804: network is hardcoded until multiple network support lands.
-	// networkName string,
-	machineId string) (*Ports, error) {
+	machineId string,
+	networkName string) (*Ports, error) {
 	openedPorts, closer := st.getCollection(openedPortsC)
 	defer closer()
 
 	var doc portsDoc
-	id := portsDocId(machineId, network.DefaultPublic)
+	id := portsDocId(st.docID(machineId), networkName)
 	err := openedPorts.FindId(id).One(&doc)
 	if err == mgo.ErrNotFound {
-		return nil, errors.NotFoundf("ports document for machine %v", machineId)
+		return nil, errors.NotFoundf("ports document for machine %v on network %v", st.localID(machineId), networkName)
 	}
 	if err != nil {
-		return nil, fmt.Errorf("cannot retrieve ports document for machine %v: %v",
-			machineId, err)
+		return nil, fmt.Errorf("cannot retrieve ports document for machine %v on network %v: %v",
+			machineId, networkName, err)
 	}
 
 	return &Ports{st, doc, false}, nil
@@ -437,13 +524,11 @@ func getPorts(st *State,
 // getOrCreatePorts attempts to retrieve a ports document
 // and returns a newly created one if it does not exist.
 func getOrCreatePorts(st *State,
-	// Network is hardcoded until multiple network
-	// support lands.
-	//network string,
-	machineId string) (*Ports, error) {
-	ports, err := getPorts(st, machineId)
+	machineId string,
+	networkName string) (*Ports, error) {
+	ports, err := getPorts(st, machineId, networkName)
 	if errors.IsNotFound(err) {
-		doc := portsDoc{Id: portsDocId(machineId, network.DefaultPublic)}
+		doc := newPortsDoc(st.docID(machineId), networkName)
 		ports = &Ports{st, doc, true}
 	} else if err != nil {
 		return nil, err
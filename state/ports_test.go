@@ -4,8 +4,11 @@
 package state_test
 
 import (
+	jc "github.com/juju/testing/checkers"
+	"gopkg.in/mgo.v2/bson"
 	gc "launchpad.net/gocheck"
 
+	"github.com/juju/juju/network"
 	"github.com/juju/juju/state"
 )
 
@@ -33,13 +36,13 @@ func (s *PortsDocSuite) SetUpTest(c *gc.C) {
 	err = s.unit.AssignToMachine(s.machine)
 	c.Assert(err, gc.IsNil)
 
-	s.ports, err = state.GetOrCreatePorts(s.State, s.machine.Id())
+	s.ports, err = state.GetOrCreatePorts(s.State, s.machine.Id(), network.DefaultPublic)
 	c.Assert(err, gc.IsNil)
 	c.Assert(s.ports, gc.NotNil)
 }
 
 func (s *PortsDocSuite) TestCreatePorts(c *gc.C) {
-	ports, err := state.GetOrCreatePorts(s.State, s.machine.Id())
+	ports, err := state.GetOrCreatePorts(s.State, s.machine.Id(), network.DefaultPublic)
 	c.Assert(err, gc.IsNil)
 	c.Assert(ports, gc.NotNil)
 	err = ports.OpenPorts(state.PortRange{
@@ -50,7 +53,7 @@ func (s *PortsDocSuite) TestCreatePorts(c *gc.C) {
 	})
 	c.Assert(err, gc.IsNil)
 
-	ports, err = state.GetPorts(s.State, s.machine.Id())
+	ports, err = state.GetPorts(s.State, s.machine.Id(), network.DefaultPublic)
 	c.Assert(err, gc.IsNil)
 	c.Assert(ports, gc.NotNil)
 
@@ -109,6 +112,105 @@ func (s *PortsDocSuite) TestCloseInvalidRange(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, "no match found for port range: .*")
 }
 
+func (s *PortsDocSuite) TestOpenPortsOnNetworks(c *gc.C) {
+	err := s.unit.OpenPort("tcp", 80)
+	c.Assert(err, gc.IsNil)
+	err = s.unit.OpenPortOnNetwork("net1", "tcp", 8080)
+	c.Assert(err, gc.IsNil)
+	// The same port can be opened on different networks.
+	err = s.unit.OpenPortOnNetwork("net1", "tcp", 80)
+	c.Assert(err, gc.IsNil)
+
+	ports, err := s.machine.OpenedPortsOnNetwork("net1")
+	c.Assert(err, gc.IsNil)
+	machineId, err := ports.MachineId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(machineId, gc.Equals, s.machine.Id())
+	networkName, err := ports.NetworkName()
+	c.Assert(err, gc.IsNil)
+	c.Assert(networkName, gc.Equals, "net1")
+
+	opened, err := s.unit.OpenedPortsOnNetwork("net1")
+	c.Assert(err, gc.IsNil)
+	c.Assert(opened, jc.DeepEquals, []state.PortRange{
+		{UnitName: s.unit.Name(), FromPort: 8080, ToPort: 8080, Protocol: "tcp"},
+		{UnitName: s.unit.Name(), FromPort: 80, ToPort: 80, Protocol: "tcp"},
+	})
+	opened, err = s.unit.OpenedPortsOnNetwork(network.DefaultPublic)
+	c.Assert(err, gc.IsNil)
+	c.Assert(opened, jc.DeepEquals, []state.PortRange{
+		{UnitName: s.unit.Name(), FromPort: 80, ToPort: 80, Protocol: "tcp"},
+	})
+	opened, err = s.unit.OpenedPortsOnNetwork("net2")
+	c.Assert(err, gc.IsNil)
+	c.Assert(opened, gc.HasLen, 0)
+
+	// Only ports on the default public network are reported by
+	// OpenedPorts.
+	c.Assert(s.unit.OpenedPorts(), jc.DeepEquals, []network.Port{{"tcp", 80}})
+
+	err = s.unit.ClosePortOnNetwork("net1", "tcp", 80)
+	c.Assert(err, gc.IsNil)
+	opened, err = s.unit.OpenedPortsOnNetwork("net1")
+	c.Assert(err, gc.IsNil)
+	c.Assert(opened, jc.DeepEquals, []state.PortRange{
+		{UnitName: s.unit.Name(), FromPort: 8080, ToPort: 8080, Protocol: "tcp"},
+	})
+	c.Assert(s.unit.OpenedPorts(), jc.DeepEquals, []network.Port{{"tcp", 80}})
+}
+
+func (s *PortsDocSuite) TestOpenedPortsOnNetworks(c *gc.C) {
+	opened, err := s.unit.OpenedPortsOnNetworks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(opened, gc.HasLen, 0)
+
+	err = s.unit.OpenPort("tcp", 80)
+	c.Assert(err, gc.IsNil)
+	err = s.unit.OpenPortOnNetwork("net1", "tcp", 8080)
+	c.Assert(err, gc.IsNil)
+	err = s.unit.OpenPortOnNetwork("net1", "udp", 53)
+	c.Assert(err, gc.IsNil)
+	err = s.unit.OpenPortOnNetwork("net2", "tcp", 22)
+	c.Assert(err, gc.IsNil)
+	err = s.unit.ClosePortOnNetwork("net2", "tcp", 22)
+	c.Assert(err, gc.IsNil)
+
+	opened, err = s.unit.OpenedPortsOnNetworks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(opened, jc.DeepEquals, map[string][]network.Port{
+		network.DefaultPublic: {{"tcp", 80}},
+		"net1":                {{"tcp", 8080}, {"udp", 53}},
+	})
+}
+
+func (s *PortsDocSuite) TestAddMachineAndNetworkToPortsDocs(c *gc.C) {
+	// Add a ports document as written before the machine id and
+	// network name were recorded.
+	openedPorts := s.MgoSuite.Session.DB("juju").C("openedPorts")
+	err := openedPorts.Insert(bson.D{
+		{"_id", "m#42#n#net1"},
+		{"ports", []state.PortRange{
+			{UnitName: "wordpress/0", FromPort: 80, ToPort: 80, Protocol: "tcp"},
+		}},
+	})
+	c.Assert(err, gc.IsNil)
+	err = s.unit.OpenPort("tcp", 80)
+	c.Assert(err, gc.IsNil)
+
+	err = state.AddMachineAndNetworkToPortsDocs(s.State)
+	c.Assert(err, gc.IsNil)
+	var doc bson.M
+	err = openedPorts.FindId("m#42#n#net1").One(&doc)
+	c.Assert(err, gc.IsNil)
+	c.Assert(doc["machineid"], gc.Equals, "42")
+	c.Assert(doc["networkname"], gc.Equals, "net1")
+	c.Assert(doc["ports"], gc.HasLen, 1)
+
+	// Running it again changes nothing.
+	err = state.AddMachineAndNetworkToPortsDocs(s.State)
+	c.Assert(err, gc.IsNil)
+}
+
 func (s *PortsDocSuite) TestRemovePortsDoc(c *gc.C) {
 	portRange := state.PortRange{
 		FromPort: 100,
@@ -119,7 +221,7 @@ func (s *PortsDocSuite) TestRemovePortsDoc(c *gc.C) {
 	err := s.ports.OpenPorts(portRange)
 	c.Assert(err, gc.IsNil)
 
-	ports, err := state.GetPorts(s.State, s.machine.Id())
+	ports, err := state.GetPorts(s.State, s.machine.Id(), network.DefaultPublic)
 	c.Assert(err, gc.IsNil)
 	c.Assert(ports, gc.NotNil)
 
@@ -131,7 +233,7 @@ func (s *PortsDocSuite) TestRemovePortsDoc(c *gc.C) {
 		c.Assert(err, gc.IsNil)
 	}
 
-	ports, err = state.GetPorts(s.State, s.machine.Id())
+	ports, err = state.GetPorts(s.State, s.machine.Id(), network.DefaultPublic)
 	c.Assert(ports, gc.IsNil)
 	c.Assert(err, gc.ErrorMatches, "ports document for machine .* not found")
 }
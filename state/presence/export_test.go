@@ -12,13 +12,18 @@ func RealTimeSlot() {
 }
 
 func FakePeriod(seconds int64) {
+	timingsMutex.Lock()
 	period = seconds
+	timingsMutex.Unlock()
 }
 
 var realPeriod = period
 
 func RealPeriod() {
+	timingsMutex.Lock()
 	period = realPeriod
+	pingInterval = 0
+	timingsMutex.Unlock()
 }
 
 func FindAllBeings(w *Watcher) (map[int64]beingInfo, error) {
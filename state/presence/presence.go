@@ -210,11 +210,65 @@ func (w *Watcher) Alive(key string) (bool, error) {
 	return alive, nil
 }
 
-// period is the length of each time slot in seconds.
-// It's not a time.Duration because the code is more convenient like
-// this and also because sub-second timings don't work as the slot
-// identifier is an int64 in seconds.
-var period int64 = 30
+var (
+	timingsMutex sync.Mutex // protects period, pingInterval
+
+	// period is the length of each time slot in seconds.
+	// It's not a time.Duration because the code is more convenient like
+	// this and also because sub-second timings don't work as the slot
+	// identifier is an int64 in seconds.
+	period int64 = 30
+
+	// pingInterval is the time between pings. If zero, it is derived
+	// from period so that every slot is pinged at least once.
+	pingInterval time.Duration
+)
+
+// SetTimings changes the interval between pings and the liveness
+// window (the length of each time slot) for every pinger and watcher
+// in this process. A key is considered alive for between one and two
+// windows after its last ping. Running pingers and watchers adopt the
+// new values on their next ping or refresh. If interval is zero, a
+// suitable one is derived from the window.
+//
+// All processes sharing a presence collection must use the same window,
+// or they will disagree about which slots to ping and observe.
+func SetTimings(interval, window time.Duration) error {
+	if window < time.Second || window%time.Second != 0 {
+		return fmt.Errorf("liveness window must be a positive whole number of seconds, got %v", window)
+	}
+	if interval < 0 || interval >= window {
+		return fmt.Errorf("ping interval %v must not be negative and must be shorter than the liveness window %v", interval, window)
+	}
+	timingsMutex.Lock()
+	defer timingsMutex.Unlock()
+	period = int64(window / time.Second)
+	pingInterval = interval
+	return nil
+}
+
+// Timings returns the interval between pings and the liveness window
+// currently in use in this process.
+func Timings() (interval, window time.Duration) {
+	return currentPingInterval(), time.Duration(currentPeriod()) * time.Second
+}
+
+// currentPeriod returns the length of each time slot in seconds.
+func currentPeriod() int64 {
+	timingsMutex.Lock()
+	defer timingsMutex.Unlock()
+	return period
+}
+
+// currentPingInterval returns the time between pings.
+func currentPingInterval() time.Duration {
+	timingsMutex.Lock()
+	defer timingsMutex.Unlock()
+	if pingInterval != 0 {
+		return pingInterval
+	}
+	return time.Duration(float64(period+1)*0.75) * time.Second
+}
 
 // loop implements the main watcher loop.
 func (w *Watcher) loop() error {
@@ -228,7 +282,7 @@ func (w *Watcher) loop() error {
 		case <-w.tomb.Dying():
 			return tomb.ErrDying
 		case <-w.next:
-			w.next = time.After(time.Duration(period) * time.Second)
+			w.next = time.After(time.Duration(currentPeriod()) * time.Second)
 			syncDone := w.syncDone
 			w.syncDone = nil
 			if err := w.sync(); err != nil {
@@ -349,6 +403,7 @@ func (w *Watcher) sync() error {
 		}
 	}
 	slot := timeSlot(time.Now(), w.delta)
+	period := currentPeriod()
 	session := w.pings.Database.Session.Copy()
 	defer session.Close()
 	pings := w.pings.With(session)
@@ -570,7 +625,7 @@ func (p *Pinger) loop() error {
 		select {
 		case <-p.tomb.Dying():
 			return tomb.ErrDying
-		case <-time.After(time.Duration(float64(period+1)*0.75) * time.Second):
+		case <-time.After(currentPingInterval()):
 			if err := p.ping(); err != nil {
 				return err
 			}
@@ -717,6 +772,7 @@ func clockDelta(c *mgo.Collection) (time.Duration, error) {
 // The result of this method may be manipulated for test purposes
 // by fakeTimeSlot and realTimeSlot.
 func timeSlot(now time.Time, delta time.Duration) int64 {
+	period := currentPeriod()
 	fakeMutex.Lock()
 	fake := !fakeNow.IsZero()
 	if fake {
@@ -278,6 +278,36 @@ func (s *PresenceSuite) TestWatchPeriod(c *gc.C) {
 	assertChange(c, ch, presence.Change{"a", true})
 }
 
+func (s *PresenceSuite) TestSetTimings(c *gc.C) {
+	err := presence.SetTimings(5*time.Second, 10*time.Second)
+	c.Assert(err, gc.IsNil)
+	interval, window := presence.Timings()
+	c.Assert(interval, gc.Equals, 5*time.Second)
+	c.Assert(window, gc.Equals, 10*time.Second)
+
+	// A zero interval is derived from the window.
+	err = presence.SetTimings(0, 3*time.Second)
+	c.Assert(err, gc.IsNil)
+	interval, window = presence.Timings()
+	c.Assert(interval, gc.Equals, 3*time.Second)
+	c.Assert(window, gc.Equals, 3*time.Second)
+}
+
+func (s *PresenceSuite) TestSetTimingsInvalid(c *gc.C) {
+	err := presence.SetTimings(0, 500*time.Millisecond)
+	c.Assert(err, gc.ErrorMatches, "liveness window must be a positive whole number of seconds, got 500ms")
+	err = presence.SetTimings(0, 1500*time.Millisecond)
+	c.Assert(err, gc.ErrorMatches, "liveness window must be a positive whole number of seconds, got 1.5s")
+	err = presence.SetTimings(10*time.Second, 10*time.Second)
+	c.Assert(err, gc.ErrorMatches, "ping interval 10s must not be negative and must be shorter than the liveness window 10s")
+	err = presence.SetTimings(-time.Second, 10*time.Second)
+	c.Assert(err, gc.ErrorMatches, "ping interval -1s must not be negative and must be shorter than the liveness window 10s")
+
+	// The existing timings are unchanged.
+	_, window := presence.Timings()
+	c.Assert(window, gc.Equals, 30*time.Second)
+}
+
 func (s *PresenceSuite) TestWatchUnwatchOnQueue(c *gc.C) {
 	w := presence.NewWatcher(s.presence)
 	ch := make(chan presence.Change)
@@ -0,0 +1,132 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// The states of completed transactions, as recorded
+// by mgo/txn in the "s" field of transaction documents.
+const (
+	txnAborted = 5
+	txnApplied = 6
+)
+
+// pruneBatchSize is the number of transactions removed at once.
+const pruneBatchSize = 1000
+
+// PruneTransactions removes the completed transactions started before
+// the given time from the transactions collection. Transactions still
+// named in the transaction queue of any document, including those of
+// the documents stashed by mgo/txn, are kept, as are the transactions
+// still pending, so that they can be resumed. The transaction log needs
+// no pruning, as it is a capped collection.
+func (st *State) PruneTransactions(before time.Time) (err error) {
+	defer errors.Maskf(&err, "cannot prune transactions")
+	// Completed transactions are only ever removed from queues, so
+	// the references must be found before the transactions to prune.
+	referenced, err := st.referencedTransactions()
+	if err != nil {
+		return err
+	}
+	txns := st.db.C(txnsC)
+	iter := txns.Find(bson.D{
+		{"_id", bson.D{{"$lt", bson.NewObjectIdWithTime(before)}}},
+		{"s", bson.D{{"$in", []int{txnAborted, txnApplied}}}},
+	}).Select(bson.D{{"_id", 1}}).Iter()
+	var doc struct {
+		Id bson.ObjectId `bson:"_id"`
+	}
+	var ids []bson.ObjectId
+	for iter.Next(&doc) {
+		if referenced[doc.Id] {
+			continue
+		}
+		ids = append(ids, doc.Id)
+		if len(ids) == pruneBatchSize {
+			if err := removeTransactions(txns, ids); err != nil {
+				iter.Close()
+				return err
+			}
+			ids = ids[:0]
+		}
+	}
+	if err := iter.Close(); err != nil {
+		return err
+	}
+	return removeTransactions(txns, ids)
+}
+
+func removeTransactions(txns *mgo.Collection, ids []bson.ObjectId) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := txns.RemoveAll(bson.D{{"_id", bson.D{{"$in", ids}}}})
+	return err
+}
+
+// referencedTransactions returns the ids of the transactions named in
+// the transaction queues of the documents of all the collections of
+// the database, including the stash of mgo/txn.
+func (st *State) referencedTransactions() (map[bson.ObjectId]bool, error) {
+	names, err := st.db.CollectionNames()
+	if err != nil {
+		return nil, err
+	}
+	referenced := make(map[bson.ObjectId]bool)
+	for _, name := range names {
+		if name == txnsC || name == txnLogC || strings.HasPrefix(name, "system.") {
+			continue
+		}
+		iter := st.db.C(name).Find(
+			bson.D{{"txn-queue.0", bson.D{{"$exists", true}}}},
+		).Select(bson.D{{"txn-queue", 1}}).Iter()
+		var doc struct {
+			Queue []string `bson:"txn-queue"`
+		}
+		for iter.Next(&doc) {
+			for _, token := range doc.Queue {
+				// Tokens are made of the transaction
+				// id and a nonce, joined by "_".
+				if i := strings.Index(token, "_"); i > 0 && bson.IsObjectIdHex(token[:i]) {
+					referenced[bson.ObjectIdHex(token[:i])] = true
+				}
+			}
+		}
+		if err := iter.Close(); err != nil {
+			return nil, fmt.Errorf("cannot read transaction queues of %s: %v", name, err)
+		}
+	}
+	return referenced, nil
+}
+
+// PruneStatusHistory removes the status history entries recorded
+// before the given time and, for each machine and unit, the entries
+// beyond the newest maxEntries.
+func (st *State) PruneStatusHistory(before time.Time, maxEntries int) error {
+	history, closer := st.getCollection(statusHistoryC)
+	defer closer()
+
+	_, err := history.RemoveAll(bson.D{{"time", bson.D{{"$lt", before.UTC()}}}})
+	if err != nil {
+		return fmt.Errorf("cannot prune status history: %v", err)
+	}
+	var entities []string
+	if err := history.Find(nil).Distinct("entity", &entities); err != nil {
+		return fmt.Errorf("cannot prune status history: %v", err)
+	}
+	for _, entity := range entities {
+		if err := pruneEntityStatusHistory(history, entity, maxEntries); err != nil {
+			return fmt.Errorf("cannot prune status history of %s: %v", entity, err)
+		}
+	}
+	return nil
+}
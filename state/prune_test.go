@@ -0,0 +1,126 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"time"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/params"
+)
+
+type PruneSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&PruneSuite{})
+
+func (s *PruneSuite) TestPruneTransactions(c *gc.C) {
+	_, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	db := s.MgoSuite.Session.DB("juju")
+	txns := db.C("txns")
+	count, err := txns.Count()
+	c.Assert(err, gc.IsNil)
+	c.Assert(count, gc.Not(gc.Equals), 0)
+
+	// Transactions started after the given time are kept.
+	err = s.State.PruneTransactions(time.Now().Add(-time.Hour))
+	c.Assert(err, gc.IsNil)
+	pruned, err := txns.Count()
+	c.Assert(err, gc.IsNil)
+	c.Assert(pruned, gc.Equals, count)
+
+	// Pending transactions are kept, as are completed transactions
+	// still named in a transaction queue, including that of a
+	// stashed document.
+	started := time.Now().Add(-time.Hour)
+	pendingId := bson.NewObjectIdWithTime(started)
+	queuedId := bson.NewObjectIdWithTime(started)
+	stashedId := bson.NewObjectIdWithTime(started)
+	completedId := bson.NewObjectIdWithTime(started)
+	err = txns.Insert(
+		bson.D{{"_id", pendingId}, {"s", 2}},
+		bson.D{{"_id", queuedId}, {"s", 6}},
+		bson.D{{"_id", stashedId}, {"s", 5}},
+		bson.D{{"_id", completedId}, {"s", 6}},
+	)
+	c.Assert(err, gc.IsNil)
+	queuedToken := queuedId.Hex() + "_0123abcd"
+	err = db.C("machines").UpdateId("0", bson.D{{"$push", bson.D{{"txn-queue", queuedToken}}}})
+	c.Assert(err, gc.IsNil)
+	stashedKey := bson.D{{"c", "machines"}, {"id", "42"}}
+	err = db.C("txns.stash").Insert(bson.D{
+		{"_id", stashedKey},
+		{"txn-queue", []string{stashedId.Hex() + "_4567cdef"}},
+	})
+	c.Assert(err, gc.IsNil)
+
+	err = s.State.PruneTransactions(time.Now().Add(time.Second))
+	c.Assert(err, gc.IsNil)
+	assertTxnExists(c, txns, pendingId, true)
+	assertTxnExists(c, txns, queuedId, true)
+	assertTxnExists(c, txns, stashedId, true)
+	assertTxnExists(c, txns, completedId, false)
+
+	// Once no longer queued, completed transactions are pruned.
+	err = db.C("machines").UpdateId("0", bson.D{{"$pull", bson.D{{"txn-queue", queuedToken}}}})
+	c.Assert(err, gc.IsNil)
+	err = db.C("txns.stash").RemoveId(stashedKey)
+	c.Assert(err, gc.IsNil)
+	err = s.State.PruneTransactions(time.Now().Add(time.Second))
+	c.Assert(err, gc.IsNil)
+	assertTxnExists(c, txns, pendingId, true)
+	assertTxnExists(c, txns, queuedId, false)
+	assertTxnExists(c, txns, stashedId, false)
+	err = txns.RemoveId(pendingId)
+	c.Assert(err, gc.IsNil)
+
+	// State keeps working without the pruned transactions.
+	_, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+}
+
+func assertTxnExists(c *gc.C, txns *mgo.Collection, id bson.ObjectId, exists bool) {
+	count, err := txns.FindId(id).Count()
+	c.Assert(err, gc.IsNil)
+	c.Assert(count == 1, gc.Equals, exists)
+}
+
+func (s *PruneSuite) TestPruneStatusHistory(c *gc.C) {
+	var machines []*state.Machine
+	for i := 0; i < 2; i++ {
+		machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+		c.Assert(err, gc.IsNil)
+		for _, info := range []string{"one", "two", "three", "four"} {
+			err = machine.SetStatus(params.StatusError, info, nil)
+			c.Assert(err, gc.IsNil)
+		}
+		machines = append(machines, machine)
+	}
+
+	// The newest entries of each machine are kept up to the limit.
+	err := s.State.PruneStatusHistory(time.Now().Add(-time.Hour), 2)
+	c.Assert(err, gc.IsNil)
+	for _, machine := range machines {
+		entries, err := s.State.StatusHistory(machine.Tag())
+		c.Assert(err, gc.IsNil)
+		c.Assert(entries, gc.HasLen, 2)
+		c.Assert(entries[0].Info, gc.Equals, "four")
+		c.Assert(entries[1].Info, gc.Equals, "three")
+	}
+
+	// Entries recorded before the given time are removed.
+	err = s.State.PruneStatusHistory(time.Now().Add(time.Second), 10)
+	c.Assert(err, gc.IsNil)
+	for _, machine := range machines {
+		entries, err := s.State.StatusHistory(machine.Tag())
+		c.Assert(err, gc.IsNil)
+		c.Assert(entries, gc.HasLen, 0)
+	}
+}
@@ -42,6 +42,15 @@ type relationDoc struct {
 	Endpoints []Endpoint
 	Life      Life
 	UnitCount int
+
+	// EnvUUID is the UUID of the environment the relation belongs
+	// to. It is empty for relations added before environments were
+	// recorded on them, which belong to the state server environment.
+	EnvUUID string `bson:"env-uuid,omitempty"`
+
+	// Networks maps the names of the services whose endpoints are
+	// bound to a network to the name of that network.
+	Networks map[string]string `bson:",omitempty"`
 }
 
 // Relation represents a relation between one or two service endpoints.
@@ -58,12 +67,12 @@ func newRelation(st *State, doc *relationDoc) *Relation {
 }
 
 func (r *Relation) String() string {
-	return r.doc.Key
+	return r.st.localID(r.doc.Key)
 }
 
 // Tag returns a name identifying the relation.
 func (r *Relation) Tag() names.Tag {
-	return names.NewRelationTag(r.doc.Key)
+	return names.NewRelationTag(r.String())
 }
 
 // Refresh refreshes the contents of the relation from the underlying
@@ -130,7 +139,7 @@ func (r *Relation) Destroy() (err error) {
 		}
 		return ops, nil
 	}
-	return rel.st.run(buildTxn)
+	return rel.st.runDiagnosed(buildTxn)
 }
 
 var errAlreadyDying = stderrors.New("entity is already dying and cannot be destroyed")
@@ -176,6 +185,7 @@ func (r *Relation) removeOps(ignoreService string, departingUnit *Unit) ([]txn.O
 		relOp.Assert = bson.D{{"life", Alive}, {"unitcount", 0}}
 	}
 	ops := []txn.Op{relOp}
+	ignoreService = r.st.localID(ignoreService)
 	for _, ep := range r.doc.Endpoints {
 		if ep.ServiceName == ignoreService {
 			continue
@@ -199,7 +209,7 @@ func (r *Relation) removeOps(ignoreService string, departingUnit *Unit) ([]txn.O
 
 			svc := &Service{st: r.st}
 			hasLastRef := bson.D{{"life", Dying}, {"unitcount", 0}, {"relationcount", 1}}
-			removable := append(bson.D{{"_id", ep.ServiceName}}, hasLastRef...)
+			removable := append(bson.D{{"_id", r.st.docID(ep.ServiceName)}}, hasLastRef...)
 			if err := services.Find(removable).One(&svc.doc); err == nil {
 				ops = append(ops, svc.removeOps(hasLastRef)...)
 				continue
@@ -216,7 +226,7 @@ func (r *Relation) removeOps(ignoreService string, departingUnit *Unit) ([]txn.O
 		}
 		ops = append(ops, txn.Op{
 			C:      servicesC,
-			Id:     ep.ServiceName,
+			Id:     r.st.docID(ep.ServiceName),
 			Assert: asserts,
 			Update: bson.D{{"$inc", bson.D{{"relationcount", -1}}}},
 		})
@@ -236,6 +246,7 @@ func (r *Relation) Id() int {
 // Endpoint returns the endpoint of the relation for the named service.
 // If the service is not part of the relation, an error will be returned.
 func (r *Relation) Endpoint(serviceName string) (Endpoint, error) {
+	serviceName = r.st.localID(serviceName)
 	for _, ep := range r.doc.Endpoints {
 		if ep.ServiceName == serviceName {
 			return ep, nil
@@ -244,6 +255,63 @@ func (r *Relation) Endpoint(serviceName string) (Endpoint, error) {
 	return Endpoint{}, fmt.Errorf("service %q is not a member of %q", serviceName, r)
 }
 
+// EndpointNetwork returns the name of the network the endpoint of the
+// named service is bound to, or an empty string if it is not bound.
+// Units of the service exchange addresses from that network in the
+// relation settings. If the service is not part of the relation, an
+// error will be returned.
+func (r *Relation) EndpointNetwork(serviceName string) (string, error) {
+	if _, err := r.Endpoint(serviceName); err != nil {
+		return "", err
+	}
+	return r.doc.Networks[serviceName], nil
+}
+
+// SetEndpointNetwork binds the endpoint of the named service to the
+// named network, which must be alive. An empty network name unbinds
+// the endpoint. The relation must be alive.
+func (r *Relation) SetEndpointNetwork(serviceName, networkName string) (err error) {
+	defer errors.Maskf(&err, "cannot bind relation %q endpoint of service %q to network %q", r, serviceName, networkName)
+	if _, err := r.Endpoint(serviceName); err != nil {
+		return err
+	}
+	if networkName != "" && !names.IsValidNetwork(networkName) {
+		return fmt.Errorf("invalid network name")
+	}
+	field := "networks." + serviceName
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		op := txn.Op{
+			C:      relationsC,
+			Id:     r.doc.Key,
+			Assert: append(isAliveDoc, bson.DocElem{"id", r.doc.Id}),
+		}
+		if networkName == "" {
+			op.Update = bson.D{{"$unset", bson.D{{field, 1}}}}
+			return []txn.Op{op}, nil
+		}
+		op.Update = bson.D{{"$set", bson.D{{field, networkName}}}}
+		return []txn.Op{{
+			C:      networksC,
+			Id:     r.st.docID(networkName),
+			Assert: networkAliveDoc,
+		}, op}, nil
+	}
+	if err := r.st.runDiagnosed(buildTxn); err != nil {
+		return err
+	}
+	networks := make(map[string]string)
+	for name, network := range r.doc.Networks {
+		networks[name] = network
+	}
+	if networkName == "" {
+		delete(networks, serviceName)
+	} else {
+		networks[serviceName] = networkName
+	}
+	r.doc.Networks = networks
+	return nil
+}
+
 // Endpoints returns the endpoints for the relation.
 func (r *Relation) Endpoints() []Endpoint {
 	return r.doc.Endpoints
@@ -282,7 +350,7 @@ func (r *Relation) Unit(u *Unit) (*RelationUnit, error) {
 		if container == "" {
 			container = u.doc.Name
 		}
-		scope = append(scope, container)
+		scope = append(scope, r.st.localID(container))
 	}
 	return &RelationUnit{
 		st:       r.st,
@@ -282,3 +282,76 @@ func assertOneRelation(c *gc.C, srv *state.Service, relId int, endpoints ...stat
 	c.Assert(eps, gc.DeepEquals, []state.Endpoint{expectEp})
 	return rel
 }
+
+func (s *RelationSuite) TestSetEndpointNetwork(c *gc.C) {
+	_, err := s.State.AddNetwork(state.NetworkInfo{"net1", "net1", "10.0.0.0/16", 0, ""})
+	c.Assert(err, gc.IsNil)
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	eps, err := s.State.InferEndpoints([]string{"wordpress", "mysql"})
+	c.Assert(err, gc.IsNil)
+	rel, err := s.State.AddRelation(eps...)
+	c.Assert(err, gc.IsNil)
+
+	network, err := rel.EndpointNetwork("mysql")
+	c.Assert(err, gc.IsNil)
+	c.Assert(network, gc.Equals, "")
+
+	err = rel.SetEndpointNetwork("mysql", "net1")
+	c.Assert(err, gc.IsNil)
+	network, err = rel.EndpointNetwork("mysql")
+	c.Assert(err, gc.IsNil)
+	c.Assert(network, gc.Equals, "net1")
+
+	// The binding is persisted, and only applies to the one endpoint.
+	rel, err = s.State.Relation(rel.Id())
+	c.Assert(err, gc.IsNil)
+	network, err = rel.EndpointNetwork("mysql")
+	c.Assert(err, gc.IsNil)
+	c.Assert(network, gc.Equals, "net1")
+	network, err = rel.EndpointNetwork("wordpress")
+	c.Assert(err, gc.IsNil)
+	c.Assert(network, gc.Equals, "")
+
+	// Binding to no network removes the binding.
+	err = rel.SetEndpointNetwork("mysql", "")
+	c.Assert(err, gc.IsNil)
+	err = rel.Refresh()
+	c.Assert(err, gc.IsNil)
+	network, err = rel.EndpointNetwork("mysql")
+	c.Assert(err, gc.IsNil)
+	c.Assert(network, gc.Equals, "")
+}
+
+func (s *RelationSuite) TestSetEndpointNetworkErrors(c *gc.C) {
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	eps, err := s.State.InferEndpoints([]string{"wordpress", "mysql"})
+	c.Assert(err, gc.IsNil)
+	rel, err := s.State.AddRelation(eps...)
+	c.Assert(err, gc.IsNil)
+	prefix := `cannot bind relation "wordpress:db mysql:server" endpoint of service "(.*)" to network "(.*)": `
+
+	_, err = rel.EndpointNetwork("riak")
+	c.Assert(err, gc.ErrorMatches, `service "riak" is not a member of "wordpress:db mysql:server"`)
+	err = rel.SetEndpointNetwork("riak", "net1")
+	c.Assert(err, gc.ErrorMatches, prefix+`service "riak" is not a member of "wordpress:db mysql:server"`)
+	err = rel.SetEndpointNetwork("mysql", "bad net")
+	c.Assert(err, gc.ErrorMatches, prefix+"invalid network name")
+	err = rel.SetEndpointNetwork("mysql", "net1")
+	c.Assert(err, gc.ErrorMatches, prefix+"network net1 not found")
+
+	network, err := s.State.AddNetwork(state.NetworkInfo{"net1", "net1", "10.0.0.0/16", 0, ""})
+	c.Assert(err, gc.IsNil)
+	err = network.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = rel.SetEndpointNetwork("mysql", "net1")
+	c.Assert(err, gc.ErrorMatches, prefix+"network net1 is dying")
+
+	_, err = s.State.AddNetwork(state.NetworkInfo{"net2", "net2", "10.1.0.0/16", 0, ""})
+	c.Assert(err, gc.IsNil)
+	err = rel.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = rel.SetEndpointNetwork("mysql", "net2")
+	c.Assert(err, gc.ErrorMatches, prefix+"relation wordpress:db mysql:server not found")
+}
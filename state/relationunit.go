@@ -209,7 +209,7 @@ func (ru *RelationUnit) subordinateOps() ([]txn.Op, string, error) {
 		return nil, "", fmt.Errorf("expected single related endpoint, got %v", related)
 	}
 	serviceName, unitName := related[0].ServiceName, ru.unit.doc.Name
-	selSubordinate := bson.D{{"service", serviceName}, {"principal", unitName}}
+	selSubordinate := bson.D{{"service", ru.st.docID(serviceName)}, {"principal", unitName}}
 	var lDoc lifeDoc
 	if err := units.Find(selSubordinate).One(&lDoc); err == mgo.ErrNotFound {
 		service, err := ru.st.Service(serviceName)
@@ -333,7 +333,7 @@ func (ru *RelationUnit) LeaveScope() error {
 		}
 		return ops, nil
 	}
-	if err = ru.st.run(buildTxn); err != nil {
+	if err = ru.st.runDiagnosed(buildTxn); err != nil {
 		return fmt.Errorf("cannot leave scope for %s: %v", desc, err)
 	}
 	return nil
@@ -4,7 +4,11 @@
 package state
 
 import (
+	"fmt"
+
+	"github.com/juju/names"
 	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
 	"gopkg.in/mgo.v2/txn"
 )
 
@@ -14,22 +18,62 @@ import (
 type requestedNetworksDoc struct {
 	Id       string   `bson:"_id"`
 	Networks []string `bson:"networks"`
+	Exclude  []string `bson:"exclude,omitempty"`
 }
 
-func newRequestedNetworksDoc(networks []string) *requestedNetworksDoc {
-	return &requestedNetworksDoc{Networks: networks}
+func newRequestedNetworksDoc(networks, exclude []string) *requestedNetworksDoc {
+	return &requestedNetworksDoc{Networks: networks, Exclude: exclude}
 }
 
-func createRequestedNetworksOp(st *State, id string, networks []string) txn.Op {
+func createRequestedNetworksOp(st *State, id string, networks, exclude []string) txn.Op {
 	return txn.Op{
 		C:      requestedNetworksC,
 		Id:     id,
 		Assert: txn.DocMissing,
-		Insert: newRequestedNetworksDoc(networks),
+		Insert: newRequestedNetworksDoc(networks, exclude),
+	}
+}
+
+// setRequestedNetworksOp returns an operation replacing the networks
+// requested for the entity with the given global key. Legacy entities
+// may have no document yet, in which case exists is false and the
+// document is created.
+func setRequestedNetworksOp(st *State, id string, exists bool, networks, exclude []string) txn.Op {
+	if !exists {
+		return createRequestedNetworksOp(st, id, networks, exclude)
+	}
+	return txn.Op{
+		C:      requestedNetworksC,
+		Id:     id,
+		Assert: txn.DocExists,
+		Update: bson.D{{"$set", bson.D{
+			{"networks", networks},
+			{"exclude", exclude},
+		}}},
 	}
 }
 
-// While networks are immutable, there is no setNetworksOp function.
+// validateRequestedNetworks checks that the given networks to
+// include and exclude have valid names, and that no network is
+// both included and excluded.
+func validateRequestedNetworks(include, exclude []string) error {
+	included := make(map[string]bool)
+	for _, name := range include {
+		if !names.IsValidNetwork(name) {
+			return fmt.Errorf("invalid network name %q", name)
+		}
+		included[name] = true
+	}
+	for _, name := range exclude {
+		if !names.IsValidNetwork(name) {
+			return fmt.Errorf("invalid network name %q", name)
+		}
+		if included[name] {
+			return fmt.Errorf("network %q is both included and excluded", name)
+		}
+	}
+	return nil
+}
 
 func removeRequestedNetworksOp(st *State, id string) txn.Op {
 	return txn.Op{
@@ -40,6 +84,13 @@ func removeRequestedNetworksOp(st *State, id string) txn.Op {
 }
 
 func readRequestedNetworks(st *State, id string) ([]string, error) {
+	doc, _, err := readRequestedNetworksDoc(st, id)
+	return doc.Networks, err
+}
+
+// readRequestedNetworksDoc returns the requested networks document
+// with the given id, and whether it exists.
+func readRequestedNetworksDoc(st *State, id string) (requestedNetworksDoc, bool, error) {
 	requestedNetworks, closer := st.getCollection(requestedNetworksC)
 	defer closer()
 
@@ -50,7 +101,10 @@ func readRequestedNetworks(st *State, id string) ([]string, error) {
 		// service or machine we create, but in legacy databases this
 		// is not the case. We ignore the error here for
 		// backwards-compatibility.
-		return nil, nil
+		return requestedNetworksDoc{}, false, nil
 	}
-	return doc.Networks, err
+	if err != nil {
+		return requestedNetworksDoc{}, false, err
+	}
+	return doc, true, nil
 }
@@ -0,0 +1,236 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/juju/charm"
+	"github.com/juju/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+)
+
+// CharmResource describes a resource declared by a charm.
+type CharmResource struct {
+	// Name identifies the resource within the charm.
+	Name string
+
+	// Type holds the kind of the resource, such as "file".
+	Type string
+
+	// Hash holds the expected hash of the resource content.
+	Hash string
+}
+
+// ResourceRevision records the revision of a resource
+// used by a service or unit.
+type ResourceRevision struct {
+	Revision int
+	Hash     string
+}
+
+// charmResourcesDoc records the resources declared by a charm.
+// The document ID field is the charm URL.
+type charmResourcesDoc struct {
+	URL       string `bson:"_id"`
+	Resources []CharmResource
+}
+
+// resourceRevisionsDoc records the revisions of resources used
+// by a service or unit, keyed by resource name. The document ID
+// field is the globalKey of the service or unit.
+type resourceRevisionsDoc struct {
+	Id        string `bson:"_id"`
+	Resources map[string]ResourceRevision
+}
+
+var validResourceName = regexp.MustCompile("^[a-z][a-z0-9]*(-[a-z0-9]+)*$")
+
+type resourcesByName []CharmResource
+
+func (r resourcesByName) Len() int           { return len(r) }
+func (r resourcesByName) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+func (r resourcesByName) Less(i, j int) bool { return r[i].Name < r[j].Name }
+
+// SetCharmResources records the resources declared by the charm with
+// the given URL, which must have been added. Like the charm itself,
+// the declared resources cannot be changed once set.
+func (st *State) SetCharmResources(curl *charm.URL, resources []CharmResource) (err error) {
+	defer errors.Maskf(&err, "cannot set resources of charm %q", curl)
+	seen := make(map[string]bool)
+	for _, res := range resources {
+		if !validResourceName.MatchString(res.Name) {
+			return fmt.Errorf("invalid resource name %q", res.Name)
+		}
+		if seen[res.Name] {
+			return fmt.Errorf("duplicate resource %q", res.Name)
+		}
+		seen[res.Name] = true
+		if res.Type == "" {
+			return fmt.Errorf("resource %q has no type", res.Name)
+		}
+	}
+	sorted := make([]CharmResource, len(resources))
+	copy(sorted, resources)
+	sort.Sort(resourcesByName(sorted))
+	ops := []txn.Op{{
+		C:      charmsC,
+		Id:     curl.String(),
+		Assert: txn.DocExists,
+	}, {
+		C:      charmResourcesC,
+		Id:     curl.String(),
+		Assert: txn.DocMissing,
+		Insert: &charmResourcesDoc{
+			URL:       curl.String(),
+			Resources: sorted,
+		},
+	}}
+	err = st.runTransaction(ops)
+	if err == txn.ErrAborted {
+		if cause := st.abortCause(ops); cause != nil {
+			return cause
+		}
+	}
+	return err
+}
+
+// CharmResources returns the resources declared by the charm with
+// the given URL, ordered by name.
+func (st *State) CharmResources(curl *charm.URL) ([]CharmResource, error) {
+	charmResources, closer := st.getCollection(charmResourcesC)
+	defer closer()
+
+	var doc charmResourcesDoc
+	err := charmResources.FindId(curl.String()).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get resources of charm %q: %v", curl, err)
+	}
+	return doc.Resources, nil
+}
+
+// ResourceRevisions returns the revisions of the resources
+// currently used by the service, keyed by resource name.
+func (s *Service) ResourceRevisions() (map[string]ResourceRevision, error) {
+	return readResourceRevisions(s.st, s.globalKey())
+}
+
+// SetResourceRevision records that the service uses the given
+// revision of the named resource, which must be declared by the
+// service's charm. The service must be alive.
+func (s *Service) SetResourceRevision(name string, rev ResourceRevision) (err error) {
+	defer errors.Maskf(&err, "cannot set revision of resource %q for service %q", name, s)
+	assertOp := txn.Op{
+		C:      servicesC,
+		Id:     s.doc.Name,
+		Assert: append(isAliveDoc, bson.DocElem{"charmurl", s.doc.CharmURL}),
+	}
+	return setResourceRevision(s.st, s.globalKey(), s.doc.CharmURL, assertOp, name, rev)
+}
+
+// ResourceRevisions returns the revisions of the resources
+// currently used by the unit, keyed by resource name.
+func (u *Unit) ResourceRevisions() (map[string]ResourceRevision, error) {
+	return readResourceRevisions(u.st, u.globalKey())
+}
+
+// SetResourceRevision records that the unit uses the given revision
+// of the named resource, which must be declared by the unit's charm,
+// or by its service's charm if the unit has not yet set its own. The
+// unit must be alive.
+func (u *Unit) SetResourceRevision(name string, rev ResourceRevision) (err error) {
+	defer errors.Maskf(&err, "cannot set revision of resource %q for unit %q", name, u)
+	curl, ok := u.CharmURL()
+	if !ok {
+		svc, err := u.Service()
+		if err != nil {
+			return err
+		}
+		curl, _ = svc.CharmURL()
+	}
+	assertOp := txn.Op{
+		C:      unitsC,
+		Id:     u.doc.Name,
+		Assert: append(isAliveDoc, bson.DocElem{"charmurl", u.doc.CharmURL}),
+	}
+	return setResourceRevision(u.st, u.globalKey(), curl, assertOp, name, rev)
+}
+
+// setResourceRevision records the revision of the named resource for
+// the entity with the given global key, running the given operation
+// asserting the state of the entity in the same transaction.
+func setResourceRevision(st *State, key string, curl *charm.URL, assertOp txn.Op, name string, rev ResourceRevision) error {
+	if rev.Revision < 0 {
+		return fmt.Errorf("invalid revision %d", rev.Revision)
+	}
+	declared, err := st.CharmResources(curl)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, res := range declared {
+		if res.Name == name {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return errors.NotFoundf("resource %q of charm %q", name, curl)
+	}
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		revisions, closer := st.getCollection(resourceRevisionsC)
+		defer closer()
+
+		count, err := revisions.FindId(key).Count()
+		if err != nil {
+			return nil, err
+		}
+		op := txn.Op{
+			C:  resourceRevisionsC,
+			Id: key,
+		}
+		if count == 0 {
+			op.Assert = txn.DocMissing
+			op.Insert = &resourceRevisionsDoc{
+				Id:        key,
+				Resources: map[string]ResourceRevision{name: rev},
+			}
+		} else {
+			op.Assert = txn.DocExists
+			op.Update = bson.D{{"$set", bson.D{{"resources." + name, rev}}}}
+		}
+		return []txn.Op{assertOp, op}, nil
+	}
+	return st.runDiagnosed(buildTxn)
+}
+
+func readResourceRevisions(st *State, key string) (map[string]ResourceRevision, error) {
+	revisions, closer := st.getCollection(resourceRevisionsC)
+	defer closer()
+
+	var doc resourceRevisionsDoc
+	err := revisions.FindId(key).One(&doc)
+	if err == mgo.ErrNotFound {
+		return map[string]ResourceRevision{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get resource revisions: %v", err)
+	}
+	return doc.Resources, nil
+}
+
+func removeResourceRevisionsOp(key string) txn.Op {
+	return txn.Op{
+		C:      resourceRevisionsC,
+		Id:     key,
+		Remove: true,
+	}
+}
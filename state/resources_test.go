@@ -0,0 +1,163 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"github.com/juju/charm"
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+)
+
+type ResourcesSuite struct {
+	ConnSuite
+	charm   *state.Charm
+	service *state.Service
+}
+
+var _ = gc.Suite(&ResourcesSuite{})
+
+func (s *ResourcesSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	s.charm = s.AddTestingCharm(c, "wordpress")
+	s.service = s.AddTestingService(c, "wordpress", s.charm)
+}
+
+func (s *ResourcesSuite) setResources(c *gc.C) {
+	err := s.State.SetCharmResources(s.charm.URL(), []state.CharmResource{
+		{Name: "theme", Type: "file", Hash: "deadbeef"},
+		{Name: "plugins", Type: "file"},
+	})
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *ResourcesSuite) TestCharmResources(c *gc.C) {
+	resources, err := s.State.CharmResources(s.charm.URL())
+	c.Assert(err, gc.IsNil)
+	c.Assert(resources, gc.HasLen, 0)
+
+	s.setResources(c)
+	resources, err = s.State.CharmResources(s.charm.URL())
+	c.Assert(err, gc.IsNil)
+	c.Assert(resources, jc.DeepEquals, []state.CharmResource{
+		{Name: "plugins", Type: "file"},
+		{Name: "theme", Type: "file", Hash: "deadbeef"},
+	})
+
+	// Declared resources cannot be changed.
+	err = s.State.SetCharmResources(s.charm.URL(), nil)
+	c.Assert(err, gc.ErrorMatches, `cannot set resources of charm ".*": document .* in charmresources already exists`)
+}
+
+func (s *ResourcesSuite) TestSetCharmResourcesErrors(c *gc.C) {
+	curl := s.charm.URL()
+	for i, test := range []struct {
+		resources []state.CharmResource
+		err       string
+	}{{
+		resources: []state.CharmResource{{Name: "Theme", Type: "file"}},
+		err:       `invalid resource name "Theme"`,
+	}, {
+		resources: []state.CharmResource{{Name: "theme", Type: "file"}, {Name: "theme", Type: "file"}},
+		err:       `duplicate resource "theme"`,
+	}, {
+		resources: []state.CharmResource{{Name: "theme"}},
+		err:       `resource "theme" has no type`,
+	}} {
+		c.Logf("test %d", i)
+		err := s.State.SetCharmResources(curl, test.resources)
+		c.Check(err, gc.ErrorMatches, `cannot set resources of charm ".*": `+test.err)
+	}
+
+	missing := charm.MustParseURL("cs:quantal/missing-1")
+	err := s.State.SetCharmResources(missing, []state.CharmResource{{Name: "theme", Type: "file"}})
+	c.Assert(err, gc.ErrorMatches, `cannot set resources of charm "cs:quantal/missing-1": charm cs:quantal/missing-1 not found`)
+}
+
+func (s *ResourcesSuite) TestServiceResourceRevisions(c *gc.C) {
+	s.setResources(c)
+	revisions, err := s.service.ResourceRevisions()
+	c.Assert(err, gc.IsNil)
+	c.Assert(revisions, gc.HasLen, 0)
+
+	err = s.service.SetResourceRevision("theme", state.ResourceRevision{Revision: 1, Hash: "deadbeef"})
+	c.Assert(err, gc.IsNil)
+	err = s.service.SetResourceRevision("plugins", state.ResourceRevision{Revision: 3, Hash: "cafe"})
+	c.Assert(err, gc.IsNil)
+	err = s.service.SetResourceRevision("theme", state.ResourceRevision{Revision: 2, Hash: "f00d"})
+	c.Assert(err, gc.IsNil)
+	revisions, err = s.service.ResourceRevisions()
+	c.Assert(err, gc.IsNil)
+	c.Assert(revisions, jc.DeepEquals, map[string]state.ResourceRevision{
+		"theme":   {Revision: 2, Hash: "f00d"},
+		"plugins": {Revision: 3, Hash: "cafe"},
+	})
+}
+
+func (s *ResourcesSuite) TestSetResourceRevisionErrors(c *gc.C) {
+	s.setResources(c)
+	err := s.service.SetResourceRevision("logo", state.ResourceRevision{Revision: 1})
+	c.Assert(err, gc.ErrorMatches, `cannot set revision of resource "logo" for service "wordpress": resource "logo" of charm ".*" not found`)
+	err = s.service.SetResourceRevision("theme", state.ResourceRevision{Revision: -1})
+	c.Assert(err, gc.ErrorMatches, `cannot set revision of resource "theme" for service "wordpress": invalid revision -1`)
+
+	err = s.service.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = s.service.SetResourceRevision("theme", state.ResourceRevision{Revision: 1})
+	c.Assert(err, gc.ErrorMatches, `cannot set revision of resource "theme" for service "wordpress": service wordpress not found`)
+}
+
+func (s *ResourcesSuite) TestUnitResourceRevisions(c *gc.C) {
+	s.setResources(c)
+	unit, err := s.service.AddUnit()
+	c.Assert(err, gc.IsNil)
+
+	err = unit.SetResourceRevision("theme", state.ResourceRevision{Revision: 1, Hash: "deadbeef"})
+	c.Assert(err, gc.IsNil)
+	revisions, err := unit.ResourceRevisions()
+	c.Assert(err, gc.IsNil)
+	c.Assert(revisions, jc.DeepEquals, map[string]state.ResourceRevision{
+		"theme": {Revision: 1, Hash: "deadbeef"},
+	})
+	revisions, err = s.service.ResourceRevisions()
+	c.Assert(err, gc.IsNil)
+	c.Assert(revisions, gc.HasLen, 0)
+
+	err = unit.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = unit.SetResourceRevision("theme", state.ResourceRevision{Revision: 2})
+	c.Assert(err, gc.ErrorMatches, `cannot set revision of resource "theme" for unit "wordpress/0": unit wordpress/0 not found`)
+}
+
+func (s *ResourcesSuite) TestResourceRevisionsRemovedWithEntities(c *gc.C) {
+	s.setResources(c)
+	unit, err := s.service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit.SetResourceRevision("theme", state.ResourceRevision{Revision: 1})
+	c.Assert(err, gc.IsNil)
+	err = s.service.SetResourceRevision("theme", state.ResourceRevision{Revision: 1})
+	c.Assert(err, gc.IsNil)
+
+	err = s.service.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = unit.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = unit.Remove()
+	c.Assert(err, gc.IsNil)
+	err = s.service.Refresh()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	// Revisions do not survive into a new service of the same name.
+	s.service = s.AddTestingService(c, "wordpress", s.charm)
+	revisions, err := s.service.ResourceRevisions()
+	c.Assert(err, gc.IsNil)
+	c.Assert(revisions, gc.HasLen, 0)
+	unit, err = s.service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	revisions, err = unit.ResourceRevisions()
+	c.Assert(err, gc.IsNil)
+	c.Assert(revisions, gc.HasLen, 0)
+}
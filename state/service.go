@@ -9,6 +9,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/juju/charm"
 	"github.com/juju/errors"
@@ -19,6 +20,7 @@ import (
 	"gopkg.in/mgo.v2/txn"
 
 	"github.com/juju/juju/constraints"
+	"github.com/juju/juju/network"
 	"github.com/juju/juju/state/api/params"
 )
 
@@ -27,12 +29,14 @@ type Service struct {
 	st  *State
 	doc serviceDoc
 	annotator
+	labeller
 }
 
 // serviceDoc represents the internal state of a service in MongoDB.
 // Note the correspondence with ServiceInfo in state/api/params.
 type serviceDoc struct {
 	Name          string `bson:"_id"`
+	EnvUUID       string `bson:"env-uuid,omitempty"`
 	Series        string
 	Subordinate   bool
 	CharmURL      *charm.URL
@@ -42,9 +46,34 @@ type serviceDoc struct {
 	UnitCount     int
 	RelationCount int
 	Exposed       bool
+	ExposedPorts  []network.Port `bson:",omitempty"`
 	MinUnits      int
 	OwnerTag      string
-	TxnRevno      int64 `bson:"txn-revno"`
+	Deployment    *DeploymentRecord `bson:",omitempty"`
+	TxnRevno      int64             `bson:"txn-revno"`
+}
+
+// DeploymentRecord records the provenance of a service:
+// who deployed it, when, and from where.
+type DeploymentRecord struct {
+	// User holds the tag of the user that deployed the service.
+	User string
+
+	// ClientHost holds the name of the host the service
+	// was deployed from, as reported by the client.
+	ClientHost string
+
+	// CharmURL and CharmSha256 identify the charm the
+	// service was deployed with, and the digest of its bundle.
+	CharmURL    string
+	CharmSha256 string
+
+	// Bundle holds the name of the bundle the service was
+	// deployed as part of, if any.
+	Bundle string
+
+	// Deployed holds the time the service was deployed.
+	Deployed time.Time
 }
 
 func newService(st *State, doc *serviceDoc) *Service {
@@ -57,12 +86,19 @@ func newService(st *State, doc *serviceDoc) *Service {
 		tag:       svc.Tag(),
 		st:        st,
 	}
+	svc.labeller = labeller{
+		st:         st,
+		globalKey:  svc.globalKey(),
+		tag:        svc.Tag(),
+		collection: servicesC,
+		id:         doc.Name,
+	}
 	return svc
 }
 
 // Name returns the service name.
 func (s *Service) Name() string {
-	return s.doc.Name
+	return s.st.localID(s.doc.Name)
 }
 
 // Tag returns a name identifying the service.
@@ -131,7 +167,7 @@ func (s *Service) Destroy() (err error) {
 		}
 		return nil, jujutxn.ErrTransientFailure
 	}
-	return s.st.run(buildTxn)
+	return s.st.runDiagnosed(buildTxn)
 }
 
 // destroyOps returns the operations required to destroy the service. If it
@@ -154,7 +190,7 @@ func (s *Service) destroyOps() ([]txn.Op, error) {
 	ops := []txn.Op{minUnitsRemoveOp(s.st, s.doc.Name)}
 	removeCount := 0
 	for _, rel := range rels {
-		relOps, isRemove, err := rel.destroyOps(s.doc.Name)
+		relOps, isRemove, err := rel.destroyOps(s.Name())
 		if err == errAlreadyDying {
 			relOps = []txn.Op{{
 				C:      relationsC,
@@ -196,6 +232,11 @@ func (s *Service) destroyOps() ([]txn.Op, error) {
 	} else {
 		notLastRefs = append(notLastRefs, bson.D{{"unitcount", 0}}...)
 	}
+	// Any relations that could not be removed directly are now dying, and
+	// will be removed once all their units have left scope.
+	if s.doc.RelationCount > removeCount {
+		ops = append(ops, s.st.newCleanupOp(cleanupRelationsForDyingService, s.doc.Name))
+	}
 	update := bson.D{{"$set", bson.D{{"life", Dying}}}}
 	if removeCount != 0 {
 		decref := bson.D{{"$inc", bson.D{{"relationcount", -removeCount}}}}
@@ -225,9 +266,15 @@ func (s *Service) removeOps(asserts bson.D) []txn.Op {
 		C:      settingsC,
 		Id:     s.settingsKey(),
 		Remove: true,
+	}, {
+		C:      leasesC,
+		Id:     leadershipLeaseName(s.doc.Name),
+		Remove: true,
 	}}
 	ops = append(ops, removeRequestedNetworksOp(s.st, s.globalKey()))
 	ops = append(ops, removeConstraintsOp(s.st, s.globalKey()))
+	ops = append(ops, removeResourceRevisionsOp(s.globalKey()))
+	ops = append(ops, labelsRemoveOp(s.globalKey()))
 	return append(ops, annotationRemoveOp(s.st, s.globalKey()))
 }
 
@@ -238,32 +285,76 @@ func (s *Service) IsExposed() bool {
 	return s.doc.Exposed
 }
 
-// SetExposed marks the service as exposed.
+// ExposedPorts returns the ports that are accessible from outside the
+// local deployment network when the service is exposed. If it returns
+// nil, all the ports opened by the service's units are exposed.
+func (s *Service) ExposedPorts() []network.Port {
+	ports := make([]network.Port, len(s.doc.ExposedPorts))
+	copy(ports, s.doc.ExposedPorts)
+	if len(ports) == 0 {
+		return nil
+	}
+	return ports
+}
+
+// SetExposed marks the service as exposed, with all the ports
+// opened by its units accessible.
 // See ClearExposed and IsExposed.
 func (s *Service) SetExposed() error {
-	return s.setExposed(true)
+	return s.setExposed(true, nil)
+}
+
+// SetExposedPorts marks the service as exposed, with only the given
+// ports accessible if they are opened by its units. If no ports are
+// given, all opened ports are accessible, as for SetExposed.
+func (s *Service) SetExposedPorts(ports []network.Port) error {
+	return s.setExposed(true, ports)
 }
 
 // ClearExposed removes the exposed flag from the service.
 // See SetExposed and IsExposed.
 func (s *Service) ClearExposed() error {
-	return s.setExposed(false)
+	return s.setExposed(false, nil)
 }
 
-func (s *Service) setExposed(exposed bool) (err error) {
+func (s *Service) setExposed(exposed bool, ports []network.Port) (err error) {
+	var update bson.D
+	if len(ports) > 0 {
+		ports = append([]network.Port(nil), ports...)
+		network.SortPorts(ports)
+		update = bson.D{{"$set", bson.D{{"exposed", exposed}, {"exposedports", ports}}}}
+	} else {
+		ports = nil
+		update = bson.D{
+			{"$set", bson.D{{"exposed", exposed}}},
+			{"$unset", bson.D{{"exposedports", nil}}},
+		}
+	}
 	ops := []txn.Op{{
 		C:      servicesC,
 		Id:     s.doc.Name,
 		Assert: isAliveDoc,
-		Update: bson.D{{"$set", bson.D{{"exposed", exposed}}}},
+		Update: update,
 	}}
 	if err := s.st.runTransaction(ops); err != nil {
 		return fmt.Errorf("cannot set exposed flag for service %q to %v: %v", s, exposed, onAbort(err, errNotAlive))
 	}
 	s.doc.Exposed = exposed
+	s.doc.ExposedPorts = ports
 	return nil
 }
 
+// DeploymentRecord returns the record of how the service was
+// deployed, or nil if none has been set.
+func (s *Service) DeploymentRecord() *DeploymentRecord {
+	if s.doc.Deployment == nil {
+		return nil
+	}
+	record := *s.doc.Deployment
+	record.Deployed = record.Deployed.UTC()
+	return &record
+}
+
 // Charm returns the service's charm and whether units should upgrade to that
 // charm even if they are in an error state.
 func (s *Service) Charm() (ch *Charm, force bool, err error) {
@@ -295,7 +386,7 @@ func (s *Service) Endpoints() (eps []Endpoint, err error) {
 	collect := func(role charm.RelationRole, rels map[string]charm.Relation) {
 		for _, rel := range rels {
 			eps = append(eps, Endpoint{
-				ServiceName: s.doc.Name,
+				ServiceName: s.Name(),
 				Relation:    rel,
 			})
 		}
@@ -356,7 +447,7 @@ func (s *Service) checkRelationsOps(ch *Charm, relations []*Relation) ([]txn.Op,
 	asserts := make([]txn.Op, 0, len(relations))
 	// All relations must still exist and their endpoints are implemented by the charm.
 	for _, rel := range relations {
-		if ep, err := rel.Endpoint(s.doc.Name); err != nil {
+		if ep, err := rel.Endpoint(s.Name()); err != nil {
 			return nil, err
 		} else if !ep.ImplementedBy(ch) {
 			return nil, fmt.Errorf("cannot upgrade service %q to charm %q: would break relation %q", s, ch, rel)
@@ -483,7 +574,7 @@ func (s *Service) SetCharm(ch *Charm, force bool) (err error) {
 			if alive, err := isAliveWithSession(settings, s.doc.Name); err != nil {
 				return nil, err
 			} else if !alive {
-				return nil, fmt.Errorf("service %q is not alive", s.doc.Name)
+				return nil, fmt.Errorf("service %q is not alive", s)
 			}
 		}
 		// Make sure the service doesn't have this charm already.
@@ -509,7 +600,7 @@ func (s *Service) SetCharm(ch *Charm, force bool) (err error) {
 		}
 		return ops, nil
 	}
-	if err = s.st.run(buildTxn); err == nil {
+	if err = s.st.runDiagnosed(buildTxn); err == nil {
 		s.doc.CharmURL = ch.URL()
 		s.doc.ForceCharm = force
 		return nil
@@ -519,7 +610,7 @@ func (s *Service) SetCharm(ch *Charm, force bool) (err error) {
 
 // String returns the service name.
 func (s *Service) String() string {
-	return s.doc.Name
+	return s.Name()
 }
 
 // Refresh refreshes the contents of the Service from the underlying
@@ -570,9 +661,13 @@ func (s *Service) addUnitOps(principalName string, asserts bson.D) (string, []tx
 	if err != nil {
 		return "", nil, err
 	}
+	if principalName != "" {
+		principalName = s.st.docID(principalName)
+	}
 	globalKey := unitGlobalKey(name)
 	udoc := &unitDoc{
 		Name:      name,
+		EnvUUID:   s.doc.EnvUUID,
 		Service:   s.doc.Name,
 		Series:    s.doc.Series,
 		Life:      Alive,
@@ -675,8 +770,17 @@ func (s *Service) removeUnitOps(u *Unit, asserts bson.D) ([]txn.Op, error) {
 		removeConstraintsOp(s.st, u.globalKey()),
 		removeStatusOp(s.st, u.globalKey()),
 		annotationRemoveOp(s.st, u.globalKey()),
+		removeResourceRevisionsOp(u.globalKey()),
 		s.st.newCleanupOp(cleanupRemovedUnit, u.doc.Name),
 	)
+	if s.doc.Life == Dying && !s.doc.Subordinate {
+		// The unit's container is destroyed along with it, if possible,
+		// but its host will need to be destroyed once the container has
+		// been removed.
+		if parentId := ParentId(u.doc.MachineId); parentId != "" {
+			ops = append(ops, s.st.newCleanupOp(cleanupOrphanedMachine, parentId))
+		}
+	}
 	if u.doc.CharmURL != nil {
 		decOps, err := settingsDecRefOps(s.st, s.doc.Name, u.doc.CharmURL)
 		if errors.IsNotFound(err) {
@@ -713,6 +817,7 @@ func (s *Service) removeUnitOps(u *Unit, asserts bson.D) ([]txn.Op, error) {
 
 // Unit returns the service's unit with name.
 func (s *Service) Unit(name string) (*Unit, error) {
+	name = s.st.localID(name)
 	if !names.IsValidUnit(name) {
 		return nil, fmt.Errorf("%q is not a valid unit name", name)
 	}
@@ -721,9 +826,9 @@ func (s *Service) Unit(name string) (*Unit, error) {
 	defer closer()
 
 	udoc := &unitDoc{}
-	sel := bson.D{{"_id", name}, {"service", s.doc.Name}}
+	sel := bson.D{{"_id", s.st.docID(name)}, {"service", s.doc.Name}}
 	if err := units.Find(sel).One(udoc); err != nil {
-		return nil, fmt.Errorf("cannot get unit %q from service %q: %v", name, s.doc.Name, err)
+		return nil, fmt.Errorf("cannot get unit %q from service %q: %v", name, s, err)
 	}
 	return newUnit(s.st, udoc), nil
 }
@@ -740,7 +845,7 @@ func allUnits(st *State, service string) (units []*Unit, err error) {
 	docs := []unitDoc{}
 	err = unitsCollection.Find(bson.D{{"service", service}}).All(&docs)
 	if err != nil {
-		return nil, fmt.Errorf("cannot get all units from service %q: %v", service, err)
+		return nil, fmt.Errorf("cannot get all units from service %q: %v", st.localID(service), err)
 	}
 	for i := range docs {
 		units = append(units, newUnit(st, &docs[i]))
@@ -754,12 +859,14 @@ func (s *Service) Relations() (relations []*Relation, err error) {
 }
 
 func serviceRelations(st *State, name string) (relations []*Relation, err error) {
+	name = st.localID(name)
 	defer errors.Maskf(&err, "can't get relations for service %q", name)
 	relationsCollection, closer := st.getCollection(relationsC)
 	defer closer()
 
 	docs := []relationDoc{}
-	err = relationsCollection.Find(bson.D{{"endpoints.servicename", name}}).All(&docs)
+	sel := append(bson.D{{"endpoints.servicename", name}}, st.environSelector()...)
+	err = relationsCollection.Find(sel).All(&docs)
 	if err != nil {
 		return nil, err
 	}
@@ -819,6 +926,17 @@ func (s *Service) Constraints() (constraints.Value, error) {
 	return readConstraints(s.st, s.globalKey())
 }
 
+// EffectiveConstraints returns the service constraints merged with the
+// environment constraints, as they are used for the machines added for
+// the service's units.
+func (s *Service) EffectiveConstraints() (constraints.Value, error) {
+	cons, err := s.Constraints()
+	if err != nil {
+		return constraints.Value{}, err
+	}
+	return s.st.resolveConstraints(cons)
+}
+
 // SetConstraints replaces the current service constraints.
 func (s *Service) SetConstraints(cons constraints.Value) (err error) {
 	unsupported, err := s.st.validateConstraints(cons)
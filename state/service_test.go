@@ -6,6 +6,7 @@ package state_test
 import (
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/juju/charm"
 	"github.com/juju/errors"
@@ -16,6 +17,7 @@ import (
 
 	"github.com/juju/juju/constraints"
 	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/network"
 	"github.com/juju/juju/state"
 	"github.com/juju/juju/state/testing"
 )
@@ -767,6 +769,61 @@ func (s *ServiceSuite) TestServiceExposed(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, notAliveErr)
 }
 
+func (s *ServiceSuite) TestServiceExposedPorts(c *gc.C) {
+	c.Assert(s.mysql.ExposedPorts(), gc.IsNil)
+
+	err := s.mysql.SetExposedPorts([]network.Port{{"tcp", 443}, {"tcp", 80}})
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.mysql.IsExposed(), gc.Equals, true)
+	expected := []network.Port{{"tcp", 80}, {"tcp", 443}}
+	c.Assert(s.mysql.ExposedPorts(), jc.DeepEquals, expected)
+
+	// The ports are stored with the service.
+	svc, err := s.State.Service("mysql")
+	c.Assert(err, gc.IsNil)
+	c.Assert(svc.IsExposed(), gc.Equals, true)
+	c.Assert(svc.ExposedPorts(), jc.DeepEquals, expected)
+
+	// Exposing the service without ports exposes all of them.
+	err = s.mysql.SetExposed()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.mysql.ExposedPorts(), gc.IsNil)
+	err = svc.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(svc.ExposedPorts(), gc.IsNil)
+
+	// Unexposing the service forgets the ports.
+	err = s.mysql.SetExposedPorts([]network.Port{{"udp", 53}})
+	c.Assert(err, gc.IsNil)
+	err = s.mysql.ClearExposed()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.mysql.IsExposed(), gc.Equals, false)
+	c.Assert(s.mysql.ExposedPorts(), gc.IsNil)
+	err = svc.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(svc.ExposedPorts(), gc.IsNil)
+}
+
+func (s *ServiceSuite) TestDeploymentRecord(c *gc.C) {
+	c.Assert(s.mysql.DeploymentRecord(), gc.IsNil)
+
+	record := state.DeploymentRecord{
+		User:        "user-admin",
+		ClientHost:  "laptop",
+		CharmURL:    s.charm.URL().String(),
+		CharmSha256: s.charm.BundleSha256(),
+		Bundle:      "wiki",
+		Deployed:    time.Date(2014, 9, 1, 12, 30, 0, 0, time.UTC),
+	}
+	wordpress, err := s.State.AddDeployedService("wordpress", "user-admin", s.charm, nil, record)
+	c.Assert(err, gc.IsNil)
+	c.Assert(wordpress.DeploymentRecord(), jc.DeepEquals, &record)
+
+	svc, err := s.State.Service("wordpress")
+	c.Assert(err, gc.IsNil)
+	c.Assert(svc.DeploymentRecord(), jc.DeepEquals, &record)
+}
+
 func (s *ServiceSuite) TestAddUnit(c *gc.C) {
 	// Check that principal units can be added on their own.
 	unitZero, err := s.mysql.AddUnit()
@@ -1185,6 +1242,17 @@ func (s *ServiceSuite) TestConstraints(c *gc.C) {
 	c.Assert(&cons6, jc.Satisfies, constraints.IsEmpty)
 }
 
+func (s *ServiceSuite) TestEffectiveConstraints(c *gc.C) {
+	err := s.State.SetEnvironConstraints(constraints.MustParse("mem=8G arch=amd64 cpu-cores=4"))
+	c.Assert(err, gc.IsNil)
+	err = s.mysql.SetConstraints(constraints.MustParse("instance-type=foo cpu-cores=2"))
+	c.Assert(err, gc.IsNil)
+	cons, err := s.mysql.EffectiveConstraints()
+	c.Assert(err, gc.IsNil)
+	// mem conflicts with instance-type, so it is discarded.
+	c.Assert(cons, gc.DeepEquals, constraints.MustParse("arch=amd64 cpu-cores=2 instance-type=foo"))
+}
+
 func (s *ServiceSuite) TestSetInvalidConstraints(c *gc.C) {
 	cons := constraints.MustParse("mem=4G instance-type=foo")
 	err := s.mysql.SetConstraints(cons)
@@ -0,0 +1,194 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"sync"
+
+	"gopkg.in/mgo.v2"
+	"launchpad.net/tomb"
+
+	"github.com/juju/juju/state/presence"
+	"github.com/juju/juju/state/watcher"
+)
+
+// sharedWatchers tails the transaction log and the presence collection
+// on behalf of all the state handles opened from the same state server
+// connection, so that neither the handles of the hosted environments
+// nor those the API server opens for each connection tail them again.
+// The logical watchers of every handle register their own channels and
+// filters with them. They use their own session, and are stopped once
+// the last handle using them is closed.
+type sharedWatchers struct {
+	txnLog   *watcher.Watcher
+	presence *presence.Watcher
+	session  *mgo.Session
+
+	// mu guards refs.
+	mu   sync.Mutex
+	refs int
+}
+
+// newSharedWatchers returns the watchers for the state handle st and
+// the handles opened from it, which report the depth of the queue of
+// the transaction log watcher to the metrics sink of st.
+func newSharedWatchers(st *State, db *mgo.Database) *sharedWatchers {
+	session := db.Session.Copy()
+	reportQueueDepth := func(depth int) {
+		st.metrics().SetGauge(MetricWatcherQueueDepth, int64(depth))
+	}
+	return &sharedWatchers{
+		txnLog:   watcher.NewReporting(db.With(session).C(txnLogC), reportQueueDepth),
+		presence: presence.NewWatcher(session.DB("presence").C(presenceC)),
+		session:  session,
+	}
+}
+
+// acquire records that a state handle uses the watchers.
+func (w *sharedWatchers) acquire() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.refs++
+}
+
+// release stops the watchers if they are
+// no longer used by any state handle.
+func (w *sharedWatchers) release() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.refs--
+	if w.refs > 0 {
+		return nil
+	}
+	err := w.txnLog.Stop()
+	if perr := w.presence.Stop(); err == nil {
+		err = perr
+	}
+	w.session.Close()
+	return err
+}
+
+// newStateWatcher returns a view of the shared transaction
+// log watcher for the watchers of a state handle.
+func (w *sharedWatchers) newStateWatcher() *stateWatcher {
+	return &stateWatcher{
+		Watcher: w.txnLog,
+		view:    newWatcherView(w.txnLog),
+	}
+}
+
+// newPresenceWatcher returns a view of the shared presence
+// watcher for the presence watchers of a state handle.
+func (w *sharedWatchers) newPresenceWatcher() *presenceWatcher {
+	return &presenceWatcher{
+		Watcher: w.presence,
+		view:    newWatcherView(w.presence),
+	}
+}
+
+// stateWatcher is the view of the shared transaction log watcher
+// used by the watchers of a single state handle.
+type stateWatcher struct {
+	*watcher.Watcher
+	view *watcherView
+}
+
+// Dead returns a channel that is closed when the state handle
+// has been closed or the shared watcher has stopped.
+func (sw *stateWatcher) Dead() <-chan struct{} {
+	return sw.view.Dead()
+}
+
+// Err returns the error with which the shared watcher stopped, nil
+// if the state handle was closed, or tomb.ErrStillAlive if neither
+// has happened yet.
+func (sw *stateWatcher) Err() error {
+	return sw.view.Err()
+}
+
+// Stop stops the view. It does not stop the shared watcher.
+func (sw *stateWatcher) Stop() error {
+	return sw.view.stop()
+}
+
+// presenceWatcher is the view of the shared presence watcher
+// used by the presence watchers of a single state handle.
+type presenceWatcher struct {
+	*presence.Watcher
+	view *watcherView
+}
+
+// Dead returns a channel that is closed when the state handle
+// has been closed or the shared watcher has stopped.
+func (pw *presenceWatcher) Dead() <-chan struct{} {
+	return pw.view.Dead()
+}
+
+// Err returns the error with which the shared watcher stopped, nil
+// if the state handle was closed, or tomb.ErrStillAlive if neither
+// has happened yet.
+func (pw *presenceWatcher) Err() error {
+	return pw.view.Err()
+}
+
+// Stop stops the view. It does not stop the shared watcher.
+func (pw *presenceWatcher) Stop() error {
+	return pw.view.stop()
+}
+
+// watcherView tracks the life of a shared watcher as seen by a single
+// state handle. It dies when the handle is closed or when the shared
+// watcher fails, so that closing a handle still stops the watchers
+// obtained from it.
+type watcherView struct {
+	once    sync.Once
+	closing chan struct{}
+	dead    chan struct{}
+
+	// err holds the error the shared watcher failed with.
+	// It is set before dead is closed.
+	err error
+}
+
+func newWatcherView(shared interface {
+	Dead() <-chan struct{}
+	Err() error
+}) *watcherView {
+	v := &watcherView{
+		closing: make(chan struct{}),
+		dead:    make(chan struct{}),
+	}
+	go func() {
+		select {
+		case <-shared.Dead():
+			v.err = shared.Err()
+		case <-v.closing:
+		}
+		close(v.dead)
+	}()
+	return v
+}
+
+func (v *watcherView) Dead() <-chan struct{} {
+	return v.dead
+}
+
+func (v *watcherView) Err() error {
+	select {
+	case <-v.dead:
+		return v.err
+	default:
+		return tomb.ErrStillAlive
+	}
+}
+
+// stop kills the view, returning the error the
+// shared watcher failed with, if it did.
+func (v *watcherView) stop() error {
+	v.once.Do(func() {
+		close(v.closing)
+	})
+	<-v.dead
+	return v.err
+}
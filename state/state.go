@@ -8,13 +8,13 @@ package state
 
 import (
 	"fmt"
-	"net"
 	"net/url"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/juju/charm"
 	"github.com/juju/errors"
@@ -30,10 +30,9 @@ import (
 	"github.com/juju/juju/environmentserver/authentication"
 	"github.com/juju/juju/environs/config"
 	"github.com/juju/juju/mongo"
+	"github.com/juju/juju/network"
 	"github.com/juju/juju/state/api/params"
 	"github.com/juju/juju/state/multiwatcher"
-	"github.com/juju/juju/state/presence"
-	"github.com/juju/juju/state/watcher"
 	"github.com/juju/juju/version"
 )
 
@@ -41,31 +40,49 @@ var logger = loggo.GetLogger("juju.state")
 
 const (
 	// The following define the mongo collections used to record the Juju environment state.
-	environmentsC      = "environments"
-	charmsC            = "charms"
-	machinesC          = "machines"
-	containerRefsC     = "containerRefs"
-	instanceDataC      = "instanceData"
-	relationsC         = "relations"
-	relationScopesC    = "relationscopes"
-	servicesC          = "services"
-	requestedNetworksC = "requestednetworks"
-	networksC          = "networks"
-	networkInterfacesC = "networkinterfaces"
-	minUnitsC          = "minunits"
-	settingsC          = "settings"
-	settingsrefsC      = "settingsrefs"
-	constraintsC       = "constraints"
-	unitsC             = "units"
-	actionsC           = "actions"
-	actionresultsC     = "actionresults"
-	usersC             = "users"
-	presenceC          = "presence"
-	cleanupsC          = "cleanups"
-	annotationsC       = "annotations"
-	statusesC          = "statuses"
-	stateServersC      = "stateServers"
-	openedPortsC       = "openedPorts"
+	environmentsC       = "environments"
+	charmsC             = "charms"
+	machinesC           = "machines"
+	containerRefsC      = "containerRefs"
+	instanceDataC       = "instanceData"
+	relationsC          = "relations"
+	relationScopesC     = "relationscopes"
+	servicesC           = "services"
+	requestedNetworksC  = "requestednetworks"
+	networksC           = "networks"
+	networkInterfacesC  = "networkinterfaces"
+	subnetsC            = "subnets"
+	ipAddressesC        = "ipaddresses"
+	containerAddressesC = "containeraddresses"
+	blockDevicesC       = "blockdevices"
+	storageInstancesC   = "storageinstances"
+	minUnitsC           = "minunits"
+	settingsC           = "settings"
+	settingsrefsC       = "settingsrefs"
+	constraintsC        = "constraints"
+	unitsC              = "units"
+	actionsC            = "actions"
+	actionresultsC      = "actionresults"
+	usersC              = "users"
+	environUsersC       = "environusers"
+	presenceC           = "presence"
+	cleanupsC           = "cleanups"
+	annotationsC        = "annotations"
+	statusesC           = "statuses"
+	stateServersC       = "stateServers"
+	openedPortsC        = "openedPorts"
+	machineUtilizationC = "machineutilization"
+	statusHistoryC      = "statushistory"
+	interfaceSchemasC   = "interfaceschemas"
+	leasesC             = "leases"
+	auditC              = "audit"
+	upgradeStepsC       = "upgradesteps"
+	charmResourcesC     = "charmresources"
+	resourceRevisionsC  = "resourcerevisions"
+	agentVersionsC      = "agentversions"
+	blocksC             = "blocks"
+	charmArchivesC      = "charmarchives"
+	labelsC             = "labels"
 
 	// These collections are used by the mgo transaction runner.
 	txnLogC = "txns.log"
@@ -86,12 +103,23 @@ type State struct {
 	mongoInfo         *authentication.MongoInfo
 	policy            Policy
 	db                *mgo.Database
-	watcher           *watcher.Watcher
-	pwatcher          *presence.Watcher
+	shared            *sharedWatchers
+	watcher           *stateWatcher
+	pwatcher          *presenceWatcher
 	// mu guards allManager.
 	mu         sync.Mutex
 	allManager *multiwatcher.StoreManager
+
+	// metricsMu guards metricsSink.
+	metricsMu   sync.RWMutex
+	metricsSink MetricsSink
+
 	environTag names.EnvironTag
+	serverTag  names.EnvironTag
+	// auditTag holds the tag of the user on whose behalf the state
+	// is changed. Changes are only recorded in the audit trail when
+	// it is set.
+	auditTag names.UserTag
 }
 
 // EnvironTag() returns the environment tag for the environment controlled by
@@ -100,6 +128,12 @@ func (st *State) EnvironTag() names.EnvironTag {
 	return st.environTag
 }
 
+// ServerTag returns the environment tag of the state server
+// environment, which hosts any other environment in state.
+func (st *State) ServerTag() names.EnvironTag {
+	return st.serverTag
+}
+
 // getCollection fetches a named collection using a new session if the
 // database has previously been logged in to.
 // It returns the collection and a closer function for the session.
@@ -138,6 +172,25 @@ func (st *State) MongoSession() *mgo.Session {
 	return st.db.Session
 }
 
+// LastTxnId returns the id of the most recently logged transaction,
+// or an empty string if no transaction has been logged. The value
+// changes whenever a transaction is run against the database.
+func (st *State) LastTxnId() (string, error) {
+	log, closer := st.getCollection(txnLogC)
+	defer closer()
+	var doc struct {
+		Id bson.ObjectId `bson:"_id"`
+	}
+	err := log.Find(nil).Select(bson.D{{"_id", 1}}).Sort("-$natural").One(&doc)
+	if err == mgo.ErrNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("cannot read transaction log: %v", err)
+	}
+	return doc.Id.Hex(), nil
+}
+
 func emptycloser() {}
 
 // txnRunner returns a jujutxn.Runner instance.
@@ -161,17 +214,46 @@ func (st *State) txnRunner() (_ jujutxn.Runner, closer func()) {
 }
 
 // runTransaction is a convenience method delegating to transactionRunner.
+// The changes made by the transaction are recorded in the audit trail.
 func (st *State) runTransaction(ops []txn.Op) error {
 	runner, closer := st.txnRunner()
 	defer closer()
-	return runner.RunTransaction(ops)
+	sink := st.metrics()
+	start := time.Now()
+	sink.IncCounter(MetricTxnAttempts, 1)
+	err := runner.RunTransaction(st.withAuditOp(ops))
+	if err == txn.ErrAborted {
+		sink.IncCounter(MetricTxnAborts, 1)
+	}
+	sink.ObserveDuration(MetricTxnDuration, time.Since(start))
+	return err
 }
 
 // run is a convenience method delegating to transactionRunner.
+// The changes made by each transaction are recorded in the audit trail.
 func (st *State) run(transactions jujutxn.TransactionSource) error {
 	runner, closer := st.txnRunner()
 	defer closer()
-	return runner.Run(transactions)
+	sink := st.metrics()
+	start := time.Now()
+	err := runner.Run(func(attempt int) ([]txn.Op, error) {
+		if attempt > 0 {
+			// The previous attempt was aborted.
+			sink.IncCounter(MetricTxnAborts, 1)
+			sink.IncCounter(MetricTxnRetries, 1)
+		}
+		ops, err := transactions(attempt)
+		if err != nil {
+			return nil, err
+		}
+		sink.IncCounter(MetricTxnAttempts, 1)
+		return st.withAuditOp(ops), nil
+	})
+	if err == jujutxn.ErrExcessiveContention {
+		sink.IncCounter(MetricTxnAborts, 1)
+	}
+	sink.ObserveDuration(MetricTxnDuration, time.Since(start))
+	return err
 }
 
 // ResumeTransactions resumes all pending transactions.
@@ -191,7 +273,7 @@ func (st *State) Watch() *multiwatcher.Watcher {
 }
 
 func (st *State) EnvironConfig() (*config.Config, error) {
-	settings, err := readSettings(st, environGlobalKey)
+	settings, err := readSettings(st, st.environKey())
 	if err != nil {
 		return nil, err
 	}
@@ -250,6 +332,7 @@ func (st *State) checkCanUpgrade(currentVersion, newVersion string) error {
 			{{"tools.version", bson.D{{"$not", bson.RegEx{matchNew, ""}}}}},
 		}}},
 	}}}
+	sel = append(sel, st.environSelector()...)
 	var agentTags []string
 	for _, name := range []string{machinesC, unitsC} {
 		collection := db.C(name)
@@ -261,9 +344,9 @@ func (st *State) checkCanUpgrade(currentVersion, newVersion string) error {
 		for iter.Next(&doc) {
 			switch name {
 			case machinesC:
-				agentTags = append(agentTags, names.NewMachineTag(doc.Id).String())
+				agentTags = append(agentTags, names.NewMachineTag(st.localID(doc.Id)).String())
 			case unitsC:
-				agentTags = append(agentTags, names.NewUnitTag(doc.Id).String())
+				agentTags = append(agentTags, names.NewUnitTag(st.localID(doc.Id)).String())
 			}
 		}
 		if err := iter.Close(); err != nil {
@@ -281,7 +364,7 @@ func (st *State) checkCanUpgrade(currentVersion, newVersion string) error {
 // stable state (all agents are running the current version).
 func (st *State) SetEnvironAgentVersion(newVersion version.Number) (err error) {
 	buildTxn := func(attempt int) ([]txn.Op, error) {
-		settings, err := readSettings(st, environGlobalKey)
+		settings, err := readSettings(st, st.environKey())
 		if err != nil {
 			return nil, err
 		}
@@ -304,7 +387,7 @@ func (st *State) SetEnvironAgentVersion(newVersion version.Number) (err error) {
 
 		ops := []txn.Op{{
 			C:      settingsC,
-			Id:     environGlobalKey,
+			Id:     st.environKey(),
 			Assert: bson.D{{"txn-revno", settings.txnRevno}},
 			Update: bson.D{{"$set", bson.D{{"agent-version", newVersion.String()}}}},
 		}}
@@ -349,7 +432,7 @@ func (st *State) UpdateEnvironConfig(updateAttrs map[string]interface{}, removeA
 	// applied as a delta to what's on disk; if there has
 	// been a concurrent update, the change may not be what
 	// the user asked for.
-	settings, err := readSettings(st, environGlobalKey)
+	settings, err := readSettings(st, st.environKey())
 	if err != nil {
 		return err
 	}
@@ -383,7 +466,7 @@ func (st *State) UpdateEnvironConfig(updateAttrs map[string]interface{}, removeA
 
 // EnvironConstraints returns the current environment constraints.
 func (st *State) EnvironConstraints() (constraints.Value, error) {
-	return readConstraints(st, environGlobalKey)
+	return readConstraints(st, st.environKey())
 }
 
 // SetEnvironConstraints replaces the current environment constraints.
@@ -395,7 +478,7 @@ func (st *State) SetEnvironConstraints(cons constraints.Value) error {
 	} else if err != nil {
 		return err
 	}
-	return writeConstraints(st, environGlobalKey, cons)
+	return writeConstraints(st, st.environKey(), cons)
 }
 
 var errDead = fmt.Errorf("not found or dead")
@@ -415,7 +498,7 @@ func (st *State) AllMachines() (machines []*Machine, err error) {
 	defer closer()
 
 	mdocs := machineDocSlice{}
-	err = machinesCollection.Find(nil).All(&mdocs)
+	err = machinesCollection.Find(st.environSelector()).All(&mdocs)
 	if err != nil {
 		return nil, fmt.Errorf("cannot get all machines: %v", err)
 	}
@@ -431,7 +514,15 @@ type machineDocSlice []machineDoc
 func (ms machineDocSlice) Len() int      { return len(ms) }
 func (ms machineDocSlice) Swap(i, j int) { ms[i], ms[j] = ms[j], ms[i] }
 func (ms machineDocSlice) Less(i, j int) bool {
-	return machineIdLessThan(ms[i].Id, ms[j].Id)
+	// The machines of an environment share the prefix of their ids,
+	// if any, so only what follows it is compared.
+	return machineIdLessThan(unprefixedId(ms[i].Id), unprefixedId(ms[j].Id))
+}
+
+// unprefixedId returns the document id with any
+// environment UUID prefix removed.
+func unprefixedId(id string) string {
+	return id[strings.LastIndex(id, ":")+1:]
 }
 
 // machineIdLessThan returns true if id1 < id2, false otherwise.
@@ -481,9 +572,12 @@ func (st *State) Machine(id string) (*Machine, error) {
 	machinesCollection, closer := st.getCollection(machinesC)
 	defer closer()
 
+	id = st.localID(id)
 	mdoc := &machineDoc{}
-	sel := bson.D{{"_id", id}}
-	err := machinesCollection.Find(sel).One(mdoc)
+	sel := append(bson.D{{"_id", st.docID(id)}}, st.environSelector()...)
+	err := st.timeQuery(machinesC, func() error {
+		return machinesCollection.Find(sel).One(mdoc)
+	})
 	if err == mgo.ErrNotFound {
 		return nil, errors.NotFoundf("machine %s", id)
 	}
@@ -558,22 +652,22 @@ func (st *State) parseTag(tag names.Tag) (string, string, error) {
 	id := tag.Id()
 	switch tag := tag.(type) {
 	case names.MachineTag:
-		coll = machinesC
+		coll, id = machinesC, st.docID(id)
 	case names.ServiceTag:
-		coll = servicesC
+		coll, id = servicesC, st.docID(id)
 	case names.UnitTag:
-		coll = unitsC
+		coll, id = unitsC, st.docID(id)
 	case names.UserTag:
 		coll = usersC
 	case names.RelationTag:
-		coll = relationsC
+		coll, id = relationsC, st.docID(id)
 	case names.EnvironTag:
 		coll = environmentsC
 	case names.NetworkTag:
-		coll = networksC
+		coll, id = networksC, st.docID(id)
 	case names.ActionTag:
 		coll = actionsC
-		id = actionIdFromTag(tag)
+		id = st.docID(actionIdFromTag(tag))
 	default:
 		return "", "", fmt.Errorf("%q is not a valid collection tag", tag)
 	}
@@ -624,7 +718,9 @@ func (st *State) Charm(curl *charm.URL) (*Charm, error) {
 		{"placeholder", bson.D{{"$ne", true}}},
 		{"pendingupload", bson.D{{"$ne", true}}},
 	}
-	err := charms.Find(what).One(&cdoc)
+	err := st.timeQuery(charmsC, func() error {
+		return charms.Find(what).One(&cdoc)
+	})
 	if err == mgo.ErrNotFound {
 		return nil, errors.NotFoundf("charm %q", curl)
 	}
@@ -801,7 +897,7 @@ func (st *State) PrepareStoreCharmUpload(curl *charm.URL) (*Charm, error) {
 		}
 		return ops, nil
 	}
-	if err = st.run(buildTxn); err == nil {
+	if err = st.runDiagnosed(buildTxn); err == nil {
 		return newCharm(st, &uploadedCharm)
 	}
 	return nil, err
@@ -855,7 +951,7 @@ func (st *State) AddStoreCharmPlaceholder(curl *charm.URL) (err error) {
 		})
 		return ops, nil
 	}
-	return st.run(buildTxn)
+	return st.runDiagnosed(buildTxn)
 }
 
 // deleteOldPlaceholderCharmsOps returns the txn ops required to delete all placeholder charm
@@ -974,15 +1070,16 @@ func (st *State) addPeerRelationsOps(serviceName string, peers map[string]charm.
 			return nil, err
 		}
 		eps := []Endpoint{{
-			ServiceName: serviceName,
+			ServiceName: st.localID(serviceName),
 			Relation:    rel,
 		}}
-		relKey := relationKey(eps)
+		relKey := st.docID(relationKey(eps))
 		relDoc := &relationDoc{
 			Key:       relKey,
 			Id:        relId,
 			Endpoints: eps,
 			Life:      Alive,
+			EnvUUID:   st.environTag.Id(),
 		}
 		ops = append(ops, txn.Op{
 			C:      relationsC,
@@ -998,6 +1095,16 @@ func (st *State) addPeerRelationsOps(serviceName string, peers map[string]charm.
 // supplied name (which must be unique). If the charm defines peer relations,
 // they will be created automatically.
 func (st *State) AddService(name, ownerTag string, ch *Charm, networks []string) (service *Service, err error) {
+	return st.addService(name, ownerTag, ch, networks, nil)
+}
+
+// AddDeployedService works like AddService, but also records how the
+// service was deployed, in the same transaction that creates it.
+func (st *State) AddDeployedService(name, ownerTag string, ch *Charm, networks []string, record DeploymentRecord) (service *Service, err error) {
+	return st.addService(name, ownerTag, ch, networks, &record)
+}
+
+func (st *State) addService(name, ownerTag string, ch *Charm, networks []string, record *DeploymentRecord) (service *Service, err error) {
 	defer errors.Maskf(&err, "cannot add service %q", name)
 	tag, err := names.ParseUserTag(ownerTag)
 	if err != nil {
@@ -1010,7 +1117,7 @@ func (st *State) AddService(name, ownerTag string, ch *Charm, networks []string)
 	if ch == nil {
 		return nil, fmt.Errorf("charm is nil")
 	}
-	if exists, err := isNotDead(st.db, servicesC, name); err != nil {
+	if exists, err := isNotDead(st.db, servicesC, st.docID(name)); err != nil {
 		return nil, err
 	} else if exists {
 		return nil, fmt.Errorf("service already exists")
@@ -1030,7 +1137,8 @@ func (st *State) AddService(name, ownerTag string, ch *Charm, networks []string)
 	// Create the service addition operations.
 	peers := ch.Meta().Peers
 	svcDoc := &serviceDoc{
-		Name:          name,
+		Name:          st.docID(name),
+		EnvUUID:       st.environTag.Id(),
 		Series:        ch.URL().Series,
 		Subordinate:   ch.Meta().Subordinate,
 		CharmURL:      ch.URL(),
@@ -1038,6 +1146,11 @@ func (st *State) AddService(name, ownerTag string, ch *Charm, networks []string)
 		Life:          Alive,
 		OwnerTag:      ownerTag,
 	}
+	if record != nil {
+		// Mongo stores times with millisecond precision.
+		record.Deployed = record.Deployed.UTC().Truncate(time.Millisecond)
+		svcDoc.Deployment = record
+	}
 	svc := newService(st, svcDoc)
 	ops := []txn.Op{
 		env.assertAliveOp(),
@@ -1046,7 +1159,7 @@ func (st *State) AddService(name, ownerTag string, ch *Charm, networks []string)
 		// Once we can add networks independently of machine
 		// provisioning, we should check the given networks are valid
 		// and known before setting them.
-		createRequestedNetworksOp(st, svc.globalKey(), networks),
+		createRequestedNetworksOp(st, svc.globalKey(), networks, nil),
 		createSettingsOp(st, svc.settingsKey(), nil),
 		{
 			C:      usersC,
@@ -1061,7 +1174,7 @@ func (st *State) AddService(name, ownerTag string, ch *Charm, networks []string)
 		},
 		{
 			C:      servicesC,
-			Id:     name,
+			Id:     svcDoc.Name,
 			Assert: txn.DocMissing,
 			Insert: svcDoc,
 		}}
@@ -1102,28 +1215,13 @@ func (st *State) AddService(name, ownerTag string, ch *Charm, networks []string)
 // an error satisfying errors.IsAlreadyExists is returned.
 func (st *State) AddNetwork(args NetworkInfo) (n *Network, err error) {
 	defer errors.Contextf(&err, "cannot add network %q", args.Name)
-	if args.CIDR != "" {
-		_, _, err := net.ParseCIDR(args.CIDR)
-		if err != nil {
-			return nil, err
-		}
-	}
-	if args.Name == "" {
-		return nil, fmt.Errorf("name must be not empty")
-	}
-	if !names.IsValidNetwork(args.Name) {
-		return nil, fmt.Errorf("invalid name")
-	}
-	if args.ProviderId == "" {
-		return nil, fmt.Errorf("provider id must be not empty")
-	}
-	if args.VLANTag < 0 || args.VLANTag > 4094 {
-		return nil, fmt.Errorf("invalid VLAN tag %d: must be between 0 and 4094", args.VLANTag)
+	if err := args.validate(); err != nil {
+		return nil, err
 	}
-	doc := newNetworkDoc(args)
+	doc := newNetworkDoc(st, args)
 	ops := []txn.Op{{
 		C:      networksC,
-		Id:     args.Name,
+		Id:     doc.Name,
 		Assert: txn.DocMissing,
 		Insert: doc,
 	}}
@@ -1155,8 +1253,10 @@ func (st *State) Network(name string) (*Network, error) {
 	networks, closer := st.getCollection(networksC)
 	defer closer()
 
+	name = st.localID(name)
 	doc := &networkDoc{}
-	err := networks.FindId(name).One(doc)
+	sel := append(bson.D{{"_id", st.docID(name)}}, st.environSelector()...)
+	err := networks.Find(sel).One(doc)
 	if err == mgo.ErrNotFound {
 		return nil, errors.NotFoundf("network %q", name)
 	}
@@ -1166,13 +1266,52 @@ func (st *State) Network(name string) (*Network, error) {
 	return newNetwork(st, doc), nil
 }
 
+// NetworkByProviderId returns the network with the given
+// provider-specific id.
+func (st *State) NetworkByProviderId(id network.Id) (*Network, error) {
+	networks, closer := st.getCollection(networksC)
+	defer closer()
+
+	doc := &networkDoc{}
+	sel := append(bson.D{{"providerid", id}}, st.environSelector()...)
+	err := networks.Find(sel).One(doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("network with provider id %q", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get network with provider id %q: %v", id, err)
+	}
+	return newNetwork(st, doc), nil
+}
+
+// NetworkInterfacesByMACAddress returns all the network interfaces
+// with the given MAC address, on any machine. More than one interface
+// is returned for bonds and bridges sharing the address of one of
+// their members, for VLAN interfaces on the same device, and for
+// devices duplicated by mistake.
+func (st *State) NetworkInterfacesByMACAddress(mac string) ([]*NetworkInterface, error) {
+	networkInterfaces, closer := st.getCollection(networkInterfacesC)
+	defer closer()
+
+	docs := []networkInterfaceDoc{}
+	err := networkInterfaces.Find(bson.D{{"macaddress", mac}}).All(&docs)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get network interfaces with MAC address %q: %v", mac, err)
+	}
+	ifaces := make([]*NetworkInterface, len(docs))
+	for i, doc := range docs {
+		ifaces[i] = newNetworkInterface(st, &doc)
+	}
+	return ifaces, nil
+}
+
 // AllNetworks returns all known networks in the environment.
 func (st *State) AllNetworks() (networks []*Network, err error) {
 	networksCollection, closer := st.getCollection(networksC)
 	defer closer()
 
 	docs := []networkDoc{}
-	err = networksCollection.Find(nil).All(&docs)
+	err = networksCollection.Find(st.environSelector()).All(&docs)
 	if err != nil {
 		return nil, fmt.Errorf("cannot get all networks")
 	}
@@ -1187,12 +1326,15 @@ func (st *State) Service(name string) (service *Service, err error) {
 	services, closer := st.getCollection(servicesC)
 	defer closer()
 
+	name = st.localID(name)
 	if !names.IsValidService(name) {
 		return nil, fmt.Errorf("%q is not a valid service name", name)
 	}
 	sdoc := &serviceDoc{}
-	sel := bson.D{{"_id", name}}
-	err = services.Find(sel).One(sdoc)
+	sel := append(bson.D{{"_id", st.docID(name)}}, st.environSelector()...)
+	err = st.timeQuery(servicesC, func() error {
+		return services.Find(sel).One(sdoc)
+	})
 	if err == mgo.ErrNotFound {
 		return nil, errors.NotFoundf("service %q", name)
 	}
@@ -1208,7 +1350,7 @@ func (st *State) AllServices() (services []*Service, err error) {
 	defer closer()
 
 	sdocs := []serviceDoc{}
-	err = servicesCollection.Find(bson.D{}).All(&sdocs)
+	err = servicesCollection.Find(st.environSelector()).All(&sdocs)
 	if err != nil {
 		return nil, fmt.Errorf("cannot get all services")
 	}
@@ -1362,7 +1504,7 @@ func (st *State) AddRelation(eps ...Endpoint) (r *Relation, err error) {
 	var doc *relationDoc
 	buildTxn := func(attempt int) ([]txn.Op, error) {
 		// Perform initial relation sanity check.
-		if exists, err := isNotDead(st.db, relationsC, key); err != nil {
+		if exists, err := isNotDead(st.db, relationsC, st.docID(key)); err != nil {
 			return nil, err
 		} else if exists {
 			return nil, fmt.Errorf("relation already exists")
@@ -1389,7 +1531,7 @@ func (st *State) AddRelation(eps ...Endpoint) (r *Relation, err error) {
 			}
 			ops = append(ops, txn.Op{
 				C:      servicesC,
-				Id:     ep.ServiceName,
+				Id:     svc.doc.Name,
 				Assert: bson.D{{"life", Alive}, {"charmurl", ch.URL()}},
 				Update: bson.D{{"$inc", bson.D{{"relationcount", 1}}}},
 			})
@@ -1406,10 +1548,11 @@ func (st *State) AddRelation(eps ...Endpoint) (r *Relation, err error) {
 			}
 		}
 		doc = &relationDoc{
-			Key:       key,
+			Key:       st.docID(key),
 			Id:        id,
 			Endpoints: eps,
 			Life:      Alive,
+			EnvUUID:   st.environTag.Id(),
 		}
 		ops = append(ops, txn.Op{
 			C:      relationsC,
@@ -1419,7 +1562,7 @@ func (st *State) AddRelation(eps ...Endpoint) (r *Relation, err error) {
 		})
 		return ops, nil
 	}
-	if err = st.run(buildTxn); err == nil {
+	if err = st.runDiagnosed(buildTxn); err == nil {
 		return &Relation{st, *doc}, nil
 	}
 	return nil, err
@@ -1436,8 +1579,12 @@ func (st *State) KeyRelation(key string) (*Relation, error) {
 	relations, closer := st.getCollection(relationsC)
 	defer closer()
 
+	key = st.localID(key)
 	doc := relationDoc{}
-	err := relations.Find(bson.D{{"_id", key}}).One(&doc)
+	sel := append(bson.D{{"_id", st.docID(key)}}, st.environSelector()...)
+	err := st.timeQuery(relationsC, func() error {
+		return relations.Find(sel).One(&doc)
+	})
 	if err == mgo.ErrNotFound {
 		return nil, errors.NotFoundf("relation %q", key)
 	}
@@ -1453,7 +1600,10 @@ func (st *State) Relation(id int) (*Relation, error) {
 	defer closer()
 
 	doc := relationDoc{}
-	err := relations.Find(bson.D{{"id", id}}).One(&doc)
+	sel := append(bson.D{{"id", id}}, st.environSelector()...)
+	err := st.timeQuery(relationsC, func() error {
+		return relations.Find(sel).One(&doc)
+	})
 	if err == mgo.ErrNotFound {
 		return nil, errors.NotFoundf("relation %d", id)
 	}
@@ -1469,7 +1619,7 @@ func (st *State) AllRelations() (relations []*Relation, err error) {
 	defer closer()
 
 	docs := relationDocSlice{}
-	err = relationsCollection.Find(nil).All(&docs)
+	err = relationsCollection.Find(st.environSelector()).All(&docs)
 	if err != nil {
 		return nil, errors.Annotate(err, "cannot get all relations")
 	}
@@ -1493,8 +1643,9 @@ func (st *State) Action(id string) (*Action, error) {
 	actions, closer := st.getCollection(actionsC)
 	defer closer()
 
+	id = st.localID(id)
 	doc := actionDoc{}
-	err := actions.FindId(id).One(&doc)
+	err := actions.FindId(st.docID(id)).One(&doc)
 	if err == mgo.ErrNotFound {
 		return nil, errors.NotFoundf("action %q", id)
 	}
@@ -1526,7 +1677,7 @@ func (st *State) matchingActionsByPrefix(prefix string) ([]*Action, error) {
 	actionsCollection, closer := st.getCollection(actionsC)
 	defer closer()
 
-	sel := bson.D{{"_id", bson.D{{"$regex", "^" + regexp.QuoteMeta(ensureActionMarker(prefix))}}}}
+	sel := bson.D{{"_id", bson.D{{"$regex", "^" + regexp.QuoteMeta(ensureActionMarker(st.docID(prefix)))}}}}
 	iter := actionsCollection.Find(sel).Iter()
 
 	for iter.Next(&doc) {
@@ -1540,8 +1691,9 @@ func (st *State) ActionResult(id string) (*ActionResult, error) {
 	actionresults, closer := st.getCollection(actionresultsC)
 	defer closer()
 
+	id = st.localID(id)
 	doc := actionResultDoc{}
-	err := actionresults.FindId(id).One(&doc)
+	err := actionresults.FindId(st.docID(id)).One(&doc)
 	if err == mgo.ErrNotFound {
 		return nil, errors.NotFoundf("action result %q", id)
 	}
@@ -1559,7 +1711,7 @@ func (st *State) matchingActionResults(ar ActionReceiver) ([]*ActionResult, erro
 	actionresults, closer := st.getCollection(actionresultsC)
 	defer closer()
 
-	prefix := actionResultPrefix(ar)
+	prefix := st.docID(actionResultPrefix(ar))
 	sel := bson.D{{"_id", bson.D{{"$regex", "^" + regexp.QuoteMeta(prefix)}}}}
 	iter := actionresults.Find(sel).Iter()
 	for iter.Next(&doc) {
@@ -1570,6 +1722,7 @@ func (st *State) matchingActionResults(ar ActionReceiver) ([]*ActionResult, erro
 
 // Unit returns a unit by name.
 func (st *State) Unit(name string) (*Unit, error) {
+	name = st.localID(name)
 	if !names.IsValidUnit(name) {
 		return nil, fmt.Errorf("%q is not a valid unit name", name)
 	}
@@ -1577,7 +1730,10 @@ func (st *State) Unit(name string) (*Unit, error) {
 	defer closer()
 
 	doc := unitDoc{}
-	err := units.FindId(name).One(&doc)
+	sel := append(bson.D{{"_id", st.docID(name)}}, st.environSelector()...)
+	err := st.timeQuery(unitsC, func() error {
+		return units.Find(sel).One(&doc)
+	})
 	if err == mgo.ErrNotFound {
 		return nil, errors.NotFoundf("unit %q", name)
 	}
@@ -1693,6 +1849,14 @@ func (st *State) StateServingInfo() (params.StateServingInfo, error) {
 	if info.StatePort == 0 {
 		return params.StateServingInfo{}, errors.NotFoundf("state serving info")
 	}
+	// The document fields managed by mongo and the transaction
+	// runner are not part of the info.
+	for _, field := range []string{"_id", "txn-revno", "txn-queue"} {
+		delete(info.Unknown, field)
+	}
+	if len(info.Unknown) == 0 {
+		info.Unknown = nil
+	}
 	return info, nil
 }
 
@@ -95,6 +95,22 @@ func (s *StateSuite) TestMongoSession(c *gc.C) {
 	c.Assert(session.Ping(), gc.IsNil)
 }
 
+func (s *StateSuite) TestLastTxnId(c *gc.C) {
+	before, err := s.State.LastTxnId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(before, gc.Not(gc.Equals), "")
+
+	again, err := s.State.LastTxnId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(again, gc.Equals, before)
+
+	_, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	after, err := s.State.LastTxnId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(after, gc.Not(gc.Equals), before)
+}
+
 func (s *StateSuite) TestAddresses(c *gc.C) {
 	var err error
 	machines := make([]*state.Machine, 4)
@@ -1027,28 +1043,28 @@ var addNetworkErrorsTests = []struct {
 	args      state.NetworkInfo
 	expectErr string
 }{{
-	state.NetworkInfo{"", "provider-id", "0.3.1.0/24", 0},
+	state.NetworkInfo{"", "provider-id", "0.3.1.0/24", 0, ""},
 	`cannot add network "": name must be not empty`,
 }, {
-	state.NetworkInfo{"-invalid-", "provider-id", "0.3.1.0/24", 0},
+	state.NetworkInfo{"-invalid-", "provider-id", "0.3.1.0/24", 0, ""},
 	`cannot add network "-invalid-": invalid name`,
 }, {
-	state.NetworkInfo{"net2", "", "0.3.1.0/24", 0},
+	state.NetworkInfo{"net2", "", "0.3.1.0/24", 0, ""},
 	`cannot add network "net2": provider id must be not empty`,
 }, {
-	state.NetworkInfo{"net2", "provider-id", "invalid", 0},
+	state.NetworkInfo{"net2", "provider-id", "invalid", 0, ""},
 	`cannot add network "net2": invalid CIDR address: invalid`,
 }, {
-	state.NetworkInfo{"net2", "provider-id", "0.3.1.0/24", -1},
+	state.NetworkInfo{"net2", "provider-id", "0.3.1.0/24", -1, ""},
 	`cannot add network "net2": invalid VLAN tag -1: must be between 0 and 4094`,
 }, {
-	state.NetworkInfo{"net2", "provider-id", "0.3.1.0/24", 9999},
+	state.NetworkInfo{"net2", "provider-id", "0.3.1.0/24", 9999, ""},
 	`cannot add network "net2": invalid VLAN tag 9999: must be between 0 and 4094`,
 }, {
-	state.NetworkInfo{"net1", "provider-id", "0.3.1.0/24", 0},
+	state.NetworkInfo{"net1", "provider-id", "0.3.1.0/24", 0, ""},
 	`cannot add network "net1": network "net1" already exists`,
 }, {
-	state.NetworkInfo{"net42", "provider-net1", "0.3.1.0/24", 0},
+	state.NetworkInfo{"net42", "provider-net1", "0.3.1.0/24", 0, ""},
 	`cannot add network "net42": network with provider id "provider-net1" already exists`,
 }}
 
@@ -1983,6 +1999,33 @@ func (s *StateSuite) TestWatchForEnvironConfigChanges(c *gc.C) {
 	wc.AssertNoChange()
 }
 
+func (s *StateSuite) TestWatchEnvironConstraints(c *gc.C) {
+	w := s.State.WatchEnvironConstraints()
+	defer statetesting.AssertStop(c, w)
+
+	wc := statetesting.NewNotifyWatcherC(c, s.State, w)
+	// Initially we get one change notification.
+	wc.AssertOneChange()
+
+	cons := constraints.MustParse("mem=4G")
+	err := s.State.SetEnvironConstraints(cons)
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+
+	// Changes to service constraints are not reported.
+	svc := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	err = svc.SetConstraints(constraints.MustParse("cpu-cores=2"))
+	c.Assert(err, gc.IsNil)
+	wc.AssertNoChange()
+
+	err = s.State.SetEnvironConstraints(constraints.Value{})
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+
+	statetesting.AssertStop(c, w)
+	wc.AssertClosed()
+}
+
 func (s *StateSuite) TestWatchEnvironConfigCorruptConfig(c *gc.C) {
 	cfg, err := s.State.EnvironConfig()
 	c.Assert(err, gc.IsNil)
@@ -3388,6 +3431,33 @@ func (s *StateSuite) TestStateServingInfo(c *gc.C) {
 	c.Assert(info, jc.DeepEquals, data)
 }
 
+func (s *StateSuite) TestStateServingInfoKeepsUnknownFields(c *gc.C) {
+	data := params.StateServingInfo{
+		APIPort:    69,
+		StatePort:  80,
+		Cert:       "Some cert",
+		PrivateKey: "Some key",
+		Unknown:    params.UnknownFields{"newfield": "new value"},
+	}
+	err := s.State.SetStateServingInfo(data)
+	c.Assert(err, gc.IsNil)
+
+	info, err := s.State.StateServingInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info, jc.DeepEquals, data)
+
+	// Setting the info again without the field, as an older
+	// version would, does not remove it.
+	data.Unknown = nil
+	data.Cert = "Other cert"
+	err = s.State.SetStateServingInfo(data)
+	c.Assert(err, gc.IsNil)
+	info, err = s.State.StateServingInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Cert, gc.Equals, "Other cert")
+	c.Assert(info.Unknown, jc.DeepEquals, params.UnknownFields{"newfield": "new value"})
+}
+
 var setStateServingInfoWithInvalidInfoTests = []func(info *params.StateServingInfo){
 	func(info *params.StateServingInfo) { info.APIPort = 0 },
 	func(info *params.StateServingInfo) { info.StatePort = 0 },
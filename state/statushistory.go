@@ -0,0 +1,171 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/juju/names"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+
+	"github.com/juju/juju/state/api/params"
+)
+
+// MaxStatusHistory is the maximum number of status changes retained
+// for each machine and unit. Older changes are discarded as new ones
+// are recorded.
+var MaxStatusHistory = 20
+
+// StatusHistoryEntry records a status set on a machine or unit.
+type StatusHistoryEntry struct {
+	// Entity holds the tag of the machine or unit.
+	Entity string
+
+	Status params.Status
+	Info   string
+
+	// Time is when the status was set.
+	Time time.Time
+}
+
+// statusHistoryDoc records a status set on a machine or unit.
+// Like utilization samples, entries are written directly rather
+// than through transactions: losing one is harmless, and they
+// must not make setting a status any more likely to fail.
+type statusHistoryDoc struct {
+	Id         bson.ObjectId `bson:"_id"`
+	Entity     string
+	Status     params.Status
+	StatusInfo string
+	Time       time.Time
+}
+
+func (doc *statusHistoryDoc) entry() StatusHistoryEntry {
+	return StatusHistoryEntry{
+		Entity: doc.Entity,
+		Status: doc.Status,
+		Info:   doc.StatusInfo,
+		Time:   doc.Time,
+	}
+}
+
+// recordStatusHistory adds the given status of the entity to its
+// history, discarding the oldest entries beyond MaxStatusHistory.
+// Failures are logged rather than returned, as the status itself
+// has already been set.
+func recordStatusHistory(st *State, entity names.Tag, status statusDoc) {
+	history, closer := st.getCollection(statusHistoryC)
+	defer closer()
+
+	doc := statusHistoryDoc{
+		Id:         bson.NewObjectId(),
+		Entity:     st.docID(entity.String()),
+		Status:     status.Status,
+		StatusInfo: status.StatusInfo,
+		Time:       time.Now().UTC(),
+	}
+	if err := history.Insert(&doc); err != nil {
+		logger.Warningf("cannot record status history of %s: %v", entity, err)
+		return
+	}
+	if err := pruneEntityStatusHistory(history, doc.Entity, MaxStatusHistory); err != nil {
+		logger.Warningf("cannot prune status history of %s: %v", entity, err)
+	}
+}
+
+// pruneEntityStatusHistory discards the oldest status history entries
+// of the entity with the given tag beyond the newest maxEntries.
+func pruneEntityStatusHistory(history *mgo.Collection, entity string, maxEntries int) error {
+	var oldest statusHistoryDoc
+	err := history.Find(bson.D{{"entity", entity}}).
+		Sort("-time", "-_id").Skip(maxEntries).Limit(1).One(&oldest)
+	if err == mgo.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	_, err = history.RemoveAll(bson.D{
+		{"entity", entity},
+		{"time", bson.D{{"$lte", oldest.Time}}},
+	})
+	return err
+}
+
+// removeStatusHistory removes the status history of the entity.
+func removeStatusHistory(st *State, entity names.Tag) error {
+	history, closer := st.getCollection(statusHistoryC)
+	defer closer()
+	_, err := history.RemoveAll(bson.D{{"entity", st.docID(entity.String())}})
+	return err
+}
+
+// StatusHistory returns the retained status history
+// of the given machine or unit, newest first.
+func (st *State) StatusHistory(entity names.Tag) ([]StatusHistoryEntry, error) {
+	return st.findStatusHistory(bson.D{{"entity", st.docID(entity.String())}}, 0)
+}
+
+// StatusHistory returns at most size of the retained status changes
+// of the machine, newest first. All of them are returned if size is
+// not positive.
+func (m *Machine) StatusHistory(size int) ([]StatusHistoryEntry, error) {
+	return m.st.findStatusHistory(bson.D{{"entity", m.st.docID(m.Tag().String())}}, size)
+}
+
+// StatusHistory returns at most size of the retained status changes
+// of the unit, newest first. All of them are returned if size is
+// not positive.
+func (u *Unit) StatusHistory(size int) ([]StatusHistoryEntry, error) {
+	return u.st.findStatusHistory(bson.D{{"entity", u.st.docID(u.Tag().String())}}, size)
+}
+
+// FindStatus returns the retained status history entries of all
+// machines and units whose status or status info contains the given
+// text, ignoring case, newest first. If since is not zero, only the
+// entries recorded after that time are returned.
+func (st *State) FindStatus(text string, since time.Time) ([]StatusHistoryEntry, error) {
+	pattern := bson.RegEx{Pattern: regexp.QuoteMeta(text), Options: "i"}
+	query := bson.D{{"$or", []bson.D{
+		{{"status", pattern}},
+		{{"statusinfo", pattern}},
+	}}}
+	if !since.IsZero() {
+		query = append(query, bson.DocElem{"time", bson.D{{"$gt", since.UTC()}}})
+	}
+	// Entities of hosted environments are recorded with the
+	// environment UUID as a prefix, which tags never contain.
+	if st.environTag != st.serverTag {
+		prefix := st.environTag.Id() + ":"
+		query = append(query, bson.DocElem{"entity", bson.RegEx{Pattern: "^" + regexp.QuoteMeta(prefix)}})
+	} else {
+		query = append(query, bson.DocElem{"entity", bson.D{{"$not", bson.RegEx{Pattern: ":"}}}})
+	}
+	return st.findStatusHistory(query, 0)
+}
+
+// findStatusHistory returns at most limit of the status history
+// entries matching the query, newest first, or all of them if
+// limit is not positive.
+func (st *State) findStatusHistory(query bson.D, limit int) ([]StatusHistoryEntry, error) {
+	history, closer := st.getCollection(statusHistoryC)
+	defer closer()
+
+	var docs []statusHistoryDoc
+	q := history.Find(query).Sort("-time", "-_id")
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	if err := q.All(&docs); err != nil {
+		return nil, fmt.Errorf("cannot get status history: %v", err)
+	}
+	entries := make([]StatusHistoryEntry, len(docs))
+	for i, doc := range docs {
+		entries[i] = doc.entry()
+		entries[i].Entity = st.localID(doc.Entity)
+	}
+	return entries, nil
+}
@@ -0,0 +1,164 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/params"
+)
+
+type StatusHistorySuite struct {
+	ConnSuite
+	machine *state.Machine
+	unit    *state.Unit
+}
+
+var _ = gc.Suite(&StatusHistorySuite{})
+
+func (s *StatusHistorySuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	var err error
+	s.machine, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	service := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	s.unit, err = service.AddUnit()
+	c.Assert(err, gc.IsNil)
+}
+
+// entrySummaries returns the entity, status and info of the given
+// entries, which hold times that cannot be compared directly.
+func entrySummaries(entries []state.StatusHistoryEntry) [][3]string {
+	var summaries [][3]string
+	for _, entry := range entries {
+		summaries = append(summaries, [3]string{entry.Entity, string(entry.Status), entry.Info})
+	}
+	return summaries
+}
+
+func (s *StatusHistorySuite) TestStatusHistory(c *gc.C) {
+	entries, err := s.State.StatusHistory(s.unit.Tag())
+	c.Assert(err, gc.IsNil)
+	c.Assert(entries, gc.HasLen, 0)
+
+	before := time.Now().Add(-time.Second)
+	err = s.unit.SetStatus(params.StatusStarted, "", nil)
+	c.Assert(err, gc.IsNil)
+	err = s.unit.SetStatus(params.StatusError, "disk full", nil)
+	c.Assert(err, gc.IsNil)
+	err = s.machine.SetStatus(params.StatusStarted, "", nil)
+	c.Assert(err, gc.IsNil)
+
+	entries, err = s.State.StatusHistory(s.unit.Tag())
+	c.Assert(err, gc.IsNil)
+	c.Assert(entrySummaries(entries), gc.DeepEquals, [][3]string{
+		{"unit-wordpress-0", "error", "disk full"},
+		{"unit-wordpress-0", "started", ""},
+	})
+	c.Assert(entries[0].Time.After(before), gc.Equals, true)
+}
+
+func (s *StatusHistorySuite) TestEntityStatusHistory(c *gc.C) {
+	entries, err := s.machine.StatusHistory(5)
+	c.Assert(err, gc.IsNil)
+	c.Assert(entries, gc.HasLen, 0)
+
+	for _, info := range []string{"one", "two", "three"} {
+		err := s.machine.SetStatus(params.StatusError, info, nil)
+		c.Assert(err, gc.IsNil)
+		err = s.unit.SetStatus(params.StatusError, info, nil)
+		c.Assert(err, gc.IsNil)
+		// Entries are sorted by time, so make sure
+		// that each one is recorded at a later time.
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	entries, err = s.machine.StatusHistory(2)
+	c.Assert(err, gc.IsNil)
+	c.Assert(entrySummaries(entries), gc.DeepEquals, [][3]string{
+		{"machine-0", "error", "three"},
+		{"machine-0", "error", "two"},
+	})
+	entries, err = s.unit.StatusHistory(0)
+	c.Assert(err, gc.IsNil)
+	c.Assert(entrySummaries(entries), gc.DeepEquals, [][3]string{
+		{"unit-wordpress-0", "error", "three"},
+		{"unit-wordpress-0", "error", "two"},
+		{"unit-wordpress-0", "error", "one"},
+	})
+}
+
+func (s *StatusHistorySuite) TestStatusHistoryPruned(c *gc.C) {
+	s.PatchValue(&state.MaxStatusHistory, 2)
+	for _, info := range []string{"one", "two", "three"} {
+		err := s.machine.SetStatus(params.StatusError, info, nil)
+		c.Assert(err, gc.IsNil)
+		// Entries are pruned by time, so make sure
+		// that each is recorded at a different time.
+		time.Sleep(10 * time.Millisecond)
+	}
+	entries, err := s.State.StatusHistory(s.machine.Tag())
+	c.Assert(err, gc.IsNil)
+	c.Assert(entrySummaries(entries), gc.DeepEquals, [][3]string{
+		{"machine-0", "error", "three"},
+		{"machine-0", "error", "two"},
+	})
+}
+
+func (s *StatusHistorySuite) TestFindStatus(c *gc.C) {
+	err := s.unit.SetStatus(params.StatusError, "Disk full on /var", nil)
+	c.Assert(err, gc.IsNil)
+	err = s.unit.SetStatus(params.StatusStarted, "", nil)
+	c.Assert(err, gc.IsNil)
+	err = s.machine.SetStatus(params.StatusError, "disk FULL (.*)", nil)
+	c.Assert(err, gc.IsNil)
+
+	entries, err := s.State.FindStatus("disk full", time.Time{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(entrySummaries(entries), gc.DeepEquals, [][3]string{
+		{"machine-0", "error", "disk FULL (.*)"},
+		{"unit-wordpress-0", "error", "Disk full on /var"},
+	})
+
+	// The text is not a regular expression.
+	entries, err = s.State.FindStatus("(.*)", time.Time{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(entries, gc.HasLen, 1)
+
+	// The status itself is searched too.
+	entries, err = s.State.FindStatus("started", time.Time{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(entrySummaries(entries), gc.DeepEquals, [][3]string{
+		{"unit-wordpress-0", "started", ""},
+	})
+
+	entries, err = s.State.FindStatus("disk full", time.Now().Add(time.Minute))
+	c.Assert(err, gc.IsNil)
+	c.Assert(entries, gc.HasLen, 0)
+}
+
+func (s *StatusHistorySuite) TestStatusHistoryRemoved(c *gc.C) {
+	err := s.unit.SetStatus(params.StatusStarted, "", nil)
+	c.Assert(err, gc.IsNil)
+	err = s.unit.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = s.unit.Remove()
+	c.Assert(err, gc.IsNil)
+	entries, err := s.State.StatusHistory(s.unit.Tag())
+	c.Assert(err, gc.IsNil)
+	c.Assert(entries, gc.HasLen, 0)
+
+	err = s.machine.SetStatus(params.StatusStarted, "", nil)
+	c.Assert(err, gc.IsNil)
+	err = s.machine.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = s.machine.Remove()
+	c.Assert(err, gc.IsNil)
+	entries, err = s.State.StatusHistory(s.machine.Tag())
+	c.Assert(err, gc.IsNil)
+	c.Assert(entries, gc.HasLen, 0)
+}
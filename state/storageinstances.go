@@ -0,0 +1,241 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/juju/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+)
+
+// StorageKind defines the type of storage
+// a storage instance provides to its unit.
+type StorageKind string
+
+const (
+	// StorageKindBlock is storage provided as raw block devices.
+	StorageKindBlock StorageKind = "block"
+
+	// StorageKindFilesystem is storage provided as
+	// filesystems mounted on the unit's machine.
+	StorageKindFilesystem StorageKind = "filesystem"
+)
+
+// StorageInstance represents the state of a unit's instance of one of
+// the storage requests of its charm. A storage instance is provided by
+// the block devices that refer to it.
+type StorageInstance struct {
+	st  *State
+	doc storageInstanceDoc
+}
+
+// storageInstanceDoc represents a storage instance owned by a unit.
+// The id is made of the storage name and a sequence number.
+type storageInstanceDoc struct {
+	Id          string `bson:"_id"`
+	Kind        StorageKind
+	Owner       string
+	StorageName string
+	Life        Life
+}
+
+func newStorageInstance(st *State, doc *storageInstanceDoc) *StorageInstance {
+	return &StorageInstance{st, *doc}
+}
+
+// Id returns the id of the storage instance, which
+// is unique within the environment.
+func (s *StorageInstance) Id() string {
+	return s.doc.Id
+}
+
+// Kind returns the kind of storage the storage instance provides.
+func (s *StorageInstance) Kind() StorageKind {
+	return s.doc.Kind
+}
+
+// UnitName returns the name of the unit that owns the storage instance.
+func (s *StorageInstance) UnitName() string {
+	return s.st.localID(s.doc.Owner)
+}
+
+// StorageName returns the name of the charm's storage
+// request the storage instance was created for.
+func (s *StorageInstance) StorageName() string {
+	return s.doc.StorageName
+}
+
+// Life returns whether the storage instance is Alive, Dying or Dead.
+func (s *StorageInstance) Life() Life {
+	return s.doc.Life
+}
+
+// BlockDevices returns the block devices providing the storage instance.
+func (s *StorageInstance) BlockDevices() ([]*BlockDevice, error) {
+	return s.st.blockDevices(bson.D{{"storageinstanceid", s.doc.Id}})
+}
+
+// Destroy sets the storage instance to Dying, signalling that the
+// block devices providing it should be released.
+func (s *StorageInstance) Destroy() (err error) {
+	defer errors.Maskf(&err, "cannot destroy storage instance %q", s.doc.Id)
+	if s.doc.Life != Alive {
+		return nil
+	}
+	ops := []txn.Op{{
+		C:      storageInstancesC,
+		Id:     s.doc.Id,
+		Assert: isAliveDoc,
+		Update: bson.D{{"$set", bson.D{{"life", Dying}}}},
+	}}
+	// The only abort conditions in play indicate that the storage
+	// instance is already dying or has been removed.
+	if err := onAbort(s.st.runTransaction(ops), nil); err != nil {
+		return err
+	}
+	s.doc.Life = Dying
+	return nil
+}
+
+// EnsureDead sets the storage instance to Dead, once the block devices
+// providing it have been released. It does nothing if the storage
+// instance is already dead.
+func (s *StorageInstance) EnsureDead() (err error) {
+	defer errors.Maskf(&err, "cannot set storage instance %q to dead", s.doc.Id)
+	if s.doc.Life == Dead {
+		return nil
+	}
+	ops := []txn.Op{{
+		C:      storageInstancesC,
+		Id:     s.doc.Id,
+		Assert: txn.DocExists,
+		Update: bson.D{{"$set", bson.D{{"life", Dead}}}},
+	}}
+	if err := s.st.runTransaction(ops); err != nil {
+		return onAbort(err, errors.NotFoundf("storage instance %q", s.doc.Id))
+	}
+	s.doc.Life = Dead
+	return nil
+}
+
+// Remove removes the dead storage instance from state. It fails
+// if any block device still provides the storage instance.
+func (s *StorageInstance) Remove() (err error) {
+	defer errors.Maskf(&err, "cannot remove storage instance %q", s.doc.Id)
+	if s.doc.Life != Dead {
+		return fmt.Errorf("storage instance is not dead")
+	}
+	devices, err := s.BlockDevices()
+	if err != nil {
+		return err
+	}
+	if len(devices) > 0 {
+		return fmt.Errorf("storage instance is provided by %d block devices", len(devices))
+	}
+	ops := []txn.Op{{
+		C:      storageInstancesC,
+		Id:     s.doc.Id,
+		Assert: isDeadDoc,
+		Remove: true,
+	}}
+	// Block devices cannot be added for a dead storage instance,
+	// so the only abort conditions in play indicate that the
+	// storage instance has already been removed.
+	return onAbort(s.st.runTransaction(ops), nil)
+}
+
+// Refresh refreshes the contents of the storage instance from the
+// underlying state. It returns an error that satisfies
+// errors.IsNotFound if the storage instance has been removed.
+func (s *StorageInstance) Refresh() error {
+	instance, err := s.st.StorageInstance(s.doc.Id)
+	if err != nil {
+		return err
+	}
+	s.doc = instance.doc
+	return nil
+}
+
+// AddStorageInstance creates a new instance of the named storage
+// request of the unit's charm, providing the given kind of storage.
+// The unit must be alive.
+func (u *Unit) AddStorageInstance(storageName string, kind StorageKind) (instance *StorageInstance, err error) {
+	defer errors.Contextf(&err, "cannot add storage instance %q to unit %q", storageName, u)
+	if storageName == "" || strings.Contains(storageName, "/") {
+		return nil, fmt.Errorf("invalid storage name")
+	}
+	if kind != StorageKindBlock && kind != StorageKindFilesystem {
+		return nil, fmt.Errorf("invalid storage kind %q", kind)
+	}
+	seq, err := u.st.sequence("storageinstance")
+	if err != nil {
+		return nil, err
+	}
+	doc := &storageInstanceDoc{
+		Id:          fmt.Sprintf("%s/%d", storageName, seq),
+		Kind:        kind,
+		Owner:       u.doc.Name,
+		StorageName: storageName,
+		Life:        Alive,
+	}
+	ops := []txn.Op{{
+		C:      unitsC,
+		Id:     u.doc.Name,
+		Assert: isAliveDoc,
+	}, {
+		C:      storageInstancesC,
+		Id:     doc.Id,
+		Assert: txn.DocMissing,
+		Insert: doc,
+	}}
+	switch err := u.st.runTransaction(ops); err {
+	case txn.ErrAborted:
+		return nil, fmt.Errorf("unit is not found or not alive")
+	case nil:
+		return newStorageInstance(u.st, doc), nil
+	default:
+		return nil, err
+	}
+}
+
+// StorageInstances returns the storage instances owned by the unit.
+func (u *Unit) StorageInstances() ([]*StorageInstance, error) {
+	return u.st.unitStorageInstances(u.doc.Name)
+}
+
+func (st *State) unitStorageInstances(unitName string) ([]*StorageInstance, error) {
+	storageInstances, closer := st.getCollection(storageInstancesC)
+	defer closer()
+
+	docs := []storageInstanceDoc{}
+	err := storageInstances.Find(bson.D{{"owner", unitName}}).All(&docs)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get storage instances of unit %q: %v", st.localID(unitName), err)
+	}
+	result := make([]*StorageInstance, len(docs))
+	for i, doc := range docs {
+		result[i] = newStorageInstance(st, &doc)
+	}
+	return result, nil
+}
+
+// StorageInstance returns the storage instance with the given id.
+func (st *State) StorageInstance(id string) (*StorageInstance, error) {
+	storageInstances, closer := st.getCollection(storageInstancesC)
+	defer closer()
+
+	doc := &storageInstanceDoc{}
+	err := storageInstances.FindId(id).One(doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("storage instance %q", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get storage instance %q: %v", id, err)
+	}
+	return newStorageInstance(st, doc), nil
+}
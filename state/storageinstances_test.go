@@ -0,0 +1,158 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+	statetesting "github.com/juju/juju/state/testing"
+)
+
+type StorageInstanceSuite struct {
+	ConnSuite
+	unit    *state.Unit
+	machine *state.Machine
+}
+
+var _ = gc.Suite(&StorageInstanceSuite{})
+
+func (s *StorageInstanceSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	service := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	var err error
+	s.unit, err = service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	s.machine, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *StorageInstanceSuite) TestAddStorageInstance(c *gc.C) {
+	instance, err := s.unit.AddStorageInstance("data", state.StorageKindFilesystem)
+	c.Assert(err, gc.IsNil)
+	c.Assert(instance.Id(), gc.Equals, "data/0")
+	c.Assert(instance.Kind(), gc.Equals, state.StorageKindFilesystem)
+	c.Assert(instance.UnitName(), gc.Equals, "wordpress/0")
+	c.Assert(instance.StorageName(), gc.Equals, "data")
+	c.Assert(instance.Life(), gc.Equals, state.Alive)
+
+	other, err := s.unit.AddStorageInstance("data", state.StorageKindFilesystem)
+	c.Assert(err, gc.IsNil)
+	c.Assert(other.Id(), gc.Equals, "data/1")
+
+	instances, err := s.unit.StorageInstances()
+	c.Assert(err, gc.IsNil)
+	c.Assert(instances, gc.HasLen, 2)
+
+	found, err := s.State.StorageInstance("data/0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(found, jc.DeepEquals, instance)
+}
+
+func (s *StorageInstanceSuite) TestAddStorageInstanceInvalid(c *gc.C) {
+	_, err := s.unit.AddStorageInstance("", state.StorageKindBlock)
+	c.Assert(err, gc.ErrorMatches, `cannot add storage instance "" to unit "wordpress/0": invalid storage name`)
+	_, err = s.unit.AddStorageInstance("data", "tape")
+	c.Assert(err, gc.ErrorMatches, `cannot add storage instance "data" to unit "wordpress/0": invalid storage kind "tape"`)
+}
+
+func (s *StorageInstanceSuite) TestAddStorageInstanceToDeadUnit(c *gc.C) {
+	err := s.unit.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	_, err = s.unit.AddStorageInstance("data", state.StorageKindBlock)
+	c.Assert(err, gc.ErrorMatches, `cannot add storage instance "data" to unit "wordpress/0": unit is not found or not alive`)
+}
+
+func (s *StorageInstanceSuite) TestBlockDevices(c *gc.C) {
+	instance, err := s.unit.AddStorageInstance("data", state.StorageKindBlock)
+	c.Assert(err, gc.IsNil)
+	dev, err := s.machine.AddBlockDevice(state.BlockDeviceParams{
+		Size:              1024,
+		StorageInstanceId: instance.Id(),
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(dev.StorageInstanceId(), gc.Equals, "data/0")
+	devices, err := instance.BlockDevices()
+	c.Assert(err, gc.IsNil)
+	c.Assert(devices, jc.DeepEquals, []*state.BlockDevice{dev})
+
+	// Block devices cannot be added for a storage
+	// instance that is not alive.
+	err = instance.Destroy()
+	c.Assert(err, gc.IsNil)
+	_, err = s.machine.AddBlockDevice(state.BlockDeviceParams{
+		Size:              1024,
+		StorageInstanceId: instance.Id(),
+	})
+	c.Assert(err, gc.ErrorMatches, `cannot add block device to machine "0": storage instance "data/0" is not found or not alive`)
+}
+
+func (s *StorageInstanceSuite) TestLifecycle(c *gc.C) {
+	instance, err := s.unit.AddStorageInstance("data", state.StorageKindBlock)
+	c.Assert(err, gc.IsNil)
+	dev, err := s.machine.AddBlockDevice(state.BlockDeviceParams{
+		Size:              1024,
+		StorageInstanceId: instance.Id(),
+	})
+	c.Assert(err, gc.IsNil)
+
+	err = instance.Remove()
+	c.Assert(err, gc.ErrorMatches, `cannot remove storage instance "data/0": storage instance is not dead`)
+	err = instance.Destroy()
+	c.Assert(err, gc.IsNil)
+	c.Assert(instance.Life(), gc.Equals, state.Dying)
+	err = instance.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	c.Assert(instance.Life(), gc.Equals, state.Dead)
+
+	// The storage instance cannot be removed while
+	// a block device still provides it.
+	err = instance.Remove()
+	c.Assert(err, gc.ErrorMatches, `cannot remove storage instance "data/0": storage instance is provided by 1 block devices`)
+	err = dev.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = dev.Remove()
+	c.Assert(err, gc.IsNil)
+	err = instance.Remove()
+	c.Assert(err, gc.IsNil)
+	err = instance.Refresh()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *StorageInstanceSuite) TestDestroyedWithUnit(c *gc.C) {
+	instance, err := s.unit.AddStorageInstance("data", state.StorageKindBlock)
+	c.Assert(err, gc.IsNil)
+	err = s.unit.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = s.unit.Remove()
+	c.Assert(err, gc.IsNil)
+	err = s.State.Cleanup()
+	c.Assert(err, gc.IsNil)
+	err = instance.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(instance.Life(), gc.Equals, state.Dying)
+}
+
+func (s *StorageInstanceSuite) TestWatchStorageInstances(c *gc.C) {
+	w := s.State.WatchStorageInstances()
+	defer statetesting.AssertStop(c, w)
+	wc := statetesting.NewStringsWatcherC(c, s.State, w)
+	wc.AssertChange()
+	wc.AssertNoChange()
+
+	instance, err := s.unit.AddStorageInstance("data", state.StorageKindBlock)
+	c.Assert(err, gc.IsNil)
+	wc.AssertChange("data/0")
+	wc.AssertNoChange()
+
+	err = instance.Destroy()
+	c.Assert(err, gc.IsNil)
+	wc.AssertChange("data/0")
+	wc.AssertNoChange()
+
+	statetesting.AssertStop(c, w)
+	wc.AssertClosed()
+}
@@ -0,0 +1,304 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+
+	"github.com/juju/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+
+	"github.com/juju/juju/network"
+)
+
+// Subnet represents the state of a subnet of a network. A provider
+// network may contain several routable subnets.
+type Subnet struct {
+	st  *State
+	doc subnetDoc
+}
+
+// SubnetInfo describes a single subnet.
+type SubnetInfo struct {
+	// ProviderId is a provider-specific subnet id. It may be empty
+	// when the provider has no notion of subnets.
+	ProviderId network.Id
+
+	// CIDR of the subnet, in 123.45.67.0/24 format. It identifies
+	// the subnet within the environment.
+	CIDR string
+
+	// VLANTag needs to be between 1 and 4094 for VLANs and 0 for
+	// normal subnets. It's defined by IEEE 802.1Q standard.
+	VLANTag int
+
+	// AllocatableIPLow and AllocatableIPHigh hold the first and
+	// last addresses of the range juju may allocate addresses
+	// from. Both are empty when no addresses are allocatable.
+	AllocatableIPLow  string
+	AllocatableIPHigh string
+
+	// AvailabilityZone is the availability zone the subnet
+	// belongs to, if the provider has any.
+	AvailabilityZone string
+}
+
+// subnetDoc represents a subnet of a network.
+type subnetDoc struct {
+	CIDR        string `bson:"_id"`
+	NetworkName string
+	ProviderId  network.Id
+
+	VLANTag           int
+	AllocatableIPLow  string `bson:",omitempty"`
+	AllocatableIPHigh string `bson:",omitempty"`
+	AvailabilityZone  string `bson:",omitempty"`
+}
+
+func newSubnet(st *State, doc *subnetDoc) *Subnet {
+	return &Subnet{st, *doc}
+}
+
+func newSubnetDoc(networkName string, args SubnetInfo) *subnetDoc {
+	return &subnetDoc{
+		CIDR:              args.CIDR,
+		NetworkName:       networkName,
+		ProviderId:        args.ProviderId,
+		VLANTag:           args.VLANTag,
+		AllocatableIPLow:  args.AllocatableIPLow,
+		AllocatableIPHigh: args.AllocatableIPHigh,
+		AvailabilityZone:  args.AvailabilityZone,
+	}
+}
+
+// validate returns an error if the subnet details are invalid,
+// or if the subnet does not fit in the CIDR of the network it
+// belongs to, when that is known.
+func (args SubnetInfo) validate(networkCIDR string) error {
+	if args.CIDR == "" {
+		return fmt.Errorf("CIDR must be not empty")
+	}
+	_, ipNet, err := net.ParseCIDR(args.CIDR)
+	if err != nil {
+		return err
+	}
+	if networkCIDR != "" {
+		_, netNet, err := net.ParseCIDR(networkCIDR)
+		if err != nil {
+			return err
+		}
+		netOnes, _ := netNet.Mask.Size()
+		subnetOnes, _ := ipNet.Mask.Size()
+		if !netNet.Contains(ipNet.IP) || subnetOnes < netOnes {
+			return fmt.Errorf("CIDR %q is not within network CIDR %q", args.CIDR, networkCIDR)
+		}
+	}
+	if args.VLANTag < 0 || args.VLANTag > 4094 {
+		return fmt.Errorf("invalid VLAN tag %d: must be between 0 and 4094", args.VLANTag)
+	}
+	if args.AllocatableIPLow == "" && args.AllocatableIPHigh == "" {
+		return nil
+	}
+	low := net.ParseIP(args.AllocatableIPLow)
+	if low == nil || !ipNet.Contains(low) {
+		return fmt.Errorf("invalid allocatable range low address %q", args.AllocatableIPLow)
+	}
+	high := net.ParseIP(args.AllocatableIPHigh)
+	if high == nil || !ipNet.Contains(high) {
+		return fmt.Errorf("invalid allocatable range high address %q", args.AllocatableIPHigh)
+	}
+	if bytes.Compare(low.To16(), high.To16()) > 0 {
+		return fmt.Errorf("allocatable range %s-%s is empty", args.AllocatableIPLow, args.AllocatableIPHigh)
+	}
+	return nil
+}
+
+// normalizeCIDR returns the canonical form of the given CIDR, with
+// the host bits of the address cleared, so that all the ways of writing
+// a subnet identify the same subnet document.
+func normalizeCIDR(cidr string) (string, error) {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return "", err
+	}
+	return ipNet.String(), nil
+}
+
+// GoString implements fmt.GoStringer.
+func (s *Subnet) GoString() string {
+	return fmt.Sprintf(
+		"&state.Subnet{cidr: %q, network: %q, providerId: %q, vlanTag: %v}",
+		s.CIDR(), s.NetworkName(), s.ProviderId(), s.VLANTag())
+}
+
+// CIDR returns the subnet CIDR (e.g. 192.168.50.0/24).
+func (s *Subnet) CIDR() string {
+	return s.doc.CIDR
+}
+
+// NetworkName returns the name of the network the subnet belongs to.
+func (s *Subnet) NetworkName() string {
+	return s.st.localID(s.doc.NetworkName)
+}
+
+// ProviderId returns the provider-specific id of the subnet.
+func (s *Subnet) ProviderId() network.Id {
+	return s.doc.ProviderId
+}
+
+// VLANTag returns the subnet VLAN tag. It's a number between 1 and
+// 4094 for VLANs and 0 if the subnet is not a VLAN.
+func (s *Subnet) VLANTag() int {
+	return s.doc.VLANTag
+}
+
+// AllocatableIPLow returns the first address of the range juju may
+// allocate addresses from, or an empty string if there is none.
+func (s *Subnet) AllocatableIPLow() string {
+	return s.doc.AllocatableIPLow
+}
+
+// AllocatableIPHigh returns the last address of the range juju may
+// allocate addresses from, or an empty string if there is none.
+func (s *Subnet) AllocatableIPHigh() string {
+	return s.doc.AllocatableIPHigh
+}
+
+// AvailabilityZone returns the availability zone
+// of the subnet, if the provider has any.
+func (s *Subnet) AvailabilityZone() string {
+	return s.doc.AvailabilityZone
+}
+
+// Network returns the network the subnet belongs to.
+func (s *Subnet) Network() (*Network, error) {
+	return s.st.Network(s.doc.NetworkName)
+}
+
+// Remove removes the subnet and the IP addresses
+// allocated from it from state.
+func (s *Subnet) Remove() (err error) {
+	defer errors.Maskf(&err, "cannot remove subnet %q", s.doc.CIDR)
+
+	ops, err := s.st.removeIPAddressesOps(bson.D{{"subnetcidr", s.doc.CIDR}})
+	if err != nil {
+		return err
+	}
+	ops = append(ops, txn.Op{
+		C:      subnetsC,
+		Id:     s.doc.CIDR,
+		Remove: true,
+	})
+	// The only abort conditions in play indicate that the subnet
+	// has already been removed.
+	return onAbort(s.st.runTransaction(ops), nil)
+}
+
+// AddSubnet creates a new subnet of the network with the given
+// args. The network must be alive. If a subnet with the same CIDR
+// already exists, an error satisfying errors.IsAlreadyExists is
+// returned.
+func (n *Network) AddSubnet(args SubnetInfo) (subnet *Subnet, err error) {
+	defer errors.Contextf(&err, "cannot add subnet %q to network %q", args.CIDR, n.Name())
+
+	if err := args.validate(n.doc.CIDR); err != nil {
+		return nil, err
+	}
+	if args.CIDR, err = normalizeCIDR(args.CIDR); err != nil {
+		return nil, err
+	}
+	doc := newSubnetDoc(n.doc.Name, args)
+	ops := []txn.Op{{
+		C:      networksC,
+		Id:     n.doc.Name,
+		Assert: networkAliveDoc,
+	}, {
+		C:      subnetsC,
+		Id:     doc.CIDR,
+		Assert: txn.DocMissing,
+		Insert: doc,
+	}}
+	err = n.st.runTransaction(ops)
+	switch err {
+	case txn.ErrAborted:
+		if _, err := n.st.Subnet(args.CIDR); err == nil {
+			return nil, errors.AlreadyExistsf("subnet %q", args.CIDR)
+		} else if !errors.IsNotFound(err) {
+			return nil, err
+		}
+		if err := n.Refresh(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("network is not alive")
+	case nil:
+		return newSubnet(n.st, doc), nil
+	}
+	return nil, err
+}
+
+// Subnets returns all the subnets of the network.
+func (n *Network) Subnets() ([]*Subnet, error) {
+	subnets, closer := n.st.getCollection(subnetsC)
+	defer closer()
+
+	docs := []subnetDoc{}
+	err := subnets.Find(bson.D{{"networkname", n.doc.Name}}).All(&docs)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get subnets of network %q: %v", n.Name(), err)
+	}
+	result := make([]*Subnet, len(docs))
+	for i, doc := range docs {
+		result[i] = newSubnet(n.st, &doc)
+	}
+	return result, nil
+}
+
+// removeSubnetsOps returns the operations that remove all the
+// subnets of the network and the IP addresses allocated from them.
+func (n *Network) removeSubnetsOps() ([]txn.Op, error) {
+	subnets, closer := n.st.getCollection(subnetsC)
+	defer closer()
+
+	var ops []txn.Op
+	iter := subnets.Find(bson.D{{"networkname", n.doc.Name}}).Select(bson.D{{"_id", 1}}).Iter()
+	var doc subnetDoc
+	for iter.Next(&doc) {
+		addressesOps, err := n.st.removeIPAddressesOps(bson.D{{"subnetcidr", doc.CIDR}})
+		if err != nil {
+			iter.Close()
+			return nil, err
+		}
+		ops = append(ops, addressesOps...)
+		ops = append(ops, txn.Op{
+			C:      subnetsC,
+			Id:     doc.CIDR,
+			Remove: true,
+		})
+	}
+	return ops, iter.Close()
+}
+
+// Subnet returns the subnet with the given CIDR. The CIDR may be
+// written in any form, such as 10.0.1.5/24 for the 10.0.1.0/24 subnet.
+func (st *State) Subnet(cidr string) (*Subnet, error) {
+	subnets, closer := st.getCollection(subnetsC)
+	defer closer()
+
+	if normalized, err := normalizeCIDR(cidr); err == nil {
+		cidr = normalized
+	}
+	doc := &subnetDoc{}
+	err := subnets.FindId(cidr).One(doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("subnet %q", cidr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get subnet %q: %v", cidr, err)
+	}
+	return newSubnet(st, doc), nil
+}
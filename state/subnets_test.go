@@ -0,0 +1,161 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+)
+
+type SubnetSuite struct {
+	ConnSuite
+	network *state.Network
+}
+
+var _ = gc.Suite(&SubnetSuite{})
+
+func (s *SubnetSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	var err error
+	s.network, err = s.State.AddNetwork(state.NetworkInfo{"net1", "net1", "10.0.0.0/16", 0, ""})
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *SubnetSuite) TestAddSubnet(c *gc.C) {
+	subnet, err := s.network.AddSubnet(state.SubnetInfo{
+		ProviderId:        "subnet-a",
+		CIDR:              "10.0.1.0/24",
+		VLANTag:           42,
+		AllocatableIPLow:  "10.0.1.10",
+		AllocatableIPHigh: "10.0.1.200",
+		AvailabilityZone:  "zone1",
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(subnet.CIDR(), gc.Equals, "10.0.1.0/24")
+	c.Assert(subnet.NetworkName(), gc.Equals, "net1")
+	c.Assert(string(subnet.ProviderId()), gc.Equals, "subnet-a")
+	c.Assert(subnet.VLANTag(), gc.Equals, 42)
+	c.Assert(subnet.AllocatableIPLow(), gc.Equals, "10.0.1.10")
+	c.Assert(subnet.AllocatableIPHigh(), gc.Equals, "10.0.1.200")
+	c.Assert(subnet.AvailabilityZone(), gc.Equals, "zone1")
+
+	found, err := s.State.Subnet("10.0.1.0/24")
+	c.Assert(err, gc.IsNil)
+	c.Assert(found, jc.DeepEquals, subnet)
+
+	network, err := found.Network()
+	c.Assert(err, gc.IsNil)
+	c.Assert(network.Name(), gc.Equals, "net1")
+}
+
+func (s *SubnetSuite) TestAddSubnetAlreadyExists(c *gc.C) {
+	_, err := s.network.AddSubnet(state.SubnetInfo{CIDR: "10.0.1.0/24"})
+	c.Assert(err, gc.IsNil)
+	_, err = s.network.AddSubnet(state.SubnetInfo{CIDR: "10.0.1.0/24"})
+	c.Assert(err, jc.Satisfies, errors.IsAlreadyExists)
+	c.Assert(err, gc.ErrorMatches, `cannot add subnet "10.0.1.0/24" to network "net1": subnet "10.0.1.0/24" already exists`)
+}
+
+func (s *SubnetSuite) TestAddSubnetNormalizesCIDR(c *gc.C) {
+	subnet, err := s.network.AddSubnet(state.SubnetInfo{CIDR: "10.0.1.5/24"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(subnet.CIDR(), gc.Equals, "10.0.1.0/24")
+
+	subnet, err = s.State.Subnet("10.0.1.7/24")
+	c.Assert(err, gc.IsNil)
+	c.Assert(subnet.CIDR(), gc.Equals, "10.0.1.0/24")
+
+	_, err = s.network.AddSubnet(state.SubnetInfo{CIDR: "10.0.1.0/24"})
+	c.Assert(err, jc.Satisfies, errors.IsAlreadyExists)
+}
+
+func (s *SubnetSuite) TestAddSubnetToDyingNetwork(c *gc.C) {
+	err := s.network.Destroy()
+	c.Assert(err, gc.IsNil)
+	_, err = s.network.AddSubnet(state.SubnetInfo{CIDR: "10.0.1.0/24"})
+	c.Assert(err, gc.ErrorMatches, `cannot add subnet "10.0.1.0/24" to network "net1": network is not alive`)
+}
+
+var invalidSubnetTests = []struct {
+	info state.SubnetInfo
+	err  string
+}{{
+	state.SubnetInfo{},
+	"CIDR must be not empty",
+}, {
+	state.SubnetInfo{CIDR: "10.0.1.0"},
+	"invalid CIDR address: 10.0.1.0",
+}, {
+	state.SubnetInfo{CIDR: "10.1.0.0/24"},
+	`CIDR "10.1.0.0/24" is not within network CIDR "10.0.0.0/16"`,
+}, {
+	state.SubnetInfo{CIDR: "10.0.0.0/8"},
+	`CIDR "10.0.0.0/8" is not within network CIDR "10.0.0.0/16"`,
+}, {
+	state.SubnetInfo{CIDR: "10.0.1.0/24", VLANTag: 4095},
+	"invalid VLAN tag 4095: must be between 0 and 4094",
+}, {
+	state.SubnetInfo{CIDR: "10.0.1.0/24", AllocatableIPLow: "10.0.1.10"},
+	`invalid allocatable range high address ""`,
+}, {
+	state.SubnetInfo{CIDR: "10.0.1.0/24", AllocatableIPLow: "10.0.2.10", AllocatableIPHigh: "10.0.1.20"},
+	`invalid allocatable range low address "10.0.2.10"`,
+}, {
+	state.SubnetInfo{CIDR: "10.0.1.0/24", AllocatableIPLow: "10.0.1.20", AllocatableIPHigh: "10.0.1.10"},
+	"allocatable range 10.0.1.20-10.0.1.10 is empty",
+}}
+
+func (s *SubnetSuite) TestAddSubnetInvalid(c *gc.C) {
+	for i, t := range invalidSubnetTests {
+		c.Logf("test %d: %#v", i, t.info)
+		_, err := s.network.AddSubnet(t.info)
+		c.Check(err, gc.ErrorMatches, `cannot add subnet ".*" to network "net1": `+t.err)
+	}
+}
+
+func (s *SubnetSuite) TestSubnets(c *gc.C) {
+	subnets, err := s.network.Subnets()
+	c.Assert(err, gc.IsNil)
+	c.Assert(subnets, gc.HasLen, 0)
+
+	subnet0, err := s.network.AddSubnet(state.SubnetInfo{CIDR: "10.0.1.0/24", AvailabilityZone: "zone1"})
+	c.Assert(err, gc.IsNil)
+	subnet1, err := s.network.AddSubnet(state.SubnetInfo{CIDR: "10.0.2.0/24", AvailabilityZone: "zone2"})
+	c.Assert(err, gc.IsNil)
+	other, err := s.State.AddNetwork(state.NetworkInfo{"net2", "net2", "", 0, ""})
+	c.Assert(err, gc.IsNil)
+	_, err = other.AddSubnet(state.SubnetInfo{CIDR: "192.168.0.0/24"})
+	c.Assert(err, gc.IsNil)
+
+	subnets, err = s.network.Subnets()
+	c.Assert(err, gc.IsNil)
+	c.Assert(subnets, jc.DeepEquals, []*state.Subnet{subnet0, subnet1})
+}
+
+func (s *SubnetSuite) TestRemove(c *gc.C) {
+	subnet, err := s.network.AddSubnet(state.SubnetInfo{CIDR: "10.0.1.0/24"})
+	c.Assert(err, gc.IsNil)
+	err = subnet.Remove()
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.Subnet("10.0.1.0/24")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	// Removing it again does nothing.
+	err = subnet.Remove()
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *SubnetSuite) TestRemovedWithNetwork(c *gc.C) {
+	_, err := s.network.AddSubnet(state.SubnetInfo{CIDR: "10.0.1.0/24"})
+	c.Assert(err, gc.IsNil)
+	err = s.network.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = s.network.Remove()
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.Subnet("10.0.1.0/24")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
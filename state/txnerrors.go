@@ -0,0 +1,139 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+
+	"github.com/juju/errors"
+	jujutxn "github.com/juju/txn"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+)
+
+// entityKinds holds the kinds of entity described by the documents of
+// collections, as used in the errors explaining failed assertions.
+// Documents of other collections are described as documents.
+var entityKinds = map[string]string{
+	environmentsC:       "environment",
+	charmsC:             "charm",
+	machinesC:           "machine",
+	relationsC:          "relation",
+	servicesC:           "service",
+	networksC:           "network",
+	subnetsC:            "subnet",
+	blockDevicesC:       "block device",
+	storageInstancesC:   "storage instance",
+	ipAddressesC:        "IP address",
+	unitsC:              "unit",
+	usersC:              "user",
+	settingsC:           "settings",
+	containerAddressesC: "container address",
+}
+
+// describeDoc returns a description of the document
+// with the given id in the given collection.
+func describeDoc(collection string, id interface{}) string {
+	if kind, ok := entityKinds[collection]; ok {
+		return fmt.Sprintf("%s %v", kind, id)
+	}
+	return fmt.Sprintf("document %v in %s", id, collection)
+}
+
+// runDiagnosed is like run, except that when the transactions built by
+// the source are still aborted after being retried, it returns an
+// error describing the assertion of the last transaction that failed,
+// such as "machine 3 is dead", instead of ErrExcessiveContention.
+func (st *State) runDiagnosed(transactions jujutxn.TransactionSource) error {
+	var lastOps []txn.Op
+	err := st.run(func(attempt int) ([]txn.Op, error) {
+		ops, err := transactions(attempt)
+		lastOps = ops
+		return ops, err
+	})
+	if err != jujutxn.ErrExcessiveContention {
+		return err
+	}
+	if cause := st.abortCause(lastOps); cause != nil {
+		return cause
+	}
+	return err
+}
+
+// abortCause returns an error describing the first assertion of the
+// given operations that does not hold, or nil if they all hold, as
+// they may do once the transaction that aborted them has completed.
+// The error satisfies errors.IsNotFound if a document asserted to
+// exist does not, and errors.IsAlreadyExists if a document asserted
+// to be missing exists.
+func (st *State) abortCause(ops []txn.Op) error {
+	for _, op := range ops {
+		if op.Assert == nil {
+			continue
+		}
+		if err := st.assertionFailure(op); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// assertionFailure returns an error describing why the assertion
+// of the given operation does not hold, or nil if it does.
+func (st *State) assertionFailure(op txn.Op) error {
+	coll, closer := st.getCollection(op.C)
+	defer closer()
+
+	what := describeDoc(op.C, op.Id)
+	var doc struct {
+		Life *Life
+	}
+	err := coll.FindId(op.Id).Select(bson.D{{"life", 1}}).One(&doc)
+	if err != nil && err != mgo.ErrNotFound {
+		return fmt.Errorf("cannot check assertion on %s: %v", what, err)
+	}
+	exists := err == nil
+	if assert, ok := op.Assert.(string); ok {
+		switch {
+		case assert == txn.DocExists && !exists:
+			return errors.NotFoundf("%s", what)
+		case assert == txn.DocMissing && exists:
+			return errors.AlreadyExistsf("%s", what)
+		}
+		return nil
+	}
+	if !exists {
+		return errors.NotFoundf("%s", what)
+	}
+	sel := bson.D{{"$and", []interface{}{bson.D{{"_id", op.Id}}, op.Assert}}}
+	count, err := coll.Find(sel).Count()
+	if err != nil {
+		return fmt.Errorf("cannot check assertion on %s: %v", what, err)
+	}
+	if count > 0 {
+		return nil
+	}
+	if doc.Life != nil && *doc.Life != Alive && assertsLife(op.Assert) {
+		return fmt.Errorf("%s is %s", what, *doc.Life)
+	}
+	return fmt.Errorf("%s has changed", what)
+}
+
+// assertsLife returns whether the given assertion
+// refers to the life of the document.
+func assertsLife(assert interface{}) bool {
+	switch assert := assert.(type) {
+	case bson.D:
+		for _, elem := range assert {
+			if elem.Name == "life" {
+				return true
+			}
+		}
+	case bson.M:
+		_, ok := assert["life"]
+		return ok
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"github.com/juju/errors"
+	gitjujutesting "github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/testing"
+)
+
+type TxnErrorsSuite struct {
+	testing.BaseSuite
+	gitjujutesting.MgoSuite
+	state *State
+}
+
+var _ = gc.Suite(&TxnErrorsSuite{})
+
+func (s *TxnErrorsSuite) SetUpSuite(c *gc.C) {
+	s.BaseSuite.SetUpSuite(c)
+	s.MgoSuite.SetUpSuite(c)
+}
+
+func (s *TxnErrorsSuite) TearDownSuite(c *gc.C) {
+	s.MgoSuite.TearDownSuite(c)
+	s.BaseSuite.TearDownSuite(c)
+}
+
+func (s *TxnErrorsSuite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	s.MgoSuite.SetUpTest(c)
+	st, err := Open(TestingMongoInfo(), TestingDialOpts(), Policy(nil))
+	c.Assert(err, gc.IsNil)
+	s.state = st
+
+	err = s.state.runTransaction([]txn.Op{{
+		C:      machinesC,
+		Id:     "0",
+		Assert: txn.DocMissing,
+		Insert: &machineDoc{Id: "0", Life: Alive},
+	}, {
+		C:      machinesC,
+		Id:     "3",
+		Assert: txn.DocMissing,
+		Insert: &machineDoc{Id: "3", Life: Dead},
+	}})
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *TxnErrorsSuite) TearDownTest(c *gc.C) {
+	s.state.Close()
+	s.MgoSuite.TearDownTest(c)
+	s.BaseSuite.TearDownTest(c)
+}
+
+func (s *TxnErrorsSuite) TestAbortCause(c *gc.C) {
+	for i, test := range []struct {
+		about string
+		op    txn.Op
+		err   string
+		check func(error) bool
+	}{{
+		about: "assertions holding",
+		op:    txn.Op{C: machinesC, Id: "0", Assert: isAliveDoc},
+	}, {
+		about: "document missing",
+		op:    txn.Op{C: unitsC, Id: "wordpress/0", Assert: txn.DocExists},
+		err:   "unit wordpress/0 not found",
+		check: errors.IsNotFound,
+	}, {
+		about: "document existing",
+		op:    txn.Op{C: machinesC, Id: "0", Assert: txn.DocMissing},
+		err:   "machine 0 already exists",
+		check: errors.IsAlreadyExists,
+	}, {
+		about: "document missing for field assertion",
+		op:    txn.Op{C: machinesC, Id: "42", Assert: notDeadDoc},
+		err:   "machine 42 not found",
+		check: errors.IsNotFound,
+	}, {
+		about: "entity not alive",
+		op:    txn.Op{C: machinesC, Id: "3", Assert: notDeadDoc},
+		err:   "machine 3 is dead",
+	}, {
+		about: "other field assertion",
+		op:    txn.Op{C: machinesC, Id: "0", Assert: bson.D{{"series", "precise"}}},
+		err:   "machine 0 has changed",
+	}, {
+		about: "collection of no entity kind",
+		op:    txn.Op{C: "things", Id: "x", Assert: txn.DocExists},
+		err:   "document x in things not found",
+	}} {
+		c.Logf("test %d: %s", i, test.about)
+		err := s.state.abortCause([]txn.Op{
+			{C: machinesC, Id: "0", Assert: txn.DocExists},
+			test.op,
+		})
+		if test.err == "" {
+			c.Check(err, gc.IsNil)
+			continue
+		}
+		c.Check(err, gc.ErrorMatches, test.err)
+		if test.check != nil {
+			c.Check(err, jc.Satisfies, test.check)
+		}
+	}
+}
+
+func (s *TxnErrorsSuite) TestRunDiagnosed(c *gc.C) {
+	attempts := 0
+	err := s.state.runDiagnosed(func(attempt int) ([]txn.Op, error) {
+		attempts++
+		return []txn.Op{{
+			C:      machinesC,
+			Id:     "3",
+			Assert: isAliveDoc,
+			Update: bson.D{{"$set", bson.D{{"nonce", "foo"}}}},
+		}}, nil
+	})
+	c.Assert(err, gc.ErrorMatches, "machine 3 is dead")
+	c.Assert(attempts > 1, jc.IsTrue)
+}
+
+func (s *TxnErrorsSuite) TestRunDiagnosedSuccess(c *gc.C) {
+	err := s.state.runDiagnosed(func(attempt int) ([]txn.Op, error) {
+		return []txn.Op{{
+			C:      machinesC,
+			Id:     "0",
+			Assert: isAliveDoc,
+			Update: bson.D{{"$set", bson.D{{"nonce", "foo"}}}},
+		}}, nil
+	})
+	c.Assert(err, gc.IsNil)
+}
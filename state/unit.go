@@ -67,6 +67,7 @@ const (
 // Note the correspondence with UnitInfo in state/api/params.
 type unitDoc struct {
 	Name         string `bson:"_id"`
+	EnvUUID      string `bson:"env-uuid,omitempty"`
 	Service      string
 	Series       string
 	CharmURL     *charm.URL
@@ -79,6 +80,11 @@ type unitDoc struct {
 	Life         Life
 	TxnRevno     int64 `bson:"txn-revno"`
 	PasswordHash string
+	// NetworkPortsRevno is incremented whenever the unit opens or
+	// closes ports on a network other than the default public one,
+	// which are not recorded in Ports, so that watchers of the unit
+	// notice.
+	NetworkPortsRevno int64 `bson:",omitempty"`
 
 	// No longer used - to be removed.
 	PublicAddress  string
@@ -136,7 +142,7 @@ func (u *Unit) ConfigSettings() (charm.Settings, error) {
 
 // ServiceName returns the service name.
 func (u *Unit) ServiceName() string {
-	return u.doc.Service
+	return u.st.localID(u.doc.Service)
 }
 
 // Series returns the deployed charm's series.
@@ -146,12 +152,12 @@ func (u *Unit) Series() string {
 
 // String returns the unit as string.
 func (u *Unit) String() string {
-	return u.doc.Name
+	return u.Name()
 }
 
 // Name returns the unit name.
 func (u *Unit) Name() string {
-	return u.doc.Name
+	return u.st.localID(u.doc.Name)
 }
 
 // unitGlobalKey returns the global database key for the named unit.
@@ -331,7 +337,7 @@ func (u *Unit) destroyOps() ([]txn.Op, error) {
 	// lose much time and (2) by maintaining this restriction, I can reduce
 	// the number of tests that have to change and defer that improvement to
 	// its own CL.
-	minUnitsOp := minUnitsTriggerOp(u.st, u.ServiceName())
+	minUnitsOp := minUnitsTriggerOp(u.st, u.doc.Service)
 	cleanupOp := u.st.newCleanupOp(cleanupDyingUnit, u.doc.Name)
 	setDyingOps := []txn.Op{{
 		C:      unitsC,
@@ -567,7 +573,12 @@ func (u *Unit) Remove() (err error) {
 		}
 		return nil, jujutxn.ErrNoOperations
 	}
-	return unit.st.run(buildTxn)
+	if err := unit.st.runDiagnosed(buildTxn); err != nil {
+		return err
+	}
+	// The status history is not written transactionally, so it
+	// is removed only once the unit itself is gone.
+	return removeStatusHistory(u.st, u.Tag())
 }
 
 // Resolved returns the resolved mode for the unit.
@@ -584,7 +595,9 @@ func (u *Unit) IsPrincipal() bool {
 // SubordinateNames returns the names of any subordinate units.
 func (u *Unit) SubordinateNames() []string {
 	names := make([]string, len(u.doc.Subordinates))
-	copy(names, u.doc.Subordinates)
+	for i, name := range u.doc.Subordinates {
+		names[i] = u.st.localID(name)
+	}
 	return names
 }
 
@@ -631,9 +644,9 @@ func (u *Unit) relations(predicate relationPredicate) ([]*Relation, error) {
 // the unit. If no such entity can be determined, false is returned.
 func (u *Unit) DeployerTag() (names.Tag, bool) {
 	if u.doc.Principal != "" {
-		return names.NewUnitTag(u.doc.Principal), true
+		return names.NewUnitTag(u.st.localID(u.doc.Principal)), true
 	} else if u.doc.MachineId != "" {
-		return names.NewMachineTag(u.doc.MachineId), true
+		return names.NewMachineTag(u.st.localID(u.doc.MachineId)), true
 	}
 	return nil, false
 }
@@ -641,7 +654,7 @@ func (u *Unit) DeployerTag() (names.Tag, bool) {
 // PrincipalName returns the name of the unit's principal.
 // If the unit is not a subordinate, false is returned.
 func (u *Unit) PrincipalName() (string, bool) {
-	return u.doc.Principal, u.doc.Principal != ""
+	return u.st.localID(u.doc.Principal), u.doc.Principal != ""
 }
 
 // addressesOfMachine returns Addresses of the related machine if present.
@@ -733,11 +746,19 @@ func (u *Unit) SetStatus(status params.Status, info string, data params.StatusDa
 	if err != nil {
 		return fmt.Errorf("cannot set status of unit %q: %v", u, onAbort(err, errDead))
 	}
+	recordStatusHistory(u.st, u.Tag(), doc)
 	return nil
 }
 
-// OpenPort sets the policy of the port with protocol and number to be opened.
-func (u *Unit) OpenPort(protocol string, number int) (err error) {
+// OpenPort sets the policy of the port with protocol and number to be
+// opened on the default public network.
+func (u *Unit) OpenPort(protocol string, number int) error {
+	return u.OpenPortOnNetwork(network.DefaultPublic, protocol, number)
+}
+
+// OpenPortOnNetwork sets the policy of the port with protocol and
+// number to be opened on the given network of the unit's machine.
+func (u *Unit) OpenPortOnNetwork(networkName, protocol string, number int) (err error) {
 	ports, err := NewPortRange(u.Name(), number, number, protocol)
 	if err != nil {
 		return err
@@ -749,15 +770,20 @@ func (u *Unit) OpenPort(protocol string, number int) (err error) {
 		return err
 	}
 
-	machinePorts, err := getOrCreatePorts(u.st, machineId)
+	machinePorts, err := getOrCreatePorts(u.st, machineId, networkName)
+	if err != nil {
+		return err
+	}
 
 	// Check if this unit is still storing ports in its own document,
-	// if so - attempt a migration.
+	// if so - attempt a migration. The ports in the unit document
+	// were all opened on the default public network.
 	// Migration is only performed if the openedPorts document contains
 	// no ports for the unit - this condition will be removed when
 	// the unit ports list will be cleared after migration.
 	// TODO(domas) 2014-07-04 bug #1337817: remove second condition
-	if len(u.doc.Ports) != 0 && len(machinePorts.PortsForUnit(u.Name())) == 0 {
+	isDefault := networkName == network.DefaultPublic
+	if isDefault && len(u.doc.Ports) != 0 && len(machinePorts.PortsForUnit(u.Name())) == 0 {
 		err = machinePorts.migratePorts(u)
 		if err != nil {
 			unitLogger.Errorf("could not migrate ports collection for unit %v: %v", u, err)
@@ -773,10 +799,30 @@ func (u *Unit) OpenPort(protocol string, number int) (err error) {
 	if err != nil {
 		return err
 	}
+	if !isDefault {
+		return u.networkPortsChanged()
+	}
 	// TODO(domas) 2014-07-04 bug #1337813: remove once firewaller is updated to watch openedPorts collection
 	return u.openUnitPort(protocol, number)
 }
 
+// networkPortsChanged records on the unit document that the unit has
+// opened or closed ports on a network other than the default public
+// one, so that the firewaller, which watches the unit, notices.
+// TODO(domas) 2014-07-04 bug #1337813: remove once firewaller is updated to watch openedPorts collection
+func (u *Unit) networkPortsChanged() error {
+	ops := []txn.Op{{
+		C:      unitsC,
+		Id:     u.doc.Name,
+		Assert: notDeadDoc,
+		Update: bson.D{{"$inc", bson.D{{"networkportsrevno", 1}}}},
+	}}
+	if err := u.st.runTransaction(ops); err != nil {
+		return onAbort(err, errDead)
+	}
+	return nil
+}
+
 // openUnitPort is the old implementation of OpenPort that amends the list of ports on the unit document.
 // TODO(domas) 2014-07-04 bug #1337813
 // This is kept in place until the firewaller is updated to watch the OpenedPorts collection.
@@ -832,8 +878,15 @@ func (u *Unit) closeUnitPort(protocol string, number int) (err error) {
 	return nil
 }
 
-// ClosePort sets the policy of the port with protocol and number to be closed.
-func (u *Unit) ClosePort(protocol string, number int) (err error) {
+// ClosePort sets the policy of the port with protocol and number to be
+// closed on the default public network.
+func (u *Unit) ClosePort(protocol string, number int) error {
+	return u.ClosePortOnNetwork(network.DefaultPublic, protocol, number)
+}
+
+// ClosePortOnNetwork sets the policy of the port with protocol and
+// number to be closed on the given network of the unit's machine.
+func (u *Unit) ClosePortOnNetwork(networkName, protocol string, number int) (err error) {
 	ports, err := NewPortRange(u.Name(), number, number, protocol)
 	if err != nil {
 		return err
@@ -845,7 +898,7 @@ func (u *Unit) ClosePort(protocol string, number int) (err error) {
 		return err
 	}
 
-	machinePorts, err := getOrCreatePorts(u.st, machineId)
+	machinePorts, err := getOrCreatePorts(u.st, machineId, networkName)
 	if err != nil {
 		return err
 	}
@@ -853,7 +906,8 @@ func (u *Unit) ClosePort(protocol string, number int) (err error) {
 	// Check if this unit is still storing ports in its own document,
 	// if so - attempt a migration.
 	// TODO(domas) 2014-07-04 bug #1337817: remove second condition
-	if len(u.doc.Ports) != 0 && len(machinePorts.PortsForUnit(u.Name())) == 0 {
+	isDefault := networkName == network.DefaultPublic
+	if isDefault && len(u.doc.Ports) != 0 && len(machinePorts.PortsForUnit(u.Name())) == 0 {
 		err = machinePorts.migratePorts(u)
 		if err != nil {
 			unitLogger.Errorf("could not migrate ports collection for unit %v: %v", u, err)
@@ -869,11 +923,15 @@ func (u *Unit) ClosePort(protocol string, number int) (err error) {
 	if err != nil {
 		return err
 	}
+	if !isDefault {
+		return u.networkPortsChanged()
+	}
 	// TODO(domas) 2014-07-04 bug #1337813: remove once firewaller is updated to watch openedPorts collection
 	return u.closeUnitPort(protocol, number)
 }
 
-// OpenedPorts returns a slice containing the open ports of the unit.
+// OpenedPorts returns a slice containing the open ports of the unit
+// on the default public network.
 // TODO(domas) 2014-07-04 but #1337817: update this function to return port ranges.
 func (u *Unit) OpenedPorts() []network.Port {
 	machineId, err := u.AssignedMachineId()
@@ -882,7 +940,7 @@ func (u *Unit) OpenedPorts() []network.Port {
 		return nil
 	}
 
-	machinePorts, err := getPorts(u.st, machineId)
+	machinePorts, err := getPorts(u.st, machineId, network.DefaultPublic)
 	result := []network.Port{}
 	if err == nil {
 		ports := machinePorts.PortsForUnit(u.Name())
@@ -900,6 +958,67 @@ func (u *Unit) OpenedPorts() []network.Port {
 	return result
 }
 
+// OpenedPortsOnNetwork returns the port ranges the unit has opened on
+// the given network of its machine.
+func (u *Unit) OpenedPortsOnNetwork(networkName string) ([]PortRange, error) {
+	machineId, err := u.AssignedMachineId()
+	if err != nil {
+		return nil, err
+	}
+	machinePorts, err := getPorts(u.st, machineId, networkName)
+	if errors.IsNotFound(err) {
+		return []PortRange{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return machinePorts.PortsForUnit(u.Name()), nil
+}
+
+// OpenedPortsOnNetworks returns the ports the unit has opened on each
+// network of its machine, keyed by network name. Networks on which
+// the unit has opened no ports are omitted. The ports are sorted.
+func (u *Unit) OpenedPortsOnNetworks() (map[string][]network.Port, error) {
+	machineId, err := u.AssignedMachineId()
+	if err != nil {
+		return nil, err
+	}
+	machine, err := u.st.Machine(machineId)
+	if err != nil {
+		return nil, err
+	}
+	machinePorts, err := machine.OpenedPorts(u.st)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string][]network.Port)
+	for _, ports := range machinePorts {
+		networkName, err := ports.NetworkName()
+		if err != nil {
+			return nil, err
+		}
+		if networkName == network.DefaultPublic {
+			// The default network may still be
+			// recorded in the unit document.
+			continue
+		}
+		var opened []network.Port
+		for _, port := range ports.PortsForUnit(u.Name()) {
+			opened = append(opened, network.Port{
+				Protocol: port.Protocol,
+				Number:   port.FromPort,
+			})
+		}
+		if len(opened) > 0 {
+			network.SortPorts(opened)
+			result[networkName] = opened
+		}
+	}
+	if opened := u.OpenedPorts(); len(opened) > 0 {
+		result[network.DefaultPublic] = opened
+	}
+	return result, nil
+}
+
 // CharmURL returns the charm URL this unit is currently using.
 func (u *Unit) CharmURL() (*charm.URL, bool) {
 	if u.doc.CharmURL == nil {
@@ -970,7 +1089,7 @@ func (u *Unit) SetCharmURL(curl *charm.URL) (err error) {
 		}
 		return ops, nil
 	}
-	return u.st.run(buildTxn)
+	return u.st.runDiagnosed(buildTxn)
 }
 
 // AgentPresence returns whether the respective remote agent is alive.
@@ -1044,7 +1163,7 @@ func (u *Unit) AssignedMachineId() (id string, err error) {
 		if u.doc.MachineId == "" {
 			return "", &NotAssignedError{u}
 		}
-		return u.doc.MachineId, nil
+		return u.st.localID(u.doc.MachineId), nil
 	}
 
 	units, closer := u.st.getCollection(unitsC)
@@ -1053,14 +1172,14 @@ func (u *Unit) AssignedMachineId() (id string, err error) {
 	pudoc := unitDoc{}
 	err = units.Find(bson.D{{"_id", u.doc.Principal}}).One(&pudoc)
 	if err == mgo.ErrNotFound {
-		return "", errors.NotFoundf("principal unit %q of %q", u.doc.Principal, u)
+		return "", errors.NotFoundf("principal unit %q of %q", u.st.localID(u.doc.Principal), u)
 	} else if err != nil {
 		return "", err
 	}
 	if pudoc.MachineId == "" {
 		return "", &NotAssignedError{u}
 	}
-	return pudoc.MachineId, nil
+	return u.st.localID(pudoc.MachineId), nil
 }
 
 var (
@@ -1083,7 +1202,7 @@ func (u *Unit) assignToMachine(m *Machine, unused bool) (err error) {
 		return fmt.Errorf("series does not match")
 	}
 	if u.doc.MachineId != "" {
-		if u.doc.MachineId != m.Id() {
+		if u.doc.MachineId != m.doc.Id {
 			return alreadyAssignedErr
 		}
 		return nil
@@ -1101,6 +1220,9 @@ func (u *Unit) assignToMachine(m *Machine, unused bool) (err error) {
 	if !canHost {
 		return fmt.Errorf("machine %q cannot host units", m)
 	}
+	if m.doc.Maintenance {
+		return fmt.Errorf("machine %q is in maintenance", m)
+	}
 	// assignToMachine implies assignment to an existing machine,
 	// which is only permitted if unit placement is supported.
 	if err := u.st.supportsUnitPlacement(); err != nil {
@@ -1109,10 +1231,10 @@ func (u *Unit) assignToMachine(m *Machine, unused bool) (err error) {
 	assert := append(isAliveDoc, bson.D{
 		{"$or", []bson.D{
 			{{"machineid", ""}},
-			{{"machineid", m.Id()}},
+			{{"machineid", m.doc.Id}},
 		}},
 	}...)
-	massert := isAliveDoc
+	massert := append(isAliveDoc, notInMaintenance...)
 	if unused {
 		massert = append(massert, bson.D{{"clean", bson.D{{"$ne", false}}}}...)
 	}
@@ -1149,12 +1271,17 @@ func (u *Unit) assignToMachine(m *Machine, unused bool) (err error) {
 		return unitNotAliveErr
 	case m0.Life() != Alive:
 		return machineNotAliveErr
+	case m0.doc.Maintenance:
+		return fmt.Errorf("machine %q is in maintenance", m)
 	case u0.doc.MachineId != "" || !unused:
 		return alreadyAssignedErr
 	}
 	return inUseErr
 }
 
+// notInMaintenance asserts that a machine is not in maintenance mode.
+var notInMaintenance = bson.D{{"maintenance", bson.D{{"$ne", true}}}}
+
 func assignContextf(err *error, unit *Unit, target string) {
 	if *err != nil {
 		*err = fmt.Errorf("cannot assign unit %q to %s: %v", unit, target, *err)
@@ -1171,6 +1298,7 @@ func (u *Unit) AssignToMachine(m *Machine) (err error) {
 // the supplied params, with the supplied constraints.
 func (u *Unit) assignToNewMachine(template MachineTemplate, parentId string, containerType instance.ContainerType) error {
 	template.principals = []string{u.doc.Name}
+	template.service = u.doc.Service
 	template.Dirty = true
 
 	var (
@@ -1186,9 +1314,11 @@ func (u *Unit) assignToNewMachine(template MachineTemplate, parentId string, con
 			return fmt.Errorf("assignToNewMachine called without container type (should never happen)")
 		}
 		// The new parent machine is clean and only hosts units,
-		// regardless of its child.
+		// regardless of its child. It is created for the unit's
+		// service, but the unit is deployed to the child.
 		parentParams := template
 		parentParams.Jobs = []MachineJob{JobHostUnits}
+		parentParams.principals = nil
 		mdoc, ops, err = u.st.addMachineInsideNewMachineOps(template, parentParams, containerType)
 	default:
 		// Container type is specified but no parent id.
@@ -1202,7 +1332,7 @@ func (u *Unit) assignToNewMachine(template MachineTemplate, parentId string, con
 		ops = append(ops, txn.Op{
 			C:      machinesC,
 			Id:     parentId,
-			Assert: bson.D{{"clean", true}},
+			Assert: append(bson.D{{"clean", true}}, notInMaintenance...),
 		}, txn.Op{
 			C:      containerRefsC,
 			Id:     parentId,
@@ -1233,7 +1363,7 @@ func (u *Unit) assignToNewMachine(template MachineTemplate, parentId string, con
 	//  * the unit is no longer alive
 	//  * the unit has been assigned to a different machine
 	//  * the parent machine we want to create a container on was
-	//  clean but became dirty
+	//  clean but became dirty, or was put in maintenance
 	unit, err := u.st.Unit(u.Name())
 	if err != nil {
 		return err
@@ -1251,7 +1381,7 @@ func (u *Unit) assignToNewMachine(template MachineTemplate, parentId string, con
 	if err != nil {
 		return err
 	}
-	if !m.Clean() {
+	if !m.Clean() || m.InMaintenance() {
 		return machineNotCleanErr
 	}
 	containers, err := m.Containers()
@@ -1436,8 +1566,10 @@ func (u *Unit) findCleanMachineQuery(requireEmpty bool, cons *constraints.Value)
 		{"series", u.doc.Series},
 		{"jobs", []MachineJob{JobHostUnits}},
 		{"clean", true},
+		{"maintenance", bson.D{{"$ne", true}}},
 		{"_id", bson.D{{"$nin", machinesWithContainers}}},
 	}
+	terms = append(terms, u.st.environSelector()...)
 	// Add the container filter term if necessary.
 	var containerType instance.ContainerType
 	if cons.Container != nil {
@@ -1642,7 +1774,7 @@ func (u *Unit) AddAction(name string, payload map[string]interface{}) (*Action,
 		}
 		return ops, nil
 	}
-	if err = u.st.run(buildTxn); err == nil {
+	if err = u.st.runDiagnosed(buildTxn); err == nil {
 		return newAction(u.st, doc), nil
 	}
 	return nil, err
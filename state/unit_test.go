@@ -21,7 +21,7 @@ import (
 )
 
 const (
-	contentionErr = ".*: state changing too quickly; try again soon"
+	unitDeadErr = ".*: unit .* is dead"
 )
 
 type UnitSuite struct {
@@ -1119,7 +1119,7 @@ func (s *UnitSuite) TestOpenClosePortWhenDying(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 
 	preventUnitDestroyRemove(c, s.unit)
-	testWhenDying(c, s.unit, noErr, contentionErr, func() error {
+	testWhenDying(c, s.unit, noErr, unitDeadErr, func() error {
 		err := s.unit.OpenPort("tcp", 20)
 		if err != nil {
 			return err
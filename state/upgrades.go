@@ -0,0 +1,44 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"github.com/juju/errors"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+)
+
+// AddEnvironmentAccessForUsers grants admin access to the state's
+// environment to all the users that have not been granted any access
+// to it, so that users added before access was recorded per
+// environment can still log in.
+func AddEnvironmentAccessForUsers(st *State) error {
+	users, closer := st.getCollection(usersC)
+	defer closer()
+	environUsers, closer := st.getCollection(environUsersC)
+	defer closer()
+
+	var ops []txn.Op
+	iter := users.Find(nil).Select(bson.D{{"_id", 1}}).Iter()
+	var doc userDoc
+	for iter.Next(&doc) {
+		count, err := environUsers.FindId(st.environUserId(doc.Name)).Count()
+		if err != nil {
+			iter.Close()
+			return errors.Trace(err)
+		}
+		if count == 0 {
+			ops = append(ops, st.addEnvironUserOp(doc.Name, EnvironmentAdminAccess))
+		}
+	}
+	if err := iter.Close(); err != nil {
+		return errors.Trace(err)
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+	// Users granted access concurrently would abort the transaction;
+	// the upgrade step is simply run again.
+	return errors.Trace(st.runTransaction(ops))
+}
@@ -0,0 +1,72 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+
+	"github.com/juju/juju/version"
+)
+
+// upgradeStepsDoc records the upgrade steps of a Juju version that
+// have been completed against the database. Steps are identified by
+// their descriptions.
+type upgradeStepsDoc struct {
+	Id    string `bson:"_id"`
+	Steps []string
+}
+
+// CompletedUpgradeSteps returns the descriptions of the upgrade steps
+// for the given version that have been recorded as completed.
+func (st *State) CompletedUpgradeSteps(vers version.Number) ([]string, error) {
+	upgradeSteps, closer := st.getCollection(upgradeStepsC)
+	defer closer()
+
+	var doc upgradeStepsDoc
+	err := upgradeSteps.FindId(vers.String()).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get upgrade steps completed for version %v: %v", vers, err)
+	}
+	return doc.Steps, nil
+}
+
+// SetUpgradeStepCompleted records that the upgrade step with the given
+// description for the given version has been completed, so that it is
+// not run again should the upgrade be retried.
+func (st *State) SetUpgradeStepCompleted(vers version.Number, description string) error {
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		completed, err := st.CompletedUpgradeSteps(vers)
+		if err != nil {
+			return nil, err
+		}
+		if completed == nil {
+			return []txn.Op{{
+				C:      upgradeStepsC,
+				Id:     vers.String(),
+				Assert: txn.DocMissing,
+				Insert: &upgradeStepsDoc{
+					Id:    vers.String(),
+					Steps: []string{description},
+				},
+			}}, nil
+		}
+		return []txn.Op{{
+			C:      upgradeStepsC,
+			Id:     vers.String(),
+			Assert: txn.DocExists,
+			Update: bson.D{{"$addToSet", bson.D{{"steps", description}}}},
+		}}, nil
+	}
+	if err := st.runDiagnosed(buildTxn); err != nil {
+		return fmt.Errorf("cannot record upgrade step %q completed: %v", description, err)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/version"
+)
+
+type UpgradeStepsSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&UpgradeStepsSuite{})
+
+func (s *UpgradeStepsSuite) TestCompletedUpgradeStepsNone(c *gc.C) {
+	steps, err := s.State.CompletedUpgradeSteps(version.MustParse("1.21.0"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(steps, gc.HasLen, 0)
+}
+
+func (s *UpgradeStepsSuite) TestSetUpgradeStepCompleted(c *gc.C) {
+	v121 := version.MustParse("1.21.0")
+	v122 := version.MustParse("1.22.0")
+	err := s.State.SetUpgradeStepCompleted(v121, "step 1")
+	c.Assert(err, gc.IsNil)
+	err = s.State.SetUpgradeStepCompleted(v121, "step 2")
+	c.Assert(err, gc.IsNil)
+	err = s.State.SetUpgradeStepCompleted(v122, "step 1")
+	c.Assert(err, gc.IsNil)
+
+	// Recording a step again changes nothing.
+	err = s.State.SetUpgradeStepCompleted(v121, "step 1")
+	c.Assert(err, gc.IsNil)
+
+	steps, err := s.State.CompletedUpgradeSteps(v121)
+	c.Assert(err, gc.IsNil)
+	c.Assert(steps, gc.DeepEquals, []string{"step 1", "step 2"})
+	steps, err = s.State.CompletedUpgradeSteps(v122)
+	c.Assert(err, gc.IsNil)
+	c.Assert(steps, gc.DeepEquals, []string{"step 1"})
+}
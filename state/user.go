@@ -31,11 +31,21 @@ func (st *State) AddAdminUser(password string) (*User, error) {
 	return st.AddUser("admin", "", password, "")
 }
 
-// AddUser adds a user to the state.
+// AddUser adds a user to the state, with admin access
+// to the state's environment.
 func (st *State) AddUser(username, displayName, password, creator string) (*User, error) {
+	return st.AddUserWithAccess(username, displayName, password, creator, EnvironmentAdminAccess)
+}
+
+// AddUserWithAccess adds a user to the state, with the
+// given access to the state's environment.
+func (st *State) AddUserWithAccess(username, displayName, password, creator string, access EnvironmentAccess) (*User, error) {
 	if !names.IsValidUser(username) {
 		return nil, errors.Errorf("invalid user name %q", username)
 	}
+	if err := access.Validate(); err != nil {
+		return nil, errors.Trace(err)
+	}
 	salt, err := utils.RandomSalt()
 	if err != nil {
 		return nil, err
@@ -57,7 +67,7 @@ func (st *State) AddUser(username, displayName, password, creator string) (*User
 		Id:     username,
 		Assert: txn.DocMissing,
 		Insert: &u.doc,
-	}}
+	}, st.addEnvironUserOp(username, access)}
 	err = st.runTransaction(ops)
 	if err == txn.ErrAborted {
 		err = errors.New("user already exists")
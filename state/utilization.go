@@ -0,0 +1,131 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// MaxUtilizationSamples is the maximum number of utilization samples
+// retained for each machine. Older samples are discarded as new ones
+// are recorded.
+var MaxUtilizationSamples = 60
+
+// UtilizationSample holds a single sample of the resources in use
+// on a machine.
+type UtilizationSample struct {
+	// Time is when the sample was taken.
+	Time time.Time
+
+	// CPUPercent is the percentage of CPU time spent non-idle since
+	// the previous sample, across all cores.
+	CPUPercent float64
+
+	// MemUsed and MemTotal are the used and total memory in MiB.
+	MemUsed  uint64
+	MemTotal uint64
+
+	// DiskUsed and DiskTotal are the used and total space in MiB
+	// of the filesystem holding the juju data directory.
+	DiskUsed  uint64
+	DiskTotal uint64
+}
+
+// utilizationDoc records a single utilization sample of a machine.
+// Samples are written directly rather than through transactions:
+// they are high volume, independent of each other, and losing one
+// is harmless.
+type utilizationDoc struct {
+	Id         bson.ObjectId `bson:"_id"`
+	MachineId  string
+	Time       time.Time
+	CPUPercent float64
+	MemUsed    uint64
+	MemTotal   uint64
+	DiskUsed   uint64
+	DiskTotal  uint64
+}
+
+func (doc *utilizationDoc) sample() UtilizationSample {
+	return UtilizationSample{
+		Time:       doc.Time,
+		CPUPercent: doc.CPUPercent,
+		MemUsed:    doc.MemUsed,
+		MemTotal:   doc.MemTotal,
+		DiskUsed:   doc.DiskUsed,
+		DiskTotal:  doc.DiskTotal,
+	}
+}
+
+// RecordUtilization stores the given resource utilization sample for
+// the machine, discarding the oldest samples beyond
+// MaxUtilizationSamples.
+func (m *Machine) RecordUtilization(sample UtilizationSample) error {
+	if m.doc.Life == Dead {
+		return fmt.Errorf("cannot record utilization of machine %s: machine is dead", m)
+	}
+	if sample.Time.IsZero() {
+		sample.Time = time.Now()
+	}
+	samples, closer := m.st.getCollection(machineUtilizationC)
+	defer closer()
+
+	doc := utilizationDoc{
+		Id:         bson.NewObjectId(),
+		MachineId:  m.doc.Id,
+		Time:       sample.Time.UTC(),
+		CPUPercent: sample.CPUPercent,
+		MemUsed:    sample.MemUsed,
+		MemTotal:   sample.MemTotal,
+		DiskUsed:   sample.DiskUsed,
+		DiskTotal:  sample.DiskTotal,
+	}
+	if err := samples.Insert(&doc); err != nil {
+		return fmt.Errorf("cannot record utilization of machine %s: %v", m, err)
+	}
+	// Find the newest sample that falls outside the retention limit,
+	// and remove it along with everything older.
+	var oldest utilizationDoc
+	err := samples.Find(bson.D{{"machineid", m.doc.Id}}).
+		Sort("-time").Skip(MaxUtilizationSamples).Limit(1).One(&oldest)
+	if err == nil {
+		_, err = samples.RemoveAll(bson.D{
+			{"machineid", m.doc.Id},
+			{"time", bson.D{{"$lte", oldest.Time}}},
+		})
+		if err != nil {
+			logger.Warningf("cannot prune utilization samples of machine %s: %v", m, err)
+		}
+	}
+	return nil
+}
+
+// Utilization returns the retained resource utilization samples of the
+// machine, oldest first.
+func (m *Machine) Utilization() ([]UtilizationSample, error) {
+	samples, closer := m.st.getCollection(machineUtilizationC)
+	defer closer()
+
+	var docs []utilizationDoc
+	err := samples.Find(bson.D{{"machineid", m.doc.Id}}).Sort("time").All(&docs)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get utilization of machine %s: %v", m, err)
+	}
+	result := make([]UtilizationSample, len(docs))
+	for i, doc := range docs {
+		result[i] = doc.sample()
+	}
+	return result, nil
+}
+
+// removeUtilization removes all utilization samples of the machine.
+func (m *Machine) removeUtilization() error {
+	samples, closer := m.st.getCollection(machineUtilizationC)
+	defer closer()
+	_, err := samples.RemoveAll(bson.D{{"machineid", m.doc.Id}})
+	return err
+}
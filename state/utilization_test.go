@@ -0,0 +1,93 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+)
+
+type UtilizationSuite struct {
+	ConnSuite
+	machine *state.Machine
+}
+
+var _ = gc.Suite(&UtilizationSuite{})
+
+func (s *UtilizationSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	var err error
+	s.machine, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+}
+
+func sampleAt(t time.Time, cpu float64) state.UtilizationSample {
+	return state.UtilizationSample{
+		Time:       t,
+		CPUPercent: cpu,
+		MemUsed:    512,
+		MemTotal:   2048,
+		DiskUsed:   1024,
+		DiskTotal:  8192,
+	}
+}
+
+func (s *UtilizationSuite) TestRecordAndGetUtilization(c *gc.C) {
+	samples, err := s.machine.Utilization()
+	c.Assert(err, gc.IsNil)
+	c.Assert(samples, gc.HasLen, 0)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	err = s.machine.RecordUtilization(sampleAt(now, 25))
+	c.Assert(err, gc.IsNil)
+	err = s.machine.RecordUtilization(sampleAt(now.Add(-time.Minute), 50))
+	c.Assert(err, gc.IsNil)
+
+	samples, err = s.machine.Utilization()
+	c.Assert(err, gc.IsNil)
+	c.Assert(samples, gc.HasLen, 2)
+	c.Assert(samples[0].Time.Equal(now.Add(-time.Minute)), gc.Equals, true)
+	c.Assert(samples[0].CPUPercent, gc.Equals, 50.0)
+	c.Assert(samples[1].Time.Equal(now), gc.Equals, true)
+	c.Assert(samples[1].CPUPercent, gc.Equals, 25.0)
+	c.Assert(samples[1].MemUsed, gc.Equals, uint64(512))
+	c.Assert(samples[1].DiskTotal, gc.Equals, uint64(8192))
+}
+
+func (s *UtilizationSuite) TestRecordUtilizationPrunesOldSamples(c *gc.C) {
+	s.PatchValue(&state.MaxUtilizationSamples, 3)
+	start := time.Now().UTC().Truncate(time.Second)
+	for i := 0; i < 5; i++ {
+		err := s.machine.RecordUtilization(sampleAt(start.Add(time.Duration(i)*time.Second), float64(i)))
+		c.Assert(err, gc.IsNil)
+	}
+	samples, err := s.machine.Utilization()
+	c.Assert(err, gc.IsNil)
+	c.Assert(samples, gc.HasLen, 3)
+	for i, sample := range samples {
+		c.Check(sample.CPUPercent, gc.Equals, float64(i+2))
+	}
+}
+
+func (s *UtilizationSuite) TestRecordUtilizationDeadMachine(c *gc.C) {
+	err := s.machine.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = s.machine.RecordUtilization(sampleAt(time.Now(), 1))
+	c.Assert(err, gc.ErrorMatches, "cannot record utilization of machine 0: machine is dead")
+}
+
+func (s *UtilizationSuite) TestRemoveMachineRemovesUtilization(c *gc.C) {
+	err := s.machine.RecordUtilization(sampleAt(time.Now(), 1))
+	c.Assert(err, gc.IsNil)
+	err = s.machine.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = s.machine.Remove()
+	c.Assert(err, gc.IsNil)
+	samples, err := s.machine.Utilization()
+	c.Assert(err, gc.IsNil)
+	c.Assert(samples, gc.HasLen, 0)
+}
@@ -163,7 +163,37 @@ func collFactory(st *State, collName string) func() (*mgo.Collection, func()) {
 // WatchServices returns a StringsWatcher that notifies of changes to
 // the lifecycles of the services in the environment.
 func (st *State) WatchServices() StringsWatcher {
-	return newLifecycleWatcher(st, servicesC, nil, nil)
+	return newLifecycleWatcher(st, servicesC, st.environSelector(), nil)
+}
+
+// WatchNetworks returns a StringsWatcher that notifies of changes
+// to the lifecycles of networks, including their addition and
+// removal.
+func (st *State) WatchNetworks() StringsWatcher {
+	return newLifecycleWatcher(st, networksC, st.environSelector(), nil)
+}
+
+// WatchBlockDevices returns a StringsWatcher that notifies of changes
+// to the lifecycles of all block devices in the environment.
+func (st *State) WatchBlockDevices() StringsWatcher {
+	return newLifecycleWatcher(st, blockDevicesC, nil, nil)
+}
+
+// WatchBlockDevices returns a StringsWatcher that notifies of changes
+// to the lifecycles of the block devices of the machine.
+func (m *Machine) WatchBlockDevices() StringsWatcher {
+	members := bson.D{{"machineid", m.doc.Id}}
+	prefix := blockDeviceIdPrefix(m.doc.Id)
+	filter := func(id interface{}) bool {
+		return strings.HasPrefix(id.(string), prefix)
+	}
+	return newLifecycleWatcher(m.st, blockDevicesC, members, filter)
+}
+
+// WatchStorageInstances returns a StringsWatcher that notifies of
+// changes to the lifecycles of all storage instances in the environment.
+func (st *State) WatchStorageInstances() StringsWatcher {
+	return newLifecycleWatcher(st, storageInstancesC, nil, nil)
 }
 
 // WatchUnits returns a StringsWatcher that notifies of changes to the
@@ -180,11 +210,12 @@ func (s *Service) WatchUnits() StringsWatcher {
 // WatchRelations returns a StringsWatcher that notifies of changes to the
 // lifecycles of relations involving s.
 func (s *Service) WatchRelations() StringsWatcher {
-	members := bson.D{{"endpoints.servicename", s.doc.Name}}
-	prefix := s.doc.Name + ":"
+	members := bson.D{{"endpoints.servicename", s.Name()}}
+	members = append(members, s.st.environSelector()...)
+	prefix := s.Name() + ":"
 	infix := " " + prefix
 	filter := func(key interface{}) bool {
-		k := key.(string)
+		k := s.st.localID(key.(string))
 		return strings.HasPrefix(k, prefix) || strings.Contains(k, infix)
 	}
 	return newLifecycleWatcher(s.st, relationsC, members, filter)
@@ -197,6 +228,7 @@ func (st *State) WatchEnvironMachines() StringsWatcher {
 		{{"containertype", ""}},
 		{{"containertype", bson.D{{"$exists", false}}}},
 	}}}
+	members = append(members, st.environSelector()...)
 	filter := func(id interface{}) bool {
 		return !strings.Contains(id.(string), "/")
 	}
@@ -295,8 +327,13 @@ func (w *lifecycleWatcher) merge(ids set.Strings, updates map[interface{}]bool)
 	// Collect life states from ids thought to exist. Any that don't actually
 	// exist are ignored (we'll hear about them in the next set of updates --
 	// all that's actually happened in that situation is that the watcher
-	// events have lagged a little behind reality).
-	iter := coll.Find(bson.D{{"_id", bson.D{{"$in", changed}}}}).Select(lifeFields).Iter()
+	// events have lagged a little behind reality). So are any that are not
+	// members of the watched set, such as entities of other environments.
+	sel := bson.D{{"_id", bson.D{{"$in", changed}}}}
+	if len(w.members) > 0 {
+		sel = bson.D{{"$and", []bson.D{sel, w.members}}}
+	}
+	iter := coll.Find(sel).Select(lifeFields).Iter()
 	var doc lifeDoc
 	for iter.Next(&doc) {
 		latest[doc.Id] = doc.Life
@@ -366,7 +403,7 @@ func (w *lifecycleWatcher) loop() error {
 			if !ids.IsEmpty() {
 				out = w.out
 			}
-		case out <- ids.Values():
+		case out <- w.st.localIDs(ids.Values()):
 			ids = set.NewStrings()
 			out = nil
 		}
@@ -413,6 +450,9 @@ func (w *minUnitsWatcher) initial() (set.Strings, error) {
 
 	iter := newMinUnits.Find(nil).Iter()
 	for iter.Next(&doc) {
+		if !w.st.ownsDocID(doc.ServiceName) {
+			continue
+		}
 		w.known[doc.ServiceName] = doc.Revno
 		serviceNames.Add(doc.ServiceName)
 	}
@@ -421,6 +461,9 @@ func (w *minUnitsWatcher) initial() (set.Strings, error) {
 
 func (w *minUnitsWatcher) merge(serviceNames set.Strings, change watcher.Change) error {
 	serviceName := change.Id.(string)
+	if !w.st.ownsDocID(serviceName) {
+		return nil
+	}
 	if change.Revno == -1 {
 		delete(w.known, serviceName)
 		serviceNames.Remove(serviceName)
@@ -462,7 +505,7 @@ func (w *minUnitsWatcher) loop() (err error) {
 			if !serviceNames.IsEmpty() {
 				out = w.out
 			}
-		case out <- serviceNames.Values():
+		case out <- w.st.localIDs(serviceNames.Values()):
 			out = nil
 			serviceNames = set.NewStrings()
 		}
@@ -1025,7 +1068,7 @@ func (w *unitsWatcher) loop(coll, id string) error {
 			if len(changes) > 0 {
 				out = w.out
 			}
-		case out <- changes:
+		case out <- w.st.localIDs(changes):
 			out = nil
 			changes = nil
 		}
@@ -1068,7 +1111,7 @@ func (w *EnvironConfigWatcher) Changes() <-chan *config.Config {
 }
 
 func (w *EnvironConfigWatcher) loop() (err error) {
-	sw := w.st.watchSettings(environGlobalKey)
+	sw := w.st.watchSettings(w.st.environKey())
 	defer sw.Stop()
 	out := w.out
 	out = nil
@@ -1201,7 +1244,13 @@ func (e *Environment) Watch() NotifyWatcher {
 // Config to change. This differs from WatchEnvironConfig in that the watcher
 // is a NotifyWatcher that does not give content during Changes()
 func (st *State) WatchForEnvironConfigChanges() NotifyWatcher {
-	return newEntityWatcher(st, settingsC, environGlobalKey)
+	return newEntityWatcher(st, settingsC, st.environKey())
+}
+
+// WatchEnvironConstraints returns a NotifyWatcher that notifies
+// when the environment constraints change.
+func (st *State) WatchEnvironConstraints() NotifyWatcher {
+	return newEntityWatcher(st, constraintsC, st.environKey())
 }
 
 // WatchAPIHostPorts returns a NotifyWatcher that notifies
@@ -1439,7 +1488,7 @@ func (w *machineUnitsWatcher) loop() error {
 			if len(changes) > 0 {
 				out = w.out
 			}
-		case out <- changes:
+		case out <- w.st.localIDs(changes):
 			out = nil
 			changes = nil
 		}
@@ -1585,15 +1634,24 @@ type idPrefixWatcher struct {
 var _ StringsWatcher = (*idPrefixWatcher)(nil)
 
 // newIdPrefixWatcher starts and returns a new StringsWatcher configured
-// with the given collection and filter function
+// with the given collection and filter function. The filter is given
+// the local ids of the documents of the environment of the state.
 func newIdPrefixWatcher(st *State, collectionName string, filter func(interface{}) bool) StringsWatcher {
 	w := &idPrefixWatcher{
 		commonWatcher: commonWatcher{st: st},
 		source:        make(chan watcher.Change),
 		sink:          make(chan []string),
-		filterFn:      filter,
 		targetC:       collectionName,
 	}
+	w.filterFn = func(key interface{}) bool {
+		if id, ok := key.(string); ok {
+			if !st.ownsDocID(id) {
+				return false
+			}
+			key = st.localID(id)
+		}
+		return filter == nil || filter(key)
+	}
 
 	go func() {
 		defer w.tomb.Done()
@@ -1647,7 +1705,7 @@ func (w *idPrefixWatcher) loop() error {
 			} else {
 				out = nil
 			}
-		case out <- changes.Values():
+		case out <- w.st.localIDs(changes.Values()):
 			changes = set.NewStrings()
 			out = nil
 		}
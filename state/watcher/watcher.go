@@ -49,6 +49,10 @@ type Watcher struct {
 
 	// lastId is the most recent transaction id observed by a sync.
 	lastId interface{}
+
+	// queueDepth, if not nil, is called with the number of
+	// queued events each time they are flushed.
+	queueDepth func(int)
 }
 
 // A Change holds information about a document change.
@@ -105,11 +109,19 @@ type event struct {
 // New returns a new Watcher observing the changelog collection,
 // which must be a capped collection maintained by mgo/txn.
 func New(changelog *mgo.Collection) *Watcher {
+	return NewReporting(changelog, nil)
+}
+
+// NewReporting is like New, except that the returned Watcher calls
+// queueDepth with the number of events queued for delivery to the
+// watching channels each time it delivers them.
+func NewReporting(changelog *mgo.Collection, queueDepth func(int)) *Watcher {
 	w := &Watcher{
-		log:     changelog,
-		watches: make(map[watchKey][]watchInfo),
-		current: make(map[watchKey]int64),
-		request: make(chan interface{}),
+		log:        changelog,
+		watches:    make(map[watchKey][]watchInfo),
+		current:    make(map[watchKey]int64),
+		request:    make(chan interface{}),
+		queueDepth: queueDepth,
 	}
 	go func() {
 		w.tomb.Kill(w.loop())
@@ -235,6 +247,9 @@ func (w *Watcher) loop() error {
 
 // flush sends all pending events to their respective channels.
 func (w *Watcher) flush() {
+	if w.queueDepth != nil {
+		w.queueDepth(len(w.syncEvents) + len(w.requestEvents))
+	}
 	// refreshEvents are stored newest first.
 	for i := len(w.syncEvents) - 1; i >= 0; i-- {
 		e := &w.syncEvents[i]
@@ -703,3 +703,37 @@ func (s *SlowPeriodSuite) TestStartSyncStartsImmediately(c *gc.C) {
 	case <-time.After(justLongEnough):
 	}
 }
+
+func (s *FastPeriodSuite) TestQueueDepthReported(c *gc.C) {
+	depths := make(chan int, 100)
+	w := watcher.NewReporting(s.log, func(depth int) {
+		select {
+		case depths <- depth:
+		default:
+		}
+	})
+	defer func() {
+		c.Assert(w.Stop(), gc.IsNil)
+	}()
+	w.WatchCollection("test", s.ch)
+	s.insertAll(c, "test", "a", "b")
+	w.StartSync()
+	for i := 0; i < 2; i++ {
+		select {
+		case <-s.ch:
+		case <-time.After(worstCase):
+			c.Fatalf("watch reported nothing")
+		}
+	}
+	// Both changes were queued before being delivered.
+	for {
+		select {
+		case depth := <-depths:
+			if depth == 2 {
+				return
+			}
+		case <-time.After(worstCase):
+			c.Fatalf("queue depth of 2 not reported")
+		}
+	}
+}
@@ -30,6 +30,7 @@ type UserParams struct {
 	DisplayName string
 	Password    string
 	Creator     string
+	Access      state.EnvironmentAccess
 }
 
 // IdentityParams provides the optional values for the Factory.MakeIdentity method.
@@ -112,8 +113,11 @@ func (factory *Factory) MakeUser(vParams ...UserParams) *state.User {
 	if params.Creator == "" {
 		params.Creator = "admin"
 	}
-	user, err := factory.st.AddUser(
-		params.Username, params.DisplayName, params.Password, params.Creator)
+	if params.Access == "" {
+		params.Access = state.EnvironmentAdminAccess
+	}
+	user, err := factory.st.AddUserWithAccess(
+		params.Username, params.DisplayName, params.Password, params.Creator, params.Access)
 	factory.c.Assert(err, gc.IsNil)
 	return user
 }
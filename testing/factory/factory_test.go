@@ -111,6 +111,18 @@ func (s *factorySuite) TestMakeUserParams(c *gc.C) {
 	c.Assert(saved.IsDeactivated(), gc.Equals, user.IsDeactivated())
 }
 
+func (s *factorySuite) TestMakeUserAccess(c *gc.C) {
+	user := s.Factory.MakeUser()
+	access, err := user.EnvironmentAccess()
+	c.Assert(err, gc.IsNil)
+	c.Assert(access, gc.Equals, state.EnvironmentAdminAccess)
+
+	user = s.Factory.MakeUser(factory.UserParams{Access: state.EnvironmentReadAccess})
+	access, err = user.EnvironmentAccess()
+	c.Assert(err, gc.IsNil)
+	c.Assert(access, gc.Equals, state.EnvironmentReadAccess)
+}
+
 func (s *factorySuite) TestMakeMachineNil(c *gc.C) {
 	machine := s.Factory.MakeMachine()
 	c.Assert(machine, gc.NotNil)
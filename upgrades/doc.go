@@ -11,4 +11,7 @@
 //     target      - the type of Juju node being upgraded
 //     context     - provides API access to Juju state servers
 //
+// Steps that only target the database master are recorded in state
+// once completed, so that a retried upgrade does not run them again.
+//
 package upgrades
@@ -0,0 +1,15 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package upgrades
+
+import (
+	"github.com/juju/juju/state"
+)
+
+// addEnvironmentAccessForUsers grants existing users admin access to
+// the environment, as they had before access was recorded per
+// environment.
+func addEnvironmentAccessForUsers(context Context) error {
+	return state.AddEnvironmentAccessForUsers(context.State())
+}
@@ -0,0 +1,45 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package upgrades_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/testing/factory"
+	"github.com/juju/juju/upgrades"
+)
+
+type environAccessSuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&environAccessSuite{})
+
+func (s *environAccessSuite) TestAddEnvironmentAccessForUsers(c *gc.C) {
+	f := factory.NewFactory(s.State, c)
+	bob := f.MakeUser(factory.UserParams{Username: "bob"})
+	err := bob.RevokeEnvironmentAccess()
+	c.Assert(err, gc.IsNil)
+	mary := f.MakeUser(factory.UserParams{Username: "mary"})
+	err = mary.SetEnvironmentAccess(state.EnvironmentReadAccess)
+	c.Assert(err, gc.IsNil)
+
+	ctx := &mockContext{state: s.State}
+	err = upgrades.AddEnvironmentAccessForUsers(ctx)
+	c.Assert(err, gc.IsNil)
+
+	access, err := bob.EnvironmentAccess()
+	c.Assert(err, gc.IsNil)
+	c.Assert(access, gc.Equals, state.EnvironmentAdminAccess)
+	// Access already granted is left alone.
+	access, err = mary.EnvironmentAccess()
+	c.Assert(err, gc.IsNil)
+	c.Assert(access, gc.Equals, state.EnvironmentReadAccess)
+
+	// The step can be run again.
+	err = upgrades.AddEnvironmentAccessForUsers(ctx)
+	c.Assert(err, gc.IsNil)
+}
@@ -20,4 +20,9 @@ var (
 	UpdateRsyslogPort                      = updateRsyslogPort
 	ProcessDeprecatedEnvSettings           = processDeprecatedEnvSettings
 	MigrateLocalProviderAgentConfig        = migrateLocalProviderAgentConfig
+
+	// 121 upgrade functions
+	StepsFor121                     = stepsFor121
+	AddEnvironmentAccessForUsers    = addEnvironmentAccessForUsers
+	AddMachineAndNetworkToPortsDocs = addMachineAndNetworkToPortsDocs
 )
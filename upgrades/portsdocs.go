@@ -0,0 +1,15 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package upgrades
+
+import (
+	"github.com/juju/juju/state"
+)
+
+// addMachineAndNetworkToPortsDocs records the machine and network on
+// existing opened ports documents, so ports can be looked up per
+// machine and network.
+func addMachineAndNetworkToPortsDocs(context Context) error {
+	return state.AddMachineAndNetworkToPortsDocs(context.State())
+}
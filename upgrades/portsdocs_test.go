@@ -0,0 +1,39 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package upgrades_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/upgrades"
+)
+
+type portsDocsSuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&portsDocsSuite{})
+
+func (s *portsDocsSuite) TestAddMachineAndNetworkToPortsDocs(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	unit, err := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress")).AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit.AssignToMachine(machine)
+	c.Assert(err, gc.IsNil)
+	err = unit.OpenPortOnNetwork("net1", "tcp", 80)
+	c.Assert(err, gc.IsNil)
+
+	ctx := &mockContext{state: s.State}
+	err = upgrades.AddMachineAndNetworkToPortsDocs(ctx)
+	c.Assert(err, gc.IsNil)
+
+	ports, err := machine.OpenedPortsOnNetwork("net1")
+	c.Assert(err, gc.IsNil)
+	networkName, err := ports.NetworkName()
+	c.Assert(err, gc.IsNil)
+	c.Assert(networkName, gc.Equals, "net1")
+}
@@ -0,0 +1,20 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package upgrades
+
+// stepsFor121 returns upgrade steps to upgrade to a Juju 1.21 deployment.
+func stepsFor121() []Step {
+	return []Step{
+		&upgradeStep{
+			description: "grant existing users admin access to the environment",
+			targets:     []Target{DatabaseMaster},
+			run:         addEnvironmentAccessForUsers,
+		},
+		&upgradeStep{
+			description: "record machine and network on opened ports documents",
+			targets:     []Target{DatabaseMaster},
+			run:         addMachineAndNetworkToPortsDocs,
+		},
+	}
+}
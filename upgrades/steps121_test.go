@@ -0,0 +1,28 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package upgrades_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/testing"
+	"github.com/juju/juju/upgrades"
+)
+
+type steps121Suite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&steps121Suite{})
+
+var expectedSteps121 = []string{
+	"grant existing users admin access to the environment",
+	"record machine and network on opened ports documents",
+}
+
+func (s *steps121Suite) TestUpgradeOperationsContent(c *gc.C) {
+	upgradeSteps := upgrades.StepsFor121()
+	c.Assert(upgradeSteps, gc.HasLen, len(expectedSteps121))
+	assertExpectedSteps(c, upgradeSteps, expectedSteps121)
+}
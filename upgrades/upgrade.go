@@ -171,16 +171,50 @@ func validTarget(target Target, step Step) bool {
 	return len(step.Targets()) == 0
 }
 
+// databaseOnly returns true if the step only targets the database
+// master, so that it changes the database shared by all the machines
+// and needs to be run only once.
+func databaseOnly(step Step) bool {
+	targets := step.Targets()
+	for _, target := range targets {
+		if target != DatabaseMaster {
+			return false
+		}
+	}
+	return len(targets) > 0
+}
+
 // runUpgradeSteps runs all the upgrade steps relevant to target.
 // As soon as any error is encountered, the operation is aborted since
 // subsequent steps may required successful completion of earlier ones.
 // The steps must be idempotent so that the entire upgrade operation can
-// be retried.
+// be retried. Steps that only change the database are recorded in state
+// once completed, and are not run again when the upgrade is retried.
 func runUpgradeSteps(context Context, target Target, upgradeOp Operation) *upgradeError {
+	st := context.State()
+	var completed map[string]bool
 	for _, step := range upgradeOp.Steps() {
 		if !validTarget(target, step) {
 			continue
 		}
+		record := st != nil && databaseOnly(step)
+		if record && completed == nil {
+			descriptions, err := st.CompletedUpgradeSteps(upgradeOp.TargetVersion())
+			if err != nil {
+				return &upgradeError{
+					description: step.Description(),
+					err:         err,
+				}
+			}
+			completed = make(map[string]bool)
+			for _, description := range descriptions {
+				completed[description] = true
+			}
+		}
+		if record && completed[step.Description()] {
+			logger.Infof("skipping completed upgrade step: %v", step.Description())
+			continue
+		}
 		logger.Infof("running upgrade step on target %q: %v", target, step.Description())
 		if err := step.Run(context); err != nil {
 			logger.Errorf("upgrade step %q failed: %v", step.Description(), err)
@@ -189,6 +223,14 @@ func runUpgradeSteps(context Context, target Target, upgradeOp Operation) *upgra
 				err:         err,
 			}
 		}
+		if record {
+			if err := st.SetUpgradeStepCompleted(upgradeOp.TargetVersion(), step.Description()); err != nil {
+				return &upgradeError{
+					description: step.Description(),
+					err:         err,
+				}
+			}
+		}
 	}
 	logger.Infof("All upgrade steps completed successfully")
 	return nil
@@ -15,6 +15,7 @@ import (
 
 	"github.com/juju/juju/agent"
 	"github.com/juju/juju/environmentserver/authentication"
+	jujutesting "github.com/juju/juju/juju/testing"
 	"github.com/juju/juju/state"
 	"github.com/juju/juju/state/api"
 	"github.com/juju/juju/state/api/params"
@@ -321,6 +322,35 @@ func (s *upgradeSuite) TestPerformUpgrade(c *gc.C) {
 	}
 }
 
+type upgradeStateSuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&upgradeStateSuite{})
+
+func (s *upgradeStateSuite) TestDatabaseStepsRecorded(c *gc.C) {
+	s.PatchValue(upgrades.UpgradeOperations, upgradeOperations)
+	vers := version.Current
+	vers.Number = version.MustParse("1.21.0")
+	s.PatchValue(&version.Current, vers)
+	fromVersion := version.MustParse("1.20.0")
+
+	ctx := &mockContext{state: s.State}
+	err := upgrades.PerformUpgrade(fromVersion, upgrades.DatabaseMaster, ctx)
+	c.Assert(err, gc.IsNil)
+	c.Assert(ctx.messages, gc.DeepEquals, []string{"mongo fix - 1.21-alpha2", "db schema - 1.21-alpha2"})
+	completed, err := s.State.CompletedUpgradeSteps(version.MustParse("1.21-alpha2"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(completed, gc.DeepEquals, []string{"db schema - 1.21-alpha2"})
+
+	// Steps changing only the database are not run again
+	// when the upgrade is retried.
+	ctx = &mockContext{state: s.State}
+	err = upgrades.PerformUpgrade(fromVersion, upgrades.DatabaseMaster, ctx)
+	c.Assert(err, gc.IsNil)
+	c.Assert(ctx.messages, gc.DeepEquals, []string{"mongo fix - 1.21-alpha2"})
+}
+
 func (s *upgradeSuite) TestUpgradeOperationsOrdered(c *gc.C) {
 	var previous version.Number
 	for i, utv := range (*upgrades.UpgradeOperations)() {
@@ -332,7 +362,7 @@ func (s *upgradeSuite) TestUpgradeOperationsOrdered(c *gc.C) {
 	}
 }
 
-var expectedVersions = []string{"1.18.0"}
+var expectedVersions = []string{"1.18.0", "1.21-alpha1"}
 
 func (s *upgradeSuite) TestUpgradeOperationsVersions(c *gc.C) {
 	var versions []string
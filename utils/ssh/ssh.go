@@ -35,6 +35,15 @@ type Options struct {
 	// knownHostsFile is a path to a file in which to save the host's
 	// fingerprint.
 	knownHostsFile string
+	// localForwards and remoteForwards hold the specifications
+	// of the ports forwarded from the local host to the remote
+	// host and from the remote host to the local host.
+	localForwards  []string
+	remoteForwards []string
+	// dynamicForward holds the specification of the local
+	// port on which to serve a SOCKS proxy through the remote
+	// host; empty means no SOCKS proxy is served.
+	dynamicForward string
 }
 
 // SetProxyCommand sets a command to execute to proxy traffic through.
@@ -78,6 +87,38 @@ func (o *Options) SetIdentities(identityFiles ...string) {
 	o.identities = append([]string{}, identityFiles...)
 }
 
+// AddLocalForward forwards a local port to a host and port reachable
+// from the remote host. The specification is given in the OpenSSH
+// format, [bind_address:]port:host:hostport.
+//
+// Port forwarding only applies to commands, not to copies.
+func (o *Options) AddLocalForward(spec string) {
+	o.localForwards = append(o.localForwards, spec)
+}
+
+// AddRemoteForward forwards a port of the remote host to a host
+// and port reachable from the local host. The specification is
+// given in the OpenSSH format, [bind_address:]port:host:hostport.
+//
+// Port forwarding only applies to commands, not to copies.
+func (o *Options) AddRemoteForward(spec string) {
+	o.remoteForwards = append(o.remoteForwards, spec)
+}
+
+// SetDynamicForward serves a SOCKS proxy through the remote host on
+// a local port. The specification is given in the OpenSSH format,
+// [bind_address:]port.
+//
+// Port forwarding only applies to commands, not to copies.
+func (o *Options) SetDynamicForward(spec string) {
+	o.dynamicForward = spec
+}
+
+// hasForwards reports whether any port forwarding is requested.
+func (o *Options) hasForwards() bool {
+	return len(o.localForwards) > 0 || len(o.remoteForwards) > 0 || o.dynamicForward != ""
+}
+
 // Client is an interface for SSH clients to implement
 type Client interface {
 	// Command returns a Command for executing a command
@@ -11,6 +11,7 @@ import (
 	"os"
 	"os/exec"
 	"os/user"
+	"strconv"
 	"strings"
 
 	"code.google.com/p/go.crypto/ssh"
@@ -53,12 +54,15 @@ func (c *GoCryptoClient) Command(host string, command []string, options *Options
 			port = options.port
 		}
 		proxyCommand = options.proxyCommand
+		if options.hasForwards() {
+			logger.Warningf("port forwarding is not implemented (OpenSSH ssh not available in PATH)")
+		}
 	}
 	logger.Debugf(`running (equivalent of): ssh "%s@%s" -p %d '%s'`, user, host, port, shellCommand)
 	return &Cmd{impl: &goCryptoCommand{
 		signers:      signers,
 		user:         user,
-		addr:         fmt.Sprintf("%s:%d", host, port),
+		addr:         net.JoinHostPort(host, strconv.Itoa(port)),
 		command:      shellCommand,
 		proxyCommand: proxyCommand,
 	}}
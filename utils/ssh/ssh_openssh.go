@@ -117,6 +117,17 @@ func opensshOptions(options *Options, commandKind opensshCommandKind) []string {
 			args = append(args, "-p", port)
 		}
 	}
+	if commandKind == sshKind {
+		for _, spec := range options.localForwards {
+			args = append(args, "-L", spec)
+		}
+		for _, spec := range options.remoteForwards {
+			args = append(args, "-R", spec)
+		}
+		if options.dynamicForward != "" {
+			args = append(args, "-D", options.dynamicForward)
+		}
+	}
 	return args
 }
 
@@ -145,17 +145,31 @@ func (s *SSHCommandSuite) TestCommandPort(c *gc.C) {
 	)
 }
 
+func (s *SSHCommandSuite) TestCommandForwards(c *gc.C) {
+	var opts ssh.Options
+	opts.AddLocalForward("8080:10.0.0.1:80")
+	opts.AddLocalForward("127.0.0.1:5432:localhost:5432")
+	opts.AddRemoteForward("9000:localhost:9000")
+	opts.SetDynamicForward("1080")
+	s.assertCommandArgs(c, s.commandOptions([]string{echoCommand, "123"}, &opts),
+		fmt.Sprintf("%s -o StrictHostKeyChecking no -o PasswordAuthentication no -o ServerAliveInterval 30 "+
+			"-L 8080:10.0.0.1:80 -L 127.0.0.1:5432:localhost:5432 -R 9000:localhost:9000 -D 1080 localhost %s 123",
+			s.fakessh, echoCommand),
+	)
+}
+
 func (s *SSHCommandSuite) TestCopy(c *gc.C) {
 	var opts ssh.Options
 	opts.EnablePTY()
 	opts.AllowPasswordAuthentication()
 	opts.SetIdentities("x", "y")
 	opts.SetPort(2022)
+	opts.AddLocalForward("8080:localhost:80")
 	err := s.client.Copy([]string{"/tmp/blah", "foo@bar.com:baz"}, &opts)
 	c.Assert(err, gc.IsNil)
 	out, err := ioutil.ReadFile(s.fakescp + ".args")
 	c.Assert(err, gc.IsNil)
-	// EnablePTY has no effect for Copy
+	// EnablePTY and port forwarding have no effect for Copy
 	c.Assert(string(out), gc.Equals, s.fakescp+" -o StrictHostKeyChecking no -o ServerAliveInterval 30 -i x -i y -P 2022 /tmp/blah foo@bar.com:baz\n")
 
 	// Try passing extra args
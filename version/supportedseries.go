@@ -20,6 +20,19 @@ const (
 	Windows
 )
 
+var osTypeNames = map[OSType]string{
+	Unknown: "unknown",
+	Ubuntu:  "ubuntu",
+	Windows: "windows",
+}
+
+func (t OSType) String() string {
+	if name, ok := osTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("OSType(%d)", int(t))
+}
+
 // seriesVersions provides a mapping between Ubuntu series names and version numbers.
 // The values here are current as of the time of writing. On Ubuntu systems, we update
 // these values from /usr/share/distro-info/ubuntu.csv to ensure we have the latest values.
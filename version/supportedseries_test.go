@@ -37,6 +37,9 @@ var getOSFromSeriesTests = []struct {
 	want:   version.Windows,
 }, {
 	// GetOSFromSeries only supports Ubuntu and Windows.
+	series: "centos7",
+	err:    `invalid series "centos7"`,
+}, {
 	series: "mountainlion",
 	err:    `invalid series "mountainlion"`,
 }}
@@ -52,3 +55,9 @@ func (s *supportedSeriesSuite) TestGetOSFromSeries(c *gc.C) {
 		}
 	}
 }
+
+func (s *supportedSeriesSuite) TestOSTypeString(c *gc.C) {
+	c.Assert(version.Ubuntu.String(), gc.Equals, "ubuntu")
+	c.Assert(version.Windows.String(), gc.Equals, "windows")
+	c.Assert(version.OSType(42).String(), gc.Equals, "OSType(42)")
+}
@@ -55,6 +55,11 @@ func (c *APIAddressUpdater) Handle() error {
 	if err != nil {
 		return fmt.Errorf("error getting addresses: %v", err)
 	}
+	// Hostnames are resolved here, so that agents on IPv6-only
+	// networks are given the IPv6 addresses of the API servers.
+	for i, server := range addresses {
+		addresses[i] = network.ResolveHostPorts(server)
+	}
 	if err := c.setter.SetAPIHostPorts(addresses); err != nil {
 		return fmt.Errorf("error setting addresses: %v", err)
 	}
@@ -0,0 +1,43 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package auditpruner implements a worker that periodically removes
+// old entries from the audit trail held in state.
+package auditpruner
+
+import (
+	"time"
+
+	"github.com/juju/loggo"
+
+	"github.com/juju/juju/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.auditpruner")
+
+// DefaultRetention is how long entries are kept in the audit trail.
+const DefaultRetention = 90 * 24 * time.Hour
+
+// DefaultInterval is how often the machine agent prunes the audit trail.
+const DefaultInterval = time.Hour
+
+// AuditTrailPruner defines the interface for types capable
+// of removing old entries from the audit trail.
+type AuditTrailPruner interface {
+	// PruneAuditTrail removes the entries recorded before the given time.
+	PruneAuditTrail(before time.Time) error
+}
+
+// NewPruner returns a worker that prunes the audit trail when it
+// starts and again after each interval, keeping the entries recorded
+// within the given retention period.
+func NewPruner(p AuditTrailPruner, retention, interval time.Duration) worker.Worker {
+	return worker.NewPeriodicWorker(func(<-chan struct{}) error {
+		// Failures are logged rather than returned, so
+		// that pruning is attempted again after the interval.
+		if err := p.PruneAuditTrail(time.Now().Add(-retention)); err != nil {
+			logger.Errorf("cannot prune audit trail: %v", err)
+		}
+		return nil
+	}, interval)
+}
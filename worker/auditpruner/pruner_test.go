@@ -0,0 +1,64 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package auditpruner_test
+
+import (
+	"errors"
+	"sync"
+	stdtesting "testing"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/worker"
+	"github.com/juju/juju/worker/auditpruner"
+)
+
+func TestPackage(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+type PrunerSuite struct {
+	coretesting.BaseSuite
+}
+
+var _ = gc.Suite(&PrunerSuite{})
+
+var _ auditpruner.AuditTrailPruner = (*state.State)(nil)
+
+func (s *PrunerSuite) TestPrunesPeriodically(c *gc.C) {
+	testInterval := 10 * time.Millisecond
+	p := &pruneRecorder{err: errors.New("boom")}
+	start := time.Now()
+	ap := auditpruner.NewPruner(p, time.Hour, testInterval)
+	time.Sleep(10 * testInterval)
+	c.Assert(worker.Stop(ap), gc.IsNil)
+	end := time.Now()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// The trail is pruned straight away, and again after
+	// each interval, even if pruning fails.
+	c.Assert(len(p.befores) > 1, gc.Equals, true)
+	for _, before := range p.befores {
+		c.Assert(before.Before(start.Add(-time.Hour)), gc.Equals, false)
+		c.Assert(before.After(end.Add(-time.Hour)), gc.Equals, false)
+	}
+}
+
+// pruneRecorder records the times it is asked to prune before.
+type pruneRecorder struct {
+	mu      sync.Mutex
+	befores []time.Time
+	err     error
+}
+
+func (p *pruneRecorder) PruneAuditTrail(before time.Time) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.befores = append(p.befores, before)
+	return p.err
+}
@@ -37,7 +37,7 @@ type Firewaller struct {
 	serviceds       map[string]*serviceData
 	exposedChange   chan *exposedChange
 	globalMode      bool
-	globalPortRef   map[network.Port]int
+	globalPortRef   map[string]map[network.Port]int
 }
 
 // NewFirewaller returns a new Firewaller.
@@ -78,9 +78,14 @@ func (fw *Firewaller) loop() error {
 	if err != nil {
 		return err
 	}
-	if fw.environ.Config().FirewallMode() == config.FwGlobal {
+	switch fw.environ.Config().FirewallMode() {
+	case config.FwGlobal:
 		fw.globalMode = true
-		fw.globalPortRef = make(map[network.Port]int)
+		fw.globalPortRef = make(map[string]map[network.Port]int)
+	case config.FwNone:
+		logger.Infof("firewall-mode is %q, not managing the firewall", config.FwNone)
+		<-fw.tomb.Dying()
+		return tomb.ErrDying
 	}
 	for {
 		select {
@@ -127,6 +132,7 @@ func (fw *Firewaller) loop() error {
 			}
 		case change := <-fw.exposedChange:
 			change.serviced.exposed = change.exposed
+			change.serviced.exposedPorts = change.ports
 			unitds := []*unitData{}
 			for _, unitd := range change.serviced.unitds {
 				unitds = append(unitds, unitd)
@@ -157,7 +163,7 @@ func (fw *Firewaller) startMachine(machineTag string) error {
 		fw:     fw,
 		tag:    tag,
 		unitds: make(map[string]*unitData),
-		ports:  make([]network.Port, 0),
+		ports:  make(map[string][]network.Port),
 	}
 	m, err := machined.machine()
 	if params.IsCodeNotFound(err) {
@@ -201,7 +207,7 @@ func (fw *Firewaller) startUnit(unit *apifirewaller.Unit, machineTag string) err
 	}
 	serviceName := service.Name()
 	unitName := unit.Name()
-	openedPorts, err := unit.OpenedPorts()
+	openedPorts, err := unit.OpenedPortsOnNetworks()
 	if err != nil {
 		return err
 	}
@@ -224,10 +230,7 @@ func (fw *Firewaller) startUnit(unit *apifirewaller.Unit, machineTag string) err
 	unitd.serviced = fw.serviceds[serviceName]
 	unitd.serviced.unitds[unitName] = unitd
 
-	ports := make([]network.Port, len(unitd.ports))
-	copy(ports, unitd.ports)
-
-	go unitd.watchLoop(ports)
+	go unitd.watchLoop(copyNetworkPorts(unitd.ports))
 	return nil
 }
 
@@ -238,60 +241,82 @@ func (fw *Firewaller) startService(service *apifirewaller.Service) error {
 	if err != nil {
 		return err
 	}
+	exposedPorts, err := service.ExposedPorts()
+	if err != nil {
+		return err
+	}
 	serviced := &serviceData{
-		fw:      fw,
-		service: service,
-		exposed: exposed,
-		unitds:  make(map[string]*unitData),
+		fw:           fw,
+		service:      service,
+		exposed:      exposed,
+		exposedPorts: exposedPorts,
+		unitds:       make(map[string]*unitData),
 	}
 	fw.serviceds[service.Name()] = serviced
-	go serviced.watchLoop(serviced.exposed)
+	go serviced.watchLoop(serviced.exposed, serviced.exposedPorts)
 	return nil
 }
 
 // reconcileGlobal compares the initially started watcher for machines,
 // units and services with the opened and closed ports globally and
-// opens and closes the appropriate ports for the whole environment.
+// opens and closes the appropriate ports for the whole environment,
+// on each network.
 func (fw *Firewaller) reconcileGlobal() error {
-	initialPorts, err := fw.environ.Ports()
-	if err != nil {
-		return err
+	collector := map[string]map[network.Port]bool{
+		network.DefaultPublic: {},
 	}
-	collector := make(map[network.Port]bool)
 	for _, unitd := range fw.unitds {
-		if unitd.serviced.exposed {
-			for _, port := range unitd.ports {
-				collector[port] = true
+		for networkName, ports := range unitd.ports {
+			for _, port := range ports {
+				if !unitd.serviced.exposes(port) {
+					continue
+				}
+				if collector[networkName] == nil {
+					collector[networkName] = make(map[network.Port]bool)
+				}
+				collector[networkName][port] = true
 			}
 		}
 	}
-	wantedPorts := []network.Port{}
-	for port := range collector {
-		wantedPorts = append(wantedPorts, port)
-	}
-	// Check which ports to open or to close.
-	toOpen := Diff(wantedPorts, initialPorts)
-	toClose := Diff(initialPorts, wantedPorts)
-	if len(toOpen) > 0 {
-		logger.Infof("opening global ports %v", toOpen)
-		if err := fw.environ.OpenPorts(toOpen); err != nil {
-			return err
+	for networkName, wanted := range collector {
+		firewall := environFirewall(fw.environ, networkName)
+		if firewall == nil {
+			logger.Warningf("cannot open global ports on network %q: not supported by the environment", networkName)
+			continue
 		}
-		network.SortPorts(toOpen)
-	}
-	if len(toClose) > 0 {
-		logger.Infof("closing global ports %v", toClose)
-		if err := fw.environ.ClosePorts(toClose); err != nil {
+		initialPorts, err := firewall.Ports()
+		if err != nil {
 			return err
 		}
-		network.SortPorts(toClose)
+		wantedPorts := []network.Port{}
+		for port := range wanted {
+			wantedPorts = append(wantedPorts, port)
+		}
+		// Check which ports to open or to close.
+		toOpen := Diff(wantedPorts, initialPorts)
+		toClose := Diff(initialPorts, wantedPorts)
+		if len(toOpen) > 0 {
+			logger.Infof("opening global ports %v on network %q", toOpen, networkName)
+			if err := firewall.OpenPorts(toOpen); err != nil {
+				return err
+			}
+			network.SortPorts(toOpen)
+		}
+		if len(toClose) > 0 {
+			logger.Infof("closing global ports %v on network %q", toClose, networkName)
+			if err := firewall.ClosePorts(toClose); err != nil {
+				return err
+			}
+			network.SortPorts(toClose)
+		}
 	}
 	return nil
 }
 
 // reconcileInstances compares the initially started watcher for machines,
 // units and services with the opened and closed ports of the instances and
-// opens and closes the appropriate ports for each instance.
+// opens and closes the appropriate ports for each instance, on each
+// network.
 func (fw *Firewaller) reconcileInstances() error {
 	for _, machined := range fw.machineds {
 		m, err := machined.machine()
@@ -314,30 +339,44 @@ func (fw *Firewaller) reconcileInstances() error {
 			return err
 		}
 		machineId := machined.tag.Id()
-		initialPorts, err := instances[0].Ports(machineId)
-		if err != nil {
-			return err
-		}
-		// Check which ports to open or to close.
-		toOpen := Diff(machined.ports, initialPorts)
-		toClose := Diff(initialPorts, machined.ports)
-		if len(toOpen) > 0 {
-			logger.Infof("opening instance ports %v for %q",
-				toOpen, machined.tag)
-			if err := instances[0].OpenPorts(machineId, toOpen); err != nil {
-				// TODO(mue) Add local retry logic.
-				return err
+		networkNames := []string{network.DefaultPublic}
+		for networkName := range machined.ports {
+			if networkName != network.DefaultPublic {
+				networkNames = append(networkNames, networkName)
 			}
-			network.SortPorts(toOpen)
 		}
-		if len(toClose) > 0 {
-			logger.Infof("closing instance ports %v for %q",
-				toClose, machined.tag)
-			if err := instances[0].ClosePorts(machineId, toClose); err != nil {
-				// TODO(mue) Add local retry logic.
+		for _, networkName := range networkNames {
+			firewall := instanceFirewall(instances[0], machineId, networkName)
+			if firewall == nil {
+				logger.Warningf("cannot open ports on network %q for %q: not supported by the instance", networkName, machined.tag)
+				continue
+			}
+			initialPorts, err := firewall.Ports()
+			if err != nil {
 				return err
 			}
-			network.SortPorts(toClose)
+			// Check which ports to open or to close.
+			wanted := machined.ports[networkName]
+			toOpen := Diff(wanted, initialPorts)
+			toClose := Diff(initialPorts, wanted)
+			if len(toOpen) > 0 {
+				logger.Infof("opening instance ports %v on network %q for %q",
+					toOpen, networkName, machined.tag)
+				if err := firewall.OpenPorts(toOpen); err != nil {
+					// TODO(mue) Add local retry logic.
+					return err
+				}
+				network.SortPorts(toOpen)
+			}
+			if len(toClose) > 0 {
+				logger.Infof("closing instance ports %v on network %q for %q",
+					toClose, networkName, machined.tag)
+				if err := firewall.ClosePorts(toClose); err != nil {
+					// TODO(mue) Add local retry logic.
+					return err
+				}
+				network.SortPorts(toClose)
+			}
 		}
 	}
 	return nil
@@ -399,21 +438,39 @@ func (fw *Firewaller) flushUnits(unitds []*unitData) error {
 
 // flushMachine opens and closes ports for the passed machine.
 func (fw *Firewaller) flushMachine(machined *machineData) error {
-	// Gather ports to open and close.
-	ports := map[network.Port]bool{}
+	// Gather ports to open and close, on each network.
+	ports := map[string]map[network.Port]bool{}
 	for _, unitd := range machined.unitds {
-		if unitd.serviced.exposed {
-			for _, port := range unitd.ports {
-				ports[port] = true
+		for networkName, unitPorts := range unitd.ports {
+			for _, port := range unitPorts {
+				if !unitd.serviced.exposes(port) {
+					continue
+				}
+				if ports[networkName] == nil {
+					ports[networkName] = make(map[network.Port]bool)
+				}
+				ports[networkName][port] = true
 			}
 		}
 	}
-	want := []network.Port{}
-	for port := range ports {
-		want = append(want, port)
+	want := map[string][]network.Port{}
+	for networkName, networkPorts := range ports {
+		for port := range networkPorts {
+			want[networkName] = append(want[networkName], port)
+		}
+	}
+	toOpen := map[string][]network.Port{}
+	toClose := map[string][]network.Port{}
+	for networkName, wanted := range want {
+		if opened := Diff(wanted, machined.ports[networkName]); len(opened) > 0 {
+			toOpen[networkName] = opened
+		}
+	}
+	for networkName, current := range machined.ports {
+		if closed := Diff(current, want[networkName]); len(closed) > 0 {
+			toClose[networkName] = closed
+		}
 	}
-	toOpen := Diff(want, machined.ports)
-	toClose := Diff(machined.ports, want)
 	machined.ports = want
 	if fw.globalMode {
 		return fw.flushGlobalPorts(toOpen, toClose)
@@ -421,47 +478,68 @@ func (fw *Firewaller) flushMachine(machined *machineData) error {
 	return fw.flushInstancePorts(machined, toOpen, toClose)
 }
 
-// flushGlobalPorts opens and closes global ports in the environment.
-// It keeps a reference count for ports so that only 0-to-1 and 1-to-0 events
-// modify the environment.
-func (fw *Firewaller) flushGlobalPorts(rawOpen, rawClose []network.Port) error {
+// flushGlobalPorts opens and closes global ports in the environment,
+// on each network. It keeps a reference count for ports so that only
+// 0-to-1 and 1-to-0 events modify the environment.
+func (fw *Firewaller) flushGlobalPorts(rawOpen, rawClose map[string][]network.Port) error {
 	// Filter which ports are really to open or close.
-	var toOpen, toClose []network.Port
-	for _, port := range rawOpen {
-		if fw.globalPortRef[port] == 0 {
-			toOpen = append(toOpen, port)
+	toOpen := map[string][]network.Port{}
+	toClose := map[string][]network.Port{}
+	for networkName, ports := range rawOpen {
+		refs := fw.globalPortRef[networkName]
+		if refs == nil {
+			refs = make(map[network.Port]int)
+			fw.globalPortRef[networkName] = refs
+		}
+		for _, port := range ports {
+			if refs[port] == 0 {
+				toOpen[networkName] = append(toOpen[networkName], port)
+			}
+			refs[port]++
 		}
-		fw.globalPortRef[port]++
 	}
-	for _, port := range rawClose {
-		fw.globalPortRef[port]--
-		if fw.globalPortRef[port] == 0 {
-			toClose = append(toClose, port)
-			delete(fw.globalPortRef, port)
+	for networkName, ports := range rawClose {
+		refs := fw.globalPortRef[networkName]
+		for _, port := range ports {
+			refs[port]--
+			if refs[port] == 0 {
+				toClose[networkName] = append(toClose[networkName], port)
+				delete(refs, port)
+			}
 		}
 	}
 	// Open and close the ports.
-	if len(toOpen) > 0 {
-		if err := fw.environ.OpenPorts(toOpen); err != nil {
+	for networkName, ports := range toOpen {
+		firewall := environFirewall(fw.environ, networkName)
+		if firewall == nil {
+			logger.Warningf("cannot open global ports %v on network %q: not supported by the environment", ports, networkName)
+			continue
+		}
+		if err := firewall.OpenPorts(ports); err != nil {
 			// TODO(mue) Add local retry logic.
 			return err
 		}
-		network.SortPorts(toOpen)
-		logger.Infof("opened ports %v in environment", toOpen)
+		network.SortPorts(ports)
+		logger.Infof("opened ports %v on network %q in environment", ports, networkName)
 	}
-	if len(toClose) > 0 {
-		if err := fw.environ.ClosePorts(toClose); err != nil {
+	for networkName, ports := range toClose {
+		firewall := environFirewall(fw.environ, networkName)
+		if firewall == nil {
+			continue
+		}
+		if err := firewall.ClosePorts(ports); err != nil {
 			// TODO(mue) Add local retry logic.
 			return err
 		}
-		network.SortPorts(toClose)
-		logger.Infof("closed ports %v in environment", toClose)
+		network.SortPorts(ports)
+		logger.Infof("closed ports %v on network %q in environment", ports, networkName)
 	}
 	return nil
 }
 
-// flushInstancePorts opens and closes ports global on the machine.
-func (fw *Firewaller) flushInstancePorts(machined *machineData, toOpen, toClose []network.Port) error {
+// flushInstancePorts opens and closes ports on the machine's
+// instance, on each network.
+func (fw *Firewaller) flushInstancePorts(machined *machineData, toOpen, toClose map[string][]network.Port) error {
 	// If there's nothing to do, do nothing.
 	// This is important because when a machine is first created,
 	// it will have no instance id but also no open ports -
@@ -486,21 +564,30 @@ func (fw *Firewaller) flushInstancePorts(machined *machineData, toOpen, toClose
 		return err
 	}
 	// Open and close the ports.
-	if len(toOpen) > 0 {
-		if err := instances[0].OpenPorts(machineId, toOpen); err != nil {
+	for networkName, ports := range toOpen {
+		firewall := instanceFirewall(instances[0], machineId, networkName)
+		if firewall == nil {
+			logger.Warningf("cannot open ports %v on network %q for %q: not supported by the instance", ports, networkName, machined.tag)
+			continue
+		}
+		if err := firewall.OpenPorts(ports); err != nil {
 			// TODO(mue) Add local retry logic.
 			return err
 		}
-		network.SortPorts(toOpen)
-		logger.Infof("opened ports %v on %q", toOpen, machined.tag)
+		network.SortPorts(ports)
+		logger.Infof("opened ports %v on network %q for %q", ports, networkName, machined.tag)
 	}
-	if len(toClose) > 0 {
-		if err := instances[0].ClosePorts(machineId, toClose); err != nil {
+	for networkName, ports := range toClose {
+		firewall := instanceFirewall(instances[0], machineId, networkName)
+		if firewall == nil {
+			continue
+		}
+		if err := firewall.ClosePorts(ports); err != nil {
 			// TODO(mue) Add local retry logic.
 			return err
 		}
-		network.SortPorts(toClose)
-		logger.Infof("closed ports %v on %q", toClose, machined.tag)
+		network.SortPorts(ports)
+		logger.Infof("closed ports %v on network %q for %q", ports, networkName, machined.tag)
 	}
 	return nil
 }
@@ -619,7 +706,7 @@ type machineData struct {
 	fw     *Firewaller
 	tag    names.MachineTag
 	unitds map[string]*unitData
-	ports  []network.Port
+	ports  map[string][]network.Port
 }
 
 func (md *machineData) machine() (*apifirewaller.Machine, error) {
@@ -657,10 +744,11 @@ func (md *machineData) Stop() error {
 	return md.tomb.Wait()
 }
 
-// portsChange contains the changed ports for one specific unit.
+// portsChange contains the changed ports on each network for one
+// specific unit.
 type portsChange struct {
 	unitd *unitData
-	ports []network.Port
+	ports map[string][]network.Port
 }
 
 // unitData holds unit details and watches port changes.
@@ -670,11 +758,11 @@ type unitData struct {
 	unit     *apifirewaller.Unit
 	serviced *serviceData
 	machined *machineData
-	ports    []network.Port
+	ports    map[string][]network.Port
 }
 
 // watchLoop watches the unit for port changes.
-func (ud *unitData) watchLoop(latestPorts []network.Port) {
+func (ud *unitData) watchLoop(latestPorts map[string][]network.Port) {
 	defer ud.tomb.Done()
 	w, err := ud.unit.Watch()
 	if err != nil {
@@ -697,15 +785,15 @@ func (ud *unitData) watchLoop(latestPorts []network.Port) {
 				}
 				return
 			}
-			change, err := ud.unit.OpenedPorts()
+			change, err := ud.unit.OpenedPortsOnNetworks()
 			if err != nil {
 				ud.fw.tomb.Kill(err)
 				return
 			}
-			if samePorts(change, latestPorts) {
+			if sameNetworkPorts(change, latestPorts) {
 				continue
 			}
-			latestPorts = append(latestPorts[:0], change...)
+			latestPorts = copyNetworkPorts(change)
 			select {
 			case ud.fw.portsChange <- &portsChange{ud, change}:
 			case <-ud.tomb.Dying():
@@ -729,29 +817,74 @@ func samePorts(old, new []network.Port) bool {
 	return true
 }
 
+// sameNetworkPorts returns whether old and new contain the same set
+// of ports on each network. The ports of each network must be sorted.
+func sameNetworkPorts(old, new map[string][]network.Port) bool {
+	if len(old) != len(new) {
+		return false
+	}
+	for networkName, ports := range old {
+		newPorts, ok := new[networkName]
+		if !ok || !samePorts(ports, newPorts) {
+			return false
+		}
+	}
+	return true
+}
+
+// copyNetworkPorts returns a copy of the given ports on each network.
+func copyNetworkPorts(ports map[string][]network.Port) map[string][]network.Port {
+	result := make(map[string][]network.Port, len(ports))
+	for networkName, networkPorts := range ports {
+		result[networkName] = append([]network.Port(nil), networkPorts...)
+	}
+	return result
+}
+
 // Stop stops the unit watching.
 func (ud *unitData) Stop() error {
 	ud.tomb.Kill(nil)
 	return ud.tomb.Wait()
 }
 
-// exposedChange contains the changed exposed flag for one specific service.
+// exposedChange contains the changed exposed flag and exposed
+// ports for one specific service.
 type exposedChange struct {
 	serviced *serviceData
 	exposed  bool
+	ports    []network.Port
 }
 
 // serviceData holds service details and watches exposure changes.
 type serviceData struct {
-	tomb    tomb.Tomb
-	fw      *Firewaller
-	service *apifirewaller.Service
-	exposed bool
-	unitds  map[string]*unitData
+	tomb         tomb.Tomb
+	fw           *Firewaller
+	service      *apifirewaller.Service
+	exposed      bool
+	exposedPorts []network.Port
+	unitds       map[string]*unitData
 }
 
-// watchLoop watches the service's exposed flag for changes.
-func (sd *serviceData) watchLoop(exposed bool) {
+// exposes returns whether the given port, when opened by
+// one of the service's units, should be opened in the firewall.
+func (sd *serviceData) exposes(port network.Port) bool {
+	if !sd.exposed {
+		return false
+	}
+	if len(sd.exposedPorts) == 0 {
+		return true
+	}
+	for _, p := range sd.exposedPorts {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
+
+// watchLoop watches the service's exposed flag and
+// exposed ports for changes.
+func (sd *serviceData) watchLoop(exposed bool, ports []network.Port) {
 	defer sd.tomb.Done()
 	w, err := sd.service.Watch()
 	if err != nil {
@@ -779,12 +912,17 @@ func (sd *serviceData) watchLoop(exposed bool) {
 				sd.fw.tomb.Kill(err)
 				return
 			}
-			if change == exposed {
+			changedPorts, err := sd.service.ExposedPorts()
+			if err != nil {
+				sd.fw.tomb.Kill(err)
+				return
+			}
+			if change == exposed && samePorts(changedPorts, ports) {
 				continue
 			}
-			exposed = change
+			exposed, ports = change, changedPorts
 			select {
-			case sd.fw.exposedChange <- &exposedChange{sd, change}:
+			case sd.fw.exposedChange <- &exposedChange{sd, change, changedPorts}:
 			case <-sd.tomb.Dying():
 				return
 			}
@@ -798,6 +936,92 @@ func (sd *serviceData) Stop() error {
 	return sd.tomb.Wait()
 }
 
+// portsFirewall opens, closes and lists the ports of one network,
+// either for the whole environment or on one instance.
+type portsFirewall interface {
+	OpenPorts(ports []network.Port) error
+	ClosePorts(ports []network.Port) error
+	Ports() ([]network.Port, error)
+}
+
+// environFirewall returns the firewall of the named network for the
+// whole environment, or nil if the environment cannot open ports on
+// that network alone.
+func environFirewall(env environs.Environ, networkName string) portsFirewall {
+	if networkName == network.DefaultPublic {
+		return env
+	}
+	if networkEnv, ok := env.(environs.NetworkFirewaller); ok {
+		return &environNetworkFirewall{networkEnv, networkName}
+	}
+	return nil
+}
+
+type environNetworkFirewall struct {
+	env         environs.NetworkFirewaller
+	networkName string
+}
+
+func (f *environNetworkFirewall) OpenPorts(ports []network.Port) error {
+	return f.env.OpenPortsOnNetwork(f.networkName, ports)
+}
+
+func (f *environNetworkFirewall) ClosePorts(ports []network.Port) error {
+	return f.env.ClosePortsOnNetwork(f.networkName, ports)
+}
+
+func (f *environNetworkFirewall) Ports() ([]network.Port, error) {
+	return f.env.PortsOnNetwork(f.networkName)
+}
+
+// instanceFirewall returns the firewall of the named network of the
+// given instance, or nil if the instance cannot open ports on that
+// network alone.
+func instanceFirewall(inst instance.Instance, machineId, networkName string) portsFirewall {
+	if networkName == network.DefaultPublic {
+		return &instanceDefaultFirewall{inst, machineId}
+	}
+	if networkInst, ok := inst.(instance.NetworkFirewaller); ok {
+		return &instanceNetworkFirewall{networkInst, machineId, networkName}
+	}
+	return nil
+}
+
+type instanceDefaultFirewall struct {
+	inst      instance.Instance
+	machineId string
+}
+
+func (f *instanceDefaultFirewall) OpenPorts(ports []network.Port) error {
+	return f.inst.OpenPorts(f.machineId, ports)
+}
+
+func (f *instanceDefaultFirewall) ClosePorts(ports []network.Port) error {
+	return f.inst.ClosePorts(f.machineId, ports)
+}
+
+func (f *instanceDefaultFirewall) Ports() ([]network.Port, error) {
+	return f.inst.Ports(f.machineId)
+}
+
+type instanceNetworkFirewall struct {
+	inst        instance.NetworkFirewaller
+	machineId   string
+	networkName string
+}
+
+func (f *instanceNetworkFirewall) OpenPorts(ports []network.Port) error {
+	return f.inst.OpenPortsOnNetwork(f.machineId, f.networkName, ports)
+}
+
+func (f *instanceNetworkFirewall) ClosePorts(ports []network.Port) error {
+	return f.inst.ClosePortsOnNetwork(f.machineId, f.networkName, ports)
+}
+
+func (f *instanceNetworkFirewall) Ports() ([]network.Port, error) {
+	return f.inst.PortsOnNetwork(f.machineId, f.networkName)
+}
+
 // Diff returns all the ports that exist in A but not B.
 func Diff(A, B []network.Port) (missing []network.Port) {
 next:
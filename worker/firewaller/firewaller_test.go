@@ -11,6 +11,7 @@ import (
 	"github.com/juju/utils"
 	gc "launchpad.net/gocheck"
 
+	"github.com/juju/juju/environs"
 	"github.com/juju/juju/environs/config"
 	"github.com/juju/juju/instance"
 	"github.com/juju/juju/juju"
@@ -69,6 +70,31 @@ func (s *FirewallerSuite) assertPorts(c *gc.C, inst instance.Instance, machineId
 	}
 }
 
+// assertPortsOnNetwork retrieves the open ports on the named network
+// of the instance and compares them to the expected.
+func (s *FirewallerSuite) assertPortsOnNetwork(c *gc.C, inst instance.Instance, machineId, networkName string, expected []network.Port) {
+	s.BackingState.StartSync()
+	start := time.Now()
+	for {
+		got, err := inst.(instance.NetworkFirewaller).PortsOnNetwork(machineId, networkName)
+		if err != nil {
+			c.Fatal(err)
+			return
+		}
+		network.SortPorts(got)
+		network.SortPorts(expected)
+		if reflect.DeepEqual(got, expected) {
+			c.Succeed()
+			return
+		}
+		if time.Since(start) > coretesting.LongWait {
+			c.Fatalf("timed out: expected %q; got %q", expected, got)
+			return
+		}
+		time.Sleep(coretesting.ShortWait)
+	}
+}
+
 // assertEnvironPorts retrieves the open ports of environment and compares them
 // to the expected.
 func (s *FirewallerSuite) assertEnvironPorts(c *gc.C, expected []network.Port) {
@@ -94,6 +120,31 @@ func (s *FirewallerSuite) assertEnvironPorts(c *gc.C, expected []network.Port) {
 	}
 }
 
+// assertEnvironPortsOnNetwork retrieves the open ports on the named
+// network of the environment and compares them to the expected.
+func (s *FirewallerSuite) assertEnvironPortsOnNetwork(c *gc.C, networkName string, expected []network.Port) {
+	s.BackingState.StartSync()
+	start := time.Now()
+	for {
+		got, err := s.Environ.(environs.NetworkFirewaller).PortsOnNetwork(networkName)
+		if err != nil {
+			c.Fatal(err)
+			return
+		}
+		network.SortPorts(got)
+		network.SortPorts(expected)
+		if reflect.DeepEqual(got, expected) {
+			c.Succeed()
+			return
+		}
+		if time.Since(start) > coretesting.LongWait {
+			c.Fatalf("timed out: expected %q; got %q", expected, got)
+			return
+		}
+		time.Sleep(coretesting.ShortWait)
+	}
+}
+
 var _ = gc.Suite(&FirewallerSuite{})
 
 func (s FirewallerGlobalModeSuite) SetUpTest(c *gc.C) {
@@ -196,6 +247,33 @@ func (s *FirewallerSuite) TestExposedService(c *gc.C) {
 	s.assertPorts(c, inst, m.Id(), []network.Port{{"tcp", 8080}})
 }
 
+func (s *FirewallerSuite) TestExposedServiceOnNetworks(c *gc.C) {
+	fw, err := firewaller.NewFirewaller(s.firewaller)
+	c.Assert(err, gc.IsNil)
+	defer func() { c.Assert(fw.Stop(), gc.IsNil) }()
+
+	svc := s.AddTestingService(c, "wordpress", s.charm)
+	err = svc.SetExposed()
+	c.Assert(err, gc.IsNil)
+	u, m := s.addUnit(c, svc)
+	inst := s.startInstance(c, m)
+
+	// Ports are only opened on the network they were opened on.
+	err = u.OpenPort("tcp", 80)
+	c.Assert(err, gc.IsNil)
+	err = u.OpenPortOnNetwork("net1", "tcp", 8080)
+	c.Assert(err, gc.IsNil)
+
+	s.assertPorts(c, inst, m.Id(), []network.Port{{"tcp", 80}})
+	s.assertPortsOnNetwork(c, inst, m.Id(), "net1", []network.Port{{"tcp", 8080}})
+
+	err = u.ClosePortOnNetwork("net1", "tcp", 8080)
+	c.Assert(err, gc.IsNil)
+
+	s.assertPortsOnNetwork(c, inst, m.Id(), "net1", nil)
+	s.assertPorts(c, inst, m.Id(), []network.Port{{"tcp", 80}})
+}
+
 func (s *FirewallerSuite) TestMultipleExposedServices(c *gc.C) {
 	fw, err := firewaller.NewFirewaller(s.firewaller)
 	c.Assert(err, gc.IsNil)
@@ -399,6 +477,45 @@ func (s *FirewallerSuite) TestSetClearExposedService(c *gc.C) {
 	s.assertPorts(c, inst, m.Id(), nil)
 }
 
+func (s *FirewallerSuite) TestExposedPortsService(c *gc.C) {
+	fw, err := firewaller.NewFirewaller(s.firewaller)
+	c.Assert(err, gc.IsNil)
+	defer func() { c.Assert(fw.Stop(), gc.IsNil) }()
+
+	svc := s.AddTestingService(c, "wordpress", s.charm)
+
+	u, m := s.addUnit(c, svc)
+	inst := s.startInstance(c, m)
+	err = u.OpenPort("tcp", 80)
+	c.Assert(err, gc.IsNil)
+	err = u.OpenPort("tcp", 443)
+	c.Assert(err, gc.IsNil)
+
+	// Only the exposed ports opened by the unit are opened.
+	err = svc.SetExposedPorts([]network.Port{{"tcp", 443}, {"tcp", 8080}})
+	c.Assert(err, gc.IsNil)
+	s.assertPorts(c, inst, m.Id(), []network.Port{{"tcp", 443}})
+
+	// Opening another exposed port opens it.
+	err = u.OpenPort("tcp", 8080)
+	c.Assert(err, gc.IsNil)
+	s.assertPorts(c, inst, m.Id(), []network.Port{{"tcp", 443}, {"tcp", 8080}})
+
+	// Changing the exposed ports changes the opened ports.
+	err = svc.SetExposedPorts([]network.Port{{"tcp", 80}})
+	c.Assert(err, gc.IsNil)
+	s.assertPorts(c, inst, m.Id(), []network.Port{{"tcp", 80}})
+
+	// Exposing all ports opens all of them.
+	err = svc.SetExposed()
+	c.Assert(err, gc.IsNil)
+	s.assertPorts(c, inst, m.Id(), []network.Port{{"tcp", 80}, {"tcp", 443}, {"tcp", 8080}})
+
+	err = svc.ClearExposed()
+	c.Assert(err, gc.IsNil)
+	s.assertPorts(c, inst, m.Id(), nil)
+}
+
 func (s *FirewallerSuite) TestRemoveUnit(c *gc.C) {
 	fw, err := firewaller.NewFirewaller(s.firewaller)
 	c.Assert(err, gc.IsNil)
@@ -613,6 +730,30 @@ func (s *FirewallerGlobalModeSuite) TestGlobalMode(c *gc.C) {
 	s.assertEnvironPorts(c, nil)
 }
 
+func (s *FirewallerGlobalModeSuite) TestGlobalModeOnNetworks(c *gc.C) {
+	fw, err := firewaller.NewFirewaller(s.firewaller)
+	c.Assert(err, gc.IsNil)
+	defer func() { c.Assert(fw.Stop(), gc.IsNil) }()
+
+	svc := s.AddTestingService(c, "wordpress", s.charm)
+	err = svc.SetExposed()
+	c.Assert(err, gc.IsNil)
+	u, m := s.addUnit(c, svc)
+	s.startInstance(c, m)
+
+	err = u.OpenPort("tcp", 80)
+	c.Assert(err, gc.IsNil)
+	err = u.OpenPortOnNetwork("net1", "tcp", 8080)
+	c.Assert(err, gc.IsNil)
+	s.assertEnvironPorts(c, []network.Port{{"tcp", 80}})
+	s.assertEnvironPortsOnNetwork(c, "net1", []network.Port{{"tcp", 8080}})
+
+	err = u.ClosePortOnNetwork("net1", "tcp", 8080)
+	c.Assert(err, gc.IsNil)
+	s.assertEnvironPortsOnNetwork(c, "net1", nil)
+	s.assertEnvironPorts(c, []network.Port{{"tcp", 80}})
+}
+
 func (s *FirewallerGlobalModeSuite) TestGlobalModeStartWithUnexposedService(c *gc.C) {
 	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
 	c.Assert(err, gc.IsNil)
@@ -760,3 +901,48 @@ func (s *FirewallerGlobalModeSuite) TestGlobalModeRestartPortCount(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	s.assertEnvironPorts(c, nil)
 }
+
+// FirewallerNoneModeSuite runs the firewaller in none mode. It does not
+// embed FirewallerSuite, whose tests all expect the firewall managed.
+type FirewallerNoneModeSuite struct {
+	suite FirewallerSuite
+}
+
+var _ = gc.Suite(&FirewallerNoneModeSuite{})
+
+func (s *FirewallerNoneModeSuite) SetUpSuite(c *gc.C) {
+	s.suite.SetUpSuite(c)
+}
+
+func (s *FirewallerNoneModeSuite) TearDownSuite(c *gc.C) {
+	s.suite.TearDownSuite(c)
+}
+
+func (s *FirewallerNoneModeSuite) SetUpTest(c *gc.C) {
+	add := map[string]interface{}{"firewall-mode": config.FwNone}
+	s.suite.DummyConfig = dummy.SampleConfig().Merge(add).Delete("admin-secret", "ca-private-key")
+	s.suite.SetUpTest(c)
+}
+
+func (s *FirewallerNoneModeSuite) TearDownTest(c *gc.C) {
+	s.suite.TearDownTest(c)
+}
+
+func (s *FirewallerNoneModeSuite) TestNoneMode(c *gc.C) {
+	fw, err := firewaller.NewFirewaller(s.suite.firewaller)
+	c.Assert(err, gc.IsNil)
+
+	svc := s.suite.AddTestingService(c, "wordpress", s.suite.charm)
+	err = svc.SetExposed()
+	c.Assert(err, gc.IsNil)
+	u, m := s.suite.addUnit(c, svc)
+	s.suite.startInstance(c, m)
+	err = u.OpenPort("tcp", 80)
+	c.Assert(err, gc.IsNil)
+
+	// The dummy provider refuses to open ports in none mode, which
+	// would stop the firewaller with an error if it tried.
+	s.suite.BackingState.StartSync()
+	time.Sleep(coretesting.ShortWait)
+	c.Assert(fw.Stop(), gc.IsNil)
+}
@@ -5,6 +5,8 @@ package networker
 
 import (
 	"path/filepath"
+
+	"github.com/juju/juju/network"
 )
 
 const (
@@ -33,3 +35,10 @@ func ChangeConfigDirName(dirName string) {
 	ConfigFileName = configFileName
 	ConfigSubDirName = configSubDirName
 }
+
+// ConfigText returns the configuration text generated for the
+// named interface, given the network info of all interfaces.
+func ConfigText(networkInfo []network.Info, ifaceName string) string {
+	s := &configState{networkInfo: networkInfo}
+	return s.configText(ifaceName, s.lookupNetworkInfo(ifaceName))
+}
@@ -26,6 +26,10 @@ type networker struct {
 	st                     *apinetworker.State
 	tag                    string
 	isVLANSupportInstalled bool
+
+	// isBondSupportInstalled is set once the packages
+	// needed to configure bonds and bridges are installed.
+	isBondSupportInstalled bool
 }
 
 // NewNetworker returns a Worker that handles machine networking
@@ -101,6 +105,12 @@ func (nw *networker) Handle() error {
 		nw.isVLANSupportInstalled = true
 	}
 
+	// Add commands to install bonding and bridging support, if required.
+	if !nw.isBondSupportInstalled && s.hasBondsOrBridges() {
+		s.ensureBondSupport()
+		nw.isBondSupportInstalled = true
+	}
+
 	// Up configured interfaces.
 	s.bringUpInterfaces()
 	if err = s.apply(); err != nil {
@@ -6,6 +6,7 @@ package networker
 import (
 	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/juju/juju/network"
 )
@@ -113,12 +114,55 @@ func (s *configState) ensureVLANModule() {
 	s.commands = append(s.commands, commands...)
 }
 
+// hasBondsOrBridges returns whether any of the interfaces
+// to configure is a bond or a bridge.
+func (s *configState) hasBondsOrBridges() bool {
+	for _, info := range s.networkInfo {
+		if info.InterfaceType == network.BondInterface || info.InterfaceType == network.BridgeInterface {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *configState) ensureBondSupport() {
+	commands := []string{
+		`dpkg-query -s ifenslave || apt-get --option Dpkg::Options::=--force-confold --assume-yes install ifenslave`,
+		`dpkg-query -s bridge-utils || apt-get --option Dpkg::Options::=--force-confold --assume-yes install bridge-utils`,
+		`lsmod | grep -q bonding || modprobe bonding`,
+		`grep -q bonding /etc/modules || echo bonding >> /etc/modules`,
+	}
+	s.commands = append(s.commands, commands...)
+}
+
+// memberNames returns the sorted names of the
+// interfaces that are members of the given one.
+func (s *configState) memberNames(ifaceName string) []string {
+	var names []string
+	for _, info := range s.networkInfo {
+		if info.ParentInterfaceName == ifaceName && !info.Disabled {
+			names = append(names, info.ActualInterfaceName())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 // configText generate configuration text for interface based on its configuration.
 func (s *configState) configText(interfaceName string, info *network.Info) string {
 	if info == nil {
 		return ""
 	}
-	text := fmt.Sprintf("auto %s\niface %s inet dhcp\n", interfaceName, interfaceName)
+	// Members of bonds and bridges get their addresses through them.
+	var parent *network.Info
+	if info.ParentInterfaceName != "" {
+		parent = s.lookupNetworkInfo(info.ParentInterfaceName)
+	}
+	method := "dhcp"
+	if parent != nil {
+		method = "manual"
+	}
+	text := fmt.Sprintf("auto %s\niface %s inet %s\n", interfaceName, interfaceName, method)
 
 	// Add vlan-raw-device line for VLAN interfaces.
 	if info.VLANTag != 0 {
@@ -127,5 +171,20 @@ func (s *configState) configText(interfaceName string, info *network.Info) strin
 			text += fmt.Sprintf("\tvlan-raw-device %s\n", interfaceName[:len(interfaceName)-len(suffix)])
 		}
 	}
+
+	if parent != nil && parent.InterfaceType == network.BondInterface {
+		text += fmt.Sprintf("\tbond-master %s\n", info.ParentInterfaceName)
+	}
+	switch info.InterfaceType {
+	case network.BondInterface:
+		// The members name their bond with bond-master.
+		text += fmt.Sprintf("\tbond-mode %s\n\tbond-slaves none\n", info.BondMode)
+	case network.BridgeInterface:
+		ports := s.memberNames(interfaceName)
+		if len(ports) == 0 {
+			ports = []string{"none"}
+		}
+		text += fmt.Sprintf("\tbridge_ports %s\n", strings.Join(ports, " "))
+	}
 	return text
 }
@@ -0,0 +1,73 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package networker_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/testing"
+	"github.com/juju/juju/worker/networker"
+)
+
+type stateSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&stateSuite{})
+
+var bondAndBridgeInfo = []network.Info{{
+	InterfaceName:       "eth1",
+	ParentInterfaceName: "bond0",
+}, {
+	InterfaceName:       "eth2",
+	ParentInterfaceName: "bond0",
+}, {
+	InterfaceName: "bond0",
+	InterfaceType: network.BondInterface,
+	BondMode:      "802.3ad",
+}, {
+	InterfaceName:       "eth3",
+	ParentInterfaceName: "br1",
+}, {
+	InterfaceName:       "eth4",
+	ParentInterfaceName: "br1",
+}, {
+	InterfaceName: "br1",
+	InterfaceType: network.BridgeInterface,
+}, {
+	InterfaceName: "eth5",
+	VLANTag:       42,
+}, {
+	InterfaceName:       "eth6",
+	ParentInterfaceName: "missing",
+}}
+
+func (s *stateSuite) TestConfigText(c *gc.C) {
+	for i, test := range []struct {
+		iface string
+		text  string
+	}{{
+		iface: "eth1",
+		text:  "auto eth1\niface eth1 inet manual\n\tbond-master bond0\n",
+	}, {
+		iface: "bond0",
+		text:  "auto bond0\niface bond0 inet dhcp\n\tbond-mode 802.3ad\n\tbond-slaves none\n",
+	}, {
+		iface: "eth3",
+		text:  "auto eth3\niface eth3 inet manual\n",
+	}, {
+		iface: "br1",
+		text:  "auto br1\niface br1 inet dhcp\n\tbridge_ports eth3 eth4\n",
+	}, {
+		iface: "eth5.42",
+		text:  "auto eth5.42\niface eth5.42 inet dhcp\n\tvlan-raw-device eth5\n",
+	}, {
+		iface: "eth6",
+		text:  "auto eth6\niface eth6 inet dhcp\n",
+	}} {
+		c.Logf("test %d: %s", i, test.iface)
+		c.Check(networker.ConfigText(bondAndBridgeInfo, test.iface), gc.Equals, test.text)
+	}
+}
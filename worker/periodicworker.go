@@ -0,0 +1,60 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package worker
+
+import (
+	"time"
+
+	"launchpad.net/tomb"
+)
+
+// PeriodicWorkerCall is the function called by a periodic worker. It is
+// given a channel that is closed when the worker is killed.
+type PeriodicWorkerCall func(stop <-chan struct{}) error
+
+// periodicWorker implements the worker returned by NewPeriodicWorker.
+type periodicWorker struct {
+	tomb tomb.Tomb
+}
+
+// NewPeriodicWorker returns a worker that calls the given function
+// straight away, and again each period after the previous call
+// returns. If the function returns an error, the worker stops with
+// that error; functions that should be retried after failing must
+// handle their errors themselves.
+func NewPeriodicWorker(call PeriodicWorkerCall, period time.Duration) Worker {
+	w := &periodicWorker{}
+	go func() {
+		defer w.tomb.Done()
+		w.tomb.Kill(w.run(call, period))
+	}()
+	return w
+}
+
+func (w *periodicWorker) run(call PeriodicWorkerCall, period time.Duration) error {
+	next := time.After(0)
+	for {
+		select {
+		case <-w.tomb.Dying():
+			return tomb.ErrDying
+		case <-next:
+			if err := call(w.tomb.Dying()); err != nil {
+				return err
+			}
+			next = time.After(period)
+		}
+	}
+}
+
+// Kill implements Worker.Kill() and will close the channel given to
+// the called function.
+func (w *periodicWorker) Kill() {
+	w.tomb.Kill(nil)
+}
+
+// Wait implements Worker.Wait(), and will return the error returned
+// by the called function, if any.
+func (w *periodicWorker) Wait() error {
+	return w.tomb.Wait()
+}
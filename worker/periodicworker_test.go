@@ -0,0 +1,65 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package worker
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/testing"
+)
+
+type periodicWorkerSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&periodicWorkerSuite{})
+
+func (s *periodicWorkerSuite) TestCallsPeriodically(c *gc.C) {
+	calls := make(chan struct{}, 10)
+	doWork := func(_ <-chan struct{}) error {
+		select {
+		case calls <- struct{}{}:
+		default:
+		}
+		return nil
+	}
+
+	w := NewPeriodicWorker(doWork, time.Millisecond)
+	defer func() { c.Assert(Stop(w), gc.IsNil) }()
+	for i := 0; i < 3; i++ {
+		select {
+		case <-calls:
+		case <-time.After(testing.LongWait):
+			c.Fatalf("function not called")
+		}
+	}
+}
+
+func (s *periodicWorkerSuite) TestCallError(c *gc.C) {
+	doWork := func(_ <-chan struct{}) error {
+		return testError
+	}
+
+	w := NewPeriodicWorker(doWork, time.Hour)
+	c.Assert(w.Wait(), gc.Equals, testError)
+}
+
+func (s *periodicWorkerSuite) TestKill(c *gc.C) {
+	called := make(chan struct{})
+	doWork := func(stopCh <-chan struct{}) error {
+		close(called)
+		<-stopCh
+		return nil
+	}
+
+	w := NewPeriodicWorker(doWork, time.Hour)
+	<-called
+	w.Kill()
+	c.Assert(w.Wait(), gc.IsNil)
+
+	// test we can kill again without a panic
+	w.Kill()
+}
@@ -0,0 +1,52 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package presencetimings
+
+import (
+	"github.com/juju/loggo"
+
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/watcher"
+	"github.com/juju/juju/state/presence"
+	"github.com/juju/juju/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.presencetimings")
+
+// Updater keeps the presence timings used by this process in line
+// with the environment configuration.
+type Updater struct {
+	st *state.State
+}
+
+// NewUpdater returns a worker.Worker that applies the presence ping
+// interval and liveness window from the environment configuration
+// whenever it changes.
+func NewUpdater(st *state.State) worker.Worker {
+	return worker.NewNotifyWorker(&Updater{st: st})
+}
+
+func (u *Updater) SetUp() (watcher.NotifyWatcher, error) {
+	return u.st.WatchForEnvironConfigChanges(), nil
+}
+
+func (u *Updater) Handle() error {
+	cfg, err := u.st.EnvironConfig()
+	if err != nil {
+		return err
+	}
+	timings := cfg.PresenceTimings()
+	logger.Debugf("setting presence ping interval %v, liveness window %v", timings.PingInterval, timings.LivenessWindow)
+	if err := presence.SetTimings(timings.PingInterval, timings.LivenessWindow); err != nil {
+		// The configuration is validated when set, so this should
+		// never happen; keep the current timings rather than stop.
+		logger.Errorf("cannot set presence timings: %v", err)
+	}
+	return nil
+}
+
+func (u *Updater) TearDown() error {
+	// Nothing to clean up, only state is the watcher
+	return nil
+}
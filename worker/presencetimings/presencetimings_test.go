@@ -0,0 +1,73 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package presencetimings_test
+
+import (
+	stdtesting "testing"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state/presence"
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/worker"
+	"github.com/juju/juju/worker/presencetimings"
+)
+
+func TestPackage(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type UpdaterSuite struct {
+	testing.JujuConnSuite
+}
+
+var _ = gc.Suite(&UpdaterSuite{})
+
+var _ worker.NotifyWatchHandler = (*presencetimings.Updater)(nil)
+
+func (s *UpdaterSuite) TearDownTest(c *gc.C) {
+	err := presence.SetTimings(0, 30*time.Second)
+	c.Assert(err, gc.IsNil)
+	s.JujuConnSuite.TearDownTest(c)
+}
+
+func (s *UpdaterSuite) TestUpdater(c *gc.C) {
+	u := presencetimings.NewUpdater(s.State)
+	defer func() { c.Assert(worker.Stop(u), gc.IsNil) }()
+
+	// The default timings are applied at once.
+	s.waitForTimings(c, 23*time.Second, 30*time.Second)
+
+	// Changes to the environment configuration are adopted.
+	err := s.State.UpdateEnvironConfig(map[string]interface{}{
+		"presence-ping-interval":   5,
+		"presence-liveness-window": 10,
+	}, nil, nil)
+	c.Assert(err, gc.IsNil)
+	s.waitForTimings(c, 5*time.Second, 10*time.Second)
+
+	// Removing the ping interval derives it from the window again.
+	err = s.State.UpdateEnvironConfig(nil, []string{"presence-ping-interval"}, nil)
+	c.Assert(err, gc.IsNil)
+	s.waitForTimings(c, 8*time.Second, 10*time.Second)
+}
+
+func (s *UpdaterSuite) waitForTimings(c *gc.C, interval, window time.Duration) {
+	timeout := time.After(coretesting.LongWait)
+	for {
+		s.State.StartSync()
+		gotInterval, gotWindow := presence.Timings()
+		if gotInterval == interval && gotWindow == window {
+			return
+		}
+		select {
+		case <-timeout:
+			c.Fatalf("timed out waiting for presence timings %v, %v; got %v, %v",
+				interval, window, gotInterval, gotWindow)
+		case <-time.After(coretesting.ShortWait):
+		}
+	}
+}
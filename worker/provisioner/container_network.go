@@ -0,0 +1,162 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package provisioner
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/juju/juju/agent"
+	"github.com/juju/juju/constraints"
+	"github.com/juju/juju/container"
+	"github.com/juju/juju/container/kvm"
+	"github.com/juju/juju/container/lxc"
+	"github.com/juju/juju/instance"
+)
+
+// procNetRoute holds the path of the kernel routing table, used to
+// find the host device carrying the default route.
+var procNetRoute = "/proc/net/route"
+
+// sysClassNet holds the path of the directory describing the host's
+// network devices, used to tell whether a device is a bridge.
+var sysClassNet = "/sys/class/net"
+
+// containerNetworkBackend wires the network interfaces of the
+// containers started on a host.
+type containerNetworkBackend interface {
+	// NetworkConfig returns the network configuration for a new
+	// container of the given type on the host with the given
+	// agent config.
+	NetworkConfig(containerType instance.ContainerType, agentConfig agent.Config) (*container.NetworkConfig, error)
+}
+
+// containerNetworkBackends holds the container network backends,
+// keyed by network type.
+var containerNetworkBackends = map[string]containerNetworkBackend{
+	container.BridgeNetwork:     bridgeBackend{},
+	container.HostBridgeNetwork: hostBridgeBackend{},
+	container.MACVLANNetwork:    macvlanBackend{},
+	container.OVSNetwork:        ovsBackend{},
+}
+
+// containerNetworkConfig returns the network configuration to use for
+// a new container of the given type. The network type is taken from
+// the container's container-network constraint, if set, or else from
+// the environment's container-network-type setting; bridged networking
+// is used if neither is set.
+func containerNetworkConfig(
+	containerType instance.ContainerType,
+	agentConfig agent.Config,
+	envNetworkType string,
+	cons constraints.Value,
+) (*container.NetworkConfig, error) {
+	networkType := envNetworkType
+	if cons.HasContainerNetwork() {
+		networkType = *cons.ContainerNetwork
+	}
+	if networkType == "" {
+		networkType = container.BridgeNetwork
+	}
+	backend, ok := containerNetworkBackends[networkType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported container network type %q", networkType)
+	}
+	return backend.NetworkConfig(containerType, agentConfig)
+}
+
+// bridgeBackend attaches containers to the bridge named in the agent
+// config, or else to the default bridge for the container type (such
+// as lxcbr0), which is private to the host.
+type bridgeBackend struct{}
+
+func (bridgeBackend) NetworkConfig(containerType instance.ContainerType, agentConfig agent.Config) (*container.NetworkConfig, error) {
+	if device := agentConfig.Value(agent.LxcBridge); device != "" {
+		return container.BridgeNetworkConfig(device), nil
+	}
+	if containerType == instance.KVM {
+		return container.BridgeNetworkConfig(kvm.DefaultKvmBridge), nil
+	}
+	return container.BridgeNetworkConfig(lxc.DefaultLxcBridge), nil
+}
+
+// hostBridgeBackend attaches containers to the bridge carrying the
+// host's default route, so that they share the host's network and
+// get addresses from it.
+type hostBridgeBackend struct{}
+
+func (hostBridgeBackend) NetworkConfig(containerType instance.ContainerType, agentConfig agent.Config) (*container.NetworkConfig, error) {
+	device, err := defaultRouteDevice()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(filepath.Join(sysClassNet, device, "bridge")); err != nil {
+		return nil, fmt.Errorf("%q networking requires the host device with the default route (%s) to be a bridge", container.HostBridgeNetwork, device)
+	}
+	return container.BridgeNetworkConfig(device), nil
+}
+
+// macvlanBackend gives LXC containers a MACVLAN interface on the
+// device named in the agent config, or else on the host device
+// carrying the default route. The host-only LXC bridge has no uplink
+// and is never used.
+type macvlanBackend struct{}
+
+func (macvlanBackend) NetworkConfig(containerType instance.ContainerType, agentConfig agent.Config) (*container.NetworkConfig, error) {
+	if containerType != instance.LXC {
+		return nil, fmt.Errorf("%s containers do not support %q networking", containerType, container.MACVLANNetwork)
+	}
+	device := agentConfig.Value(agent.LxcBridge)
+	if device == "" {
+		var err error
+		if device, err = defaultRouteDevice(); err != nil {
+			return nil, err
+		}
+	}
+	return container.MACVLANNetworkConfig(device), nil
+}
+
+// ovsBackend attaches LXC containers to the Open vSwitch bridge named
+// in the agent config.
+type ovsBackend struct{}
+
+func (ovsBackend) NetworkConfig(containerType instance.ContainerType, agentConfig agent.Config) (*container.NetworkConfig, error) {
+	if containerType != instance.LXC {
+		return nil, fmt.Errorf("%s containers do not support %q networking", containerType, container.OVSNetwork)
+	}
+	device := agentConfig.Value(agent.LxcBridge)
+	if device == "" {
+		return nil, fmt.Errorf("%q networking requires %s to name an Open vSwitch bridge", container.OVSNetwork, agent.LxcBridge)
+	}
+	return container.OVSNetworkConfig(device), nil
+}
+
+// defaultRouteDevice returns the name of the host network device
+// carrying the default IPv4 route.
+func defaultRouteDevice() (string, error) {
+	f, err := os.Open(procNetRoute)
+	if err != nil {
+		return "", fmt.Errorf("cannot read routing table: %v", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// Each line holds Iface, Destination, Gateway, Flags, ...,
+		// Mask, ...; the first line is a header.
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 || fields[0] == "Iface" {
+			continue
+		}
+		if fields[1] == "00000000" && fields[7] == "00000000" {
+			return fields[0], nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("cannot read routing table: %v", err)
+	}
+	return "", fmt.Errorf("cannot find host device with a default route")
+}
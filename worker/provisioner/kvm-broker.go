@@ -64,25 +64,23 @@ func (broker *kvmBroker) StartInstance(args environs.StartInstanceParams) (insta
 	machineId := args.MachineConfig.MachineId
 	kvmLogger.Infof("starting kvm container for machineId: %s", machineId)
 
-	// TODO: Default to using the host network until we can configure.  Yes,
-	// this is using the LxcBridge value, we should put it in the api call for
-	// container config.
-	bridgeDevice := broker.agentConfig.Value(agent.LxcBridge)
-	if bridgeDevice == "" {
-		bridgeDevice = kvm.DefaultKvmBridge
+	config, err := broker.api.ContainerConfig()
+	if err != nil {
+		kvmLogger.Errorf("failed to get container config: %v", err)
+		return nil, nil, nil, err
+	}
+
+	network, err := containerNetworkConfig(instance.KVM, broker.agentConfig, config.ContainerNetworkType, args.Constraints)
+	if err != nil {
+		kvmLogger.Errorf("failed to configure container network: %v", err)
+		return nil, nil, nil, err
 	}
-	network := container.BridgeNetworkConfig(bridgeDevice)
 
 	// TODO: series doesn't necessarily need to be the same as the host.
 	series := args.Tools.OneSeries()
 	args.MachineConfig.MachineContainerType = instance.KVM
 	args.MachineConfig.Tools = args.Tools[0]
 
-	config, err := broker.api.ContainerConfig()
-	if err != nil {
-		kvmLogger.Errorf("failed to get container config: %v", err)
-		return nil, nil, nil, err
-	}
 	if err := environs.PopulateMachineConfig(
 		args.MachineConfig,
 		config.ProviderType,
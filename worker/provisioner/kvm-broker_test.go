@@ -5,6 +5,8 @@ package provisioner_test
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"time"
 
@@ -40,6 +42,7 @@ type kvmBrokerSuite struct {
 	kvmSuite
 	broker      environs.InstanceBroker
 	agentConfig agent.Config
+	api         *fakeAPI
 }
 
 var _ = gc.Suite(&kvmBrokerSuite{})
@@ -82,11 +85,18 @@ func (s *kvmBrokerSuite) SetUpTest(c *gc.C) {
 		})
 	c.Assert(err, gc.IsNil)
 	managerConfig := container.ManagerConfig{container.ConfigName: "juju"}
-	s.broker, err = provisioner.NewKvmBroker(&fakeAPI{}, tools, s.agentConfig, managerConfig)
+	s.api = &fakeAPI{}
+	s.broker, err = provisioner.NewKvmBroker(s.api, tools, s.agentConfig, managerConfig)
 	c.Assert(err, gc.IsNil)
 }
 
 func (s *kvmBrokerSuite) startInstance(c *gc.C, machineId string) instance.Instance {
+	inst, err := s.tryStartInstance(machineId)
+	c.Assert(err, gc.IsNil)
+	return inst
+}
+
+func (s *kvmBrokerSuite) tryStartInstance(machineId string) (instance.Instance, error) {
 	machineNonce := "fake-nonce"
 	stateInfo := jujutesting.FakeStateInfo(machineId)
 	apiInfo := jujutesting.FakeAPIInfo(machineId)
@@ -98,8 +108,39 @@ func (s *kvmBrokerSuite) startInstance(c *gc.C, machineId string) instance.Insta
 		Tools:         possibleTools,
 		MachineConfig: machineConfig,
 	})
+	return kvm, err
+}
+
+func (s *kvmBrokerSuite) TestStartInstanceBridgeNetwork(c *gc.C) {
+	s.api.networkType = container.BridgeNetwork
+	kvm := s.startInstance(c, "1/kvm/0")
+	s.assertInstances(c, kvm)
+}
+
+func (s *kvmBrokerSuite) TestStartInstanceOnlySupportsBridgeNetwork(c *gc.C) {
+	for i, networkType := range []string{container.MACVLANNetwork, container.OVSNetwork} {
+		c.Logf("test %d: %s", i, networkType)
+		s.api.networkType = networkType
+		_, err := s.tryStartInstance("1/kvm/0")
+		c.Assert(err, gc.ErrorMatches, fmt.Sprintf("kvm containers do not support %q networking", networkType))
+		s.assertInstances(c)
+	}
+}
+
+func (s *kvmBrokerSuite) TestStartInstanceHostBridgeNetwork(c *gc.C) {
+	routes := "Iface\tDestination\tGateway \tFlags\tRefCnt\tUse\tMetric\tMask\t\tMTU\tWindow\tIRTT\n" +
+		"br0\t00000000\t0100000A\t0003\t0\t0\t0\t00000000\t0\t0\t0\n"
+	dir := c.MkDir()
+	err := ioutil.WriteFile(filepath.Join(dir, "route"), []byte(routes), 0644)
+	c.Assert(err, gc.IsNil)
+	s.PatchValue(provisioner.ProcNetRoute, filepath.Join(dir, "route"))
+	err = os.MkdirAll(filepath.Join(dir, "net", "br0", "bridge"), 0755)
 	c.Assert(err, gc.IsNil)
-	return kvm
+	s.PatchValue(provisioner.SysClassNet, filepath.Join(dir, "net"))
+
+	s.api.networkType = container.HostBridgeNetwork
+	kvm := s.startInstance(c, "1/kvm/0")
+	s.assertInstances(c, kvm)
 }
 
 func (s *kvmBrokerSuite) TestStopInstance(c *gc.C) {
@@ -64,22 +64,22 @@ func (broker *lxcBroker) StartInstance(args environs.StartInstanceParams) (insta
 	machineId := args.MachineConfig.MachineId
 	lxcLogger.Infof("starting lxc container for machineId: %s", machineId)
 
-	// Default to using the host network until we can configure.
-	bridgeDevice := broker.agentConfig.Value(agent.LxcBridge)
-	if bridgeDevice == "" {
-		bridgeDevice = lxc.DefaultLxcBridge
+	config, err := broker.api.ContainerConfig()
+	if err != nil {
+		lxcLogger.Errorf("failed to get container config: %v", err)
+		return nil, nil, nil, err
+	}
+
+	network, err := containerNetworkConfig(instance.LXC, broker.agentConfig, config.ContainerNetworkType, args.Constraints)
+	if err != nil {
+		lxcLogger.Errorf("failed to configure container network: %v", err)
+		return nil, nil, nil, err
 	}
-	network := container.BridgeNetworkConfig(bridgeDevice)
 
 	series := args.Tools.OneSeries()
 	args.MachineConfig.MachineContainerType = instance.LXC
 	args.MachineConfig.Tools = args.Tools[0]
 
-	config, err := broker.api.ContainerConfig()
-	if err != nil {
-		lxcLogger.Errorf("failed to get container config: %v", err)
-		return nil, nil, nil, err
-	}
 	if err := environs.PopulateMachineConfig(
 		args.MachineConfig,
 		config.ProviderType,
@@ -6,6 +6,7 @@ package provisioner_test
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"time"
 
@@ -42,6 +43,7 @@ type lxcBrokerSuite struct {
 	lxcSuite
 	broker      environs.InstanceBroker
 	agentConfig agent.ConfigSetterWriter
+	api         *fakeAPI
 }
 
 var _ = gc.Suite(&lxcBrokerSuite{})
@@ -84,24 +86,33 @@ func (s *lxcBrokerSuite) SetUpTest(c *gc.C) {
 		})
 	c.Assert(err, gc.IsNil)
 	managerConfig := container.ManagerConfig{container.ConfigName: "juju", "use-clone": "false"}
-	s.broker, err = provisioner.NewLxcBroker(&fakeAPI{}, tools, s.agentConfig, managerConfig)
+	s.api = &fakeAPI{}
+	s.broker, err = provisioner.NewLxcBroker(s.api, tools, s.agentConfig, managerConfig)
 	c.Assert(err, gc.IsNil)
 }
 
 func (s *lxcBrokerSuite) startInstance(c *gc.C, machineId string) instance.Instance {
+	inst, err := s.tryStartInstance(machineId)
+	c.Assert(err, gc.IsNil)
+	return inst
+}
+
+func (s *lxcBrokerSuite) tryStartInstance(machineId string) (instance.Instance, error) {
+	return s.tryStartInstanceWithConstraints(machineId, constraints.Value{})
+}
+
+func (s *lxcBrokerSuite) tryStartInstanceWithConstraints(machineId string, cons constraints.Value) (instance.Instance, error) {
 	machineNonce := "fake-nonce"
 	stateInfo := jujutesting.FakeStateInfo(machineId)
 	apiInfo := jujutesting.FakeAPIInfo(machineId)
 	machineConfig := environs.NewMachineConfig(machineId, machineNonce, nil, stateInfo, apiInfo)
-	cons := constraints.Value{}
 	possibleTools := s.broker.(coretools.HasTools).Tools("precise")
 	lxc, _, _, err := s.broker.StartInstance(environs.StartInstanceParams{
 		Constraints:   cons,
 		Tools:         possibleTools,
 		MachineConfig: machineConfig,
 	})
-	c.Assert(err, gc.IsNil)
-	return lxc
+	return lxc, err
 }
 
 func (s *lxcBrokerSuite) TestStartInstance(c *gc.C) {
@@ -131,6 +142,125 @@ func (s *lxcBrokerSuite) TestStartInstanceWithBridgeEnviron(c *gc.C) {
 	c.Assert(string(lxcConfContents), jc.Contains, "lxc.network.link = br0")
 }
 
+func (s *lxcBrokerSuite) patchDefaultRoute(c *gc.C, device string) {
+	routes := "Iface\tDestination\tGateway \tFlags\tRefCnt\tUse\tMetric\tMask\t\tMTU\tWindow\tIRTT\n" +
+		"lxcbr0\t0003000A\t00000000\t0001\t0\t0\t0\t00FFFFFF\t0\t0\t0\n" +
+		device + "\t00000000\t0100000A\t0003\t0\t0\t0\t00000000\t0\t0\t0\n"
+	path := filepath.Join(c.MkDir(), "route")
+	err := ioutil.WriteFile(path, []byte(routes), 0644)
+	c.Assert(err, gc.IsNil)
+	s.PatchValue(provisioner.ProcNetRoute, path)
+}
+
+// patchBridge makes the given host device a bridge, or not.
+func (s *lxcBrokerSuite) patchBridge(c *gc.C, device string, isBridge bool) {
+	dir := c.MkDir()
+	if isBridge {
+		err := os.MkdirAll(filepath.Join(dir, device, "bridge"), 0755)
+		c.Assert(err, gc.IsNil)
+	}
+	s.PatchValue(provisioner.SysClassNet, dir)
+}
+
+func (s *lxcBrokerSuite) lxcConfContents(c *gc.C, lxc instance.Instance) string {
+	contents, err := ioutil.ReadFile(filepath.Join(s.ContainerDir, string(lxc.Id()), "lxc.conf"))
+	c.Assert(err, gc.IsNil)
+	return string(contents)
+}
+
+func (s *lxcBrokerSuite) TestStartInstanceMACVLAN(c *gc.C) {
+	s.patchDefaultRoute(c, "eth1")
+	s.api.networkType = container.MACVLANNetwork
+	lxc := s.startInstance(c, "1/lxc/0")
+	// The MACVLAN interface sits on the device with the default
+	// route, not on lxcbr0.
+	lxcConf := s.lxcConfContents(c, lxc)
+	c.Assert(lxcConf, jc.Contains, "lxc.network.type = macvlan")
+	c.Assert(lxcConf, jc.Contains, "lxc.network.link = eth1")
+	c.Assert(lxcConf, jc.Contains, "lxc.network.macvlan.mode = bridge")
+}
+
+func (s *lxcBrokerSuite) TestStartInstanceMACVLANWithBridgeEnviron(c *gc.C) {
+	s.patchDefaultRoute(c, "eth1")
+	s.agentConfig.SetValue(agent.LxcBridge, "br0")
+	s.api.networkType = container.MACVLANNetwork
+	lxc := s.startInstance(c, "1/lxc/0")
+	lxcConf := s.lxcConfContents(c, lxc)
+	c.Assert(lxcConf, jc.Contains, "lxc.network.type = macvlan")
+	c.Assert(lxcConf, jc.Contains, "lxc.network.link = br0")
+}
+
+func (s *lxcBrokerSuite) TestStartInstanceMACVLANNoDefaultRoute(c *gc.C) {
+	s.PatchValue(provisioner.ProcNetRoute, filepath.Join(c.MkDir(), "missing"))
+	s.api.networkType = container.MACVLANNetwork
+	_, err := s.tryStartInstance("1/lxc/0")
+	c.Assert(err, gc.ErrorMatches, "cannot read routing table: .*")
+	s.assertInstances(c)
+}
+
+func (s *lxcBrokerSuite) TestStartInstanceOVS(c *gc.C) {
+	s.agentConfig.SetValue(agent.LxcBridge, "br-int")
+	s.api.networkType = container.OVSNetwork
+	lxc := s.startInstance(c, "1/lxc/0")
+	lxcConf := s.lxcConfContents(c, lxc)
+	c.Assert(lxcConf, jc.Contains, "lxc.network.type = veth")
+	c.Assert(lxcConf, jc.Contains, "lxc.network.link = br-int")
+}
+
+func (s *lxcBrokerSuite) TestStartInstanceOVSRequiresBridge(c *gc.C) {
+	s.api.networkType = container.OVSNetwork
+	_, err := s.tryStartInstance("1/lxc/0")
+	c.Assert(err, gc.ErrorMatches, `"ovs" networking requires LXC_BRIDGE to name an Open vSwitch bridge`)
+	s.assertInstances(c)
+}
+
+func (s *lxcBrokerSuite) TestStartInstanceHostBridge(c *gc.C) {
+	s.patchDefaultRoute(c, "br-eth0")
+	s.patchBridge(c, "br-eth0", true)
+	s.api.networkType = container.HostBridgeNetwork
+	lxc := s.startInstance(c, "1/lxc/0")
+	lxcConf := s.lxcConfContents(c, lxc)
+	c.Assert(lxcConf, jc.Contains, "lxc.network.type = veth")
+	c.Assert(lxcConf, jc.Contains, "lxc.network.link = br-eth0")
+}
+
+func (s *lxcBrokerSuite) TestStartInstanceHostBridgeRequiresBridge(c *gc.C) {
+	s.patchDefaultRoute(c, "eth0")
+	s.patchBridge(c, "eth0", false)
+	s.api.networkType = container.HostBridgeNetwork
+	_, err := s.tryStartInstance("1/lxc/0")
+	c.Assert(err, gc.ErrorMatches, `"host-bridge" networking requires the host device with the default route \(eth0\) to be a bridge`)
+	s.assertInstances(c)
+}
+
+func (s *lxcBrokerSuite) TestStartInstanceContainerNetworkConstraint(c *gc.C) {
+	s.patchDefaultRoute(c, "eth1")
+	s.api.networkType = container.BridgeNetwork
+	// The machine's constraint overrides the environment's
+	// container network type.
+	cons := constraints.MustParse("container-network=macvlan")
+	lxc, err := s.tryStartInstanceWithConstraints("1/lxc/0", cons)
+	c.Assert(err, gc.IsNil)
+	lxcConf := s.lxcConfContents(c, lxc)
+	c.Assert(lxcConf, jc.Contains, "lxc.network.type = macvlan")
+	c.Assert(lxcConf, jc.Contains, "lxc.network.link = eth1")
+
+	// An empty constraint leaves the environment's type in force.
+	cons = constraints.MustParse("container-network=")
+	lxc, err = s.tryStartInstanceWithConstraints("1/lxc/1", cons)
+	c.Assert(err, gc.IsNil)
+	lxcConf = s.lxcConfContents(c, lxc)
+	c.Assert(lxcConf, jc.Contains, "lxc.network.type = veth")
+	c.Assert(lxcConf, jc.Contains, "lxc.network.link = lxcbr0")
+}
+
+func (s *lxcBrokerSuite) TestStartInstanceUnsupportedNetworkType(c *gc.C) {
+	s.api.networkType = container.PhysicalNetwork
+	_, err := s.tryStartInstance("1/lxc/0")
+	c.Assert(err, gc.ErrorMatches, `unsupported container network type "physical"`)
+	s.assertInstances(c)
+}
+
 func (s *lxcBrokerSuite) TestStopInstance(c *gc.C) {
 	lxc0 := s.startInstance(c, "1/lxc/0")
 	lxc1 := s.startInstance(c, "1/lxc/1")
@@ -300,11 +430,14 @@ func (s *lxcProvisionerSuite) TestContainerStartedAndStopped(c *gc.C) {
 	s.waitRemoved(c, container)
 }
 
-type fakeAPI struct{}
+type fakeAPI struct {
+	networkType string
+}
 
-func (*fakeAPI) ContainerConfig() (params.ContainerConfig, error) {
+func (api *fakeAPI) ContainerConfig() (params.ContainerConfig, error) {
 	return params.ContainerConfig{
 		ProviderType:            "fake",
 		AuthorizedKeys:          coretesting.FakeAuthKeys,
-		SSLHostnameVerification: true}, nil
+		SSLHostnameVerification: true,
+		ContainerNetworkType:    api.networkType}, nil
 }
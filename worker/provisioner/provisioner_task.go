@@ -448,6 +448,10 @@ func (task *provisionerTask) prepareNetworkAndInterfaces(networkInfo []network.I
 			NetworkTag:    networkTag,
 			IsVirtual:     info.IsVirtual(),
 			Disabled:      info.Disabled,
+
+			InterfaceType:       info.InterfaceType,
+			ParentInterfaceName: info.ParentInterfaceName,
+			BondMode:            info.BondMode,
 		})
 	}
 	return networks, ifaces
@@ -458,6 +462,16 @@ func (task *provisionerTask) startMachine(machine *apiprovisioner.Machine) error
 	if err != nil {
 		return err
 	}
+	if provisioningInfo.Maintenance {
+		// The error is marked as transient, so that provisioning
+		// is retried once the machine is out of maintenance.
+		logger.Infof("not starting machine %q: machine is in maintenance", machine)
+		err := machine.SetStatus(params.StatusError, "machine is in maintenance", params.StatusData{"transient": true})
+		if err != nil {
+			return errors.Annotatef(err, "cannot set status of machine %q", machine)
+		}
+		return nil
+	}
 	possibleTools, err := task.possibleTools(provisioningInfo.Series, provisioningInfo.Constraints)
 	if err != nil {
 		return task.setErrorStatus("cannot find tools for machine %q: %v", machine, err)
@@ -508,6 +522,7 @@ type provisioningInfo struct {
 	Constraints   constraints.Value
 	Series        string
 	Placement     string
+	Maintenance   bool
 	MachineConfig *cloudinit.MachineConfig
 }
 
@@ -550,6 +565,7 @@ func (task *provisionerTask) provisioningInfo(machine *apiprovisioner.Machine) (
 		Constraints:   pInfo.Constraints,
 		Series:        pInfo.Series,
 		Placement:     pInfo.Placement,
+		Maintenance:   pInfo.Maintenance,
 		MachineConfig: machineConfig,
 	}, nil
 }
@@ -1067,6 +1067,37 @@ func (s *ProvisionerSuite) TestProvisionerRetriesTransientErrors(c *gc.C) {
 	c.Assert(err, jc.Satisfies, state.IsNotProvisionedError)
 }
 
+func (s *ProvisionerSuite) TestProvisionerSkipsMachinesInMaintenance(c *gc.C) {
+	s.PatchValue(&apiserverprovisioner.ErrorRetryWaitDelay, 5*time.Millisecond)
+	m, err := s.addMachine()
+	c.Assert(err, gc.IsNil)
+	err = m.SetMaintenance()
+	c.Assert(err, gc.IsNil)
+	p := s.newEnvironProvisioner(c)
+	defer stop(c, p)
+
+	// No instance is started while the machine is in maintenance...
+	s.checkNoOperations(c)
+	t0 := time.Now()
+	for time.Since(t0) < coretesting.LongWait {
+		status, info, data, err := m.Status()
+		c.Assert(err, gc.IsNil)
+		if status == params.StatusPending {
+			time.Sleep(coretesting.ShortWait)
+			continue
+		}
+		c.Assert(status, gc.Equals, params.StatusError)
+		c.Assert(info, gc.Equals, "machine is in maintenance")
+		c.Assert(data, gc.DeepEquals, params.StatusData{"transient": true})
+		break
+	}
+
+	// ...but one is once the machine is out of maintenance.
+	err = m.ClearMaintenance()
+	c.Assert(err, gc.IsNil)
+	s.checkStartInstance(c, m)
+}
+
 func (s *ProvisionerSuite) TestProvisionerObservesMachineJobs(c *gc.C) {
 	s.PatchValue(&apiserverprovisioner.ErrorRetryWaitDelay, 5*time.Millisecond)
 	broker := &mockBroker{Environ: s.Environ, retryCount: make(map[string]int)}
@@ -0,0 +1,159 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package singular
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"launchpad.net/tomb"
+
+	"github.com/juju/juju/worker"
+)
+
+// LeaseClaimer claims named leases on behalf of their holders.
+// It is implemented by *state.State.
+type LeaseClaimer interface {
+	// ClaimLease claims or renews the named lease for the given
+	// holder, and reports whether the holder now holds it.
+	ClaimLease(name, holder string, duration time.Duration) (bool, error)
+}
+
+var (
+	// LeaseDuration holds how long the leases claimed
+	// by lease runners last before they must be renewed.
+	LeaseDuration = 30 * time.Second
+
+	// LeaseRenewInterval holds how often lease runners renew the
+	// leases they hold and try to claim those they do not.
+	LeaseRenewInterval = 10 * time.Second
+)
+
+type leaseRunner struct {
+	worker.Runner
+	claimer LeaseClaimer
+	holder  string
+
+	mu   sync.Mutex
+	held int
+}
+
+// NewLeaseRunner returns a Runner that can be used to start workers
+// that must run exactly once across several machines. Unlike the
+// runners returned by New, which run all their workers on a single
+// master, a lease runner guards each worker with its own lease, named
+// after the worker's id, so that the workers can be spread across the
+// machines sharing the claimer.
+//
+// A worker started on the runner runs on the underlying runner only
+// while the given holder holds its lease; the lease is renewed while
+// the worker runs, and the worker is stopped with an error if the
+// lease is lost. To spread the load, a runner that already holds
+// leases defers claiming another by one renewal interval for each
+// lease it holds, giving less busy machines the chance to claim it
+// first.
+func NewLeaseRunner(underlying worker.Runner, claimer LeaseClaimer, holder string) worker.Runner {
+	return &leaseRunner{
+		Runner:  underlying,
+		claimer: claimer,
+		holder:  holder,
+	}
+}
+
+func (r *leaseRunner) StartWorker(id string, startFunc func() (worker.Worker, error)) error {
+	return r.Runner.StartWorker(id, func() (worker.Worker, error) {
+		w := &leaseWorker{
+			runner: r,
+			name:   id,
+			start:  startFunc,
+		}
+		go func() {
+			defer w.tomb.Done()
+			w.tomb.Kill(w.loop())
+		}()
+		return w, nil
+	})
+}
+
+// load returns the number of leases currently held by the runner.
+func (r *leaseRunner) load() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.held
+}
+
+// addLoad adjusts the number of leases held by the runner.
+func (r *leaseRunner) addLoad(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.held += n
+}
+
+// leaseWorker runs a worker while its runner holds the
+// lease named after the worker.
+type leaseWorker struct {
+	tomb   tomb.Tomb
+	runner *leaseRunner
+	name   string
+	start  func() (worker.Worker, error)
+}
+
+func (w *leaseWorker) Kill() {
+	w.tomb.Kill(nil)
+}
+
+func (w *leaseWorker) Wait() error {
+	return w.tomb.Wait()
+}
+
+func (w *leaseWorker) loop() error {
+	var inner worker.Worker
+	innerDone := make(chan error, 1)
+	defer func() {
+		if inner != nil {
+			inner.Kill()
+			<-innerDone
+			w.runner.addLoad(-1)
+		}
+	}()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	deferred := 0
+	for {
+		select {
+		case <-w.tomb.Dying():
+			return tomb.ErrDying
+		case err := <-innerDone:
+			inner = nil
+			w.runner.addLoad(-1)
+			return err
+		case <-timer.C:
+			timer.Reset(LeaseRenewInterval)
+			if inner == nil && deferred < w.runner.load() {
+				deferred++
+				continue
+			}
+			deferred = 0
+			held, err := w.runner.claimer.ClaimLease(w.name, w.runner.holder, LeaseDuration)
+			if err != nil {
+				return err
+			}
+			switch {
+			case inner != nil && !held:
+				logger.Infof("lease %q lost; stopping %q", w.name, w.name)
+				return fmt.Errorf("lease %q lost", w.name)
+			case inner == nil && held:
+				logger.Infof("lease %q claimed; starting %q", w.name, w.name)
+				if inner, err = w.start(); err != nil {
+					return err
+				}
+				w.runner.addLoad(1)
+				go func(inner worker.Worker) {
+					innerDone <- inner.Wait()
+				}(inner)
+			}
+		}
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package singular_test
+
+import (
+	"sync"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/testing"
+	"github.com/juju/juju/worker"
+	"github.com/juju/juju/worker/singular"
+)
+
+type leaseSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&leaseSuite{})
+
+func (s *leaseSuite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	s.PatchValue(&singular.LeaseRenewInterval, 50*time.Millisecond)
+}
+
+func (s *leaseSuite) TestStartsWorkerWhenLeaseHeld(c *gc.C) {
+	claimer := newFakeClaimer()
+	claimer.setHolder("worker", "machine-1")
+	underlying := newRunner()
+	defer worker.Stop(underlying)
+	r := singular.NewLeaseRunner(underlying, claimer, "machine-0")
+
+	started := make(chan string, 1)
+	err := r.StartWorker("worker", startRecorder(started, "worker"))
+	c.Assert(err, gc.IsNil)
+	select {
+	case <-started:
+		c.Fatalf("worker started while lease held elsewhere")
+	case <-time.After(testing.ShortWait):
+	}
+
+	// Once the lease is released, the worker starts.
+	claimer.setHolder("worker", "")
+	assertStarted(c, started, "worker")
+	c.Assert(claimer.holder("worker"), gc.Equals, "machine-0")
+}
+
+func (s *leaseSuite) TestLeaseLostStopsWorker(c *gc.C) {
+	claimer := newFakeClaimer()
+	underlying := newRunner()
+	defer worker.Stop(underlying)
+	r := singular.NewLeaseRunner(underlying, claimer, "machine-0")
+
+	started := make(chan string, 1)
+	stopped := make(chan struct{}, 1)
+	err := r.StartWorker("worker", func() (worker.Worker, error) {
+		return worker.NewSimpleWorker(func(stop <-chan struct{}) error {
+			started <- "worker"
+			<-stop
+			stopped <- struct{}{}
+			return nil
+		}), nil
+	})
+	c.Assert(err, gc.IsNil)
+	assertStarted(c, started, "worker")
+
+	claimer.setHolder("worker", "machine-1")
+	select {
+	case <-stopped:
+	case <-time.After(testing.LongWait):
+		c.Fatalf("timed out waiting for worker to stop")
+	}
+}
+
+func (s *leaseSuite) TestWorkersSpreadAcrossHolders(c *gc.C) {
+	claimer := newFakeClaimer()
+	underlying0 := newRunner()
+	defer worker.Stop(underlying0)
+	underlying1 := newRunner()
+	defer worker.Stop(underlying1)
+	r0 := singular.NewLeaseRunner(underlying0, claimer, "machine-0")
+	r1 := singular.NewLeaseRunner(underlying1, claimer, "machine-1")
+
+	started := make(chan string, 4)
+	err := r0.StartWorker("first", startRecorder(started, "first"))
+	c.Assert(err, gc.IsNil)
+	assertStarted(c, started, "first")
+
+	// machine-0 already holds a lease, so it defers claiming
+	// the second one, which goes to machine-1.
+	for _, r := range []worker.Runner{r0, r1} {
+		err := r.StartWorker("second", startRecorder(started, "second"))
+		c.Assert(err, gc.IsNil)
+	}
+	assertStarted(c, started, "second")
+	c.Assert(claimer.holder("first"), gc.Equals, "machine-0")
+	c.Assert(claimer.holder("second"), gc.Equals, "machine-1")
+
+	// The second worker runs only once.
+	select {
+	case name := <-started:
+		c.Fatalf("worker %q started twice", name)
+	case <-time.After(testing.ShortWait):
+	}
+}
+
+func startRecorder(started chan<- string, name string) func() (worker.Worker, error) {
+	return func() (worker.Worker, error) {
+		return worker.NewSimpleWorker(func(stop <-chan struct{}) error {
+			started <- name
+			<-stop
+			return nil
+		}), nil
+	}
+}
+
+func assertStarted(c *gc.C, started <-chan string, name string) {
+	select {
+	case got := <-started:
+		c.Assert(got, gc.Equals, name)
+	case <-time.After(testing.LongWait):
+		c.Fatalf("timed out waiting for %q to start", name)
+	}
+}
+
+// fakeClaimer implements singular.LeaseClaimer with leases
+// that never expire.
+type fakeClaimer struct {
+	mu      sync.Mutex
+	holders map[string]string
+}
+
+func newFakeClaimer() *fakeClaimer {
+	return &fakeClaimer{holders: make(map[string]string)}
+}
+
+func (f *fakeClaimer) ClaimLease(name, holder string, duration time.Duration) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if current := f.holders[name]; current != "" && current != holder {
+		return false, nil
+	}
+	f.holders[name] = holder
+	return true, nil
+}
+
+func (f *fakeClaimer) setHolder(name, holder string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.holders[name] = holder
+}
+
+func (f *fakeClaimer) holder(name string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.holders[name]
+}
@@ -0,0 +1,80 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package statepruner implements a worker that periodically removes
+// completed transactions and old status history from state, so that
+// the database of a long-lived environment does not grow without
+// bound. The transaction log is a capped collection, and so needs no
+// pruning.
+package statepruner
+
+import (
+	"time"
+
+	"github.com/juju/loggo"
+
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.statepruner")
+
+// Thresholds holds the limits beyond which data is pruned.
+type Thresholds struct {
+	// TxnRetention is how long completed transactions are kept.
+	TxnRetention time.Duration
+
+	// HistoryRetention is how long status history entries are kept.
+	HistoryRetention time.Duration
+
+	// MaxHistoryEntries is the number of status history
+	// entries kept for each machine and unit.
+	MaxHistoryEntries int
+}
+
+// DefaultThresholds holds the thresholds used by the machine agent.
+var DefaultThresholds = Thresholds{
+	TxnRetention:      24 * time.Hour,
+	HistoryRetention:  30 * 24 * time.Hour,
+	MaxHistoryEntries: state.MaxStatusHistory,
+}
+
+// DefaultInterval is how often the machine agent prunes state.
+const DefaultInterval = time.Hour
+
+// StatePruner defines the interface for types capable of removing
+// old transactions and status history.
+type StatePruner interface {
+	// PruneTransactions removes the completed transactions
+	// started before the given time that are no longer
+	// referenced by any document.
+	PruneTransactions(before time.Time) error
+
+	// PruneStatusHistory removes the status history entries recorded
+	// before the given time, and those of each machine and unit
+	// beyond the newest maxEntries.
+	PruneStatusHistory(before time.Time, maxEntries int) error
+}
+
+// NewPruner returns a worker that prunes state when it starts and
+// again after each interval, keeping the data within the given
+// thresholds.
+func NewPruner(p StatePruner, thresholds Thresholds, interval time.Duration) worker.Worker {
+	return worker.NewPeriodicWorker(func(<-chan struct{}) error {
+		prune(p, thresholds)
+		return nil
+	}, interval)
+}
+
+// prune prunes state, logging rather than returning failures
+// so that pruning is attempted again after the interval.
+func prune(p StatePruner, thresholds Thresholds) {
+	now := time.Now()
+	if err := p.PruneTransactions(now.Add(-thresholds.TxnRetention)); err != nil {
+		logger.Errorf("cannot prune transactions: %v", err)
+	}
+	before := now.Add(-thresholds.HistoryRetention)
+	if err := p.PruneStatusHistory(before, thresholds.MaxHistoryEntries); err != nil {
+		logger.Errorf("cannot prune status history: %v", err)
+	}
+}
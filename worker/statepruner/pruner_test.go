@@ -0,0 +1,86 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package statepruner_test
+
+import (
+	"errors"
+	"sync"
+	stdtesting "testing"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/worker"
+	"github.com/juju/juju/worker/statepruner"
+)
+
+func TestPackage(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+type PrunerSuite struct {
+	coretesting.BaseSuite
+}
+
+var _ = gc.Suite(&PrunerSuite{})
+
+var _ statepruner.StatePruner = (*state.State)(nil)
+
+func (s *PrunerSuite) TestPrunesPeriodically(c *gc.C) {
+	testInterval := 10 * time.Millisecond
+	p := &pruneRecorder{err: errors.New("boom")}
+	thresholds := statepruner.Thresholds{
+		TxnRetention:      time.Hour,
+		HistoryRetention:  2 * time.Hour,
+		MaxHistoryEntries: 42,
+	}
+	start := time.Now()
+	sp := statepruner.NewPruner(p, thresholds, testInterval)
+	time.Sleep(10 * testInterval)
+	c.Assert(worker.Stop(sp), gc.IsNil)
+	end := time.Now()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// State is pruned straight away, and again after
+	// each interval, even if pruning fails.
+	c.Assert(len(p.txnBefores) > 1, gc.Equals, true)
+	c.Assert(p.historyBefores, gc.HasLen, len(p.txnBefores))
+	for i := range p.txnBefores {
+		assertBetween(c, p.txnBefores[i], start.Add(-time.Hour), end.Add(-time.Hour))
+		assertBetween(c, p.historyBefores[i], start.Add(-2*time.Hour), end.Add(-2*time.Hour))
+		c.Assert(p.maxEntries[i], gc.Equals, 42)
+	}
+}
+
+func assertBetween(c *gc.C, t, start, end time.Time) {
+	c.Assert(t.Before(start), gc.Equals, false)
+	c.Assert(t.After(end), gc.Equals, false)
+}
+
+// pruneRecorder records the arguments it is asked to prune with.
+type pruneRecorder struct {
+	mu             sync.Mutex
+	txnBefores     []time.Time
+	historyBefores []time.Time
+	maxEntries     []int
+	err            error
+}
+
+func (p *pruneRecorder) PruneTransactions(before time.Time) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.txnBefores = append(p.txnBefores, before)
+	return p.err
+}
+
+func (p *pruneRecorder) PruneStatusHistory(before time.Time, maxEntries int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.historyBefores = append(p.historyBefores, before)
+	p.maxEntries = append(p.maxEntries, maxEntries)
+	return p.err
+}
@@ -20,6 +20,7 @@ import (
 	utilexec "github.com/juju/utils/exec"
 	"github.com/juju/utils/proxy"
 
+	"github.com/juju/juju/interfaceschema"
 	"github.com/juju/juju/state/api/params"
 	"github.com/juju/juju/state/api/uniter"
 	"github.com/juju/juju/version"
@@ -98,6 +99,9 @@ type HookContext struct {
 
 	// proxySettings are the current proxy settings that the uniter knows about
 	proxySettings proxy.Settings
+
+	// hookEnv holds the environment-wide variables exported to hooks.
+	hookEnv map[string]string
 }
 
 func NewHookContext(
@@ -111,6 +115,7 @@ func NewHookContext(
 	apiAddrs []string,
 	serviceOwner string,
 	proxySettings proxy.Settings,
+	hookEnv map[string]string,
 	actionParams map[string]interface{},
 ) (*HookContext, error) {
 	ctx := &HookContext{
@@ -124,6 +129,7 @@ func NewHookContext(
 		apiAddrs:       apiAddrs,
 		serviceOwner:   serviceOwner,
 		proxySettings:  proxySettings,
+		hookEnv:        hookEnv,
 		actionParams:   actionParams,
 	}
 	// Get and cache the addresses.
@@ -286,11 +292,37 @@ func (ctx *HookContext) hookVars(charmDir, toolsDir, socketPath string) []string
 		vars = append(vars, "JUJU_REMOTE_UNIT="+name)
 	}
 	vars = append(vars, ctx.proxySettings.AsEnvironmentValues()...)
+	names := make([]string, 0, len(ctx.hookEnv))
+	for name := range ctx.hookEnv {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		vars = append(vars, name+"="+ctx.hookEnv[name])
+	}
 	return vars
 }
 
+// updateRelationAddresses sets the given private address in the unit's
+// settings for every relation in the context. Like any other settings
+// change made during the hook, it is only written if the hook succeeds.
+func (ctx *HookContext) updateRelationAddresses(address string) error {
+	for id, rctx := range ctx.relations {
+		settings, err := rctx.Settings()
+		if err != nil {
+			return fmt.Errorf("cannot read settings for relation %d: %v", id, err)
+		}
+		if settings.Map()["private-address"] != address {
+			settings.Set("private-address", address)
+		}
+	}
+	return nil
+}
+
 func (ctx *HookContext) finalizeContext(process string, err error) error {
-	writeChanges := err == nil
+	// A missing hook cannot have made changes of its own, but the
+	// uniter may have staged some before running it.
+	writeChanges := err == nil || IsMissingHookError(err)
 	for id, rctx := range ctx.relations {
 		if writeChanges {
 			if e := rctx.WriteSettings(); e != nil {
@@ -509,6 +541,10 @@ type ContextRelation struct {
 	// for units that are not currently participating in the relation. Its
 	// contents should be cleared whenever a new hook is executed.
 	cache SettingsMap
+
+	// charmSchemas holds the interface schemas declared by the unit's
+	// charm, keyed by interface name.
+	charmSchemas map[string]*interfaceschema.Schema
 }
 
 // NewContextRelation creates a new context for the given relation unit.
@@ -584,6 +620,25 @@ func (ctx *ContextRelation) Settings() (jujuc.Settings, error) {
 	return ctx.settings, nil
 }
 
+// setCharmSchemas sets the interface schemas declared by the unit's
+// charm, against which changes to the unit's settings are validated.
+func (ctx *ContextRelation) setCharmSchemas(schemas map[string]*interfaceschema.Schema) {
+	ctx.charmSchemas = schemas
+}
+
+// ValidateSettings returns an error if the given changes to the unit's
+// settings do not conform to the schema declared by the unit's charm
+// for the relation's interface, or to the schema registered for it in
+// the environment.
+func (ctx *ContextRelation) ValidateSettings(settings params.RelationSettings) error {
+	if schema, ok := ctx.charmSchemas[ctx.ru.Endpoint().Interface]; ok {
+		if err := schema.ValidateSettings(settings); err != nil {
+			return err
+		}
+	}
+	return ctx.ru.ValidateSettings(settings)
+}
+
 func (ctx *ContextRelation) ReadSettings(unit string) (settings params.RelationSettings, err error) {
 	settings, member := ctx.members[unit]
 	if settings == nil {
@@ -19,6 +19,7 @@ import (
 	"github.com/juju/utils/proxy"
 	gc "launchpad.net/gocheck"
 
+	"github.com/juju/juju/interfaceschema"
 	"github.com/juju/juju/juju/testing"
 	"github.com/juju/juju/network"
 	"github.com/juju/juju/state"
@@ -158,6 +159,7 @@ var runHookTests = []struct {
 	err           string
 	env           map[string]string
 	proxySettings proxy.Settings
+	hookEnv       map[string]string
 }{
 	{
 		summary: "missing hook is not an error",
@@ -217,6 +219,16 @@ var runHookTests = []struct {
 			"no_proxy":           "no proxy",
 			"NO_PROXY":           "no proxy",
 		},
+	}, {
+		summary: "check shell environment with environment-wide variables",
+		relid:   -1,
+		spec:    hookSpec{perm: 0700},
+		hookEnv: map[string]string{"TENANT_ID": "tenant-1", "region": "eu"},
+		env: map[string]string{
+			"JUJU_UNIT_NAME": "u/0",
+			"TENANT_ID":      "tenant-1",
+			"region":         "eu",
+		},
 	}, {
 		summary: "check shell environment for relation-broken hook context",
 		relid:   1,
@@ -250,7 +262,7 @@ func (s *RunHookSuite) TestRunHook(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	for i, t := range runHookTests {
 		c.Logf("\ntest %d: %s; perm %v", i, t.summary, t.spec.perm)
-		ctx := s.getHookContext(c, uuid.String(), t.relid, t.remote, t.proxySettings)
+		ctx := s.getHookContextWithEnv(c, uuid.String(), t.relid, t.remote, t.proxySettings, t.hookEnv)
 		var charmDir, outPath string
 		var hookExists bool
 		if t.spec.perm == 0 {
@@ -376,6 +388,27 @@ func (s *RunHookSuite) TestRunHookRelationFlushing(c *gc.C) {
 	})
 }
 
+func (s *RunHookSuite) TestRunHookUpdateRelationAddresses(c *gc.C) {
+	uuid, err := utils.NewUUID()
+	c.Assert(err, gc.IsNil)
+	ctx := s.getHookContext(c, uuid.String(), -1, "", noProxies)
+	err = ctx.UpdateRelationAddresses("10.0.0.1")
+	c.Assert(err, gc.IsNil)
+
+	// The address is published even if the charm has no hook.
+	err = ctx.RunHook("config-changed", c.MkDir(), c.MkDir(), "/path/to/socket")
+	c.Assert(uniter.IsMissingHookError(err), jc.IsTrue)
+
+	for i, ru := range s.relunits {
+		settings, err := ru.ReadSettings("u/0")
+		c.Assert(err, gc.IsNil)
+		c.Assert(settings, gc.DeepEquals, map[string]interface{}{
+			"relation-name":   fmt.Sprintf("db%d", i),
+			"private-address": "10.0.0.1",
+		})
+	}
+}
+
 type ContextRelationSuite struct {
 	testing.JujuConnSuite
 	svc *state.Service
@@ -582,6 +615,33 @@ func (s *ContextRelationSuite) TestSettings(c *gc.C) {
 	c.Assert(settings, gc.DeepEquals, expectMap)
 }
 
+func (s *ContextRelationSuite) TestValidateSettings(c *gc.C) {
+	ctx := uniter.NewContextRelation(s.apiRelUnit, nil)
+	err := ctx.ValidateSettings(params.RelationSettings{"port": "ring"})
+	c.Assert(err, gc.IsNil)
+
+	// Schemas declared by the charm are checked by the uniter.
+	ctx.SetCharmSchemas(map[string]*interfaceschema.Schema{
+		"riak": {
+			Interface: "riak",
+			Fields:    map[string]interfaceschema.FieldType{"port": interfaceschema.Int},
+		},
+	})
+	err = ctx.ValidateSettings(params.RelationSettings{"port": "8098"})
+	c.Assert(err, gc.IsNil)
+	err = ctx.ValidateSettings(params.RelationSettings{"port": "ring"})
+	c.Assert(err, gc.ErrorMatches, `setting "port" of interface "riak" must be of type int, got "ring"`)
+
+	// Schemas registered in the environment are checked by the server.
+	err = s.State.SetInterfaceSchema(interfaceschema.Schema{
+		Interface: "riak",
+		Fields:    map[string]interfaceschema.FieldType{"ring": interfaceschema.Bool},
+	})
+	c.Assert(err, gc.IsNil)
+	err = ctx.ValidateSettings(params.RelationSettings{"port": "8098", "ring": "round"})
+	c.Assert(err, gc.ErrorMatches, `setting "ring" of interface "riak" must be of type bool, got "round"`)
+}
+
 type InterfaceSuite struct {
 	HookContextSuite
 }
@@ -745,13 +805,18 @@ func (s *HookContextSuite) AddContextRelation(c *gc.C, name string) {
 
 func (s *HookContextSuite) getHookContext(c *gc.C, uuid string, relid int,
 	remote string, proxies proxy.Settings) *uniter.HookContext {
+	return s.getHookContextWithEnv(c, uuid, relid, remote, proxies, nil)
+}
+
+func (s *HookContextSuite) getHookContextWithEnv(c *gc.C, uuid string, relid int,
+	remote string, proxies proxy.Settings, hookEnv map[string]string) *uniter.HookContext {
 	if relid != -1 {
 		_, found := s.relctxs[relid]
 		c.Assert(found, jc.IsTrue)
 	}
 	context, err := uniter.NewHookContext(s.apiUnit, "TestCtx", uuid,
 		"test-env-name", relid, remote, s.relctxs, apiAddrs, "test-owner",
-		proxies, map[string]interface{}(nil))
+		proxies, hookEnv, map[string]interface{}(nil))
 	c.Assert(err, gc.IsNil)
 	return context
 }
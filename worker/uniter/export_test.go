@@ -5,6 +5,8 @@ package uniter
 
 import (
 	"github.com/juju/utils/proxy"
+
+	"github.com/juju/juju/interfaceschema"
 )
 
 func SetUniterObserver(u *Uniter, observer UniterExecutionObserver) {
@@ -18,3 +20,11 @@ func (u *Uniter) GetProxyValues() proxy.Settings {
 }
 
 var MergeEnvironment = mergeEnvironment
+
+func (ctx *ContextRelation) SetCharmSchemas(schemas map[string]*interfaceschema.Schema) {
+	ctx.setCharmSchemas(schemas)
+}
+
+func (ctx *HookContext) UpdateRelationAddresses(address string) error {
+	return ctx.updateRelationAddresses(address)
+}
@@ -4,6 +4,7 @@
 package uniter
 
 import (
+	"reflect"
 	"sort"
 
 	"github.com/juju/charm"
@@ -92,6 +93,7 @@ type filter struct {
 	relations        []int
 	actionsPending   []string
 	nextAction       *hook.Info
+	hookEnv          map[string]string
 }
 
 // newFilter returns a filter that handles state changes pertaining to the
@@ -336,6 +338,14 @@ func (f *filter) loop(unitTag string) (err error) {
 		return err
 	}
 	defer watcher.Stop(addressesw, &f.tomb)
+	environw, err := f.st.WatchForEnvironConfigChanges()
+	if err != nil {
+		return err
+	}
+	defer watcher.Stop(environw, &f.tomb)
+	if _, err = f.hookEnvChanged(); err != nil {
+		return err
+	}
 
 	// Config events cannot be meaningfully discarded until one is available;
 	// once we receive the initial change, we unblock discard requests by
@@ -389,6 +399,22 @@ func (f *filter) loop(unitTag string) (err error) {
 			// address change causes config-changed event
 			filterLogger.Debugf("preparing new config event")
 			f.outConfig = f.outConfigOn
+		case _, ok = <-environw.Changes():
+			filterLogger.Debugf("got environment config change")
+			if !ok {
+				return watcher.MustErr(environw)
+			}
+			var changed bool
+			if changed, err = f.hookEnvChanged(); err != nil {
+				return err
+			}
+			// Like address changes, a change to the hook environment
+			// causes a config-changed event, once the first one has
+			// been processed.
+			if changed && addressChanges != nil {
+				filterLogger.Debugf("preparing new config event")
+				f.outConfig = f.outConfigOn
+			}
 		case ids, ok := <-actionsw.Changes():
 			filterLogger.Debugf("got %d actions", len(ids))
 			if !ok {
@@ -556,6 +582,22 @@ func (f *filter) serviceChanged() error {
 	return f.upgradeChanged()
 }
 
+// hookEnvChanged reads the hook environment variables from the
+// environment configuration, and reports whether they differ from
+// those read previously.
+func (f *filter) hookEnvChanged() (bool, error) {
+	cfg, err := f.st.EnvironConfig()
+	if err != nil {
+		return false, err
+	}
+	hookEnv := cfg.HookEnvironment()
+	if f.hookEnv != nil && reflect.DeepEqual(hookEnv, f.hookEnv) {
+		return false, nil
+	}
+	f.hookEnv = hookEnv
+	return true, nil
+}
+
 // upgradeChanged responds to changes in the service or in the
 // upgrade requests that defines which charm changes should be
 // delivered as upgrades.
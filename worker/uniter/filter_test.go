@@ -367,6 +367,51 @@ func (s *FilterSuite) TestConfigEvents(c *gc.C) {
 	assertChange()
 }
 
+func (s *FilterSuite) TestHookEnvironmentEvents(c *gc.C) {
+	f, err := newFilter(s.uniter, s.unit.Tag().String())
+	c.Assert(err, gc.IsNil)
+	defer statetesting.AssertStop(c, f)
+
+	assertNoChange := func() {
+		s.BackingState.StartSync()
+		select {
+		case <-f.ConfigEvents():
+			c.Fatalf("unexpected config event")
+		case <-time.After(coretesting.ShortWait):
+		}
+	}
+	assertChange := func() {
+		s.BackingState.StartSync()
+		select {
+		case _, ok := <-f.ConfigEvents():
+			c.Assert(ok, gc.Equals, true)
+		case <-time.After(coretesting.LongWait):
+			c.Fatalf("timed out")
+		}
+		assertNoChange()
+	}
+
+	// Set the charm URL and consume the initial config event.
+	err = f.SetCharm(s.wpcharm.URL())
+	c.Assert(err, gc.IsNil)
+	assertChange()
+
+	// Adding a hook environment variable causes a config event.
+	err = s.State.UpdateEnvironConfig(map[string]interface{}{"hook-env-FOO": "bar"}, nil, nil)
+	c.Assert(err, gc.IsNil)
+	assertChange()
+
+	// Other environment config changes do not.
+	err = s.State.UpdateEnvironConfig(map[string]interface{}{"logging-config": "<root>=DEBUG"}, nil, nil)
+	c.Assert(err, gc.IsNil)
+	assertNoChange()
+
+	// Removing a hook environment variable causes a config event.
+	err = s.State.UpdateEnvironConfig(nil, []string{"hook-env-FOO"}, nil)
+	c.Assert(err, gc.IsNil)
+	assertChange()
+}
+
 func (s *FilterSuite) TestInitialAddressEventIgnored(c *gc.C) {
 	f, err := newFilter(s.uniter, s.unit.Tag().String())
 	c.Assert(err, gc.IsNil)
@@ -85,6 +85,11 @@ type ContextRelation interface {
 
 	// ReadSettings returns the settings of any remote unit in the relation.
 	ReadSettings(unit string) (params.RelationSettings, error)
+
+	// ValidateSettings returns an error if the given changes to the
+	// local unit's settings do not conform to the schema of the
+	// relation's interface.
+	ValidateSettings(settings params.RelationSettings) error
 }
 
 // Settings is implemented by types that manipulate unit settings.
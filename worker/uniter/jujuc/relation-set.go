@@ -59,6 +59,9 @@ func (c *RelationSetCommand) Run(ctx *cmd.Context) (err error) {
 	if !found {
 		return fmt.Errorf("unknown relation id")
 	}
+	if err := r.ValidateSettings(c.Settings); err != nil {
+		return err
+	}
 	settings, err := r.Settings()
 	for k, v := range c.Settings {
 		if v != "" {
@@ -213,6 +213,21 @@ func (s *RelationSetSuite) TestRun(c *gc.C) {
 	}
 }
 
+func (s *RelationSetSuite) TestRunInvalidSettings(c *gc.C) {
+	hctx := s.GetHookContext(c, 1, "")
+	basic := Settings{"base": "value"}
+	hctx.rels[1].units["u/0"] = basic
+	hctx.rels[1].settingsErr = fmt.Errorf(`setting "port" of interface "mysql" must be of type int, got "mysql"`)
+
+	com, err := jujuc.NewCommand(hctx, "relation-set")
+	c.Assert(err, gc.IsNil)
+	_, err = testing.RunCommand(c, com, "port=mysql")
+	c.Assert(err, gc.ErrorMatches, `setting "port" of interface "mysql" must be of type int, got "mysql"`)
+
+	// The settings are left unchanged.
+	c.Assert(hctx.rels[1].units["u/0"], gc.DeepEquals, Settings{"base": "value"})
+}
+
 func (s *RelationSetSuite) TestRunDeprecationWarning(c *gc.C) {
 	hctx := s.GetHookContext(c, 0, "")
 	com, _ := jujuc.NewCommand(hctx, "relation-set")
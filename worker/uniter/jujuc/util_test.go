@@ -151,6 +151,9 @@ type ContextRelation struct {
 	id    int
 	name  string
 	units map[string]Settings
+
+	// settingsErr is returned by ValidateSettings.
+	settingsErr error
 }
 
 func (r *ContextRelation) Id() int {
@@ -186,6 +189,10 @@ func (r *ContextRelation) ReadSettings(name string) (params.RelationSettings, er
 	return s.Map(), nil
 }
 
+func (r *ContextRelation) ValidateSettings(settings params.RelationSettings) error {
+	return r.settingsErr
+}
+
 type Settings params.RelationSettings
 
 func (s Settings) Get(k string) (interface{}, bool) {
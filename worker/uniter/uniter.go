@@ -26,6 +26,7 @@ import (
 
 	"github.com/juju/juju/agent/tools"
 	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/interfaceschema"
 	"github.com/juju/juju/state/api/params"
 	"github.com/juju/juju/state/api/uniter"
 	apiwatcher "github.com/juju/juju/state/api/watcher"
@@ -361,9 +362,24 @@ func (u *Uniter) getHookContext(hctxId string, relationId int, remoteUnitName st
 	if err != nil {
 		return nil, err
 	}
+	// The hook environment is read afresh for every hook, so that
+	// the config-changed hook run when it changes sees the new values.
+	environConfig, err := u.st.EnvironConfig()
+	if err != nil {
+		return nil, err
+	}
+	// Relation settings are validated against the interface schemas
+	// declared by the charm, which are read afresh for every hook so
+	// that an upgraded charm's schemas are used.
+	charmSchemas, err := interfaceschema.ReadCharmSchemas(u.charmPath)
+	if err != nil {
+		return nil, err
+	}
 	ctxRelations := map[int]*ContextRelation{}
 	for id, r := range u.relationers {
-		ctxRelations[id] = r.Context()
+		ctxRelation := r.Context()
+		ctxRelation.setCharmSchemas(charmSchemas)
+		ctxRelations[id] = ctxRelation
 	}
 
 	u.proxyMutex.Lock()
@@ -373,7 +389,7 @@ func (u *Uniter) getHookContext(hctxId string, relationId int, remoteUnitName st
 	proxySettings := u.proxy
 	return NewHookContext(u.unit, hctxId, u.uuid, u.envName, relationId,
 		remoteUnitName, ctxRelations, apiAddrs, ownerTag, proxySettings,
-		actionParams)
+		environConfig.HookEnvironment(), actionParams)
 }
 
 func (u *Uniter) acquireHookLock(message string) (err error) {
@@ -526,6 +542,15 @@ func (u *Uniter) runHook(hi hook.Info) (err error) {
 	if err != nil {
 		return err
 	}
+	if hi.Kind == hooks.ConfigChanged {
+		// Config-changed hooks are also run when the unit's
+		// addresses change, so publish the current address
+		// to the unit's relations along with the hook's own
+		// settings changes.
+		if err = u.updateRelationAddresses(hctx); err != nil {
+			return err
+		}
+	}
 
 	srv, socketPath, err := u.startJujucServer(hctx)
 	if err != nil {
@@ -576,6 +601,18 @@ func (u *Uniter) runHook(hi hook.Info) (err error) {
 	return u.commitHook(hi)
 }
 
+// updateRelationAddresses stages the unit's current private
+// address in its settings for every relation in the hook context.
+func (u *Uniter) updateRelationAddresses(hctx *HookContext) error {
+	address, err := u.unit.PrivateAddress()
+	if params.IsCodeNoAddressSet(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return hctx.updateRelationAddresses(address)
+}
+
 // commitHook ensures that state is consistent with the supplied hook, and
 // that the fact of the hook's completion is persisted.
 func (u *Uniter) commitHook(hi hook.Info) error {
@@ -0,0 +1,30 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package utilization
+
+import (
+	"time"
+)
+
+var (
+	ReadCPU    = readCPU
+	ReadMemory = readMemory
+	ReadDisk   = readDisk
+)
+
+func PatchProcFiles(stat, meminfo string) func() {
+	oldStat, oldMeminfo := procStat, procMeminfo
+	procStat, procMeminfo = stat, meminfo
+	return func() {
+		procStat, procMeminfo = oldStat, oldMeminfo
+	}
+}
+
+func SetInterval(i time.Duration) {
+	interval = i
+}
+
+func RestoreInterval() {
+	interval = defaultInterval
+}
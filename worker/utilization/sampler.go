@@ -0,0 +1,208 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package utilization implements a worker that periodically samples
+// the resources in use on a machine and records them in state.
+package utilization
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/juju/loggo"
+	"launchpad.net/tomb"
+
+	"github.com/juju/juju/state/api/params"
+)
+
+var logger = loggo.GetLogger("juju.worker.utilization")
+
+// defaultInterval is the standard value for the interval setting.
+const defaultInterval = 5 * time.Minute
+
+// interval sets how often the machine's resources are sampled.
+var interval = defaultInterval
+
+// These are the files the samples are read from; they are
+// variables so they can be changed in tests.
+var (
+	procStat    = "/proc/stat"
+	procMeminfo = "/proc/meminfo"
+)
+
+// Recorder defines the interface for types capable of recording
+// resource utilization samples of a machine.
+type Recorder interface {
+	RecordUtilization(params.UtilizationSample) error
+}
+
+// Sampler is responsible for periodically sampling the resources
+// in use on the machine.
+type Sampler struct {
+	tomb    tomb.Tomb
+	rec     Recorder
+	diskDir string
+
+	// lastIdle and lastTotal hold the CPU counters read in the
+	// previous sample, so CPU usage can be computed as a delta.
+	lastIdle  uint64
+	lastTotal uint64
+}
+
+// NewSampler returns a worker that periodically samples CPU and memory
+// usage, and the disk usage of the filesystem holding diskDir, and
+// passes the samples to the given recorder.
+func NewSampler(rec Recorder, diskDir string) *Sampler {
+	s := &Sampler{rec: rec, diskDir: diskDir}
+	go func() {
+		defer s.tomb.Done()
+		s.tomb.Kill(s.loop())
+	}()
+	return s
+}
+
+func (s *Sampler) String() string {
+	return fmt.Sprintf("utilization sampler")
+}
+
+func (s *Sampler) Kill() {
+	s.tomb.Kill(nil)
+}
+
+func (s *Sampler) Stop() error {
+	s.tomb.Kill(nil)
+	return s.tomb.Wait()
+}
+
+func (s *Sampler) Wait() error {
+	return s.tomb.Wait()
+}
+
+func (s *Sampler) loop() error {
+	// Prime the CPU counters so the first sample has a baseline.
+	if idle, total, err := readCPU(); err == nil {
+		s.lastIdle, s.lastTotal = idle, total
+	}
+	for {
+		select {
+		case <-s.tomb.Dying():
+			return tomb.ErrDying
+		case <-time.After(interval):
+			sample, err := s.sample()
+			if err != nil {
+				logger.Warningf("cannot sample machine utilization: %v", err)
+				continue
+			}
+			if err := s.rec.RecordUtilization(sample); err != nil {
+				logger.Errorf("cannot record machine utilization: %v", err)
+			}
+		}
+	}
+}
+
+// sample reads the current resource usage of the machine.
+func (s *Sampler) sample() (params.UtilizationSample, error) {
+	sample := params.UtilizationSample{Time: time.Now()}
+	idle, total, err := readCPU()
+	if err != nil {
+		return sample, err
+	}
+	if total > s.lastTotal {
+		busy := (total - s.lastTotal) - (idle - s.lastIdle)
+		sample.CPUPercent = 100 * float64(busy) / float64(total-s.lastTotal)
+	}
+	s.lastIdle, s.lastTotal = idle, total
+
+	if sample.MemUsed, sample.MemTotal, err = readMemory(); err != nil {
+		return sample, err
+	}
+	if sample.DiskUsed, sample.DiskTotal, err = readDisk(s.diskDir); err != nil {
+		return sample, err
+	}
+	return sample, nil
+}
+
+// readCPU returns the cumulative idle and total CPU time counters,
+// as found on the first line of /proc/stat.
+func readCPU() (idle, total uint64, err error) {
+	f, err := os.Open(procStat)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	line, err := bufio.NewReader(f).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return 0, 0, err
+	}
+	fields := strings.Fields(line)
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return 0, 0, fmt.Errorf("unexpected format in %s: %q", procStat, line)
+	}
+	for i, field := range fields[1:] {
+		v, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("unexpected format in %s: %v", procStat, err)
+		}
+		total += v
+		// The fourth value is idle time, the fifth time spent
+		// waiting for I/O; both count as idle.
+		if i == 3 || i == 4 {
+			idle += v
+		}
+	}
+	return idle, total, nil
+}
+
+// readMemory returns the used and total memory in MiB, as found in
+// /proc/meminfo. Memory used for buffers and caches counts as free.
+func readMemory() (used, total uint64, err error) {
+	f, err := os.Open(procMeminfo)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	values := make(map[string]uint64)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		values[strings.TrimSuffix(fields[0], ":")] = v
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, 0, err
+	}
+	totalKB, ok := values["MemTotal"]
+	if !ok {
+		return 0, 0, fmt.Errorf("MemTotal not found in %s", procMeminfo)
+	}
+	freeKB := values["MemFree"] + values["Buffers"] + values["Cached"]
+	if freeKB > totalKB {
+		freeKB = totalKB
+	}
+	return (totalKB - freeKB) / 1024, totalKB / 1024, nil
+}
+
+// readDisk returns the used and total space in MiB of the filesystem
+// holding the given directory.
+func readDisk(dir string) (used, total uint64, err error) {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(dir, &fs); err != nil {
+		return 0, 0, err
+	}
+	blockSize := uint64(fs.Bsize)
+	total = fs.Blocks * blockSize / (1024 * 1024)
+	free := fs.Bfree * blockSize / (1024 * 1024)
+	return total - free, total, nil
+}
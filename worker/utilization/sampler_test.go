@@ -0,0 +1,100 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package utilization_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	stdtesting "testing"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state/api/params"
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/worker/utilization"
+)
+
+func TestPackage(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+type SamplerSuite struct {
+	coretesting.BaseSuite
+}
+
+var _ = gc.Suite(&SamplerSuite{})
+
+const fakeStat = `cpu  100 0 100 700 100 0 0 0 0 0
+cpu0 50 0 50 350 50 0 0 0 0 0
+`
+
+const fakeMeminfo = `MemTotal:        2097152 kB
+MemFree:          524288 kB
+Buffers:          262144 kB
+Cached:           262144 kB
+SwapTotal:             0 kB
+`
+
+func (s *SamplerSuite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	dir := c.MkDir()
+	stat := filepath.Join(dir, "stat")
+	meminfo := filepath.Join(dir, "meminfo")
+	err := ioutil.WriteFile(stat, []byte(fakeStat), 0644)
+	c.Assert(err, gc.IsNil)
+	err = ioutil.WriteFile(meminfo, []byte(fakeMeminfo), 0644)
+	c.Assert(err, gc.IsNil)
+	restore := utilization.PatchProcFiles(stat, meminfo)
+	s.AddCleanup(func(*gc.C) { restore() })
+}
+
+func (s *SamplerSuite) TestReadCPU(c *gc.C) {
+	idle, total, err := utilization.ReadCPU()
+	c.Assert(err, gc.IsNil)
+	c.Assert(idle, gc.Equals, uint64(800))
+	c.Assert(total, gc.Equals, uint64(1000))
+}
+
+func (s *SamplerSuite) TestReadMemory(c *gc.C) {
+	used, total, err := utilization.ReadMemory()
+	c.Assert(err, gc.IsNil)
+	c.Assert(used, gc.Equals, uint64(1024))
+	c.Assert(total, gc.Equals, uint64(2048))
+}
+
+func (s *SamplerSuite) TestReadDisk(c *gc.C) {
+	used, total, err := utilization.ReadDisk(c.MkDir())
+	c.Assert(err, gc.IsNil)
+	c.Assert(total > 0, gc.Equals, true)
+	c.Assert(used <= total, gc.Equals, true)
+}
+
+type recorderMock struct {
+	samples chan params.UtilizationSample
+}
+
+func (r *recorderMock) RecordUtilization(sample params.UtilizationSample) error {
+	r.samples <- sample
+	return nil
+}
+
+func (s *SamplerSuite) TestSamplerRecords(c *gc.C) {
+	utilization.SetInterval(10 * time.Millisecond)
+	defer utilization.RestoreInterval()
+
+	rec := &recorderMock{samples: make(chan params.UtilizationSample, 10)}
+	sampler := utilization.NewSampler(rec, c.MkDir())
+	defer func() { c.Assert(sampler.Stop(), gc.IsNil) }()
+
+	select {
+	case sample := <-rec.samples:
+		c.Assert(sample.MemUsed, gc.Equals, uint64(1024))
+		c.Assert(sample.MemTotal, gc.Equals, uint64(2048))
+		c.Assert(sample.DiskTotal > 0, gc.Equals, true)
+		c.Assert(sample.Time.IsZero(), gc.Equals, false)
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("timed out waiting for a utilization sample")
+	}
+}
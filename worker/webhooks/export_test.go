@@ -0,0 +1,23 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package webhooks
+
+import (
+	"time"
+
+	"github.com/juju/utils"
+)
+
+var (
+	UpgradeCheckInterval = &upgradeCheckInterval
+	HTTPClient           = &httpClient
+)
+
+// PatchPostAttempts makes events be retried quickly, and returns a
+// function that restores the original attempt strategy.
+func PatchPostAttempts() func() {
+	orig := postAttempts
+	postAttempts = utils.AttemptStrategy{Total: 100 * time.Millisecond, Delay: 10 * time.Millisecond}
+	return func() { postAttempts = orig }
+}
@@ -0,0 +1,376 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package webhooks implements a worker that posts the events of an
+// environment, such as units starting and hooks failing, to the webhook
+// URLs configured by the operator, so that external systems are
+// notified without having to poll the status of the environment.
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/loggo"
+	"github.com/juju/names"
+	"github.com/juju/utils"
+	"launchpad.net/tomb"
+
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/version"
+)
+
+var logger = loggo.GetLogger("juju.worker.webhooks")
+
+// The kinds of events posted to the webhooks.
+const (
+	UnitStarted        = "unit-started"
+	HookFailed         = "hook-failed"
+	MachineProvisioned = "machine-provisioned"
+	UpgradeComplete    = "upgrade-complete"
+)
+
+// SignatureHeader is the HTTP header holding the signature of the
+// posted event when the environment's webhook-secret is set. The
+// signature is "sha256=" followed by the hex encoded HMAC-SHA256 of
+// the request body, keyed by the secret.
+const SignatureHeader = "X-Juju-Signature"
+
+// Event holds the JSON document posted to the webhooks.
+type Event struct {
+	Kind        string    `json:"kind"`
+	Environment string    `json:"environment"`
+	Entity      string    `json:"entity,omitempty"`
+	Info        string    `json:"info,omitempty"`
+	Time        time.Time `json:"time"`
+}
+
+var (
+	// upgradeCheckInterval sets how often the machine agents are
+	// checked for the completion of an upgrade.
+	upgradeCheckInterval = 30 * time.Second
+
+	// postAttempts governs how events are retried
+	// when a webhook cannot be reached.
+	postAttempts = utils.AttemptStrategy{
+		Total: time.Minute,
+		Delay: 10 * time.Second,
+	}
+
+	// httpClient is used to post the events. Its timeout stops
+	// an unresponsive webhook holding up the delivery of events.
+	httpClient = &http.Client{Timeout: 30 * time.Second}
+)
+
+// Notifier watches the environment and posts its events to the webhooks.
+type Notifier struct {
+	tomb    tomb.Tomb
+	st      *state.State
+	envUUID string
+
+	// machines and units hold the last known instance id of each
+	// machine and status of each unit, so that the changes reported
+	// by the watcher can be compared with them.
+	machines map[string]string
+	units    map[string]params.UnitInfo
+
+	// upgraded holds the last agent version that every
+	// machine agent was known to run.
+	upgraded version.Number
+
+	// deliveries carries the events from the main loop to the
+	// goroutine that posts them, so that slow webhooks do not
+	// stop the notifier keeping up with the environment.
+	deliveries chan []Event
+}
+
+// NewNotifier returns a worker that posts the events of
+// the environment held in the given state to its webhooks.
+func NewNotifier(st *state.State) *Notifier {
+	n := &Notifier{
+		st:         st,
+		machines:   make(map[string]string),
+		units:      make(map[string]params.UnitInfo),
+		deliveries: make(chan []Event),
+	}
+	go func() {
+		defer n.tomb.Done()
+		n.tomb.Kill(n.loop())
+	}()
+	return n
+}
+
+func (n *Notifier) String() string {
+	return "webhooks notifier"
+}
+
+func (n *Notifier) Kill() {
+	n.tomb.Kill(nil)
+}
+
+func (n *Notifier) Stop() error {
+	n.tomb.Kill(nil)
+	return n.tomb.Wait()
+}
+
+func (n *Notifier) Wait() error {
+	return n.tomb.Wait()
+}
+
+func (n *Notifier) loop() error {
+	env, err := n.st.Environment()
+	if err != nil {
+		return err
+	}
+	n.envUUID = env.UUID()
+	cfg, err := n.st.EnvironConfig()
+	if err != nil {
+		return err
+	}
+	n.upgraded, _ = cfg.AgentVersion()
+
+	w := n.st.Watch()
+	defer w.Stop()
+	deltas := make(chan []params.Delta)
+	watchErr := make(chan error, 1)
+	go func() {
+		for {
+			d, err := w.Next()
+			if err != nil {
+				watchErr <- err
+				return
+			}
+			select {
+			case deltas <- d:
+			case <-n.tomb.Dying():
+				return
+			}
+		}
+	}()
+	delivered := make(chan struct{})
+	go func() {
+		defer close(delivered)
+		n.deliver()
+	}()
+	defer func() { <-delivered }()
+
+	ticker := time.NewTicker(upgradeCheckInterval)
+	defer ticker.Stop()
+	// queue holds the events waiting to be handed to the
+	// delivery goroutine.
+	var queue []Event
+	for {
+		var out chan []Event
+		if len(queue) > 0 {
+			out = n.deliveries
+		}
+		select {
+		case <-n.tomb.Dying():
+			return tomb.ErrDying
+		case err := <-watchErr:
+			return err
+		case d := <-deltas:
+			queue = append(queue, n.handleDeltas(d)...)
+		case <-ticker.C:
+			event, err := n.checkUpgrade()
+			if err != nil {
+				logger.Warningf("cannot check for a completed upgrade: %v", err)
+			} else if event != nil {
+				queue = append(queue, *event)
+			}
+		case out <- queue:
+			queue = nil
+		}
+	}
+}
+
+// deliver posts the events sent on n.deliveries, in order,
+// until the notifier is stopped.
+func (n *Notifier) deliver() {
+	for {
+		select {
+		case <-n.tomb.Dying():
+			return
+		case events := <-n.deliveries:
+			n.send(events)
+		}
+	}
+}
+
+// handleDeltas records the given changes and returns the events they
+// make. Entities seen for the first time make no events, so that the
+// initial contents of the environment are not reported.
+func (n *Notifier) handleDeltas(deltas []params.Delta) []Event {
+	var events []Event
+	for _, delta := range deltas {
+		switch info := delta.Entity.(type) {
+		case *params.MachineInfo:
+			if delta.Removed {
+				delete(n.machines, info.Id)
+				continue
+			}
+			last, known := n.machines[info.Id]
+			if known && last == "" && info.InstanceId != "" {
+				events = append(events, n.newEvent(MachineProvisioned, names.NewMachineTag(info.Id), info.InstanceId))
+			}
+			n.machines[info.Id] = info.InstanceId
+		case *params.UnitInfo:
+			if delta.Removed {
+				delete(n.units, info.Name)
+				continue
+			}
+			last, known := n.units[info.Name]
+			n.units[info.Name] = *info
+			if !known || (last.Status == info.Status && last.StatusInfo == info.StatusInfo) {
+				continue
+			}
+			tag := names.NewUnitTag(info.Name)
+			switch {
+			case info.Status == params.StatusStarted && last.Status != params.StatusStarted:
+				events = append(events, n.newEvent(UnitStarted, tag, ""))
+			case info.Status == params.StatusError && strings.HasPrefix(info.StatusInfo, "hook failed"):
+				events = append(events, n.newEvent(HookFailed, tag, info.StatusInfo))
+			}
+		}
+	}
+	return events
+}
+
+// checkUpgrade returns an UpgradeComplete event if the environment's
+// agent version has changed, and every live machine agent now runs it.
+func (n *Notifier) checkUpgrade() (*Event, error) {
+	cfg, err := n.st.EnvironConfig()
+	if err != nil {
+		return nil, err
+	}
+	target, ok := cfg.AgentVersion()
+	if !ok || target == n.upgraded {
+		return nil, nil
+	}
+	machines, err := n.st.AllMachines()
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range machines {
+		if m.Life() != state.Alive {
+			continue
+		}
+		tools, err := m.AgentTools()
+		if errors.IsNotFound(err) {
+			// The agent has not started yet.
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if tools.Version.Number != target {
+			return nil, nil
+		}
+	}
+	n.upgraded = target
+	event := n.newEvent(UpgradeComplete, nil, target.String())
+	return &event, nil
+}
+
+// newEvent returns an event of the given kind about the given
+// entity, which may be nil for events about the whole environment.
+func (n *Notifier) newEvent(kind string, entity names.Tag, info string) Event {
+	event := Event{
+		Kind:        kind,
+		Environment: n.envUUID,
+		Info:        info,
+		Time:        time.Now().UTC(),
+	}
+	if entity != nil {
+		event.Entity = entity.String()
+	}
+	return event
+}
+
+// send posts the given events to each of the webhooks configured
+// in the environment. Failures are logged, and do not stop the
+// events being posted to the other webhooks.
+func (n *Notifier) send(events []Event) {
+	if len(events) == 0 {
+		return
+	}
+	cfg, err := n.st.EnvironConfig()
+	if err != nil {
+		logger.Errorf("cannot read the webhooks configuration: %v", err)
+		return
+	}
+	urls := cfg.WebhookURLs()
+	for _, event := range events {
+		logger.Debugf("%s event for %q", event.Kind, event.Entity)
+		body, err := json.Marshal(event)
+		if err != nil {
+			logger.Errorf("cannot encode %s event: %v", event.Kind, err)
+			continue
+		}
+		for _, url := range urls {
+			if err := n.post(url, cfg.WebhookSecret(), body); err != nil {
+				logger.Errorf("cannot post %s event to %s: %v", event.Kind, url, err)
+			}
+		}
+	}
+}
+
+// post posts the given body to the given URL, retrying according
+// to postAttempts until it is accepted or the notifier is stopped.
+func (n *Notifier) post(url, secret string, body []byte) error {
+	var err error
+	for a := postAttempts.Start(); a.Next(); {
+		if err = postEvent(url, secret, body); err == nil {
+			return nil
+		}
+		logger.Debugf("cannot post event to %s: %v", url, err)
+		select {
+		case <-n.tomb.Dying():
+			return err
+		default:
+		}
+	}
+	return err
+}
+
+// postEvent posts the body of an event to the given URL,
+// signed with the given secret if it is not empty.
+func postEvent(url, secret string, body []byte) error {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		req.Header.Set(SignatureHeader, Sign(secret, body))
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the signature of the given body, as sent in the
+// SignatureHeader, so that receivers can verify the events posted
+// to them.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,218 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package webhooks_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	stdtesting "testing"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/params"
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/version"
+	"github.com/juju/juju/worker"
+	"github.com/juju/juju/worker/webhooks"
+)
+
+func TestPackage(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type webhooksSuite struct {
+	testing.JujuConnSuite
+	server *httptest.Server
+	events chan webhooks.Event
+	status int
+}
+
+var _ = gc.Suite(&webhooksSuite{})
+
+func (s *webhooksSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.PatchValue(webhooks.UpgradeCheckInterval, 10*time.Millisecond)
+	restore := webhooks.PatchPostAttempts()
+	s.AddCleanup(func(*gc.C) { restore() })
+
+	s.events = make(chan webhooks.Event, 10)
+	s.status = http.StatusOK
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.AddCleanup(func(*gc.C) { s.server.Close() })
+	err := s.State.UpdateEnvironConfig(map[string]interface{}{
+		"webhook-urls":   s.server.URL,
+		"webhook-secret": "sekrit",
+	}, nil, nil)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *webhooksSuite) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil || req.Header.Get(webhooks.SignatureHeader) != webhooks.Sign("sekrit", body) {
+		http.Error(w, "bad signature", http.StatusForbidden)
+		return
+	}
+	if s.status != http.StatusOK {
+		http.Error(w, "unavailable", s.status)
+		return
+	}
+	var event webhooks.Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.events <- event
+}
+
+func (s *webhooksSuite) assertEvent(c *gc.C, kind, entity, info string) {
+	env, err := s.State.Environment()
+	c.Assert(err, gc.IsNil)
+	timeout := time.After(coretesting.LongWait)
+	for {
+		s.State.StartSync()
+		select {
+		case event := <-s.events:
+			c.Assert(event.Kind, gc.Equals, kind)
+			c.Assert(event.Environment, gc.Equals, env.UUID())
+			c.Assert(event.Entity, gc.Equals, entity)
+			c.Assert(event.Info, gc.Equals, info)
+			return
+		case <-time.After(coretesting.ShortWait):
+		case <-timeout:
+			c.Fatalf("timed out waiting for %s event", kind)
+		}
+	}
+}
+
+func (s *webhooksSuite) assertNoEvent(c *gc.C) {
+	s.State.StartSync()
+	select {
+	case event := <-s.events:
+		c.Fatalf("unexpected event %#v", event)
+	case <-time.After(coretesting.ShortWait):
+	}
+}
+
+// waitForNotifier waits until the notifier has seen the current
+// contents of the environment, so that later changes make events.
+func (s *webhooksSuite) waitForNotifier(c *gc.C) {
+	for i := 0; i < 10; i++ {
+		s.State.StartSync()
+		time.Sleep(coretesting.ShortWait)
+	}
+}
+
+func (s *webhooksSuite) TestSign(c *gc.C) {
+	c.Assert(webhooks.Sign("key", []byte("The quick brown fox jumps over the lazy dog")), gc.Equals,
+		"sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8")
+}
+
+func (s *webhooksSuite) TestMachineProvisioned(c *gc.C) {
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	n := webhooks.NewNotifier(s.State)
+	defer func() { c.Assert(worker.Stop(n), gc.IsNil) }()
+	s.waitForNotifier(c)
+
+	err = m.SetProvisioned(instance.Id("i-123"), "fake_nonce", nil)
+	c.Assert(err, gc.IsNil)
+	s.assertEvent(c, webhooks.MachineProvisioned, m.Tag().String(), "i-123")
+}
+
+func (s *webhooksSuite) TestUnitEvents(c *gc.C) {
+	svc := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	unit, err := svc.AddUnit()
+	c.Assert(err, gc.IsNil)
+	n := webhooks.NewNotifier(s.State)
+	defer func() { c.Assert(worker.Stop(n), gc.IsNil) }()
+	s.waitForNotifier(c)
+
+	err = unit.SetStatus(params.StatusStarted, "", nil)
+	c.Assert(err, gc.IsNil)
+	s.assertEvent(c, webhooks.UnitStarted, unit.Tag().String(), "")
+
+	// Errors other than failed hooks are not reported.
+	err = unit.SetStatus(params.StatusError, "disk full", nil)
+	c.Assert(err, gc.IsNil)
+	s.assertNoEvent(c)
+
+	err = unit.SetStatus(params.StatusError, `hook failed: "install"`, nil)
+	c.Assert(err, gc.IsNil)
+	s.assertEvent(c, webhooks.HookFailed, unit.Tag().String(), `hook failed: "install"`)
+}
+
+func (s *webhooksSuite) TestExistingEntitiesMakeNoEvents(c *gc.C) {
+	svc := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	unit, err := svc.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit.SetStatus(params.StatusStarted, "", nil)
+	c.Assert(err, gc.IsNil)
+	n := webhooks.NewNotifier(s.State)
+	defer func() { c.Assert(worker.Stop(n), gc.IsNil) }()
+	s.waitForNotifier(c)
+	s.assertNoEvent(c)
+}
+
+func (s *webhooksSuite) TestUpgradeComplete(c *gc.C) {
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	n := webhooks.NewNotifier(s.State)
+	defer func() { c.Assert(worker.Stop(n), gc.IsNil) }()
+
+	current := version.Current
+	next := current
+	next.Minor++
+	err = m.SetAgentVersion(current)
+	c.Assert(err, gc.IsNil)
+	err = s.State.SetEnvironAgentVersion(next.Number)
+	c.Assert(err, gc.IsNil)
+	s.assertNoEvent(c)
+
+	err = m.SetAgentVersion(next)
+	c.Assert(err, gc.IsNil)
+	s.assertEvent(c, webhooks.UpgradeComplete, "", next.Number.String())
+}
+
+func (s *webhooksSuite) TestFailingWebhookDoesNotStopNotifier(c *gc.C) {
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	s.status = http.StatusServiceUnavailable
+	n := webhooks.NewNotifier(s.State)
+	s.waitForNotifier(c)
+
+	err = m.SetProvisioned(instance.Id("i-123"), "fake_nonce", nil)
+	c.Assert(err, gc.IsNil)
+	s.assertNoEvent(c)
+	c.Assert(worker.Stop(n), gc.IsNil)
+}
+
+func (s *webhooksSuite) TestUnresponsiveWebhookDoesNotBlockNotifier(c *gc.C) {
+	unblock := make(chan struct{})
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-unblock
+	}))
+	defer hanging.Close()
+	defer close(unblock)
+	s.PatchValue(webhooks.HTTPClient, &http.Client{Timeout: coretesting.ShortWait})
+	err := s.State.UpdateEnvironConfig(map[string]interface{}{
+		"webhook-urls": hanging.URL + " " + s.server.URL,
+	}, nil, nil)
+	c.Assert(err, gc.IsNil)
+
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	n := webhooks.NewNotifier(s.State)
+	s.waitForNotifier(c)
+
+	err = m.SetProvisioned(instance.Id("i-123"), "fake_nonce", nil)
+	c.Assert(err, gc.IsNil)
+	s.assertEvent(c, webhooks.MachineProvisioned, m.Tag().String(), "i-123")
+	c.Assert(worker.Stop(n), gc.IsNil)
+}